@@ -1,6 +1,14 @@
 import { api } from './client'
-import type { User } from '@/types/api'
+import type { User, UserUsage } from '@/types/api'
 
 export const getMe = () => api.get('auth/me').json<User>()
 
+export const getUsage = () => api.get('auth/me/usage').json<UserUsage>()
+
 export const logout = () => api.post('auth/logout')
+
+// Starts the GitHub OAuth flow again after the stored token was rejected
+export const reconnectGitHub = async () => {
+  const { url } = await api.post('auth/reconnect').json<{ url: string }>()
+  window.location.href = url
+}
@@ -7,6 +7,11 @@ export const api = ky.create({
     afterResponse: [
       async (_request, _options, response) => {
         if (response.status === 401) {
+          // A rejected GitHub token is not a lost session; callers prompt to reconnect
+          const body = await response.clone().json().catch(() => null)
+          if (body?.code === 'GITHUB_REAUTH_REQUIRED') {
+            return
+          }
           // Only redirect if not already on login page
           if (!window.location.pathname.includes('/login')) {
             window.location.href = '/login'
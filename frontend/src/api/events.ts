@@ -39,6 +39,20 @@ export interface AgentLogData {
   timestamp: string
 }
 
+export interface AgentLogBatchData {
+  run_id: string
+  lines: Omit<AgentLogData, 'run_id'>[]
+}
+
+// Lines of a run skipped for exceeding the server's log line-rate cap; they
+// remain in the full log
+export interface AgentLogThrottledData {
+  run_id: string
+  skipped_lines: number
+  from_line: number
+  to_line: number
+}
+
 export interface AgentCompletedData {
   run_id: string
   subtask_id: string
@@ -60,12 +74,33 @@ export interface AgentFailedData {
   next_attempt_at?: string
 }
 
+export interface AgentStalledData {
+  run_id: string
+  subtask_id: string | null
+  task_id: string
+  agent_type: AgentType
+  attempt_number: number
+  last_output_at: string
+  stalled_for_ms: number
+  cancelled: boolean
+}
+
 export interface TaskStatusChangedData {
   task_id: string
   old_status: TaskStatus | ''
   new_status: TaskStatus
 }
 
+export interface TaskCompletedData {
+  task_id: string
+  title: string
+  subtask_count: number
+  merged_count: number
+  token_usage: number
+  duration_ms: number
+  completed_at: string
+}
+
 export interface SubtaskStatusChangedData {
   subtask_id: string
   task_id: string
@@ -76,12 +111,38 @@ export interface SubtaskStatusChangedData {
   pr_number?: number
 }
 
+export interface SubtaskCreatedData {
+  subtask_id: string
+  task_id: string
+  title: string
+  status: SubtaskStatus
+  blocked_reason: BlockedReason | null
+  beads_issue_id: string | null
+  created_at: string
+}
+
 export interface SubtaskUnblockedData {
   subtask_id: string
   task_id: string
   unblocked_by_id: string
 }
 
+export interface OperationFailedData {
+  operation: string
+  task_id: string
+  subtask_id?: string
+  error: string
+  failed_at: string
+}
+
+export interface ReauthRequiredData {
+  operation: string
+  task_id: string
+  subtask_id?: string
+  error: string
+  detected_at: string
+}
+
 export interface ConnectedData {
   connection_id: string
   active_runs: ActiveRun[]
@@ -97,17 +158,42 @@ export type ProjectEvent =
   | { type: 'heartbeat'; data: HeartbeatData }
   | { type: 'agent:started'; data: AgentStartedData }
   | { type: 'agent:log'; data: AgentLogData }
+  | { type: 'agent:log_batch'; data: AgentLogBatchData }
+  | { type: 'agent:log_throttled'; data: AgentLogThrottledData }
   | { type: 'agent:completed'; data: AgentCompletedData }
   | { type: 'agent:failed'; data: AgentFailedData }
+  | { type: 'agent:stalled'; data: AgentStalledData }
   | { type: 'task:status_changed'; data: TaskStatusChangedData }
+  | { type: 'task:completed'; data: TaskCompletedData }
   | { type: 'subtask:status_changed'; data: SubtaskStatusChangedData }
+  | { type: 'subtask:created'; data: SubtaskCreatedData }
   | { type: 'subtask:unblocked'; data: SubtaskUnblockedData }
+  | { type: 'operation:failed'; data: OperationFailedData }
+  | { type: 'github:reauth_required'; data: ReauthRequiredData }
+
+// Event schema version this client understands; the server wraps each
+// event's data in a { version, data } envelope of this version
+export const EVENT_SCHEMA_VERSION = 1
+
+interface EventEnvelope {
+  version: number
+  data: unknown
+}
+
+// Parse the SSE data of a versioned stream and unwrap its envelope
+export function parseEventData(raw: string): unknown {
+  const envelope = JSON.parse(raw) as EventEnvelope
+  if (envelope.version !== EVENT_SCHEMA_VERSION) {
+    throw new Error(`Unsupported event schema version: ${envelope.version}`)
+  }
+  return envelope.data
+}
 
 // Parse SSE message event into typed event
 export function parseSSEEvent(event: MessageEvent): ProjectEvent | null {
   try {
     const eventType = (event as any).type || 'message'
-    const data = JSON.parse(event.data)
+    const data = parseEventData(event.data)
 
     // Handle different event types
     switch (eventType) {
@@ -119,16 +205,30 @@ export function parseSSEEvent(event: MessageEvent): ProjectEvent | null {
         return { type: 'agent:started', data: data as AgentStartedData }
       case 'agent:log':
         return { type: 'agent:log', data: data as AgentLogData }
+      case 'agent:log_batch':
+        return { type: 'agent:log_batch', data: data as AgentLogBatchData }
+      case 'agent:log_throttled':
+        return { type: 'agent:log_throttled', data: data as AgentLogThrottledData }
       case 'agent:completed':
         return { type: 'agent:completed', data: data as AgentCompletedData }
       case 'agent:failed':
         return { type: 'agent:failed', data: data as AgentFailedData }
+      case 'agent:stalled':
+        return { type: 'agent:stalled', data: data as AgentStalledData }
       case 'task:status_changed':
         return { type: 'task:status_changed', data: data as TaskStatusChangedData }
+      case 'task:completed':
+        return { type: 'task:completed', data: data as TaskCompletedData }
       case 'subtask:status_changed':
         return { type: 'subtask:status_changed', data: data as SubtaskStatusChangedData }
+      case 'subtask:created':
+        return { type: 'subtask:created', data: data as SubtaskCreatedData }
       case 'subtask:unblocked':
         return { type: 'subtask:unblocked', data: data as SubtaskUnblockedData }
+      case 'operation:failed':
+        return { type: 'operation:failed', data: data as OperationFailedData }
+      case 'github:reauth_required':
+        return { type: 'github:reauth_required', data: data as ReauthRequiredData }
       default:
         console.warn('Unknown SSE event type:', eventType)
         return null
@@ -146,11 +246,12 @@ export function createEventSource(
 ): EventSource {
   const baseUrl = `/api/projects/${projectId}/events`
   const params = new URLSearchParams()
+  params.set('schema_version', String(EVENT_SCHEMA_VERSION))
 
   if (logSubscriptions && logSubscriptions.length > 0) {
     params.set('subscribe_logs', logSubscriptions.join(','))
   }
 
-  const url = params.toString() ? `${baseUrl}?${params}` : baseUrl
+  const url = `${baseUrl}?${params}`
   return new EventSource(url, { withCredentials: true })
 }
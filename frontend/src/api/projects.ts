@@ -1,5 +1,5 @@
 import { api } from './client'
-import type { Project, CreateProjectResponse } from '@/types/api'
+import type { AuditEntry, Project, CreateProjectResponse } from '@/types/api'
 
 export const listProjects = () => api.get('projects').json<Project[]>()
 
@@ -13,4 +13,10 @@ export const createProject = (repoUrl: string) =>
     })
     .json<CreateProjectResponse>()
 
+export const cancelCreateProject = (repoUrl: string) =>
+  api.post('projects/cancel', { json: { repo_url: repoUrl } })
+
 export const deleteProject = (id: string) => api.delete(`projects/${id}`)
+
+export const listAuditLog = (id: string, limit?: number) =>
+  api.get(`projects/${id}/audit`, { searchParams: limit ? { limit } : undefined }).json<AuditEntry[]>()
@@ -1,17 +1,31 @@
 import { api } from './client'
-import type { Subtask } from '@/types/api'
+import type { Subtask, SubtaskDiff } from '@/types/api'
 
 export const listSubtasks = (taskId: string) =>
   api.get(`tasks/${taskId}/subtasks`).json<Subtask[]>()
 
-export const startSubtask = (id: string) =>
-  api.post(`subtasks/${id}/start`).json<Subtask>()
+// expectedUpdatedAt is the updated_at of the copy the user acted on; the
+// server answers 409 with the current subtask if it has changed since.
+const expected = (expectedUpdatedAt?: string) =>
+  expectedUpdatedAt ? { json: { expected_updated_at: expectedUpdatedAt } } : undefined
 
-export const markMerged = (id: string) =>
-  api.post(`subtasks/${id}/mark-merged`).json<Subtask>()
+export const startSubtask = (id: string, expectedUpdatedAt?: string) =>
+  api.post(`subtasks/${id}/start`, expected(expectedUpdatedAt)).json<Subtask>()
 
-export const retrySubtask = (id: string) =>
-  api.post(`subtasks/${id}/retry`).json<Subtask>()
+export const getSubtaskDiff = (id: string) => api.get(`subtasks/${id}/diff`).json<SubtaskDiff>()
 
-export const updatePosition = (id: string, position: number) =>
-  api.patch(`subtasks/${id}/position`, { json: { position } }).json<Subtask>()
+export const markMerged = (id: string, expectedUpdatedAt?: string) =>
+  api.post(`subtasks/${id}/mark-merged`, expected(expectedUpdatedAt)).json<Subtask>()
+
+export const retrySubtask = (id: string, expectedUpdatedAt?: string) =>
+  api.post(`subtasks/${id}/retry`, expected(expectedUpdatedAt)).json<Subtask>()
+
+export const stopSubtask = (id: string, expectedUpdatedAt?: string) =>
+  api.post(`subtasks/${id}/stop`, expected(expectedUpdatedAt)).json<Subtask>()
+
+export const updatePosition = (id: string, position: number, expectedUpdatedAt?: string) =>
+  api
+    .patch(`subtasks/${id}/position`, {
+      json: { position, expected_updated_at: expectedUpdatedAt },
+    })
+    .json<Subtask>()
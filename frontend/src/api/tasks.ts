@@ -1,13 +1,46 @@
 import { api } from './client'
-import type { Task } from '@/types/api'
+import type { Subtask, Task, TaskTree } from '@/types/api'
 
 export const listTasks = (projectId: string) =>
   api.get(`projects/${projectId}/tasks`).json<Task[]>()
 
-export const createTask = (projectId: string, title: string, description: string) =>
-  api.post(`projects/${projectId}/tasks`, { json: { title, description } }).json<Task>()
+// Uploaded files are sent as multipart/form-data; otherwise the body is JSON
+export const createTask = (
+  projectId: string,
+  title: string,
+  description: string,
+  baseBranch?: string,
+  attachments: File[] = [],
+) => {
+  if (attachments.length === 0) {
+    return api
+      .post(`projects/${projectId}/tasks`, { json: { title, description, base_branch: baseBranch } })
+      .json<Task>()
+  }
+  const body = new FormData()
+  body.append('title', title)
+  body.append('description', description)
+  if (baseBranch) body.append('base_branch', baseBranch)
+  for (const file of attachments) body.append('attachments', file)
+  return api.post(`projects/${projectId}/tasks`, { body }).json<Task>()
+}
+
+export const getTaskTree = (taskId: string) =>
+  api.get(`tasks/${taskId}/tree`).json<TaskTree>()
 
 export const retryPlanning = (taskId: string) =>
   api.post(`tasks/${taskId}/retry-planning`).json<Task>()
 
+export const pauseTask = (taskId: string, stopWorkers = false) =>
+  api.post(`tasks/${taskId}/pause`, { json: { stop_workers: stopWorkers } }).json<Task>()
+
+export const resumeTask = (taskId: string) =>
+  api.post(`tasks/${taskId}/resume`).json<Task>()
+
+export const resyncTask = (taskId: string) =>
+  api.post(`tasks/${taskId}/resync`).json<Subtask[]>()
+
+export const replanTask = (taskId: string) =>
+  api.post(`tasks/${taskId}/replan`).json<Task>()
+
 export const deleteTask = (taskId: string) => api.delete(`tasks/${taskId}`)
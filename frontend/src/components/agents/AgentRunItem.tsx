@@ -6,6 +6,7 @@ import {
   ChevronDown,
   ChevronUp,
   Terminal,
+  Pause,
 } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
@@ -46,6 +47,16 @@ function formatTokens(tokens: number | null): string {
   return tokens.toString()
 }
 
+function formatBytes(bytes: number): string {
+  if (bytes >= 1024 * 1024) {
+    return `${(bytes / (1024 * 1024)).toFixed(1)} MB`
+  }
+  if (bytes >= 1024) {
+    return `${(bytes / 1024).toFixed(1)} KB`
+  }
+  return `${bytes} B`
+}
+
 export function AgentRunItem({ run, onViewLogs }: AgentRunItemProps) {
   const [isOpen, setIsOpen] = useState(false)
 
@@ -53,13 +64,18 @@ export function AgentRunItem({ run, onViewLogs }: AgentRunItemProps) {
     RUNNING: <Loader2 className="h-4 w-4 animate-spin text-blue-400" />,
     SUCCEEDED: <CheckCircle2 className="h-4 w-4 text-green-400" />,
     FAILED: <XCircle className="h-4 w-4 text-red-400" />,
+    INTERRUPTED: <Pause className="h-4 w-4 text-yellow-400" />,
   }[run.status]
 
+  // Older attempt logs are pruned on the server (MAX_ATTEMPT_LOGS)
+  const logAvailable = run.log_size !== undefined || run.status === 'RUNNING'
+
   const statusVariant = {
     RUNNING: 'secondary',
     SUCCEEDED: 'success',
     FAILED: 'error',
-  }[run.status] as 'secondary' | 'success' | 'error'
+    INTERRUPTED: 'warning',
+  }[run.status] as 'secondary' | 'success' | 'error' | 'warning'
 
   return (
     <Collapsible open={isOpen} onOpenChange={setIsOpen}>
@@ -102,10 +118,21 @@ export function AgentRunItem({ run, onViewLogs }: AgentRunItemProps) {
             </div>
           )}
 
-          <Button variant="outline" size="sm" onClick={onViewLogs}>
-            <Terminal className="mr-2 h-4 w-4" />
-            View Full Logs
-          </Button>
+          {logAvailable ? (
+            <Button variant="outline" size="sm" onClick={onViewLogs}>
+              <Terminal className="mr-2 h-4 w-4" />
+              View Full Logs
+              {run.log_size !== undefined && (
+                <span className="ml-2 text-xs text-muted-foreground">
+                  {formatBytes(run.log_size)}
+                </span>
+              )}
+            </Button>
+          ) : (
+            <p className="text-sm text-muted-foreground">
+              Log no longer available (older attempt logs are pruned)
+            </p>
+          )}
         </div>
       </CollapsibleContent>
     </Collapsible>
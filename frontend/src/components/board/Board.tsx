@@ -31,6 +31,8 @@ interface BoardProps {
   onRetryPlanning?: (taskId: string) => void
   retryingPlanningIds?: Set<string>
   onDeleteTask?: (taskId: string) => void
+  onPauseTask?: (taskId: string) => void
+  onResumeTask?: (taskId: string) => void
   onViewTaskDetails?: (task: Task) => void
 }
 
@@ -50,6 +52,8 @@ export function Board({
   onRetryPlanning,
   retryingPlanningIds = new Set(),
   onDeleteTask,
+  onPauseTask,
+  onResumeTask,
   onViewTaskDetails,
 }: BoardProps) {
   const [localSubtasks, setLocalSubtasks] = useState<Subtask[]>([])
@@ -76,14 +80,19 @@ export function Board({
     return map
   }, [tasks])
 
-  // Tasks that are still being planned (show TaskCards for these)
+  // Tasks that are still being planned or reviewed (show TaskCards for these)
   const planningTasks = useMemo(() => {
-    return tasks.filter((t) => t.status === 'PLANNING' || t.status === 'PLANNING_FAILED')
+    return tasks.filter(
+      (t) =>
+        t.status === 'PLANNING' ||
+        t.status === 'PLANNING_FAILED' ||
+        t.status === 'AWAITING_APPROVAL'
+    )
   }, [tasks])
 
-  // Tasks that are in progress (ACTIVE status)
+  // Tasks that are in progress (ACTIVE or PAUSED status)
   const activeTasks = useMemo(() => {
-    return tasks.filter((t) => t.status === 'ACTIVE')
+    return tasks.filter((t) => t.status === 'ACTIVE' || t.status === 'PAUSED')
   }, [tasks])
 
   // Completed tasks (DONE status)
@@ -190,6 +199,8 @@ export function Board({
                   activeRuns={activeRuns}
                   onViewLogs={onViewLogs}
                   onDelete={onDeleteTask ? () => onDeleteTask(task.id) : undefined}
+                  onPause={onPauseTask ? () => onPauseTask(task.id) : undefined}
+                  onResume={onResumeTask ? () => onResumeTask(task.id) : undefined}
                   onViewDetails={onViewTaskDetails ? () => onViewTaskDetails(task) : undefined}
                 />
               </div>
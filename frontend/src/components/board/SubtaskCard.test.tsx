@@ -36,8 +36,11 @@ const baseSubtask: Subtask = {
   pr_number: null,
   retry_count: 0,
   token_usage: 0,
+  token_budget: null,
+  priority: 2,
   position: 1,
   created_at: '2026-02-05T00:00:00Z',
+  updated_at: '2026-02-05T00:00:00Z',
 }
 
 describe('SubtaskCard', () => {
@@ -174,6 +177,26 @@ describe('SubtaskCard', () => {
     })
   })
 
+  describe('BLOCKED status with BUDGET_EXCEEDED reason', () => {
+    const overBudgetSubtask: Subtask = {
+      ...baseSubtask,
+      status: 'BLOCKED',
+      blocked_reason: 'BUDGET_EXCEEDED',
+      token_usage: 1500,
+      token_budget: 1000,
+    }
+
+    it('shows Over token budget badge', () => {
+      render(<SubtaskCard subtask={overBudgetSubtask} />)
+      expect(screen.getByText(/over token budget/i)).toBeInTheDocument()
+    })
+
+    it('shows Retry button', () => {
+      render(<SubtaskCard subtask={overBudgetSubtask} onRetry={vi.fn()} />)
+      expect(screen.getByRole('button', { name: /retry/i })).toBeInTheDocument()
+    })
+  })
+
   it('calls onClick when card is clicked', () => {
     const onClick = vi.fn()
     render(<SubtaskCard subtask={baseSubtask} onClick={onClick} />)
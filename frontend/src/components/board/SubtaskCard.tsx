@@ -53,6 +53,10 @@ const STATUS_CONFIG: Record<
     bgClass: 'border-muted bg-muted/30',
     icon: <CheckCheck className="h-4 w-4 text-muted-foreground" />,
   },
+  COMPLETED_NO_CHANGES: {
+    bgClass: 'border-muted bg-muted/30',
+    icon: <CheckCheck className="h-4 w-4 text-muted-foreground" />,
+  },
   BLOCKED: {
     bgClass: 'border-yellow-500/50 bg-yellow-500/5',
     icon: <Clock className="h-4 w-4 text-yellow-400" />,
@@ -60,11 +64,23 @@ const STATUS_CONFIG: Record<
 }
 
 function getBlockedConfig(reason: BlockedReason) {
-  if (reason === 'FAILURE') {
+  if (
+    reason === 'FAILURE' ||
+    reason === 'BUDGET_EXCEEDED' ||
+    reason === 'STOPPED' ||
+    reason === 'POLICY_VIOLATION'
+  ) {
     return {
       bgClass: 'border-red-500/50 bg-red-500/5',
       icon: <AlertCircle className="h-4 w-4 text-red-400" />,
-      label: 'Failed',
+      label:
+        reason === 'FAILURE'
+          ? 'Failed'
+          : reason === 'STOPPED'
+            ? 'Stopped'
+            : reason === 'POLICY_VIOLATION'
+              ? 'Path policy violation'
+              : 'Over token budget',
       variant: 'error' as const,
     }
   }
@@ -109,7 +125,12 @@ export function SubtaskCard({
       : STATUS_CONFIG[subtask.status]
 
   const isBlocked = subtask.status === 'BLOCKED'
-  const isFailure = isBlocked && subtask.blocked_reason === 'FAILURE'
+  const isFailure =
+    isBlocked &&
+    (subtask.blocked_reason === 'FAILURE' ||
+      subtask.blocked_reason === 'BUDGET_EXCEEDED' ||
+      subtask.blocked_reason === 'STOPPED' ||
+      subtask.blocked_reason === 'POLICY_VIOLATION')
 
   // Find active worker run for this subtask
   const workerRun = activeRuns.find(
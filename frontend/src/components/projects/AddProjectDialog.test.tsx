@@ -11,6 +11,7 @@ const mockProjectResponse: CreateProjectResponse = {
   is_fork: false,
   default_branch: 'main',
   created_at: new Date().toISOString(),
+  squash_before_pr: false,
   was_forked: false,
 }
 
@@ -56,7 +56,9 @@ const STATUS_LABELS: Record<SubtaskStatus, { label: string; variant: 'default' |
   IN_PROGRESS: { label: 'In Progress', variant: 'secondary' },
   COMPLETED: { label: 'Completed', variant: 'success' },
   MERGED: { label: 'Merged', variant: 'success' },
+  COMPLETED_NO_CHANGES: { label: 'No changes', variant: 'success' },
   BLOCKED: { label: 'Blocked', variant: 'warning' },
+  CANCELLED: { label: 'Cancelled', variant: 'secondary' },
 }
 
 export function SubtaskDetail({
@@ -90,7 +92,15 @@ export function SubtaskDetail({
 
   const statusConfig = STATUS_LABELS[subtask.status]
   const isBlocked = subtask.status === 'BLOCKED'
-  const isFailure = isBlocked && subtask.blocked_reason === 'FAILURE'
+  const isFailure =
+    isBlocked &&
+    (subtask.blocked_reason === 'FAILURE' ||
+      subtask.blocked_reason === 'BUDGET_EXCEEDED' ||
+      subtask.blocked_reason === 'STOPPED' ||
+      subtask.blocked_reason === 'POLICY_VIOLATION')
+
+  // Runs are latest attempt first; surface why the last attempt failed
+  const failureReason = isFailure ? runs?.[0]?.error_message : null
 
   // Find active worker run for this subtask
   const workerRun = activeRuns.find(
@@ -121,7 +131,13 @@ export function SubtaskDetail({
                 <Badge variant={isFailure ? 'error' : 'warning'}>
                   {subtask.blocked_reason === 'FAILURE'
                     ? 'Failed'
-                    : 'Waiting on dependency'}
+                    : subtask.blocked_reason === 'BUDGET_EXCEEDED'
+                      ? 'Over token budget'
+                      : subtask.blocked_reason === 'STOPPED'
+                        ? 'Stopped'
+                        : subtask.blocked_reason === 'POLICY_VIOLATION'
+                          ? 'Path policy violation'
+                          : 'Waiting on dependency'}
                 </Badge>
               )}
             </SheetDescription>
@@ -203,6 +219,13 @@ export function SubtaskDetail({
                 )}
               </div>
 
+              {failureReason && (
+                <div className="rounded-md border border-destructive/50 bg-destructive/10 p-3 text-sm">
+                  <span className="font-medium">Last attempt failed:</span>{' '}
+                  {failureReason}
+                </div>
+              )}
+
               {/* Branch */}
               {subtask.branch_name && (
                 <>
@@ -285,12 +308,16 @@ export function SubtaskDetail({
               {/* Stats */}
               <Separator />
               <div className="flex items-center gap-6 text-sm text-muted-foreground">
+                <div>
+                  <span className="font-medium">Priority:</span> P{subtask.priority}
+                </div>
                 <div>
                   <span className="font-medium">Retries:</span> {subtask.retry_count}
                 </div>
                 <div>
                   <span className="font-medium">Tokens:</span>{' '}
                   {subtask.token_usage.toLocaleString()}
+                  {subtask.token_budget != null && ` / ${subtask.token_budget.toLocaleString()}`}
                 </div>
               </div>
             </div>
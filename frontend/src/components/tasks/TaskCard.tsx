@@ -1,4 +1,4 @@
-import { Loader2, AlertCircle, CheckCircle2, RefreshCw, MoreVertical, Trash2, Terminal, FileText } from 'lucide-react'
+import { Loader2, AlertCircle, CheckCircle2, RefreshCw, MoreVertical, Trash2, Terminal, FileText, Pause, Play } from 'lucide-react'
 import { Badge } from '@/components/ui/badge'
 import { Button } from '@/components/ui/button'
 import {
@@ -15,6 +15,8 @@ interface TaskCardProps {
   onRetryPlanning?: () => void
   isRetrying?: boolean
   onDelete?: () => void
+  onPause?: () => void
+  onResume?: () => void
   activeRuns?: ActiveRun[]
   onViewLogs?: (runId: string, title: string, agentType: AgentType) => void
   onViewDetails?: () => void
@@ -35,15 +37,28 @@ const STATUS_CONFIG: Record<TaskStatus, {
     variant: 'error',
     icon: <AlertCircle className="h-3 w-3" />,
   },
+  AWAITING_APPROVAL: {
+    label: 'Awaiting Approval',
+    variant: 'warning',
+  },
   ACTIVE: {
     label: 'Active',
     variant: 'outline',
   },
+  PAUSED: {
+    label: 'Paused',
+    variant: 'warning',
+    icon: <Pause className="h-3 w-3" />,
+  },
   DONE: {
     label: 'Complete',
     variant: 'success',
     icon: <CheckCircle2 className="h-3 w-3" />,
   },
+  CANCELLED: {
+    label: 'Cancelled',
+    variant: 'secondary',
+  },
 }
 
 export function TaskCard({
@@ -51,6 +66,8 @@ export function TaskCard({
   onRetryPlanning,
   isRetrying,
   onDelete,
+  onPause,
+  onResume,
   activeRuns = [],
   onViewLogs,
   onViewDetails,
@@ -84,6 +101,18 @@ export function TaskCard({
               </Button>
             </DropdownMenuTrigger>
             <DropdownMenuContent align="end">
+              {task.status === 'ACTIVE' && onPause && (
+                <DropdownMenuItem onClick={onPause}>
+                  <Pause className="mr-2 h-4 w-4" />
+                  Pause
+                </DropdownMenuItem>
+              )}
+              {task.status === 'PAUSED' && onResume && (
+                <DropdownMenuItem onClick={onResume}>
+                  <Play className="mr-2 h-4 w-4" />
+                  Resume
+                </DropdownMenuItem>
+              )}
               <DropdownMenuItem
                 onClick={onDelete}
                 className="text-destructive focus:text-destructive"
@@ -120,7 +149,7 @@ export function TaskCard({
             )}
             Retry Planning
           </Button>
-        ) : (task.status === 'ACTIVE' || task.status === 'DONE') && onViewDetails ? (
+        ) : (task.status === 'ACTIVE' || task.status === 'PAUSED' || task.status === 'DONE') && onViewDetails ? (
           <Button
             variant="outline"
             size="sm"
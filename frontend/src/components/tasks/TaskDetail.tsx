@@ -29,14 +29,26 @@ const STATUS_CONFIG: Record<TaskStatus, {
     label: 'Planning Failed',
     variant: 'error',
   },
+  AWAITING_APPROVAL: {
+    label: 'Awaiting Approval',
+    variant: 'warning',
+  },
   ACTIVE: {
     label: 'Active',
     variant: 'outline',
   },
+  PAUSED: {
+    label: 'Paused',
+    variant: 'warning',
+  },
   DONE: {
     label: 'Complete',
     variant: 'success',
   },
+  CANCELLED: {
+    label: 'Cancelled',
+    variant: 'secondary',
+  },
 }
 
 export function TaskDetail({
@@ -40,7 +40,7 @@ class MockEventSource {
 
   emit(type: string, data: unknown) {
     const handlers = this.listeners.get(type) || []
-    const event = new MessageEvent(type, { data: JSON.stringify(data) })
+    const event = new MessageEvent(type, { data: JSON.stringify({ version: 1, data }) })
     handlers.forEach(h => h(event))
   }
 }
@@ -306,6 +306,40 @@ describe('ProjectEventsContext', () => {
     })
   })
 
+  it('buffers coalesced log batches', async () => {
+    const { result } = renderHook(
+      () => useProjectEvents(),
+      { wrapper: createWrapper('project-1') }
+    )
+
+    await waitFor(() => {
+      expect(MockEventSource.instances.length).toBe(1)
+    })
+
+    const eventSource = MockEventSource.instances[0]
+
+    act(() => {
+      result.current.subscribeToLogs('run-1')
+    })
+
+    act(() => {
+      eventSource.emit('agent:log_batch', {
+        run_id: 'run-1',
+        lines: [
+          { line: 'Log line 1', line_number: 1, timestamp: '14:32:05' },
+          { line: 'Log line 2', line_number: 2, timestamp: '14:32:06' },
+        ],
+      })
+    })
+
+    await waitFor(() => {
+      const logs = result.current.getLogsForRun('run-1')
+      expect(logs.length).toBe(2)
+      expect(logs[1].lineNumber).toBe(2)
+      expect(logs[1].content).toBe('Log line 2')
+    })
+  })
+
   it('closes EventSource on unmount', async () => {
     const { unmount } = renderHook(
       () => useProjectEvents(),
@@ -7,12 +7,15 @@ import React, {
   useCallback,
 } from 'react'
 import { useQueryClient } from '@tanstack/react-query'
+import { toast } from 'sonner'
 import {
   createEventSource,
+  parseEventData,
   type ActiveRun,
   type LogLine,
   type ProjectEvent,
 } from '@/api/events'
+import { reconnectGitHub } from '@/api/auth'
 import type { Task, Subtask } from '@/types/api'
 
 interface ProjectEventsContextValue {
@@ -27,6 +30,14 @@ interface ProjectEventsContextValue {
 
 const ProjectEventsContext = createContext<ProjectEventsContextValue | null>(null)
 
+// Human-readable names for operation:failed events
+const OPERATION_LABELS: Record<string, string> = {
+  close_beads_issue: 'Closing the beads issue',
+  unblock_dependents: 'Unblocking dependent subtasks',
+  check_task_completion: 'Checking task completion',
+  remove_worktree: 'Removing the worktree',
+}
+
 // Reconnection backoff delays (in ms)
 const BACKOFF_DELAYS = [1000, 2000, 4000, 8000, 16000, 30000]
 
@@ -144,6 +155,38 @@ export function ProjectEventsProvider({
           })
           break
 
+        case 'agent:log_batch':
+          // Append the coalesced lines to the buffer in one update
+          setLogBuffers((prev) => {
+            const runId = event.data.run_id
+            const existing = prev.get(runId) || []
+            const newLines: LogLine[] = event.data.lines.map((l) => ({
+              lineNumber: l.line_number,
+              content: l.line,
+              timestamp: l.timestamp,
+            }))
+            const newMap = new Map(prev)
+            newMap.set(runId, [...existing, ...newLines])
+            return newMap
+          })
+          break
+
+        case 'agent:log_throttled':
+          // Stand in for the skipped lines, which only the full log has
+          setLogBuffers((prev) => {
+            const runId = event.data.run_id
+            const existing = prev.get(runId) || []
+            const notice: LogLine = {
+              lineNumber: event.data.from_line,
+              content: `... ${event.data.skipped_lines.toLocaleString()} lines skipped (lines ${event.data.from_line}-${event.data.to_line}); download the full log to see them`,
+              timestamp: '',
+            }
+            const newMap = new Map(prev)
+            newMap.set(runId, [...existing, notice])
+            return newMap
+          })
+          break
+
         case 'agent:completed':
           // Remove from active runs
           setActiveRuns((prev) => prev.filter((r) => r.id !== event.data.run_id))
@@ -162,6 +205,15 @@ export function ProjectEventsProvider({
           })
           break
 
+        case 'agent:stalled':
+          // The run's log has gone quiet; a cancelled attempt is followed by agent:failed
+          toast.warning('Agent stalled', {
+            description: event.data.cancelled
+              ? 'No output for a while; the attempt was stopped.'
+              : 'No output for a while; it may be stuck.',
+          })
+          break
+
         case 'task:status_changed':
           // Update task in cache
           queryClient.setQueriesData<Task[]>(
@@ -194,6 +246,13 @@ export function ProjectEventsProvider({
           )
           break
 
+        case 'subtask:created':
+          // A re-plan added a subtask; refetch the task's subtasks
+          queryClient.invalidateQueries({
+            queryKey: ['subtasks', event.data.task_id],
+          })
+          break
+
         case 'subtask:unblocked':
           // Update subtask in cache
           queryClient.setQueriesData<Subtask[]>(
@@ -206,6 +265,25 @@ export function ProjectEventsProvider({
               )
           )
           break
+
+        case 'operation:failed':
+          // Best-effort follow-up step failed; the action itself succeeded
+          toast.error(`${OPERATION_LABELS[event.data.operation] ?? event.data.operation} failed`, {
+            description: event.data.error,
+          })
+          break
+
+        case 'github:reauth_required':
+          // GitHub rejected the stored token; the subtask was blocked until the user reconnects
+          queryClient.invalidateQueries({
+            queryKey: ['subtasks', event.data.task_id],
+          })
+          toast.error('GitHub access was revoked or expired', {
+            description: 'Reconnect GitHub, then retry the blocked subtask.',
+            duration: Infinity,
+            action: { label: 'Reconnect', onClick: () => void reconnectGitHub() },
+          })
+          break
       }
     },
     [projectId, queryClient]
@@ -228,17 +306,24 @@ export function ProjectEventsProvider({
       'heartbeat',
       'agent:started',
       'agent:log',
+      'agent:log_batch',
+      'agent:log_throttled',
       'agent:completed',
       'agent:failed',
+      'agent:stalled',
       'task:status_changed',
+      'task:completed',
       'subtask:status_changed',
+      'subtask:created',
       'subtask:unblocked',
+      'operation:failed',
+      'github:reauth_required',
     ]
 
     eventTypes.forEach((type) => {
       eventSource.addEventListener(type, (event: MessageEvent) => {
         try {
-          const data = JSON.parse(event.data)
+          const data = parseEventData(event.data)
           handleEvent({ type, data } as ProjectEvent)
         } catch (err) {
           console.error('Failed to parse event:', err)
@@ -246,6 +331,13 @@ export function ProjectEventsProvider({
       })
     })
 
+    // The server is about to close the stream (connection timeout); reopen it right away
+    eventSource.addEventListener('reconnect', () => {
+      eventSource.close()
+      reconnectAttemptRef.current = 0
+      connect()
+    })
+
     eventSource.onopen = () => {
       setIsConnected(true)
       setConnectionError(null)
@@ -100,8 +100,15 @@ export function useUpdatePosition() {
   const queryClient = useQueryClient()
 
   return useMutation({
-    mutationFn: ({ id, position }: { id: string; position: number }) =>
-      updatePosition(id, position),
+    mutationFn: ({
+      id,
+      position,
+      expectedUpdatedAt,
+    }: {
+      id: string
+      position: number
+      expectedUpdatedAt?: string
+    }) => updatePosition(id, position, expectedUpdatedAt),
     onSuccess: (updatedSubtask) => {
       queryClient.setQueryData<Subtask[]>(
         ['subtasks', updatedSubtask.task_id],
@@ -111,5 +118,9 @@ export function useUpdatePosition() {
             : [updatedSubtask]
       )
     },
+    onError: () => {
+      // A 409 means another change won; refetch so the board shows it
+      queryClient.invalidateQueries({ queryKey: ['subtasks'] })
+    },
   })
 }
@@ -1,5 +1,12 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
-import { listTasks, createTask, retryPlanning, deleteTask } from '@/api/tasks'
+import {
+  listTasks,
+  createTask,
+  retryPlanning,
+  pauseTask,
+  resumeTask,
+  deleteTask,
+} from '@/api/tasks'
 import { POLLING_INTERVAL } from '@/lib/constants'
 import type { Task } from '@/types/api'
 
@@ -61,6 +68,35 @@ export function useRetryPlanning() {
   })
 }
 
+export function usePauseTask() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: ({ taskId, stopWorkers }: { taskId: string; stopWorkers?: boolean }) =>
+      pauseTask(taskId, stopWorkers),
+    onSuccess: (updatedTask) => {
+      queryClient.setQueryData<Task[]>(['tasks', updatedTask.project_id], (old) =>
+        old ? old.map((t) => (t.id === updatedTask.id ? updatedTask : t)) : [updatedTask]
+      )
+      // Stopped Workers leave their subtasks BLOCKED
+      queryClient.invalidateQueries({ queryKey: ['subtasks', updatedTask.id] })
+    },
+  })
+}
+
+export function useResumeTask() {
+  const queryClient = useQueryClient()
+
+  return useMutation({
+    mutationFn: (taskId: string) => resumeTask(taskId),
+    onSuccess: (updatedTask) => {
+      queryClient.setQueryData<Task[]>(['tasks', updatedTask.project_id], (old) =>
+        old ? old.map((t) => (t.id === updatedTask.id ? updatedTask : t)) : [updatedTask]
+      )
+    },
+  })
+}
+
 export function useDeleteTask(projectId: string) {
   const queryClient = useQueryClient()
 
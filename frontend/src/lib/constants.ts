@@ -16,6 +16,6 @@ export const COLUMN_STATUS_MAP: Record<SubtaskColumn, string[]> = {
   Ready: ['READY'],
   'In Progress': ['IN_PROGRESS'],
   Completed: ['COMPLETED'],
-  Merged: ['MERGED'],
+  Merged: ['MERGED', 'COMPLETED_NO_CHANGES'],
   Blocked: ['BLOCKED'],
 }
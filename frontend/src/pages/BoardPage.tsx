@@ -15,7 +15,14 @@ import { LiveLogPanel } from '@/components/agents/LiveLogPanel'
 import { ProjectEventsProvider, useProjectEvents } from '@/contexts/ProjectEventsContext'
 import { useAuth } from '@/hooks/useAuth'
 import { useProjects, useProject } from '@/hooks/useProjects'
-import { useTasks, useCreateTask, useDeleteTask, useRetryPlanning } from '@/hooks/useTasks'
+import {
+  useTasks,
+  useCreateTask,
+  useDeleteTask,
+  useRetryPlanning,
+  usePauseTask,
+  useResumeTask,
+} from '@/hooks/useTasks'
 import {
   useSubtasks,
   useStartSubtask,
@@ -42,6 +49,8 @@ function BoardPageContent({ projectId }: { projectId: string }) {
   const createTask = useCreateTask(projectId)
   const deleteTask = useDeleteTask(projectId)
   const retryPlanning = useRetryPlanning()
+  const pauseTask = usePauseTask()
+  const resumeTask = useResumeTask()
   const startSubtask = useStartSubtask()
   const markMerged = useMarkMerged()
   const retrySubtask = useRetrySubtask()
@@ -141,12 +150,31 @@ function BoardPageContent({ projectId }: { projectId: string }) {
 
   const handlePositionUpdate = async (subtaskId: string, position: number) => {
     try {
-      await updatePosition.mutateAsync({ id: subtaskId, position })
+      const expectedUpdatedAt = allSubtasks.find((s) => s.id === subtaskId)?.updated_at
+      await updatePosition.mutateAsync({ id: subtaskId, position, expectedUpdatedAt })
     } catch {
       toast.error('Failed to update position')
     }
   }
 
+  const handlePauseTask = async (taskId: string) => {
+    try {
+      await pauseTask.mutateAsync({ taskId })
+      toast.success('Task paused - running workers will finish')
+    } catch {
+      toast.error('Failed to pause task')
+    }
+  }
+
+  const handleResumeTask = async (taskId: string) => {
+    try {
+      await resumeTask.mutateAsync(taskId)
+      toast.success('Task resumed')
+    } catch {
+      toast.error('Failed to resume task')
+    }
+  }
+
   const handleRetryPlanning = async (taskId: string) => {
     setRetryingPlanningIds((prev) => new Set(prev).add(taskId))
     try {
@@ -287,6 +315,8 @@ function BoardPageContent({ projectId }: { projectId: string }) {
               onRetryPlanning={handleRetryPlanning}
               retryingPlanningIds={retryingPlanningIds}
               onDeleteTask={(taskId) => openDeleteDialog(tasks.find(t => t.id === taskId)!)}
+              onPauseTask={handlePauseTask}
+              onResumeTask={handleResumeTask}
               onViewTaskDetails={handleViewTaskDetails}
             />
           )}
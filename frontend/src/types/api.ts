@@ -4,6 +4,17 @@ export interface User {
   created_at: string
 }
 
+export interface QuotaUsage {
+  used: number
+  limit: number // 0 = unlimited
+}
+
+export interface UserUsage {
+  projects: QuotaUsage
+  active_tasks: QuotaUsage
+  concurrent_agents: QuotaUsage
+}
+
 export interface Project {
   id: string
   github_owner: string
@@ -11,13 +22,49 @@ export interface Project {
   is_fork: boolean
   default_branch: string
   created_at: string
+  max_subtasks_per_task?: number
+  pr_title_template?: string
+  squash_before_pr: boolean
+  path_policy?: PathPolicy // omitted when Workers may modify every path
+  summary?: ProjectSummary // only with ?include=summary
+}
+
+// Globs limiting the repository paths a project's Workers may modify
+export interface PathPolicy {
+  allowed: string[] | null
+  denied: string[] | null
+}
+
+export interface ProjectSummary {
+  tasks: Partial<Record<TaskStatus, number>>
+  subtasks: Partial<Record<SubtaskStatus, number>>
+  active_agents: number
+  token_usage: number
+}
+
+export interface AuditEntry {
+  id: string
+  user_id: string
+  action: string // e.g. 'subtask.retry'
+  entity_type: 'task' | 'subtask'
+  entity_id: string
+  old_status?: string // omitted when the action created the entity
+  new_status?: string // omitted when the action deleted the entity
+  created_at: string
 }
 
 export interface CreateProjectResponse extends Project {
   was_forked: boolean
 }
 
-export type TaskStatus = 'PLANNING' | 'PLANNING_FAILED' | 'ACTIVE' | 'DONE'
+export type TaskStatus =
+  | 'PLANNING'
+  | 'PLANNING_FAILED'
+  | 'AWAITING_APPROVAL'
+  | 'ACTIVE'
+  | 'PAUSED'
+  | 'DONE'
+  | 'CANCELLED'
 
 export interface Task {
   id: string
@@ -25,6 +72,7 @@ export interface Task {
   title: string
   description: string
   status: TaskStatus
+  base_branch: string
   created_at: string
 }
 
@@ -35,8 +83,10 @@ export type SubtaskStatus =
   | 'IN_PROGRESS'
   | 'COMPLETED'
   | 'MERGED'
+  | 'COMPLETED_NO_CHANGES'
+  | 'CANCELLED'
 
-export type BlockedReason = 'DEPENDENCY' | 'FAILURE' | null
+export type BlockedReason = 'DEPENDENCY' | 'FAILURE' | 'BUDGET_EXCEEDED' | 'STOPPED' | 'POLICY_VIOLATION' | null
 
 export interface Subtask {
   id: string
@@ -51,23 +101,71 @@ export interface Subtask {
   pr_number: number | null
   retry_count: number
   token_usage: number
+  token_budget: number | null // null means unlimited
+  priority: number // 0 (most urgent) to 4
   position: number
   created_at: string
+  updated_at: string
+  next_attempt_at?: string // only while a Worker backs off between attempts
+  runs?: AgentRun[] // only with ?include=runs
+  blocked_by?: BlockingDependency[] // only when BLOCKED by DEPENDENCY
+}
+
+export interface BlockingDependency {
+  id: string
+  title: string
+  status: SubtaskStatus
+}
+
+// A subtask branch's changes against its task's base branch, from
+// GET /api/subtasks/{id}/diff
+export interface SubtaskDiff {
+  subtask_id: string
+  base: string
+  branch: string
+  source: 'worktree' | 'github' // github once the worktree is removed
+  diff: string // unified diff, cut at a line boundary when truncated
+  size: number // bytes in the full diff
+  truncated: boolean
+  note?: string // only when truncated
+}
+
+// A task with everything its board renders, from GET /api/tasks/{id}/tree
+export interface TaskTree {
+  task: Task
+  subtasks: Subtask[]
+  dependencies: { subtask_id: string; depends_on_id: string }[]
+  token_usage: number // planner_token_usage plus every subtask's token_usage
+  planner_token_usage: number
+  archive?: TaskArchive // once the task's runs and logs are archived
+}
+
+// Summary of an archived task; the archive is at GET /api/tasks/{id}/archive
+export interface TaskArchive {
+  run_count: number
+  planner_token_usage: number
+  size_bytes: number
+  archived_at: string
 }
 
 export type AgentType = 'PLANNER' | 'WORKER'
-export type AgentRunStatus = 'RUNNING' | 'SUCCEEDED' | 'FAILED'
+// INTERRUPTED: stopped by an orchestrator shutdown and resumed on restart
+export type AgentRunStatus = 'RUNNING' | 'SUCCEEDED' | 'FAILED' | 'INTERRUPTED'
 
 export interface AgentRun {
   id: string
   subtask_id: string
+  task_id?: string
   agent_type: AgentType
   attempt_number: number
   status: AgentRunStatus
   started_at: string
   ended_at: string | null
+  duration_ms?: number
   token_usage: number | null
   error_message: string | null
+  log_path: string
+  log_size?: number // bytes; omitted once the attempt log is pruned
 }
 
 export interface ApiError {
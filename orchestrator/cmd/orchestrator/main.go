@@ -37,13 +37,22 @@ func main() {
 	}
 	zerolog.SetGlobalLevel(level)
 
+	// Fail fast on an unusable data volume rather than on the first clone
+	if err := cfg.DataPaths().Validate(cfg.DataDirMinFreeMB); err != nil {
+		log.Fatal().Err(err).Msg("invalid data directory")
+	}
+
 	log.Info().
 		Str("log_level", cfg.LogLevel).
 		Int("port", cfg.Port).
 		Msg("starting orchestrator")
 
-	// Initialize crypto for token encryption
-	crypto, err := repository.NewCrypto([]byte(cfg.EncryptionKey))
+	// Initialize crypto for token encryption (old keys allow decryption during key rotation)
+	oldKeys := make([][]byte, len(cfg.EncryptionKeysOld))
+	for i, key := range cfg.EncryptionKeysOld {
+		oldKeys[i] = []byte(key)
+	}
+	crypto, err := repository.NewCryptoWithOldKeys([]byte(cfg.EncryptionKey), oldKeys)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize crypto")
 	}
@@ -59,6 +68,33 @@ func main() {
 	// Create repository
 	repo := db.Repository()
 
+	// Re-encrypt tokens under the current key so old keys can eventually be retired
+	if len(oldKeys) > 0 {
+		result, err := repo.ReEncryptAllTokens(ctx, crypto)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to re-encrypt user tokens")
+		} else {
+			log.Info().
+				Str("primary_key_id", crypto.PrimaryKeyID()).
+				Int("total", result.Total).
+				Int("re_encrypted", result.ReEncrypted).
+				Int("failed", result.Failed).
+				Msg("re-encrypted user tokens")
+		}
+
+		result, err = repo.ReEncryptWebhookSecrets(ctx, crypto)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to re-encrypt webhook secrets")
+		} else {
+			log.Info().
+				Str("primary_key_id", crypto.PrimaryKeyID()).
+				Int("total", result.Total).
+				Int("re_encrypted", result.ReEncrypted).
+				Int("failed", result.Failed).
+				Msg("re-encrypted webhook secrets")
+		}
+	}
+
 	// Create and start HTTP server
 	server, err := api.NewServer(cfg, db, repo, crypto)
 	if err != nil {
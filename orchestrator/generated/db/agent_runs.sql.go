@@ -13,6 +13,23 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countActiveAgentRunsByProject = `-- name: CountActiveAgentRunsByProject :one
+SELECT COUNT(*) AS count
+FROM agent_runs ar
+LEFT JOIN subtasks s ON ar.subtask_id = s.id
+JOIN tasks t ON t.id = COALESCE(ar.task_id, s.task_id)
+WHERE t.project_id = $1
+AND ar.status = 'RUNNING'
+`
+
+// Running Planner and Worker runs for the project summary
+func (q *Queries) CountActiveAgentRunsByProject(ctx context.Context, projectID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countActiveAgentRunsByProject, projectID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countAgentRunsForSubtask = `-- name: CountAgentRunsForSubtask :one
 SELECT COUNT(*) AS count
 FROM agent_runs
@@ -146,6 +163,60 @@ func (q *Queries) CreateAgentRunForTask(ctx context.Context, arg CreateAgentRunF
 	return i, err
 }
 
+const createAgentRunTokenUsage = `-- name: CreateAgentRunTokenUsage :one
+INSERT INTO agent_run_token_usage (
+    agent_run_id,
+    input_tokens,
+    output_tokens,
+    total_tokens,
+    recorded_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, agent_run_id, input_tokens, output_tokens, total_tokens, recorded_at
+`
+
+type CreateAgentRunTokenUsageParams struct {
+	AgentRunID   uuid.UUID `json:"agent_run_id"`
+	InputTokens  int32     `json:"input_tokens"`
+	OutputTokens int32     `json:"output_tokens"`
+	TotalTokens  int32     `json:"total_tokens"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// Records one token usage report of a run
+func (q *Queries) CreateAgentRunTokenUsage(ctx context.Context, arg CreateAgentRunTokenUsageParams) (AgentRunTokenUsage, error) {
+	row := q.db.QueryRow(ctx, createAgentRunTokenUsage,
+		arg.AgentRunID,
+		arg.InputTokens,
+		arg.OutputTokens,
+		arg.TotalTokens,
+		arg.RecordedAt,
+	)
+	var i AgentRunTokenUsage
+	err := row.Scan(
+		&i.ID,
+		&i.AgentRunID,
+		&i.InputTokens,
+		&i.OutputTokens,
+		&i.TotalTokens,
+		&i.RecordedAt,
+	)
+	return i, err
+}
+
+const deleteAgentRunsForTask = `-- name: DeleteAgentRunsForTask :exec
+DELETE FROM agent_runs
+WHERE task_id = $1::uuid
+OR subtask_id IN (SELECT id FROM subtasks WHERE subtasks.task_id = $1::uuid)
+`
+
+// Deletes the task's Planner runs and the Worker runs of all its subtasks once they are archived
+func (q *Queries) DeleteAgentRunsForTask(ctx context.Context, taskID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteAgentRunsForTask, taskID)
+	return err
+}
+
 const getAgentRunByID = `-- name: GetAgentRunByID :one
 SELECT id, subtask_id, agent_type, attempt_number, status, started_at, ended_at, token_usage, error_message, log_path, prompt_text, created_at, task_id FROM agent_runs
 WHERE id = $1 LIMIT 1
@@ -269,6 +340,49 @@ func (q *Queries) GetRunningAgentRuns(ctx context.Context) ([]AgentRun, error) {
 	return items, nil
 }
 
+const getSubtaskRunStats = `-- name: GetSubtaskRunStats :one
+SELECT
+    COUNT(*)::int AS attempt_count,
+    COUNT(*) FILTER (WHERE status = 'SUCCEEDED')::int AS succeeded_count,
+    COUNT(*) FILTER (WHERE status = 'FAILED')::int AS failed_count,
+    COUNT(*) FILTER (WHERE status = 'RUNNING')::int AS running_count,
+    COUNT(*) FILTER (WHERE status = 'INTERRUPTED')::int AS interrupted_count,
+    COALESCE(SUM(EXTRACT(EPOCH FROM ended_at - started_at) * 1000) FILTER (WHERE ended_at IS NOT NULL), 0)::bigint AS total_duration_ms,
+    COALESCE(AVG(EXTRACT(EPOCH FROM ended_at - started_at) * 1000) FILTER (WHERE ended_at IS NOT NULL), 0)::bigint AS average_duration_ms,
+    MAX(started_at)::timestamptz AS last_attempt_at
+FROM agent_runs
+WHERE subtask_id = $1
+`
+
+type GetSubtaskRunStatsRow struct {
+	AttemptCount      int32              `json:"attempt_count"`
+	SucceededCount    int32              `json:"succeeded_count"`
+	FailedCount       int32              `json:"failed_count"`
+	RunningCount      int32              `json:"running_count"`
+	InterruptedCount  int32              `json:"interrupted_count"`
+	TotalDurationMs   int64              `json:"total_duration_ms"`
+	AverageDurationMs int64              `json:"average_duration_ms"`
+	LastAttemptAt     pgtype.Timestamptz `json:"last_attempt_at"`
+}
+
+// Aggregates of a subtask's Worker runs; durations are in milliseconds and
+// count finished runs only
+func (q *Queries) GetSubtaskRunStats(ctx context.Context, subtaskID pgtype.UUID) (GetSubtaskRunStatsRow, error) {
+	row := q.db.QueryRow(ctx, getSubtaskRunStats, subtaskID)
+	var i GetSubtaskRunStatsRow
+	err := row.Scan(
+		&i.AttemptCount,
+		&i.SucceededCount,
+		&i.FailedCount,
+		&i.RunningCount,
+		&i.InterruptedCount,
+		&i.TotalDurationMs,
+		&i.AverageDurationMs,
+		&i.LastAttemptAt,
+	)
+	return i, err
+}
+
 const listActiveAgentRunsByProject = `-- name: ListActiveAgentRunsByProject :many
 SELECT
     ar.id,
@@ -408,6 +522,288 @@ func (q *Queries) ListAgentRunsByTask(ctx context.Context, taskID pgtype.UUID) (
 	return items, nil
 }
 
+const listAllAgentRunsForTask = `-- name: ListAllAgentRunsForTask :many
+SELECT id, subtask_id, agent_type, attempt_number, status, started_at, ended_at, token_usage, error_message, log_path, prompt_text, created_at, task_id FROM agent_runs
+WHERE (task_id = $1::uuid
+    OR subtask_id IN (SELECT id FROM subtasks WHERE subtasks.task_id = $1::uuid))
+AND ($2::text IS NULL OR status = $2::text)
+AND ($3::text IS NULL OR agent_type = $3::text)
+ORDER BY started_at ASC
+`
+
+type ListAllAgentRunsForTaskParams struct {
+	TaskID    uuid.UUID `json:"task_id"`
+	Status    *string   `json:"status"`
+	AgentType *string   `json:"agent_type"`
+}
+
+// Returns the task's Planner runs and the Worker runs of all its subtasks, oldest first
+func (q *Queries) ListAllAgentRunsForTask(ctx context.Context, arg ListAllAgentRunsForTaskParams) ([]AgentRun, error) {
+	rows, err := q.db.Query(ctx, listAllAgentRunsForTask, arg.TaskID, arg.Status, arg.AgentType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AgentRun{}
+	for rows.Next() {
+		var i AgentRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubtaskID,
+			&i.AgentType,
+			&i.AttemptNumber,
+			&i.Status,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.TokenUsage,
+			&i.ErrorMessage,
+			&i.LogPath,
+			&i.PromptText,
+			&i.CreatedAt,
+			&i.TaskID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInterruptedWorkerRuns = `-- name: ListInterruptedWorkerRuns :many
+SELECT ar.id, ar.subtask_id, ar.agent_type, ar.attempt_number, ar.status, ar.started_at, ar.ended_at, ar.token_usage, ar.error_message, ar.log_path, ar.prompt_text, ar.created_at, ar.task_id FROM agent_runs ar
+JOIN subtasks s ON s.id = ar.subtask_id
+WHERE ar.status = 'INTERRUPTED'
+AND s.status = 'IN_PROGRESS'
+AND NOT EXISTS (
+    SELECT 1 FROM agent_runs later
+    WHERE later.subtask_id = ar.subtask_id
+    AND later.created_at > ar.created_at
+)
+ORDER BY ar.started_at ASC
+`
+
+// Worker runs interrupted by a shutdown that are still their IN_PROGRESS
+// subtask's latest run, for resuming on startup
+func (q *Queries) ListInterruptedWorkerRuns(ctx context.Context) ([]AgentRun, error) {
+	rows, err := q.db.Query(ctx, listInterruptedWorkerRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AgentRun{}
+	for rows.Next() {
+		var i AgentRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubtaskID,
+			&i.AgentType,
+			&i.AttemptNumber,
+			&i.Status,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.TokenUsage,
+			&i.ErrorMessage,
+			&i.LogPath,
+			&i.PromptText,
+			&i.CreatedAt,
+			&i.TaskID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentAgentRunsBySubtask = `-- name: ListRecentAgentRunsBySubtask :many
+SELECT id, subtask_id, agent_type, attempt_number, status, started_at, ended_at, token_usage, error_message, log_path, prompt_text, created_at, task_id FROM agent_runs
+WHERE subtask_id = $1
+ORDER BY attempt_number DESC
+LIMIT $2
+`
+
+type ListRecentAgentRunsBySubtaskParams struct {
+	SubtaskID pgtype.UUID `json:"subtask_id"`
+	Limit     int32       `json:"limit"`
+}
+
+// Latest attempts first, for embedding run history in subtask responses
+func (q *Queries) ListRecentAgentRunsBySubtask(ctx context.Context, arg ListRecentAgentRunsBySubtaskParams) ([]AgentRun, error) {
+	rows, err := q.db.Query(ctx, listRecentAgentRunsBySubtask, arg.SubtaskID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AgentRun{}
+	for rows.Next() {
+		var i AgentRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubtaskID,
+			&i.AgentType,
+			&i.AttemptNumber,
+			&i.Status,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.TokenUsage,
+			&i.ErrorMessage,
+			&i.LogPath,
+			&i.PromptText,
+			&i.CreatedAt,
+			&i.TaskID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRunningAgentRunsWithOwners = `-- name: ListRunningAgentRunsWithOwners :many
+SELECT
+    ar.id,
+    ar.subtask_id,
+    t.id AS task_id,
+    p.id AS project_id,
+    p.github_owner,
+    p.github_repo,
+    u.id AS user_id,
+    u.github_username,
+    ar.agent_type,
+    ar.attempt_number,
+    ar.started_at,
+    COALESCE(s.token_usage, 0)::int AS subtask_token_usage
+FROM agent_runs ar
+LEFT JOIN subtasks s ON ar.subtask_id = s.id
+JOIN tasks t ON t.id = COALESCE(ar.task_id, s.task_id)
+JOIN projects p ON p.id = t.project_id
+JOIN users u ON u.id = p.user_id
+WHERE ar.status = 'RUNNING'
+ORDER BY ar.started_at ASC
+`
+
+type ListRunningAgentRunsWithOwnersRow struct {
+	ID                uuid.UUID   `json:"id"`
+	SubtaskID         pgtype.UUID `json:"subtask_id"`
+	TaskID            uuid.UUID   `json:"task_id"`
+	ProjectID         uuid.UUID   `json:"project_id"`
+	GithubOwner       string      `json:"github_owner"`
+	GithubRepo        string      `json:"github_repo"`
+	UserID            uuid.UUID   `json:"user_id"`
+	GithubUsername    string      `json:"github_username"`
+	AgentType         string      `json:"agent_type"`
+	AttemptNumber     int32       `json:"attempt_number"`
+	StartedAt         time.Time   `json:"started_at"`
+	SubtaskTokenUsage int32       `json:"subtask_token_usage"`
+}
+
+// Returns every RUNNING Planner and Worker run across all projects with its
+// project and owner, oldest first. subtask_token_usage is what the run's
+// subtask has used in earlier attempts (0 for Planner runs).
+func (q *Queries) ListRunningAgentRunsWithOwners(ctx context.Context) ([]ListRunningAgentRunsWithOwnersRow, error) {
+	rows, err := q.db.Query(ctx, listRunningAgentRunsWithOwners)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRunningAgentRunsWithOwnersRow{}
+	for rows.Next() {
+		var i ListRunningAgentRunsWithOwnersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubtaskID,
+			&i.TaskID,
+			&i.ProjectID,
+			&i.GithubOwner,
+			&i.GithubRepo,
+			&i.UserID,
+			&i.GithubUsername,
+			&i.AgentType,
+			&i.AttemptNumber,
+			&i.StartedAt,
+			&i.SubtaskTokenUsage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTokenUsageForTask = `-- name: ListTokenUsageForTask :many
+SELECT
+    u.id,
+    u.agent_run_id,
+    ar.subtask_id,
+    ar.agent_type,
+    ar.attempt_number,
+    u.input_tokens,
+    u.output_tokens,
+    u.total_tokens,
+    u.recorded_at
+FROM agent_run_token_usage u
+JOIN agent_runs ar ON ar.id = u.agent_run_id
+WHERE ar.task_id = $1::uuid
+OR ar.subtask_id IN (SELECT id FROM subtasks WHERE subtasks.task_id = $1::uuid)
+ORDER BY u.recorded_at ASC, u.id ASC
+`
+
+type ListTokenUsageForTaskRow struct {
+	ID            uuid.UUID   `json:"id"`
+	AgentRunID    uuid.UUID   `json:"agent_run_id"`
+	SubtaskID     pgtype.UUID `json:"subtask_id"`
+	AgentType     string      `json:"agent_type"`
+	AttemptNumber int32       `json:"attempt_number"`
+	InputTokens   int32       `json:"input_tokens"`
+	OutputTokens  int32       `json:"output_tokens"`
+	TotalTokens   int32       `json:"total_tokens"`
+	RecordedAt    time.Time   `json:"recorded_at"`
+}
+
+// Returns the token usage reports of the task's Planner runs and the Worker
+// runs of all its subtasks with the run they belong to, oldest first
+func (q *Queries) ListTokenUsageForTask(ctx context.Context, taskID uuid.UUID) ([]ListTokenUsageForTaskRow, error) {
+	rows, err := q.db.Query(ctx, listTokenUsageForTask, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTokenUsageForTaskRow{}
+	for rows.Next() {
+		var i ListTokenUsageForTaskRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AgentRunID,
+			&i.SubtaskID,
+			&i.AgentType,
+			&i.AttemptNumber,
+			&i.InputTokens,
+			&i.OutputTokens,
+			&i.TotalTokens,
+			&i.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markStaleAgentRunsFailed = `-- name: MarkStaleAgentRunsFailed :exec
 UPDATE agent_runs
 SET status = 'FAILED',
@@ -422,6 +818,20 @@ func (q *Queries) MarkStaleAgentRunsFailed(ctx context.Context, startedAt time.T
 	return err
 }
 
+const sumPlannerTokenUsageForTask = `-- name: SumPlannerTokenUsageForTask :one
+SELECT COALESCE(SUM(token_usage), 0)::int AS token_usage
+FROM agent_runs
+WHERE task_id = $1
+`
+
+// Tokens used by a task's Planner runs; Worker tokens are summed per subtask
+func (q *Queries) SumPlannerTokenUsageForTask(ctx context.Context, taskID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, sumPlannerTokenUsageForTask, taskID)
+	var token_usage int32
+	err := row.Scan(&token_usage)
+	return token_usage, err
+}
+
 const updateAgentRunStatus = `-- name: UpdateAgentRunStatus :one
 UPDATE agent_runs
 SET status = $2,
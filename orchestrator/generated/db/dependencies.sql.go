@@ -16,7 +16,7 @@ const countUnmergedDependencies = `-- name: CountUnmergedDependencies :one
 SELECT COUNT(*) AS count
 FROM subtask_dependencies sd
 JOIN subtasks s ON sd.depends_on_id = s.id
-WHERE sd.subtask_id = $1 AND s.status != 'MERGED'
+WHERE sd.subtask_id = $1 AND s.status NOT IN ('MERGED', 'COMPLETED_NO_CHANGES', 'CANCELLED')
 `
 
 func (q *Queries) CountUnmergedDependencies(ctx context.Context, subtaskID uuid.UUID) (int64, error) {
@@ -187,7 +187,7 @@ const hasBlockingDependencies = `-- name: HasBlockingDependencies :one
 SELECT EXISTS(
     SELECT 1 FROM subtask_dependencies sd
     JOIN subtasks s ON sd.depends_on_id = s.id
-    WHERE sd.subtask_id = $1 AND s.status != 'MERGED'
+    WHERE sd.subtask_id = $1 AND s.status NOT IN ('MERGED', 'COMPLETED_NO_CHANGES', 'CANCELLED')
 ) AS has_blocking
 `
 
@@ -197,3 +197,72 @@ func (q *Queries) HasBlockingDependencies(ctx context.Context, subtaskID uuid.UU
 	err := row.Scan(&has_blocking)
 	return has_blocking, err
 }
+
+const listBlockingDependencies = `-- name: ListBlockingDependencies :many
+SELECT s.id, s.title, s.status
+FROM subtask_dependencies sd
+JOIN subtasks s ON sd.depends_on_id = s.id
+WHERE sd.subtask_id = $1 AND s.status NOT IN ('MERGED', 'COMPLETED_NO_CHANGES', 'CANCELLED')
+ORDER BY s.position ASC, s.created_at ASC
+`
+
+type ListBlockingDependenciesRow struct {
+	ID     uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	Status string    `json:"status"`
+}
+
+// Dependencies still blocking a subtask: those not MERGED, COMPLETED_NO_CHANGES or CANCELLED
+func (q *Queries) ListBlockingDependencies(ctx context.Context, subtaskID uuid.UUID) ([]ListBlockingDependenciesRow, error) {
+	rows, err := q.db.Query(ctx, listBlockingDependencies, subtaskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBlockingDependenciesRow{}
+	for rows.Next() {
+		var i ListBlockingDependenciesRow
+		if err := rows.Scan(&i.ID, &i.Title, &i.Status); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDependenciesForTask = `-- name: ListDependenciesForTask :many
+SELECT sd.id, sd.subtask_id, sd.depends_on_id, sd.created_at
+FROM subtask_dependencies sd
+JOIN subtasks s ON sd.subtask_id = s.id
+WHERE s.task_id = $1
+ORDER BY sd.created_at ASC
+`
+
+// Every dependency edge between the subtasks of a task
+func (q *Queries) ListDependenciesForTask(ctx context.Context, taskID uuid.UUID) ([]SubtaskDependency, error) {
+	rows, err := q.db.Query(ctx, listDependenciesForTask, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SubtaskDependency{}
+	for rows.Next() {
+		var i SubtaskDependency
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubtaskID,
+			&i.DependsOnID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
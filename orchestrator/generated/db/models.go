@@ -27,39 +27,79 @@ type AgentRun struct {
 	TaskID        pgtype.UUID        `json:"task_id"`
 }
 
-type Project struct {
-	ID            uuid.UUID `json:"id"`
-	UserID        uuid.UUID `json:"user_id"`
-	GithubOwner   string    `json:"github_owner"`
-	GithubRepo    string    `json:"github_repo"`
-	IsFork        bool      `json:"is_fork"`
-	UpstreamOwner *string   `json:"upstream_owner"`
-	UpstreamRepo  *string   `json:"upstream_repo"`
-	DefaultBranch string    `json:"default_branch"`
-	ClonePath     string    `json:"clone_path"`
-	BeadsPrefix   string    `json:"beads_prefix"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+type AgentRunTokenUsage struct {
+	ID           uuid.UUID `json:"id"`
+	AgentRunID   uuid.UUID `json:"agent_run_id"`
+	InputTokens  int32     `json:"input_tokens"`
+	OutputTokens int32     `json:"output_tokens"`
+	TotalTokens  int32     `json:"total_tokens"`
+	RecordedAt   time.Time `json:"recorded_at"`
 }
 
-type Subtask struct {
+type AuditLog struct {
+	ID         uuid.UUID `json:"id"`
+	ProjectID  uuid.UUID `json:"project_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   uuid.UUID `json:"entity_id"`
+	OldStatus  *string   `json:"old_status"`
+	NewStatus  *string   `json:"new_status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	UserID       uuid.UUID `json:"user_id"`
+	Key          string    `json:"key"`
+	Route        string    `json:"route"`
+	RequestHash  string    `json:"request_hash"`
+	StatusCode   *int32    `json:"status_code"`
+	ResponseBody []byte    `json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type Project struct {
 	ID                 uuid.UUID `json:"id"`
-	TaskID             uuid.UUID `json:"task_id"`
-	Title              string    `json:"title"`
-	Spec               *string   `json:"spec"`
-	ImplementationPlan *string   `json:"implementation_plan"`
-	Status             string    `json:"status"`
-	BlockedReason      *string   `json:"blocked_reason"`
-	BranchName         *string   `json:"branch_name"`
-	PrUrl              *string   `json:"pr_url"`
-	PrNumber           *int32    `json:"pr_number"`
-	RetryCount         int32     `json:"retry_count"`
-	TokenUsage         int32     `json:"token_usage"`
-	Position           int32     `json:"position"`
-	BeadsIssueID       *string   `json:"beads_issue_id"`
-	WorktreePath       *string   `json:"worktree_path"`
+	UserID             uuid.UUID `json:"user_id"`
+	GithubOwner        string    `json:"github_owner"`
+	GithubRepo         string    `json:"github_repo"`
+	IsFork             bool      `json:"is_fork"`
+	UpstreamOwner      *string   `json:"upstream_owner"`
+	UpstreamRepo       *string   `json:"upstream_repo"`
+	DefaultBranch      string    `json:"default_branch"`
+	ClonePath          string    `json:"clone_path"`
+	BeadsPrefix        string    `json:"beads_prefix"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
+	MaxSubtasksPerTask *int32    `json:"max_subtasks_per_task"`
+	PrTitleTemplate    *string   `json:"pr_title_template"`
+	SquashBeforePr     bool      `json:"squash_before_pr"`
+	AllowedPaths       []string  `json:"allowed_paths"`
+	DeniedPaths        []string  `json:"denied_paths"`
+}
+
+type Subtask struct {
+	ID                 uuid.UUID          `json:"id"`
+	TaskID             uuid.UUID          `json:"task_id"`
+	Title              string             `json:"title"`
+	Spec               *string            `json:"spec"`
+	ImplementationPlan *string            `json:"implementation_plan"`
+	Status             string             `json:"status"`
+	BlockedReason      *string            `json:"blocked_reason"`
+	BranchName         *string            `json:"branch_name"`
+	PrUrl              *string            `json:"pr_url"`
+	PrNumber           *int32             `json:"pr_number"`
+	RetryCount         int32              `json:"retry_count"`
+	TokenUsage         int32              `json:"token_usage"`
+	Position           int32              `json:"position"`
+	BeadsIssueID       *string            `json:"beads_issue_id"`
+	WorktreePath       *string            `json:"worktree_path"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+	NextAttemptAt      pgtype.Timestamptz `json:"next_attempt_at"`
+	TokenBudget        *int32             `json:"token_budget"`
+	Priority           int32              `json:"priority"`
 }
 
 type SubtaskDependency struct {
@@ -78,6 +118,17 @@ type Task struct {
 	BeadsEpicID *string   `json:"beads_epic_id"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	DryRun      bool      `json:"dry_run"`
+	BaseBranch  string    `json:"base_branch"`
+}
+
+type TaskArchive struct {
+	TaskID            uuid.UUID `json:"task_id"`
+	ArchivePath       string    `json:"archive_path"`
+	RunCount          int32     `json:"run_count"`
+	PlannerTokenUsage int32     `json:"planner_token_usage"`
+	SizeBytes         int64     `json:"size_bytes"`
+	ArchivedAt        time.Time `json:"archived_at"`
 }
 
 type User struct {
@@ -88,3 +139,13 @@ type User struct {
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
+
+type Webhook struct {
+	ID         uuid.UUID `json:"id"`
+	ProjectID  uuid.UUID `json:"project_id"`
+	Url        string    `json:"url"`
+	Secret     string    `json:"secret"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -7,10 +7,24 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+const countProjectsByUser = `-- name: CountProjectsByUser :one
+SELECT COUNT(*) AS count
+FROM projects
+WHERE user_id = $1
+`
+
+func (q *Queries) CountProjectsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countProjectsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createProject = `-- name: CreateProject :one
 
 INSERT INTO projects (
@@ -26,7 +40,7 @@ INSERT INTO projects (
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9
 )
-RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at
+RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths
 `
 
 type CreateProjectParams struct {
@@ -69,6 +83,11 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (P
 		&i.BeadsPrefix,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
 	)
 	return i, err
 }
@@ -83,8 +102,38 @@ func (q *Queries) DeleteProject(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getProjectByClonePath = `-- name: GetProjectByClonePath :one
+SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths FROM projects
+WHERE clone_path = $1 LIMIT 1
+`
+
+func (q *Queries) GetProjectByClonePath(ctx context.Context, clonePath string) (Project, error) {
+	row := q.db.QueryRow(ctx, getProjectByClonePath, clonePath)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GithubOwner,
+		&i.GithubRepo,
+		&i.IsFork,
+		&i.UpstreamOwner,
+		&i.UpstreamRepo,
+		&i.DefaultBranch,
+		&i.ClonePath,
+		&i.BeadsPrefix,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
+	)
+	return i, err
+}
+
 const getProjectByID = `-- name: GetProjectByID :one
-SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at FROM projects
+SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths FROM projects
 WHERE id = $1 LIMIT 1
 `
 
@@ -104,12 +153,17 @@ func (q *Queries) GetProjectByID(ctx context.Context, id uuid.UUID) (Project, er
 		&i.BeadsPrefix,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
 	)
 	return i, err
 }
 
 const getProjectByOwnerRepo = `-- name: GetProjectByOwnerRepo :one
-SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at FROM projects
+SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths FROM projects
 WHERE user_id = $1 AND github_owner = $2 AND github_repo = $3
 LIMIT 1
 `
@@ -136,12 +190,123 @@ func (q *Queries) GetProjectByOwnerRepo(ctx context.Context, arg GetProjectByOwn
 		&i.BeadsPrefix,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
 	)
 	return i, err
 }
 
+const listAllProjects = `-- name: ListAllProjects :many
+SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths FROM projects
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAllProjects(ctx context.Context) ([]Project, error) {
+	rows, err := q.db.Query(ctx, listAllProjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Project{}
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.GithubOwner,
+			&i.GithubRepo,
+			&i.IsFork,
+			&i.UpstreamOwner,
+			&i.UpstreamRepo,
+			&i.DefaultBranch,
+			&i.ClonePath,
+			&i.BeadsPrefix,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MaxSubtasksPerTask,
+			&i.PrTitleTemplate,
+			&i.SquashBeforePr,
+			&i.AllowedPaths,
+			&i.DeniedPaths,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIdleProjects = `-- name: ListIdleProjects :many
+SELECT p.id, p.user_id, p.github_owner, p.github_repo, p.is_fork, p.upstream_owner, p.upstream_repo, p.default_branch, p.clone_path, p.beads_prefix, p.created_at, p.updated_at, p.max_subtasks_per_task, p.pr_title_template, p.squash_before_pr, p.allowed_paths, p.denied_paths FROM projects p
+WHERE p.updated_at < $1::timestamptz
+AND NOT EXISTS (
+    SELECT 1 FROM tasks t
+    WHERE t.project_id = p.id
+    AND (t.updated_at >= $1::timestamptz OR t.status IN ('PLANNING', 'AWAITING_APPROVAL'))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM subtasks s
+    JOIN tasks t ON s.task_id = t.id
+    WHERE t.project_id = p.id
+    AND (s.updated_at >= $1::timestamptz OR s.status = 'IN_PROGRESS')
+)
+AND NOT EXISTS (
+    SELECT 1 FROM agent_runs ar
+    LEFT JOIN subtasks s ON ar.subtask_id = s.id
+    JOIN tasks t ON t.id = COALESCE(ar.task_id, s.task_id)
+    WHERE t.project_id = p.id
+    AND (ar.started_at >= $1::timestamptz OR ar.status = 'RUNNING')
+)
+ORDER BY p.updated_at ASC
+`
+
+// Projects with no task, subtask, or agent run activity since the cutoff and no work in flight
+func (q *Queries) ListIdleProjects(ctx context.Context, idleSince time.Time) ([]Project, error) {
+	rows, err := q.db.Query(ctx, listIdleProjects, idleSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Project{}
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.GithubOwner,
+			&i.GithubRepo,
+			&i.IsFork,
+			&i.UpstreamOwner,
+			&i.UpstreamRepo,
+			&i.DefaultBranch,
+			&i.ClonePath,
+			&i.BeadsPrefix,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MaxSubtasksPerTask,
+			&i.PrTitleTemplate,
+			&i.SquashBeforePr,
+			&i.AllowedPaths,
+			&i.DeniedPaths,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProjectsByUser = `-- name: ListProjectsByUser :many
-SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at FROM projects
+SELECT id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths FROM projects
 WHERE user_id = $1
 ORDER BY created_at DESC
 `
@@ -168,6 +333,11 @@ func (q *Queries) ListProjectsByUser(ctx context.Context, userID uuid.UUID) ([]P
 			&i.BeadsPrefix,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.MaxSubtasksPerTask,
+			&i.PrTitleTemplate,
+			&i.SquashBeforePr,
+			&i.AllowedPaths,
+			&i.DeniedPaths,
 		); err != nil {
 			return nil, err
 		}
@@ -179,6 +349,28 @@ func (q *Queries) ListProjectsByUser(ctx context.Context, userID uuid.UUID) ([]P
 	return items, nil
 }
 
+const sumTokenUsageForProject = `-- name: SumTokenUsageForProject :one
+SELECT (
+    (SELECT COALESCE(SUM(s.token_usage), 0) FROM subtasks s
+     JOIN tasks t ON s.task_id = t.id
+     WHERE t.project_id = $1)
+  + (SELECT COALESCE(SUM(ar.token_usage), 0) FROM agent_runs ar
+     JOIN tasks t ON ar.task_id = t.id
+     WHERE t.project_id = $1)
+  + (SELECT COALESCE(SUM(ta.planner_token_usage), 0) FROM task_archives ta
+     JOIN tasks t ON ta.task_id = t.id
+     WHERE t.project_id = $1)
+)::bigint AS token_usage
+`
+
+// Tokens used by all of a project's Workers and Planners, including archived Planner runs
+func (q *Queries) SumTokenUsageForProject(ctx context.Context, projectID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, sumTokenUsageForProject, projectID)
+	var token_usage int64
+	err := row.Scan(&token_usage)
+	return token_usage, err
+}
+
 const updateProject = `-- name: UpdateProject :one
 UPDATE projects
 SET github_owner = $2,
@@ -191,7 +383,7 @@ SET github_owner = $2,
     beads_prefix = $9,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at
+RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths
 `
 
 type UpdateProjectParams struct {
@@ -232,6 +424,169 @@ func (q *Queries) UpdateProject(ctx context.Context, arg UpdateProjectParams) (P
 		&i.BeadsPrefix,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
+	)
+	return i, err
+}
+
+const updateProjectMaxSubtasks = `-- name: UpdateProjectMaxSubtasks :one
+UPDATE projects
+SET max_subtasks_per_task = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths
+`
+
+type UpdateProjectMaxSubtasksParams struct {
+	ID                 uuid.UUID `json:"id"`
+	MaxSubtasksPerTask *int32    `json:"max_subtasks_per_task"`
+}
+
+// NULL clears the override so MAX_SUBTASKS_PER_TASK applies
+func (q *Queries) UpdateProjectMaxSubtasks(ctx context.Context, arg UpdateProjectMaxSubtasksParams) (Project, error) {
+	row := q.db.QueryRow(ctx, updateProjectMaxSubtasks, arg.ID, arg.MaxSubtasksPerTask)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GithubOwner,
+		&i.GithubRepo,
+		&i.IsFork,
+		&i.UpstreamOwner,
+		&i.UpstreamRepo,
+		&i.DefaultBranch,
+		&i.ClonePath,
+		&i.BeadsPrefix,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
+	)
+	return i, err
+}
+
+const updateProjectPRTitleTemplate = `-- name: UpdateProjectPRTitleTemplate :one
+UPDATE projects
+SET pr_title_template = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths
+`
+
+type UpdateProjectPRTitleTemplateParams struct {
+	ID              uuid.UUID `json:"id"`
+	PrTitleTemplate *string   `json:"pr_title_template"`
+}
+
+// NULL restores the default PR title format
+func (q *Queries) UpdateProjectPRTitleTemplate(ctx context.Context, arg UpdateProjectPRTitleTemplateParams) (Project, error) {
+	row := q.db.QueryRow(ctx, updateProjectPRTitleTemplate, arg.ID, arg.PrTitleTemplate)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GithubOwner,
+		&i.GithubRepo,
+		&i.IsFork,
+		&i.UpstreamOwner,
+		&i.UpstreamRepo,
+		&i.DefaultBranch,
+		&i.ClonePath,
+		&i.BeadsPrefix,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
+	)
+	return i, err
+}
+
+const updateProjectPathPolicy = `-- name: UpdateProjectPathPolicy :one
+UPDATE projects
+SET allowed_paths = $2,
+    denied_paths = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths
+`
+
+type UpdateProjectPathPolicyParams struct {
+	ID           uuid.UUID `json:"id"`
+	AllowedPaths []string  `json:"allowed_paths"`
+	DeniedPaths  []string  `json:"denied_paths"`
+}
+
+// Limit the repository paths a project's Workers may modify
+func (q *Queries) UpdateProjectPathPolicy(ctx context.Context, arg UpdateProjectPathPolicyParams) (Project, error) {
+	row := q.db.QueryRow(ctx, updateProjectPathPolicy, arg.ID, arg.AllowedPaths, arg.DeniedPaths)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GithubOwner,
+		&i.GithubRepo,
+		&i.IsFork,
+		&i.UpstreamOwner,
+		&i.UpstreamRepo,
+		&i.DefaultBranch,
+		&i.ClonePath,
+		&i.BeadsPrefix,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
+	)
+	return i, err
+}
+
+const updateProjectSquashBeforePR = `-- name: UpdateProjectSquashBeforePR :one
+UPDATE projects
+SET squash_before_pr = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, github_owner, github_repo, is_fork, upstream_owner, upstream_repo, default_branch, clone_path, beads_prefix, created_at, updated_at, max_subtasks_per_task, pr_title_template, squash_before_pr, allowed_paths, denied_paths
+`
+
+type UpdateProjectSquashBeforePRParams struct {
+	ID             uuid.UUID `json:"id"`
+	SquashBeforePr bool      `json:"squash_before_pr"`
+}
+
+// Squash a Worker's commits into one before its PR is opened
+func (q *Queries) UpdateProjectSquashBeforePR(ctx context.Context, arg UpdateProjectSquashBeforePRParams) (Project, error) {
+	row := q.db.QueryRow(ctx, updateProjectSquashBeforePR, arg.ID, arg.SquashBeforePr)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GithubOwner,
+		&i.GithubRepo,
+		&i.IsFork,
+		&i.UpstreamOwner,
+		&i.UpstreamRepo,
+		&i.DefaultBranch,
+		&i.ClonePath,
+		&i.BeadsPrefix,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MaxSubtasksPerTask,
+		&i.PrTitleTemplate,
+		&i.SquashBeforePr,
+		&i.AllowedPaths,
+		&i.DeniedPaths,
 	)
 	return i, err
 }
@@ -7,10 +7,92 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimSubtaskForStart = `-- name: ClaimSubtaskForStart :one
+UPDATE subtasks
+SET status = 'IN_PROGRESS',
+    blocked_reason = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND status = $2
+    AND ($3::timestamptz IS NULL OR updated_at = $3::timestamptz)
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
+`
+
+type ClaimSubtaskForStartParams struct {
+	ID                uuid.UUID  `json:"id"`
+	Status            string     `json:"status"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at"`
+}
+
+// Atomically moves a subtask to IN_PROGRESS only if it is still in the
+// expected status, so concurrent start requests cannot both win.
+func (q *Queries) ClaimSubtaskForStart(ctx context.Context, arg ClaimSubtaskForStartParams) (Subtask, error) {
+	row := q.db.QueryRow(ctx, claimSubtaskForStart, arg.ID, arg.Status, arg.ExpectedUpdatedAt)
+	var i Subtask
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.Title,
+		&i.Spec,
+		&i.ImplementationPlan,
+		&i.Status,
+		&i.BlockedReason,
+		&i.BranchName,
+		&i.PrUrl,
+		&i.PrNumber,
+		&i.RetryCount,
+		&i.TokenUsage,
+		&i.Position,
+		&i.BeadsIssueID,
+		&i.WorktreePath,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
+	)
+	return i, err
+}
+
+const countSubtasksByStatusForProject = `-- name: CountSubtasksByStatusForProject :many
+SELECT s.status, COUNT(*) AS count
+FROM subtasks s
+JOIN tasks t ON s.task_id = t.id
+WHERE t.project_id = $1
+GROUP BY s.status
+`
+
+type CountSubtasksByStatusForProjectRow struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// Subtask counts per status across all of a project's tasks, for the project summary
+func (q *Queries) CountSubtasksByStatusForProject(ctx context.Context, projectID uuid.UUID) ([]CountSubtasksByStatusForProjectRow, error) {
+	rows, err := q.db.Query(ctx, countSubtasksByStatusForProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountSubtasksByStatusForProjectRow{}
+	for rows.Next() {
+		var i CountSubtasksByStatusForProjectRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createSubtask = `-- name: CreateSubtask :one
 
 INSERT INTO subtasks (
@@ -20,11 +102,12 @@ INSERT INTO subtasks (
     implementation_plan,
     status,
     position,
-    beads_issue_id
+    beads_issue_id,
+    priority
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
 `
 
 type CreateSubtaskParams struct {
@@ -35,6 +118,7 @@ type CreateSubtaskParams struct {
 	Status             string    `json:"status"`
 	Position           int32     `json:"position"`
 	BeadsIssueID       *string   `json:"beads_issue_id"`
+	Priority           int32     `json:"priority"`
 }
 
 // Subtasks SQL queries
@@ -48,6 +132,7 @@ func (q *Queries) CreateSubtask(ctx context.Context, arg CreateSubtaskParams) (S
 		arg.Status,
 		arg.Position,
 		arg.BeadsIssueID,
+		arg.Priority,
 	)
 	var i Subtask
 	err := row.Scan(
@@ -68,6 +153,9 @@ func (q *Queries) CreateSubtask(ctx context.Context, arg CreateSubtaskParams) (S
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -96,7 +184,7 @@ func (q *Queries) GetNextPosition(ctx context.Context, taskID uuid.UUID) (int32,
 }
 
 const getSubtaskByBeadsID = `-- name: GetSubtaskByBeadsID :one
-SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at FROM subtasks
+SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority FROM subtasks
 WHERE beads_issue_id = $1 LIMIT 1
 `
 
@@ -121,12 +209,15 @@ func (q *Queries) GetSubtaskByBeadsID(ctx context.Context, beadsIssueID *string)
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
 
 const getSubtaskByID = `-- name: GetSubtaskByID :one
-SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at FROM subtasks
+SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority FROM subtasks
 WHERE id = $1 LIMIT 1
 `
 
@@ -151,12 +242,15 @@ func (q *Queries) GetSubtaskByID(ctx context.Context, id uuid.UUID) (Subtask, er
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
 
 const getSubtasksByStatus = `-- name: GetSubtasksByStatus :many
-SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at FROM subtasks
+SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority FROM subtasks
 WHERE status = $1
 ORDER BY created_at DESC
 `
@@ -188,6 +282,104 @@ func (q *Queries) GetSubtasksByStatus(ctx context.Context, status string) ([]Sub
 			&i.WorktreePath,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.NextAttemptAt,
+			&i.TokenBudget,
+			&i.Priority,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFailedSubtasksByProject = `-- name: ListFailedSubtasksByProject :many
+SELECT
+    s.id, s.task_id, s.title, s.spec, s.implementation_plan, s.status, s.blocked_reason, s.branch_name, s.pr_url, s.pr_number, s.retry_count, s.token_usage, s.position, s.beads_issue_id, s.worktree_path, s.created_at, s.updated_at, s.next_attempt_at, s.token_budget, s.priority,
+    t.title AS task_title,
+    lr.error_message AS last_error,
+    lr.ended_at AS last_failed_at
+FROM subtasks s
+JOIN tasks t ON s.task_id = t.id
+LEFT JOIN LATERAL (
+    SELECT ar.error_message, ar.ended_at
+    FROM agent_runs ar
+    WHERE ar.subtask_id = s.id
+    ORDER BY ar.attempt_number DESC
+    LIMIT 1
+) lr ON TRUE
+WHERE t.project_id = $1
+AND t.status NOT IN ('DONE', 'CANCELLED')
+AND s.status = 'BLOCKED'
+AND s.blocked_reason = 'FAILURE'
+ORDER BY s.priority ASC, s.updated_at DESC
+`
+
+type ListFailedSubtasksByProjectRow struct {
+	ID                 uuid.UUID          `json:"id"`
+	TaskID             uuid.UUID          `json:"task_id"`
+	Title              string             `json:"title"`
+	Spec               *string            `json:"spec"`
+	ImplementationPlan *string            `json:"implementation_plan"`
+	Status             string             `json:"status"`
+	BlockedReason      *string            `json:"blocked_reason"`
+	BranchName         *string            `json:"branch_name"`
+	PrUrl              *string            `json:"pr_url"`
+	PrNumber           *int32             `json:"pr_number"`
+	RetryCount         int32              `json:"retry_count"`
+	TokenUsage         int32              `json:"token_usage"`
+	Position           int32              `json:"position"`
+	BeadsIssueID       *string            `json:"beads_issue_id"`
+	WorktreePath       *string            `json:"worktree_path"`
+	CreatedAt          time.Time          `json:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at"`
+	NextAttemptAt      pgtype.Timestamptz `json:"next_attempt_at"`
+	TokenBudget        *int32             `json:"token_budget"`
+	Priority           int32              `json:"priority"`
+	TaskTitle          string             `json:"task_title"`
+	LastError          *string            `json:"last_error"`
+	LastFailedAt       pgtype.Timestamptz `json:"last_failed_at"`
+}
+
+// Subtasks BLOCKED by a failure across a project's open tasks, with their
+// task's title and the error of their latest agent run, highest priority
+// and then most recently updated first, for the project's "needs attention" list
+func (q *Queries) ListFailedSubtasksByProject(ctx context.Context, projectID uuid.UUID) ([]ListFailedSubtasksByProjectRow, error) {
+	rows, err := q.db.Query(ctx, listFailedSubtasksByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFailedSubtasksByProjectRow{}
+	for rows.Next() {
+		var i ListFailedSubtasksByProjectRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.Title,
+			&i.Spec,
+			&i.ImplementationPlan,
+			&i.Status,
+			&i.BlockedReason,
+			&i.BranchName,
+			&i.PrUrl,
+			&i.PrNumber,
+			&i.RetryCount,
+			&i.TokenUsage,
+			&i.Position,
+			&i.BeadsIssueID,
+			&i.WorktreePath,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NextAttemptAt,
+			&i.TokenBudget,
+			&i.Priority,
+			&i.TaskTitle,
+			&i.LastError,
+			&i.LastFailedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -200,7 +392,7 @@ func (q *Queries) GetSubtasksByStatus(ctx context.Context, status string) ([]Sub
 }
 
 const listInProgressSubtasks = `-- name: ListInProgressSubtasks :many
-SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at FROM subtasks
+SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority FROM subtasks
 WHERE status = 'IN_PROGRESS'
 ORDER BY created_at DESC
 `
@@ -232,6 +424,9 @@ func (q *Queries) ListInProgressSubtasks(ctx context.Context) ([]Subtask, error)
 			&i.WorktreePath,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.NextAttemptAt,
+			&i.TokenBudget,
+			&i.Priority,
 		); err != nil {
 			return nil, err
 		}
@@ -244,7 +439,7 @@ func (q *Queries) ListInProgressSubtasks(ctx context.Context) ([]Subtask, error)
 }
 
 const listSubtasksByTask = `-- name: ListSubtasksByTask :many
-SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at FROM subtasks
+SELECT id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority FROM subtasks
 WHERE task_id = $1
 ORDER BY position ASC, created_at ASC
 `
@@ -276,6 +471,9 @@ func (q *Queries) ListSubtasksByTask(ctx context.Context, taskID uuid.UUID) ([]S
 			&i.WorktreePath,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.NextAttemptAt,
+			&i.TokenBudget,
+			&i.Priority,
 		); err != nil {
 			return nil, err
 		}
@@ -287,13 +485,59 @@ func (q *Queries) ListSubtasksByTask(ctx context.Context, taskID uuid.UUID) ([]S
 	return items, nil
 }
 
+const stopSubtask = `-- name: StopSubtask :one
+UPDATE subtasks
+SET status = 'BLOCKED',
+    blocked_reason = 'STOPPED',
+    next_attempt_at = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'IN_PROGRESS'
+    AND ($2::timestamptz IS NULL OR updated_at = $2::timestamptz)
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
+`
+
+type StopSubtaskParams struct {
+	ID                uuid.UUID  `json:"id"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at"`
+}
+
+// Atomically blocks a subtask with reason STOPPED only if it is still
+// IN_PROGRESS, so a stop cannot overwrite a Worker that already finished.
+func (q *Queries) StopSubtask(ctx context.Context, arg StopSubtaskParams) (Subtask, error) {
+	row := q.db.QueryRow(ctx, stopSubtask, arg.ID, arg.ExpectedUpdatedAt)
+	var i Subtask
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.Title,
+		&i.Spec,
+		&i.ImplementationPlan,
+		&i.Status,
+		&i.BlockedReason,
+		&i.BranchName,
+		&i.PrUrl,
+		&i.PrNumber,
+		&i.RetryCount,
+		&i.TokenUsage,
+		&i.Position,
+		&i.BeadsIssueID,
+		&i.WorktreePath,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
+	)
+	return i, err
+}
+
 const updateSubtaskBranch = `-- name: UpdateSubtaskBranch :one
 UPDATE subtasks
 SET branch_name = $2,
     worktree_path = $3,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
 `
 
 type UpdateSubtaskBranchParams struct {
@@ -323,6 +567,51 @@ func (q *Queries) UpdateSubtaskBranch(ctx context.Context, arg UpdateSubtaskBran
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
+	)
+	return i, err
+}
+
+const updateSubtaskNextAttemptAt = `-- name: UpdateSubtaskNextAttemptAt :one
+UPDATE subtasks
+SET next_attempt_at = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
+`
+
+type UpdateSubtaskNextAttemptAtParams struct {
+	ID            uuid.UUID          `json:"id"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+}
+
+// Set while a Worker backs off between attempts; NULL clears it
+func (q *Queries) UpdateSubtaskNextAttemptAt(ctx context.Context, arg UpdateSubtaskNextAttemptAtParams) (Subtask, error) {
+	row := q.db.QueryRow(ctx, updateSubtaskNextAttemptAt, arg.ID, arg.NextAttemptAt)
+	var i Subtask
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.Title,
+		&i.Spec,
+		&i.ImplementationPlan,
+		&i.Status,
+		&i.BlockedReason,
+		&i.BranchName,
+		&i.PrUrl,
+		&i.PrNumber,
+		&i.RetryCount,
+		&i.TokenUsage,
+		&i.Position,
+		&i.BeadsIssueID,
+		&i.WorktreePath,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -333,7 +622,7 @@ SET pr_url = $2,
     pr_number = $3,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
 `
 
 type UpdateSubtaskPRParams struct {
@@ -363,6 +652,9 @@ func (q *Queries) UpdateSubtaskPR(ctx context.Context, arg UpdateSubtaskPRParams
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -372,16 +664,18 @@ UPDATE subtasks
 SET position = $2,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at
+    AND ($3::timestamptz IS NULL OR updated_at = $3::timestamptz)
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
 `
 
 type UpdateSubtaskPositionParams struct {
-	ID       uuid.UUID `json:"id"`
-	Position int32     `json:"position"`
+	ID                uuid.UUID  `json:"id"`
+	Position          int32      `json:"position"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at"`
 }
 
 func (q *Queries) UpdateSubtaskPosition(ctx context.Context, arg UpdateSubtaskPositionParams) (Subtask, error) {
-	row := q.db.QueryRow(ctx, updateSubtaskPosition, arg.ID, arg.Position)
+	row := q.db.QueryRow(ctx, updateSubtaskPosition, arg.ID, arg.Position, arg.ExpectedUpdatedAt)
 	var i Subtask
 	err := row.Scan(
 		&i.ID,
@@ -401,6 +695,52 @@ func (q *Queries) UpdateSubtaskPosition(ctx context.Context, arg UpdateSubtaskPo
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
+	)
+	return i, err
+}
+
+const updateSubtaskPriority = `-- name: UpdateSubtaskPriority :one
+UPDATE subtasks
+SET priority = $2,
+    updated_at = NOW()
+WHERE id = $1
+    AND ($3::timestamptz IS NULL OR updated_at = $3::timestamptz)
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
+`
+
+type UpdateSubtaskPriorityParams struct {
+	ID                uuid.UUID  `json:"id"`
+	Priority          int32      `json:"priority"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at"`
+}
+
+func (q *Queries) UpdateSubtaskPriority(ctx context.Context, arg UpdateSubtaskPriorityParams) (Subtask, error) {
+	row := q.db.QueryRow(ctx, updateSubtaskPriority, arg.ID, arg.Priority, arg.ExpectedUpdatedAt)
+	var i Subtask
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.Title,
+		&i.Spec,
+		&i.ImplementationPlan,
+		&i.Status,
+		&i.BlockedReason,
+		&i.BranchName,
+		&i.PrUrl,
+		&i.PrNumber,
+		&i.RetryCount,
+		&i.TokenUsage,
+		&i.Position,
+		&i.BeadsIssueID,
+		&i.WorktreePath,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -408,18 +748,21 @@ func (q *Queries) UpdateSubtaskPosition(ctx context.Context, arg UpdateSubtaskPo
 const updateSubtaskRetryCount = `-- name: UpdateSubtaskRetryCount :one
 UPDATE subtasks
 SET retry_count = $2,
+    next_attempt_at = NULL,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at
+    AND ($3::timestamptz IS NULL OR updated_at = $3::timestamptz)
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
 `
 
 type UpdateSubtaskRetryCountParams struct {
-	ID         uuid.UUID `json:"id"`
-	RetryCount int32     `json:"retry_count"`
+	ID                uuid.UUID  `json:"id"`
+	RetryCount        int32      `json:"retry_count"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at"`
 }
 
 func (q *Queries) UpdateSubtaskRetryCount(ctx context.Context, arg UpdateSubtaskRetryCountParams) (Subtask, error) {
-	row := q.db.QueryRow(ctx, updateSubtaskRetryCount, arg.ID, arg.RetryCount)
+	row := q.db.QueryRow(ctx, updateSubtaskRetryCount, arg.ID, arg.RetryCount, arg.ExpectedUpdatedAt)
 	var i Subtask
 	err := row.Scan(
 		&i.ID,
@@ -439,6 +782,9 @@ func (q *Queries) UpdateSubtaskRetryCount(ctx context.Context, arg UpdateSubtask
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -447,19 +793,67 @@ const updateSubtaskStatus = `-- name: UpdateSubtaskStatus :one
 UPDATE subtasks
 SET status = $2,
     blocked_reason = $3,
+    next_attempt_at = NULL,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at
+    AND ($4::timestamptz IS NULL OR updated_at = $4::timestamptz)
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
 `
 
 type UpdateSubtaskStatusParams struct {
-	ID            uuid.UUID `json:"id"`
-	Status        string    `json:"status"`
-	BlockedReason *string   `json:"blocked_reason"`
+	ID                uuid.UUID  `json:"id"`
+	Status            string     `json:"status"`
+	BlockedReason     *string    `json:"blocked_reason"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at"`
 }
 
+// With expected_updated_at set, no row is returned if the subtask has changed
+// since the client read it.
 func (q *Queries) UpdateSubtaskStatus(ctx context.Context, arg UpdateSubtaskStatusParams) (Subtask, error) {
-	row := q.db.QueryRow(ctx, updateSubtaskStatus, arg.ID, arg.Status, arg.BlockedReason)
+	row := q.db.QueryRow(ctx, updateSubtaskStatus, arg.ID, arg.Status, arg.BlockedReason, arg.ExpectedUpdatedAt)
+	var i Subtask
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.Title,
+		&i.Spec,
+		&i.ImplementationPlan,
+		&i.Status,
+		&i.BlockedReason,
+		&i.BranchName,
+		&i.PrUrl,
+		&i.PrNumber,
+		&i.RetryCount,
+		&i.TokenUsage,
+		&i.Position,
+		&i.BeadsIssueID,
+		&i.WorktreePath,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
+	)
+	return i, err
+}
+
+const updateSubtaskTokenBudget = `-- name: UpdateSubtaskTokenBudget :one
+UPDATE subtasks
+SET token_budget = $2,
+    updated_at = NOW()
+WHERE id = $1
+    AND ($3::timestamptz IS NULL OR updated_at = $3::timestamptz)
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
+`
+
+type UpdateSubtaskTokenBudgetParams struct {
+	ID                uuid.UUID  `json:"id"`
+	TokenBudget       *int32     `json:"token_budget"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at"`
+}
+
+func (q *Queries) UpdateSubtaskTokenBudget(ctx context.Context, arg UpdateSubtaskTokenBudgetParams) (Subtask, error) {
+	row := q.db.QueryRow(ctx, updateSubtaskTokenBudget, arg.ID, arg.TokenBudget, arg.ExpectedUpdatedAt)
 	var i Subtask
 	err := row.Scan(
 		&i.ID,
@@ -479,6 +873,9 @@ func (q *Queries) UpdateSubtaskStatus(ctx context.Context, arg UpdateSubtaskStat
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
@@ -488,7 +885,7 @@ UPDATE subtasks
 SET token_usage = token_usage + $2,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at
+RETURNING id, task_id, title, spec, implementation_plan, status, blocked_reason, branch_name, pr_url, pr_number, retry_count, token_usage, position, beads_issue_id, worktree_path, created_at, updated_at, next_attempt_at, token_budget, priority
 `
 
 type UpdateSubtaskTokenUsageParams struct {
@@ -517,6 +914,9 @@ func (q *Queries) UpdateSubtaskTokenUsage(ctx context.Context, arg UpdateSubtask
 		&i.WorktreePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NextAttemptAt,
+		&i.TokenBudget,
+		&i.Priority,
 	)
 	return i, err
 }
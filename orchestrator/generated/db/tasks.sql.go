@@ -11,17 +11,68 @@ import (
 	"github.com/google/uuid"
 )
 
+const countActiveTasksByUser = `-- name: CountActiveTasksByUser :one
+SELECT COUNT(*) AS count
+FROM tasks t
+JOIN projects p ON t.project_id = p.id
+WHERE p.user_id = $1
+AND t.status NOT IN ('DONE', 'CANCELLED')
+`
+
+// Tasks not yet DONE or CANCELLED, across all of a user's projects
+func (q *Queries) CountActiveTasksByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countActiveTasksByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTasksByStatusForProject = `-- name: CountTasksByStatusForProject :many
+SELECT status, COUNT(*) AS count
+FROM tasks
+WHERE project_id = $1
+GROUP BY status
+`
+
+type CountTasksByStatusForProjectRow struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// Task counts per status for the project summary
+func (q *Queries) CountTasksByStatusForProject(ctx context.Context, projectID uuid.UUID) ([]CountTasksByStatusForProjectRow, error) {
+	rows, err := q.db.Query(ctx, countTasksByStatusForProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountTasksByStatusForProjectRow{}
+	for rows.Next() {
+		var i CountTasksByStatusForProjectRow
+		if err := rows.Scan(&i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createTask = `-- name: CreateTask :one
 
 INSERT INTO tasks (
     project_id,
     title,
     description,
-    status
+    status,
+    dry_run,
+    base_branch
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6
 )
-RETURNING id, project_id, title, description, status, beads_epic_id, created_at, updated_at
+RETURNING id, project_id, title, description, status, beads_epic_id, created_at, updated_at, dry_run, base_branch
 `
 
 type CreateTaskParams struct {
@@ -29,6 +80,8 @@ type CreateTaskParams struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	Status      string    `json:"status"`
+	DryRun      bool      `json:"dry_run"`
+	BaseBranch  string    `json:"base_branch"`
 }
 
 // Tasks SQL queries
@@ -39,6 +92,8 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		arg.Title,
 		arg.Description,
 		arg.Status,
+		arg.DryRun,
+		arg.BaseBranch,
 	)
 	var i Task
 	err := row.Scan(
@@ -50,6 +105,8 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, e
 		&i.BeadsEpicID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DryRun,
+		&i.BaseBranch,
 	)
 	return i, err
 }
@@ -65,7 +122,7 @@ func (q *Queries) DeleteTask(ctx context.Context, id uuid.UUID) error {
 }
 
 const getTaskByID = `-- name: GetTaskByID :one
-SELECT id, project_id, title, description, status, beads_epic_id, created_at, updated_at FROM tasks
+SELECT id, project_id, title, description, status, beads_epic_id, created_at, updated_at, dry_run, base_branch FROM tasks
 WHERE id = $1 LIMIT 1
 `
 
@@ -81,12 +138,38 @@ func (q *Queries) GetTaskByID(ctx context.Context, id uuid.UUID) (Task, error) {
 		&i.BeadsEpicID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DryRun,
+		&i.BaseBranch,
 	)
 	return i, err
 }
 
+const getTaskCompletionSummary = `-- name: GetTaskCompletionSummary :one
+SELECT
+    (SELECT COUNT(*) FROM subtasks s WHERE s.task_id = $1) AS subtask_count,
+    (SELECT COUNT(*) FROM subtasks s WHERE s.task_id = $1 AND s.status = 'MERGED') AS merged_count,
+    (
+        (SELECT COALESCE(SUM(s.token_usage), 0) FROM subtasks s WHERE s.task_id = $1)
+      + (SELECT COALESCE(SUM(ar.token_usage), 0) FROM agent_runs ar WHERE ar.task_id = $1)
+    )::bigint AS token_usage
+`
+
+type GetTaskCompletionSummaryRow struct {
+	SubtaskCount int64 `json:"subtask_count"`
+	MergedCount  int64 `json:"merged_count"`
+	TokenUsage   int64 `json:"token_usage"`
+}
+
+// Subtask counts and tokens used by the task's Planner and Workers, for the task:completed event
+func (q *Queries) GetTaskCompletionSummary(ctx context.Context, taskID uuid.UUID) (GetTaskCompletionSummaryRow, error) {
+	row := q.db.QueryRow(ctx, getTaskCompletionSummary, taskID)
+	var i GetTaskCompletionSummaryRow
+	err := row.Scan(&i.SubtaskCount, &i.MergedCount, &i.TokenUsage)
+	return i, err
+}
+
 const getTasksByStatus = `-- name: GetTasksByStatus :many
-SELECT id, project_id, title, description, status, beads_epic_id, created_at, updated_at FROM tasks
+SELECT id, project_id, title, description, status, beads_epic_id, created_at, updated_at, dry_run, base_branch FROM tasks
 WHERE status = $1
 ORDER BY created_at DESC
 `
@@ -109,6 +192,8 @@ func (q *Queries) GetTasksByStatus(ctx context.Context, status string) ([]Task,
 			&i.BeadsEpicID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DryRun,
+			&i.BaseBranch,
 		); err != nil {
 			return nil, err
 		}
@@ -121,7 +206,7 @@ func (q *Queries) GetTasksByStatus(ctx context.Context, status string) ([]Task,
 }
 
 const listTasksByProject = `-- name: ListTasksByProject :many
-SELECT id, project_id, title, description, status, beads_epic_id, created_at, updated_at FROM tasks
+SELECT id, project_id, title, description, status, beads_epic_id, created_at, updated_at, dry_run, base_branch FROM tasks
 WHERE project_id = $1
 ORDER BY created_at DESC
 `
@@ -144,6 +229,8 @@ func (q *Queries) ListTasksByProject(ctx context.Context, projectID uuid.UUID) (
 			&i.BeadsEpicID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DryRun,
+			&i.BaseBranch,
 		); err != nil {
 			return nil, err
 		}
@@ -160,7 +247,7 @@ UPDATE tasks
 SET beads_epic_id = $2,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, project_id, title, description, status, beads_epic_id, created_at, updated_at
+RETURNING id, project_id, title, description, status, beads_epic_id, created_at, updated_at, dry_run, base_branch
 `
 
 type UpdateTaskBeadsEpicIDParams struct {
@@ -180,6 +267,8 @@ func (q *Queries) UpdateTaskBeadsEpicID(ctx context.Context, arg UpdateTaskBeads
 		&i.BeadsEpicID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DryRun,
+		&i.BaseBranch,
 	)
 	return i, err
 }
@@ -189,7 +278,7 @@ UPDATE tasks
 SET status = $2,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, project_id, title, description, status, beads_epic_id, created_at, updated_at
+RETURNING id, project_id, title, description, status, beads_epic_id, created_at, updated_at, dry_run, base_branch
 `
 
 type UpdateTaskStatusParams struct {
@@ -209,6 +298,8 @@ func (q *Queries) UpdateTaskStatus(ctx context.Context, arg UpdateTaskStatusPara
 		&i.BeadsEpicID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DryRun,
+		&i.BaseBranch,
 	)
 	return i, err
 }
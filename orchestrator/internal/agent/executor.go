@@ -5,18 +5,27 @@ package agent
 
 import (
 	"bufio"
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/netproxy"
+	"github.com/intern-village/orchestrator/internal/service"
 )
 
 // ExecutionResult contains the result of executing a Claude CLI command.
@@ -24,16 +33,34 @@ type ExecutionResult struct {
 	ExitCode   int
 	LogPath    string
 	TokenUsage int
-	Duration   time.Duration
-	Error      error
+	// UsageSamples are the token usage reports of the run in the order they
+	// arrived; TokenUsage is their total when there are any
+	UsageSamples []UsageSample
+	Duration     time.Duration
+	Error        error
+}
+
+// UsageSample is the token usage reported by one result event of a run.
+type UsageSample struct {
+	At           time.Time
+	InputTokens  int
+	OutputTokens int
+}
+
+// Total returns the input and output tokens of the sample.
+func (u UsageSample) Total() int {
+	return u.InputTokens + u.OutputTokens
 }
 
 // ClaudeRun represents a running Claude CLI process.
 // Use Wait() to block until completion and get the result.
 type ClaudeRun struct {
-	LogPath    string
-	resultChan chan *ExecutionResult
-	result     *ExecutionResult
+	LogPath string
+	// CompletionToken is written in the log header and in the completion line
+	// appended when the run finishes (see service.RunCompleteLine)
+	CompletionToken string
+	resultChan      chan *ExecutionResult
+	result          *ExecutionResult
 }
 
 // Wait blocks until the Claude process completes and returns the result.
@@ -46,18 +73,45 @@ func (r *ClaudeRun) Wait() *ExecutionResult {
 	return r.result
 }
 
+// ErrPromptTooLarge is returned when a prompt file exceeds the Executor's
+// maximum prompt size.
+var ErrPromptTooLarge = errors.New("prompt too large")
+
 // Executor manages Claude CLI process execution.
 type Executor struct {
-	dataDir string
+	paths          config.DataPaths
+	maxPromptBytes int64
+	maxAttemptLogs int // 0 keeps every attempt log
+	env            []string
+	running        sync.Map // log paths still being written by a Claude process
 }
 
 // NewExecutor creates a new Executor.
-func NewExecutor(dataDir string) *Executor {
+func NewExecutor(paths config.DataPaths) *Executor {
 	return &Executor{
-		dataDir: dataDir,
+		paths: paths,
 	}
 }
 
+// SetMaxPromptBytes sets the largest prompt ExecuteClaudeAsync will pipe to
+// the Claude CLI. 0 disables the limit.
+func (e *Executor) SetMaxPromptBytes(n int64) {
+	e.maxPromptBytes = n
+}
+
+// SetMaxAttemptLogs sets how many run-NNN.log files are kept per subtask (or
+// per task for the Planner). Older ones are pruned when a new attempt starts.
+// 0 keeps them all.
+func (e *Executor) SetMaxAttemptLogs(n int) {
+	e.maxAttemptLogs = n
+}
+
+// SetProxy passes the proxy to the Claude CLI and the git commands agents run.
+// A disabled proxy leaves the process environment in charge.
+func (e *Executor) SetProxy(proxy netproxy.Config) {
+	e.env = proxy.Env()
+}
+
 // ExecuteClaudeAsync starts the Claude CLI and returns immediately with a ClaudeRun handle.
 // The log file is created before returning, so log tailing can start immediately.
 // Call Wait() on the returned ClaudeRun to block until completion.
@@ -76,10 +130,13 @@ func (e *Executor) ExecuteClaudeAsync(ctx context.Context, workDir, promptPath,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log file: %w", err)
 	}
+	e.pruneAttemptLogs(logDir, logPath)
 
 	// Write header to log file
-	header := fmt.Sprintf("=== Agent Run %d ===\nStarted: %s\nWorking Directory: %s\nPrompt: %s\n\n",
-		attemptNumber, startTime.Format(time.RFC3339), workDir, promptPath)
+	completionToken := service.NewCompletionToken()
+	header := fmt.Sprintf("=== Agent Run %d ===\nStarted: %s\nWorking Directory: %s\nPrompt: %s\n%s%s\n\n",
+		attemptNumber, startTime.Format(time.RFC3339), workDir, promptPath,
+		service.CompletionTokenHeader, completionToken)
 	if _, err := logFile.WriteString(header); err != nil {
 		logFile.Close()
 		return nil, fmt.Errorf("failed to write log header: %w", err)
@@ -92,17 +149,26 @@ func (e *Executor) ExecuteClaudeAsync(ctx context.Context, workDir, promptPath,
 	}
 
 	// Read prompt content
-	promptContent, err := os.ReadFile(promptPath) //nolint:gosec // promptPath is constructed by our code
+	promptContent, err := e.readPrompt(promptPath)
 	if err != nil {
+		//nolint:errcheck // Best effort logging
+		logFile.WriteString(fmt.Sprintf("Error: %v\n", err))
 		logFile.Close()
-		return nil, fmt.Errorf("failed to read prompt file: %w", err)
+		return nil, err
 	}
+	log.Info().
+		Str("prompt_path", promptPath).
+		Int("prompt_bytes", len(promptContent)).
+		Msg("starting claude")
 
 	// Build the command: echo the prompt and pipe to claude
 	// Using stream-json output format for real-time log streaming
 	// The --verbose flag is required for stream-json mode
 	cmd := exec.CommandContext(ctx, "claude", "--print", "--dangerously-skip-permissions", "--output-format", "stream-json", "--verbose") //nolint:gosec // Command is fixed
 	cmd.Dir = workDir
+	if len(e.env) > 0 {
+		cmd.Env = append(os.Environ(), e.env...)
+	}
 	cmd.Stdin = strings.NewReader(string(promptContent))
 
 	// Create pipes for stdout and stderr
@@ -127,56 +193,72 @@ func (e *Executor) ExecuteClaudeAsync(ctx context.Context, workDir, promptPath,
 	resultChan := make(chan *ExecutionResult, 1)
 
 	// Run the rest in a goroutine
+	e.running.Store(logPath, struct{}{})
 	go func() {
+		defer e.running.Delete(logPath)
 		defer logFile.Close()
 
 		// Capture output with timestamps
 		var wg sync.WaitGroup
 		var outputBuffer strings.Builder
 		var mu sync.Mutex // Protect concurrent writes to logFile
+		authFailed := false
+		var usageSamples []UsageSample
 
 		// captureStreamJSON parses the stream-json output format from Claude CLI
 		// and extracts meaningful content for logging
 		captureStreamJSON := func(reader io.Reader) {
 			defer wg.Done()
-			scanner := bufio.NewScanner(reader)
-			// Increase buffer size for long lines (JSON events can be large)
-			buf := make([]byte, 0, 256*1024)
-			scanner.Buffer(buf, 4*1024*1024)
-			for scanner.Scan() {
-				line := scanner.Text()
+			// Lines are read whole however long they are: a single event such
+			// as a big tool result must not end the capture, losing the
+			// result event with the token usage that follows it
+			err := readLines(reader, func(line string) {
 				if line == "" {
-					continue
+					return
+				}
+				if len(line) > largeStreamEventBytes {
+					log.Warn().
+						Str("log_path", logPath).
+						Int("bytes", len(line)).
+						Msg("oversized stream-json event from claude")
 				}
 
-				timestamp := time.Now().Format("15:04:05")
+				now := time.Now()
+				if sample, ok := parseUsageSample(line, now); ok {
+					usageSamples = append(usageSamples, sample)
+				}
+				timestamp := now.Format("15:04:05")
 				logLine := parseStreamJSONLine(line, timestamp)
 				if logLine != "" {
 					mu.Lock()
+					authFailed = authFailed || isAuthFailureOutput(line)
 					//nolint:errcheck // Best effort logging
 					logFile.WriteString(logLine)
 					logFile.Sync() // Flush to disk for real-time visibility
 					mu.Unlock()
 					outputBuffer.WriteString(line + "\n")
 				}
+			})
+			if err != nil {
+				log.Warn().Err(err).Str("log_path", logPath).Msg("failed to read claude output")
 			}
 		}
 
 		// captureStderr handles stderr output (errors, warnings)
 		captureStderr := func(reader io.Reader) {
 			defer wg.Done()
-			scanner := bufio.NewScanner(reader)
-			buf := make([]byte, 0, 64*1024)
-			scanner.Buffer(buf, 1024*1024)
-			for scanner.Scan() {
-				line := scanner.Text()
+			err := readLines(reader, func(line string) {
 				timestamp := time.Now().Format("15:04:05")
 				logLine := fmt.Sprintf("[%s] [STDERR] %s\n", timestamp, line)
 				mu.Lock()
+				authFailed = authFailed || isAuthFailure(line)
 				//nolint:errcheck // Best effort logging
 				logFile.WriteString(logLine)
 				logFile.Sync()
 				mu.Unlock()
+			})
+			if err != nil {
+				log.Warn().Err(err).Str("log_path", logPath).Msg("failed to read claude stderr")
 			}
 		}
 
@@ -202,29 +284,66 @@ func (e *Executor) ExecuteClaudeAsync(ctx context.Context, workDir, promptPath,
 		}
 
 		// Write footer to log file
-		footer := fmt.Sprintf("\n=== Run Complete ===\nDuration: %s\nExit Code: %d\n",
-			duration.String(), exitCode)
+		footer := fmt.Sprintf("\n%s\nDuration: %s\nExit Code: %d\n",
+			service.RunCompleteLine(completionToken), duration.String(), exitCode)
 		//nolint:errcheck // Best effort logging
 		logFile.WriteString(footer)
 
 		// Parse token usage from output
 		tokenUsage := parseTokenUsage(outputBuffer.String())
+		if len(usageSamples) > 0 {
+			tokenUsage = 0
+			for _, sample := range usageSamples {
+				tokenUsage += sample.Total()
+			}
+		}
+
+		// A CLI that cannot log in fails every attempt the same way
+		if cmdErr != nil && authFailed {
+			cmdErr = fmt.Errorf("%w: %w", ErrClaudeAuthFailed, cmdErr)
+		}
 
 		resultChan <- &ExecutionResult{
-			ExitCode:   exitCode,
-			LogPath:    logPath,
-			TokenUsage: tokenUsage,
-			Duration:   duration,
-			Error:      cmdErr,
+			ExitCode:     exitCode,
+			LogPath:      logPath,
+			TokenUsage:   tokenUsage,
+			UsageSamples: usageSamples,
+			Duration:     duration,
+			Error:        cmdErr,
 		}
 	}()
 
 	return &ClaudeRun{
-		LogPath:    logPath,
-		resultChan: resultChan,
+		LogPath:         logPath,
+		CompletionToken: completionToken,
+		resultChan:      resultChan,
 	}, nil
 }
 
+// largeStreamEventBytes is the size above which a stream-json event from the
+// Claude CLI is logged as oversized. Such events are still read and parsed.
+const largeStreamEventBytes = 4 * 1024 * 1024
+
+// readLines calls fn with each line read from r, without its line ending,
+// however long the line is. It reads until EOF and returns any other read
+// error.
+func readLines(r io.Reader, fn func(line string)) error {
+	reader := bufio.NewReaderSize(r, 256*1024)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			line = strings.TrimSuffix(line, "\n")
+			fn(strings.TrimSuffix(line, "\r"))
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
 // ExecuteClaude runs the Claude CLI with the given prompt file.
 // It captures stdout/stderr to a log file and returns the execution result.
 // This is a blocking call - use ExecuteClaudeAsync for non-blocking execution.
@@ -236,12 +355,32 @@ func (e *Executor) ExecuteClaude(ctx context.Context, workDir, promptPath, proje
 	return run.Wait(), nil
 }
 
+// readPrompt reads a prompt file, reading at most one byte past the limit so
+// an oversized prompt is rejected without loading all of it.
+func (e *Executor) readPrompt(promptPath string) ([]byte, error) {
+	f, err := os.Open(promptPath) //nolint:gosec // promptPath is constructed by our code
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if e.maxPromptBytes > 0 {
+		r = io.LimitReader(f, e.maxPromptBytes+1)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file: %w", err)
+	}
+	if e.maxPromptBytes > 0 && int64(len(content)) > e.maxPromptBytes {
+		return nil, fmt.Errorf("%w: %s exceeds %d bytes", ErrPromptTooLarge, promptPath, e.maxPromptBytes)
+	}
+	return content, nil
+}
+
 // getLogDir returns the log directory path for a subtask.
 func (e *Executor) getLogDir(projectID, taskID, subtaskID string) string {
-	if subtaskID != "" {
-		return filepath.Join(e.dataDir, "logs", projectID, taskID, subtaskID)
-	}
-	return filepath.Join(e.dataDir, "logs", projectID, taskID)
+	return e.paths.Logs(projectID, taskID, subtaskID)
 }
 
 // GetLogPath returns the expected log file path for a run.
@@ -250,6 +389,63 @@ func (e *Executor) GetLogPath(projectID, taskID, subtaskID string, attemptNumber
 	return filepath.Join(logDir, fmt.Sprintf("run-%03d.log", attemptNumber))
 }
 
+// attemptLogPattern matches the log file names written by ExecuteClaudeAsync.
+var attemptLogPattern = regexp.MustCompile(`^run-(\d+)\.log$`)
+
+// pruneAttemptLogs removes the oldest attempt logs in logDir beyond
+// maxAttemptLogs, counting the new log at current. Logs still being written
+// by a running Claude process are never removed. Failures are logged, since a
+// leftover log must not stop the attempt.
+func (e *Executor) pruneAttemptLogs(logDir, current string) {
+	if e.maxAttemptLogs <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		log.Warn().Err(err).Str("log_dir", logDir).Msg("failed to list attempt logs for pruning")
+		return
+	}
+
+	type attemptLog struct {
+		path    string
+		attempt int
+	}
+	var logs []attemptLog
+	for _, entry := range entries {
+		match := attemptLogPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		attempt, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		logs = append(logs, attemptLog{path: filepath.Join(logDir, entry.Name()), attempt: attempt})
+	}
+	if len(logs) <= e.maxAttemptLogs {
+		return
+	}
+
+	// Newest attempts first; everything past the limit goes
+	slices.SortFunc(logs, func(a, b attemptLog) int { return cmp.Compare(b.attempt, a.attempt) })
+	for _, l := range logs[e.maxAttemptLogs:] {
+		if l.path == current {
+			continue
+		}
+		if _, ok := e.running.Load(l.path); ok {
+			continue
+		}
+		if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Warn().Err(err).Str("log_path", l.path).Msg("failed to prune attempt log")
+		}
+	}
+}
+
+// PlannerWorktree returns the worktree a task's Planner runs in.
+func (e *Executor) PlannerWorktree(projectID, taskID string) string {
+	return e.paths.PlannerWorktree(projectID, taskID)
+}
+
 // ReadLogFile reads the content of a log file.
 func (e *Executor) ReadLogFile(logPath string) (string, error) {
 	content, err := os.ReadFile(logPath) //nolint:gosec // logPath is validated
@@ -320,42 +516,7 @@ func parseStreamJSONLine(line, timestamp string) string {
 	case "tool_use":
 		// Log tool usage
 		if event.ToolName != "" {
-			switch event.ToolName {
-			case "Read":
-				if path, ok := event.Input["file_path"].(string); ok {
-					return fmt.Sprintf("[%s] 📖 Reading: %s\n", timestamp, path)
-				}
-			case "Edit":
-				if path, ok := event.Input["file_path"].(string); ok {
-					return fmt.Sprintf("[%s] ✏️  Editing: %s\n", timestamp, path)
-				}
-			case "Write":
-				if path, ok := event.Input["file_path"].(string); ok {
-					return fmt.Sprintf("[%s] 📝 Writing: %s\n", timestamp, path)
-				}
-			case "Bash":
-				if cmd, ok := event.Input["command"].(string); ok {
-					// Truncate long commands
-					if len(cmd) > 100 {
-						cmd = cmd[:100] + "..."
-					}
-					return fmt.Sprintf("[%s] 💻 Running: %s\n", timestamp, cmd)
-				}
-			case "Glob":
-				if pattern, ok := event.Input["pattern"].(string); ok {
-					return fmt.Sprintf("[%s] 🔍 Searching: %s\n", timestamp, pattern)
-				}
-			case "Grep":
-				if pattern, ok := event.Input["pattern"].(string); ok {
-					return fmt.Sprintf("[%s] 🔎 Grepping: %s\n", timestamp, pattern)
-				}
-			case "Task":
-				if desc, ok := event.Input["description"].(string); ok {
-					return fmt.Sprintf("[%s] 🤖 Spawning agent: %s\n", timestamp, desc)
-				}
-			default:
-				return fmt.Sprintf("[%s] 🔧 Using tool: %s\n", timestamp, event.ToolName)
-			}
+			return fmt.Sprintf("[%s] %s\n", timestamp, formatToolUse(event.ToolName, event.Input))
 		}
 		return ""
 
@@ -374,6 +535,20 @@ func parseStreamJSONLine(line, timestamp string) string {
 	}
 }
 
+// parseUsageSample returns the token usage of a stream-json result event
+// received at the given time. ok is false for other lines and for result
+// events without usage.
+func parseUsageSample(line string, at time.Time) (sample UsageSample, ok bool) {
+	var event streamEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type != "result" {
+		return UsageSample{}, false
+	}
+	if event.Usage.InputTokens <= 0 && event.Usage.OutputTokens <= 0 {
+		return UsageSample{}, false
+	}
+	return UsageSample{At: at, InputTokens: event.Usage.InputTokens, OutputTokens: event.Usage.OutputTokens}, true
+}
+
 // parseTokenUsage attempts to parse token usage from Claude CLI stream-json output.
 // It looks for the "result" event which contains usage information.
 func parseTokenUsage(output string) int {
@@ -4,8 +4,16 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/intern-village/orchestrator/internal/config"
 )
 
 func TestParseTokenUsage(t *testing.T) {
@@ -66,6 +74,35 @@ func TestParseTokenUsage(t *testing.T) {
 	}
 }
 
+func TestParseUsageSample(t *testing.T) {
+	at := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		line   string
+		want   UsageSample
+		wantOK bool
+	}{
+		{
+			name:   "result event",
+			line:   `{"type":"result","subtype":"success","usage":{"input_tokens":500,"output_tokens":42}}`,
+			want:   UsageSample{At: at, InputTokens: 500, OutputTokens: 42},
+			wantOK: true,
+		},
+		{name: "result without usage", line: `{"type":"result","subtype":"error","result":"boom"}`},
+		{name: "other event", line: `{"type":"assistant","usage":{"input_tokens":500,"output_tokens":42}}`},
+		{name: "not JSON", line: "Total tokens: 1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseUsageSample(tt.line, at)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseUsageSample() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestCalculateBackoff(t *testing.T) {
 	tests := []struct {
 		attempt  int
@@ -92,7 +129,7 @@ func TestCalculateBackoff(t *testing.T) {
 }
 
 func TestExecutor_GetLogPath(t *testing.T) {
-	executor := NewExecutor("/data")
+	executor := NewExecutor(config.NewDataPaths("/data", ""))
 
 	tests := []struct {
 		name          string
@@ -137,3 +174,168 @@ func TestExecutor_GetLogPath(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutor_RejectsOversizedPrompt(t *testing.T) {
+	// Would fail differently if the executor got as far as starting claude
+	t.Setenv("PATH", t.TempDir())
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	executor := NewExecutor(paths)
+	executor.SetMaxPromptBytes(1024)
+
+	promptPath := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(promptPath, []byte(strings.Repeat("x", 1025)), 0o600); err != nil {
+		t.Fatalf("failed to write prompt: %v", err)
+	}
+
+	_, err := executor.ExecuteClaudeAsync(context.Background(), t.TempDir(), promptPath, "p", "t", "s", 1)
+	if !errors.Is(err, ErrPromptTooLarge) {
+		t.Fatalf("ExecuteClaudeAsync() error = %v, want ErrPromptTooLarge", err)
+	}
+	if ClassifyFailure(err) != FailurePermanent {
+		t.Error("oversized prompt should not be retried")
+	}
+
+	logContent, err := os.ReadFile(executor.GetLogPath("p", "t", "s", 1))
+	if err != nil {
+		t.Fatalf("failed to read run log: %v", err)
+	}
+	if !strings.Contains(string(logContent), "prompt too large") {
+		t.Errorf("run log = %q, want the prompt size error", logContent)
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("x", 2*largeStreamEventBytes)
+	var got []string
+	err := readLines(strings.NewReader("first\r\n"+long+"\n\nlast"), func(line string) {
+		got = append(got, line)
+	})
+	if err != nil {
+		t.Fatalf("readLines() error = %v", err)
+	}
+	want := []string{"first", long, "", "last"}
+	if len(got) != len(want) {
+		t.Fatalf("readLines() read %d lines, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d has %d bytes, want %d", i, len(got[i]), len(want[i]))
+		}
+	}
+}
+
+func TestExecutor_OversizedStreamEvent(t *testing.T) {
+	// A fake claude whose tool result is far larger than any line buffer,
+	// followed by the result event carrying the token usage
+	bin := t.TempDir()
+	script := fmt.Sprintf(`#!/bin/sh
+cat >/dev/null
+printf '{"type":"user","message":{"content":[{"type":"text","text":"'
+head -c %d /dev/zero | tr '\0' x
+printf '"}]}}\n'
+printf '{"type":"result","subtype":"success","usage":{"input_tokens":100,"output_tokens":23}}\n'
+`, 2*largeStreamEventBytes)
+	start := time.Now()
+	if err := os.WriteFile(filepath.Join(bin, "claude"), []byte(script), 0o755); err != nil { //nolint:gosec // test binary must be executable
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	executor := NewExecutor(config.NewDataPaths(t.TempDir(), ""))
+	promptPath := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(promptPath, []byte("do it"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := executor.ExecuteClaude(context.Background(), t.TempDir(), promptPath, "p", "t", "s", 1)
+	if err != nil {
+		t.Fatalf("ExecuteClaude() error = %v", err)
+	}
+	if result.Error != nil {
+		t.Fatalf("run error = %v", result.Error)
+	}
+	if result.TokenUsage != 123 {
+		t.Errorf("TokenUsage = %d, want 123 from the result event after the oversized one", result.TokenUsage)
+	}
+	if len(result.UsageSamples) != 1 {
+		t.Fatalf("UsageSamples = %+v, want one for the result event", result.UsageSamples)
+	}
+	if sample := result.UsageSamples[0]; sample.InputTokens != 100 || sample.OutputTokens != 23 || sample.At.Before(start) {
+		t.Errorf("usage sample = %+v, want 100 input and 23 output tokens at or after %v", sample, start)
+	}
+	logContent, err := os.ReadFile(result.LogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logContent), "Task completed successfully") {
+		t.Errorf("run log is missing the result event:\n%s", logContent)
+	}
+}
+
+func TestExecutor_PruneAttemptLogs(t *testing.T) {
+	logDir := t.TempDir()
+	for _, name := range []string{"run-001.log", "run-002.log", "run-003.log", "run-004.log", "run-005.log", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(logDir, name), []byte("log"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	executor := NewExecutor(config.NewDataPaths(t.TempDir(), ""))
+	executor.SetMaxAttemptLogs(2)
+	// An older attempt whose process is still writing its log
+	executor.running.Store(filepath.Join(logDir, "run-002.log"), struct{}{})
+
+	executor.pruneAttemptLogs(logDir, filepath.Join(logDir, "run-005.log"))
+
+	want := map[string]bool{
+		"run-001.log": false,
+		"run-002.log": true, // running
+		"run-003.log": false,
+		"run-004.log": true,
+		"run-005.log": true,
+		"notes.txt":   true, // not an attempt log
+	}
+	for name, kept := range want {
+		_, err := os.Stat(filepath.Join(logDir, name))
+		if exists := err == nil; exists != kept {
+			t.Errorf("%s exists = %v, want %v", name, exists, kept)
+		}
+	}
+}
+
+func TestExecutor_PruneAttemptLogs_Unlimited(t *testing.T) {
+	logDir := t.TempDir()
+	for i := 1; i <= 3; i++ {
+		if err := os.WriteFile(filepath.Join(logDir, fmt.Sprintf("run-%03d.log", i)), []byte("log"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	executor := NewExecutor(config.NewDataPaths(t.TempDir(), ""))
+	executor.pruneAttemptLogs(logDir, filepath.Join(logDir, "run-003.log"))
+
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("%d logs left, want all 3 kept without a limit", len(entries))
+	}
+}
+
+func TestExecutor_ReadPrompt(t *testing.T) {
+	promptPath := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(promptPath, []byte(strings.Repeat("x", 1024)), 0o600); err != nil {
+		t.Fatalf("failed to write prompt: %v", err)
+	}
+
+	executor := NewExecutor(config.NewDataPaths(t.TempDir(), ""))
+	for _, limit := range []int64{0, 1024} {
+		executor.SetMaxPromptBytes(limit)
+		content, err := executor.readPrompt(promptPath)
+		if err != nil || len(content) != 1024 {
+			t.Errorf("readPrompt() with limit %d = %d bytes, %v; want the whole prompt", limit, len(content), err)
+		}
+	}
+}
@@ -5,8 +5,11 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand/v2"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,7 +18,9 @@ import (
 
 	"github.com/intern-village/orchestrator/generated/db"
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/metrics"
 	"github.com/intern-village/orchestrator/internal/repository"
+	"github.com/intern-village/orchestrator/internal/service"
 )
 
 // uuidToPgtype converts a google/uuid.UUID to pgtype.UUID
@@ -44,11 +49,25 @@ type LoopServices struct {
 	LogTailer      LogTailerInterface
 }
 
+// TokenSource returns the user's current GitHub token. Agents call it when
+// they need the token rather than holding one from spawn time, so a token the
+// user reconnects during a long run is the one used at the end.
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken is a TokenSource that always returns token.
+func StaticToken(token string) TokenSource {
+	return func(context.Context) (string, error) { return token, nil }
+}
+
 // BeadsServiceInterface defines the beads service methods used by the agent loop.
 type BeadsServiceInterface interface {
 	ShowIssue(ctx context.Context, repoPath, issueID string) (*BeadsIssue, error)
 	CloseIssue(ctx context.Context, repoPath, issueID, reason string) error
 	FindEpicByTaskID(ctx context.Context, repoPath, taskIDPrefix string) (*BeadsIssue, error)
+	CreateWorktree(ctx context.Context, repoPath, worktreePath, branch string) error
+	RemoveWorktree(ctx context.Context, repoPath, worktreePath string) error
+	PruneWorktrees(ctx context.Context, repoPath string) error
+	DeleteBranch(ctx context.Context, repoPath, branch string) error
 }
 
 // BeadsDependency represents a dependency relationship from Beads.
@@ -85,6 +104,8 @@ type GitHubServiceInterface interface {
 	PushBranch(ctx context.Context, repoPath, branch string) error
 	CreatePR(ctx context.Context, owner, repo, accessToken, head, base, title, body string) (*PRInfo, error)
 	GetCommitMessages(ctx context.Context, repoPath, baseBranch string) ([]string, error)
+	SquashCommits(ctx context.Context, repoPath, baseBranch, message string) (bool, error)
+	GetChangedFiles(ctx context.Context, repoPath, baseBranch string) ([]ChangedFile, error)
 }
 
 // PRInfo contains pull request information.
@@ -94,24 +115,41 @@ type PRInfo struct {
 	HTMLURL string
 }
 
+// ChangedFile describes a file changed on a worker branch.
+type ChangedFile struct {
+	Path      string
+	Status    string
+	Additions int
+	Deletions int
+	Binary    bool
+}
+
 // SyncServiceInterface defines the sync service methods used by the agent loop.
 type SyncServiceInterface interface {
 	SyncTaskFromBeads(ctx context.Context, taskID uuid.UUID, repoPath string) error
+	SyncNewIssuesFromBeads(ctx context.Context, taskID uuid.UUID, repoPath string) ([]*domain.Subtask, error)
 }
 
 // TaskServiceInterface defines the task service methods used by the agent loop.
 type TaskServiceInterface interface {
 	TransitionToActive(ctx context.Context, taskID uuid.UUID) error
+	TransitionToAwaitingApproval(ctx context.Context, taskID uuid.UUID) error
 	MarkPlanningFailed(ctx context.Context, taskID uuid.UUID) error
+	CompleteEmptyPlan(ctx context.Context, taskID uuid.UUID) error
 	UpdateBeadsEpicID(ctx context.Context, taskID uuid.UUID, epicID string) error
 }
 
 // SubtaskServiceInterface defines the subtask service methods used by the agent loop.
 type SubtaskServiceInterface interface {
 	MarkCompleted(ctx context.Context, subtaskID uuid.UUID, prURL string, prNumber int) error
+	MarkCompletedNoChanges(ctx context.Context, subtaskID uuid.UUID) error
 	MarkFailed(ctx context.Context, subtaskID uuid.UUID) error
+	MarkBudgetExceeded(ctx context.Context, subtaskID uuid.UUID) error
+	MarkPolicyViolation(ctx context.Context, subtaskID uuid.UUID) error
 	IncrementRetryCount(ctx context.Context, subtaskID uuid.UUID) (int, error)
-	UpdateTokenUsage(ctx context.Context, subtaskID uuid.UUID, tokens int) error
+	DecrementRetryCount(ctx context.Context, subtaskID uuid.UUID) (int, error)
+	SetNextAttemptAt(ctx context.Context, subtaskID uuid.UUID, at *time.Time) error
+	UpdateTokenUsage(ctx context.Context, subtaskID uuid.UUID, tokens int) (*domain.Subtask, error)
 }
 
 // EventPublisherInterface defines the event publishing methods used by the agent loop.
@@ -119,25 +157,52 @@ type EventPublisherInterface interface {
 	PublishAgentStarted(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID)
 	PublishAgentCompleted(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, prURL string)
 	PublishAgentFailed(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, errMsg string, willRetry bool, nextAttemptAt *time.Time)
+	PublishAgentStalled(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, lastOutputAt time.Time, cancelled bool)
+	PublishSubtaskCreated(projectID uuid.UUID, subtask *domain.Subtask)
+	PublishReauthRequired(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string)
 }
 
 // LogTailerInterface defines the log tailing methods used by the agent loop.
 type LogTailerInterface interface {
-	StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath string) error
+	StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath, token string) error
 	StopTailing(runID uuid.UUID)
 }
 
+// ExecutorInterface defines the Claude CLI execution methods used by the agent
+// loop. Executor implements it; tests substitute scripted results.
+type ExecutorInterface interface {
+	ExecuteClaudeAsync(ctx context.Context, workDir, promptPath, projectID, taskID, subtaskID string, attemptNumber int) (*ClaudeRun, error)
+	GetLogPath(projectID, taskID, subtaskID string, attemptNumber int) string
+	PlannerWorktree(projectID, taskID string) string
+}
+
+var _ ExecutorInterface = (*Executor)(nil)
+
 // AgentLoop manages the loop-until-done execution pattern for agents.
 type AgentLoop struct {
-	executor       *Executor
+	executor       ExecutorInterface
 	promptRenderer *PromptRenderer
 	services       LoopServices
 	maxRetries     int
+	jitter         Jitter
+	metrics        *metrics.Metrics
+	wait           func(ctx context.Context, d time.Duration) // retry backoff; replaced in tests
+	// completeEmptyPlans finishes a task whose Planner created no subtasks
+	// as DONE instead of failing its planning
+	completeEmptyPlans bool
+	// diskSpace refuses agent runs while the data directory is low on space
+	diskSpace *service.DiskSpaceGuard
+	// trailerKeys name the trailers linking Worker PRs to their subtask
+	trailerKeys TrailerKeys
+	// stallTimeout is how long a Worker's log may go unwritten before it is
+	// reported stalled (0 disables the check); retryStalled also cancels it
+	stallTimeout time.Duration
+	retryStalled bool
 }
 
 // NewAgentLoop creates a new AgentLoop.
 func NewAgentLoop(
-	executor *Executor,
+	executor ExecutorInterface,
 	promptRenderer *PromptRenderer,
 	services LoopServices,
 	maxRetries int,
@@ -147,16 +212,72 @@ func NewAgentLoop(
 		promptRenderer: promptRenderer,
 		services:       services,
 		maxRetries:     maxRetries,
+		jitter:         RandomJitter,
+		wait:           wait,
+		trailerKeys:    DefaultTrailerKeys,
+	}
+}
+
+// SetMetrics sets the metrics recorder for agent run outcomes.
+func (l *AgentLoop) SetMetrics(m *metrics.Metrics) {
+	l.metrics = m
+}
+
+// SetJitter replaces the jitter added to retry backoffs (RandomJitter by
+// default). A nil jitter is NoJitter.
+func (l *AgentLoop) SetJitter(j Jitter) {
+	if j == nil {
+		j = NoJitter
 	}
+	l.jitter = j
+}
+
+// SetCompleteEmptyPlans decides what happens to a task whose Planner exits
+// successfully without creating any subtasks. By default its planning fails
+// (PLANNING_FAILED) so it can be re-planned; with complete set the task is
+// DONE.
+func (l *AgentLoop) SetCompleteEmptyPlans(complete bool) {
+	l.completeEmptyPlans = complete
+}
+
+// SetDiskSpaceGuard makes agents fail before each run, rather than partway
+// through it, while the data directory is below the guard's minimum.
+func (l *AgentLoop) SetDiskSpaceGuard(guard *service.DiskSpaceGuard) {
+	l.diskSpace = guard
+}
+
+// SetTrailerKeys sets the keys of the trailers that end Worker PR bodies and
+// squashed commit messages (see TrailerKeys).
+func (l *AgentLoop) SetTrailerKeys(keys TrailerKeys) {
+	l.trailerKeys = keys
+}
+
+// SetStallTimeout makes Worker attempts whose log has not been written for
+// timeout publish agent:stalled. With retry set the attempt is also cancelled
+// and, if attempts remain, retried like any other failed attempt. A timeout of
+// 0 disables the check.
+func (l *AgentLoop) SetStallTimeout(timeout time.Duration, retry bool) {
+	l.stallTimeout = timeout
+	l.retryStalled = retry
 }
 
 // RunPlannerLoop runs the Planner agent once.
-// The Planner runs in the main clone directory (not a worktree).
+// The Planner runs in a worktree of its own, removed when it finishes, so
+// syncs of the main clone for other tasks and concurrent Planners on the same
+// project do not change the files under it. Beads issues are shared with the
+// clone, where they are read back.
 // NOTE: Simplified - no retry loop, just run once and mark complete on exit code 0.
-func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, project *domain.Project, userToken string) error {
+//
+// A task that is already ACTIVE is being re-planned (see
+// TaskService.ReplanTask): the Planner is shown the existing subtasks, only
+// the issues it adds are synced, and the task's status is left alone.
+func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, project *domain.Project, token TokenSource) error {
+	replan := task.Status == domain.TaskStatusActive
+
 	log.Info().
 		Str("task_id", task.ID.String()).
 		Str("project_id", project.ID.String()).
+		Bool("replan", replan).
 		Msg("starting planner")
 
 	select {
@@ -165,10 +286,45 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 	default:
 	}
 
+	if err := l.diskSpace.Check(); err != nil {
+		l.publishRunRefused(project.ID, task.ID, nil, domain.AgentTypePlanner, err)
+		if !replan {
+			if markErr := l.services.TaskService.MarkPlanningFailed(ctx, task.ID); markErr != nil {
+				log.Error().Err(markErr).Msg("failed to mark task planning as failed")
+			}
+		}
+		return err
+	}
+
 	attempt := 1
 
+	workDir, err := l.createPlannerWorktree(ctx, project, task.ID)
+	if err != nil {
+		if !replan {
+			if markErr := l.services.TaskService.MarkPlanningFailed(ctx, task.ID); markErr != nil {
+				log.Error().Err(markErr).Msg("failed to mark task planning as failed")
+			}
+		}
+		return err
+	}
+	defer l.removePlannerWorktree(context.WithoutCancel(ctx), project.ClonePath, workDir, plannerBranch(task.ID))
+
+	// The prompt points the Planner at its worktree rather than the clone
+	planProject := *project
+	planProject.ClonePath = workDir
+
 	// Render prompt
-	promptContent, err := l.promptRenderer.RenderPlannerPrompt(task, project)
+	var promptContent string
+	if replan {
+		var existing []PlannedSubtask
+		existing, err = l.plannedSubtasks(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		promptContent, err = l.promptRenderer.RenderReplanPrompt(task, &planProject, existing)
+	} else {
+		promptContent, err = l.promptRenderer.RenderPlannerPrompt(task, &planProject)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to render planner prompt: %w", err)
 	}
@@ -214,7 +370,7 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 	// Start Claude asynchronously (creates log file immediately)
 	claudeRun, err := l.executor.ExecuteClaudeAsync(
 		ctx,
-		project.ClonePath,
+		workDir,
 		promptPath,
 		project.ID.String(),
 		task.ID.String(),
@@ -229,7 +385,7 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 	// Start log tailing now that log file exists
 	if l.services.LogTailer != nil {
 		go func() {
-			if err := l.services.LogTailer.StartTailing(ctx, project.ID, agentRun.ID, claudeRun.LogPath); err != nil {
+			if err := l.services.LogTailer.StartTailing(ctx, project.ID, agentRun.ID, claudeRun.LogPath, claudeRun.CompletionToken); err != nil {
 				log.Warn().Err(err).Str("run_id", agentRun.ID.String()).Msg("failed to start log tailing for planner")
 			}
 		}()
@@ -237,6 +393,7 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 
 	// Wait for Claude to complete
 	result := claudeRun.Wait()
+	l.observeRun(ctx, domain.AgentTypePlanner, result)
 
 	// Stop log tailing
 	if l.services.LogTailer != nil {
@@ -250,15 +407,18 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 
 	// Update agent run with token usage
 	if result.TokenUsage > 0 {
-		//nolint:gosec // TokenUsage is always positive and bounded
-		_, _ = l.services.Repo.UpdateAgentRunTokenUsage(ctx, db.UpdateAgentRunTokenUsageParams{
-			ID:         agentRun.ID,
-			TokenUsage: &[]int32{int32(result.TokenUsage)}[0],
-		})
+		l.recordRunTokenUsage(ctx, agentRun.ID, result)
+	}
+
+	if replan {
+		return l.finishReplan(ctx, task, project, agentRun, result)
 	}
 
 	// Simplified: if exit code 0, consider planner successful
 	if result.ExitCode == 0 {
+		// A plan the sync rejects (e.g. over the subtask limit) fails planning
+		var rejectedPlan error
+
 		// Find the epic created by the Planner using the task ID prefix
 		// Epic titles are formatted as "[{taskID_prefix}] {title}" by the planner
 		taskIDPrefix := task.ID.String()[:8]
@@ -275,9 +435,17 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 					Str("epic_id", epic.ID).
 					Msg("found and stored epic ID")
 
-				// Sync subtasks from Beads to Postgres
-				if err := l.services.SyncService.SyncTaskFromBeads(ctx, task.ID, project.ClonePath); err != nil {
+				// Sync subtasks from Beads to Postgres. Dry-run plans are only
+				// materialized once the user confirms them.
+				if task.DryRun {
+					log.Info().
+						Str("task_id", task.ID.String()).
+						Msg("dry run: holding plan for review")
+				} else if err := l.services.SyncService.SyncTaskFromBeads(ctx, task.ID, project.ClonePath); err != nil {
 					log.Error().Err(err).Msg("failed to sync subtasks from beads")
+					if domain.IsUnprocessable(err) {
+						rejectedPlan = err
+					}
 				} else {
 					log.Info().
 						Str("task_id", task.ID.String()).
@@ -288,17 +456,101 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 			log.Warn().
 				Str("task_id", task.ID.String()).
 				Str("task_id_prefix", taskIDPrefix).
-				Msg("no epic found with task ID prefix - subtasks may not appear")
+				Msg("no epic found with task ID prefix - planner created no subtasks")
+			rejectedPlan = service.EmptyPlanError()
+		}
+
+		if errors.Is(rejectedPlan, service.ErrEmptyPlan) && l.completeEmptyPlans {
+			return l.completeEmptyPlan(ctx, project.ID, task.ID, agentRun, result.TokenUsage)
+		}
+		if rejectedPlan != nil {
+			return l.failRejectedPlan(ctx, project.ID, task.ID, agentRun, rejectedPlan)
 		}
 
-		// Transition task to ACTIVE
-		if err := l.services.TaskService.TransitionToActive(ctx, task.ID); err != nil {
+		// Transition task to ACTIVE, or AWAITING_APPROVAL for a dry run
+		if task.DryRun {
+			if err := l.services.TaskService.TransitionToAwaitingApproval(ctx, task.ID); err != nil {
+				log.Error().Err(err).Msg("failed to transition task to AWAITING_APPROVAL")
+			}
+		} else if err := l.services.TaskService.TransitionToActive(ctx, task.ID); err != nil {
 			log.Error().Err(err).Msg("failed to transition task to ACTIVE")
 		}
 
 		l.markAgentRunSucceeded(ctx, agentRun.ID)
+		l.publishPlannerCompleted(project.ID, task.ID, agentRun, result.TokenUsage)
+
+		log.Info().
+			Str("task_id", task.ID.String()).
+			Msg("planner completed successfully")
+		return nil
+	}
+
+	// Planner failed
+	l.markAgentRunFailed(ctx, agentRun.ID, fmt.Sprintf("exit code: %d", result.ExitCode))
+	if err := l.services.TaskService.MarkPlanningFailed(ctx, task.ID); err != nil {
+		log.Error().Err(err).Msg("failed to mark task planning as failed")
+	}
+
+	return fmt.Errorf("planner failed with exit code: %d", result.ExitCode)
+}
+
+// plannerBranch returns the local branch of a task's Planner worktree. The
+// Planner does not commit, so the branch is deleted with the worktree.
+func plannerBranch(taskID uuid.UUID) string {
+	return "iv-planner-" + taskID.String()[:8]
+}
+
+// createPlannerWorktree creates the worktree a task's Planner runs in, branched
+// from the clone's HEAD, which the sync before planning left on the task's base
+// branch. A worktree left behind by an earlier run is removed first.
+func (l *AgentLoop) createPlannerWorktree(ctx context.Context, project *domain.Project, taskID uuid.UUID) (string, error) {
+	path := l.executor.PlannerWorktree(project.ID.String(), taskID.String())
+	branch := plannerBranch(taskID)
+
+	l.removePlannerWorktree(ctx, project.ClonePath, path, branch)
+	if err := l.services.BeadsService.CreateWorktree(ctx, project.ClonePath, path, branch); err != nil {
+		return "", fmt.Errorf("failed to create planner worktree: %w", err)
+	}
+	return path, nil
+}
+
+// removePlannerWorktree removes a Planner worktree and its branch, if present.
+func (l *AgentLoop) removePlannerWorktree(ctx context.Context, clonePath, path, branch string) {
+	if _, err := os.Stat(path); err == nil {
+		if err := l.services.BeadsService.RemoveWorktree(ctx, clonePath, path); err != nil {
+			// The Planner may have left changes that block removal; it does
+			// not commit, so nothing in the worktree is worth keeping
+			log.Warn().Err(err).Str("worktree_path", path).Msg("failed to remove planner worktree, deleting it")
+			if err := os.RemoveAll(path); err != nil {
+				log.Error().Err(err).Str("worktree_path", path).Msg("failed to delete planner worktree")
+				return
+			}
+			if err := l.services.BeadsService.PruneWorktrees(ctx, clonePath); err != nil {
+				log.Warn().Err(err).Str("clone_path", clonePath).Msg("failed to prune worktrees")
+			}
+		}
+	}
+	// Fails harmlessly when there is no branch to delete
+	if err := l.services.BeadsService.DeleteBranch(ctx, clonePath, branch); err != nil {
+		log.Debug().Err(err).Str("branch", branch).Msg("did not delete planner branch")
+	}
+}
+
+// finishReplan records the outcome of a re-planning Planner run. The issues
+// it added are synced and published as subtask:created; a failed run, or a
+// plan the sync rejects, is published as agent:failed. Either way the task
+// stays ACTIVE with its existing subtasks.
+func (l *AgentLoop) finishReplan(ctx context.Context, task *domain.Task, project *domain.Project, agentRun db.AgentRun, result *ExecutionResult) error {
+	var planErr error
+	var created []*domain.Subtask
+	if result.ExitCode != 0 {
+		planErr = fmt.Errorf("exit code: %d", result.ExitCode)
+	} else {
+		created, planErr = l.services.SyncService.SyncNewIssuesFromBeads(ctx, task.ID, project.ClonePath)
+	}
 
-		// Publish agent:completed event
+	if planErr != nil {
+		l.markAgentRunFailed(ctx, agentRun.ID, planErr.Error())
 		if l.services.EventPublisher != nil {
 			now := time.Now()
 			run := &domain.AgentRun{
@@ -306,44 +558,142 @@ func (l *AgentLoop) RunPlannerLoop(ctx context.Context, task *domain.Task, proje
 				TaskID:        &task.ID,
 				AgentType:     domain.AgentTypePlanner,
 				AttemptNumber: int(agentRun.AttemptNumber),
-				Status:        domain.AgentRunStatusSucceeded,
+				Status:        domain.AgentRunStatusFailed,
 				StartedAt:     agentRun.StartedAt,
 				EndedAt:       &now,
-				TokenUsage:    &result.TokenUsage,
 			}
-			l.services.EventPublisher.PublishAgentCompleted(project.ID, run, task.ID, "")
+			l.services.EventPublisher.PublishAgentFailed(project.ID, run, task.ID, planErr.Error(), false, nil)
 		}
+		return fmt.Errorf("re-planning failed: %w", planErr)
+	}
 
-		log.Info().
-			Str("task_id", task.ID.String()).
-			Msg("planner completed successfully")
-		return nil
+	if l.services.EventPublisher != nil {
+		for _, subtask := range created {
+			l.services.EventPublisher.PublishSubtaskCreated(project.ID, subtask)
+		}
 	}
 
-	// Planner failed
-	l.markAgentRunFailed(ctx, agentRun.ID, fmt.Sprintf("exit code: %d", result.ExitCode))
-	if err := l.services.TaskService.MarkPlanningFailed(ctx, task.ID); err != nil {
+	l.markAgentRunSucceeded(ctx, agentRun.ID)
+	l.publishPlannerCompleted(project.ID, task.ID, agentRun, result.TokenUsage)
+
+	log.Info().
+		Str("task_id", task.ID.String()).
+		Int("new_subtasks", len(created)).
+		Msg("re-planning completed successfully")
+	return nil
+}
+
+// plannedSubtasks lists a task's subtasks for the re-planning prompt.
+func (l *AgentLoop) plannedSubtasks(ctx context.Context, taskID uuid.UUID) ([]PlannedSubtask, error) {
+	subtasks, err := l.services.Repo.ListSubtasksByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subtasks: %w", err)
+	}
+	planned := make([]PlannedSubtask, len(subtasks))
+	for i, subtask := range subtasks {
+		planned[i] = PlannedSubtask{Title: subtask.Title, Status: subtask.Status}
+		if subtask.BeadsIssueID != nil {
+			planned[i].BeadsID = *subtask.BeadsIssueID
+		}
+	}
+	return planned, nil
+}
+
+// publishPlannerCompleted publishes agent:completed for a successful Planner run.
+func (l *AgentLoop) publishPlannerCompleted(projectID, taskID uuid.UUID, agentRun db.AgentRun, tokenUsage int) {
+	if l.services.EventPublisher == nil {
+		return
+	}
+	now := time.Now()
+	run := &domain.AgentRun{
+		ID:            agentRun.ID,
+		TaskID:        &taskID,
+		AgentType:     domain.AgentTypePlanner,
+		AttemptNumber: int(agentRun.AttemptNumber),
+		Status:        domain.AgentRunStatusSucceeded,
+		StartedAt:     agentRun.StartedAt,
+		EndedAt:       &now,
+		TokenUsage:    &tokenUsage,
+	}
+	l.services.EventPublisher.PublishAgentCompleted(projectID, run, taskID, "")
+}
+
+// failRejectedPlan fails a Planner run whose plan could not be synced as is,
+// leaving the task in PLANNING_FAILED so it can be re-planned.
+func (l *AgentLoop) failRejectedPlan(ctx context.Context, projectID, taskID uuid.UUID, agentRun db.AgentRun, planErr error) error {
+	errMsg := planErr.Error()
+	l.markAgentRunFailed(ctx, agentRun.ID, errMsg)
+
+	if l.services.EventPublisher != nil {
+		now := time.Now()
+		run := &domain.AgentRun{
+			ID:            agentRun.ID,
+			TaskID:        &taskID,
+			AgentType:     domain.AgentTypePlanner,
+			AttemptNumber: int(agentRun.AttemptNumber),
+			Status:        domain.AgentRunStatusFailed,
+			StartedAt:     agentRun.StartedAt,
+			EndedAt:       &now,
+			ErrorMessage:  &errMsg,
+		}
+		l.services.EventPublisher.PublishAgentFailed(projectID, run, taskID, errMsg, false, nil)
+	}
+
+	if err := l.services.TaskService.MarkPlanningFailed(ctx, taskID); err != nil {
 		log.Error().Err(err).Msg("failed to mark task planning as failed")
 	}
 
-	return fmt.Errorf("planner failed with exit code: %d", result.ExitCode)
+	return fmt.Errorf("planner produced an unusable plan: %w", planErr)
+}
+
+// completeEmptyPlan finishes a successful Planner run that created no
+// subtasks by moving its task straight to DONE.
+func (l *AgentLoop) completeEmptyPlan(ctx context.Context, projectID, taskID uuid.UUID, agentRun db.AgentRun, tokenUsage int) error {
+	if err := l.services.TaskService.CompleteEmptyPlan(ctx, taskID); err != nil {
+		log.Error().Err(err).Msg("failed to complete task with an empty plan")
+	}
+
+	l.markAgentRunSucceeded(ctx, agentRun.ID)
+	l.publishPlannerCompleted(projectID, taskID, agentRun, tokenUsage)
+
+	log.Info().
+		Str("task_id", taskID.String()).
+		Msg("planner created no subtasks, task is done")
+	return nil
 }
 
 // RunWorkerLoop runs the Worker agent loop.
 // The Worker runs in a dedicated worktree.
-func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask, project *domain.Project, userToken string) error {
+func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask, project *domain.Project, token TokenSource) error {
 	log.Info().
 		Str("subtask_id", subtask.ID.String()).
 		Str("project_id", project.ID.String()).
 		Msg("starting worker loop")
 
+	// Token usage so far across all attempts, checked against the budget
+	// after each attempt
+	usage, budget := subtask.TokenUsage, subtask.TokenBudget
+	if overBudget(usage, budget) {
+		return l.stopWorkerOverBudget(ctx, subtask.ID, 0, usage, *budget)
+	}
+
 	for attempt := 1; attempt <= l.maxRetries; attempt++ {
 		select {
 		case <-ctx.Done():
+			// Stopped between attempts, e.g. during a retry backoff
+			if errors.Is(context.Cause(ctx), ErrShutdown) {
+				l.interruptPendingWorker(ctx, subtask, project, attempt)
+			}
 			return ctx.Err()
 		default:
 		}
 
+		if err := l.diskSpace.Check(); err != nil {
+			l.publishRunRefused(project.ID, subtask.TaskID, &subtask.ID, domain.AgentTypeWorker, err)
+			l.markSubtaskFailed(ctx, subtask.ID)
+			return err
+		}
+
 		log.Info().
 			Str("subtask_id", subtask.ID.String()).
 			Int("attempt", attempt).
@@ -409,9 +759,12 @@ func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask,
 			l.services.EventPublisher.PublishAgentStarted(project.ID, run, subtask.TaskID)
 		}
 
-		// Start Claude asynchronously (creates log file immediately)
+		// Start Claude asynchronously (creates log file immediately). The
+		// attempt has a context of its own so a stalled run can be stopped
+		// without stopping the loop.
+		runCtx, stopRun := context.WithCancelCause(ctx)
 		claudeRun, err := l.executor.ExecuteClaudeAsync(
-			ctx,
+			runCtx,
 			workDir,
 			promptPath,
 			project.ID.String(),
@@ -420,9 +773,12 @@ func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask,
 			attempt,
 		)
 		if err != nil {
-			l.markAgentRunFailed(ctx, agentRun.ID, err.Error())
+			stopRun(nil)
+			if ClassifyFailure(err) == FailurePermanent {
+				return l.failWorkerPermanently(ctx, subtask.ID, agentRun.ID, attempt, err)
+			}
+			l.failWorkerAttempt(ctx, project.ID, subtask, agentRun, attempt, err.Error())
 			if attempt < l.maxRetries {
-				l.backoff(ctx, attempt)
 				continue
 			}
 			l.markSubtaskFailed(ctx, subtask.ID)
@@ -432,14 +788,19 @@ func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask,
 		// Start log tailing now that log file exists
 		if l.services.LogTailer != nil {
 			go func() {
-				if err := l.services.LogTailer.StartTailing(ctx, project.ID, agentRun.ID, claudeRun.LogPath); err != nil {
+				if err := l.services.LogTailer.StartTailing(ctx, project.ID, agentRun.ID, claudeRun.LogPath, claudeRun.CompletionToken); err != nil {
 					log.Warn().Err(err).Str("run_id", agentRun.ID.String()).Msg("failed to start log tailing for worker")
 				}
 			}()
 		}
 
+		l.watchWorkerStall(runCtx, stopRun, project.ID, subtask, agentRun, claudeRun.LogPath)
+
 		// Wait for Claude to complete
 		result := claudeRun.Wait()
+		stalled := errors.Is(context.Cause(runCtx), ErrAgentStalled)
+		stopRun(nil)
+		l.observeRun(ctx, domain.AgentTypeWorker, result)
 
 		// Stop log tailing for this attempt
 		if l.services.LogTailer != nil {
@@ -447,6 +808,15 @@ func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask,
 		}
 
 		if result.Error != nil && ctx.Err() != nil {
+			if errors.Is(context.Cause(ctx), ErrAgentKilled) {
+				// Stopped by the user, who records the run and subtask
+				log.Info().Str("subtask_id", subtask.ID.String()).Msg("worker killed")
+				return ctx.Err()
+			}
+			if errors.Is(context.Cause(ctx), ErrShutdown) {
+				l.interruptWorker(ctx, subtask.ID, agentRun.ID)
+				return ctx.Err()
+			}
 			// Context was canceled
 			l.markAgentRunFailed(ctx, agentRun.ID, result.Error.Error())
 			l.markSubtaskFailed(ctx, subtask.ID)
@@ -455,110 +825,55 @@ func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask,
 
 		// Update token usage
 		if result.TokenUsage > 0 {
-			if err := l.services.SubtaskService.UpdateTokenUsage(ctx, subtask.ID, result.TokenUsage); err != nil {
+			updated, err := l.services.SubtaskService.UpdateTokenUsage(ctx, subtask.ID, result.TokenUsage)
+			if err != nil {
 				log.Error().Err(err).Msg("failed to update subtask token usage")
+				usage += result.TokenUsage
+			} else {
+				// The stored budget may have been changed since the loop started
+				usage, budget = updated.TokenUsage, updated.TokenBudget
 			}
-			//nolint:gosec // TokenUsage is always positive and bounded
-			_, _ = l.services.Repo.UpdateAgentRunTokenUsage(ctx, db.UpdateAgentRunTokenUsageParams{
-				ID:         agentRun.ID,
-				TokenUsage: &[]int32{int32(result.TokenUsage)}[0],
-			})
-		}
-
-		// Check beads issue status
-		if subtask.BeadsIssueID != nil && *subtask.BeadsIssueID != "" {
-			issue, err := l.services.BeadsService.ShowIssue(ctx, project.ClonePath, *subtask.BeadsIssueID)
-			if err == nil && issue.Status == "closed" {
-				// Worker completed successfully
-				l.markAgentRunSucceeded(ctx, agentRun.ID)
-
-				// Push branch to remote
-				if subtask.BranchName != nil && *subtask.BranchName != "" {
-					if err := l.services.GitHubService.PushBranch(ctx, workDir, *subtask.BranchName); err != nil {
-						log.Error().Err(err).Msg("failed to push branch")
-						// Continue anyway - we'll handle PR creation failure
-					}
-
-					// Create PR
-					prTitle := fmt.Sprintf("[IV-%s] %s", subtask.ID.String()[:8], subtask.Title)
-
-					// Get commit messages for PR body
-					commits, _ := l.services.GitHubService.GetCommitMessages(ctx, workDir, project.DefaultBranch)
-					commitList := ""
-					for _, c := range commits {
-						commitList += fmt.Sprintf("- %s\n", c)
-					}
-
-					spec := ""
-					if subtask.Spec != nil {
-						spec = *subtask.Spec
-					}
-
-					prBody := fmt.Sprintf("## Summary\n\n%s\n\n## Commits\n\n%s\n---\n\n:robot: Generated by Intern Village", spec, commitList)
-
-					prInfo, err := l.services.GitHubService.CreatePR(
-						ctx,
-						project.GitHubOwner,
-						project.GitHubRepo,
-						userToken,
-						*subtask.BranchName,
-						project.DefaultBranch,
-						prTitle,
-						prBody,
-					)
-					if err != nil {
-						log.Error().Err(err).Msg("failed to create PR")
-						// Mark as completed without PR
-						if err := l.services.SubtaskService.MarkCompleted(ctx, subtask.ID, "", 0); err != nil {
-							log.Error().Err(err).Msg("failed to mark subtask as completed")
-						}
-					} else {
-						// Mark as completed with PR info
-						if err := l.services.SubtaskService.MarkCompleted(ctx, subtask.ID, prInfo.HTMLURL, prInfo.Number); err != nil {
-							log.Error().Err(err).Msg("failed to mark subtask as completed")
-						}
-					}
-				}
+			l.recordRunTokenUsage(ctx, agentRun.ID, result)
+		}
 
-				// Publish agent:completed event
-				if l.services.EventPublisher != nil {
-					now := time.Now()
-					prURL := ""
-					if subtask.PRUrl != nil {
-						prURL = *subtask.PRUrl
-					}
-					subtaskIDPtr := pgtypeToUUID(agentRun.SubtaskID)
-					run := &domain.AgentRun{
-						ID:            agentRun.ID,
-						SubtaskID:     &subtaskIDPtr,
-						AgentType:     domain.AgentTypeWorker,
-						AttemptNumber: int(agentRun.AttemptNumber),
-						Status:        domain.AgentRunStatusSucceeded,
-						StartedAt:     agentRun.StartedAt,
-						EndedAt:       &now,
-						TokenUsage:    &result.TokenUsage,
-					}
-					l.services.EventPublisher.PublishAgentCompleted(project.ID, run, subtask.TaskID, prURL)
-				}
+		if ClassifyFailure(result.Error) == FailurePermanent {
+			return l.failWorkerPermanently(ctx, subtask.ID, agentRun.ID, attempt, result.Error)
+		}
 
-				log.Info().
-					Str("subtask_id", subtask.ID.String()).
-					Int("attempt", attempt).
-					Msg("worker completed successfully")
-				return nil
+		signal, err := l.checkWorkerCompletion(ctx, subtask, project, workDir, result)
+		if ClassifyFailure(err) == FailurePermanent {
+			return l.failWorkerPermanently(ctx, subtask.ID, agentRun.ID, attempt, err)
+		}
+		if signal != "" {
+			if err := l.checkPathPolicy(ctx, subtask, project, workDir); err != nil {
+				return l.stopWorkerPolicyViolation(ctx, project.ID, subtask, agentRun, attempt, err)
 			}
+			l.completeWorker(ctx, subtask, project, agentRun, workDir, result, token)
+
+			log.Info().
+				Str("subtask_id", subtask.ID.String()).
+				Int("attempt", attempt).
+				Str("completion", string(signal)).
+				Msg("worker completed successfully")
+			return nil
 		}
 
-		// Issue not closed, check exit code
+		// Not complete, check exit code
+		errMsg := "issue not closed"
+		if subtask.BeadsIssueID == nil || *subtask.BeadsIssueID == "" {
+			errMsg = "no beads issue and no changes on branch"
+		}
 		if result.ExitCode != 0 {
-			l.markAgentRunFailed(ctx, agentRun.ID, fmt.Sprintf("exit code: %d", result.ExitCode))
-		} else {
-			l.markAgentRunFailed(ctx, agentRun.ID, "issue not closed")
+			errMsg = fmt.Sprintf("exit code: %d", result.ExitCode)
 		}
-
-		if attempt < l.maxRetries {
-			l.backoff(ctx, attempt)
+		if stalled {
+			errMsg = fmt.Sprintf("stalled: no log output for %s", l.stallTimeout)
+		}
+		if overBudget(usage, budget) {
+			l.markAgentRunFailed(ctx, agentRun.ID, errMsg+"; token budget exceeded")
+			return l.stopWorkerOverBudget(ctx, subtask.ID, attempt, usage, *budget)
 		}
+		l.failWorkerAttempt(ctx, project.ID, subtask, agentRun, attempt, errMsg)
 	}
 
 	// Max retries reached, mark subtask as failed
@@ -572,21 +887,448 @@ func (l *AgentLoop) RunWorkerLoop(ctx context.Context, subtask *domain.Subtask,
 	return fmt.Errorf("worker max retries (%d) reached", l.maxRetries)
 }
 
-// backoff waits with exponential backoff before the next retry.
-// Formula: min(5 * 2^attempt, 120) + jitter (0-20%)
-func (l *AgentLoop) backoff(ctx context.Context, attempt int) {
-	baseDelay := 5.0           // seconds
-	multiplier := 1 << attempt // 2^attempt
-	delay := baseDelay * float64(multiplier)
-	if delay > 120 {
-		delay = 120
+// watchWorkerStall watches a Worker attempt's log until runCtx is done,
+// publishing agent:stalled each time it goes quiet for the stall timeout and,
+// if stalled attempts are retried, stopping the attempt with ErrAgentStalled.
+func (l *AgentLoop) watchWorkerStall(runCtx context.Context, stopRun context.CancelCauseFunc, projectID uuid.UUID, subtask *domain.Subtask, agentRun db.AgentRun, logPath string) {
+	if l.stallTimeout <= 0 {
+		return
 	}
+	go watchStall(runCtx, logPath, l.stallTimeout, func(lastWrite time.Time) {
+		log.Warn().
+			Str("subtask_id", subtask.ID.String()).
+			Str("run_id", agentRun.ID.String()).
+			Time("last_output_at", lastWrite).
+			Bool("cancel", l.retryStalled).
+			Msg("worker stalled")
+
+		if l.services.EventPublisher != nil {
+			subtaskIDPtr := pgtypeToUUID(agentRun.SubtaskID)
+			run := &domain.AgentRun{
+				ID:            agentRun.ID,
+				SubtaskID:     &subtaskIDPtr,
+				AgentType:     domain.AgentTypeWorker,
+				AttemptNumber: int(agentRun.AttemptNumber),
+				Status:        domain.AgentRunStatusRunning,
+				StartedAt:     agentRun.StartedAt,
+				LogPath:       agentRun.LogPath,
+			}
+			l.services.EventPublisher.PublishAgentStalled(projectID, run, subtask.TaskID, lastWrite, l.retryStalled)
+		}
+		if l.retryStalled {
+			stopRun(ErrAgentStalled)
+		}
+	})
+}
 
-	// Add jitter (0-20%)
-	jitter := delay * 0.2 * rand.Float64() //nolint:gosec // Non-cryptographic use for backoff jitter
-	delay += jitter
+// completionSignal names the evidence a Worker attempt was judged complete on.
+type completionSignal string
 
-	timer := time.NewTimer(time.Duration(delay * float64(time.Second)))
+// Completion signals, logged with each completed Worker.
+const (
+	// completionIssueClosed: the subtask's beads issue was closed.
+	completionIssueClosed completionSignal = "beads_issue_closed"
+	// completionBranchChanged: the subtask has no beads issue, and the Worker
+	// exited cleanly leaving its branch with changes relative to the base.
+	completionBranchChanged completionSignal = "branch_changed"
+)
+
+// checkWorkerCompletion reports how a finished attempt completed its subtask,
+// or "" if it did not. The beads issue is authoritative; only a subtask
+// without one (created manually, or missed by a sync) falls back to the
+// branch, so finished work is not thrown away retrying an issue that can
+// never close. A permanent ShowIssue failure is returned for the caller to
+// fail fast; other errors just mean not complete.
+func (l *AgentLoop) checkWorkerCompletion(ctx context.Context, subtask *domain.Subtask, project *domain.Project, workDir string, result *ExecutionResult) (completionSignal, error) {
+	if subtask.BeadsIssueID != nil && *subtask.BeadsIssueID != "" {
+		issue, err := l.services.BeadsService.ShowIssue(ctx, project.ClonePath, *subtask.BeadsIssueID)
+		if err != nil {
+			return "", err
+		}
+		if issue.Status == "closed" {
+			return completionIssueClosed, nil
+		}
+		return "", nil
+	}
+
+	if result.Error != nil || result.ExitCode != 0 || subtask.BranchName == nil || *subtask.BranchName == "" {
+		return "", nil
+	}
+	baseBranch, _ := l.prTarget(ctx, subtask, project)
+	files, err := l.services.GitHubService.GetChangedFiles(ctx, workDir, baseBranch)
+	if err != nil {
+		log.Warn().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to check branch for changes")
+		return "", nil
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	return completionBranchChanged, nil
+}
+
+// maxListedViolations caps the paths named in a path policy violation.
+const maxListedViolations = 10
+
+// checkPathPolicy returns an error wrapping ErrPathPolicyViolation if the
+// Worker's branch modifies paths the project's path policy forbids. It runs
+// before anything is pushed; a branch whose changed files cannot be listed
+// is treated as violating, so a git failure never bypasses the policy.
+func (l *AgentLoop) checkPathPolicy(ctx context.Context, subtask *domain.Subtask, project *domain.Project, workDir string) error {
+	if project.PathPolicy.IsEmpty() || subtask.BranchName == nil || *subtask.BranchName == "" {
+		return nil
+	}
+
+	baseBranch, _ := l.prTarget(ctx, subtask, project)
+	files, err := l.services.GitHubService.GetChangedFiles(ctx, workDir, baseBranch)
+	if err != nil {
+		return fmt.Errorf("%w: could not list the changed paths: %v", ErrPathPolicyViolation, err)
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+
+	violations := project.PathPolicy.Violations(paths)
+	if len(violations) == 0 {
+		return nil
+	}
+	listed := strings.Join(violations[:min(len(violations), maxListedViolations)], ", ")
+	if len(violations) > maxListedViolations {
+		listed += fmt.Sprintf(" and %d more", len(violations)-maxListedViolations)
+	}
+	return fmt.Errorf("%w: modified %s", ErrPathPolicyViolation, listed)
+}
+
+// stopWorkerPolicyViolation fails a finished attempt whose branch breaks the
+// project's path policy. The branch is neither pushed nor opened as a PR; the
+// subtask is blocked with reason POLICY_VIOLATION without using the remaining
+// attempts, since a retry would be given the same instructions.
+func (l *AgentLoop) stopWorkerPolicyViolation(ctx context.Context, projectID uuid.UUID, subtask *domain.Subtask, agentRun db.AgentRun, attempt int, err error) error {
+	errMsg := err.Error()
+	l.markAgentRunFailed(ctx, agentRun.ID, errMsg)
+	if err := l.services.SubtaskService.MarkPolicyViolation(ctx, subtask.ID); err != nil {
+		log.Error().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to mark subtask as violating the path policy")
+	}
+
+	if l.services.EventPublisher != nil {
+		now := time.Now()
+		subtaskIDPtr := pgtypeToUUID(agentRun.SubtaskID)
+		run := &domain.AgentRun{
+			ID:            agentRun.ID,
+			SubtaskID:     &subtaskIDPtr,
+			AgentType:     domain.AgentTypeWorker,
+			AttemptNumber: int(agentRun.AttemptNumber),
+			Status:        domain.AgentRunStatusFailed,
+			StartedAt:     agentRun.StartedAt,
+			EndedAt:       &now,
+			ErrorMessage:  &errMsg,
+		}
+		l.services.EventPublisher.PublishAgentFailed(projectID, run, subtask.TaskID, errMsg, false, nil)
+	}
+
+	log.Warn().Err(err).
+		Str("subtask_id", subtask.ID.String()).
+		Int("attempt", attempt).
+		Msg("worker modified paths forbidden by the project's path policy, not opening a PR")
+
+	return err
+}
+
+// squashWorkerCommits replaces the Worker's commits with a single commit
+// before the branch is pushed, for projects with SquashBeforePR set. A failure
+// is logged and the branch is pushed as it is.
+func (l *AgentLoop) squashWorkerCommits(ctx context.Context, subtask *domain.Subtask, workDir, baseBranch string) {
+	commits, err := l.services.GitHubService.GetCommitMessages(ctx, workDir, baseBranch)
+	if err != nil {
+		log.Warn().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to list commits to squash")
+		return
+	}
+	squashed, err := l.services.GitHubService.SquashCommits(ctx, workDir, baseBranch, buildSquashMessage(subtask, commits, buildTrailers(l.trailerKeys, subtask)))
+	if err != nil {
+		log.Warn().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to squash commits, pushing them as they are")
+		return
+	}
+	if squashed {
+		log.Info().
+			Str("subtask_id", subtask.ID.String()).
+			Int("commits", len(commits)).
+			Msg("squashed worker commits")
+	}
+}
+
+// completeWorker finishes a successful attempt: it marks the run succeeded,
+// squashes the branch if the project asks for it, pushes the branch, opens
+// the PR, marks the subtask completed, and publishes agent:completed. A
+// branch without commits has nothing to open a PR for, so the subtask is
+// marked COMPLETED_NO_CHANGES instead (see completeWorkerNoChanges). If
+// GitHub rejects the user's token the subtask is failed instead (see
+// failWorkerReauth). The token is fetched from token just before the PR is
+// opened; the push uses the one in the clone's origin remote, which
+// ProjectService.RefreshCloneTokens updates when the user reconnects.
+func (l *AgentLoop) completeWorker(ctx context.Context, subtask *domain.Subtask, project *domain.Project, agentRun db.AgentRun, workDir string, result *ExecutionResult, token TokenSource) {
+	l.markAgentRunSucceeded(ctx, agentRun.ID)
+
+	if subtask.BranchName != nil && *subtask.BranchName != "" {
+		baseBranch, taskTitle := l.prTarget(ctx, subtask, project)
+
+		// GitHub refuses a PR without commits between base and head; a
+		// failure to list them falls through to the PR attempt as before
+		if commits, err := l.services.GitHubService.GetCommitMessages(ctx, workDir, baseBranch); err == nil && len(commits) == 0 {
+			l.completeWorkerNoChanges(ctx, subtask, project, agentRun, result, baseBranch)
+			return
+		}
+
+		if project.SquashBeforePR {
+			l.squashWorkerCommits(ctx, subtask, workDir, baseBranch)
+		}
+
+		// Push branch to remote
+		if err := l.services.GitHubService.PushBranch(ctx, workDir, *subtask.BranchName); err != nil {
+			if errors.Is(err, service.ErrTokenInvalid) {
+				l.failWorkerReauth(ctx, project.ID, subtask, service.OperationPushBranch, err)
+				return
+			}
+			log.Error().Err(err).Msg("failed to push branch")
+			// Continue anyway - we'll handle PR creation failure
+		}
+
+		// Create PR
+		prTitle := buildPRTitle(project, subtask, taskTitle)
+
+		// Get commits and changed files for PR body; a failure only
+		// drops the corresponding section
+		commits, _ := l.services.GitHubService.GetCommitMessages(ctx, workDir, baseBranch)
+		files, err := l.services.GitHubService.GetChangedFiles(ctx, workDir, baseBranch)
+		if err != nil {
+			log.Warn().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to get changed files for PR body")
+			files = nil
+		}
+
+		prBody := buildPRBody(subtask, commits, files, buildTrailers(l.trailerKeys, subtask))
+
+		var prInfo *PRInfo
+		userToken, err := token(ctx)
+		if err == nil {
+			prInfo, err = l.services.GitHubService.CreatePR(
+				ctx,
+				project.GitHubOwner,
+				project.GitHubRepo,
+				userToken,
+				*subtask.BranchName,
+				baseBranch,
+				prTitle,
+				prBody,
+			)
+		}
+		if errors.Is(err, service.ErrTokenInvalid) {
+			l.failWorkerReauth(ctx, project.ID, subtask, service.OperationCreatePR, err)
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("failed to create PR")
+			// Mark as completed without PR
+			if err := l.services.SubtaskService.MarkCompleted(ctx, subtask.ID, "", 0); err != nil {
+				log.Error().Err(err).Msg("failed to mark subtask as completed")
+			}
+		} else {
+			// Mark as completed with PR info
+			if err := l.services.SubtaskService.MarkCompleted(ctx, subtask.ID, prInfo.HTMLURL, prInfo.Number); err != nil {
+				log.Error().Err(err).Msg("failed to mark subtask as completed")
+			}
+		}
+	}
+
+	// Publish agent:completed event
+	if l.services.EventPublisher != nil {
+		now := time.Now()
+		prURL := ""
+		if subtask.PRUrl != nil {
+			prURL = *subtask.PRUrl
+		}
+		subtaskIDPtr := pgtypeToUUID(agentRun.SubtaskID)
+		run := &domain.AgentRun{
+			ID:            agentRun.ID,
+			SubtaskID:     &subtaskIDPtr,
+			AgentType:     domain.AgentTypeWorker,
+			AttemptNumber: int(agentRun.AttemptNumber),
+			Status:        domain.AgentRunStatusSucceeded,
+			StartedAt:     agentRun.StartedAt,
+			EndedAt:       &now,
+			TokenUsage:    &result.TokenUsage,
+		}
+		l.services.EventPublisher.PublishAgentCompleted(project.ID, run, subtask.TaskID, prURL)
+	}
+}
+
+// completeWorkerNoChanges finishes a successful attempt whose branch has no
+// commits on top of baseBranch, e.g. because the change was already present.
+// Nothing is pushed and no PR is opened: the subtask is marked
+// COMPLETED_NO_CHANGES and agent:completed is published without a PR URL.
+func (l *AgentLoop) completeWorkerNoChanges(ctx context.Context, subtask *domain.Subtask, project *domain.Project, agentRun db.AgentRun, result *ExecutionResult, baseBranch string) {
+	log.Info().
+		Str("subtask_id", subtask.ID.String()).
+		Str("branch", *subtask.BranchName).
+		Str("base_branch", baseBranch).
+		Msg("worker branch has no commits, completing without a PR")
+
+	if err := l.services.SubtaskService.MarkCompletedNoChanges(ctx, subtask.ID); err != nil {
+		log.Error().Err(err).Msg("failed to mark subtask as completed without changes")
+	}
+
+	if l.services.EventPublisher != nil {
+		now := time.Now()
+		subtaskIDPtr := pgtypeToUUID(agentRun.SubtaskID)
+		run := &domain.AgentRun{
+			ID:            agentRun.ID,
+			SubtaskID:     &subtaskIDPtr,
+			AgentType:     domain.AgentTypeWorker,
+			AttemptNumber: int(agentRun.AttemptNumber),
+			Status:        domain.AgentRunStatusSucceeded,
+			StartedAt:     agentRun.StartedAt,
+			EndedAt:       &now,
+			TokenUsage:    &result.TokenUsage,
+		}
+		l.services.EventPublisher.PublishAgentCompleted(project.ID, run, subtask.TaskID, "")
+	}
+}
+
+// failWorkerReauth handles GitHub rejecting the user's token while a finished
+// Worker's branch is pushed or its PR opened. The work is kept in the
+// worktree; the subtask is marked failed so it can be retried once the user
+// reconnects GitHub, and github:reauth_required tells the user to do so.
+func (l *AgentLoop) failWorkerReauth(ctx context.Context, projectID uuid.UUID, subtask *domain.Subtask, operation string, err error) {
+	log.Warn().Err(err).
+		Str("subtask_id", subtask.ID.String()).
+		Str("operation", operation).
+		Msg("GitHub rejected the user's token, reconnect required")
+
+	l.markSubtaskFailed(ctx, subtask.ID)
+	if l.services.EventPublisher != nil {
+		l.services.EventPublisher.PublishReauthRequired(projectID, subtask.TaskID, &subtask.ID, operation, err.Error())
+	}
+}
+
+// publishRunRefused publishes agent:failed for an agent run that was refused
+// before it started, so it has no run record. subtaskID is nil for a Planner.
+func (l *AgentLoop) publishRunRefused(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, agentType domain.AgentType, err error) {
+	log.Warn().Err(err).
+		Str("task_id", taskID.String()).
+		Str("agent_type", string(agentType)).
+		Msg("agent run refused")
+
+	if l.services.EventPublisher == nil {
+		return
+	}
+	run := &domain.AgentRun{SubtaskID: subtaskID, AgentType: agentType, Status: domain.AgentRunStatusFailed}
+	if subtaskID == nil {
+		run.TaskID = &taskID
+	}
+	l.services.EventPublisher.PublishAgentFailed(projectID, run, taskID, err.Error(), false, nil)
+}
+
+// failWorkerPermanently fails a worker attempt whose error will recur on every
+// retry (see ClassifyFailure), marking the subtask failed without using the
+// remaining attempts.
+func (l *AgentLoop) failWorkerPermanently(ctx context.Context, subtaskID, runID uuid.UUID, attempt int, err error) error {
+	l.markAgentRunFailed(ctx, runID, fmt.Sprintf("not retryable: %v", err))
+	l.markSubtaskFailed(ctx, subtaskID)
+
+	log.Warn().Err(err).
+		Str("subtask_id", subtaskID.String()).
+		Int("attempt", attempt).
+		Msg("worker failed with a non-retryable error, skipping remaining retries")
+
+	return fmt.Errorf("worker failed (not retryable): %w", err)
+}
+
+// overBudget reports whether usage has reached budget, so another attempt
+// would exceed it. A nil budget is unlimited.
+func overBudget(usage int, budget *int) bool {
+	return budget != nil && usage >= *budget
+}
+
+// stopWorkerOverBudget blocks a subtask with reason BUDGET_EXCEEDED instead of
+// retrying it. attempt is the attempt that used up the budget, or 0 if it was
+// already used up when the loop started.
+func (l *AgentLoop) stopWorkerOverBudget(ctx context.Context, subtaskID uuid.UUID, attempt, usage, budget int) error {
+	if err := l.services.SubtaskService.MarkBudgetExceeded(ctx, subtaskID); err != nil {
+		log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to mark subtask over budget")
+	}
+
+	log.Warn().
+		Str("subtask_id", subtaskID.String()).
+		Int("attempt", attempt).
+		Int("token_usage", usage).
+		Int("token_budget", budget).
+		Msg("worker token budget exceeded, skipping remaining retries")
+
+	return fmt.Errorf("%w: used %d of %d tokens", ErrTokenBudgetExceeded, usage, budget)
+}
+
+// failWorkerAttempt marks a failed Worker attempt and publishes agent:failed.
+// If attempts remain it then backs off; the time of the next attempt is sent
+// in the event and stored on the subtask, so clients that connect during the
+// wait can still show it.
+func (l *AgentLoop) failWorkerAttempt(ctx context.Context, projectID uuid.UUID, subtask *domain.Subtask, agentRun db.AgentRun, attempt int, errMsg string) {
+	l.markAgentRunFailed(ctx, agentRun.ID, errMsg)
+
+	willRetry := attempt < l.maxRetries
+	var delay time.Duration
+	var nextAttemptAt *time.Time
+	if willRetry {
+		delay = l.backoffDelay(attempt)
+		next := time.Now().Add(delay)
+		nextAttemptAt = &next
+		if err := l.services.SubtaskService.SetNextAttemptAt(ctx, subtask.ID, nextAttemptAt); err != nil {
+			log.Error().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to store next attempt time")
+		}
+	}
+
+	if l.services.EventPublisher != nil {
+		now := time.Now()
+		subtaskIDPtr := pgtypeToUUID(agentRun.SubtaskID)
+		run := &domain.AgentRun{
+			ID:            agentRun.ID,
+			SubtaskID:     &subtaskIDPtr,
+			AgentType:     domain.AgentTypeWorker,
+			AttemptNumber: int(agentRun.AttemptNumber),
+			Status:        domain.AgentRunStatusFailed,
+			StartedAt:     agentRun.StartedAt,
+			EndedAt:       &now,
+			ErrorMessage:  &errMsg,
+		}
+		l.services.EventPublisher.PublishAgentFailed(projectID, run, subtask.TaskID, errMsg, willRetry, nextAttemptAt)
+	}
+
+	if willRetry {
+		l.wait(ctx, delay)
+	}
+}
+
+// Jitter returns the extra wait added to a retry backoff of delay, so Workers
+// that fail together do not all retry at the same moment.
+type Jitter func(delay time.Duration) time.Duration
+
+// RandomJitter adds a random 0-20% of delay. It is the default.
+func RandomJitter(delay time.Duration) time.Duration {
+	return time.Duration(float64(delay) * 0.2 * rand.Float64()) //nolint:gosec // Non-cryptographic use for backoff jitter
+}
+
+// NoJitter adds nothing, making retry timing deterministic.
+func NoJitter(time.Duration) time.Duration {
+	return 0
+}
+
+// backoffDelay returns the wait before the attempt after attempt:
+// CalculateBackoff(attempt) plus jitter.
+func (l *AgentLoop) backoffDelay(attempt int) time.Duration {
+	delay := CalculateBackoff(attempt)
+	return delay + l.jitter(delay)
+}
+
+// wait blocks for d or until ctx is done.
+func wait(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
 	defer timer.Stop()
 
 	select {
@@ -595,6 +1337,30 @@ func (l *AgentLoop) backoff(ctx context.Context, attempt int) {
 	}
 }
 
+// observeRun records the outcome of a single agent run in metrics.
+func (l *AgentLoop) observeRun(ctx context.Context, agentType domain.AgentType, result *ExecutionResult) {
+	status := "succeeded"
+	switch {
+	case result.Error != nil && ctx.Err() != nil:
+		status = "canceled"
+	case result.Error != nil || result.ExitCode != 0:
+		status = "failed"
+	}
+	l.metrics.ObserveAgentRun(string(agentType), status, result.Duration, result.TokenUsage)
+}
+
+// prTarget returns the branch a subtask's PR targets (its task's base branch)
+// and the task's title. If the task cannot be read the PR targets the project
+// default branch and the title is empty.
+func (l *AgentLoop) prTarget(ctx context.Context, subtask *domain.Subtask, project *domain.Project) (baseBranch, taskTitle string) {
+	task, err := l.services.Repo.GetTaskByID(ctx, subtask.TaskID)
+	if err != nil || task.BaseBranch == "" {
+		log.Warn().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to get task base branch, using project default")
+		return project.DefaultBranch, task.Title
+	}
+	return task.BaseBranch, task.Title
+}
+
 // markAgentRunSucceeded marks an agent run as succeeded.
 func (l *AgentLoop) markAgentRunSucceeded(ctx context.Context, runID uuid.UUID) {
 	now := time.Now()
@@ -608,6 +1374,44 @@ func (l *AgentLoop) markAgentRunSucceeded(ctx context.Context, runID uuid.UUID)
 	}
 }
 
+// recordRunTokenUsage stores the token usage of a finished run: its total on
+// the run and each usage report as a timestamped sample for usage over time.
+// Output without result events is recorded as one sample at the end of the
+// run, so the samples always add up to the run's total.
+func (l *AgentLoop) recordRunTokenUsage(ctx context.Context, runID uuid.UUID, result *ExecutionResult) {
+	//nolint:gosec // TokenUsage is always positive and bounded
+	_, _ = l.services.Repo.UpdateAgentRunTokenUsage(ctx, db.UpdateAgentRunTokenUsageParams{
+		ID:         runID,
+		TokenUsage: &[]int32{int32(result.TokenUsage)}[0],
+	})
+
+	samples := make([]db.CreateAgentRunTokenUsageParams, 0, len(result.UsageSamples))
+	for _, sample := range result.UsageSamples {
+		//nolint:gosec // token counts are always positive and bounded
+		samples = append(samples, db.CreateAgentRunTokenUsageParams{
+			AgentRunID:   runID,
+			InputTokens:  int32(sample.InputTokens),
+			OutputTokens: int32(sample.OutputTokens),
+			TotalTokens:  int32(sample.Total()),
+			RecordedAt:   sample.At,
+		})
+	}
+	if len(samples) == 0 {
+		// The split between input and output is unknown
+		//nolint:gosec // TokenUsage is always positive and bounded
+		samples = append(samples, db.CreateAgentRunTokenUsageParams{
+			AgentRunID:  runID,
+			TotalTokens: int32(result.TokenUsage),
+			RecordedAt:  time.Now(),
+		})
+	}
+	for _, sample := range samples {
+		if _, err := l.services.Repo.CreateAgentRunTokenUsage(ctx, sample); err != nil {
+			log.Error().Err(err).Str("run_id", runID.String()).Msg("failed to record token usage sample")
+		}
+	}
+}
+
 // markAgentRunFailed marks an agent run as failed.
 func (l *AgentLoop) markAgentRunFailed(ctx context.Context, runID uuid.UUID, errorMsg string) {
 	now := time.Now()
@@ -622,6 +1426,64 @@ func (l *AgentLoop) markAgentRunFailed(ctx context.Context, runID uuid.UUID, err
 	}
 }
 
+// interruptWorker records a Worker attempt stopped by a shutdown: the run is
+// INTERRUPTED rather than failed, its retry is given back, and the subtask is
+// left IN_PROGRESS for Recovery to resume. ctx is already cancelled, so the
+// updates run without its cancellation.
+func (l *AgentLoop) interruptWorker(ctx context.Context, subtaskID, runID uuid.UUID) {
+	ctx = context.WithoutCancel(ctx)
+	now := time.Now()
+	errorMsg := "interrupted by orchestrator shutdown"
+	_, err := l.services.Repo.UpdateAgentRunStatus(ctx, db.UpdateAgentRunStatusParams{
+		ID:           runID,
+		Status:       string(domain.AgentRunStatusInterrupted),
+		EndedAt:      repository.PointerToTimestamptz(&now),
+		ErrorMessage: &errorMsg,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("run_id", runID.String()).Msg("failed to mark agent run interrupted")
+	}
+	if _, err := l.services.SubtaskService.DecrementRetryCount(ctx, subtaskID); err != nil {
+		log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to give back retry of interrupted worker")
+	}
+	log.Info().Str("subtask_id", subtaskID.String()).Msg("worker interrupted by shutdown")
+}
+
+// interruptPendingWorker records a shutdown that stopped the Worker loop
+// between attempts, when no run is active for interruptWorker to mark. An
+// INTERRUPTED run is created for the attempt that would have started, so
+// Recovery resumes the subtask like any interrupted Worker. The attempt never
+// incremented the retry count, so there is nothing to give back.
+func (l *AgentLoop) interruptPendingWorker(ctx context.Context, subtask *domain.Subtask, project *domain.Project, attempt int) {
+	ctx = context.WithoutCancel(ctx)
+	run, err := l.services.Repo.CreateAgentRun(ctx, db.CreateAgentRunParams{
+		SubtaskID:     uuidToPgtype(subtask.ID),
+		AgentType:     string(domain.AgentTypeWorker),
+		AttemptNumber: int32(attempt), //nolint:gosec // attempt is bounded by maxRetries
+		Status:        string(domain.AgentRunStatusInterrupted),
+		LogPath:       l.executor.GetLogPath(project.ID.String(), subtask.TaskID.String(), subtask.ID.String(), attempt),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to record worker interrupted between attempts")
+		return
+	}
+
+	now := time.Now()
+	errorMsg := "interrupted by orchestrator shutdown before the attempt started"
+	if _, err := l.services.Repo.UpdateAgentRunStatus(ctx, db.UpdateAgentRunStatusParams{
+		ID:           run.ID,
+		Status:       string(domain.AgentRunStatusInterrupted),
+		EndedAt:      repository.PointerToTimestamptz(&now),
+		ErrorMessage: &errorMsg,
+	}); err != nil {
+		log.Error().Err(err).Str("run_id", run.ID.String()).Msg("failed to mark agent run interrupted")
+	}
+	log.Info().
+		Str("subtask_id", subtask.ID.String()).
+		Int("attempt", attempt).
+		Msg("worker interrupted by shutdown between attempts")
+}
+
 // markSubtaskFailed marks a subtask as blocked due to failure.
 func (l *AgentLoop) markSubtaskFailed(ctx context.Context, subtaskID uuid.UUID) {
 	if err := l.services.SubtaskService.MarkFailed(ctx, subtaskID); err != nil {
@@ -4,8 +4,24 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+	"github.com/intern-village/orchestrator/internal/service"
 )
 
 func TestCalculateBackoffValues(t *testing.T) {
@@ -55,3 +71,1079 @@ func TestCalculateBackoffAlwaysPositive(t *testing.T) {
 		}
 	}
 }
+
+func TestBackoffDelay_Jitter(t *testing.T) {
+	loop := NewAgentLoop(nil, nil, LoopServices{}, 3)
+	base := CalculateBackoff(2)
+
+	for i := 0; i < 100; i++ {
+		if got := loop.backoffDelay(2); got < base || got > base+base/5 {
+			t.Fatalf("backoffDelay(2) = %v with random jitter, want within [%v, %v]", got, base, base+base/5)
+		}
+	}
+
+	loop.SetJitter(func(delay time.Duration) time.Duration { return delay / 10 })
+	if got, want := loop.backoffDelay(2), base+base/10; got != want {
+		t.Errorf("backoffDelay(2) = %v with fixed jitter, want %v", got, want)
+	}
+
+	loop.SetJitter(nil)
+	if got := loop.backoffDelay(2); got != base {
+		t.Errorf("backoffDelay(2) = %v without jitter, want %v", got, base)
+	}
+}
+
+// fakeExecutor stands in for the Claude CLI. Each attempt returns the next
+// scripted result, repeating the last one, and writes a log file where the
+// real Executor would. onStart, if set, runs as each attempt starts.
+type fakeExecutor struct {
+	paths    config.DataPaths
+	results  []ExecutionResult
+	startErr error
+	onStart  func()
+
+	mu       sync.Mutex
+	attempts []int
+}
+
+func (e *fakeExecutor) ExecuteClaudeAsync(_ context.Context, workDir, _, projectID, taskID, subtaskID string, attemptNumber int) (*ClaudeRun, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.attempts = append(e.attempts, attemptNumber)
+	if e.onStart != nil {
+		e.onStart()
+	}
+	if e.startErr != nil {
+		return nil, e.startErr
+	}
+
+	logPath := e.GetLogPath(projectID, taskID, subtaskID, attemptNumber)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o755); err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("=== Agent Run %d ===\nWorking Directory: %s\n", attemptNumber, workDir)
+	if err := os.WriteFile(logPath, []byte(header), 0o600); err != nil {
+		return nil, err
+	}
+
+	result := e.results[min(len(e.attempts), len(e.results))-1]
+	result.LogPath = logPath
+	return &ClaudeRun{LogPath: logPath, result: &result}, nil
+}
+
+func (e *fakeExecutor) GetLogPath(projectID, taskID, subtaskID string, attemptNumber int) string {
+	return filepath.Join(e.paths.Logs(projectID, taskID, subtaskID), fmt.Sprintf("run-%03d.log", attemptNumber))
+}
+
+func (e *fakeExecutor) PlannerWorktree(projectID, taskID string) string {
+	return e.paths.PlannerWorktree(projectID, taskID)
+}
+
+func TestRunWorkerLoop_ScriptedAttempts(t *testing.T) {
+	failed := ExecutionResult{ExitCode: 1, Error: errors.New("exit status 1")}
+	succeeded := ExecutionResult{ExitCode: 0}
+	budget := 1000
+
+	tests := []struct {
+		name           string
+		results        []ExecutionResult
+		budget         *int
+		wantErr        string // substring of the error; empty for success
+		wantAttempts   int
+		wantWaits      int
+		wantCompleted  int
+		wantFailed     int
+		wantOverBudget int
+		wantSamples    int // token usage samples recorded
+	}{
+		{
+			name:          "completes after retries",
+			results:       []ExecutionResult{failed, failed, succeeded},
+			wantAttempts:  3,
+			wantWaits:     2,
+			wantCompleted: 1,
+		},
+		{
+			name:         "fails after max retries",
+			results:      []ExecutionResult{failed},
+			wantErr:      "worker max retries (3) reached",
+			wantAttempts: 3,
+			wantWaits:    2,
+			wantFailed:   1,
+		},
+		{
+			name:         "permanent failure is not retried",
+			results:      []ExecutionResult{{ExitCode: 1, Error: fmt.Errorf("%w: exit status 1", ErrClaudeAuthFailed)}},
+			wantErr:      ErrClaudeAuthFailed.Error(),
+			wantAttempts: 1,
+			wantFailed:   1,
+		},
+		{
+			name:           "stops once the token budget is used up",
+			results:        []ExecutionResult{{ExitCode: 1, Error: errors.New("exit status 1"), TokenUsage: 600}},
+			budget:         &budget,
+			wantErr:        ErrTokenBudgetExceeded.Error(),
+			wantAttempts:   2,
+			wantWaits:      1,
+			wantOverBudget: 1,
+			wantSamples:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := config.NewDataPaths(t.TempDir(), "")
+			renderer, err := NewPromptRenderer(paths)
+			if err != nil {
+				t.Fatalf("NewPromptRenderer() error = %v", err)
+			}
+
+			executor := &fakeExecutor{paths: paths, results: tt.results}
+			dbtx := &workerDB{}
+			subtasks := &fakeSubtaskService{tokenBudget: tt.budget}
+			github := &fakeGitHubService{files: []ChangedFile{{Path: "login.go", Status: "A"}}}
+			loop := NewAgentLoop(executor, renderer, LoopServices{
+				Repo:           repository.New(dbtx),
+				SubtaskService: subtasks,
+				GitHubService:  github,
+			}, 3)
+			loop.SetJitter(NoJitter)
+			var waits []time.Duration
+			loop.wait = func(_ context.Context, d time.Duration) { waits = append(waits, d) }
+
+			branch := "iv-1-add-login"
+			project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main"}
+			subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch, TokenBudget: tt.budget}
+
+			err = loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token"))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("RunWorkerLoop() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("RunWorkerLoop() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if len(executor.attempts) != tt.wantAttempts || dbtx.runsCreated != tt.wantAttempts {
+				t.Errorf("ran attempts %v with %d run records, want %d", executor.attempts, dbtx.runsCreated, tt.wantAttempts)
+			}
+			for i, attempt := range executor.attempts {
+				if _, err := os.Stat(executor.GetLogPath(project.ID.String(), subtask.TaskID.String(), subtask.ID.String(), attempt)); err != nil {
+					t.Errorf("attempt %d log: %v", attempt, err)
+				}
+				if attempt != i+1 {
+					t.Errorf("attempts = %v, want numbered from 1", executor.attempts)
+				}
+			}
+			// Without jitter each wait is exactly the backoff after that attempt
+			if len(waits) != tt.wantWaits {
+				t.Fatalf("waited %v, want %d backoffs", waits, tt.wantWaits)
+			}
+			for i, d := range waits {
+				if want := CalculateBackoff(i + 1); d != want {
+					t.Errorf("backoff after attempt %d = %v, want %v", i+1, d, want)
+				}
+			}
+			if subtasks.completed != tt.wantCompleted || subtasks.failed != tt.wantFailed || subtasks.budgetExceeded != tt.wantOverBudget {
+				t.Errorf("completed, failed, over budget = %d, %d, %d; want %d, %d, %d",
+					subtasks.completed, subtasks.failed, subtasks.budgetExceeded,
+					tt.wantCompleted, tt.wantFailed, tt.wantOverBudget)
+			}
+			if subtasks.increments != tt.wantAttempts {
+				t.Errorf("retry count incremented %d times, want %d", subtasks.increments, tt.wantAttempts)
+			}
+			if dbtx.usageSamples != tt.wantSamples {
+				t.Errorf("recorded %d token usage samples, want %d", dbtx.usageSamples, tt.wantSamples)
+			}
+		})
+	}
+}
+
+func TestRunWorkerLoop_Killed(t *testing.T) {
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	executor := &fakeExecutor{
+		paths:   paths,
+		results: []ExecutionResult{{ExitCode: -1, Error: errors.New("signal: killed")}},
+		onStart: func() { cancel(ErrAgentKilled) },
+	}
+	subtasks := &fakeSubtaskService{}
+	loop := NewAgentLoop(executor, renderer, LoopServices{
+		Repo:           repository.New(&workerDB{}),
+		SubtaskService: subtasks,
+	}, 3)
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login"}
+
+	if err := loop.RunWorkerLoop(ctx, subtask, project, StaticToken("token")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunWorkerLoop() error = %v, want context.Canceled", err)
+	}
+	if len(executor.attempts) != 1 {
+		t.Errorf("ran attempts %v, want one", executor.attempts)
+	}
+	// Whoever killed the Worker records the subtask's state
+	if subtasks.failed != 0 {
+		t.Errorf("killed worker marked its subtask failed %d times", subtasks.failed)
+	}
+}
+
+func TestRunWorkerLoop_ShutdownDoesNotUseRetry(t *testing.T) {
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	executor := &fakeExecutor{
+		paths:   paths,
+		results: []ExecutionResult{{ExitCode: -1, Error: errors.New("signal: killed")}},
+		onStart: func() { cancel(ErrShutdown) },
+	}
+	dbtx := &workerDB{}
+	subtasks := &fakeSubtaskService{}
+	loop := NewAgentLoop(executor, renderer, LoopServices{
+		Repo:           repository.New(dbtx),
+		SubtaskService: subtasks,
+	}, 3)
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login"}
+
+	if err := loop.RunWorkerLoop(ctx, subtask, project, StaticToken("token")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunWorkerLoop() error = %v, want context.Canceled", err)
+	}
+	if subtasks.increments != 1 || subtasks.decrements != 1 {
+		t.Errorf("retry count incremented %d and decremented %d times; want the retry given back", subtasks.increments, subtasks.decrements)
+	}
+	// The subtask stays IN_PROGRESS for Recovery to resume
+	if subtasks.failed != 0 {
+		t.Errorf("interrupted worker marked its subtask failed %d times", subtasks.failed)
+	}
+	if want := []string{string(domain.AgentRunStatusInterrupted)}; !slices.Equal(dbtx.runStatuses, want) {
+		t.Errorf("run statuses = %v, want %v", dbtx.runStatuses, want)
+	}
+}
+
+func TestRunWorkerLoop_ShutdownDuringBackoffIsResumable(t *testing.T) {
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	executor := &fakeExecutor{
+		paths:   paths,
+		results: []ExecutionResult{{ExitCode: 1, Error: errors.New("exit status 1")}},
+	}
+	dbtx := &workerDB{}
+	subtasks := &fakeSubtaskService{}
+	loop := NewAgentLoop(executor, renderer, LoopServices{
+		Repo:           repository.New(dbtx),
+		SubtaskService: subtasks,
+	}, 3)
+	// The orchestrator shuts down while the loop waits to retry
+	loop.wait = func(context.Context, time.Duration) { cancel(ErrShutdown) }
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login"}
+
+	if err := loop.RunWorkerLoop(ctx, subtask, project, StaticToken("token")); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RunWorkerLoop() error = %v, want context.Canceled", err)
+	}
+	// The failed attempt stays FAILED and the next one is recorded INTERRUPTED,
+	// so the subtask's latest run is one Recovery resumes
+	if dbtx.runsCreated != 2 {
+		t.Errorf("created %d runs, want the failed attempt and an interrupted one", dbtx.runsCreated)
+	}
+	if want := []string{string(domain.AgentRunStatusFailed), string(domain.AgentRunStatusInterrupted)}; !slices.Equal(dbtx.runStatuses, want) {
+		t.Errorf("run statuses = %v, want %v", dbtx.runStatuses, want)
+	}
+	if subtasks.increments != 1 || subtasks.decrements != 0 || subtasks.failed != 0 {
+		t.Errorf("retry count incremented %d and decremented %d times, failed %d; want 1, 0 and 0", subtasks.increments, subtasks.decrements, subtasks.failed)
+	}
+}
+
+// failurePublisher records agent:failed, agent:completed and agent:stalled
+// events and cancels the loop on the first failure, so the test does not sit
+// through the backoff.
+type failurePublisher struct {
+	cancel        context.CancelFunc
+	failures      int
+	lastError     string
+	willRetry     bool
+	nextAttemptAt *time.Time
+	reauthOps     []string
+	completed     int
+	prURL         string // of the last agent:completed
+
+	mu     sync.Mutex
+	stalls []bool // cancelled flag of each agent:stalled
+}
+
+func (p *failurePublisher) PublishAgentStarted(uuid.UUID, *domain.AgentRun, uuid.UUID) {}
+
+func (p *failurePublisher) PublishAgentCompleted(_ uuid.UUID, _ *domain.AgentRun, _ uuid.UUID, prURL string) {
+	p.completed++
+	p.prURL = prURL
+}
+
+func (p *failurePublisher) PublishSubtaskCreated(uuid.UUID, *domain.Subtask) {}
+
+func (p *failurePublisher) PublishReauthRequired(_, _ uuid.UUID, _ *uuid.UUID, operation, _ string) {
+	p.reauthOps = append(p.reauthOps, operation)
+}
+
+func (p *failurePublisher) PublishAgentStalled(_ uuid.UUID, _ *domain.AgentRun, _ uuid.UUID, _ time.Time, cancelled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stalls = append(p.stalls, cancelled)
+}
+
+func (p *failurePublisher) PublishAgentFailed(_ uuid.UUID, _ *domain.AgentRun, _ uuid.UUID, errMsg string, willRetry bool, nextAttemptAt *time.Time) {
+	p.failures++
+	p.lastError = errMsg
+	p.willRetry = willRetry
+	p.nextAttemptAt = nextAttemptAt
+	p.cancel()
+}
+
+func TestRunWorkerLoop_PublishesNextAttemptAt(t *testing.T) {
+	// A claude that always fails without closing the issue
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher := &failurePublisher{cancel: cancel}
+	subtasks := &fakeSubtaskService{}
+	loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+		Repo:           repository.New(&workerDB{}),
+		SubtaskService: subtasks,
+		EventPublisher: publisher,
+	}, 3)
+	loop.SetJitter(NoJitter)
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login"}
+
+	before := time.Now()
+	_ = loop.RunWorkerLoop(ctx, subtask, project, StaticToken("token"))
+	after := time.Now()
+
+	if !publisher.willRetry || publisher.nextAttemptAt == nil {
+		t.Fatalf("agent:failed willRetry = %v, nextAttemptAt = %v; want a scheduled retry", publisher.willRetry, publisher.nextAttemptAt)
+	}
+
+	// Without jitter attempt 1 backs off exactly CalculateBackoff(1)
+	base := CalculateBackoff(1)
+	earliest := before.Add(base)
+	latest := after.Add(base)
+	if got := *publisher.nextAttemptAt; got.Before(earliest) || got.After(latest) {
+		t.Errorf("nextAttemptAt = %v, want between %v and %v", got, earliest, latest)
+	}
+	if subtasks.nextAttemptAt == nil || !subtasks.nextAttemptAt.Equal(*publisher.nextAttemptAt) {
+		t.Errorf("stored nextAttemptAt = %v, want the published %v", subtasks.nextAttemptAt, *publisher.nextAttemptAt)
+	}
+}
+
+func TestRunWorkerLoop_RetriesStalledAttempt(t *testing.T) {
+	// A claude that prints one line and then hangs without exiting
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not found")
+	}
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho '{\"type\":\"system\",\"subtype\":\"init\"}'\nexec " + sleep + " 30\n"
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte(script), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher := &failurePublisher{cancel: cancel}
+	loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+		Repo:           repository.New(&workerDB{}),
+		SubtaskService: &fakeSubtaskService{},
+		EventPublisher: publisher,
+	}, 3)
+	loop.SetStallTimeout(300*time.Millisecond, true)
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login"}
+
+	start := time.Now()
+	_ = loop.RunWorkerLoop(ctx, subtask, project, StaticToken("token"))
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("RunWorkerLoop() took %v; the stalled attempt was not cancelled", elapsed)
+	}
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	if len(publisher.stalls) != 1 || !publisher.stalls[0] {
+		t.Errorf("agent:stalled cancelled flags = %v, want one cancelled stall", publisher.stalls)
+	}
+	if publisher.failures != 1 || !publisher.willRetry {
+		t.Fatalf("agent:failed published %d times (willRetry %v), want once with a retry", publisher.failures, publisher.willRetry)
+	}
+	if !strings.Contains(publisher.lastError, "stalled") {
+		t.Errorf("agent:failed error = %q, want a stall", publisher.lastError)
+	}
+}
+
+func TestRunWorkerLoop_InsufficientDiskSpace(t *testing.T) {
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	executor := &fakeExecutor{paths: paths, results: []ExecutionResult{{ExitCode: 0}}}
+	dbtx := &workerDB{}
+	subtasks := &fakeSubtaskService{}
+	publisher := &failurePublisher{cancel: func() {}}
+	loop := NewAgentLoop(executor, renderer, LoopServices{
+		Repo:           repository.New(dbtx),
+		SubtaskService: subtasks,
+		EventPublisher: publisher,
+	}, 3)
+	// More free space than any disk has
+	loop.SetDiskSpaceGuard(service.NewDiskSpaceGuard(paths, 1<<30))
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login"}
+
+	err = loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token"))
+	if !domain.IsInsufficientDiskSpace(err) {
+		t.Fatalf("RunWorkerLoop() error = %v, want insufficient disk space", err)
+	}
+	if len(executor.attempts) != 0 || dbtx.runsCreated != 0 {
+		t.Errorf("ran attempts %v with %d run records, want none", executor.attempts, dbtx.runsCreated)
+	}
+	if subtasks.failed != 1 {
+		t.Errorf("subtask marked failed %d times, want 1", subtasks.failed)
+	}
+	if publisher.failures != 1 || publisher.willRetry {
+		t.Errorf("agent:failed published %d times (willRetry %v), want once without retry", publisher.failures, publisher.willRetry)
+	}
+}
+
+func TestRunWorkerLoop_StopsAtTokenBudget(t *testing.T) {
+	// A claude that uses 600 tokens per attempt without closing the issue
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho '{\"type\":\"result\",\"subtype\":\"error\",\"usage\":{\"input_tokens\":500,\"output_tokens\":100}}'\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte(script), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	tests := []struct {
+		name      string
+		usage     int // tokens used by earlier attempts
+		budget    int
+		wantRuns  int
+		wantUsage int
+	}{
+		{name: "budget passed by this attempt", usage: 900, budget: 1000, wantRuns: 1, wantUsage: 1500},
+		{name: "budget already used up", usage: 1000, budget: 1000, wantRuns: 0, wantUsage: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := config.NewDataPaths(t.TempDir(), "")
+			renderer, err := NewPromptRenderer(paths)
+			if err != nil {
+				t.Fatalf("NewPromptRenderer() error = %v", err)
+			}
+
+			dbtx := &workerDB{}
+			subtasks := &fakeSubtaskService{tokenUsage: tt.usage, tokenBudget: &tt.budget}
+			loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+				Repo:           repository.New(dbtx),
+				SubtaskService: subtasks,
+			}, 5)
+
+			project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+			subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", TokenUsage: tt.usage, TokenBudget: &tt.budget}
+
+			err = loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token"))
+			if !errors.Is(err, ErrTokenBudgetExceeded) {
+				t.Fatalf("RunWorkerLoop() error = %v, want ErrTokenBudgetExceeded", err)
+			}
+			if dbtx.runsCreated != tt.wantRuns {
+				t.Errorf("ran %d attempts, want %d of 5", dbtx.runsCreated, tt.wantRuns)
+			}
+			if subtasks.tokenUsage != tt.wantUsage {
+				t.Errorf("token usage = %d, want %d", subtasks.tokenUsage, tt.wantUsage)
+			}
+			if subtasks.budgetExceeded != 1 || subtasks.failed != 0 {
+				t.Errorf("MarkBudgetExceeded called %d times and MarkFailed %d; want 1 and 0", subtasks.budgetExceeded, subtasks.failed)
+			}
+		})
+	}
+}
+
+// fakeGitHubService reports a fixed set of changed files and counts pushes,
+// PRs, and squashes. The branch has one commit unless noCommits is set.
+// prToken is the token the last PR was opened with.
+type fakeGitHubService struct {
+	files     []ChangedFile
+	noCommits bool
+	pushes    int
+	prs       int
+	prToken   string
+	squashes  int
+	pushErr   error
+}
+
+func (g *fakeGitHubService) PushBranch(context.Context, string, string) error {
+	g.pushes++
+	return g.pushErr
+}
+
+func (g *fakeGitHubService) CreatePR(_ context.Context, _, _, accessToken, _, _, _, _ string) (*PRInfo, error) {
+	g.prs++
+	g.prToken = accessToken
+	return &PRInfo{Number: 1, HTMLURL: "https://github.com/o/r/pull/1"}, nil
+}
+
+func (g *fakeGitHubService) GetCommitMessages(context.Context, string, string) ([]string, error) {
+	if g.noCommits {
+		return nil, nil
+	}
+	return []string{"abc1234 Add login"}, nil
+}
+
+func (g *fakeGitHubService) SquashCommits(context.Context, string, string, string) (bool, error) {
+	g.squashes++
+	return true, nil
+}
+
+func (g *fakeGitHubService) GetChangedFiles(context.Context, string, string) ([]ChangedFile, error) {
+	return g.files, nil
+}
+
+func TestRunWorkerLoop_CompletesWithoutBeadsIssue(t *testing.T) {
+	// A claude that exits cleanly; the subtask has no beads issue to close
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	tests := []struct {
+		name          string
+		files         []ChangedFile
+		wantCompleted bool
+	}{
+		{name: "branch has changes", files: []ChangedFile{{Path: "login.go", Status: "A"}}, wantCompleted: true},
+		{name: "branch unchanged", files: nil, wantCompleted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := config.NewDataPaths(t.TempDir(), "")
+			renderer, err := NewPromptRenderer(paths)
+			if err != nil {
+				t.Fatalf("NewPromptRenderer() error = %v", err)
+			}
+
+			github := &fakeGitHubService{files: tt.files}
+			subtasks := &fakeSubtaskService{}
+			loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+				Repo:           repository.New(&workerDB{}),
+				SubtaskService: subtasks,
+				GitHubService:  github,
+			}, 1)
+
+			branch := "iv-1-add-login"
+			project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main"}
+			subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch}
+
+			err = loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token"))
+			if tt.wantCompleted {
+				if err != nil {
+					t.Fatalf("RunWorkerLoop() error = %v", err)
+				}
+				if subtasks.completed != 1 || github.prs != 1 {
+					t.Errorf("completed %d subtasks with %d PRs, want 1 and 1", subtasks.completed, github.prs)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("RunWorkerLoop() should fail when the branch has no changes")
+			}
+			if subtasks.completed != 0 || subtasks.failed != 1 {
+				t.Errorf("completed = %d, failed = %d; want 0 and 1", subtasks.completed, subtasks.failed)
+			}
+		})
+	}
+}
+
+func TestRunWorkerLoop_SquashBeforePR(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	for _, squash := range []bool{false, true} {
+		t.Run(fmt.Sprintf("squash_before_pr=%v", squash), func(t *testing.T) {
+			paths := config.NewDataPaths(t.TempDir(), "")
+			renderer, err := NewPromptRenderer(paths)
+			if err != nil {
+				t.Fatalf("NewPromptRenderer() error = %v", err)
+			}
+
+			github := &fakeGitHubService{files: []ChangedFile{{Path: "login.go", Status: "A"}}}
+			subtasks := &fakeSubtaskService{}
+			loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+				Repo:           repository.New(&workerDB{}),
+				SubtaskService: subtasks,
+				GitHubService:  github,
+			}, 1)
+
+			branch := "iv-1-add-login"
+			project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main", SquashBeforePR: squash}
+			subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch}
+
+			if err := loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token")); err != nil {
+				t.Fatalf("RunWorkerLoop() error = %v", err)
+			}
+			wantSquashes := 0
+			if squash {
+				wantSquashes = 1
+			}
+			if github.squashes != wantSquashes || github.prs != 1 {
+				t.Errorf("squashed %d times with %d PRs, want %d and 1", github.squashes, github.prs, wantSquashes)
+			}
+		})
+	}
+}
+
+func TestRunWorkerLoop_EmptyBranchCompletesWithoutPR(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	// The Worker finished but committed nothing, e.g. the change was already there
+	github := &fakeGitHubService{files: []ChangedFile{{Path: "login.go", Status: "M"}}, noCommits: true}
+	subtasks := &fakeSubtaskService{}
+	publisher := &failurePublisher{}
+	loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+		Repo:           repository.New(&workerDB{}),
+		SubtaskService: subtasks,
+		GitHubService:  github,
+		EventPublisher: publisher,
+	}, 1)
+
+	branch := "iv-1-add-login"
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main", SquashBeforePR: true}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch}
+
+	if err := loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token")); err != nil {
+		t.Fatalf("RunWorkerLoop() error = %v", err)
+	}
+	if subtasks.noChanges != 1 || subtasks.completed != 0 {
+		t.Errorf("completed without changes %d times and with a PR %d times, want 1 and 0", subtasks.noChanges, subtasks.completed)
+	}
+	if github.pushes != 0 || github.squashes != 0 || github.prs != 0 {
+		t.Errorf("pushed %d, squashed %d, opened %d PRs; want nothing for an empty branch", github.pushes, github.squashes, github.prs)
+	}
+	if publisher.completed != 1 || publisher.prURL != "" {
+		t.Errorf("published %d agent:completed with PR URL %q, want 1 without a PR", publisher.completed, publisher.prURL)
+	}
+}
+
+func TestRunWorkerLoop_PathPolicyViolation(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	// The Worker finished, but also changed the project's CI config
+	github := &fakeGitHubService{files: []ChangedFile{
+		{Path: "login.go", Status: "A"},
+		{Path: ".github/workflows/ci.yml", Status: "M"},
+	}}
+	subtasks := &fakeSubtaskService{}
+	publisher := &failurePublisher{cancel: func() {}}
+	dbtx := &workerDB{}
+	loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+		Repo:           repository.New(dbtx),
+		SubtaskService: subtasks,
+		GitHubService:  github,
+		EventPublisher: publisher,
+	}, 3)
+	loop.wait = func(context.Context, time.Duration) { t.Error("unexpected retry") }
+
+	branch := "iv-1-add-login"
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main", PathPolicy: domain.PathPolicy{Denied: []string{".github"}}}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch}
+
+	err = loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token"))
+	if !errors.Is(err, ErrPathPolicyViolation) {
+		t.Fatalf("RunWorkerLoop() error = %v, want ErrPathPolicyViolation", err)
+	}
+	if subtasks.violations != 1 || subtasks.completed != 0 || subtasks.failed != 0 {
+		t.Errorf("policy violations %d, completed %d, failed %d; want 1, 0 and 0", subtasks.violations, subtasks.completed, subtasks.failed)
+	}
+	if github.pushes != 0 || github.prs != 0 {
+		t.Errorf("pushed %d and opened %d PRs, want neither for a policy violation", github.pushes, github.prs)
+	}
+	if want := []string{string(domain.AgentRunStatusFailed)}; !slices.Equal(dbtx.runStatuses, want) {
+		t.Errorf("run statuses = %v, want %v", dbtx.runStatuses, want)
+	}
+	if publisher.failures != 1 || publisher.willRetry || !strings.Contains(publisher.lastError, ".github/workflows/ci.yml") || strings.Contains(publisher.lastError, "login.go") {
+		t.Errorf("published %d agent:failed (retry %v) with %q, want 1 naming only the forbidden path", publisher.failures, publisher.willRetry, publisher.lastError)
+	}
+}
+
+func TestRunWorkerLoop_RevokedTokenRequiresReauth(t *testing.T) {
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir)
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	github := &fakeGitHubService{
+		files:   []ChangedFile{{Path: "login.go", Status: "A"}},
+		pushErr: fmt.Errorf("%w: %w", service.ErrPushFailed, service.ErrTokenInvalid),
+	}
+	subtasks := &fakeSubtaskService{}
+	publisher := &failurePublisher{cancel: func() {}}
+	loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+		Repo:           repository.New(&workerDB{}),
+		SubtaskService: subtasks,
+		GitHubService:  github,
+		EventPublisher: publisher,
+	}, 1)
+
+	branch := "iv-1-add-login"
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main"}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch}
+
+	_ = loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token"))
+
+	if subtasks.completed != 0 || subtasks.failed != 1 || github.prs != 0 {
+		t.Errorf("completed = %d, failed = %d, PRs = %d; want 0, 1 and 0", subtasks.completed, subtasks.failed, github.prs)
+	}
+	if len(publisher.reauthOps) != 1 || publisher.reauthOps[0] != service.OperationPushBranch {
+		t.Errorf("github:reauth_required operations = %v, want [%s]", publisher.reauthOps, service.OperationPushBranch)
+	}
+}
+
+func TestRunWorkerLoop_UsesTokenCurrentAtPR(t *testing.T) {
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	// The user reconnects GitHub while the Worker is running
+	stored := "spawn-token"
+	executor := &fakeExecutor{paths: paths, results: []ExecutionResult{{ExitCode: 0}}, onStart: func() { stored = "reconnected-token" }}
+	github := &fakeGitHubService{files: []ChangedFile{{Path: "login.go", Status: "A"}}}
+	subtasks := &fakeSubtaskService{}
+	loop := NewAgentLoop(executor, renderer, LoopServices{
+		Repo:           repository.New(&workerDB{}),
+		SubtaskService: subtasks,
+		GitHubService:  github,
+	}, 1)
+	token := func(context.Context) (string, error) { return stored, nil }
+
+	branch := "iv-1-add-login"
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main"}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch}
+
+	if err := loop.RunWorkerLoop(context.Background(), subtask, project, token); err != nil {
+		t.Fatalf("RunWorkerLoop() error = %v", err)
+	}
+	if github.prs != 1 || github.prToken != "reconnected-token" {
+		t.Errorf("opened %d PRs with token %q, want 1 with the reconnected token", github.prs, github.prToken)
+	}
+	if subtasks.completed != 1 {
+		t.Errorf("completed = %d, want 1", subtasks.completed)
+	}
+}
+
+func TestRunWorkerLoop_TokenUnavailableCompletesWithoutPR(t *testing.T) {
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	github := &fakeGitHubService{files: []ChangedFile{{Path: "login.go", Status: "A"}}}
+	subtasks := &fakeSubtaskService{}
+	loop := NewAgentLoop(&fakeExecutor{paths: paths, results: []ExecutionResult{{ExitCode: 0}}}, renderer, LoopServices{
+		Repo:           repository.New(&workerDB{}),
+		SubtaskService: subtasks,
+		GitHubService:  github,
+	}, 1)
+	token := func(context.Context) (string, error) { return "", errors.New("failed to decrypt token") }
+
+	branch := "iv-1-add-login"
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir(), DefaultBranch: "main"}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", BranchName: &branch}
+
+	if err := loop.RunWorkerLoop(context.Background(), subtask, project, token); err != nil {
+		t.Fatalf("RunWorkerLoop() error = %v", err)
+	}
+	if github.pushes != 1 || github.prs != 0 || subtasks.completed != 1 {
+		t.Errorf("pushes = %d, PRs = %d, completed = %d; want the branch pushed and the subtask completed without a PR", github.pushes, github.prs, subtasks.completed)
+	}
+}
+
+// plannerBeads creates Planner worktrees as plain directories and records
+// which were created and removed. epic is what FindEpicByTaskID finds.
+type plannerBeads struct {
+	epic *BeadsIssue
+
+	mu      sync.Mutex
+	created []string
+	removed []string
+}
+
+func (b *plannerBeads) ShowIssue(context.Context, string, string) (*BeadsIssue, error) {
+	return nil, errors.New("unexpected ShowIssue")
+}
+
+func (b *plannerBeads) CloseIssue(context.Context, string, string, string) error {
+	return errors.New("unexpected CloseIssue")
+}
+
+func (b *plannerBeads) FindEpicByTaskID(context.Context, string, string) (*BeadsIssue, error) {
+	return b.epic, nil
+}
+
+func (b *plannerBeads) CreateWorktree(_ context.Context, _, worktreePath, _ string) error {
+	b.mu.Lock()
+	b.created = append(b.created, worktreePath)
+	b.mu.Unlock()
+	return os.MkdirAll(worktreePath, 0o755)
+}
+
+func (b *plannerBeads) RemoveWorktree(_ context.Context, _, worktreePath string) error {
+	b.mu.Lock()
+	b.removed = append(b.removed, worktreePath)
+	b.mu.Unlock()
+	return os.RemoveAll(worktreePath)
+}
+
+func (b *plannerBeads) PruneWorktrees(context.Context, string) error { return nil }
+
+func (b *plannerBeads) DeleteBranch(context.Context, string, string) error { return nil }
+
+// plannerTasks records which tasks finished planning, which failed, and
+// which were done without subtasks.
+type plannerTasks struct {
+	mu     sync.Mutex
+	active []uuid.UUID
+	failed []uuid.UUID
+	done   []uuid.UUID
+}
+
+func (s *plannerTasks) TransitionToActive(_ context.Context, taskID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = append(s.active, taskID)
+	return nil
+}
+
+func (s *plannerTasks) TransitionToAwaitingApproval(context.Context, uuid.UUID) error {
+	return errors.New("unexpected TransitionToAwaitingApproval")
+}
+
+func (s *plannerTasks) MarkPlanningFailed(_ context.Context, taskID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = append(s.failed, taskID)
+	return nil
+}
+
+func (s *plannerTasks) CompleteEmptyPlan(_ context.Context, taskID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = append(s.done, taskID)
+	return nil
+}
+
+func (s *plannerTasks) UpdateBeadsEpicID(context.Context, uuid.UUID, string) error {
+	return nil
+}
+
+// plannerSync syncs a plan of issues subtasks, failing like SyncService when
+// there are none.
+type plannerSync struct {
+	issues int
+}
+
+func (s *plannerSync) SyncTaskFromBeads(context.Context, uuid.UUID, string) error {
+	if s.issues == 0 {
+		return service.EmptyPlanError()
+	}
+	return nil
+}
+
+func (s *plannerSync) SyncNewIssuesFromBeads(context.Context, uuid.UUID, string) ([]*domain.Subtask, error) {
+	return nil, errors.New("unexpected SyncNewIssuesFromBeads")
+}
+
+func TestRunPlannerLoop_ConcurrentPlannersUseOwnWorktrees(t *testing.T) {
+	// A claude that writes a scratch file, and fails if another Planner
+	// overwrote it while it was working
+	binDir := t.TempDir()
+	script := "#!/bin/sh\necho $$ > plan.txt\nsleep 0.3\ntest \"$(cat plan.txt)\" = \"$$\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte(script), 0o755); err != nil { //nolint:gosec // test script must be executable
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	beads := &plannerBeads{epic: &BeadsIssue{ID: "bd-1"}}
+	tasks := &plannerTasks{}
+	loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+		Repo:         repository.New(&workerDB{}),
+		BeadsService: beads,
+		SyncService:  &plannerSync{issues: 1},
+		TaskService:  tasks,
+	}, 3)
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	var wg sync.WaitGroup
+	for range 2 {
+		task := &domain.Task{ID: uuid.New(), ProjectID: project.ID, Title: "Add login", Status: domain.TaskStatusPlanning}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := loop.RunPlannerLoop(context.Background(), task, project, StaticToken("token")); err != nil {
+				t.Errorf("RunPlannerLoop() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(tasks.active) != 2 || len(tasks.failed) != 0 {
+		t.Errorf("active = %d, failed = %d; want both tasks planned", len(tasks.active), len(tasks.failed))
+	}
+	if len(beads.created) != 2 || beads.created[0] == beads.created[1] {
+		t.Fatalf("created worktrees = %v, want one per task", beads.created)
+	}
+	for _, path := range beads.created {
+		if !slices.Contains(beads.removed, path) {
+			t.Errorf("worktree %s was not removed", path)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("worktree %s still exists", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(project.ClonePath, "plan.txt")); !os.IsNotExist(err) {
+		t.Error("a Planner ran in the main clone")
+	}
+}
+
+func TestRunPlannerLoop_EmptyPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		epic     *BeadsIssue
+		complete bool
+		wantDone bool
+	}{
+		{name: "empty epic fails planning", epic: &BeadsIssue{ID: "bd-1"}},
+		{name: "no epic fails planning"},
+		{name: "empty epic completes the task", epic: &BeadsIssue{ID: "bd-1"}, complete: true, wantDone: true},
+		{name: "no epic completes the task", complete: true, wantDone: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := config.NewDataPaths(t.TempDir(), "")
+			renderer, err := NewPromptRenderer(paths)
+			if err != nil {
+				t.Fatalf("NewPromptRenderer() error = %v", err)
+			}
+			tasks := &plannerTasks{}
+			loop := NewAgentLoop(&fakeExecutor{paths: paths, results: []ExecutionResult{{ExitCode: 0}}}, renderer, LoopServices{
+				Repo:         repository.New(&workerDB{}),
+				BeadsService: &plannerBeads{epic: tt.epic},
+				SyncService:  &plannerSync{},
+				TaskService:  tasks,
+			}, 3)
+			loop.SetCompleteEmptyPlans(tt.complete)
+
+			project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+			task := &domain.Task{ID: uuid.New(), ProjectID: project.ID, Title: "Fix typo", Status: domain.TaskStatusPlanning}
+			err = loop.RunPlannerLoop(context.Background(), task, project, StaticToken("token"))
+
+			if len(tasks.active) != 0 {
+				t.Error("a task without subtasks was made ACTIVE")
+			}
+			if tt.wantDone {
+				if err != nil {
+					t.Errorf("RunPlannerLoop() error = %v", err)
+				}
+				if len(tasks.done) != 1 || len(tasks.failed) != 0 {
+					t.Errorf("done = %d, failed = %d; want the task done", len(tasks.done), len(tasks.failed))
+				}
+				return
+			}
+			if !errors.Is(err, service.ErrEmptyPlan) {
+				t.Errorf("RunPlannerLoop() error = %v, want ErrEmptyPlan", err)
+			}
+			if len(tasks.failed) != 1 || len(tasks.done) != 0 {
+				t.Errorf("failed = %d, done = %d; want planning failed", len(tasks.failed), len(tasks.done))
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -12,16 +13,28 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/metrics"
 	"github.com/intern-village/orchestrator/internal/repository"
 	"github.com/intern-village/orchestrator/internal/service"
 )
 
+// ErrAgentKilled is the cancellation cause of an agent stopped by
+// KillAgentsForTask or KillAgentsForSubtask. The caller that kills a Worker
+// records the outcome of its run and subtask, so the Worker loop leaves them alone.
+var ErrAgentKilled = errors.New("agent killed")
+
+// ErrShutdown is the cancellation cause of agents stopped by Shutdown. A
+// Worker stopped by it records its run as INTERRUPTED rather than failed, so
+// Recovery resumes it on the next startup without using up a retry.
+var ErrShutdown = errors.New("orchestrator shutting down")
+
 // runningAgent represents a running agent with its cancel function.
 type runningAgent struct {
+	userID    uuid.UUID
 	taskID    uuid.UUID
 	subtaskID uuid.UUID
 	agentType domain.AgentType
-	cancel    context.CancelFunc
+	cancel    context.CancelCauseFunc
 }
 
 // AgentManager manages spawning and tracking of agents.
@@ -31,6 +44,10 @@ type AgentManager struct {
 	projectService *service.ProjectService
 	crypto         *repository.Crypto
 	eventHub       service.EventHub
+	metrics        *metrics.Metrics
+
+	// Per-user cap on concurrently running agents; 0 disables it
+	maxAgentsPerUser int
 
 	// Track running agents
 	mu            sync.RWMutex
@@ -39,7 +56,7 @@ type AgentManager struct {
 	// Shutdown handling
 	wg     sync.WaitGroup
 	ctx    context.Context
-	cancel context.CancelFunc
+	cancel context.CancelCauseFunc
 }
 
 // NewAgentManager creates a new AgentManager.
@@ -50,7 +67,7 @@ func NewAgentManager(
 	crypto *repository.Crypto,
 	eventHub service.EventHub,
 ) *AgentManager {
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancelCause(context.Background())
 	return &AgentManager{
 		loop:           loop,
 		repo:           repo,
@@ -63,42 +80,61 @@ func NewAgentManager(
 	}
 }
 
+// SetMetrics sets the metrics recorder for agent spawns and runs.
+func (m *AgentManager) SetMetrics(metrics *metrics.Metrics) {
+	m.metrics = metrics
+	m.loop.SetMetrics(metrics)
+}
+
+// SetMaxAgentsPerUser caps how many agents may run at once for one user.
+// Zero disables the cap.
+func (m *AgentManager) SetMaxAgentsPerUser(n int) {
+	m.maxAgentsPerUser = n
+}
+
 // SpawnPlanner spawns a Planner agent for a task.
 func (m *AgentManager) SpawnPlanner(ctx context.Context, task *domain.Task, project *domain.Project) error {
 	m.mu.Lock()
 	if _, exists := m.runningAgents[task.ID]; exists {
 		m.mu.Unlock()
-		return fmt.Errorf("planner already running for task %s", task.ID)
+		return fmt.Errorf("%w: planner for task %s", service.ErrAgentAlreadyRunning, task.ID)
+	}
+	if err := m.checkAgentQuotaLocked(project.UserID); err != nil {
+		m.mu.Unlock()
+		return err
 	}
 
 	// Create context for this agent
-	agentCtx, agentCancel := context.WithCancel(m.ctx)
+	agentCtx, agentCancel := context.WithCancelCause(m.ctx)
 
 	m.runningAgents[task.ID] = &runningAgent{
+		userID:    project.UserID,
 		taskID:    task.ID,
 		agentType: domain.AgentTypePlanner,
 		cancel:    agentCancel,
 	}
 	m.mu.Unlock()
 
-	// Get user token for GitHub operations
-	userToken, err := m.getUserToken(ctx, project.UserID)
-	if err != nil {
+	// Check the user's token now; the agent fetches it again when it needs it
+	if _, err := m.getUserToken(ctx, project.UserID); err != nil {
 		m.removeRunningAgent(task.ID)
+		m.metrics.AgentSpawnFailed(string(domain.AgentTypePlanner))
 		return fmt.Errorf("failed to get user token: %w", err)
 	}
 
 	// Run in goroutine
+	m.metrics.AgentStarted(string(domain.AgentTypePlanner))
 	m.wg.Add(1)
 	go func() {
 		defer m.wg.Done()
 		defer m.removeRunningAgent(task.ID)
+		defer m.metrics.AgentStopped(string(domain.AgentTypePlanner))
 
 		log.Info().
 			Str("task_id", task.ID.String()).
 			Msg("planner agent started")
 
-		if err := m.loop.RunPlannerLoop(agentCtx, task, project, userToken); err != nil {
+		if err := m.loop.RunPlannerLoop(agentCtx, task, project, m.userTokenSource(project.UserID)); err != nil {
 			log.Error().
 				Err(err).
 				Str("task_id", task.ID.String()).
@@ -114,13 +150,18 @@ func (m *AgentManager) SpawnWorker(ctx context.Context, subtask *domain.Subtask,
 	m.mu.Lock()
 	if _, exists := m.runningAgents[subtask.ID]; exists {
 		m.mu.Unlock()
-		return fmt.Errorf("worker already running for subtask %s", subtask.ID)
+		return fmt.Errorf("%w: worker for subtask %s", service.ErrAgentAlreadyRunning, subtask.ID)
+	}
+	if err := m.checkAgentQuotaLocked(project.UserID); err != nil {
+		m.mu.Unlock()
+		return err
 	}
 
 	// Create context for this agent
-	agentCtx, agentCancel := context.WithCancel(m.ctx)
+	agentCtx, agentCancel := context.WithCancelCause(m.ctx)
 
 	m.runningAgents[subtask.ID] = &runningAgent{
+		userID:    project.UserID,
 		subtaskID: subtask.ID,
 		taskID:    subtask.TaskID,
 		agentType: domain.AgentTypeWorker,
@@ -128,24 +169,26 @@ func (m *AgentManager) SpawnWorker(ctx context.Context, subtask *domain.Subtask,
 	}
 	m.mu.Unlock()
 
-	// Get user token for GitHub operations
-	userToken, err := m.getUserToken(ctx, project.UserID)
-	if err != nil {
+	// Check the user's token now; the agent fetches it again when it needs it
+	if _, err := m.getUserToken(ctx, project.UserID); err != nil {
 		m.removeRunningAgent(subtask.ID)
+		m.metrics.AgentSpawnFailed(string(domain.AgentTypeWorker))
 		return fmt.Errorf("failed to get user token: %w", err)
 	}
 
 	// Run in goroutine
+	m.metrics.AgentStarted(string(domain.AgentTypeWorker))
 	m.wg.Add(1)
 	go func() {
 		defer m.wg.Done()
 		defer m.removeRunningAgent(subtask.ID)
+		defer m.metrics.AgentStopped(string(domain.AgentTypeWorker))
 
 		log.Info().
 			Str("subtask_id", subtask.ID.String()).
 			Msg("worker agent started")
 
-		if err := m.loop.RunWorkerLoop(agentCtx, subtask, project, userToken); err != nil {
+		if err := m.loop.RunWorkerLoop(agentCtx, subtask, project, m.userTokenSource(project.UserID)); err != nil {
 			log.Error().
 				Err(err).
 				Str("subtask_id", subtask.ID.String()).
@@ -164,7 +207,7 @@ func (m *AgentManager) KillAgentsForTask(ctx context.Context, taskID uuid.UUID)
 	killed := 0
 	for id, agent := range m.runningAgents {
 		if agent.taskID == taskID {
-			agent.cancel()
+			agent.cancel(ErrAgentKilled)
 			delete(m.runningAgents, id)
 			killed++
 			log.Info().
@@ -189,7 +232,7 @@ func (m *AgentManager) KillAgentsForSubtask(ctx context.Context, subtaskID uuid.
 	defer m.mu.Unlock()
 
 	if agent, exists := m.runningAgents[subtaskID]; exists {
-		agent.cancel()
+		agent.cancel(ErrAgentKilled)
 		delete(m.runningAgents, subtaskID)
 		log.Info().
 			Str("subtask_id", subtaskID.String()).
@@ -211,6 +254,34 @@ func (m *AgentManager) GetRunningAgents() map[uuid.UUID]domain.AgentType {
 	return result
 }
 
+// CountRunningAgentsForUser returns how many agents are running for a user.
+func (m *AgentManager) CountRunningAgentsForUser(userID uuid.UUID) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.countAgentsLocked(userID)
+}
+
+// countAgentsLocked counts a user's running agents. The caller must hold m.mu.
+func (m *AgentManager) countAgentsLocked(userID uuid.UUID) int {
+	count := 0
+	for _, agent := range m.runningAgents {
+		if agent.userID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// checkAgentQuotaLocked fails if the user already has the maximum number of
+// agents running. The caller must hold m.mu, so the check and the claim of a
+// slot in runningAgents are atomic.
+func (m *AgentManager) checkAgentQuotaLocked(userID uuid.UUID) error {
+	if m.maxAgentsPerUser == 0 || m.countAgentsLocked(userID) < m.maxAgentsPerUser {
+		return nil
+	}
+	return domain.NewQuotaExceededError(service.QuotaConcurrentAgents, m.maxAgentsPerUser)
+}
+
 // IsRunning checks if an agent is running for the given ID.
 func (m *AgentManager) IsRunning(id uuid.UUID) bool {
 	m.mu.RLock()
@@ -220,12 +291,13 @@ func (m *AgentManager) IsRunning(id uuid.UUID) bool {
 }
 
 // Shutdown gracefully shuts down the agent manager.
-// It cancels all running agents and waits for them to complete.
+// It cancels all running agents with ErrShutdown and waits for them to
+// complete.
 func (m *AgentManager) Shutdown(ctx context.Context) error {
 	log.Info().Msg("shutting down agent manager")
 
 	// Cancel all running agents
-	m.cancel()
+	m.cancel(ErrShutdown)
 
 	// Wait for all agents to complete with timeout
 	done := make(chan struct{})
@@ -265,3 +337,12 @@ func (m *AgentManager) getUserToken(ctx context.Context, userID uuid.UUID) (stri
 
 	return token, nil
 }
+
+// userTokenSource returns a TokenSource that reads and decrypts the user's
+// stored GitHub token on each call, so an agent picks up a token the user
+// reconnected after it was spawned.
+func (m *AgentManager) userTokenSource(userID uuid.UUID) TokenSource {
+	return func(ctx context.Context) (string, error) {
+		return m.getUserToken(ctx, userID)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+func TestAgentManager_RefusesDuplicateSpawn(t *testing.T) {
+	m := NewAgentManager(nil, nil, nil, nil, nil)
+	task := &domain.Task{ID: uuid.New()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: task.ID}
+	project := &domain.Project{ID: uuid.New()}
+
+	m.runningAgents[task.ID] = &runningAgent{taskID: task.ID, agentType: domain.AgentTypePlanner}
+	m.runningAgents[subtask.ID] = &runningAgent{subtaskID: subtask.ID, agentType: domain.AgentTypeWorker}
+
+	if err := m.SpawnPlanner(context.Background(), task, project); !errors.Is(err, service.ErrAgentAlreadyRunning) {
+		t.Errorf("SpawnPlanner() error = %v, want ErrAgentAlreadyRunning", err)
+	}
+	if err := m.SpawnWorker(context.Background(), subtask, project); !errors.Is(err, service.ErrAgentAlreadyRunning) {
+		t.Errorf("SpawnWorker() error = %v, want ErrAgentAlreadyRunning", err)
+	}
+	if len(m.runningAgents) != 2 {
+		t.Errorf("running agents = %d, want the original 2", len(m.runningAgents))
+	}
+}
+
+func TestAgentManager_EnforcesPerUserAgentQuota(t *testing.T) {
+	m := NewAgentManager(nil, nil, nil, nil, nil)
+	m.SetMaxAgentsPerUser(2)
+
+	user, otherUser := uuid.New(), uuid.New()
+	m.runningAgents[uuid.New()] = &runningAgent{userID: user, agentType: domain.AgentTypeWorker}
+	m.runningAgents[uuid.New()] = &runningAgent{userID: user, agentType: domain.AgentTypeWorker}
+	m.runningAgents[uuid.New()] = &runningAgent{userID: otherUser, agentType: domain.AgentTypeWorker}
+
+	project := &domain.Project{ID: uuid.New(), UserID: user}
+	task := &domain.Task{ID: uuid.New()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: task.ID}
+
+	if err := m.SpawnPlanner(context.Background(), task, project); !domain.IsQuotaExceeded(err) {
+		t.Errorf("SpawnPlanner() error = %v, want quota exceeded", err)
+	}
+	if err := m.SpawnWorker(context.Background(), subtask, project); !domain.IsQuotaExceeded(err) {
+		t.Errorf("SpawnWorker() error = %v, want quota exceeded", err)
+	}
+
+	if got := m.CountRunningAgentsForUser(user); got != 2 {
+		t.Errorf("CountRunningAgentsForUser(user) = %d, want 2", got)
+	}
+	if got := m.CountRunningAgentsForUser(otherUser); got != 1 {
+		t.Errorf("CountRunningAgentsForUser(otherUser) = %d, want 1", got)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// buildPRTitle renders the pull request title for a completed subtask from the
+// project's template, or domain.DefaultPRTitleTemplate if it has none.
+func buildPRTitle(project *domain.Project, subtask *domain.Subtask, taskTitle string) string {
+	tmpl := ""
+	if project.PRTitleTemplate != nil {
+		tmpl = *project.PRTitleTemplate
+	}
+	beadsID := ""
+	if subtask.BeadsIssueID != nil {
+		beadsID = *subtask.BeadsIssueID
+	}
+	return domain.RenderPRTitle(tmpl, domain.PRTitleVars{
+		SubtaskID: subtask.ID.String()[:8],
+		BeadsID:   beadsID,
+		Title:     subtask.Title,
+		TaskTitle: taskTitle,
+	})
+}
+
+// buildSquashMessage renders the message of the single commit that replaces
+// a Worker's commits when its project squashes before the PR: the subtask
+// title, followed by the subjects of the squashed commits and then trailers.
+// commits are "<hash> <subject>" lines; the hashes are dropped as they do not
+// survive the squash.
+func buildSquashMessage(subtask *domain.Subtask, commits, trailers []string) string {
+	var b strings.Builder
+	b.WriteString(subtask.Title)
+	if len(commits) > 0 {
+		b.WriteString("\n\nSquashed commits:")
+		for _, c := range commits {
+			if _, subject, ok := strings.Cut(c, " "); ok {
+				c = subject
+			}
+			b.WriteString("\n- " + c)
+		}
+	}
+	b.WriteString("\n")
+	return appendTrailers(b.String(), trailers)
+}
+
+// maxPRBodyBytes is the longest PR body GitHub accepts (65536 characters;
+// counting bytes keeps multi-byte text safely under it).
+const maxPRBodyBytes = 65536
+
+// buildPRBody renders the pull request description for a completed subtask,
+// ending with trailers (see buildTrailers). A nil files slice means the diff
+// could not be computed and the "Files changed" section is omitted. A body
+// longer than maxPRBodyBytes is cut down by dropping commits from the end of
+// the list, then changed files, then the tail of the spec, each with a note
+// pointing at the branch.
+func buildPRBody(subtask *domain.Subtask, commits []string, files []ChangedFile, trailers []string) string {
+	spec := ""
+	if subtask.Spec != nil {
+		spec = *subtask.Spec
+	}
+	commitLines := make([]string, len(commits))
+	for i, c := range commits {
+		commitLines[i] = "- " + c
+	}
+	var fileLines []string
+	if files != nil {
+		fileLines = make([]string, len(files))
+		for i, f := range files {
+			if f.Binary {
+				fileLines[i] = fmt.Sprintf("- `%s` (%s, binary)", f.Path, f.Status)
+			} else {
+				fileLines[i] = fmt.Sprintf("- `%s` (%s, +%d −%d)", f.Path, f.Status, f.Additions, f.Deletions)
+			}
+		}
+	}
+
+	body := renderPRBody(subtask, spec, commitLines, files, fileLines, trailers)
+	over := len(body) - maxPRBodyBytes
+	if over <= 0 {
+		return body
+	}
+
+	where := "see the branch"
+	if subtask.BranchName != nil && *subtask.BranchName != "" {
+		where = fmt.Sprintf("see branch `%s`", *subtask.BranchName)
+	}
+	commitLines, over = truncateLines(commitLines, over, "commits", where)
+	fileLines, over = truncateLines(fileLines, over, "files", where)
+	spec = truncateText(spec, over, where)
+
+	return renderPRBody(subtask, spec, commitLines, files, fileLines, trailers)
+}
+
+// renderPRBody assembles the PR body from its rendered parts. Totals in the
+// "Files changed" header always cover every file in files, even when
+// fileLines has been truncated.
+func renderPRBody(subtask *domain.Subtask, spec string, commitLines []string, files []ChangedFile, fileLines, trailers []string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Summary\n\n%s\n\n", spec)
+
+	b.WriteString("## Commits\n\n")
+	for _, line := range commitLines {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+
+	if files != nil {
+		additions, deletions := 0, 0
+		for _, f := range files {
+			additions += f.Additions
+			deletions += f.Deletions
+		}
+		fmt.Fprintf(&b, "## Files changed\n\n%d files changed, +%d −%d\n\n", len(files), additions, deletions)
+		for _, line := range fileLines {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "Subtask: `%s`", subtask.ID)
+	if subtask.BeadsIssueID != nil && *subtask.BeadsIssueID != "" {
+		fmt.Fprintf(&b, " · Beads issue: `%s`", *subtask.BeadsIssueID)
+	}
+	b.WriteString("\n\n:robot: Generated by Intern Village")
+
+	return appendTrailers(b.String(), trailers)
+}
+
+// truncateLines drops lines from the end of a list, replacing them with a
+// "…and N more" note, until the list is at least over bytes shorter. It
+// returns the shortened list and how many bytes are still over, which is
+// non-zero only if dropping every line was not enough.
+func truncateLines(lines []string, over int, noun, where string) ([]string, int) {
+	if over <= 0 || len(lines) == 0 {
+		return lines, over
+	}
+
+	size := 0
+	for _, line := range lines {
+		size += len(line) + 1
+	}
+	kept := size
+	for k := len(lines) - 1; k >= 0; k-- {
+		kept -= len(lines[k]) + 1
+		note := fmt.Sprintf("- …and %d more %s, truncated; %s", len(lines)-k, noun, where)
+		if saved := size - kept - len(note) - 1; saved >= over || k == 0 {
+			return append(lines[:k:k], note), over - saved
+		}
+	}
+	return lines, over
+}
+
+// truncateText cuts over bytes, plus room for a truncation note, from the end
+// of text without splitting a UTF-8 character.
+func truncateText(text string, over int, where string) string {
+	if over <= 0 {
+		return text
+	}
+	note := fmt.Sprintf("\n\n…truncated, %s for the full spec", where)
+	cut := max(len(text)-over-len(note), 0)
+	return strings.ToValidUTF8(text[:cut], "") + note
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+func TestBuildPRBody(t *testing.T) {
+	spec := "Add the login form"
+	beadsID := "bd-42"
+	subtask := &domain.Subtask{
+		ID:           uuid.MustParse("11111111-2222-3333-4444-555555555555"),
+		Spec:         &spec,
+		BeadsIssueID: &beadsID,
+	}
+	files := []ChangedFile{
+		{Path: "login.go", Status: "A", Additions: 40},
+		{Path: "main.go", Status: "M", Additions: 2, Deletions: 1},
+		{Path: "logo.png", Status: "A", Binary: true},
+	}
+
+	body := buildPRBody(subtask, []string{"abc123 add login form"}, files, nil)
+
+	for _, want := range []string{
+		"## Summary\n\nAdd the login form",
+		"- abc123 add login form",
+		"## Files changed\n\n3 files changed, +42 −1",
+		"- `login.go` (A, +40 −0)",
+		"- `main.go` (M, +2 −1)",
+		"- `logo.png` (A, binary)",
+		"Subtask: `11111111-2222-3333-4444-555555555555` · Beads issue: `bd-42`",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("buildPRBody() missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestBuildPRBody_WithoutDiff(t *testing.T) {
+	subtask := &domain.Subtask{ID: uuid.New()}
+
+	body := buildPRBody(subtask, nil, nil, nil)
+
+	if strings.Contains(body, "## Files changed") {
+		t.Errorf("buildPRBody() should omit files section when diff failed:\n%s", body)
+	}
+	if strings.Contains(body, "Beads issue") {
+		t.Errorf("buildPRBody() should omit beads issue when unset:\n%s", body)
+	}
+	if !strings.Contains(body, "## Commits") {
+		t.Errorf("buildPRBody() missing commits section:\n%s", body)
+	}
+}
+
+func TestBuildPRBody_TruncationBoundary(t *testing.T) {
+	branch := "iv/login"
+	subtask := &domain.Subtask{ID: uuid.New(), BranchName: &branch}
+	commits := []string{"abc123 add login form"}
+
+	// Pad the spec so the body lands exactly on the limit
+	empty := ""
+	subtask.Spec = &empty
+	spec := strings.Repeat("s", maxPRBodyBytes-len(buildPRBody(subtask, commits, nil, nil)))
+	subtask.Spec = &spec
+
+	if body := buildPRBody(subtask, commits, nil, nil); len(body) != maxPRBodyBytes || strings.Contains(body, "truncated") {
+		t.Errorf("body at the limit should be unchanged, got %d bytes", len(body))
+	}
+
+	spec += "s"
+	body := buildPRBody(subtask, commits, nil, nil)
+	if len(body) > maxPRBodyBytes {
+		t.Errorf("body is %d bytes, want at most %d", len(body), maxPRBodyBytes)
+	}
+	if !strings.Contains(body, "more commits, truncated; see branch `iv/login`") {
+		t.Errorf("body should note the dropped commit:\n%s", body[len(body)-300:])
+	}
+}
+
+func TestBuildPRBody_TruncatesLongSections(t *testing.T) {
+	spec := strings.Repeat("é", maxPRBodyBytes)
+	subtask := &domain.Subtask{ID: uuid.New(), Spec: &spec}
+	commits := make([]string, 5000)
+	for i := range commits {
+		commits[i] = fmt.Sprintf("%07x commit number %d", i, i)
+	}
+	files := make([]ChangedFile, 3000)
+	for i := range files {
+		files[i] = ChangedFile{Path: fmt.Sprintf("pkg/file_%d.go", i), Status: "M", Additions: 1}
+	}
+
+	body := buildPRBody(subtask, commits, files, nil)
+
+	if len(body) > maxPRBodyBytes {
+		t.Errorf("body is %d bytes, want at most %d", len(body), maxPRBodyBytes)
+	}
+	if !utf8.ValidString(body) {
+		t.Error("body should stay valid UTF-8")
+	}
+	for _, want := range []string{
+		"## Summary\n\néé",
+		"…truncated, see the branch for the full spec",
+		"- …and 5000 more commits, truncated; see the branch",
+		"- …and 3000 more files, truncated; see the branch",
+		"3000 files changed, +3000 −0",
+		"Subtask: `" + subtask.ID.String() + "`",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("buildPRBody() missing %q", want)
+		}
+	}
+}
+
+func TestBuildPRTitle(t *testing.T) {
+	beadsID := "bd-42"
+	subtask := &domain.Subtask{
+		ID:           uuid.MustParse("11111111-2222-3333-4444-555555555555"),
+		Title:        "Add login form",
+		BeadsIssueID: &beadsID,
+	}
+	custom := "feat({beads_id}): {title} [{task_title}]"
+
+	tests := []struct {
+		name     string
+		template *string
+		want     string
+	}{
+		{name: "default format", template: nil, want: "[IV-11111111] Add login form"},
+		{name: "project template", template: &custom, want: "feat(bd-42): Add login form [User auth]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &domain.Project{PRTitleTemplate: tt.template}
+			if got := buildPRTitle(project, subtask, "User auth"); got != tt.want {
+				t.Errorf("buildPRTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSquashMessage(t *testing.T) {
+	subtask := &domain.Subtask{Title: "Add login form"}
+
+	got := buildSquashMessage(subtask, []string{"abc1234 add form", "def5678 fix typo"}, nil)
+	want := "Add login form\n\nSquashed commits:\n- add form\n- fix typo\n"
+	if got != want {
+		t.Errorf("buildSquashMessage() = %q, want %q", got, want)
+	}
+
+	if got, want := buildSquashMessage(subtask, nil, nil), "Add login form\n"; got != want {
+		t.Errorf("buildSquashMessage() without commits = %q, want %q", got, want)
+	}
+}
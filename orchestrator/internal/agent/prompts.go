@@ -5,13 +5,16 @@ package agent
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
 
 	"github.com/google/uuid"
+	"github.com/intern-village/orchestrator/internal/config"
 	"github.com/intern-village/orchestrator/internal/domain"
 	"github.com/intern-village/orchestrator/prompts"
 )
@@ -38,8 +41,25 @@ var templateFuncs = template.FuncMap{
 
 // PlannerContext is the template context for the Planner prompt.
 type PlannerContext struct {
-	Task    *domain.Task
-	Project *domain.Project
+	Task        *domain.Task
+	Project     *domain.Project
+	Attachments []PromptAttachment
+	// ExistingSubtasks is set when re-planning an ACTIVE task; the Planner
+	// then adds issues under the task's epic instead of creating a new plan.
+	ExistingSubtasks []PlannedSubtask
+}
+
+// PlannedSubtask is an existing subtask shown to a re-planning Planner.
+type PlannedSubtask struct {
+	BeadsID string
+	Title   string
+	Status  string
+}
+
+// PromptAttachment is a task attachment rendered into the Planner prompt.
+type PromptAttachment struct {
+	Name    string
+	Content string
 }
 
 // WorkerContext is the template context for the Worker prompt.
@@ -52,11 +72,11 @@ type WorkerContext struct {
 type PromptRenderer struct {
 	plannerTmpl *template.Template
 	workerTmpl  *template.Template
-	dataDir     string
+	paths       config.DataPaths
 }
 
 // NewPromptRenderer creates a new PromptRenderer.
-func NewPromptRenderer(dataDir string) (*PromptRenderer, error) {
+func NewPromptRenderer(paths config.DataPaths) (*PromptRenderer, error) {
 	// Load planner template
 	plannerContent, err := prompts.FS.ReadFile("planner.md")
 	if err != nil {
@@ -82,15 +102,29 @@ func NewPromptRenderer(dataDir string) (*PromptRenderer, error) {
 	return &PromptRenderer{
 		plannerTmpl: plannerTmpl,
 		workerTmpl:  workerTmpl,
-		dataDir:     dataDir,
+		paths:       paths,
 	}, nil
 }
 
-// RenderPlannerPrompt renders the Planner prompt template.
+// RenderPlannerPrompt renders the Planner prompt template, including the
+// task's attachments verbatim.
 func (r *PromptRenderer) RenderPlannerPrompt(task *domain.Task, project *domain.Project) (string, error) {
+	return r.RenderReplanPrompt(task, project, nil)
+}
+
+// RenderReplanPrompt renders the Planner prompt for re-planning a task that
+// already has subtasks. With no existing subtasks it is RenderPlannerPrompt.
+func (r *PromptRenderer) RenderReplanPrompt(task *domain.Task, project *domain.Project, existing []PlannedSubtask) (string, error) {
+	attachments, err := r.loadAttachments(task.ProjectID.String(), task.ID.String())
+	if err != nil {
+		return "", err
+	}
+
 	ctx := PlannerContext{
-		Task:    task,
-		Project: project,
+		Task:             task,
+		Project:          project,
+		Attachments:      attachments,
+		ExistingSubtasks: existing,
 	}
 
 	var buf bytes.Buffer
@@ -101,6 +135,32 @@ func (r *PromptRenderer) RenderPlannerPrompt(task *domain.Task, project *domain.
 	return buf.String(), nil
 }
 
+// loadAttachments reads a task's attachments in name order. A task without
+// attachments has no directory.
+func (r *PromptRenderer) loadAttachments(projectID, taskID string) ([]PromptAttachment, error) {
+	dir := r.paths.Attachments(projectID, taskID)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachments: %w", err)
+	}
+
+	var attachments []PromptAttachment
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment %s: %w", entry.Name(), err)
+		}
+		attachments = append(attachments, PromptAttachment{Name: entry.Name(), Content: string(content)})
+	}
+	return attachments, nil
+}
+
 // RenderWorkerPrompt renders the Worker prompt template.
 func (r *PromptRenderer) RenderWorkerPrompt(subtask *domain.Subtask, project *domain.Project) (string, error) {
 	ctx := WorkerContext{
@@ -120,7 +180,7 @@ func (r *PromptRenderer) RenderWorkerPrompt(subtask *domain.Subtask, project *do
 // Returns the path to the saved file.
 func (r *PromptRenderer) SavePrompt(content, projectID, taskID, subtaskID string) (string, error) {
 	// Create directory structure: /data/prompts/{project_id}/{task_id}/
-	promptDir := filepath.Join(r.dataDir, "prompts", projectID, taskID)
+	promptDir := r.paths.Prompts(projectID, taskID)
 	if err := os.MkdirAll(promptDir, 0o755); err != nil {
 		return "", fmt.Errorf("failed to create prompt directory: %w", err)
 	}
@@ -143,7 +203,7 @@ func (r *PromptRenderer) SavePrompt(content, projectID, taskID, subtaskID string
 
 // GetPromptPath returns the expected path for a prompt file.
 func (r *PromptRenderer) GetPromptPath(projectID, taskID, subtaskID string) string {
-	promptDir := filepath.Join(r.dataDir, "prompts", projectID, taskID)
+	promptDir := r.paths.Prompts(projectID, taskID)
 	if subtaskID != "" {
 		return filepath.Join(promptDir, fmt.Sprintf("%s.md", subtaskID))
 	}
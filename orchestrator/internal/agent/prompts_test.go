@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/intern-village/orchestrator/internal/config"
 	"github.com/intern-village/orchestrator/internal/domain"
 )
 
@@ -22,7 +23,7 @@ func TestNewPromptRenderer(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	renderer, err := NewPromptRenderer(tmpDir)
+	renderer, err := NewPromptRenderer(config.NewDataPaths(tmpDir, ""))
 	if err != nil {
 		t.Fatalf("NewPromptRenderer() error = %v", err)
 	}
@@ -31,8 +32,8 @@ func TestNewPromptRenderer(t *testing.T) {
 		t.Fatal("NewPromptRenderer() returned nil")
 	}
 
-	if renderer.dataDir != tmpDir {
-		t.Errorf("dataDir = %q, want %q", renderer.dataDir, tmpDir)
+	if renderer.paths.Root() != tmpDir {
+		t.Errorf("data root = %q, want %q", renderer.paths.Root(), tmpDir)
 	}
 }
 
@@ -43,7 +44,7 @@ func TestRenderPlannerPrompt(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	renderer, err := NewPromptRenderer(tmpDir)
+	renderer, err := NewPromptRenderer(config.NewDataPaths(tmpDir, ""))
 	if err != nil {
 		t.Fatalf("NewPromptRenderer() error = %v", err)
 	}
@@ -54,6 +55,7 @@ func TestRenderPlannerPrompt(t *testing.T) {
 		Title:       "Add user authentication",
 		Description: "Implement OAuth login with GitHub",
 		Status:      domain.TaskStatusPlanning,
+		BaseBranch:  "release/2.0",
 	}
 
 	project := &domain.Project{
@@ -74,7 +76,7 @@ func TestRenderPlannerPrompt(t *testing.T) {
 		"Add user authentication",
 		"Implement OAuth login with GitHub",
 		"testowner/testrepo",
-		"main",
+		"release/2.0",
 		"/data/projects/test",
 		"Planner Agent",
 	}
@@ -84,6 +86,86 @@ func TestRenderPlannerPrompt(t *testing.T) {
 			t.Errorf("prompt does not contain expected content: %q", expected)
 		}
 	}
+	if strings.Contains(prompt, "## Attachments") {
+		t.Error("prompt without attachments should not have an Attachments section")
+	}
+}
+
+func TestRenderPlannerPrompt_Attachments(t *testing.T) {
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	project := &domain.Project{ID: uuid.New(), GitHubOwner: "testowner", GitHubRepo: "testrepo"}
+	task := &domain.Task{ID: uuid.New(), ProjectID: project.ID, Title: "Fix crash", Description: "See the log"}
+
+	dir := paths.Attachments(project.ID.String(), task.ID.String())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "error.log"), []byte("panic: nil map"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt, err := renderer.RenderPlannerPrompt(task, project)
+	if err != nil {
+		t.Fatalf("RenderPlannerPrompt() error = %v", err)
+	}
+	for _, expected := range []string{"## Attachments", "### error.log", "panic: nil map"} {
+		if !strings.Contains(prompt, expected) {
+			t.Errorf("prompt does not contain expected content: %q", expected)
+		}
+	}
+}
+
+func TestRenderReplanPrompt(t *testing.T) {
+	renderer, err := NewPromptRenderer(config.NewDataPaths(t.TempDir(), ""))
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	epicID := "bd-7"
+	project := &domain.Project{ID: uuid.New(), GitHubOwner: "testowner", GitHubRepo: "testrepo"}
+	task := &domain.Task{ID: uuid.New(), ProjectID: project.ID, Title: "Add auth", Status: domain.TaskStatusActive, BeadsEpicID: &epicID}
+	existing := []PlannedSubtask{
+		{BeadsID: "bd-8", Title: "Add OAuth callback", Status: "MERGED"},
+		{BeadsID: "bd-9", Title: "Store sessions", Status: "IN_PROGRESS"},
+	}
+
+	prompt, err := renderer.RenderReplanPrompt(task, project, existing)
+	if err != nil {
+		t.Fatalf("RenderReplanPrompt() error = %v", err)
+	}
+	for _, expected := range []string{
+		"## Existing Subtasks",
+		"| `bd-8` | Add OAuth callback | MERGED |",
+		"| `bd-9` | Store sessions | IN_PROGRESS |",
+		"create new issues under `bd-7`",
+		"Leave the epic as it is",
+	} {
+		if !strings.Contains(prompt, expected) {
+			t.Errorf("prompt does not contain expected content: %q", expected)
+		}
+	}
+	for _, unexpected := range []string{"--type epic", "Ignore any existing beads issues", "bd close"} {
+		if strings.Contains(prompt, unexpected) {
+			t.Errorf("re-plan prompt should not contain %q", unexpected)
+		}
+	}
+
+	// Without existing subtasks it is the normal Planner prompt
+	plain, err := renderer.RenderReplanPrompt(task, project, nil)
+	if err != nil {
+		t.Fatalf("RenderReplanPrompt() error = %v", err)
+	}
+	if want, _ := renderer.RenderPlannerPrompt(task, project); plain != want {
+		t.Error("RenderReplanPrompt() without subtasks should match RenderPlannerPrompt()")
+	}
+	if !strings.Contains(plain, "--type epic") {
+		t.Error("planner prompt should create the epic")
+	}
 }
 
 func TestRenderWorkerPrompt(t *testing.T) {
@@ -93,7 +175,7 @@ func TestRenderWorkerPrompt(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	renderer, err := NewPromptRenderer(tmpDir)
+	renderer, err := NewPromptRenderer(config.NewDataPaths(tmpDir, ""))
 	if err != nil {
 		t.Fatalf("NewPromptRenderer() error = %v", err)
 	}
@@ -153,7 +235,7 @@ func TestSavePrompt(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	renderer, err := NewPromptRenderer(tmpDir)
+	renderer, err := NewPromptRenderer(config.NewDataPaths(tmpDir, ""))
 	if err != nil {
 		t.Fatalf("NewPromptRenderer() error = %v", err)
 	}
@@ -203,7 +285,7 @@ func TestSavePrompt(t *testing.T) {
 }
 
 func TestGetPromptPath(t *testing.T) {
-	renderer := &PromptRenderer{dataDir: "/data"}
+	renderer := &PromptRenderer{paths: config.NewDataPaths("/data", "")}
 
 	tests := []struct {
 		name        string
@@ -5,12 +5,18 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 
 	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/config"
 	"github.com/intern-village/orchestrator/internal/domain"
 	"github.com/intern-village/orchestrator/internal/repository"
 )
@@ -22,12 +28,51 @@ type Recovery struct {
 	projectService ProjectServiceInterface
 	taskService    TaskServiceInterface
 	subtaskService SubtaskServiceInterface
+	worktrees      WorktreeCleanerInterface
 	maxRetries     int
+	config         RecoveryConfig
+}
+
+// RecoveryConfig holds configuration for stale agent detection. Every RUNNING
+// run found at startup is dead; the cutoffs only decide whether its attempt
+// counts against the retries.
+type RecoveryConfig struct {
+	// PlannerStaleAfter is how long a RUNNING planner run may go without log
+	// activity before it is considered stale.
+	PlannerStaleAfter time.Duration
+
+	// WorkerStaleAfter is how long a RUNNING worker run may go without log
+	// activity before it is considered stale.
+	WorkerStaleAfter time.Duration
+}
+
+// DefaultRecoveryConfig returns the default recovery configuration.
+func DefaultRecoveryConfig() RecoveryConfig {
+	return RecoveryConfig{
+		PlannerStaleAfter: 5 * time.Minute,
+		WorkerStaleAfter:  15 * time.Minute,
+	}
+}
+
+// staleAfter returns the stale cutoff for the given agent type.
+func (c RecoveryConfig) staleAfter(agentType domain.AgentType) time.Duration {
+	if agentType == domain.AgentTypePlanner {
+		return c.PlannerStaleAfter
+	}
+	return c.WorkerStaleAfter
+}
+
+// WorktreeCleanerInterface defines the worktree operations used for recovery.
+type WorktreeCleanerInterface interface {
+	EnsureWorktree(ctx context.Context, repoPath, worktreePath, branch string) error
+	RemoveWorktree(ctx context.Context, repoPath, worktreePath string) error
+	PruneWorktrees(ctx context.Context, repoPath string) error
 }
 
 // ProjectServiceInterface defines the project service methods used for recovery.
 type ProjectServiceInterface interface {
 	GetProjectByIDInternal(ctx context.Context, projectID uuid.UUID) (*domain.Project, error)
+	WorktreeRoot(projectID uuid.UUID) string
 }
 
 // NewRecovery creates a new Recovery instance.
@@ -37,19 +82,32 @@ func NewRecovery(
 	projectService ProjectServiceInterface,
 	taskService TaskServiceInterface,
 	subtaskService SubtaskServiceInterface,
+	worktrees WorktreeCleanerInterface,
 	maxRetries int,
+	config RecoveryConfig,
 ) *Recovery {
+	defaults := DefaultRecoveryConfig()
+	if config.PlannerStaleAfter <= 0 {
+		config.PlannerStaleAfter = defaults.PlannerStaleAfter
+	}
+	if config.WorkerStaleAfter <= 0 {
+		config.WorkerStaleAfter = defaults.WorkerStaleAfter
+	}
+
 	return &Recovery{
 		repo:           repo,
 		manager:        manager,
 		projectService: projectService,
 		taskService:    taskService,
 		subtaskService: subtaskService,
+		worktrees:      worktrees,
 		maxRetries:     maxRetries,
+		config:         config,
 	}
 }
 
-// RecoverStaleAgents recovers any agent runs that were marked as RUNNING but have no active process.
+// RecoverStaleAgents recovers any agent runs that were marked as RUNNING but have no active process,
+// then resumes Workers interrupted by the last shutdown (see resumeInterruptedWorkers).
 // This should be called on orchestrator startup.
 func (r *Recovery) RecoverStaleAgents(ctx context.Context) error {
 	log.Info().Msg("checking for stale agent runs")
@@ -62,24 +120,39 @@ func (r *Recovery) RecoverStaleAgents(ctx context.Context) error {
 
 	if len(runningRuns) == 0 {
 		log.Info().Msg("no stale agent runs found")
-		return nil
+		return r.resumeInterruptedWorkers(ctx)
 	}
 
-	log.Info().Int("count", len(runningRuns)).Msg("found stale agent runs")
+	log.Info().Int("count", len(runningRuns)).Msg("found running agent runs")
 
-	// Mark stale runs as failed (anything started more than 5 minutes ago with no process)
-	cutoff := time.Now().Add(-5 * time.Minute)
-	err = r.repo.MarkStaleAgentRunsFailed(ctx, cutoff)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to mark stale agent runs as failed")
-	}
-
-	// Group runs by subtask to determine if we should restart
-	// Note: Planner runs have SubtaskID as nil, they are task-level runs
+	// Agents run inside this process, so a RUNNING run without an agent in the
+	// manager lost its process with the previous orchestrator. A stale run (older
+	// than the cutoff for its agent type, with no log write within it) is marked
+	// failed and its attempt counts against the retries. A recent one was most
+	// likely cut short by the crash, so it is marked interrupted, its retry is
+	// given back, and resumeInterruptedWorkers restarts it.
+	now := time.Now()
 	subtaskRuns := make(map[uuid.UUID][]db.AgentRun)
 	for _, run := range runningRuns {
-		// Skip Planner runs (they have nil SubtaskID) - they don't need recovery
-		// since they run synchronously during task creation
+		if r.manager != nil && r.manager.IsRunning(runAgentID(run)) {
+			log.Info().
+				Str("run_id", run.ID.String()).
+				Str("agent_type", run.AgentType).
+				Msg("agent run is still active, skipping recovery")
+			continue
+		}
+
+		agentType := domain.AgentType(run.AgentType)
+		if !isRunStale(run, r.config.staleAfter(agentType), now) {
+			r.markRunInterrupted(ctx, run)
+			continue
+		}
+
+		r.markRunStale(ctx, run.ID)
+
+		// Group runs by subtask to determine if we should restart
+		// Note: Planner runs have SubtaskID as nil, they are task-level runs
+		// and don't need recovery since they run synchronously during task creation
 		if !run.SubtaskID.Valid {
 			continue
 		}
@@ -96,9 +169,106 @@ func (r *Recovery) RecoverStaleAgents(ctx context.Context) error {
 		}
 	}
 
+	return r.resumeInterruptedWorkers(ctx)
+}
+
+// resumeInterruptedWorkers restarts the Workers of IN_PROGRESS subtasks whose
+// latest run was interrupted by a shutdown. Unlike a stale run, the
+// interrupted attempt is not counted against the retries.
+func (r *Recovery) resumeInterruptedWorkers(ctx context.Context) error {
+	runs, err := r.repo.ListInterruptedWorkerRuns(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range runs {
+		subtaskID := pgtypeToUUID(run.SubtaskID)
+		log.Info().
+			Str("subtask_id", subtaskID.String()).
+			Int32("attempt", run.AttemptNumber).
+			Msg("resuming worker interrupted by shutdown")
+
+		subtask, err := r.repo.GetSubtaskByID(ctx, subtaskID)
+		if err == nil {
+			err = r.restartWorker(ctx, subtask)
+		}
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("subtask_id", subtaskID.String()).
+				Msg("failed to resume interrupted worker")
+		}
+	}
+
 	return nil
 }
 
+// runAgentID returns the key AgentManager tracks a run's agent under: the
+// subtask for a Worker, the task for a Planner.
+func runAgentID(run db.AgentRun) uuid.UUID {
+	if run.SubtaskID.Valid {
+		return pgtypeToUUID(run.SubtaskID)
+	}
+	return pgtypeToUUID(run.TaskID)
+}
+
+// isRunStale reports whether a dead RUNNING agent run's attempt should count
+// against the retries. Runs started within the cutoff are never stale. Older
+// runs are not stale either if their log file was modified within the cutoff.
+func isRunStale(run db.AgentRun, cutoff time.Duration, now time.Time) bool {
+	if now.Sub(run.StartedAt) < cutoff {
+		return false
+	}
+
+	if run.LogPath != "" {
+		if info, err := os.Stat(run.LogPath); err == nil && now.Sub(info.ModTime()) < cutoff {
+			return false
+		}
+	}
+
+	return true
+}
+
+// markRunStale marks an agent run as failed because it was found stale during recovery.
+func (r *Recovery) markRunStale(ctx context.Context, runID uuid.UUID) {
+	now := time.Now()
+	errorMsg := "agent run was stale on orchestrator startup"
+	_, err := r.repo.UpdateAgentRunStatus(ctx, db.UpdateAgentRunStatusParams{
+		ID:           runID,
+		Status:       string(domain.AgentRunStatusFailed),
+		EndedAt:      repository.PointerToTimestamptz(&now),
+		ErrorMessage: &errorMsg,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("run_id", runID.String()).Msg("failed to mark stale agent run as failed")
+	}
+}
+
+// markRunInterrupted marks a recent agent run that lost its process as
+// interrupted and, for a Worker, gives back the retry its attempt used, as
+// for a run interrupted by a shutdown.
+func (r *Recovery) markRunInterrupted(ctx context.Context, run db.AgentRun) {
+	now := time.Now()
+	errorMsg := "agent process was lost when the orchestrator stopped"
+	_, err := r.repo.UpdateAgentRunStatus(ctx, db.UpdateAgentRunStatusParams{
+		ID:           run.ID,
+		Status:       string(domain.AgentRunStatusInterrupted),
+		EndedAt:      repository.PointerToTimestamptz(&now),
+		ErrorMessage: &errorMsg,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("run_id", run.ID.String()).Msg("failed to mark lost agent run interrupted")
+		return
+	}
+	if !run.SubtaskID.Valid {
+		return
+	}
+	subtaskID := pgtypeToUUID(run.SubtaskID)
+	if _, err := r.subtaskService.DecrementRetryCount(ctx, subtaskID); err != nil {
+		log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to give back retry of lost worker")
+	}
+}
+
 // recoverSubtask attempts to recover a subtask with stale agent runs.
 func (r *Recovery) recoverSubtask(ctx context.Context, subtaskID uuid.UUID, runs []db.AgentRun) error {
 	if len(runs) == 0 {
@@ -166,6 +336,7 @@ func (r *Recovery) recoverPlannerRun(ctx context.Context, taskID uuid.UUID, run
 			Description: task.Description,
 			Status:      domain.TaskStatus(task.Status),
 			BeadsEpicID: task.BeadsEpicID,
+			DryRun:      task.DryRun,
 			CreatedAt:   task.CreatedAt,
 			UpdatedAt:   task.UpdatedAt,
 		}
@@ -207,60 +378,243 @@ func (r *Recovery) recoverWorkerRun(ctx context.Context, subtaskID uuid.UUID, ru
 
 	// Subtask is still IN_PROGRESS, we can restart the worker
 	if domain.SubtaskStatus(subtask.Status) == domain.SubtaskStatusInProgress {
-		log.Info().
-			Str("subtask_id", subtaskID.String()).
-			Msg("restarting worker for subtask")
+		return r.restartWorker(ctx, subtask)
+	}
 
-		// Get task and project
-		task, err := r.repo.GetTaskByID(ctx, subtask.TaskID)
-		if err != nil {
+	return nil
+}
+
+// restartWorker spawns a Worker for an IN_PROGRESS subtask whose previous
+// Worker is gone, first repairing its worktree.
+func (r *Recovery) restartWorker(ctx context.Context, subtask db.Subtask) error {
+	subtaskID := subtask.ID
+	log.Info().
+		Str("subtask_id", subtaskID.String()).
+		Msg("restarting worker for subtask")
+
+	// Get task and project
+	task, err := r.repo.GetTaskByID(ctx, subtask.TaskID)
+	if err != nil {
+		return err
+	}
+
+	project, err := r.projectService.GetProjectByIDInternal(ctx, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	// Convert db subtask to domain subtask
+	var blockedReason *domain.BlockedReason
+	if subtask.BlockedReason != nil {
+		r := domain.BlockedReason(*subtask.BlockedReason)
+		blockedReason = &r
+	}
+
+	var prNumber *int
+	if subtask.PrNumber != nil {
+		n := int(*subtask.PrNumber)
+		prNumber = &n
+	}
+
+	domainSubtask := &domain.Subtask{
+		ID:                 subtask.ID,
+		TaskID:             subtask.TaskID,
+		Title:              subtask.Title,
+		Spec:               subtask.Spec,
+		ImplementationPlan: subtask.ImplementationPlan,
+		Status:             domain.SubtaskStatus(subtask.Status),
+		BlockedReason:      blockedReason,
+		BranchName:         subtask.BranchName,
+		PRUrl:              subtask.PrUrl,
+		PRNumber:           prNumber,
+		RetryCount:         int(subtask.RetryCount),
+		TokenUsage:         int(subtask.TokenUsage),
+		Position:           int(subtask.Position),
+		BeadsIssueID:       subtask.BeadsIssueID,
+		WorktreePath:       subtask.WorktreePath,
+		CreatedAt:          subtask.CreatedAt,
+		UpdatedAt:          subtask.UpdatedAt,
+	}
+
+	// The crash may have left the worktree locked or half created
+	if subtask.WorktreePath != nil && subtask.BranchName != nil {
+		if err := r.worktrees.EnsureWorktree(ctx, project.ClonePath, *subtask.WorktreePath, *subtask.BranchName); err != nil {
+			log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to recover worker worktree")
 			return err
 		}
+	}
 
-		project, err := r.projectService.GetProjectByIDInternal(ctx, task.ProjectID)
+	// Restart the worker
+	if err := r.manager.SpawnWorker(ctx, domainSubtask, project); err != nil {
+		log.Error().Err(err).Msg("failed to restart worker")
+		return err
+	}
+
+	return nil
+}
+
+// RecoverOrphanedWorktrees removes worktrees left behind by subtasks that no longer
+// need them, then prunes stale worktree metadata in each project clone.
+// It must run after RecoverStaleAgents so that restarted workers keep their worktrees.
+func (r *Recovery) RecoverOrphanedWorktrees(ctx context.Context) error {
+	log.Info().Msg("checking for orphaned worktrees")
+
+	projects, err := r.repo.ListAllProjects(ctx)
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, project := range projects {
+		// Legacy worktrees live inside the clone; current ones under the worktree root
+		total += r.cleanupProjectWorktrees(ctx, project.ClonePath, project.ClonePath)
+		if r.projectService != nil {
+			total += r.cleanupProjectWorktrees(ctx, project.ClonePath, r.projectService.WorktreeRoot(project.ID))
+		}
+
+		if err := r.worktrees.PruneWorktrees(ctx, project.ClonePath); err != nil {
+			log.Warn().Err(err).Str("clone_path", project.ClonePath).Msg("failed to prune worktrees")
+		}
+	}
+
+	log.Info().Int("removed", total).Msg("orphaned worktree cleanup complete")
+	return nil
+}
+
+// cleanupProjectWorktrees removes orphaned worktrees of a project clone found in dir.
+// Worktrees live at {dir}/{subtaskID}, so any directory named with a UUID is a candidate.
+// Planner worktrees ({dir}/planner-{taskID}) are removed unless their Planner was
+// restarted; a Planner removes its own when it finishes.
+func (r *Recovery) cleanupProjectWorktrees(ctx context.Context, clonePath, dir string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error().Err(err).Str("dir", dir).Msg("failed to read worktree directory")
+		}
+		return 0
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		if taskIDStr, ok := strings.CutPrefix(entry.Name(), config.PlannerWorktreePrefix); ok {
+			if taskID, err := uuid.Parse(taskIDStr); err == nil && r.removeOrphanedPlannerWorktree(ctx, clonePath, filepath.Join(dir, entry.Name()), taskID) {
+				removed++
+			}
+			continue
+		}
+
+		subtaskID, err := uuid.Parse(entry.Name())
 		if err != nil {
-			return err
+			continue // Not a worktree
 		}
 
-		// Convert db subtask to domain subtask
-		var blockedReason *domain.BlockedReason
-		if subtask.BlockedReason != nil {
-			r := domain.BlockedReason(*subtask.BlockedReason)
-			blockedReason = &r
+		// Never touch worktrees of agents that are running (e.g. just recovered)
+		if r.manager != nil && r.manager.IsRunning(subtaskID) {
+			continue
 		}
 
-		var prNumber *int
-		if subtask.PrNumber != nil {
-			n := int(*subtask.PrNumber)
-			prNumber = &n
+		worktreePath := filepath.Join(dir, entry.Name())
+		reason, remove := r.worktreeRemovalReason(ctx, subtaskID, worktreePath)
+		if !remove {
+			continue
 		}
 
-		domainSubtask := &domain.Subtask{
-			ID:                 subtask.ID,
-			TaskID:             subtask.TaskID,
-			Title:              subtask.Title,
-			Spec:               subtask.Spec,
-			ImplementationPlan: subtask.ImplementationPlan,
-			Status:             domain.SubtaskStatus(subtask.Status),
-			BlockedReason:      blockedReason,
-			BranchName:         subtask.BranchName,
-			PRUrl:              subtask.PrUrl,
-			PRNumber:           prNumber,
-			RetryCount:         int(subtask.RetryCount),
-			TokenUsage:         int(subtask.TokenUsage),
-			Position:           int(subtask.Position),
-			BeadsIssueID:       subtask.BeadsIssueID,
-			WorktreePath:       subtask.WorktreePath,
-			CreatedAt:          subtask.CreatedAt,
-			UpdatedAt:          subtask.UpdatedAt,
+		if err := r.worktrees.RemoveWorktree(ctx, clonePath, worktreePath); err != nil {
+			// Half-created worktrees may not be registered with git, so remove the directory directly
+			if rmErr := os.RemoveAll(worktreePath); rmErr != nil {
+				log.Error().
+					Err(rmErr).
+					Str("worktree_path", worktreePath).
+					Msg("failed to remove orphaned worktree")
+				continue
+			}
 		}
 
-		// Restart the worker
-		if err := r.manager.SpawnWorker(ctx, domainSubtask, project); err != nil {
-			log.Error().Err(err).Msg("failed to restart worker")
-			return err
+		log.Info().
+			Str("subtask_id", subtaskID.String()).
+			Str("worktree_path", worktreePath).
+			Str("reason", reason).
+			Msg("removed orphaned worktree")
+		removed++
+	}
+
+	return removed
+}
+
+// removeOrphanedPlannerWorktree removes the worktree of a Planner that is not
+// running and reports whether it did. Its branch is deleted by the task's next
+// Planner run.
+func (r *Recovery) removeOrphanedPlannerWorktree(ctx context.Context, clonePath, worktreePath string, taskID uuid.UUID) bool {
+	if r.manager != nil && r.manager.IsRunning(taskID) {
+		return false
+	}
+	if err := r.worktrees.RemoveWorktree(ctx, clonePath, worktreePath); err != nil {
+		if rmErr := os.RemoveAll(worktreePath); rmErr != nil {
+			log.Error().Err(rmErr).Str("worktree_path", worktreePath).Msg("failed to remove orphaned planner worktree")
+			return false
 		}
 	}
+	log.Info().
+		Str("task_id", taskID.String()).
+		Str("worktree_path", worktreePath).
+		Msg("removed orphaned planner worktree")
+	return true
+}
 
-	return nil
+// worktreeRemovalReason decides whether a subtask's worktree should be removed.
+// It returns the reason for removal and true if the worktree is no longer needed.
+func (r *Recovery) worktreeRemovalReason(ctx context.Context, subtaskID uuid.UUID, worktreePath string) (string, bool) {
+	subtask, err := r.repo.GetSubtaskByID(ctx, subtaskID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "subtask deleted", true
+		}
+		log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to get subtask for worktree cleanup")
+		return "", false
+	}
+
+	status := domain.SubtaskStatus(subtask.Status)
+	switch status {
+	case domain.SubtaskStatusCompleted, domain.SubtaskStatusMerged:
+		// PR is open or merged; the branch has been pushed
+		return "subtask " + string(status), true
+
+	case domain.SubtaskStatusCompletedNoChanges:
+		// Nothing was committed, so there is nothing to keep
+		return "subtask " + string(status), true
+
+	case domain.SubtaskStatusCancelled:
+		// Abandoned by the user; nothing will resume in this worktree
+		return "subtask " + string(status), true
+
+	case domain.SubtaskStatusInProgress:
+		// May be restarted by recovery or a later retry
+		return "", false
+
+	case domain.SubtaskStatusBlocked:
+		if subtask.BlockedReason != nil && domain.BlockedReason(*subtask.BlockedReason).IsRetryable() {
+			// Failed and over-budget subtasks keep a healthy worktree so a retry can reuse it.
+			// A half-created one (no .git link) is unusable, so drop it and clear the path.
+			if _, err := os.Stat(filepath.Join(worktreePath, ".git")); !os.IsNotExist(err) {
+				return "", false
+			}
+			if _, err := r.repo.UpdateSubtaskBranch(ctx, db.UpdateSubtaskBranchParams{
+				ID:           subtaskID,
+				BranchName:   subtask.BranchName,
+				WorktreePath: nil,
+			}); err != nil {
+				log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to clear worktree path")
+				return "", false
+			}
+			return "incomplete worktree", true
+		}
+	}
+
+	// PENDING, READY, or dependency-BLOCKED subtasks should not have a worktree;
+	// one left behind means StartSubtask crashed before the status update.
+	return "subtask " + string(status) + " without active worker", true
 }
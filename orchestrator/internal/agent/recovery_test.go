@@ -0,0 +1,228 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+func TestIsRunStale_Boundary(t *testing.T) {
+	now := time.Now()
+	cutoff := 10 * time.Minute
+
+	tests := []struct {
+		name      string
+		startedAt time.Time
+		expected  bool
+	}{
+		{"just started", now.Add(-time.Second), false},
+		{"just under cutoff", now.Add(-cutoff + time.Second), false},
+		{"exactly at cutoff", now.Add(-cutoff), true},
+		{"well past cutoff", now.Add(-2 * cutoff), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := db.AgentRun{StartedAt: tt.startedAt}
+			if got := isRunStale(run, cutoff, now); got != tt.expected {
+				t.Errorf("isRunStale() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsRunStale_LogActivity(t *testing.T) {
+	now := time.Now()
+	cutoff := 10 * time.Minute
+	logPath := filepath.Join(t.TempDir(), "run-001.log")
+
+	if err := os.WriteFile(logPath, []byte("working\n"), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	run := db.AgentRun{
+		StartedAt: now.Add(-time.Hour),
+		LogPath:   logPath,
+	}
+
+	// Recently modified log keeps an old run alive
+	if err := os.Chtimes(logPath, now.Add(-time.Minute), now.Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+	if isRunStale(run, cutoff, now) {
+		t.Error("expected run with recent log activity to not be stale")
+	}
+
+	// Log untouched for longer than the cutoff
+	if err := os.Chtimes(logPath, now.Add(-cutoff), now.Add(-cutoff)); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+	if !isRunStale(run, cutoff, now) {
+		t.Error("expected run with idle log to be stale")
+	}
+
+	// Missing log file falls back to start time only
+	run.LogPath = filepath.Join(t.TempDir(), "missing.log")
+	if !isRunStale(run, cutoff, now) {
+		t.Error("expected old run with missing log to be stale")
+	}
+}
+
+func TestRecoveryConfig_StaleAfter(t *testing.T) {
+	cfg := RecoveryConfig{
+		PlannerStaleAfter: 5 * time.Minute,
+		WorkerStaleAfter:  30 * time.Minute,
+	}
+
+	if got := cfg.staleAfter(domain.AgentTypePlanner); got != 5*time.Minute {
+		t.Errorf("planner cutoff = %v, want 5m", got)
+	}
+	if got := cfg.staleAfter(domain.AgentTypeWorker); got != 30*time.Minute {
+		t.Errorf("worker cutoff = %v, want 30m", got)
+	}
+}
+
+func TestNewRecovery_DefaultsConfig(t *testing.T) {
+	r := NewRecovery(nil, nil, nil, nil, nil, nil, 10, RecoveryConfig{})
+
+	defaults := DefaultRecoveryConfig()
+	if r.config != defaults {
+		t.Errorf("config = %+v, want defaults %+v", r.config, defaults)
+	}
+}
+
+// recoveryDB serves RUNNING agent runs to Recovery and records the statuses
+// it writes. ListInterruptedWorkerRuns returns the Worker runs it marked
+// interrupted, and the subtask lookup that starts a resume is recorded and
+// then fails, so no Worker is spawned.
+type recoveryDB struct {
+	runs     []db.AgentRun
+	statuses map[uuid.UUID]string
+	resumed  []uuid.UUID
+}
+
+func (d *recoveryDB) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	switch {
+	case strings.Contains(sql, "name: GetRunningAgentRuns "):
+		return &agentRunRows{runs: d.runs}, nil
+	case strings.Contains(sql, "name: ListInterruptedWorkerRuns "):
+		var interrupted []db.AgentRun
+		for _, run := range d.runs {
+			if run.SubtaskID.Valid && d.statuses[run.ID] == string(domain.AgentRunStatusInterrupted) {
+				interrupted = append(interrupted, run)
+			}
+		}
+		return &agentRunRows{runs: interrupted}, nil
+	}
+	return nil, errors.New("unexpected query")
+}
+
+func (d *recoveryDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	switch {
+	case strings.Contains(sql, "name: UpdateAgentRunStatus "):
+		d.statuses[args[0].(uuid.UUID)] = args[1].(string)
+		return emptyRow{}
+	case strings.Contains(sql, "name: GetSubtaskByID "):
+		d.resumed = append(d.resumed, args[0].(uuid.UUID))
+	}
+	return errRow{pgx.ErrNoRows}
+}
+
+func (d *recoveryDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("unexpected exec")
+}
+
+func (d *recoveryDB) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	return nil, errors.New("unexpected transaction")
+}
+
+// errRow fails every scan with err.
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
+
+// agentRunRows iterates over agent runs, setting the columns Recovery reads.
+type agentRunRows struct {
+	pgx.Rows
+	runs []db.AgentRun
+	next int
+}
+
+func (r *agentRunRows) Next() bool {
+	r.next++
+	return r.next <= len(r.runs)
+}
+
+func (r *agentRunRows) Scan(dest ...any) error {
+	run := r.runs[r.next-1]
+	*dest[0].(*uuid.UUID) = run.ID
+	*dest[1].(*pgtype.UUID) = run.SubtaskID
+	*dest[2].(*string) = run.AgentType
+	*dest[3].(*int32) = run.AttemptNumber
+	*dest[4].(*string) = run.Status
+	*dest[5].(*time.Time) = run.StartedAt
+	*dest[9].(*string) = run.LogPath
+	*dest[12].(*pgtype.UUID) = run.TaskID
+	return nil
+}
+
+func (r *agentRunRows) Close()     {}
+func (r *agentRunRows) Err() error { return nil }
+
+func TestRecoverStaleAgents_RecoversRecentRuns(t *testing.T) {
+	cutoff := 15 * time.Minute
+	worker := func(startedAt time.Time) db.AgentRun {
+		return db.AgentRun{
+			ID:            uuid.New(),
+			SubtaskID:     pgtype.UUID{Bytes: uuid.New(), Valid: true},
+			AgentType:     string(domain.AgentTypeWorker),
+			AttemptNumber: 1,
+			Status:        string(domain.AgentRunStatusRunning),
+			StartedAt:     startedAt,
+		}
+	}
+	// Started well within the cutoff, when the orchestrator died
+	recent := worker(time.Now().Add(-time.Minute))
+	// Still running in this process
+	active := worker(time.Now().Add(-time.Minute))
+
+	fake := &recoveryDB{runs: []db.AgentRun{recent, active}, statuses: map[uuid.UUID]string{}}
+	manager := NewAgentManager(nil, nil, nil, nil, nil)
+	manager.runningAgents[pgtypeToUUID(active.SubtaskID)] = &runningAgent{agentType: domain.AgentTypeWorker}
+	subtasks := &fakeSubtaskService{}
+	r := NewRecovery(repository.New(fake), manager, nil, nil, subtasks, nil, 3,
+		RecoveryConfig{PlannerStaleAfter: cutoff, WorkerStaleAfter: cutoff})
+
+	if err := r.RecoverStaleAgents(context.Background()); err != nil {
+		t.Fatalf("RecoverStaleAgents() error = %v", err)
+	}
+
+	if got := fake.statuses[recent.ID]; got != string(domain.AgentRunStatusInterrupted) {
+		t.Errorf("recent run status = %q, want INTERRUPTED", got)
+	}
+	if subtasks.decrements != 1 || subtasks.failed != 0 {
+		t.Errorf("decrements = %d, failed = %d; want the recent attempt given back and nothing failed", subtasks.decrements, subtasks.failed)
+	}
+	if len(fake.resumed) != 1 || fake.resumed[0] != pgtypeToUUID(recent.SubtaskID) {
+		t.Errorf("resumed subtasks = %v, want only the recent run's subtask", fake.resumed)
+	}
+	if _, ok := fake.statuses[active.ID]; ok {
+		t.Error("a run whose agent is still running should be left alone")
+	}
+}
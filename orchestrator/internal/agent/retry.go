@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os/exec"
+	"strings"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+// ErrClaudeAuthFailed is returned in an ExecutionResult when the Claude CLI
+// exits because it is not logged in or its credentials were rejected.
+var ErrClaudeAuthFailed = errors.New("claude CLI authentication failed")
+
+// ErrTokenBudgetExceeded is returned by RunWorkerLoop when the subtask's
+// Workers have used up its token budget.
+var ErrTokenBudgetExceeded = errors.New("token budget exceeded")
+
+// ErrPathPolicyViolation is returned by RunWorkerLoop when the Worker's branch
+// modifies paths the project's path policy forbids.
+var ErrPathPolicyViolation = errors.New("path policy violation")
+
+// FailureClass says whether a failed agent attempt is worth retrying.
+type FailureClass string
+
+// Failure classes.
+const (
+	// FailureRetryable failures may succeed on a later attempt: transient
+	// git or network errors, or a run that exited without closing its issue.
+	FailureRetryable FailureClass = "retryable"
+	// FailurePermanent failures will fail the same way on every attempt:
+	// missing binaries, a missing or unusable worktree, an oversized prompt,
+	// rejected credentials, too little disk space, or invalid input such as a
+	// malformed branch name.
+	FailurePermanent FailureClass = "permanent"
+)
+
+// ClassifyFailure classifies the error from a failed attempt. A nil error
+// (the agent ran but did not finish its issue) is retryable, as is anything
+// not known to be permanent.
+func ClassifyFailure(err error) FailureClass {
+	switch {
+	case err == nil:
+		return FailureRetryable
+	case errors.Is(err, service.ErrBeadsCommandNotFound),
+		errors.Is(err, service.ErrBeadsWorktreeFailed),
+		errors.Is(err, exec.ErrNotFound),
+		errors.Is(err, fs.ErrNotExist),
+		errors.Is(err, fs.ErrPermission),
+		errors.Is(err, ErrPromptTooLarge),
+		errors.Is(err, ErrClaudeAuthFailed),
+		errors.Is(err, service.ErrTokenInvalid),
+		errors.Is(err, domain.ErrInsufficientDiskSpace),
+		errors.Is(err, domain.ErrInvalidInput):
+		return FailurePermanent
+	default:
+		return FailureRetryable
+	}
+}
+
+// authFailureMarkers are lowercase substrings of Claude CLI output that mean
+// the CLI cannot authenticate.
+var authFailureMarkers = []string{
+	"invalid api key",
+	"authentication_error",
+	"please run /login",
+	"oauth token has expired",
+}
+
+// isAuthFailure reports whether a line of Claude CLI output reports an
+// authentication failure.
+func isAuthFailure(line string) bool {
+	line = strings.ToLower(line)
+	for _, marker := range authFailureMarkers {
+		if strings.Contains(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAuthFailureOutput reports whether a line of Claude CLI stdout reports an
+// authentication failure. Only plain-text lines and error results are
+// checked, so agent output that merely mentions authentication is ignored.
+func isAuthFailureOutput(line string) bool {
+	var event streamEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil {
+		return isAuthFailure(line)
+	}
+	return event.Type == "result" && strings.HasPrefix(event.Subtype, "error") && isAuthFailure(event.Result)
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want FailureClass
+	}{
+		{"issue not closed", nil, FailureRetryable},
+		{"non-zero exit", &exec.ExitError{}, FailureRetryable},
+		{"transient git error", errors.New("git fetch: connection reset by peer"), FailureRetryable},
+		{"beads show failed", fmt.Errorf("%w: exit status 1", service.ErrBeadsShowFailed), FailureRetryable},
+		{"bd not installed", fmt.Errorf("%w: %w", service.ErrBeadsShowFailed, service.ErrBeadsCommandNotFound), FailurePermanent},
+		{"claude not installed", fmt.Errorf("failed to start claude: %w", exec.ErrNotFound), FailurePermanent},
+		{"worktree creation failed", fmt.Errorf("%w: exit status 128", service.ErrBeadsWorktreeFailed), FailurePermanent},
+		{"worktree missing", fmt.Errorf("failed to start claude: %w", &fs.PathError{Op: "chdir", Err: fs.ErrNotExist}), FailurePermanent},
+		{"auth failure", fmt.Errorf("%w: exit status 1", ErrClaudeAuthFailed), FailurePermanent},
+		{"github token revoked", fmt.Errorf("%w: %w", service.ErrSyncFailed, service.ErrTokenInvalid), FailurePermanent},
+		{"disk full", fmt.Errorf("%w: 10 MB free", domain.ErrInsufficientDiskSpace), FailurePermanent},
+		{"invalid branch name", fmt.Errorf("%w: %w", service.ErrPRCreationFailed, domain.NewValidationError("base", "invalid branch name")), FailurePermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyFailure(tt.err); got != tt.want {
+				t.Errorf("ClassifyFailure(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAuthFailureOutput(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"Invalid API key · Please run /login", true},
+		{`{"type":"result","subtype":"error_during_execution","result":"API Error: 401 authentication_error"}`, true},
+		{`{"type":"result","subtype":"success","result":"Fixed the invalid API key error message"}`, false},
+		{`{"type":"assistant","message":{"content":[{"type":"text","text":"Handling authentication_error responses"}]}}`, false},
+		{"Compiling...", false},
+	}
+
+	for _, tt := range tests {
+		if got := isAuthFailureOutput(tt.line); got != tt.want {
+			t.Errorf("isAuthFailureOutput(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+// workerDB accepts agent run writes and counts the runs and token usage
+// samples created.
+type workerDB struct {
+	runsCreated  int
+	usageSamples int
+	runStatuses  []string // of each UpdateAgentRunStatus
+}
+
+func (d *workerDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("unexpected exec")
+}
+
+func (d *workerDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (d *workerDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	if strings.Contains(sql, "name: CreateAgentRun ") {
+		d.runsCreated++
+	}
+	if strings.Contains(sql, "name: CreateAgentRunTokenUsage ") {
+		d.usageSamples++
+	}
+	if strings.Contains(sql, "name: UpdateAgentRunStatus ") {
+		d.runStatuses = append(d.runStatuses, args[1].(string))
+	}
+	return emptyRow{}
+}
+
+func (d *workerDB) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	return nil, errors.New("unexpected transaction")
+}
+
+// emptyRow scans successfully without setting any values.
+type emptyRow struct{}
+
+func (emptyRow) Scan(...any) error { return nil }
+
+// fakeSubtaskService counts retry increments and decrements, completions, and
+// failures and keeps the stored next attempt time and token usage.
+type fakeSubtaskService struct {
+	increments     int
+	decrements     int
+	completed      int
+	noChanges      int
+	failed         int
+	budgetExceeded int
+	violations     int
+	nextAttemptAt  *time.Time
+	tokenUsage     int
+	tokenBudget    *int
+}
+
+func (s *fakeSubtaskService) MarkCompleted(context.Context, uuid.UUID, string, int) error {
+	s.completed++
+	return nil
+}
+
+func (s *fakeSubtaskService) MarkCompletedNoChanges(context.Context, uuid.UUID) error {
+	s.noChanges++
+	return nil
+}
+
+func (s *fakeSubtaskService) MarkFailed(context.Context, uuid.UUID) error {
+	s.failed++
+	return nil
+}
+
+func (s *fakeSubtaskService) MarkBudgetExceeded(context.Context, uuid.UUID) error {
+	s.budgetExceeded++
+	return nil
+}
+
+func (s *fakeSubtaskService) MarkPolicyViolation(context.Context, uuid.UUID) error {
+	s.violations++
+	return nil
+}
+
+func (s *fakeSubtaskService) IncrementRetryCount(context.Context, uuid.UUID) (int, error) {
+	s.increments++
+	return s.increments, nil
+}
+
+func (s *fakeSubtaskService) DecrementRetryCount(context.Context, uuid.UUID) (int, error) {
+	s.decrements++
+	return s.increments - s.decrements, nil
+}
+
+func (s *fakeSubtaskService) SetNextAttemptAt(_ context.Context, _ uuid.UUID, at *time.Time) error {
+	s.nextAttemptAt = at
+	return nil
+}
+
+func (s *fakeSubtaskService) UpdateTokenUsage(_ context.Context, id uuid.UUID, tokens int) (*domain.Subtask, error) {
+	s.tokenUsage += tokens
+	return &domain.Subtask{ID: id, TokenUsage: s.tokenUsage, TokenBudget: s.tokenBudget}, nil
+}
+
+func TestRunWorkerLoop_MissingBinaryFailsFast(t *testing.T) {
+	// No claude binary on PATH: every attempt would fail identically
+	t.Setenv("PATH", t.TempDir())
+
+	paths := config.NewDataPaths(t.TempDir(), "")
+	renderer, err := NewPromptRenderer(paths)
+	if err != nil {
+		t.Fatalf("NewPromptRenderer() error = %v", err)
+	}
+
+	dbtx := &workerDB{}
+	subtasks := &fakeSubtaskService{}
+	loop := NewAgentLoop(NewExecutor(paths), renderer, LoopServices{
+		Repo:           repository.New(dbtx),
+		SubtaskService: subtasks,
+	}, 5)
+
+	project := &domain.Project{ID: uuid.New(), ClonePath: t.TempDir()}
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login"}
+
+	err = loop.RunWorkerLoop(context.Background(), subtask, project, StaticToken("token"))
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("RunWorkerLoop() error = %v, want exec.ErrNotFound", err)
+	}
+	if dbtx.runsCreated != 1 || subtasks.increments != 1 {
+		t.Errorf("ran %d attempts (%d retry increments), want 1 of 5", dbtx.runsCreated, subtasks.increments)
+	}
+	if subtasks.failed != 1 {
+		t.Errorf("MarkFailed called %d times, want 1", subtasks.failed)
+	}
+}
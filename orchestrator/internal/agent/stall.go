@@ -0,0 +1,49 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrAgentStalled is the cancellation cause of a Worker attempt stopped
+// because its log went quiet for the stall timeout (see SetStallTimeout).
+var ErrAgentStalled = errors.New("agent stalled")
+
+// maxStallPollInterval caps how often watchStall checks the log, so a long
+// stall timeout is still noticed soon after it passes.
+const maxStallPollInterval = 30 * time.Second
+
+// watchStall checks the modification time of logPath until ctx is done and
+// calls onStall with the time of the last write once the log has not been
+// written for timeout. The Executor writes every line of agent output to the
+// log as it arrives, so a quiet log means no progress even though the process
+// is alive, such as a stuck tool call. onStall is called once per stall: if
+// the log is written again the watch starts over. A log that cannot be read
+// is skipped until it can.
+func watchStall(ctx context.Context, logPath string, timeout time.Duration, onStall func(lastWrite time.Time)) {
+	ticker := time.NewTicker(min(timeout/4, maxStallPollInterval))
+	defer ticker.Stop()
+
+	var reported time.Time // last write of the stall already reported
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			info, err := os.Stat(logPath)
+			if err != nil {
+				continue
+			}
+			lastWrite := info.ModTime()
+			if now.Sub(lastWrite) >= timeout && !lastWrite.Equal(reported) {
+				reported = lastWrite
+				onStall(lastWrite)
+			}
+		}
+	}
+}
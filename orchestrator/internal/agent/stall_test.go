@@ -0,0 +1,89 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchStall(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "run-001.log")
+	if err := os.WriteFile(logPath, []byte("=== Agent Run 1 ===\n"), 0o600); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stalls := make(chan time.Time, 4)
+	go watchStall(ctx, logPath, 200*time.Millisecond, func(lastWrite time.Time) { stalls <- lastWrite })
+
+	// The log stops being written: one report for the stall
+	var first time.Time
+	select {
+	case first = <-stalls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stall of a log that stopped being written was not reported")
+	}
+	select {
+	case again := <-stalls:
+		t.Fatalf("stall reported again (last write %v) without new output", again)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// New output ends the stall; going quiet again is a new one
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	if _, err := f.WriteString("[12:00:00] still working\n"); err != nil {
+		t.Fatalf("failed to append to log: %v", err)
+	}
+	f.Close()
+
+	select {
+	case second := <-stalls:
+		if !second.After(first) {
+			t.Errorf("second stall last write = %v, want after the first (%v)", second, first)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stall after new output was not reported")
+	}
+}
+
+func TestWatchStall_ProgressingLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "run-001.log")
+	f, err := os.Create(logPath)
+	if err != nil {
+		t.Fatalf("failed to create log: %v", err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stalled := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchStall(ctx, logPath, 300*time.Millisecond, func(time.Time) { stalled <- struct{}{} })
+	}()
+
+	// A slow run that keeps writing is never stalled
+	for range 10 {
+		if _, err := f.WriteString("[12:00:00] working\n"); err != nil {
+			t.Fatalf("failed to write log: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	select {
+	case <-stalled:
+		t.Error("a log written every 100ms was reported stalled with a 300ms timeout")
+	default:
+	}
+}
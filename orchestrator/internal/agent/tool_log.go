@@ -0,0 +1,120 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxToolInputLen bounds the input value quoted in a tool log line.
+const maxToolInputLen = 100
+
+// ToolLogFormat describes how a tool_use event is written to an agent log:
+// "{Emoji} {Label}: {value}", where value is the first of Fields present in
+// the tool's input.
+type ToolLogFormat struct {
+	Emoji  string
+	Label  string
+	Fields []string
+}
+
+// ToolLogFormats maps Claude tool names to their log format. Tools missing
+// here are logged by name with a key input field (see genericToolInputFields).
+// Add entries at startup, before any agent runs, to describe new tools.
+var ToolLogFormats = map[string]ToolLogFormat{
+	"Read":         {Emoji: "📖", Label: "Reading", Fields: []string{"file_path"}},
+	"Edit":         {Emoji: "✏️ ", Label: "Editing", Fields: []string{"file_path"}},
+	"MultiEdit":    {Emoji: "✏️ ", Label: "Editing", Fields: []string{"file_path"}},
+	"Write":        {Emoji: "📝", Label: "Writing", Fields: []string{"file_path"}},
+	"NotebookEdit": {Emoji: "📓", Label: "Editing notebook", Fields: []string{"notebook_path"}},
+	"Bash":         {Emoji: "💻", Label: "Running", Fields: []string{"command"}},
+	"Glob":         {Emoji: "🔍", Label: "Searching", Fields: []string{"pattern"}},
+	"Grep":         {Emoji: "🔎", Label: "Grepping", Fields: []string{"pattern"}},
+	"Task":         {Emoji: "🤖", Label: "Spawning agent", Fields: []string{"description"}},
+	"WebFetch":     {Emoji: "🌐", Label: "Fetching", Fields: []string{"url"}},
+	"WebSearch":    {Emoji: "🌐", Label: "Searching the web", Fields: []string{"query"}},
+	"TodoWrite":    {Emoji: "📋", Label: "Updating todos", Fields: []string{"todos"}},
+}
+
+// genericToolInputFields are the input fields, most telling first, quoted
+// when logging a tool without a ToolLogFormat.
+var genericToolInputFields = []string{
+	"file_path", "notebook_path", "path", "url", "command", "pattern", "query", "description", "prompt",
+}
+
+// formatToolUse returns the log line for a tool_use event, without timestamp
+// or trailing newline.
+func formatToolUse(name string, input map[string]any) string {
+	if format, ok := ToolLogFormats[name]; ok {
+		if value, ok := toolInputValue(input, format.Fields); ok {
+			return fmt.Sprintf("%s %s: %s", format.Emoji, format.Label, value)
+		}
+	}
+
+	line := "🔧 Using tool: " + name
+	if field, value, ok := genericToolInput(input); ok {
+		line += fmt.Sprintf(" (%s: %s)", field, value)
+	}
+	return line
+}
+
+// toolInputValue returns the first of fields present in input, formatted for a log line.
+func toolInputValue(input map[string]any, fields []string) (string, bool) {
+	for _, field := range fields {
+		if value, ok := formatToolInput(input[field]); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// genericToolInput picks the input field to quote for a tool without a
+// ToolLogFormat: a well-known field if present, else the first string field
+// by name.
+func genericToolInput(input map[string]any) (field, value string, ok bool) {
+	for _, field := range genericToolInputFields {
+		if value, ok := formatToolInput(input[field]); ok {
+			return field, value, true
+		}
+	}
+
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if s, isString := input[key].(string); isString {
+			if value, ok := formatToolInput(s); ok {
+				return key, value, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// formatToolInput renders an input value for a log line: strings on one line
+// and truncated, lists by their length. Other values are not logged.
+func formatToolInput(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		v = strings.Join(strings.Fields(v), " ")
+		if v == "" {
+			return "", false
+		}
+		if len(v) > maxToolInputLen {
+			v = strings.ToValidUTF8(v[:maxToolInputLen], "") + "..."
+		}
+		return v, true
+	case []any:
+		if len(v) == 1 {
+			return "1 item", true
+		}
+		return fmt.Sprintf("%d items", len(v)), true
+	default:
+		return "", false
+	}
+}
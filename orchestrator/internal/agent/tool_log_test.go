@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStreamJSONLine_ToolUse(t *testing.T) {
+	const ts = "12:00:00"
+	long := strings.Repeat("x", 150)
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			"known tool",
+			`{"type":"tool_use","tool_name":"Read","input":{"file_path":"main.go"}}`,
+			"📖 Reading: main.go",
+		},
+		{
+			"multi-line command on one line and truncated",
+			`{"type":"tool_use","tool_name":"Bash","input":{"command":"go test\n  ./... ` + long + `"}}`,
+			"💻 Running: go test ./... " + strings.Repeat("x", 100-len("go test ./... ")) + "...",
+		},
+		{
+			"WebFetch",
+			`{"type":"tool_use","tool_name":"WebFetch","input":{"url":"https://go.dev/doc","prompt":"summarize"}}`,
+			"🌐 Fetching: https://go.dev/doc",
+		},
+		{
+			"MultiEdit",
+			`{"type":"tool_use","tool_name":"MultiEdit","input":{"file_path":"a.go","edits":[{},{}]}}`,
+			"✏️  Editing: a.go",
+		},
+		{
+			"NotebookEdit",
+			`{"type":"tool_use","tool_name":"NotebookEdit","input":{"notebook_path":"nb.ipynb","new_source":"x = 1"}}`,
+			"📓 Editing notebook: nb.ipynb",
+		},
+		{
+			"TodoWrite counts the todos",
+			`{"type":"tool_use","tool_name":"TodoWrite","input":{"todos":[{"content":"a"},{"content":"b"},{"content":"c"}]}}`,
+			"📋 Updating todos: 3 items",
+		},
+		{
+			"known tool missing its field falls back",
+			`{"type":"tool_use","tool_name":"Read","input":{"path":"docs"}}`,
+			"🔧 Using tool: Read (path: docs)",
+		},
+		{
+			"unknown tool with a well-known field",
+			`{"type":"tool_use","tool_name":"LSP","input":{"symbol":"Foo","file_path":"x.go"}}`,
+			"🔧 Using tool: LSP (file_path: x.go)",
+		},
+		{
+			"unknown tool with other string fields",
+			`{"type":"tool_use","tool_name":"mcp__db__query","input":{"sql":"SELECT 1","database":"main","limit":5}}`,
+			"🔧 Using tool: mcp__db__query (database: main)",
+		},
+		{
+			"unknown tool without string fields",
+			`{"type":"tool_use","tool_name":"ExitPlanMode","input":{"approved":true}}`,
+			"🔧 Using tool: ExitPlanMode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := "[" + ts + "] " + tt.want + "\n"
+			if got := parseStreamJSONLine(tt.line, ts); got != want {
+				t.Errorf("parseStreamJSONLine() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestToolLogFormats_Extensible(t *testing.T) {
+	ToolLogFormats["Deploy"] = ToolLogFormat{Emoji: "🚀", Label: "Deploying", Fields: []string{"env"}}
+	t.Cleanup(func() { delete(ToolLogFormats, "Deploy") })
+
+	if got, want := formatToolUse("Deploy", map[string]any{"env": "staging"}), "🚀 Deploying: staging"; got != want {
+		t.Errorf("formatToolUse() = %q, want %q", got, want)
+	}
+}
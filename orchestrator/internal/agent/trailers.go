@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"strings"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// TrailerKeys names the git trailers that link a Worker's PR, and its
+// squashed commit, back to the subtask and beads issue it implements, so
+// tooling can correlate merged PRs with orchestrator records. An empty key
+// leaves that trailer out.
+type TrailerKeys struct {
+	BeadsIssue string
+	Subtask    string
+}
+
+// DefaultTrailerKeys are the trailer keys used unless SetTrailerKeys changes them.
+var DefaultTrailerKeys = TrailerKeys{BeadsIssue: "Beads-Issue", Subtask: "Intern-Subtask"}
+
+// buildTrailers returns the "Key: value" trailer lines for subtask. The beads
+// issue trailer is left out for a subtask without one.
+func buildTrailers(keys TrailerKeys, subtask *domain.Subtask) []string {
+	var trailers []string
+	if keys.BeadsIssue != "" && subtask.BeadsIssueID != nil && *subtask.BeadsIssueID != "" {
+		trailers = append(trailers, keys.BeadsIssue+": "+*subtask.BeadsIssueID)
+	}
+	if keys.Subtask != "" {
+		trailers = append(trailers, keys.Subtask+": "+subtask.ID.String())
+	}
+	return trailers
+}
+
+// appendTrailers ends text with trailers as its last paragraph, where
+// git interpret-trailers and GitHub's squash merge look for them. Without
+// trailers text is returned unchanged.
+func appendTrailers(text string, trailers []string) string {
+	if len(trailers) == 0 {
+		return text
+	}
+	return strings.TrimRight(text, "\n") + "\n\n" + strings.Join(trailers, "\n") + "\n"
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package agent
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+func TestBuildTrailers(t *testing.T) {
+	id := uuid.MustParse("11111111-2222-3333-4444-555555555555")
+	beadsID := "iv-5"
+	withIssue := &domain.Subtask{ID: id, BeadsIssueID: &beadsID}
+	withoutIssue := &domain.Subtask{ID: id}
+
+	tests := []struct {
+		name    string
+		keys    TrailerKeys
+		subtask *domain.Subtask
+		want    []string
+	}{
+		{"default keys", DefaultTrailerKeys, withIssue, []string{"Beads-Issue: iv-5", "Intern-Subtask: " + id.String()}},
+		{"no beads issue", DefaultTrailerKeys, withoutIssue, []string{"Intern-Subtask: " + id.String()}},
+		{"custom keys", TrailerKeys{BeadsIssue: "Refs", Subtask: "X-Subtask"}, withIssue, []string{"Refs: iv-5", "X-Subtask: " + id.String()}},
+		{"beads trailer disabled", TrailerKeys{Subtask: "Intern-Subtask"}, withIssue, []string{"Intern-Subtask: " + id.String()}},
+		{"all disabled", TrailerKeys{}, withIssue, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTrailers(tt.keys, tt.subtask); !slices.Equal(got, tt.want) {
+				t.Errorf("buildTrailers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrailersEndPRBodyAndSquashMessage(t *testing.T) {
+	beadsID := "iv-5"
+	subtask := &domain.Subtask{ID: uuid.New(), Title: "Add login", BeadsIssueID: &beadsID}
+	trailers := buildTrailers(DefaultTrailerKeys, subtask)
+	block := "\n\nBeads-Issue: iv-5\nIntern-Subtask: " + subtask.ID.String() + "\n"
+
+	if body := buildPRBody(subtask, []string{"abc123 add login"}, nil, trailers); !strings.HasSuffix(body, "Generated by Intern Village"+block) {
+		t.Errorf("PR body should end with the trailers:\n%s", body)
+	}
+	if msg := buildSquashMessage(subtask, []string{"abc123 add login"}, trailers); !strings.HasSuffix(msg, "- add login"+block) {
+		t.Errorf("squash message should end with the trailers:\n%s", msg)
+	}
+	if got := appendTrailers("text\n", nil); got != "text\n" {
+		t.Errorf("appendTrailers() without trailers = %q, want the text unchanged", got)
+	}
+}
@@ -59,6 +59,22 @@ func (a *beadsServiceAdapter) CloseIssue(ctx context.Context, repoPath, issueID,
 	return a.svc.CloseIssue(ctx, repoPath, issueID, reason)
 }
 
+func (a *beadsServiceAdapter) CreateWorktree(ctx context.Context, repoPath, worktreePath, branch string) error {
+	return a.svc.CreateWorktree(ctx, repoPath, worktreePath, branch)
+}
+
+func (a *beadsServiceAdapter) RemoveWorktree(ctx context.Context, repoPath, worktreePath string) error {
+	return a.svc.RemoveWorktree(ctx, repoPath, worktreePath)
+}
+
+func (a *beadsServiceAdapter) PruneWorktrees(ctx context.Context, repoPath string) error {
+	return a.svc.PruneWorktrees(ctx, repoPath)
+}
+
+func (a *beadsServiceAdapter) DeleteBranch(ctx context.Context, repoPath, branch string) error {
+	return a.svc.DeleteBranch(ctx, repoPath, branch)
+}
+
 func (a *beadsServiceAdapter) FindEpicByTaskID(ctx context.Context, repoPath, taskIDPrefix string) (*agent.BeadsIssue, error) {
 	issue, err := a.svc.FindEpicByTaskID(ctx, repoPath, taskIDPrefix)
 	if err != nil {
@@ -92,10 +108,32 @@ func (a *gitHubServiceAdapter) CreatePR(ctx context.Context, owner, repo, access
 	}, nil
 }
 
+func (a *gitHubServiceAdapter) SquashCommits(ctx context.Context, repoPath, baseBranch, message string) (bool, error) {
+	return a.svc.SquashCommits(ctx, repoPath, baseBranch, message)
+}
+
 func (a *gitHubServiceAdapter) GetCommitMessages(ctx context.Context, repoPath, baseBranch string) ([]string, error) {
 	return a.svc.GetCommitMessages(ctx, repoPath, baseBranch)
 }
 
+func (a *gitHubServiceAdapter) GetChangedFiles(ctx context.Context, repoPath, baseBranch string) ([]agent.ChangedFile, error) {
+	files, err := a.svc.GetChangedFiles(ctx, repoPath, baseBranch)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]agent.ChangedFile, len(files))
+	for i, f := range files {
+		result[i] = agent.ChangedFile{
+			Path:      f.Path,
+			Status:    f.Status,
+			Additions: f.Additions,
+			Deletions: f.Deletions,
+			Binary:    f.Binary,
+		}
+	}
+	return result, nil
+}
+
 // syncServiceAdapter adapts service.SyncService to agent.SyncServiceInterface.
 type syncServiceAdapter struct {
 	svc *service.SyncService
@@ -109,6 +147,10 @@ func (a *syncServiceAdapter) SyncTaskFromBeads(ctx context.Context, taskID uuid.
 	return a.svc.SyncTaskFromBeads(ctx, taskID, repoPath)
 }
 
+func (a *syncServiceAdapter) SyncNewIssuesFromBeads(ctx context.Context, taskID uuid.UUID, repoPath string) ([]*domain.Subtask, error) {
+	return a.svc.SyncNewIssuesFromBeads(ctx, taskID, repoPath)
+}
+
 // taskServiceAdapter adapts service.TaskService to agent.TaskServiceInterface.
 type taskServiceAdapter struct {
 	svc *service.TaskService
@@ -122,10 +164,18 @@ func (a *taskServiceAdapter) TransitionToActive(ctx context.Context, taskID uuid
 	return a.svc.TransitionToActive(ctx, taskID)
 }
 
+func (a *taskServiceAdapter) TransitionToAwaitingApproval(ctx context.Context, taskID uuid.UUID) error {
+	return a.svc.TransitionToAwaitingApproval(ctx, taskID)
+}
+
 func (a *taskServiceAdapter) MarkPlanningFailed(ctx context.Context, taskID uuid.UUID) error {
 	return a.svc.MarkPlanningFailed(ctx, taskID)
 }
 
+func (a *taskServiceAdapter) CompleteEmptyPlan(ctx context.Context, taskID uuid.UUID) error {
+	return a.svc.CompleteEmptyPlan(ctx, taskID)
+}
+
 func (a *taskServiceAdapter) UpdateBeadsEpicID(ctx context.Context, taskID uuid.UUID, epicID string) error {
 	return a.svc.UpdateBeadsEpicID(ctx, taskID, epicID)
 }
@@ -143,15 +193,35 @@ func (a *subtaskServiceAdapter) MarkCompleted(ctx context.Context, subtaskID uui
 	return a.svc.MarkCompleted(ctx, subtaskID, prURL, prNumber)
 }
 
+func (a *subtaskServiceAdapter) MarkCompletedNoChanges(ctx context.Context, subtaskID uuid.UUID) error {
+	return a.svc.MarkCompletedNoChanges(ctx, subtaskID)
+}
+
 func (a *subtaskServiceAdapter) MarkFailed(ctx context.Context, subtaskID uuid.UUID) error {
 	return a.svc.MarkFailed(ctx, subtaskID)
 }
 
+func (a *subtaskServiceAdapter) MarkBudgetExceeded(ctx context.Context, subtaskID uuid.UUID) error {
+	return a.svc.MarkBudgetExceeded(ctx, subtaskID)
+}
+
+func (a *subtaskServiceAdapter) MarkPolicyViolation(ctx context.Context, subtaskID uuid.UUID) error {
+	return a.svc.MarkPolicyViolation(ctx, subtaskID)
+}
+
 func (a *subtaskServiceAdapter) IncrementRetryCount(ctx context.Context, subtaskID uuid.UUID) (int, error) {
 	return a.svc.IncrementRetryCount(ctx, subtaskID)
 }
 
-func (a *subtaskServiceAdapter) UpdateTokenUsage(ctx context.Context, subtaskID uuid.UUID, tokens int) error {
+func (a *subtaskServiceAdapter) DecrementRetryCount(ctx context.Context, subtaskID uuid.UUID) (int, error) {
+	return a.svc.DecrementRetryCount(ctx, subtaskID)
+}
+
+func (a *subtaskServiceAdapter) SetNextAttemptAt(ctx context.Context, subtaskID uuid.UUID, at *time.Time) error {
+	return a.svc.SetNextAttemptAt(ctx, subtaskID, at)
+}
+
+func (a *subtaskServiceAdapter) UpdateTokenUsage(ctx context.Context, subtaskID uuid.UUID, tokens int) (*domain.Subtask, error) {
 	return a.svc.UpdateTokenUsage(ctx, subtaskID, tokens)
 }
 
@@ -179,6 +249,18 @@ func (a *eventPublisherAdapter) PublishAgentFailed(projectID uuid.UUID, run *dom
 	a.hub.PublishAgentFailed(projectID, run, taskID, errMsg, willRetry, nextAttemptAt)
 }
 
+func (a *eventPublisherAdapter) PublishAgentStalled(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, lastOutputAt time.Time, cancelled bool) {
+	a.hub.PublishAgentStalled(projectID, run, taskID, lastOutputAt, cancelled)
+}
+
+func (a *eventPublisherAdapter) PublishSubtaskCreated(projectID uuid.UUID, subtask *domain.Subtask) {
+	a.hub.PublishSubtaskCreated(projectID, subtask)
+}
+
+func (a *eventPublisherAdapter) PublishReauthRequired(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string) {
+	a.hub.PublishReauthRequired(projectID, taskID, subtaskID, operation, errMsg)
+}
+
 // logTailerAdapter adapts service.LogTailer to agent.LogTailerInterface.
 type logTailerAdapter struct {
 	tailer service.LogTailer
@@ -191,8 +273,8 @@ func newLogTailerAdapter(tailer service.LogTailer) agent.LogTailerInterface {
 	return &logTailerAdapter{tailer: tailer}
 }
 
-func (a *logTailerAdapter) StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath string) error {
-	return a.tailer.StartTailing(ctx, projectID, runID, logPath)
+func (a *logTailerAdapter) StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath, token string) error {
+	return a.tailer.StartTailing(ctx, projectID, runID, logPath, token)
 }
 
 func (a *logTailerAdapter) StopTailing(runID uuid.UUID) {
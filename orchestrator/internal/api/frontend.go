@@ -6,10 +6,14 @@
 package api
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"io/fs"
 	"net/http"
 	"strings"
+	"time"
 )
 
 //go:embed frontend/dist
@@ -19,6 +23,7 @@ var frontendFS embed.FS
 type FrontendHandler struct {
 	fileServer http.Handler
 	indexHTML  []byte
+	etags      map[string]string // content hash ETag per file path
 }
 
 // NewFrontendHandler creates a new handler for serving the frontend.
@@ -35,20 +40,46 @@ func NewFrontendHandler() (*FrontendHandler, error) {
 		return nil, err
 	}
 
+	// Embedded files have no modification time, so tag them by content
+	etags, err := hashFiles(distFS)
+	if err != nil {
+		return nil, err
+	}
+
 	return &FrontendHandler{
 		fileServer: http.FileServer(http.FS(distFS)),
 		indexHTML:  indexHTML,
+		etags:      etags,
 	}, nil
 }
 
+// hashFiles returns a strong ETag for every file in fsys, keyed by path.
+func hashFiles(fsys fs.FS) (map[string]string, error) {
+	etags := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		etags[path] = `"` + hex.EncodeToString(sum[:16]) + `"`
+		return nil
+	})
+	return etags, err
+}
+
 // ServeHTTP handles frontend requests.
 // For static assets, it serves them directly.
 // For other routes, it returns index.html for SPA routing.
 func (h *FrontendHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
 
-	// Skip API routes
-	if strings.HasPrefix(path, "/api") || path == "/health" {
+	// API paths are answered before this by spaHandler; health paths the
+	// router does not know are not SPA routes either
+	if path == "/health" || strings.HasPrefix(path, "/health/") {
 		http.NotFound(w, r)
 		return
 	}
@@ -64,13 +95,17 @@ func (h *FrontendHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	f, err := distFS.Open(cleanPath)
 	if err == nil {
 		f.Close()
-		// File exists, serve it
+		// File exists, serve it. The file server answers If-None-Match
+		// with 304 when the ETag header is already set.
+		if etag, ok := h.etags[cleanPath]; ok {
+			w.Header().Set("ETag", etag)
+		}
 		h.fileServer.ServeHTTP(w, r)
 		return
 	}
 
 	// File doesn't exist, serve index.html for SPA routing
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	w.Write(h.indexHTML)
+	w.Header().Set("ETag", h.etags["index.html"])
+	http.ServeContent(w, r, "index.html", time.Time{}, bytes.NewReader(h.indexHTML))
 }
@@ -0,0 +1,122 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// RunningAgentLister reports the agents running in this process, keyed by
+// task ID (Planners) or subtask ID (Workers).
+type RunningAgentLister interface {
+	GetRunningAgents() map[uuid.UUID]domain.AgentType
+}
+
+// AdminHandler handles operator endpoints that span every user's projects.
+// Routes must be gated by middleware.RequireAdmin.
+type AdminHandler struct {
+	repo   *repository.Repository
+	agents RunningAgentLister
+}
+
+// NewAdminHandler creates a new AdminHandler.
+func NewAdminHandler(repo *repository.Repository, agents RunningAgentLister) *AdminHandler {
+	return &AdminHandler{repo: repo, agents: agents}
+}
+
+// AdminActiveRunResponse is a running agent run in the admin view.
+type AdminActiveRunResponse struct {
+	ID             string  `json:"id"`
+	AgentType      string  `json:"agent_type"`
+	ProjectID      string  `json:"project_id"`
+	Repo           string  `json:"repo"` // owner/repo
+	TaskID         string  `json:"task_id"`
+	SubtaskID      *string `json:"subtask_id,omitempty"`
+	UserID         string  `json:"user_id"`
+	GitHubUsername string  `json:"github_username"`
+	AttemptNumber  int     `json:"attempt_number"`
+	StartedAt      string  `json:"started_at"`
+	DurationS      int64   `json:"duration_s"`
+	// Tokens the subtask used in earlier attempts; the running attempt's
+	// usage is only known once it ends
+	TokenUsage int `json:"token_usage"`
+	// Tracked is false for a RUNNING row this process is not running, e.g.
+	// one orphaned by a crash that startup recovery has not reached
+	Tracked bool `json:"tracked"`
+}
+
+// AdminActiveRunsResponse lists every running agent run with totals.
+type AdminActiveRunsResponse struct {
+	Runs            []AdminActiveRunResponse `json:"runs"`
+	TotalRuns       int                      `json:"total_runs"`
+	TotalTokenUsage int64                    `json:"total_token_usage"`
+	// RunningAgents counts the agents this process is running, including
+	// any that have not created their run record yet
+	RunningAgents int `json:"running_agents"`
+}
+
+// ActiveRuns lists running agent runs across all projects and users.
+// GET /api/admin/active-runs
+func (h *AdminHandler) ActiveRuns(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.repo.ListRunningAgentRunsWithOwners(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list running agent runs")
+		response.InternalError(w, err)
+		return
+	}
+
+	var agents map[uuid.UUID]domain.AgentType
+	if h.agents != nil {
+		agents = h.agents.GetRunningAgents()
+	}
+
+	response.OK(w, buildAdminActiveRuns(rows, agents, time.Now()))
+}
+
+// buildAdminActiveRuns converts running runs to the admin response, marking
+// which are tracked by the in-process agents and totalling token usage.
+func buildAdminActiveRuns(rows []db.ListRunningAgentRunsWithOwnersRow, agents map[uuid.UUID]domain.AgentType, now time.Time) AdminActiveRunsResponse {
+	result := AdminActiveRunsResponse{
+		Runs:          make([]AdminActiveRunResponse, len(rows)),
+		TotalRuns:     len(rows),
+		RunningAgents: len(agents),
+	}
+	for i, row := range rows {
+		run := AdminActiveRunResponse{
+			ID:             row.ID.String(),
+			AgentType:      row.AgentType,
+			ProjectID:      row.ProjectID.String(),
+			Repo:           row.GithubOwner + "/" + row.GithubRepo,
+			TaskID:         row.TaskID.String(),
+			UserID:         row.UserID.String(),
+			GitHubUsername: row.GithubUsername,
+			AttemptNumber:  int(row.AttemptNumber),
+			StartedAt:      row.StartedAt.Format(time.RFC3339),
+			DurationS:      int64(now.Sub(row.StartedAt).Seconds()),
+			TokenUsage:     int(row.SubtaskTokenUsage),
+		}
+
+		agentKey := row.TaskID
+		if row.SubtaskID.Valid {
+			subtaskID := uuid.UUID(row.SubtaskID.Bytes)
+			s := subtaskID.String()
+			run.SubtaskID = &s
+			agentKey = subtaskID
+		}
+		_, run.Tracked = agents[agentKey]
+
+		result.Runs[i] = run
+		result.TotalTokenUsage += int64(row.SubtaskTokenUsage)
+	}
+	return result
+}
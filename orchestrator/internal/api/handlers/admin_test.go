@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+func TestBuildAdminActiveRuns(t *testing.T) {
+	now := time.Now()
+	taskID, subtaskID := uuid.New(), uuid.New()
+	rows := []db.ListRunningAgentRunsWithOwnersRow{
+		{
+			ID:             uuid.New(),
+			TaskID:         taskID,
+			GithubOwner:    "octo",
+			GithubRepo:     "app",
+			GithubUsername: "alice",
+			AgentType:      string(domain.AgentTypePlanner),
+			AttemptNumber:  1,
+			StartedAt:      now.Add(-90 * time.Second),
+		},
+		{
+			ID:                uuid.New(),
+			SubtaskID:         pgtype.UUID{Bytes: subtaskID, Valid: true},
+			TaskID:            taskID,
+			GithubOwner:       "octo",
+			GithubRepo:        "app",
+			GithubUsername:    "alice",
+			AgentType:         string(domain.AgentTypeWorker),
+			AttemptNumber:     3,
+			StartedAt:         now.Add(-10 * time.Second),
+			SubtaskTokenUsage: 1200,
+		},
+	}
+	// Only the Worker is running in this process; the Planner row is orphaned.
+	agents := map[uuid.UUID]domain.AgentType{subtaskID: domain.AgentTypeWorker}
+
+	got := buildAdminActiveRuns(rows, agents, now)
+
+	if got.TotalRuns != 2 || got.TotalTokenUsage != 1200 || got.RunningAgents != 1 {
+		t.Errorf("totals = (%d runs, %d tokens, %d agents), want (2, 1200, 1)", got.TotalRuns, got.TotalTokenUsage, got.RunningAgents)
+	}
+
+	planner, worker := got.Runs[0], got.Runs[1]
+	if planner.SubtaskID != nil || planner.Tracked || planner.DurationS != 90 || planner.Repo != "octo/app" {
+		t.Errorf("planner run = %+v", planner)
+	}
+	if worker.SubtaskID == nil || *worker.SubtaskID != subtaskID.String() || !worker.Tracked || worker.AttemptNumber != 3 {
+		t.Errorf("worker run = %+v", worker)
+	}
+}
+
+func TestBuildAdminActiveRuns_Empty(t *testing.T) {
+	got := buildAdminActiveRuns(nil, nil, time.Now())
+	if got.Runs == nil || len(got.Runs) != 0 {
+		t.Errorf("Runs = %v, want an empty list", got.Runs)
+	}
+}
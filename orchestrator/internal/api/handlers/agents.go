@@ -4,11 +4,18 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,31 +23,98 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog/log"
 
+	"github.com/intern-village/orchestrator/generated/db"
 	"github.com/intern-village/orchestrator/internal/api/middleware"
 	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/domain"
 	"github.com/intern-village/orchestrator/internal/repository"
+	"github.com/intern-village/orchestrator/internal/service"
 )
 
 // AgentRunResponse represents an agent run in API responses.
 type AgentRunResponse struct {
 	ID            string  `json:"id"`
 	SubtaskID     string  `json:"subtask_id"`
+	TaskID        string  `json:"task_id,omitempty"`
 	AgentType     string  `json:"agent_type"`
 	AttemptNumber int     `json:"attempt_number"`
 	Status        string  `json:"status"`
 	StartedAt     string  `json:"started_at"`
 	EndedAt       *string `json:"ended_at,omitempty"`
+	DurationMs    *int64  `json:"duration_ms,omitempty"`
 	TokenUsage    *int    `json:"token_usage,omitempty"`
 	ErrorMessage  *string `json:"error_message,omitempty"`
 	LogPath       string  `json:"log_path"`
-	CreatedAt     string  `json:"created_at"`
+	// Bytes in the attempt log, in run listings only; omitted once the log
+	// has been pruned (MAX_ATTEMPT_LOGS) or is otherwise gone
+	LogSize   *int64 `json:"log_size,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// SubtaskRunStatsResponse aggregates a subtask's agent runs, for spotting
+// subtasks that are expensive or flaky. Durations are in milliseconds and
+// cover finished attempts only.
+type SubtaskRunStatsResponse struct {
+	SubtaskID         string  `json:"subtask_id"`
+	AttemptCount      int     `json:"attempt_count"`
+	SucceededCount    int     `json:"succeeded_count"`
+	FailedCount       int     `json:"failed_count"`
+	RunningCount      int     `json:"running_count"`
+	InterruptedCount  int     `json:"interrupted_count"`
+	TotalDurationMs   int64   `json:"total_duration_ms"`
+	AverageDurationMs int64   `json:"average_duration_ms"`
+	LastAttemptAt     *string `json:"last_attempt_at,omitempty"`
+}
+
+// TokenUsagePointResponse is one token usage report of a run in a task's
+// usage time series.
+type TokenUsagePointResponse struct {
+	RunID         string `json:"run_id"`
+	SubtaskID     string `json:"subtask_id,omitempty"`
+	AgentType     string `json:"agent_type"`
+	AttemptNumber int    `json:"attempt_number"`
+	InputTokens   int    `json:"input_tokens"`
+	OutputTokens  int    `json:"output_tokens"`
+	TotalTokens   int    `json:"total_tokens"`
+	// Tokens used by the task up to and including this report
+	CumulativeTokens int    `json:"cumulative_tokens"`
+	RecordedAt       string `json:"recorded_at"`
+}
+
+// TaskUsageTimeseriesResponse is the token usage of a task over time.
+type TaskUsageTimeseriesResponse struct {
+	TaskID      string                    `json:"task_id"`
+	TotalTokens int                       `json:"total_tokens"`
+	Points      []TokenUsagePointResponse `json:"points"`
 }
 
 // AgentRunLogsResponse represents the logs for an agent run.
+// Offset and NextOffset are byte offsets into the log file; pass NextOffset
+// as the offset of the next request to page forward.
 type AgentRunLogsResponse struct {
-	RunID   string `json:"run_id"`
-	LogPath string `json:"log_path"`
-	Content string `json:"content"`
+	RunID      string `json:"run_id"`
+	LogPath    string `json:"log_path"`
+	Content    string `json:"content"`
+	Offset     int64  `json:"offset"`
+	NextOffset int64  `json:"next_offset"`
+	Size       int64  `json:"size"`
+}
+
+// AgentRunPromptResponse is the prompt an agent run was started with.
+type AgentRunPromptResponse struct {
+	RunID     string `json:"run_id"`
+	AgentType string `json:"agent_type"`
+	Prompt    string `json:"prompt"`
+}
+
+// EventTypeLogEnd is sent on a followed log stream once the run has finished.
+const EventTypeLogEnd = "log:end"
+
+// LogEndData is the data for a log:end event.
+type LogEndData struct {
+	RunID  uuid.UUID `json:"run_id"`
+	Status string    `json:"status,omitempty"`
 }
 
 // SubtaskOwnershipChecker is an interface for checking subtask ownership.
@@ -48,17 +122,34 @@ type SubtaskOwnershipChecker interface {
 	CheckSubtaskOwnership(ctx context.Context, subtaskID, userID uuid.UUID) error
 }
 
+// TaskOwnershipChecker is an interface for checking task ownership.
+type TaskOwnershipChecker interface {
+	CheckTaskOwnership(ctx context.Context, taskID, userID uuid.UUID) error
+}
+
 // AgentHandler handles agent-related HTTP requests.
 type AgentHandler struct {
 	repo           *repository.Repository
 	subtaskService SubtaskOwnershipChecker
+	taskService    TaskOwnershipChecker
+	eventHub       service.EventHub
+	cfg            *config.Config
 }
 
 // NewAgentHandler creates a new AgentHandler.
-func NewAgentHandler(repo *repository.Repository, subtaskService SubtaskOwnershipChecker) *AgentHandler {
+func NewAgentHandler(
+	repo *repository.Repository,
+	subtaskService SubtaskOwnershipChecker,
+	taskService TaskOwnershipChecker,
+	eventHub service.EventHub,
+	cfg *config.Config,
+) *AgentHandler {
 	return &AgentHandler{
 		repo:           repo,
 		subtaskService: subtaskService,
+		taskService:    taskService,
+		eventHub:       eventHub,
+		cfg:            cfg,
 	}
 }
 
@@ -101,41 +192,338 @@ func (h *AgentHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
 	// Convert to response format
 	result := make([]AgentRunResponse, len(runs))
 	for i, run := range runs {
-		subtaskIDStr := ""
-		if run.SubtaskID.Valid {
-			subtaskIDStr = uuid.UUID(run.SubtaskID.Bytes).String()
+		result[i] = agentRunToResponse(run)
+		result[i].LogSize = logSize(run.LogPath)
+	}
+
+	response.OK(w, result)
+}
+
+// SubtaskStats returns aggregates of a subtask's agent runs: attempt counts by
+// outcome, total and average attempt duration, and when the last attempt
+// started.
+// GET /api/subtasks/{id}/stats
+func (h *AgentHandler) SubtaskStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse subtask ID from URL
+	subtaskID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid subtask ID")
+		return
+	}
+
+	// Verify user owns the subtask (via ownership check)
+	if err := h.subtaskService.CheckSubtaskOwnership(ctx, subtaskID, userID); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	stats, err := h.repo.GetSubtaskRunStats(ctx, pgtype.UUID{Bytes: subtaskID, Valid: true})
+	if err != nil {
+		log.Error().Err(err).
+			Str("subtask_id", subtaskID.String()).
+			Msg("failed to get subtask run stats")
+		response.InternalError(w, fmt.Errorf("failed to get run stats: %w", err))
+		return
+	}
+
+	response.OK(w, runStatsToResponse(subtaskID, stats))
+}
+
+// runStatsToResponse converts a subtask's run aggregates to the API response.
+func runStatsToResponse(subtaskID uuid.UUID, stats db.GetSubtaskRunStatsRow) SubtaskRunStatsResponse {
+	resp := SubtaskRunStatsResponse{
+		SubtaskID:         subtaskID.String(),
+		AttemptCount:      int(stats.AttemptCount),
+		SucceededCount:    int(stats.SucceededCount),
+		FailedCount:       int(stats.FailedCount),
+		RunningCount:      int(stats.RunningCount),
+		InterruptedCount:  int(stats.InterruptedCount),
+		TotalDurationMs:   stats.TotalDurationMs,
+		AverageDurationMs: stats.AverageDurationMs,
+	}
+	if stats.LastAttemptAt.Valid {
+		lastAttemptAt := stats.LastAttemptAt.Time.Format(time.RFC3339)
+		resp.LastAttemptAt = &lastAttemptAt
+	}
+	return resp
+}
+
+// ListTaskRuns lists the Planner runs of a task and the Worker runs of all its
+// subtasks, oldest first, for rendering a timeline.
+// GET /api/tasks/{id}/runs?status=FAILED&agent_type=WORKER
+func (h *AgentHandler) ListTaskRuns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	// Parse optional filters
+	params := db.ListAllAgentRunsForTaskParams{TaskID: taskID}
+	if status := r.URL.Query().Get("status"); status != "" {
+		if !domain.AgentRunStatus(status).IsValid() {
+			response.BadRequest(w, "invalid status filter")
+			return
 		}
-		result[i] = AgentRunResponse{
-			ID:            run.ID.String(),
-			SubtaskID:     subtaskIDStr,
-			AgentType:     run.AgentType,
-			AttemptNumber: int(run.AttemptNumber),
-			Status:        run.Status,
-			StartedAt:     run.StartedAt.Format(time.RFC3339),
-			LogPath:       run.LogPath,
-			CreatedAt:     run.CreatedAt.Format(time.RFC3339),
+		params.Status = &status
+	}
+	if agentType := r.URL.Query().Get("agent_type"); agentType != "" {
+		if !domain.AgentType(agentType).IsValid() {
+			response.BadRequest(w, "invalid agent_type filter")
+			return
 		}
+		params.AgentType = &agentType
+	}
 
-		if run.EndedAt.Valid {
-			endedAt := run.EndedAt.Time.Format(time.RFC3339)
-			result[i].EndedAt = &endedAt
-		}
+	// Verify user owns the task (via task -> project chain)
+	if err := h.taskService.CheckTaskOwnership(ctx, taskID, userID); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	runs, err := h.repo.ListAllAgentRunsForTask(ctx, params)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Msg("failed to list agent runs for task")
+		response.InternalError(w, fmt.Errorf("failed to list agent runs: %w", err))
+		return
+	}
+
+	result := make([]AgentRunResponse, len(runs))
+	for i, run := range runs {
+		result[i] = agentRunToResponse(run)
+		result[i].LogSize = logSize(run.LogPath)
+		// Worker runs are linked through their subtask; report the task they belong to
+		result[i].TaskID = taskID.String()
+	}
+
+	response.OK(w, result)
+}
 
-		if run.TokenUsage != nil {
-			tokenUsage := int(*run.TokenUsage)
-			result[i].TokenUsage = &tokenUsage
+// TaskUsageTimeseries returns the token usage reports of a task's Planner and
+// Worker runs, oldest first, with a running total for charting spend.
+// GET /api/tasks/{id}/usage/timeseries
+func (h *AgentHandler) TaskUsageTimeseries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	// Verify user owns the task (via task -> project chain)
+	if err := h.taskService.CheckTaskOwnership(ctx, taskID, userID); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	rows, err := h.repo.ListTokenUsageForTask(ctx, taskID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Msg("failed to list token usage for task")
+		response.InternalError(w, fmt.Errorf("failed to list token usage: %w", err))
+		return
+	}
+
+	response.OK(w, tokenUsageToTimeseries(taskID, rows))
+}
+
+// tokenUsageToTimeseries converts a task's token usage reports, oldest first,
+// to its usage time series.
+func tokenUsageToTimeseries(taskID uuid.UUID, rows []db.ListTokenUsageForTaskRow) TaskUsageTimeseriesResponse {
+	resp := TaskUsageTimeseriesResponse{
+		TaskID: taskID.String(),
+		Points: make([]TokenUsagePointResponse, len(rows)),
+	}
+	for i, row := range rows {
+		resp.TotalTokens += int(row.TotalTokens)
+		point := TokenUsagePointResponse{
+			RunID:            row.AgentRunID.String(),
+			AgentType:        row.AgentType,
+			AttemptNumber:    int(row.AttemptNumber),
+			InputTokens:      int(row.InputTokens),
+			OutputTokens:     int(row.OutputTokens),
+			TotalTokens:      int(row.TotalTokens),
+			CumulativeTokens: resp.TotalTokens,
+			RecordedAt:       row.RecordedAt.Format(time.RFC3339),
 		}
+		if row.SubtaskID.Valid {
+			point.SubtaskID = uuid.UUID(row.SubtaskID.Bytes).String()
+		}
+		resp.Points[i] = point
+	}
+	return resp
+}
+
+// GetPrompt returns the rendered prompt an agent run was started with, with
+// known secret formats redacted.
+// GET /api/runs/{id}/prompt
+func (h *AgentHandler) GetPrompt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse run ID from URL
+	runID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid run ID")
+		return
+	}
 
-		if run.ErrorMessage != nil {
-			result[i].ErrorMessage = run.ErrorMessage
+	run, err := h.repo.GetAgentRunByID(ctx, runID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("run_id", runID.String()).
+			Msg("failed to get agent run")
+		response.NotFound(w, "agent run not found")
+		return
+	}
+
+	if err := h.checkRunOwnership(ctx, run, userID); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	response.OK(w, AgentRunPromptResponse{
+		RunID:     run.ID.String(),
+		AgentType: run.AgentType,
+		Prompt:    redactSecrets(run.PromptText),
+	})
+}
+
+// checkRunOwnership verifies the user owns the run, through its subtask for
+// Worker runs or its task for Planner runs.
+func (h *AgentHandler) checkRunOwnership(ctx context.Context, run db.AgentRun, userID uuid.UUID) error {
+	switch {
+	case run.SubtaskID.Valid:
+		return h.subtaskService.CheckSubtaskOwnership(ctx, uuid.UUID(run.SubtaskID.Bytes), userID)
+	case run.TaskID.Valid:
+		return h.taskService.CheckTaskOwnership(ctx, uuid.UUID(run.TaskID.Bytes), userID)
+	default:
+		return domain.NewNotFoundError("agent run", run.ID.String())
+	}
+}
+
+// runProjectID returns the project of run: through its subtask's task for a
+// Worker run, which has no task_id, or through its task for a Planner run.
+func (h *AgentHandler) runProjectID(ctx context.Context, run db.AgentRun) (uuid.UUID, error) {
+	taskID := uuid.UUID(run.TaskID.Bytes)
+	if run.SubtaskID.Valid {
+		subtask, err := h.repo.GetSubtaskByID(ctx, uuid.UUID(run.SubtaskID.Bytes))
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to get subtask: %w", err)
 		}
+		taskID = subtask.TaskID
+	} else if !run.TaskID.Valid {
+		return uuid.Nil, fmt.Errorf("agent run %s has neither subtask nor task", run.ID)
 	}
 
-	response.OK(w, result)
+	task, err := h.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return task.ProjectID, nil
+}
+
+// logSize returns the size of an attempt log, or nil if it is not on disk.
+func logSize(path string) *int64 {
+	if path == "" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+	size := info.Size()
+	return &size
+}
+
+// agentRunToResponse converts a database agent run to its API representation.
+func agentRunToResponse(run db.AgentRun) AgentRunResponse {
+	resp := AgentRunResponse{
+		ID:            run.ID.String(),
+		AgentType:     run.AgentType,
+		AttemptNumber: int(run.AttemptNumber),
+		Status:        run.Status,
+		StartedAt:     run.StartedAt.Format(time.RFC3339),
+		LogPath:       run.LogPath,
+		CreatedAt:     run.CreatedAt.Format(time.RFC3339),
+	}
+
+	if run.SubtaskID.Valid {
+		resp.SubtaskID = uuid.UUID(run.SubtaskID.Bytes).String()
+	}
+
+	if run.TaskID.Valid {
+		resp.TaskID = uuid.UUID(run.TaskID.Bytes).String()
+	}
+
+	if run.EndedAt.Valid {
+		endedAt := run.EndedAt.Time.Format(time.RFC3339)
+		resp.EndedAt = &endedAt
+		durationMs := run.EndedAt.Time.Sub(run.StartedAt).Milliseconds()
+		resp.DurationMs = &durationMs
+	}
+
+	if run.TokenUsage != nil {
+		tokenUsage := int(*run.TokenUsage)
+		resp.TokenUsage = &tokenUsage
+	}
+
+	if run.ErrorMessage != nil {
+		resp.ErrorMessage = run.ErrorMessage
+	}
+
+	return resp
 }
 
 // GetLogs gets the log content for an agent run.
 // GET /api/runs/{id}/logs
+//
+// Query params:
+//   - offset: byte offset to start reading from; negative values count back
+//     from the end of the file (e.g. -65536 for the last 64KB)
+//   - limit: maximum number of bytes to return (0 or absent reads to the end)
+//   - follow: when "true", stream the log as SSE and keep following the live
+//     tail until the run finishes
+//
+// With no params the whole file is returned.
 func (h *AgentHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -154,6 +542,33 @@ func (h *AgentHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Parse range and follow params
+	offset, limit, err := parseLogRange(r.URL.Query())
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	follow := false
+	if followStr := r.URL.Query().Get("follow"); followStr != "" {
+		follow, err = strconv.ParseBool(followStr)
+		if err != nil {
+			response.BadRequest(w, "follow must be true or false")
+			return
+		}
+	}
+	if follow && limit > 0 {
+		response.BadRequest(w, "limit cannot be combined with follow")
+		return
+	}
+	var schema eventSchema
+	if follow {
+		// Followed logs are an SSE stream, versioned like the event stream
+		if schema, err = requestedEventSchema(r); err != nil {
+			response.BadRequest(w, err.Error())
+			return
+		}
+	}
+
 	// Get the agent run
 	run, err := h.repo.GetAgentRunByID(ctx, runID)
 	if err != nil {
@@ -164,18 +579,28 @@ func (h *AgentHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify user owns the subtask (via ownership check)
-	// Note: For Planner runs, SubtaskID may be nil - skip ownership check for those
-	if run.SubtaskID.Valid {
-		subtaskID := uuid.UUID(run.SubtaskID.Bytes)
-		if err := h.subtaskService.CheckSubtaskOwnership(ctx, subtaskID, userID); err != nil {
-			response.ErrorFromDomain(w, err)
+	// Verify user owns the run's subtask or, for a Planner run, its task
+	if err := h.checkRunOwnership(ctx, run, userID); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	if follow {
+		// Live log events are published per project, so resolve the run's project
+		projectID, err := h.runProjectID(ctx, run)
+		if err != nil {
+			log.Error().Err(err).
+				Str("run_id", runID.String()).
+				Msg("failed to resolve project for agent run")
+			response.InternalError(w, err)
 			return
 		}
+		h.followLogs(w, r, run, projectID, userID, offset, schema)
+		return
 	}
 
-	// Read log file content
-	content, err := os.ReadFile(run.LogPath)
+	// Read the requested slice of the log file
+	slice, err := readLogRange(run.LogPath, offset, limit)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			// Log file doesn't exist yet or has been cleaned up
@@ -195,8 +620,427 @@ func (h *AgentHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.OK(w, AgentRunLogsResponse{
-		RunID:   run.ID.String(),
-		LogPath: run.LogPath,
-		Content: string(content),
+		RunID:      run.ID.String(),
+		LogPath:    run.LogPath,
+		Content:    string(slice.content),
+		Offset:     slice.start,
+		NextOffset: slice.next,
+		Size:       slice.size,
 	})
 }
+
+// DownloadLogs streams an agent run's whole raw log file, stderr and stream
+// JSON included, as an attachment. Known secret formats are redacted line by
+// line as the file is copied, so it is never held in memory.
+// GET /api/runs/{id}/logs/download
+func (h *AgentHandler) DownloadLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse run ID from URL
+	runID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid run ID")
+		return
+	}
+
+	run, err := h.repo.GetAgentRunByID(ctx, runID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("run_id", runID.String()).
+			Msg("failed to get agent run")
+		response.NotFound(w, "agent run not found")
+		return
+	}
+
+	if err := h.checkRunOwnership(ctx, run, userID); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	f, err := os.Open(run.LogPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			response.NotFound(w, "log file not found")
+			return
+		}
+		log.Error().Err(err).
+			Str("run_id", runID.String()).
+			Str("log_path", run.LogPath).
+			Msg("failed to open log file")
+		response.InternalError(w, fmt.Errorf("failed to open log file: %w", err))
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": logDownloadName(run),
+	}))
+	if err := copyRedacted(w, f); err != nil {
+		// Headers are already sent; the client sees a truncated download
+		log.Error().Err(err).
+			Str("run_id", runID.String()).
+			Str("log_path", run.LogPath).
+			Msg("failed to stream log file")
+	}
+}
+
+// logDownloadName is the file name a run's log is downloaded as, e.g.
+// worker-attempt-2-<run id>.log.
+func logDownloadName(run db.AgentRun) string {
+	return fmt.Sprintf("%s-attempt-%d-%s.log", strings.ToLower(run.AgentType), run.AttemptNumber, run.ID)
+}
+
+// copyRedacted copies src to dst a line at a time with secrets redacted.
+func copyRedacted(dst io.Writer, src io.Reader) error {
+	reader := bufio.NewReader(src)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if _, werr := io.WriteString(dst, redactSecrets(line)); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// followLogs streams a run's log as SSE: first the existing content from
+// offset, then live lines from the EventHub until the run finishes. Every line
+// is sent as agent:log, even those published in an agent:log_batch. Event data
+// is shaped by schema, as on the event stream.
+func (h *AgentHandler) followLogs(w http.ResponseWriter, r *http.Request, run db.AgentRun, projectID, userID uuid.UUID, offset int64, schema eventSchema) {
+	ctx := r.Context()
+
+	// Reject new streams once the server is draining
+	select {
+	case <-h.eventHub.Draining():
+		response.Error(w, http.StatusServiceUnavailable, response.CodeShuttingDown, "server is shutting down")
+		return
+	default:
+	}
+
+	// Followed logs count against the same per-user limit as event streams
+	if h.eventHub.UserConnectionCount(userID) >= h.cfg.SSEMaxConnectionsPerUser {
+		response.Error(w, http.StatusTooManyRequests, response.CodeTooManyConnections,
+			fmt.Sprintf("maximum %d connections per user", h.cfg.SSEMaxConnectionsPerUser))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.InternalError(w, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	// Subscribe before reading the file so no line published in between is
+	// missed; duplicates are dropped by line number below.
+	connID, eventCh, cleanup := h.eventHub.Subscribe(projectID, userID, []uuid.UUID{run.ID})
+	defer cleanup()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	sendLine := func(line string, lineNumber int) error {
+		return writeSSE(w, flusher, service.EventTypeAgentLog, schema.payload(service.EventTypeAgentLog, service.AgentLogData{
+			RunID:      run.ID,
+			Line:       line,
+			LineNumber: lineNumber,
+			Timestamp:  service.ParseLogTimestamp(line),
+		}))
+	}
+
+	running := run.Status == string(domain.AgentRunStatusRunning)
+
+	// Send the existing content. A trailing partial line is only sent once the
+	// run has finished; while running, the LogTailer publishes it when complete.
+	var pos int64
+	lastLine := 0
+	slice, err := readLogRange(run.LogPath, offset, 0)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Error().Err(err).Str("run_id", run.ID.String()).Msg("failed to read log file")
+		return
+	}
+	if err == nil {
+		if lastLine, err = countLines(run.LogPath, slice.start); err != nil {
+			log.Error().Err(err).Str("run_id", run.ID.String()).Msg("failed to count log lines")
+			return
+		}
+		consumed, err := scanLogLines(slice.content, !running, func(line string) error {
+			lastLine++
+			return sendLine(line, lastLine)
+		})
+		if err != nil {
+			log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send log line, client disconnected")
+			return
+		}
+		pos = slice.start + int64(consumed)
+	}
+
+	// finish flushes lines the LogTailer did not publish (e.g. the footer after
+	// the completion sentinel) and tells the client the stream is complete.
+	posLine := lastLine
+	finish := func(status string) {
+		if slice, err := readLogRange(run.LogPath, pos, 0); err == nil {
+			n := posLine
+			_, _ = scanLogLines(slice.content, true, func(line string) error {
+				n++
+				if n <= lastLine {
+					return nil
+				}
+				lastLine = n
+				return sendLine(line, n)
+			})
+		}
+		if err := writeSSE(w, flusher, EventTypeLogEnd, schema.payload(EventTypeLogEnd, LogEndData{RunID: run.ID, Status: status})); err != nil {
+			log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send log end event")
+		}
+	}
+
+	if !running {
+		finish(run.Status)
+		return
+	}
+
+	// The token identifies the run's real completion line, so agent output
+	// that echoes a marker does not end the stream early
+	completionToken := service.ReadCompletionToken(run.LogPath)
+
+	// followLine sends a live line unless it was already sent, and reports
+	// whether the stream is done: the line completes the run or the client is gone
+	followLine := func(line string, lineNumber int) bool {
+		if lineNumber <= lastLine {
+			return false
+		}
+		lastLine = lineNumber
+		if err := sendLine(line, lineNumber); err != nil {
+			log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send log line, client disconnected")
+			return true
+		}
+		if service.IsRunComplete(line, completionToken) {
+			finish("")
+			return true
+		}
+		return false
+	}
+
+	heartbeatTicker := time.NewTicker(time.Duration(h.cfg.SSEHeartbeatIntervalS) * time.Second)
+	defer heartbeatTicker.Stop()
+
+	timeoutTimer := time.NewTimer(time.Duration(h.cfg.SSEConnectionTimeoutM) * time.Minute)
+	defer timeoutTimer.Stop()
+
+	log.Info().
+		Str("run_id", run.ID.String()).
+		Str("conn_id", connID).
+		Msg("log follow stream established")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-h.eventHub.Draining():
+			shutdownData := service.ShutdownData{
+				Reason:    "server shutting down",
+				Timestamp: time.Now(),
+			}
+			if err := writeSSE(w, flusher, service.EventTypeShutdown, schema.payload(service.EventTypeShutdown, shutdownData)); err != nil {
+				log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send shutdown event")
+			}
+			return
+
+		case <-timeoutTimer.C:
+			return
+
+		case <-heartbeatTicker.C:
+			if err := writeSSE(w, flusher, service.EventTypeHeartbeat, schema.payload(service.EventTypeHeartbeat, map[string]string{"time": time.Now().Format(time.RFC3339)})); err != nil {
+				return
+			}
+
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			switch data := event.Data.(type) {
+			case service.AgentLogData:
+				if data.RunID == run.ID && followLine(data.Line, data.LineNumber) {
+					return
+				}
+			case service.AgentLogBatchData:
+				if data.RunID != run.ID {
+					continue
+				}
+				for _, l := range data.Lines {
+					if followLine(l.Line, l.LineNumber) {
+						return
+					}
+				}
+			case service.AgentLogThrottledData:
+				// Pass the notice on; the skipped lines can be read with GetLogs
+				if data.RunID == run.ID {
+					if err := writeSSE(w, flusher, service.EventTypeAgentLogThrottled, schema.payload(service.EventTypeAgentLogThrottled, data)); err != nil {
+						log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send log throttled event, client disconnected")
+						return
+					}
+				}
+			case service.AgentCompletedData:
+				if data.RunID == run.ID {
+					finish(string(domain.AgentRunStatusSucceeded))
+					return
+				}
+			case service.AgentFailedData:
+				if data.RunID == run.ID {
+					finish(string(domain.AgentRunStatusFailed))
+					return
+				}
+			}
+		}
+	}
+}
+
+// logSlice is a byte range read from a log file.
+type logSlice struct {
+	content []byte
+	start   int64 // offset of the first byte of content
+	next    int64 // offset just past the last byte read
+	size    int64 // file size at the time of the read
+}
+
+// parseLogRange parses the offset and limit query params for GetLogs.
+func parseLogRange(q url.Values) (offset, limit int64, err error) {
+	if s := q.Get("offset"); s != "" {
+		offset, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("offset must be an integer")
+		}
+	}
+	if s := q.Get("limit"); s != "" {
+		limit, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || limit < 0 {
+			return 0, 0, fmt.Errorf("limit must be a non-negative integer")
+		}
+	}
+	return offset, limit, nil
+}
+
+// readLogRange reads up to limit bytes (0 = no limit) of a log file starting at offset.
+// A negative offset counts back from the end of the file and is advanced to the
+// next line boundary so the slice never starts mid-line.
+func readLogRange(path string, offset, limit int64) (logSlice, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return logSlice{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return logSlice{}, err
+	}
+	size := info.Size()
+
+	start := min(offset, size)
+	fromEnd := offset < 0
+	if fromEnd {
+		start = max(size+offset, 0)
+	}
+
+	end := size
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	content := make([]byte, end-start)
+	n, err := f.ReadAt(content, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return logSlice{}, err
+	}
+	content = content[:n]
+	end = start + int64(n)
+
+	if fromEnd && start > 0 {
+		prev := make([]byte, 1)
+		if _, err := f.ReadAt(prev, start-1); err != nil {
+			return logSlice{}, err
+		}
+		if prev[0] != '\n' {
+			i := bytes.IndexByte(content, '\n')
+			if i < 0 {
+				return logSlice{start: end, next: end, size: size}, nil
+			}
+			content = content[i+1:]
+			start += int64(i + 1)
+		}
+	}
+
+	return logSlice{content: content, start: start, next: end, size: size}, nil
+}
+
+// countLines returns the number of newline-terminated lines in the first n bytes of a file.
+func countLines(path string, n int64) (int, error) {
+	if n <= 0 {
+		return 0, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	buf := make([]byte, 32*1024)
+	reader := io.LimitReader(f, n)
+	for {
+		read, err := reader.Read(buf)
+		count += bytes.Count(buf[:read], []byte{'\n'})
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// scanLogLines calls fn for each newline-terminated line in content, and for a
+// trailing partial line if includePartial is set. It returns the number of
+// bytes consumed.
+func scanLogLines(content []byte, includePartial bool, fn func(line string) error) (int, error) {
+	consumed := 0
+	for {
+		i := bytes.IndexByte(content[consumed:], '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(content[consumed:consumed+i]), "\r")
+		if err := fn(line); err != nil {
+			return consumed, err
+		}
+		consumed += i + 1
+	}
+
+	if includePartial && consumed < len(content) {
+		if err := fn(string(content[consumed:])); err != nil {
+			return consumed, err
+		}
+		consumed = len(content)
+	}
+
+	return consumed, nil
+}
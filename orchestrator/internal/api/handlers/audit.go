@@ -0,0 +1,114 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/api/middleware"
+	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+// AuditHandler handles requests for a project's audit log.
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// AuditEntryResponse represents an audit log entry in API responses.
+type AuditEntryResponse struct {
+	ID         string  `json:"id"`
+	UserID     string  `json:"user_id"`
+	Action     string  `json:"action"`
+	EntityType string  `json:"entity_type"`
+	EntityID   string  `json:"entity_id"`
+	OldStatus  *string `json:"old_status,omitempty"`
+	NewStatus  *string `json:"new_status,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// List returns a project's audit log, newest first.
+// GET /api/projects/{id}/audit
+//
+// Query params:
+//   - limit: maximum number of entries (default 100, max 500)
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse project ID from URL
+	projectIDStr := chi.URLParam(r, "id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+
+	limit, err := parseAuditLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	entries, err := h.auditService.ListByProject(ctx, projectID, userID, limit)
+	if err != nil {
+		log.Error().Err(err).
+			Str("user_id", userID.String()).
+			Str("project_id", projectID.String()).
+			Msg("failed to list audit log")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	result := make([]AuditEntryResponse, len(entries))
+	for i, entry := range entries {
+		result[i] = auditEntryToResponse(entry)
+	}
+
+	response.OK(w, result)
+}
+
+// parseAuditLimit parses the limit query param; empty means the default.
+func parseAuditLimit(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > service.MaxAuditLogLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", service.MaxAuditLogLimit)
+	}
+	return limit, nil
+}
+
+// auditEntryToResponse converts a domain.AuditEntry to an AuditEntryResponse.
+func auditEntryToResponse(e *domain.AuditEntry) AuditEntryResponse {
+	return AuditEntryResponse{
+		ID:         e.ID.String(),
+		UserID:     e.UserID.String(),
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID.String(),
+		OldStatus:  e.OldStatus,
+		NewStatus:  e.NewStatus,
+		CreatedAt:  e.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -19,15 +19,17 @@ import (
 
 // AuthHandler handles authentication-related HTTP requests.
 type AuthHandler struct {
-	authService *service.AuthService
-	cfg         *config.Config
+	authService    *service.AuthService
+	projectService *service.ProjectService
+	cfg            *config.Config
 }
 
 // NewAuthHandler creates a new AuthHandler.
-func NewAuthHandler(authService *service.AuthService, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, projectService *service.ProjectService, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		cfg:         cfg,
+		authService:    authService,
+		projectService: projectService,
+		cfg:            cfg,
 	}
 }
 
@@ -44,17 +46,49 @@ type AuthResponse struct {
 	User  UserResponse `json:"user"`
 }
 
+// ReconnectResponse is the response to a reconnect request.
+type ReconnectResponse struct {
+	URL string `json:"url"`
+}
+
 // InitiateOAuth redirects the user to GitHub OAuth authorization.
 // GET /api/auth/github
 func (h *AuthHandler) InitiateOAuth(w http.ResponseWriter, r *http.Request) {
-	// Generate a random state for CSRF protection
-	state, err := generateRandomState()
+	authURL, err := h.startOAuth(w)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate OAuth state")
+		response.InternalError(w, err)
+		return
+	}
+
+	// Redirect to GitHub OAuth
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+}
+
+// Reconnect starts the OAuth flow again for a signed-in user whose GitHub token
+// was rejected, returning the authorization URL for the client to navigate to.
+// The callback stores the new token and points the user's clones at it.
+// POST /api/auth/reconnect
+func (h *AuthHandler) Reconnect(w http.ResponseWriter, _ *http.Request) {
+	authURL, err := h.startOAuth(w)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to generate OAuth state")
 		response.InternalError(w, err)
 		return
 	}
 
+	response.OK(w, ReconnectResponse{URL: authURL})
+}
+
+// startOAuth stores a random state in a cookie and returns the GitHub OAuth
+// authorization URL carrying it.
+func (h *AuthHandler) startOAuth(w http.ResponseWriter) (string, error) {
+	// Generate a random state for CSRF protection
+	state, err := generateRandomState()
+	if err != nil {
+		return "", err
+	}
+
 	// Store state in a secure, HttpOnly cookie for validation on callback
 	http.SetCookie(w, &http.Cookie{
 		Name:     "oauth_state",
@@ -66,9 +100,7 @@ func (h *AuthHandler) InitiateOAuth(w http.ResponseWriter, r *http.Request) {
 		MaxAge:   600, // 10 minutes
 	})
 
-	// Redirect to GitHub OAuth
-	authURL := h.authService.GetAuthURL(state)
-	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+	return h.authService.GetAuthURL(state), nil
 }
 
 // HandleCallback handles the GitHub OAuth callback.
@@ -143,6 +175,14 @@ func (h *AuthHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Clones keep the token they were made with in their remote URL; point
+	// them at the new one so a reconnect also fixes pushes
+	if updated, err := h.projectService.RefreshCloneTokens(ctx, user.ID, token.AccessToken); err != nil {
+		log.Warn().Err(err).Str("user_id", user.ID.String()).Msg("failed to refresh clone tokens")
+	} else if updated > 0 {
+		log.Debug().Str("user_id", user.ID.String()).Int("clones", updated).Msg("refreshed clone tokens")
+	}
+
 	// Generate JWT
 	jwtToken, err := h.authService.GenerateJWT(user)
 	if err != nil {
@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Event schema versions of the project event stream. Version 1 is the shape
+// of every event's data as serialized from the service event structs when
+// versioning was introduced.
+const (
+	EventSchemaV1 = 1
+
+	// LatestEventSchema is the newest version clients may request.
+	LatestEventSchema = EventSchemaV1
+)
+
+// EventSchemaHeader lets clients that can set request headers ask for a
+// schema version; browsers' EventSource cannot, so they use the
+// schema_version query parameter.
+const EventSchemaHeader = "X-Event-Schema-Version"
+
+// eventEncoders maps each supported schema version to the function that turns
+// an event's internal data into that version's shape. When a data struct
+// changes in a way clients would notice, add a version whose encoder passes
+// the new struct through and change the older encoders to map it back to the
+// shape their clients expect.
+var eventEncoders = map[int]func(eventType string, data any) any{
+	EventSchemaV1: func(_ string, data any) any { return data },
+}
+
+// EventEnvelope is the SSE data of an event on a versioned stream. The event
+// type stays in the SSE event field.
+type EventEnvelope struct {
+	Version int `json:"version"`
+	Data    any `json:"data"`
+}
+
+// eventSchema is the schema version a stream was opened with; zero is an
+// unversioned stream, whose events carry the bare v1 data for clients from
+// before versioning.
+type eventSchema int
+
+// payload returns what to send as the SSE data of an event of eventType.
+func (s eventSchema) payload(eventType string, data any) any {
+	if s == 0 {
+		return data
+	}
+	return EventEnvelope{Version: int(s), Data: eventEncoders[int(s)](eventType, data)}
+}
+
+// requestedEventSchema returns the schema version r asks for with the
+// schema_version query parameter or, failing that, EventSchemaHeader. A
+// request that asks for neither gets an unversioned stream.
+func requestedEventSchema(r *http.Request) (eventSchema, error) {
+	raw := r.URL.Query().Get("schema_version")
+	if raw == "" {
+		raw = r.Header.Get(EventSchemaHeader)
+	}
+	if raw == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(raw)
+	if _, ok := eventEncoders[version]; err != nil || !ok {
+		return 0, fmt.Errorf("unsupported event schema version %q (latest is %d)", raw, LatestEventSchema)
+	}
+	return eventSchema(version), nil
+}
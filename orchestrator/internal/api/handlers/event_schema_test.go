@@ -0,0 +1,147 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+func TestRequestedEventSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		header  string
+		want    eventSchema
+		wantErr bool
+	}{
+		{name: "unversioned"},
+		{name: "query", query: "1", want: EventSchemaV1},
+		{name: "header", header: "1", want: EventSchemaV1},
+		{name: "query wins over header", query: "1", header: "7", want: EventSchemaV1},
+		{name: "unknown version", query: "2", wantErr: true},
+		{name: "not a number", header: "v1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/events?schema_version="+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set(EventSchemaHeader, tt.header)
+			}
+			got, err := requestedEventSchema(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("requestedEventSchema() = %d, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("requestedEventSchema() = %d, %v; want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventSchemaV1_RoundTrip(t *testing.T) {
+	subtaskID := uuid.New().String()
+	at := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	events := []struct {
+		eventType string
+		data      any
+	}{
+		{service.EventTypeAgentStarted, service.AgentStartedData{RunID: uuid.New(), AgentType: "WORKER", TaskID: uuid.New(), SubtaskID: &subtaskID, AttemptNumber: 2, StartedAt: at}},
+		{service.EventTypeAgentLogBatch, service.AgentLogBatchData{RunID: uuid.New(), Lines: []service.AgentLogLine{{Line: "hello", LineNumber: 1, Timestamp: "2026-10-15T12:00:00Z"}}}},
+		{service.EventTypeAgentFailed, service.AgentFailedData{RunID: uuid.New(), AgentType: "WORKER", TaskID: uuid.New(), Error: "exit status 1", WillRetry: true, NextAttemptAt: &at}},
+		{service.EventTypeTaskStatusChanged, service.TaskStatusChangedData{TaskID: uuid.New(), OldStatus: "PLANNING", NewStatus: "ACTIVE", ChangedAt: at}},
+	}
+	for _, e := range events {
+		t.Run(e.eventType, func(t *testing.T) {
+			encoded, err := json.Marshal(eventSchema(EventSchemaV1).payload(e.eventType, e.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var envelope struct {
+				Version int             `json:"version"`
+				Data    json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(encoded, &envelope); err != nil {
+				t.Fatal(err)
+			}
+			if envelope.Version != EventSchemaV1 {
+				t.Errorf("version = %d, want %d", envelope.Version, EventSchemaV1)
+			}
+
+			// v1 data is exactly what unversioned streams send
+			bare, err := json.Marshal(eventSchema(0).payload(e.eventType, e.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(envelope.Data, bare) {
+				t.Errorf("v1 data = %s, want %s", envelope.Data, bare)
+			}
+
+			decoded := reflect.New(reflect.TypeOf(e.data))
+			if err := json.Unmarshal(envelope.Data, decoded.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if got := decoded.Elem().Interface(); !reflect.DeepEqual(got, e.data) {
+				t.Errorf("round trip = %+v, want %+v", got, e.data)
+			}
+		})
+	}
+}
+
+func TestStreamEvents_SchemaVersion(t *testing.T) {
+	hub := service.NewEventHub(10, slog.New(slog.DiscardHandler))
+	server, projectID := newTestEventServer(t, hub)
+	url := server.URL + "/api/projects/" + projectID.String() + "/events"
+
+	resp, err := http.Get(url + "?schema_version=9")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unsupported version: status %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = http.Get(url + "?schema_version=1")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var envelope struct {
+			Version int            `json:"version"`
+			Data    map[string]any `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(data), &envelope); err != nil {
+			t.Fatalf("connected data %s: %v", data, err)
+		}
+		if envelope.Version != EventSchemaV1 || envelope.Data["connection_id"] == nil {
+			t.Errorf("connected data = %s, want a v1 envelope", data)
+		}
+		return
+	}
+	t.Fatal("stream closed before the connected event")
+}
@@ -7,7 +7,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,38 +17,51 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 
+	"github.com/intern-village/orchestrator/generated/db"
 	"github.com/intern-village/orchestrator/internal/api/middleware"
 	"github.com/intern-village/orchestrator/internal/api/response"
 	"github.com/intern-village/orchestrator/internal/config"
-	"github.com/intern-village/orchestrator/internal/repository"
 	"github.com/intern-village/orchestrator/internal/service"
 )
 
 // EventHandler handles SSE event streaming.
 type EventHandler struct {
-	eventHub       service.EventHub
-	repo           *repository.Repository
-	projectService ProjectOwnershipChecker
-	cfg            *config.Config
+	eventHub          service.EventHub
+	repo              ActiveRunsLister
+	projectService    ProjectOwnershipChecker
+	cfg               *config.Config
+	connectionTimeout time.Duration
 }
 
+// Bounds for the per-request heartbeat_s query parameter.
+const (
+	minSSEHeartbeatS = 5
+	maxSSEHeartbeatS = 300
+)
+
 // ProjectOwnershipChecker is an interface for checking project ownership.
 type ProjectOwnershipChecker interface {
 	CheckProjectOwnership(projectID, userID uuid.UUID) error
 }
 
+// ActiveRunsLister is an interface for listing a project's active agent runs.
+type ActiveRunsLister interface {
+	ListActiveAgentRunsByProject(ctx context.Context, projectID uuid.UUID) ([]db.ListActiveAgentRunsByProjectRow, error)
+}
+
 // NewEventHandler creates a new EventHandler.
 func NewEventHandler(
 	eventHub service.EventHub,
-	repo *repository.Repository,
+	repo ActiveRunsLister,
 	projectService ProjectOwnershipChecker,
 	cfg *config.Config,
 ) *EventHandler {
 	return &EventHandler{
-		eventHub:       eventHub,
-		repo:           repo,
-		projectService: projectService,
-		cfg:            cfg,
+		eventHub:          eventHub,
+		repo:              repo,
+		projectService:    projectService,
+		cfg:               cfg,
+		connectionTimeout: time.Duration(cfg.SSEConnectionTimeoutM) * time.Minute,
 	}
 }
 
@@ -61,7 +76,9 @@ type ActiveRunResponse struct {
 	StartedAt string `json:"started_at"`
 }
 
-// StreamEvents handles the SSE endpoint for project events.
+// StreamEvents handles the SSE endpoint for project events. Clients that pass
+// schema_version (or EventSchemaHeader) get each event's data wrapped in an
+// EventEnvelope of that version; others get the bare v1 data.
 // GET /api/projects/{project_id}/events
 func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -87,10 +104,35 @@ func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Reject new connections once the server is draining
+	select {
+	case <-h.eventHub.Draining():
+		response.Error(w, http.StatusServiceUnavailable, response.CodeShuttingDown, "server is shutting down")
+		return
+	default:
+	}
+
+	// Back off clients reconnecting in a tight loop, and refuse the worst
+	retry := time.Duration(h.cfg.SSERetryMS) * time.Millisecond
+	if reconnect := h.eventHub.RecordConnect(userID); reconnect.Flapping {
+		retry = reconnect.Retry
+		log.Warn().
+			Str("user_id", userID.String()).
+			Dur("retry", retry).
+			Bool("rejected", reconnect.Reject).
+			Msg("SSE client reconnecting too fast")
+		if reconnect.Reject {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+			response.Error(w, http.StatusTooManyRequests, response.CodeTooManyConnections,
+				fmt.Sprintf("reconnecting too fast, retry in %s", retry.Round(time.Second)))
+			return
+		}
+	}
+
 	// Check max connections per user
 	currentConnections := h.eventHub.UserConnectionCount(userID)
 	if currentConnections >= h.cfg.SSEMaxConnectionsPerUser {
-		response.Error(w, http.StatusTooManyRequests, "TOO_MANY_CONNECTIONS",
+		response.Error(w, http.StatusTooManyRequests, response.CodeTooManyConnections,
 			fmt.Sprintf("maximum %d connections per user", h.cfg.SSEMaxConnectionsPerUser))
 		return
 	}
@@ -109,6 +151,18 @@ func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
 	}
 	// Note: "all" is handled specially by the event hub
 
+	heartbeatInterval, err := h.heartbeatInterval(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	schema, err := requestedEventSchema(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -133,24 +187,28 @@ func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
 		activeRuns = []ActiveRunResponse{}
 	}
 
+	// Tell the browser how long to wait before reconnecting on its own
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", retry.Milliseconds()); err != nil {
+		log.Error().Err(err).Msg("failed to send retry interval")
+		return
+	}
+
 	// Send connected event with active runs
 	connectedData := map[string]interface{}{
 		"connection_id": connID,
 		"active_runs":   activeRuns,
 	}
-	if err := h.writeSSE(w, flusher, "connected", connectedData); err != nil {
+	if err := writeSSE(w, flusher, "connected", schema.payload("connected", connectedData)); err != nil {
 		log.Error().Err(err).Msg("failed to send connected event")
 		return
 	}
 
 	// Start heartbeat ticker
-	heartbeatInterval := time.Duration(h.cfg.SSEHeartbeatIntervalS) * time.Second
 	heartbeatTicker := time.NewTicker(heartbeatInterval)
 	defer heartbeatTicker.Stop()
 
 	// Connection timeout
-	connectionTimeout := time.Duration(h.cfg.SSEConnectionTimeoutM) * time.Minute
-	timeoutTimer := time.NewTimer(connectionTimeout)
+	timeoutTimer := time.NewTimer(h.connectionTimeout)
 	defer timeoutTimer.Stop()
 
 	log.Info().
@@ -168,14 +226,36 @@ func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
 				Msg("SSE client disconnected")
 			return
 
+		case <-h.eventHub.Draining():
+			shutdownData := service.ShutdownData{
+				Reason:    "server shutting down",
+				Timestamp: time.Now(),
+			}
+			if err := writeSSE(w, flusher, service.EventTypeShutdown, schema.payload(service.EventTypeShutdown, shutdownData)); err != nil {
+				log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send shutdown event")
+			}
+			log.Info().
+				Str("conn_id", connID).
+				Msg("SSE connection drained for shutdown")
+			return
+
 		case <-timeoutTimer.C:
+			// Ask the client to reopen the stream right away instead of waiting out its retry delay
+			reconnectData := service.ReconnectData{
+				Reason:    "connection timeout",
+				Timestamp: time.Now(),
+			}
+			if err := writeSSE(w, flusher, service.EventTypeReconnect, schema.payload(service.EventTypeReconnect, reconnectData)); err != nil {
+				log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send reconnect event")
+			}
 			log.Info().
 				Str("conn_id", connID).
 				Msg("SSE connection timeout")
 			return
 
 		case <-heartbeatTicker.C:
-			if err := h.writeSSE(w, flusher, "heartbeat", map[string]string{"time": time.Now().Format(time.RFC3339)}); err != nil {
+			heartbeat := map[string]string{"time": time.Now().Format(time.RFC3339)}
+			if err := writeSSE(w, flusher, "heartbeat", schema.payload("heartbeat", heartbeat)); err != nil {
 				log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send heartbeat, client disconnected")
 				return
 			}
@@ -187,7 +267,7 @@ func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
 					Msg("event channel closed")
 				return
 			}
-			if err := h.writeSSE(w, flusher, event.Type, event.Data); err != nil {
+			if err := writeSSE(w, flusher, event.Type, schema.payload(event.Type, event.Data)); err != nil {
 				log.Debug().Err(err).Str("conn_id", connID).Msg("failed to send event, client disconnected")
 				return
 			}
@@ -256,8 +336,25 @@ func (h *EventHandler) getActiveRuns(projectID uuid.UUID) ([]ActiveRunResponse,
 	return result, nil
 }
 
+// heartbeatInterval returns the heartbeat interval for a stream: the heartbeat_s
+// query parameter clamped to [minSSEHeartbeatS, maxSSEHeartbeatS], or the
+// configured default if it is absent.
+func (h *EventHandler) heartbeatInterval(r *http.Request) (time.Duration, error) {
+	raw := r.URL.Query().Get("heartbeat_s")
+	if raw == "" {
+		return time.Duration(h.cfg.SSEHeartbeatIntervalS) * time.Second, nil
+	}
+
+	seconds, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("heartbeat_s must be an integer")
+	}
+	seconds = max(minSSEHeartbeatS, min(seconds, maxSSEHeartbeatS))
+	return time.Duration(seconds) * time.Second, nil
+}
+
 // writeSSE writes an SSE event to the response writer.
-func (h *EventHandler) writeSSE(w http.ResponseWriter, flusher http.Flusher, eventType string, data interface{}) error {
+func writeSSE(w http.ResponseWriter, flusher http.Flusher, eventType string, data interface{}) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event data: %w", err)
@@ -0,0 +1,243 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/api/middleware"
+	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+func newTestSSEConfig() *config.Config {
+	return &config.Config{
+		SSEHeartbeatIntervalS:    30,
+		SSEConnectionTimeoutM:    60,
+		SSEMaxConnectionsPerUser: 5,
+		SSERetryMS:               3000,
+	}
+}
+
+// allowAllOwnership is a ProjectOwnershipChecker that grants access to every project.
+type allowAllOwnership struct{}
+
+func (allowAllOwnership) CheckProjectOwnership(projectID, userID uuid.UUID) error { return nil }
+
+// noActiveRuns is an ActiveRunsLister that never returns any runs.
+type noActiveRuns struct{}
+
+func (noActiveRuns) ListActiveAgentRunsByProject(ctx context.Context, projectID uuid.UUID) ([]db.ListActiveAgentRunsByProjectRow, error) {
+	return nil, nil
+}
+
+func newTestEventServer(t *testing.T, hub service.EventHub, opts ...func(*EventHandler)) (*httptest.Server, uuid.UUID) {
+	t.Helper()
+
+	handler := NewEventHandler(hub, noActiveRuns{}, allowAllOwnership{}, newTestSSEConfig())
+	for _, opt := range opts {
+		opt(handler)
+	}
+	user := &domain.User{ID: uuid.New()}
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(middleware.SetUserInContext(r.Context(), user)))
+		})
+	})
+	r.Get("/api/projects/{project_id}/events", handler.StreamEvents)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return server, uuid.New()
+}
+
+func TestStreamEvents_DrainsOnShutdown(t *testing.T) {
+	hub := service.NewEventHub(10, slog.New(slog.DiscardHandler))
+	server, projectID := newTestEventServer(t, hub)
+
+	resp, err := http.Get(server.URL + "/api/projects/" + projectID.String() + "/events")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	// Read events in the background until the stream closes
+	events := make(chan string, 10)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if name, ok := strings.CutPrefix(scanner.Text(), "event: "); ok {
+				events <- name
+			}
+		}
+	}()
+
+	if got := <-events; got != "connected" {
+		t.Fatalf("expected connected event first, got %q", got)
+	}
+
+	hub.Drain()
+
+	select {
+	case got := <-events:
+		if got != service.EventTypeShutdown {
+			t.Errorf("expected shutdown event, got %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for shutdown event")
+	}
+
+	// Stream should close promptly after the shutdown event
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected stream to close after shutdown event")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for stream to close")
+	}
+}
+
+func TestStreamEvents_RejectsWhileDraining(t *testing.T) {
+	hub := service.NewEventHub(10, slog.New(slog.DiscardHandler))
+	server, projectID := newTestEventServer(t, hub)
+
+	hub.Drain()
+
+	resp, err := http.Get(server.URL + "/api/projects/" + projectID.String() + "/events")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", resp.StatusCode)
+	}
+}
+
+func TestStreamEvents_RetryAndReconnectOnTimeout(t *testing.T) {
+	hub := service.NewEventHub(10, slog.New(slog.DiscardHandler))
+	server, projectID := newTestEventServer(t, hub, func(h *EventHandler) {
+		h.connectionTimeout = 100 * time.Millisecond
+	})
+
+	resp, err := http.Get(server.URL + "/api/projects/" + projectID.String() + "/events")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Collect "retry:" and "event:" lines until the server closes the stream
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "retry: ") || strings.HasPrefix(line, "event: ") {
+			lines = append(lines, line)
+		}
+	}
+
+	want := []string{"retry: 3000", "event: connected", "event: " + service.EventTypeReconnect}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("stream = %v, want %v", lines, want)
+	}
+}
+
+func TestEventHandler_HeartbeatInterval(t *testing.T) {
+	handler := NewEventHandler(nil, noActiveRuns{}, allowAllOwnership{}, newTestSSEConfig())
+
+	tests := []struct {
+		name    string
+		query   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"default", "", 30 * time.Second, false},
+		{"within bounds", "?heartbeat_s=15", 15 * time.Second, false},
+		{"clamped to minimum", "?heartbeat_s=1", minSSEHeartbeatS * time.Second, false},
+		{"clamped to maximum", "?heartbeat_s=3600", maxSSEHeartbeatS * time.Second, false},
+		{"not a number", "?heartbeat_s=fast", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/projects/x/events"+tt.query, nil)
+			got, err := handler.heartbeatInterval(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("heartbeatInterval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("heartbeatInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamEvents_BacksOffFlappingClient(t *testing.T) {
+	hub := service.NewEventHub(10, slog.New(slog.DiscardHandler))
+	hub.SetReconnectPolicy(service.ReconnectPolicy{
+		Window:      time.Minute,
+		MaxConnects: 1,
+		BaseRetry:   3 * time.Second,
+		MaxRetry:    time.Minute,
+	})
+	server, projectID := newTestEventServer(t, hub, func(h *EventHandler) {
+		h.connectionTimeout = 10 * time.Millisecond
+	})
+	url := server.URL + "/api/projects/" + projectID.String() + "/events"
+
+	firstRetry := func() string {
+		t.Helper()
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Scan()
+		return scanner.Text()
+	}
+
+	if got := firstRetry(); got != "retry: 3000" {
+		t.Errorf("first connect: got %q, want the configured retry", got)
+	}
+	if got := firstRetry(); got == "retry: 3000" {
+		t.Errorf("second connect: got %q, want a backed-off retry", got)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("third connect: expected status 429, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+}
@@ -4,8 +4,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -39,6 +42,25 @@ type ProjectResponse struct {
 	IsFork        bool   `json:"is_fork"`
 	DefaultBranch string `json:"default_branch"`
 	CreatedAt     string `json:"created_at"`
+	// Project override of MAX_SUBTASKS_PER_TASK; omitted when the default applies
+	MaxSubtasksPerTask *int `json:"max_subtasks_per_task,omitempty"`
+	// Worker PR title template; omitted when the default applies
+	PRTitleTemplate *string `json:"pr_title_template,omitempty"`
+	// Whether Worker commits are squashed into one before the PR is opened
+	SquashBeforePR bool `json:"squash_before_pr"`
+	// Paths Workers may modify; omitted when every path is allowed
+	PathPolicy *domain.PathPolicy `json:"path_policy,omitempty"`
+	// Only with ?include=summary
+	Summary *ProjectSummaryResponse `json:"summary,omitempty"`
+}
+
+// ProjectSummaryResponse is a project's status at a glance, so the project
+// list can show it without fetching each project's tasks and active runs.
+type ProjectSummaryResponse struct {
+	Tasks        map[string]int `json:"tasks"`    // Task counts by status
+	Subtasks     map[string]int `json:"subtasks"` // Subtask counts by status
+	ActiveAgents int            `json:"active_agents"`
+	TokenUsage   int64          `json:"token_usage"`
 }
 
 // CreateProjectResponse includes additional info about the creation operation.
@@ -49,7 +71,71 @@ type CreateProjectResponse struct {
 
 // CreateProjectRequest represents the request body for creating a project.
 type CreateProjectRequest struct {
-	RepoURL string `json:"repo_url"`
+	RepoURL     string `json:"repo_url"`
+	BeadsPrefix string `json:"beads_prefix,omitempty"` // Optional issue-ID prefix; generated when empty
+}
+
+// UpdateProjectRequest represents the request body for updating project settings.
+// Fields are raw so that an explicit null (clear the setting) can be told apart
+// from the field being left out. At least one field is required.
+type UpdateProjectRequest struct {
+	MaxSubtasksPerTask json.RawMessage `json:"max_subtasks_per_task"`
+	PRTitleTemplate    json.RawMessage `json:"pr_title_template"`
+	SquashBeforePR     json.RawMessage `json:"squash_before_pr"`
+	PathPolicy         json.RawMessage `json:"path_policy"`
+}
+
+// parseMaxSubtasks returns the requested override, or nil for null. ok is
+// false if the field was left out.
+func (req UpdateProjectRequest) parseMaxSubtasks() (limit *int, ok bool, err error) {
+	if len(req.MaxSubtasksPerTask) == 0 {
+		return nil, false, nil
+	}
+	if err := json.Unmarshal(req.MaxSubtasksPerTask, &limit); err != nil {
+		return nil, false, errors.New("max_subtasks_per_task must be an integer or null")
+	}
+	return limit, true, nil
+}
+
+// parsePRTitleTemplate returns the requested template, or nil for null. ok is
+// false if the field was left out.
+func (req UpdateProjectRequest) parsePRTitleTemplate() (tmpl *string, ok bool, err error) {
+	if len(req.PRTitleTemplate) == 0 {
+		return nil, false, nil
+	}
+	if err := json.Unmarshal(req.PRTitleTemplate, &tmpl); err != nil {
+		return nil, false, errors.New("pr_title_template must be a string or null")
+	}
+	return tmpl, true, nil
+}
+
+// parseSquashBeforePR returns the requested setting. ok is false if the field
+// was left out.
+func (req UpdateProjectRequest) parseSquashBeforePR() (squash, ok bool, err error) {
+	if len(req.SquashBeforePR) == 0 {
+		return false, false, nil
+	}
+	var value *bool
+	if err := json.Unmarshal(req.SquashBeforePR, &value); err != nil || value == nil {
+		return false, false, errors.New("squash_before_pr must be true or false")
+	}
+	return *value, true, nil
+}
+
+// parsePathPolicy returns the requested policy, or an empty one for null. ok
+// is false if the field was left out.
+func (req UpdateProjectRequest) parsePathPolicy() (policy domain.PathPolicy, ok bool, err error) {
+	if len(req.PathPolicy) == 0 {
+		return domain.PathPolicy{}, false, nil
+	}
+	var value *domain.PathPolicy
+	if err := json.Unmarshal(req.PathPolicy, &value); err != nil {
+		return domain.PathPolicy{}, false, errors.New("path_policy must be an object with allowed and denied lists, or null")
+	}
+	if value == nil {
+		return domain.PathPolicy{}, true, nil
+	}
+	return *value, true, nil
 }
 
 // Create creates a new project.
@@ -90,6 +176,7 @@ func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
 		UserID:      user.ID,
 		RepoURL:     req.RepoURL,
 		GitHubToken: token,
+		BeadsPrefix: req.BeadsPrefix,
 	})
 	if err != nil {
 		log.Error().Err(err).
@@ -112,6 +199,42 @@ func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// CancelCreate aborts the user's in-progress project creation for a repository.
+// POST /api/projects/cancel
+func (h *ProjectHandler) CancelCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse request body
+	var req CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	if req.RepoURL == "" {
+		response.BadRequest(w, "repo_url is required")
+		return
+	}
+
+	if err := h.projectService.CancelCreateProject(userID, req.RepoURL); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("user_id", userID.String()).
+		Str("repo_url", req.RepoURL).
+		Msg("project creation canceled")
+
+	response.NoContent(w)
+}
+
 // List lists all projects for the authenticated user.
 // GET /api/projects
 func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -133,11 +256,26 @@ func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	// Convert to response format
 	result := make([]ProjectResponse, len(projects))
+	versions := make([]response.Version, len(projects))
 	for i, p := range projects {
 		result[i] = projectToResponse(p)
+		versions[i] = response.Version{ID: p.ID, UpdatedAt: p.UpdatedAt}
+	}
+
+	// Summaries change without touching the projects, so they can't share
+	// the projects' ETag
+	if hasInclude(r, "summary") {
+		for i, p := range projects {
+			if err := h.addSummary(ctx, &result[i], p); err != nil {
+				response.InternalError(w, err)
+				return
+			}
+		}
+		response.OK(w, result)
+		return
 	}
 
-	response.OK(w, result)
+	response.OKWithETag(w, r, response.ETag(versions...), result)
 }
 
 // Get retrieves a project by ID.
@@ -166,6 +304,112 @@ func (h *ProjectHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	resp := projectToResponse(project)
+	if hasInclude(r, "summary") {
+		if err := h.addSummary(ctx, &resp, project); err != nil {
+			response.InternalError(w, err)
+			return
+		}
+		response.OK(w, resp)
+		return
+	}
+
+	etag := response.ETag(response.Version{ID: project.ID, UpdatedAt: project.UpdatedAt})
+	response.OKWithETag(w, r, etag, resp)
+}
+
+// addSummary fills in the task, subtask, agent and token aggregates for ?include=summary.
+func (h *ProjectHandler) addSummary(ctx context.Context, resp *ProjectResponse, project *domain.Project) error {
+	summary, err := h.projectService.GetProjectSummary(ctx, project)
+	if err != nil {
+		log.Error().Err(err).
+			Str("project_id", project.ID.String()).
+			Msg("failed to get project summary")
+		return err
+	}
+	resp.Summary = projectSummaryToResponse(summary)
+	return nil
+}
+
+// Update changes a project's settings.
+// PATCH /api/projects/{id}
+func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse project ID from URL
+	projectID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+	limit, setLimit, err := req.parseMaxSubtasks()
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	tmpl, setTmpl, err := req.parsePRTitleTemplate()
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	squash, setSquash, err := req.parseSquashBeforePR()
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	pathPolicy, setPathPolicy, err := req.parsePathPolicy()
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if !setLimit && !setTmpl && !setSquash && !setPathPolicy {
+		response.BadRequest(w, "max_subtasks_per_task, pr_title_template, squash_before_pr or path_policy is required")
+		return
+	}
+	// Reject a bad template or path pattern before any setting is written
+	if tmpl != nil {
+		if err := domain.ValidatePRTitleTemplate(*tmpl); err != nil {
+			response.ErrorFromDomain(w, err)
+			return
+		}
+	}
+	if err := pathPolicy.Validate(); err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	var project *domain.Project
+	if setLimit {
+		project, err = h.projectService.SetMaxSubtasksPerTask(ctx, projectID, userID, limit)
+	}
+	if err == nil && setTmpl {
+		project, err = h.projectService.SetPRTitleTemplate(ctx, projectID, userID, tmpl)
+	}
+	if err == nil && setSquash {
+		project, err = h.projectService.SetSquashBeforePR(ctx, projectID, userID, squash)
+	}
+	if err == nil && setPathPolicy {
+		project, err = h.projectService.SetPathPolicy(ctx, projectID, userID, pathPolicy)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("project_id", projectID.String()).Msg("failed to update project")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
 	response.OK(w, projectToResponse(project))
 }
 
@@ -241,14 +485,190 @@ func (h *ProjectHandler) Cleanup(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, map[string]string{"message": "project cleaned up successfully"})
 }
 
+// Repair re-clones a project's repository into its existing clone path.
+// POST /api/projects/{id}/repair
+func (h *ProjectHandler) Repair(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	user, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse project ID from URL
+	projectIDStr := chi.URLParam(r, "id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+
+	// Decrypt user's GitHub token
+	token, err := h.authService.DecryptUserToken(user)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to decrypt user token")
+		response.InternalError(w, err)
+		return
+	}
+
+	project, err := h.projectService.RepairClone(ctx, projectID, user.ID, token)
+	if err != nil {
+		log.Error().Err(err).
+			Str("project_id", projectID.String()).
+			Str("user_id", user.ID.String()).
+			Msg("failed to repair project clone")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("project_id", project.ID.String()).
+		Str("clone_path", project.ClonePath).
+		Msg("project clone repaired")
+
+	response.OK(w, projectToResponse(project))
+}
+
+// DiskUsageResponse represents a project's disk usage in API responses.
+type DiskUsageResponse struct {
+	ProjectID     string `json:"project_id"`
+	ClonePath     string `json:"clone_path"`
+	WorktreeRoot  string `json:"worktree_root"`
+	Exists        bool   `json:"exists"`
+	Bytes         int64  `json:"bytes"`
+	WorktreeBytes int64  `json:"worktree_bytes"`
+}
+
+// DiskUsage reports the disk space used by a project's clone and worktrees.
+// GET /api/projects/{id}/usage/disk
+func (h *ProjectHandler) DiskUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse project ID from URL
+	projectIDStr := chi.URLParam(r, "id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+
+	usage, err := h.projectService.DiskUsage(ctx, projectID, userID)
+	if err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	response.OK(w, DiskUsageResponse{
+		ProjectID:     usage.ProjectID.String(),
+		ClonePath:     usage.ClonePath,
+		WorktreeRoot:  usage.WorktreeRoot,
+		Exists:        usage.Exists,
+		Bytes:         usage.Bytes,
+		WorktreeBytes: usage.WorktreeBytes,
+	})
+}
+
+// AttentionSubtaskResponse is a subtask BLOCKED by a failure in a project's
+// attention list, with its task's title and the error of its latest run.
+type AttentionSubtaskResponse struct {
+	SubtaskResponse
+	TaskTitle    string  `json:"task_title"`
+	LastError    *string `json:"last_error"`
+	LastFailedAt *string `json:"last_failed_at"`
+}
+
+// Attention lists the subtasks BLOCKED by a failure across the project's
+// open tasks, highest priority first and then most recently updated, so they
+// can be retried from one place.
+// GET /api/projects/{id}/attention
+func (h *ProjectHandler) Attention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse project ID from URL
+	projectIDStr := chi.URLParam(r, "id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+
+	subtasks, err := h.projectService.ListAttention(ctx, projectID, userID)
+	if err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	resp := make([]AttentionSubtaskResponse, 0, len(subtasks))
+	for _, s := range subtasks {
+		resp = append(resp, attentionSubtaskToResponse(s))
+	}
+	response.OK(w, resp)
+}
+
+// attentionSubtaskToResponse converts a service.AttentionSubtask to an
+// AttentionSubtaskResponse.
+func attentionSubtaskToResponse(s *service.AttentionSubtask) AttentionSubtaskResponse {
+	var lastFailedAt *string
+	if s.LastFailedAt != nil {
+		t := s.LastFailedAt.Format(time.RFC3339)
+		lastFailedAt = &t
+	}
+	return AttentionSubtaskResponse{
+		SubtaskResponse: subtaskToResponse(s.Subtask),
+		TaskTitle:       s.TaskTitle,
+		LastError:       s.LastError,
+		LastFailedAt:    lastFailedAt,
+	}
+}
+
 // projectToResponse converts a domain.Project to a ProjectResponse.
+func projectSummaryToResponse(s *service.ProjectSummary) *ProjectSummaryResponse {
+	resp := &ProjectSummaryResponse{
+		Tasks:        make(map[string]int, len(s.TasksByStatus)),
+		Subtasks:     make(map[string]int, len(s.SubtasksByStatus)),
+		ActiveAgents: s.ActiveAgents,
+		TokenUsage:   s.TokenUsage,
+	}
+	for status, n := range s.TasksByStatus {
+		resp.Tasks[string(status)] = n
+	}
+	for status, n := range s.SubtasksByStatus {
+		resp.Subtasks[string(status)] = n
+	}
+	return resp
+}
+
 func projectToResponse(p *domain.Project) ProjectResponse {
-	return ProjectResponse{
-		ID:            p.ID.String(),
-		GitHubOwner:   p.GitHubOwner,
-		GitHubRepo:    p.GitHubRepo,
-		IsFork:        p.IsFork,
-		DefaultBranch: p.DefaultBranch,
-		CreatedAt:     p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	resp := ProjectResponse{
+		ID:                 p.ID.String(),
+		GitHubOwner:        p.GitHubOwner,
+		GitHubRepo:         p.GitHubRepo,
+		IsFork:             p.IsFork,
+		DefaultBranch:      p.DefaultBranch,
+		CreatedAt:          p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		MaxSubtasksPerTask: p.MaxSubtasksPerTask,
+		PRTitleTemplate:    p.PRTitleTemplate,
+		SquashBeforePR:     p.SquashBeforePR,
+	}
+	if !p.PathPolicy.IsEmpty() {
+		policy := p.PathPolicy
+		resp.PathPolicy = &policy
 	}
+	return resp
 }
@@ -6,11 +6,14 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
 )
 
 func TestProjectHandler_List(t *testing.T) {
@@ -97,6 +100,85 @@ func TestProjectResponse_Format(t *testing.T) {
 	if resp.DefaultBranch != "main" {
 		t.Errorf("DefaultBranch = %v, want %v", resp.DefaultBranch, "main")
 	}
+	if resp.PathPolicy != nil {
+		t.Errorf("PathPolicy = %+v, want omitted when every path is allowed", resp.PathPolicy)
+	}
+
+	project.PathPolicy = domain.PathPolicy{Denied: []string{".github"}}
+	if resp := projectToResponse(project); resp.PathPolicy == nil || !reflect.DeepEqual(resp.PathPolicy.Denied, []string{".github"}) {
+		t.Errorf("PathPolicy = %+v, want the project's policy", resp.PathPolicy)
+	}
+}
+
+func TestProjectSummaryToResponse(t *testing.T) {
+	resp := projectToResponse(&domain.Project{ID: uuid.New()})
+	body, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(body, []byte(`"summary"`)) {
+		t.Errorf("summary should be omitted unless requested, got %s", body)
+	}
+
+	resp.Summary = projectSummaryToResponse(&service.ProjectSummary{
+		TasksByStatus:    map[domain.TaskStatus]int{domain.TaskStatusActive: 2},
+		SubtasksByStatus: map[domain.SubtaskStatus]int{domain.SubtaskStatusInProgress: 3, domain.SubtaskStatusMerged: 1},
+		ActiveAgents:     3,
+		TokenUsage:       12000,
+	})
+	if got := resp.Summary.Tasks["ACTIVE"]; got != 2 {
+		t.Errorf("Tasks[ACTIVE] = %d, want 2", got)
+	}
+	if got := resp.Summary.Subtasks["IN_PROGRESS"]; got != 3 {
+		t.Errorf("Subtasks[IN_PROGRESS] = %d, want 3", got)
+	}
+	if got := resp.Summary.Subtasks["MERGED"]; got != 1 {
+		t.Errorf("Subtasks[MERGED] = %d, want 1", got)
+	}
+	if resp.Summary.ActiveAgents != 3 || resp.Summary.TokenUsage != 12000 {
+		t.Errorf("ActiveAgents, TokenUsage = %d, %d, want 3, 12000", resp.Summary.ActiveAgents, resp.Summary.TokenUsage)
+	}
+}
+
+func TestAttentionSubtaskToResponse(t *testing.T) {
+	failure := domain.BlockedReasonFailure
+	lastErr := "exit code: 1"
+	failedAt := time.Date(2026, 2, 5, 14, 30, 0, 0, time.UTC)
+	resp := attentionSubtaskToResponse(&service.AttentionSubtask{
+		Subtask:      &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Title: "Add login", Status: domain.SubtaskStatusBlocked, BlockedReason: &failure},
+		TaskTitle:    "Auth",
+		LastError:    &lastErr,
+		LastFailedAt: &failedAt,
+	})
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	// The subtask's fields sit alongside the attention context
+	want := map[string]any{
+		"title":          "Add login",
+		"status":         "BLOCKED",
+		"blocked_reason": "FAILURE",
+		"task_title":     "Auth",
+		"last_error":     "exit code: 1",
+		"last_failed_at": "2026-02-05T14:30:00Z",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+
+	// A subtask that never ran has no error
+	resp = attentionSubtaskToResponse(&service.AttentionSubtask{Subtask: &domain.Subtask{ID: uuid.New()}})
+	if resp.LastError != nil || resp.LastFailedAt != nil {
+		t.Errorf("LastError, LastFailedAt = %v, %v, want nil", resp.LastError, resp.LastFailedAt)
+	}
 }
 
 func TestProjectHandler_Get_InvalidID(t *testing.T) {
@@ -104,3 +186,157 @@ func TestProjectHandler_Get_InvalidID(t *testing.T) {
 	// Full integration test requires database and service setup
 	t.Skip("requires full service setup")
 }
+
+func TestUpdateProjectRequest_ParseMaxSubtasks(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantLimit *int
+		wantSet   bool
+		wantErr   bool
+	}{
+		{name: "set limit", body: `{"max_subtasks_per_task": 20}`, wantLimit: func() *int { n := 20; return &n }(), wantSet: true},
+		{name: "null clears override", body: `{"max_subtasks_per_task": null}`, wantSet: true},
+		{name: "missing field", body: `{}`},
+		{name: "not an integer", body: `{"max_subtasks_per_task": "many"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req UpdateProjectRequest
+			if err := json.NewDecoder(bytes.NewBufferString(tt.body)).Decode(&req); err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+
+			limit, set, err := req.parseMaxSubtasks()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if set != tt.wantSet {
+				t.Errorf("set = %v, want %v", set, tt.wantSet)
+			}
+			if (limit == nil) != (tt.wantLimit == nil) || (limit != nil && *limit != *tt.wantLimit) {
+				t.Errorf("limit = %v, want %v", limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestUpdateProjectRequest_ParsePRTitleTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    *string
+		wantSet bool
+		wantErr bool
+	}{
+		{name: "set template", body: `{"pr_title_template": "feat: {title}"}`, want: strPtr("feat: {title}"), wantSet: true},
+		{name: "null restores default", body: `{"pr_title_template": null}`, wantSet: true},
+		{name: "missing field", body: `{"max_subtasks_per_task": 5}`},
+		{name: "not a string", body: `{"pr_title_template": 7}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req UpdateProjectRequest
+			if err := json.NewDecoder(bytes.NewBufferString(tt.body)).Decode(&req); err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+
+			tmpl, set, err := req.parsePRTitleTemplate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if set != tt.wantSet {
+				t.Errorf("set = %v, want %v", set, tt.wantSet)
+			}
+			if (tmpl == nil) != (tt.want == nil) || (tmpl != nil && *tmpl != *tt.want) {
+				t.Errorf("template = %v, want %v", tmpl, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateProjectRequest_ParseSquashBeforePR(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    bool
+		wantSet bool
+		wantErr bool
+	}{
+		{name: "enable", body: `{"squash_before_pr": true}`, want: true, wantSet: true},
+		{name: "disable", body: `{"squash_before_pr": false}`, wantSet: true},
+		{name: "missing field", body: `{"max_subtasks_per_task": 5}`},
+		{name: "null", body: `{"squash_before_pr": null}`, wantErr: true},
+		{name: "not a boolean", body: `{"squash_before_pr": "yes"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req UpdateProjectRequest
+			if err := json.NewDecoder(bytes.NewBufferString(tt.body)).Decode(&req); err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+
+			squash, set, err := req.parseSquashBeforePR()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if set != tt.wantSet {
+				t.Errorf("set = %v, want %v", set, tt.wantSet)
+			}
+			if squash != tt.want {
+				t.Errorf("squash = %v, want %v", squash, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateProjectRequest_ParsePathPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    domain.PathPolicy
+		wantSet bool
+		wantErr bool
+	}{
+		{name: "set", body: `{"path_policy": {"allowed": ["src"], "denied": [".github"]}}`, want: domain.PathPolicy{Allowed: []string{"src"}, Denied: []string{".github"}}, wantSet: true},
+		{name: "deny only", body: `{"path_policy": {"denied": ["infra"]}}`, want: domain.PathPolicy{Denied: []string{"infra"}}, wantSet: true},
+		{name: "null clears", body: `{"path_policy": null}`, wantSet: true},
+		{name: "missing field", body: `{"squash_before_pr": true}`},
+		{name: "not an object", body: `{"path_policy": [".github"]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req UpdateProjectRequest
+			if err := json.NewDecoder(bytes.NewBufferString(tt.body)).Decode(&req); err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+
+			policy, set, err := req.parsePathPolicy()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if set != tt.wantSet {
+				t.Errorf("set = %v, want %v", set, tt.wantSet)
+			}
+			if !reflect.DeepEqual(policy, tt.want) {
+				t.Errorf("policy = %+v, want %+v", policy, tt.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -20,12 +26,14 @@ import (
 // SubtaskHandler handles subtask-related HTTP requests.
 type SubtaskHandler struct {
 	subtaskService *service.SubtaskService
+	authService    *service.AuthService
 }
 
 // NewSubtaskHandler creates a new SubtaskHandler.
-func NewSubtaskHandler(subtaskService *service.SubtaskService) *SubtaskHandler {
+func NewSubtaskHandler(subtaskService *service.SubtaskService, authService *service.AuthService) *SubtaskHandler {
 	return &SubtaskHandler{
 		subtaskService: subtaskService,
+		authService:    authService,
 	}
 }
 
@@ -44,15 +52,72 @@ type SubtaskResponse struct {
 	RetryCount         int     `json:"retry_count"`
 	TokenUsage         int     `json:"token_usage"`
 	Position           int     `json:"position"`
+	TokenBudget        *int    `json:"token_budget"`
+	Priority           int     `json:"priority"`
 	BeadsIssueID       *string `json:"beads_issue_id,omitempty"`
 	WorktreePath       *string `json:"worktree_path,omitempty"`
 	CreatedAt          string  `json:"created_at"`
 	UpdatedAt          string  `json:"updated_at"`
+
+	// NextAttemptAt is when a Worker backing off after a failed attempt
+	// will try again.
+	NextAttemptAt *string `json:"next_attempt_at,omitempty"`
+
+	// BlockedBy lists the dependencies still unmerged, only for subtasks
+	// BLOCKED with reason DEPENDENCY.
+	BlockedBy []BlockingDependencyResponse `json:"blocked_by,omitempty"`
+
+	// Runs holds the most recent agent runs, latest first. Only set when
+	// requested with ?include=runs.
+	Runs []AgentRunResponse `json:"runs,omitempty"`
+}
+
+// BlockingDependencyResponse is a dependency a subtask is waiting on.
+type BlockingDependencyResponse struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// subtaskRunHistoryLimit caps the runs embedded by ?include=runs.
+const subtaskRunHistoryLimit = 10
+
+// SubtaskMutationRequest is the optional request body of the start,
+// mark-merged and retry endpoints.
+type SubtaskMutationRequest struct {
+	// ExpectedUpdatedAt is the updated_at of the copy the client acted on, as
+	// returned (with full precision) in SubtaskResponse. When set, the request
+	// is rejected with 409 if the subtask has changed since.
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at,omitempty"`
 }
 
 // UpdatePositionRequest represents the request body for updating subtask position.
 type UpdatePositionRequest struct {
-	Position int `json:"position"`
+	Position          int        `json:"position"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at,omitempty"`
+}
+
+// UpdateSubtaskRequest represents the request body for editing a subtask.
+type UpdateSubtaskRequest struct {
+	// TokenBudget caps the tokens the subtask's Workers may use across all
+	// attempts; null removes the cap.
+	TokenBudget json.RawMessage `json:"token_budget"`
+	// Priority orders the subtask among others needing attention, from 0
+	// (most urgent) to 4.
+	Priority          *int       `json:"priority,omitempty"`
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at,omitempty"`
+}
+
+// parseTokenBudget returns the requested budget, or nil for null. ok is
+// false if the field was left out.
+func (req UpdateSubtaskRequest) parseTokenBudget() (budget *int, ok bool, err error) {
+	if len(req.TokenBudget) == 0 {
+		return nil, false, nil
+	}
+	if err := json.Unmarshal(req.TokenBudget, &budget); err != nil {
+		return nil, false, errors.New("token_budget must be an integer or null")
+	}
+	return budget, true, nil
 }
 
 // List lists all subtasks for a task.
@@ -89,6 +154,10 @@ func (h *SubtaskHandler) List(w http.ResponseWriter, r *http.Request) {
 	result := make([]SubtaskResponse, len(subtasks))
 	for i, s := range subtasks {
 		result[i] = subtaskToResponse(s)
+		if err := h.addBlockedBy(ctx, &result[i], s); err != nil {
+			response.InternalError(w, err)
+			return
+		}
 	}
 
 	response.OK(w, result)
@@ -120,7 +189,145 @@ func (h *SubtaskHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.OK(w, subtaskToResponse(subtask))
+	resp := subtaskToResponse(subtask)
+	if err := h.addBlockedBy(ctx, &resp, subtask); err != nil {
+		response.InternalError(w, err)
+		return
+	}
+
+	// Embed attempt history so failure reasons are visible without fetching logs
+	if hasInclude(r, "runs") {
+		runs, err := h.subtaskService.ListRecentRuns(ctx, subtaskID, subtaskRunHistoryLimit)
+		if err != nil {
+			log.Error().Err(err).
+				Str("subtask_id", subtaskID.String()).
+				Msg("failed to list agent runs for subtask")
+			response.InternalError(w, err)
+			return
+		}
+		resp.Runs = make([]AgentRunResponse, len(runs))
+		for i, run := range runs {
+			resp.Runs[i] = agentRunToResponse(run)
+		}
+	}
+
+	response.OK(w, resp)
+}
+
+// SubtaskDiffResponse is the diff of a subtask's branch against its task's base branch.
+type SubtaskDiffResponse struct {
+	SubtaskID string `json:"subtask_id"`
+	Base      string `json:"base"`
+	Branch    string `json:"branch"`
+	Source    string `json:"source"`
+	Diff      string `json:"diff"`
+	Size      int    `json:"size"`
+	Truncated bool   `json:"truncated"`
+	Note      string `json:"note,omitempty"`
+}
+
+// Diff returns the unified diff of a subtask's branch for review before merging.
+// GET /api/subtasks/{id}/diff
+func (h *SubtaskHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse subtask ID from URL
+	subtaskIDStr := chi.URLParam(r, "id")
+	subtaskID, err := uuid.Parse(subtaskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid subtask ID")
+		return
+	}
+
+	// Diffing a branch whose worktree is gone goes through the GitHub API
+	user, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+	token, err := h.authService.DecryptUserToken(user)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to decrypt user token")
+		response.InternalError(w, err)
+		return
+	}
+
+	diff, err := h.subtaskService.GetDiff(ctx, subtaskID, userID, token)
+	if err != nil {
+		log.Error().Err(err).
+			Str("subtask_id", subtaskID.String()).
+			Msg("failed to get subtask diff")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	response.OK(w, subtaskDiffToResponse(subtaskID, diff))
+}
+
+// subtaskDiffToResponse converts a service.SubtaskDiff to a SubtaskDiffResponse,
+// noting how much was left out of a truncated diff.
+func subtaskDiffToResponse(subtaskID uuid.UUID, diff *service.SubtaskDiff) SubtaskDiffResponse {
+	resp := SubtaskDiffResponse{
+		SubtaskID: subtaskID.String(),
+		Base:      diff.Base,
+		Branch:    diff.Branch,
+		Source:    diff.Source,
+		Diff:      diff.Diff,
+		Size:      diff.Size,
+		Truncated: diff.Truncated,
+	}
+	if diff.Truncated {
+		resp.Note = fmt.Sprintf("diff truncated to the first %d of %d bytes; view the full diff on GitHub", len(diff.Diff), diff.Size)
+	}
+	return resp
+}
+
+// addBlockedBy fills in the dependencies a subtask blocked by DEPENDENCY is waiting on.
+func (h *SubtaskHandler) addBlockedBy(ctx context.Context, resp *SubtaskResponse, subtask *domain.Subtask) error {
+	blocking, err := h.subtaskService.BlockedBy(ctx, subtask)
+	if err != nil {
+		log.Error().Err(err).
+			Str("subtask_id", subtask.ID.String()).
+			Msg("failed to get blocking dependencies")
+		return err
+	}
+	resp.BlockedBy = blockingDependenciesToResponse(blocking)
+	return nil
+}
+
+// blockingDependenciesToResponse converts blocking dependencies to their API
+// representation, returning nil for none so the field is omitted.
+func blockingDependenciesToResponse(deps []domain.BlockingDependency) []BlockingDependencyResponse {
+	if len(deps) == 0 {
+		return nil
+	}
+	result := make([]BlockingDependencyResponse, len(deps))
+	for i, dep := range deps {
+		result[i] = BlockingDependencyResponse{
+			ID:     dep.ID.String(),
+			Title:  dep.Title,
+			Status: string(dep.Status),
+		}
+	}
+	return result
+}
+
+// hasInclude reports whether the comma-separated ?include= parameter names the
+// given expansion.
+func hasInclude(r *http.Request, name string) bool {
+	for _, v := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(v) == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Start starts a subtask by spawning the Worker agent.
@@ -143,13 +350,19 @@ func (h *SubtaskHandler) Start(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	subtask, err := h.subtaskService.StartSubtask(ctx, subtaskID, userID)
+	expectedUpdatedAt, err := decodeSubtaskMutation(r)
+	if err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	subtask, err := h.subtaskService.StartSubtask(ctx, subtaskID, userID, expectedUpdatedAt)
 	if err != nil {
 		log.Error().Err(err).
 			Str("subtask_id", subtaskID.String()).
 			Str("user_id", userID.String()).
 			Msg("failed to start subtask")
-		response.ErrorFromDomain(w, err)
+		writeSubtaskError(w, err)
 		return
 	}
 
@@ -181,13 +394,19 @@ func (h *SubtaskHandler) MarkMerged(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	subtask, err := h.subtaskService.MarkMerged(ctx, subtaskID, userID)
+	expectedUpdatedAt, err := decodeSubtaskMutation(r)
+	if err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	subtask, err := h.subtaskService.MarkMerged(ctx, subtaskID, userID, expectedUpdatedAt)
 	if err != nil {
 		log.Error().Err(err).
 			Str("subtask_id", subtaskID.String()).
 			Str("user_id", userID.String()).
 			Msg("failed to mark subtask as merged")
-		response.ErrorFromDomain(w, err)
+		writeSubtaskError(w, err)
 		return
 	}
 
@@ -218,13 +437,32 @@ func (h *SubtaskHandler) Retry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	subtask, err := h.subtaskService.RetrySubtask(ctx, subtaskID, userID)
+	expectedUpdatedAt, err := decodeSubtaskMutation(r)
+	if err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	// Checking whether the subtask's PR was already merged needs the user's GitHub token
+	user, ok := middleware.GetUserFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+	token, err := h.authService.DecryptUserToken(user)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to decrypt user token")
+		response.InternalError(w, err)
+		return
+	}
+
+	subtask, err := h.subtaskService.RetrySubtask(ctx, subtaskID, userID, token, expectedUpdatedAt)
 	if err != nil {
 		log.Error().Err(err).
 			Str("subtask_id", subtaskID.String()).
 			Str("user_id", userID.String()).
 			Msg("failed to retry subtask")
-		response.ErrorFromDomain(w, err)
+		writeSubtaskError(w, err)
 		return
 	}
 
@@ -235,6 +473,45 @@ func (h *SubtaskHandler) Retry(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, subtaskToResponse(subtask))
 }
 
+// Stop kills the running Worker of a subtask.
+// POST /api/subtasks/{id}/stop
+func (h *SubtaskHandler) Stop(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse subtask ID from URL
+	subtaskIDStr := chi.URLParam(r, "id")
+	subtaskID, err := uuid.Parse(subtaskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid subtask ID")
+		return
+	}
+
+	expectedUpdatedAt, err := decodeSubtaskMutation(r)
+	if err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	subtask, err := h.subtaskService.StopSubtask(ctx, subtaskID, userID, expectedUpdatedAt)
+	if err != nil {
+		log.Error().Err(err).
+			Str("subtask_id", subtaskID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to stop subtask")
+		writeSubtaskError(w, err)
+		return
+	}
+
+	response.OK(w, subtaskToResponse(subtask))
+}
+
 // UpdatePosition updates the position of a subtask.
 // PATCH /api/subtasks/{id}/position
 func (h *SubtaskHandler) UpdatePosition(w http.ResponseWriter, r *http.Request) {
@@ -268,19 +545,107 @@ func (h *SubtaskHandler) UpdatePosition(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	subtask, err := h.subtaskService.UpdatePosition(ctx, subtaskID, userID, req.Position)
+	subtask, err := h.subtaskService.UpdatePosition(ctx, subtaskID, userID, req.Position, req.ExpectedUpdatedAt)
 	if err != nil {
 		log.Error().Err(err).
 			Str("subtask_id", subtaskID.String()).
 			Int("position", req.Position).
 			Msg("failed to update subtask position")
-		response.ErrorFromDomain(w, err)
+		writeSubtaskError(w, err)
+		return
+	}
+
+	response.OK(w, subtaskToResponse(subtask))
+}
+
+// Update edits a subtask's settings: its token_budget and priority.
+// PATCH /api/subtasks/{id}
+func (h *SubtaskHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse subtask ID from URL
+	subtaskID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid subtask ID")
+		return
+	}
+
+	var req UpdateSubtaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
 		return
 	}
+	budget, setBudget, err := req.parseTokenBudget()
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+	if !setBudget && req.Priority == nil {
+		response.BadRequest(w, "token_budget or priority is required")
+		return
+	}
+	// Reject a bad priority before the budget is applied
+	if req.Priority != nil {
+		if err := domain.ValidateSubtaskPriority(*req.Priority); err != nil {
+			writeSubtaskError(w, err)
+			return
+		}
+	}
+
+	var subtask *domain.Subtask
+	expectedUpdatedAt := req.ExpectedUpdatedAt
+	if setBudget {
+		subtask, err = h.subtaskService.SetTokenBudget(ctx, subtaskID, userID, budget, expectedUpdatedAt)
+		if err != nil {
+			log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to update subtask")
+			writeSubtaskError(w, err)
+			return
+		}
+		// The budget update already checked the client's copy
+		expectedUpdatedAt = nil
+	}
+	if req.Priority != nil {
+		subtask, err = h.subtaskService.SetPriority(ctx, subtaskID, userID, *req.Priority, expectedUpdatedAt)
+		if err != nil {
+			log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to update subtask")
+			writeSubtaskError(w, err)
+			return
+		}
+	}
 
 	response.OK(w, subtaskToResponse(subtask))
 }
 
+// decodeSubtaskMutation reads the optional SubtaskMutationRequest body.
+// An empty body means no expected_updated_at.
+func decodeSubtaskMutation(r *http.Request) (*time.Time, error) {
+	var req SubtaskMutationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return req.ExpectedUpdatedAt, nil
+}
+
+// writeSubtaskError writes a subtask mutation error. A stale update is
+// answered with the current subtask so the client can refresh in place.
+func writeSubtaskError(w http.ResponseWriter, err error) {
+	var stale *domain.StaleError
+	if errors.As(err, &stale) {
+		if current, ok := stale.Current.(*domain.Subtask); ok {
+			response.Stale(w, stale.Error(), subtaskToResponse(current))
+			return
+		}
+	}
+	response.ErrorFromDomain(w, err)
+}
+
 // subtaskToResponse converts a domain.Subtask to a SubtaskResponse.
 func subtaskToResponse(s *domain.Subtask) SubtaskResponse {
 	var blockedReason *string
@@ -289,6 +654,12 @@ func subtaskToResponse(s *domain.Subtask) SubtaskResponse {
 		blockedReason = &r
 	}
 
+	var nextAttemptAt *string
+	if s.NextAttemptAt != nil {
+		t := s.NextAttemptAt.Format(time.RFC3339)
+		nextAttemptAt = &t
+	}
+
 	return SubtaskResponse{
 		ID:                 s.ID.String(),
 		TaskID:             s.TaskID.String(),
@@ -303,10 +674,13 @@ func subtaskToResponse(s *domain.Subtask) SubtaskResponse {
 		RetryCount:         s.RetryCount,
 		TokenUsage:         s.TokenUsage,
 		Position:           s.Position,
+		TokenBudget:        s.TokenBudget,
+		Priority:           s.Priority,
 		BeadsIssueID:       s.BeadsIssueID,
 		WorktreePath:       s.WorktreePath,
 		CreatedAt:          s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:          s.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:          s.UpdatedAt.Format(time.RFC3339Nano),
+		NextAttemptAt:      nextAttemptAt,
 	}
 }
 
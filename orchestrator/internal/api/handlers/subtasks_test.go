@@ -5,9 +5,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
 )
 
 func TestSubtaskResponse_Format(t *testing.T) {
@@ -210,3 +217,153 @@ func TestPtrStr(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestHasInclude(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", false},
+		{"?include=runs", true},
+		{"?include=deps,runs", true},
+		{"?include=deps,%20runs", true},
+		{"?include=runsx", false},
+		{"?include=deps", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/subtasks/1"+tt.query, nil)
+			if got := hasInclude(r, "runs"); got != tt.want {
+				t.Errorf("hasInclude(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtaskResponse_RunsOmittedByDefault(t *testing.T) {
+	data, err := json.Marshal(SubtaskResponse{ID: "1"})
+	if err != nil {
+		t.Fatalf("failed to marshal response: %v", err)
+	}
+
+	var unmarshaled map[string]interface{}
+	if err := json.Unmarshal(data, &unmarshaled); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if _, ok := unmarshaled["runs"]; ok {
+		t.Error("runs should be omitted unless requested")
+	}
+}
+
+func TestDecodeSubtaskMutation(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty body", body: ""},
+		{name: "empty object", body: `{}`},
+		{name: "expected_updated_at", body: `{"expected_updated_at": "2026-02-04T10:00:00.123456Z"}`, want: "2026-02-04T10:00:00.123456Z"},
+		{name: "malformed timestamp", body: `{"expected_updated_at": "yesterday"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/subtasks/1/start", strings.NewReader(tt.body))
+			got, err := decodeSubtaskMutation(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == "" {
+				if got != nil && !tt.wantErr {
+					t.Errorf("expected no timestamp, got %v", got)
+				}
+				return
+			}
+			if got == nil || got.Format(time.RFC3339Nano) != tt.want {
+				t.Errorf("got %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSubtaskRequest_ParseTokenBudget(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantBudget *int
+		wantSet    bool
+		wantErr    bool
+	}{
+		{name: "set budget", body: `{"token_budget": 50000}`, wantBudget: func() *int { n := 50000; return &n }(), wantSet: true},
+		{name: "null removes budget", body: `{"token_budget": null}`, wantSet: true},
+		{name: "missing field", body: `{}`},
+		{name: "not an integer", body: `{"token_budget": "lots"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req UpdateSubtaskRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+
+			budget, set, err := req.parseTokenBudget()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if set != tt.wantSet {
+				t.Errorf("set = %v, want %v", set, tt.wantSet)
+			}
+			if (budget == nil) != (tt.wantBudget == nil) || (budget != nil && *budget != *tt.wantBudget) {
+				t.Errorf("budget = %v, want %v", budget, tt.wantBudget)
+			}
+		})
+	}
+}
+
+func TestWriteSubtaskError_StaleIncludesCurrent(t *testing.T) {
+	updatedAt := time.Date(2026, 2, 4, 10, 0, 0, 123456000, time.UTC)
+	current := &domain.Subtask{Title: "Current", Position: 3, UpdatedAt: updatedAt}
+
+	w := httptest.NewRecorder()
+	writeSubtaskError(w, domain.NewStaleError("subtask", "subtask was modified since expected_updated_at", current))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", w.Code)
+	}
+	var body struct {
+		Code    string          `json:"code"`
+		Current SubtaskResponse `json:"current"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Code != "CONFLICT" {
+		t.Errorf("code = %s, want CONFLICT", body.Code)
+	}
+	if body.Current.Position != 3 || body.Current.UpdatedAt != "2026-02-04T10:00:00.123456Z" {
+		t.Errorf("unexpected current subtask: %+v", body.Current)
+	}
+}
+
+func TestSubtaskDiffToResponse(t *testing.T) {
+	subtaskID := uuid.New()
+	diff := &service.SubtaskDiff{Base: "main", Branch: "iv-1", Source: service.DiffSourceWorktree, Diff: "diff --git\n", Size: 11}
+
+	resp := subtaskDiffToResponse(subtaskID, diff)
+	if resp.SubtaskID != subtaskID.String() || resp.Source != "worktree" || resp.Truncated || resp.Note != "" {
+		t.Errorf("untruncated response = %+v", resp)
+	}
+
+	diff.Size, diff.Truncated = 5000000, true
+	resp = subtaskDiffToResponse(subtaskID, diff)
+	if !resp.Truncated || resp.Size != 5000000 || !strings.Contains(resp.Note, "first 11 of 5000000 bytes") {
+		t.Errorf("truncated response = %+v, want a truncation note", resp)
+	}
+}
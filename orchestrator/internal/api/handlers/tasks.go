@@ -5,7 +5,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -20,12 +25,14 @@ import (
 // TaskHandler handles task-related HTTP requests.
 type TaskHandler struct {
 	taskService *service.TaskService
+	authService *service.AuthService
 }
 
 // NewTaskHandler creates a new TaskHandler.
-func NewTaskHandler(taskService *service.TaskService) *TaskHandler {
+func NewTaskHandler(taskService *service.TaskService, authService *service.AuthService) *TaskHandler {
 	return &TaskHandler{
 		taskService: taskService,
+		authService: authService,
 	}
 }
 
@@ -37,14 +44,74 @@ type TaskResponse struct {
 	Description string  `json:"description"`
 	Status      string  `json:"status"`
 	BeadsEpicID *string `json:"beads_epic_id,omitempty"`
+	DryRun      bool    `json:"dry_run"`
+	BaseBranch  string  `json:"base_branch"`
 	CreatedAt   string  `json:"created_at"`
 	UpdatedAt   string  `json:"updated_at"`
 }
 
+// TaskTreeResponse is a task with its subtasks and the dependencies between
+// them, everything a task board needs in one response.
+type TaskTreeResponse struct {
+	Task         TaskResponse                 `json:"task"`
+	Subtasks     []SubtaskResponse            `json:"subtasks"`
+	Dependencies []TaskTreeDependencyResponse `json:"dependencies"`
+	// TokenUsage is the task's total: PlannerTokenUsage plus every subtask's token_usage.
+	TokenUsage        int `json:"token_usage"`
+	PlannerTokenUsage int `json:"planner_token_usage"`
+	// Archive is set once the task's agent runs and logs have been archived.
+	Archive *TaskArchiveResponse `json:"archive,omitempty"`
+}
+
+// TaskArchiveResponse summarizes an archived task; the archive itself is at
+// GET /api/tasks/{id}/archive.
+type TaskArchiveResponse struct {
+	RunCount          int    `json:"run_count"`
+	PlannerTokenUsage int    `json:"planner_token_usage"`
+	SizeBytes         int64  `json:"size_bytes"`
+	ArchivedAt        string `json:"archived_at"`
+}
+
+// TaskTreeDependencyResponse is a dependency edge: subtask_id depends on depends_on_id.
+type TaskTreeDependencyResponse struct {
+	SubtaskID   string `json:"subtask_id"`
+	DependsOnID string `json:"depends_on_id"`
+}
+
 // CreateTaskRequest represents the request body for creating a task.
 type CreateTaskRequest struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
+	DryRun      bool   `json:"dry_run"`
+	BaseBranch  string `json:"base_branch"` // Optional; defaults to the project default branch
+	// Optional files in the repository, relative to its root, given to the Planner as context
+	AttachmentPaths []string `json:"attachment_paths"`
+}
+
+// maxTaskFormBytes bounds a multipart task creation request, uploads included.
+const maxTaskFormBytes = 8 << 20
+
+// PauseTaskRequest is the optional request body for pausing a task.
+type PauseTaskRequest struct {
+	// StopWorkers kills running Workers and blocks their subtasks (FAILURE)
+	// instead of letting them finish.
+	StopWorkers bool `json:"stop_workers"`
+}
+
+// PlannedSubtaskResponse represents a proposed subtask in a plan preview.
+type PlannedSubtaskResponse struct {
+	BeadsIssueID       string   `json:"beads_issue_id"`
+	Title              string   `json:"title"`
+	Spec               string   `json:"spec"`
+	ImplementationPlan string   `json:"implementation_plan"`
+	DependsOn          []string `json:"depends_on"`
+}
+
+// PlanPreviewResponse represents the Planner's proposed breakdown for a dry-run task.
+type PlanPreviewResponse struct {
+	TaskID   string                   `json:"task_id"`
+	EpicID   string                   `json:"epic_id,omitempty"`
+	Subtasks []PlannedSubtaskResponse `json:"subtasks"`
 }
 
 // Create creates a new task.
@@ -67,9 +134,16 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse request body
+	// Parse request body: JSON, or a multipart form when files are uploaded
 	var req CreateTaskRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var attachments []domain.TaskAttachment
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		req, attachments, err = parseCreateTaskForm(w, r)
+		if err != nil {
+			response.BadRequest(w, err.Error())
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		response.BadRequest(w, "invalid request body")
 		return
 	}
@@ -84,12 +158,34 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Checking that a base branch exists needs the user's GitHub token
+	var token string
+	if req.BaseBranch != "" {
+		user, ok := middleware.GetUserFromContext(ctx)
+		if !ok {
+			response.Unauthorized(w, "not authenticated")
+			return
+		}
+		token, err = h.authService.DecryptUserToken(user)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to decrypt user token")
+			response.InternalError(w, err)
+			return
+		}
+	}
+
 	// Create the task
 	task, err := h.taskService.CreateTask(ctx, service.CreateTaskInput{
 		ProjectID:   projectID,
 		UserID:      userID,
 		Title:       req.Title,
 		Description: req.Description,
+		DryRun:      req.DryRun,
+		BaseBranch:  req.BaseBranch,
+		GitHubToken: token,
+
+		Attachments:     attachments,
+		AttachmentPaths: req.AttachmentPaths,
 	})
 	if err != nil {
 		log.Error().Err(err).
@@ -109,6 +205,46 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, taskToResponse(task))
 }
 
+// parseCreateTaskForm reads a multipart/form-data task creation request. The
+// CreateTaskRequest fields are form values (attachment_paths may repeat) and
+// each "attachments" part is an uploaded file.
+func parseCreateTaskForm(w http.ResponseWriter, r *http.Request) (CreateTaskRequest, []domain.TaskAttachment, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxTaskFormBytes)
+	if err := r.ParseMultipartForm(maxTaskFormBytes); err != nil {
+		return CreateTaskRequest{}, nil, errors.New("invalid multipart form")
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	req := CreateTaskRequest{
+		Title:           r.FormValue("title"),
+		Description:     r.FormValue("description"),
+		BaseBranch:      r.FormValue("base_branch"),
+		AttachmentPaths: r.MultipartForm.Value["attachment_paths"],
+	}
+	if v := r.FormValue("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			return CreateTaskRequest{}, nil, errors.New("dry_run must be a boolean")
+		}
+		req.DryRun = dryRun
+	}
+
+	var attachments []domain.TaskAttachment
+	for _, header := range r.MultipartForm.File["attachments"] {
+		f, err := header.Open()
+		if err != nil {
+			return CreateTaskRequest{}, nil, errors.New("failed to read attachment")
+		}
+		content, err := io.ReadAll(f)
+		_ = f.Close()
+		if err != nil {
+			return CreateTaskRequest{}, nil, errors.New("failed to read attachment")
+		}
+		attachments = append(attachments, domain.TaskAttachment{Name: header.Filename, Content: content})
+	}
+	return req, attachments, nil
+}
+
 // List lists all tasks for a project.
 // GET /api/projects/{project_id}/tasks
 func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -141,11 +277,13 @@ func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	// Convert to response format
 	result := make([]TaskResponse, len(tasks))
+	versions := make([]response.Version, len(tasks))
 	for i, t := range tasks {
 		result[i] = taskToResponse(t)
+		versions[i] = response.Version{ID: t.ID, UpdatedAt: t.UpdatedAt}
 	}
 
-	response.OK(w, result)
+	response.OKWithETag(w, r, response.ETag(versions...), result)
 }
 
 // Get retrieves a task by ID.
@@ -174,7 +312,86 @@ func (h *TaskHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.OK(w, taskToResponse(task))
+	etag := response.ETag(response.Version{ID: task.ID, UpdatedAt: task.UpdatedAt})
+	response.OKWithETag(w, r, etag, taskToResponse(task))
+}
+
+// GetTree retrieves a task with its subtasks and their dependencies.
+// GET /api/tasks/{id}/tree
+func (h *TaskHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	tree, err := h.taskService.GetTaskTree(ctx, taskID, userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("user_id", userID.String()).
+			Str("task_id", taskID.String()).
+			Msg("failed to get task tree")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	response.OK(w, taskTreeToResponse(tree))
+}
+
+// DownloadArchive streams the tar.gz holding an archived task's agent runs,
+// logs, and prompts. 404 until the task has been archived.
+// GET /api/tasks/{id}/archive
+func (h *TaskHandler) DownloadArchive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	archive, err := h.taskService.GetTaskArchive(ctx, taskID, userID)
+	if err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	f, err := os.Open(archive.ArchivePath)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Str("archive_path", archive.ArchivePath).
+			Msg("failed to open task archive")
+		response.NotFound(w, "task archive file not found")
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": "task-" + taskID.String() + ".tar.gz",
+	}))
+	http.ServeContent(w, r, "", archive.ArchivedAt, f)
 }
 
 // Delete deletes a task.
@@ -250,6 +467,284 @@ func (h *TaskHandler) RetryPlanning(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, taskToResponse(task))
 }
 
+// Pause pauses an active task.
+// POST /api/tasks/{id}/pause
+func (h *TaskHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	// Parse request body (optional)
+	var req PauseTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	task, err := h.taskService.PauseTask(ctx, taskID, userID, req.StopWorkers)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to pause task")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("task_id", taskID.String()).
+		Bool("stop_workers", req.StopWorkers).
+		Msg("task paused")
+
+	response.OK(w, taskToResponse(task))
+}
+
+// Resume resumes a paused task.
+// POST /api/tasks/{id}/resume
+func (h *TaskHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	task, err := h.taskService.ResumeTask(ctx, taskID, userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to resume task")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("task_id", taskID.String()).
+		Msg("task resumed")
+
+	response.OK(w, taskToResponse(task))
+}
+
+// Resync re-runs the beads sync for a task and returns its subtasks.
+// POST /api/tasks/{id}/resync
+func (h *TaskHandler) Resync(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	subtasks, err := h.taskService.ResyncTask(ctx, taskID, userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to re-sync task")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("task_id", taskID.String()).
+		Int("subtasks", len(subtasks)).
+		Msg("task re-synced from beads")
+
+	result := make([]SubtaskResponse, len(subtasks))
+	for i, s := range subtasks {
+		result[i] = subtaskToResponse(s)
+	}
+
+	response.OK(w, result)
+}
+
+// Replan re-runs the Planner for an active task, keeping its existing subtasks.
+// POST /api/tasks/{id}/replan
+func (h *TaskHandler) Replan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	task, err := h.taskService.ReplanTask(ctx, taskID, userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to re-plan task")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("task_id", taskID.String()).
+		Msg("task re-planning started")
+
+	response.JSON(w, http.StatusAccepted, taskToResponse(task))
+}
+
+// GetPlan returns the proposed plan of a dry-run task awaiting approval.
+// GET /api/tasks/{id}/plan
+func (h *TaskHandler) GetPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	preview, err := h.taskService.GetPlanPreview(ctx, taskID, userID)
+	if err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	response.OK(w, planPreviewToResponse(preview))
+}
+
+// ConfirmPlan approves a dry-run plan, creating its subtasks.
+// POST /api/tasks/{id}/plan/confirm
+func (h *TaskHandler) ConfirmPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse task ID from URL
+	taskIDStr := chi.URLParam(r, "id")
+	taskID, err := uuid.Parse(taskIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid task ID")
+		return
+	}
+
+	task, err := h.taskService.ConfirmPlan(ctx, taskID, userID)
+	if err != nil {
+		log.Error().Err(err).
+			Str("task_id", taskID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to confirm plan")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("task_id", taskID.String()).
+		Msg("task plan confirmed")
+
+	response.OK(w, taskToResponse(task))
+}
+
+// planPreviewToResponse converts a service.PlanPreview to a PlanPreviewResponse.
+func planPreviewToResponse(p *service.PlanPreview) PlanPreviewResponse {
+	subtasks := make([]PlannedSubtaskResponse, len(p.Subtasks))
+	for i, s := range p.Subtasks {
+		subtasks[i] = PlannedSubtaskResponse{
+			BeadsIssueID:       s.BeadsIssueID,
+			Title:              s.Title,
+			Spec:               s.Spec,
+			ImplementationPlan: s.ImplementationPlan,
+			DependsOn:          s.DependsOn,
+		}
+	}
+	return PlanPreviewResponse{
+		TaskID:   p.TaskID.String(),
+		EpicID:   p.EpicID,
+		Subtasks: subtasks,
+	}
+}
+
+// taskTreeToResponse converts a service.TaskTree to a TaskTreeResponse.
+func taskTreeToResponse(t *service.TaskTree) TaskTreeResponse {
+	subtasks := make([]SubtaskResponse, len(t.Subtasks))
+	for i, s := range t.Subtasks {
+		subtasks[i] = subtaskToResponse(s)
+	}
+	deps := make([]TaskTreeDependencyResponse, len(t.Dependencies))
+	for i, d := range t.Dependencies {
+		deps[i] = TaskTreeDependencyResponse{
+			SubtaskID:   d.SubtaskID.String(),
+			DependsOnID: d.DependsOnID.String(),
+		}
+	}
+	resp := TaskTreeResponse{
+		Task:              taskToResponse(t.Task),
+		Subtasks:          subtasks,
+		Dependencies:      deps,
+		TokenUsage:        t.TokenUsage,
+		PlannerTokenUsage: t.PlannerTokenUsage,
+	}
+	if a := t.Archive; a != nil {
+		resp.Archive = &TaskArchiveResponse{
+			RunCount:          a.RunCount,
+			PlannerTokenUsage: a.PlannerTokenUsage,
+			SizeBytes:         a.SizeBytes,
+			ArchivedAt:        a.ArchivedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	return resp
+}
+
 // taskToResponse converts a domain.Task to a TaskResponse.
 func taskToResponse(t *domain.Task) TaskResponse {
 	return TaskResponse{
@@ -259,6 +754,8 @@ func taskToResponse(t *domain.Task) TaskResponse {
 		Description: t.Description,
 		Status:      string(t.Status),
 		BeadsEpicID: t.BeadsEpicID,
+		DryRun:      t.DryRun,
+		BaseBranch:  t.BaseBranch,
 		CreatedAt:   t.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:   t.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	}
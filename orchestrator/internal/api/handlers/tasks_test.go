@@ -5,10 +5,18 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/api/middleware"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
 )
 
 func TestCreateTaskRequest_Validation(t *testing.T) {
@@ -99,7 +107,7 @@ func TestTaskResponse_Format(t *testing.T) {
 	}
 
 	// Check required fields are present
-	requiredFields := []string{"id", "project_id", "title", "description", "status", "created_at", "updated_at"}
+	requiredFields := []string{"id", "project_id", "title", "description", "status", "dry_run", "created_at", "updated_at"}
 	for _, field := range requiredFields {
 		if _, ok := unmarshaled[field]; !ok {
 			t.Errorf("missing required field: %s", field)
@@ -112,6 +120,86 @@ func TestTaskResponse_Format(t *testing.T) {
 	}
 }
 
+func TestPlanPreviewToResponse(t *testing.T) {
+	taskID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	resp := planPreviewToResponse(&service.PlanPreview{
+		TaskID: taskID,
+		EpicID: "iv-1",
+		Subtasks: []service.PlannedSubtask{
+			{BeadsIssueID: "iv-2", Title: "Add model", Spec: "spec", ImplementationPlan: "plan", DependsOn: []string{}},
+			{BeadsIssueID: "iv-3", Title: "Add handler", DependsOn: []string{"iv-2"}},
+		},
+	})
+
+	if resp.TaskID != taskID.String() || resp.EpicID != "iv-1" {
+		t.Errorf("unexpected ids: task=%s epic=%s", resp.TaskID, resp.EpicID)
+	}
+	if len(resp.Subtasks) != 2 {
+		t.Fatalf("expected 2 subtasks, got %d", len(resp.Subtasks))
+	}
+	if resp.Subtasks[1].DependsOn[0] != "iv-2" {
+		t.Errorf("expected iv-3 to depend on iv-2, got %v", resp.Subtasks[1].DependsOn)
+	}
+
+	// An empty plan still encodes subtasks as an array
+	data, err := json.Marshal(planPreviewToResponse(&service.PlanPreview{TaskID: taskID, Subtasks: []service.PlannedSubtask{}}))
+	if err != nil {
+		t.Fatalf("failed to marshal response: %v", err)
+	}
+	var unmarshaled map[string]interface{}
+	if err := json.Unmarshal(data, &unmarshaled); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if _, ok := unmarshaled["subtasks"].([]interface{}); !ok {
+		t.Errorf("subtasks should be an array, got %v", unmarshaled["subtasks"])
+	}
+	if _, ok := unmarshaled["epic_id"]; ok {
+		t.Error("epic_id should be omitted when empty")
+	}
+}
+
+func TestTaskTreeToResponse(t *testing.T) {
+	task := &domain.Task{ID: uuid.New(), ProjectID: uuid.New(), Title: "Add auth", Status: domain.TaskStatusActive}
+	first := &domain.Subtask{ID: uuid.New(), TaskID: task.ID, Title: "Add model", Status: domain.SubtaskStatusMerged, TokenUsage: 300}
+	second := &domain.Subtask{ID: uuid.New(), TaskID: task.ID, Title: "Add handler", Status: domain.SubtaskStatusReady}
+
+	resp := taskTreeToResponse(&service.TaskTree{
+		Task:              task,
+		Subtasks:          []*domain.Subtask{first, second},
+		Dependencies:      []service.TaskTreeDependency{{SubtaskID: second.ID, DependsOnID: first.ID}},
+		PlannerTokenUsage: 100,
+		TokenUsage:        400,
+	})
+
+	if resp.Task.ID != task.ID.String() || len(resp.Subtasks) != 2 {
+		t.Fatalf("unexpected tree: task=%s subtasks=%d", resp.Task.ID, len(resp.Subtasks))
+	}
+	if resp.Subtasks[0].TokenUsage != 300 || resp.Subtasks[1].Status != "READY" {
+		t.Errorf("unexpected subtasks: %+v", resp.Subtasks)
+	}
+	if len(resp.Dependencies) != 1 || resp.Dependencies[0].SubtaskID != second.ID.String() || resp.Dependencies[0].DependsOnID != first.ID.String() {
+		t.Errorf("unexpected dependencies: %+v", resp.Dependencies)
+	}
+	if resp.TokenUsage != 400 || resp.PlannerTokenUsage != 100 {
+		t.Errorf("token usage = %d (planner %d), want 400 (planner 100)", resp.TokenUsage, resp.PlannerTokenUsage)
+	}
+
+	// A task without subtasks still encodes empty arrays
+	data, err := json.Marshal(taskTreeToResponse(&service.TaskTree{Task: task, Subtasks: []*domain.Subtask{}, Dependencies: []service.TaskTreeDependency{}}))
+	if err != nil {
+		t.Fatalf("failed to marshal response: %v", err)
+	}
+	var unmarshaled map[string]interface{}
+	if err := json.Unmarshal(data, &unmarshaled); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	for _, key := range []string{"subtasks", "dependencies"} {
+		if _, ok := unmarshaled[key].([]interface{}); !ok {
+			t.Errorf("%s should be an array, got %v", key, unmarshaled[key])
+		}
+	}
+}
+
 func TestTaskHandler_CreateBadRequest(t *testing.T) {
 	// Test that invalid JSON returns 400
 	req := httptest.NewRequest(http.MethodPost, "/api/projects/invalid-uuid/tasks", bytes.NewBufferString("not json"))
@@ -130,3 +218,33 @@ func TestTaskHandler_CreateBadRequest(t *testing.T) {
 
 	_ = w // Avoid unused variable warning
 }
+
+func TestTaskHandler_PauseBadRequest(t *testing.T) {
+	h := NewTaskHandler(nil, nil)
+	user := &domain.User{ID: uuid.New()}
+
+	tests := []struct {
+		name   string
+		taskID string
+		body   string
+	}{
+		{"invalid task ID", "not-a-uuid", ""},
+		{"malformed body", uuid.New().String(), `{"stop_workers": "yes"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.taskID)
+			req := httptest.NewRequest(http.MethodPost, "/api/tasks/"+tt.taskID+"/pause", bytes.NewBufferString(tt.body))
+			ctx := context.WithValue(middleware.SetUserInContext(req.Context(), user), chi.RouteCtxKey, rctx)
+
+			w := httptest.NewRecorder()
+			h.Pause(w, req.WithContext(ctx))
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/api/middleware"
+	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+// UsageHandler handles requests for a user's quota usage.
+type UsageHandler struct {
+	quotaService *service.QuotaService
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(quotaService *service.QuotaService) *UsageHandler {
+	return &UsageHandler{quotaService: quotaService}
+}
+
+// QuotaUsageResponse is one quota's usage. A limit of 0 means unlimited.
+type QuotaUsageResponse struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+}
+
+// UsageResponse represents a user's usage of the per-user quotas.
+type UsageResponse struct {
+	Projects         QuotaUsageResponse `json:"projects"`
+	ActiveTasks      QuotaUsageResponse `json:"active_tasks"`
+	ConcurrentAgents QuotaUsageResponse `json:"concurrent_agents"`
+}
+
+// GetUsage returns the current user's usage of each quota.
+// GET /api/auth/me/usage
+func (h *UsageHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	usage, err := h.quotaService.Usage(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get quota usage")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	response.OK(w, usageToResponse(usage))
+}
+
+// usageToResponse converts service.UserUsage to a UsageResponse.
+func usageToResponse(u *service.UserUsage) UsageResponse {
+	return UsageResponse{
+		Projects:         QuotaUsageResponse(u.Projects),
+		ActiveTasks:      QuotaUsageResponse(u.ActiveTasks),
+		ConcurrentAgents: QuotaUsageResponse(u.ConcurrentAgents),
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/api/middleware"
+	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/service"
+)
+
+// WebhookHandler handles webhook management HTTP requests.
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// WebhookResponse represents a webhook in API responses.
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	ProjectID  string   `json:"project_id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"` // only returned on creation
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreateWebhookRequest represents the request body for creating a webhook.
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`
+}
+
+// List lists the webhooks of a project.
+// GET /api/projects/{id}/webhooks
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse project ID from URL
+	projectIDStr := chi.URLParam(r, "id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(ctx, projectID, userID)
+	if err != nil {
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	result := make([]WebhookResponse, len(webhooks))
+	for i, wh := range webhooks {
+		result[i] = webhookToResponse(wh)
+	}
+
+	response.OK(w, result)
+}
+
+// Create registers a webhook for a project.
+// POST /api/projects/{id}/webhooks
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse project ID from URL
+	projectIDStr := chi.URLParam(r, "id")
+	projectID, err := uuid.Parse(projectIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+
+	// Parse request body
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.BadRequest(w, "invalid request body")
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(ctx, service.CreateWebhookInput{
+		ProjectID:  projectID,
+		UserID:     userID,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+	})
+	if err != nil {
+		log.Error().Err(err).
+			Str("project_id", projectID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to create webhook")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("webhook_id", webhook.ID.String()).
+		Str("project_id", projectID.String()).
+		Msg("webhook created")
+
+	resp := webhookToResponse(webhook)
+	resp.Secret = webhook.Secret
+	response.Created(w, resp)
+}
+
+// Delete removes a webhook from a project.
+// DELETE /api/projects/{id}/webhooks/{webhook_id}
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get authenticated user
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		response.Unauthorized(w, "not authenticated")
+		return
+	}
+
+	// Parse IDs from URL
+	projectID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		response.BadRequest(w, "invalid project ID")
+		return
+	}
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhook_id"))
+	if err != nil {
+		response.BadRequest(w, "invalid webhook ID")
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(ctx, projectID, webhookID, userID); err != nil {
+		log.Error().Err(err).
+			Str("webhook_id", webhookID.String()).
+			Str("user_id", userID.String()).
+			Msg("failed to delete webhook")
+		response.ErrorFromDomain(w, err)
+		return
+	}
+
+	log.Info().
+		Str("webhook_id", webhookID.String()).
+		Msg("webhook deleted")
+
+	response.NoContent(w)
+}
+
+// webhookToResponse converts a domain.Webhook to a WebhookResponse without its secret.
+func webhookToResponse(wh *domain.Webhook) WebhookResponse {
+	eventTypes := wh.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return WebhookResponse{
+		ID:         wh.ID.String(),
+		ProjectID:  wh.ProjectID.String(),
+		URL:        wh.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  wh.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// requiredBinaries are the external commands agents and services shell out to.
+var requiredBinaries = []string{"git", "bd", "claude"}
+
+// Health check result values.
+const (
+	healthOK          = "ok"
+	healthDegraded    = "degraded"
+	healthUnavailable = "unavailable"
+)
+
+// pinger checks database connectivity. It is implemented by postgres.DB.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ReadinessResponse is the body returned by the readiness probe.
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+	// DiskFreeBytes is the free space in the data directory, where it can be read.
+	DiskFreeBytes *uint64 `json:"disk_free_bytes,omitempty"`
+}
+
+// healthRoutes registers the health probes on r. /health is kept as an alias
+// of /health/ready for existing probes.
+func (s *Server) healthRoutes(r chi.Router) {
+	r.Get("/health", s.handleReady)
+	r.Get("/health/live", s.handleLive)
+	r.Get("/health/ready", s.handleReady)
+}
+
+// handleLive reports that the process is up. It never touches dependencies,
+// so a database outage does not cause the orchestrator to be restarted.
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	response.OK(w, map[string]string{"status": healthOK})
+}
+
+// handleReady reports whether the server can serve traffic: the database is
+// reachable, startup recovery has finished, and (optionally) required
+// binaries are on PATH. Missing binaries that are not required for readiness
+// still show up in the checks, with an overall status of "degraded".
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	checks := make(map[string]string)
+	ready := true
+
+	// Database connectivity
+	if err := s.db.Ping(ctx); err != nil {
+		log.Error().Err(err).Msg("readiness check failed: database unreachable")
+		checks["database"] = "database unreachable"
+		ready = false
+	} else {
+		checks["database"] = healthOK
+	}
+
+	// Shutdown in progress
+	if s.draining.Load() {
+		checks["shutdown"] = "draining"
+		ready = false
+	}
+
+	// Startup recovery
+	if s.recoveryDone.Load() {
+		checks["recovery"] = healthOK
+	} else {
+		checks["recovery"] = "recovery in progress"
+		ready = false
+	}
+
+	// External binaries: missing ones make the server unready only when
+	// HEALTH_CHECK_BINARIES is set, otherwise it is reported as degraded
+	degraded := false
+	for _, name := range requiredBinaries {
+		if _, err := exec.LookPath(name); err != nil {
+			checks["binary:"+name] = "not found on PATH"
+			if s.cfg.HealthCheckBinaries {
+				ready = false
+			} else {
+				degraded = true
+			}
+		} else {
+			checks["binary:"+name] = healthOK
+		}
+	}
+
+	// Free disk space: below DATA_DIR_MIN_FREE_MB no clone, worktree, or
+	// agent run is started, but reads still work, so it only degrades
+	var diskFree *uint64
+	if s.diskSpace != nil {
+		if free, ok, err := s.diskSpace.FreeBytes(); err == nil && ok {
+			diskFree = &free
+		}
+		switch err := s.diskSpace.Check(); {
+		case domain.IsInsufficientDiskSpace(err):
+			checks["disk"] = err.Error()
+			degraded = true
+		case err != nil:
+			log.Error().Err(err).Msg("readiness check failed: free disk space unknown")
+			checks["disk"] = "free space unknown"
+			degraded = true
+		default:
+			checks["disk"] = healthOK
+		}
+	}
+
+	if !ready {
+		response.JSON(w, http.StatusServiceUnavailable, ReadinessResponse{Status: healthUnavailable, Checks: checks, DiskFreeBytes: diskFree})
+		return
+	}
+
+	if degraded {
+		response.OK(w, ReadinessResponse{Status: healthDegraded, Checks: checks, DiskFreeBytes: diskFree})
+		return
+	}
+
+	response.OK(w, ReadinessResponse{Status: healthOK, Checks: checks, DiskFreeBytes: diskFree})
+}
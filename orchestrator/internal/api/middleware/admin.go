@@ -0,0 +1,37 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/api/response"
+)
+
+// RequireAdmin returns a middleware that only lets through users whose GitHub
+// ID is in adminGitHubIDs; everyone else gets 403, whatever they own. It must
+// run after RequireAuth. With no admin IDs every request is forbidden.
+func RequireAdmin(adminGitHubIDs []int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				response.Unauthorized(w, "not authenticated")
+				return
+			}
+			if !slices.Contains(adminGitHubIDs, user.GitHubID) {
+				log.Warn().
+					Str("user_id", user.ID.String()).
+					Str("path", r.URL.Path).
+					Msg("non-admin user denied admin endpoint")
+				response.Forbidden(w, "admin access required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		admins   []int64
+		githubID int64
+		want     int
+	}{
+		{name: "admin", admins: []int64{1, 12345}, githubID: 12345, want: http.StatusOK},
+		{name: "not admin", admins: []int64{1}, githubID: 12345, want: http.StatusForbidden},
+		{name: "no admins configured", githubID: 12345, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &mockValidator{user: &domain.User{ID: uuid.New(), GitHubID: tt.githubID}}
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/active-runs", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rr := httptest.NewRecorder()
+
+			NewAuthMiddleware(validator).RequireAuth(RequireAdmin(tt.admins)(handler)).ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin_Unauthenticated(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/active-runs", nil)
+	rr := httptest.NewRecorder()
+
+	RequireAdmin([]int64{12345})(handler).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rr.Code)
+	}
+}
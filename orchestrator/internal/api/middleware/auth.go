@@ -5,13 +5,13 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 
+	"github.com/intern-village/orchestrator/internal/api/response"
 	"github.com/intern-village/orchestrator/internal/domain"
 )
 
@@ -46,7 +46,7 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := extractToken(r)
 		if token == "" {
-			writeUnauthorized(w, "missing authentication")
+			response.Unauthorized(w, "missing authentication")
 			return
 		}
 
@@ -54,7 +54,7 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		user, err := m.validator.ValidateJWT(token)
 		if err != nil {
 			log.Debug().Err(err).Msg("JWT validation failed")
-			writeUnauthorized(w, "invalid or expired token")
+			response.Unauthorized(w, "invalid or expired token")
 			return
 		}
 
@@ -85,16 +85,6 @@ func extractToken(r *http.Request) string {
 	return ""
 }
 
-// writeUnauthorized writes a 401 Unauthorized response.
-func writeUnauthorized(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusUnauthorized)
-	_ = json.NewEncoder(w).Encode(map[string]string{
-		"code":    "UNAUTHORIZED",
-		"message": message,
-	})
-}
-
 // GetUserFromContext retrieves the authenticated user from the request context.
 func GetUserFromContext(ctx context.Context) (*domain.User, bool) {
 	user, ok := ctx.Value(UserContextKey).(*domain.User)
@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/intern-village/orchestrator/internal/api/response"
+)
+
+// Drain returns a middleware that rejects new mutating requests with 503 once
+// draining is set, so nothing new (a task, a clone, a Worker) is started while
+// the server shuts down. Reads are still served, and requests already past the
+// middleware run to completion.
+func Drain(draining *atomic.Bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if draining.Load() && isMutating(r.Method) {
+				w.Header().Set("Connection", "close")
+				response.Error(w, http.StatusServiceUnavailable, response.CodeShuttingDown, "server is shutting down")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isMutating reports whether a request method may change state.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDrain(t *testing.T) {
+	var draining atomic.Bool
+	handler := Drain(&draining)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/api/projects", nil))
+		return rec.Code
+	}
+
+	if code := serve(http.MethodPost); code != http.StatusOK {
+		t.Errorf("POST before draining = %d, want 200", code)
+	}
+
+	draining.Store(true)
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		if code := serve(method); code != http.StatusServiceUnavailable {
+			t.Errorf("%s while draining = %d, want 503", method, code)
+		}
+	}
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		if code := serve(method); code != http.StatusOK {
+			t.Errorf("%s while draining = %d, want 200", method, code)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client's key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from a stored result.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyLeaseSlack is added to a route's lease so a request still
+	// finishing right at its timeout keeps its claim.
+	idempotencyLeaseSlack = time.Minute
+)
+
+// IdempotencyStore records the outcome of requests made with an Idempotency-Key.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, userID uuid.UUID, key, route, requestHash string, lease time.Duration) (*domain.IdempotencyClaim, *domain.IdempotentResponse, error)
+	Complete(ctx context.Context, claim *domain.IdempotencyClaim, resp *domain.IdempotentResponse) error
+	Release(ctx context.Context, claim *domain.IdempotencyClaim) error
+}
+
+// recordingWriter captures the status and body written by a handler while
+// passing them through to the client.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+		rw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Idempotency returns a middleware that makes a route safe to retry when the
+// client sends an Idempotency-Key header. Keys are scoped to the authenticated
+// user and the request method and path; it must run after RequireAuth.
+//
+// The first request with a key executes normally and its response is stored
+// (unless it is a 5xx, in which case the key is released). Retries with the
+// same key and body replay the stored response; a retry while the first
+// request is still running gets 409, and reuse with a different body gets 422.
+// Requests without the header are passed through untouched.
+//
+// lease is the route's timeout. A first request holds its key for lease plus
+// idempotencyLeaseSlack; after that, a retry with the same body takes over a
+// claim left behind by a crashed server instead of being blocked until expiry.
+// A request whose claim was taken over can no longer complete or release it.
+func Idempotency(store IdempotencyStore, lease time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				response.BadRequest(w, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+				return
+			}
+
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok {
+				response.Unauthorized(w, "not authenticated")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				response.BadRequest(w, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			sum := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(sum[:])
+			route := r.Method + " " + r.URL.Path
+
+			claim, stored, err := store.Begin(r.Context(), userID, key, route, requestHash, lease+idempotencyLeaseSlack)
+			if err != nil {
+				response.ErrorFromDomain(w, err)
+				return
+			}
+			if stored != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(stored.StatusCode)
+				_, _ = w.Write(stored.Body)
+				return
+			}
+
+			// Record the outcome even if the client has disconnected, which is
+			// exactly when it is most likely to retry
+			storeCtx := context.WithoutCancel(r.Context())
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := store.Release(storeCtx, claim); err != nil {
+					log.Error().Err(err).Str("route", route).Msg("failed to release idempotency key")
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			if err := store.Complete(storeCtx, claim, &domain.IdempotentResponse{
+				StatusCode: rec.status,
+				Body:       rec.body.Bytes(),
+			}); err != nil {
+				log.Error().Err(err).Str("route", route).Msg("failed to store idempotent response")
+				return
+			}
+			completed = true
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// memoryIdempotencyStore is an in-memory IdempotencyStore for tests.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	hashes  map[string]string
+	results map[string]*domain.IdempotentResponse
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{
+		hashes:  make(map[string]string),
+		results: make(map[string]*domain.IdempotentResponse),
+	}
+}
+
+func (s *memoryIdempotencyStore) id(userID uuid.UUID, key, route string) string {
+	return userID.String() + "|" + key + "|" + route
+}
+
+func (s *memoryIdempotencyStore) Begin(_ context.Context, userID uuid.UUID, key, route, requestHash string, _ time.Duration) (*domain.IdempotencyClaim, *domain.IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.id(userID, key, route)
+	hash, exists := s.hashes[id]
+	if !exists {
+		s.hashes[id] = requestHash
+		return &domain.IdempotencyClaim{UserID: userID, Key: key, Route: route, ClaimedAt: time.Now()}, nil, nil
+	}
+	if hash != requestHash {
+		return nil, nil, domain.NewUnprocessableError("idempotency key", "key was already used for a different request")
+	}
+	if s.results[id] == nil {
+		return nil, nil, domain.NewConflictError("idempotency key", "original request is still in progress")
+	}
+	return nil, s.results[id], nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, claim *domain.IdempotencyClaim, resp *domain.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[s.id(claim.UserID, claim.Key, claim.Route)] = resp
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, claim *domain.IdempotencyClaim) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.id(claim.UserID, claim.Key, claim.Route)
+	delete(s.hashes, id)
+	delete(s.results, id)
+	return nil
+}
+
+// idempotentRequest builds an authenticated POST with an optional Idempotency-Key.
+func idempotentRequest(user *domain.User, key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/projects", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return req.WithContext(context.WithValue(req.Context(), UserContextKey, user))
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	user := &domain.User{ID: uuid.New()}
+
+	calls := 0
+	handler := Idempotency(store, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, idempotentRequest(user, "key-1", `"a"`))
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, idempotentRequest(user, "key-1", `"a"`))
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+	if second.Code != http.StatusCreated {
+		t.Errorf("replay status = %d, want %d", second.Code, http.StatusCreated)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("replay body = %q, want %q", second.Body.String(), first.Body.String())
+	}
+	if second.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("replay missing %s header", IdempotentReplayedHeader)
+	}
+}
+
+func TestIdempotency_WithoutKeyAlwaysExecutes(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	user := &domain.User{ID: uuid.New()}
+
+	calls := 0
+	handler := Idempotency(store, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(user, "", `{}`))
+	}
+
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	user := &domain.User{ID: uuid.New()}
+
+	calls := 0
+	handler := Idempotency(store, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(user, "key-1", `{}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest(user, "key-1", `{}`))
+
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+	if rr.Code != http.StatusCreated {
+		t.Errorf("retry status = %d, want %d", rr.Code, http.StatusCreated)
+	}
+}
+
+func TestIdempotency_KeyReuseWithDifferentBody(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	user := &domain.User{ID: uuid.New()}
+
+	handler := Idempotency(store, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(user, "key-1", `{"repo":"a"}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest(user, "key-1", `{"repo":"b"}`))
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotency_InFlightConflict(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	user := &domain.User{ID: uuid.New()}
+
+	var inner *httptest.ResponseRecorder
+	var handler http.Handler
+	handler = Idempotency(store, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A retry arriving while the original is still running
+		if inner == nil {
+			inner = httptest.NewRecorder()
+			handler.ServeHTTP(inner, idempotentRequest(user, "key-1", `{}`))
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(user, "key-1", `{}`))
+
+	if inner.Code != http.StatusConflict {
+		t.Errorf("in-flight retry status = %d, want %d", inner.Code, http.StatusConflict)
+	}
+}
+
+func TestIdempotency_KeysScopedByUser(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+
+	calls := 0
+	handler := Idempotency(store, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(&domain.User{ID: uuid.New()}, "key-1", `{}`))
+	handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest(&domain.User{ID: uuid.New()}, "key-1", `{}`))
+
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	handler := Idempotency(newMemoryIdempotencyStore(), time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, idempotentRequest(&domain.User{ID: uuid.New()}, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/intern-village/orchestrator/internal/metrics"
+)
+
+// unmatchedRoute is the route label used for requests that did not match any route.
+// Using the route pattern (not the raw path) keeps label cardinality bounded.
+const unmatchedRoute = "unmatched"
+
+// Metrics returns a middleware that records request latency and status codes.
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapped := wrapResponseWriter(w)
+
+			next.ServeHTTP(wrapped, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					route = pattern
+				}
+			}
+
+			m.ObserveHTTPRequest(r.Method, route, wrapped.status, time.Since(start))
+		})
+	}
+}
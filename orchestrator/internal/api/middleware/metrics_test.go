@@ -0,0 +1,59 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/intern-village/orchestrator/internal/metrics"
+)
+
+func TestMetrics_RecordsRoutePatternAndStatus(t *testing.T) {
+	m := metrics.New()
+
+	r := chi.NewRouter()
+	r.Use(Metrics(m))
+	r.Get("/api/projects/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/projects/123", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", rr.Code)
+	}
+
+	out := httptest.NewRecorder()
+	m.Handler().ServeHTTP(out, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := out.Body.String()
+
+	if !strings.Contains(body, `route="/api/projects/{id}"`) {
+		t.Error("expected route pattern label, not raw path")
+	}
+	if !strings.Contains(body, `status="404"`) {
+		t.Error("expected status label 404")
+	}
+	if strings.Contains(body, "/api/projects/123") {
+		t.Error("raw path should not appear in labels")
+	}
+}
+
+func TestMetrics_NilMetrics(t *testing.T) {
+	handler := Metrics(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/config"
+)
+
+// preflightTimeout bounds the `--version` calls made at startup.
+const preflightTimeout = 10 * time.Second
+
+// binaryCheck is the startup result for one required binary.
+type binaryCheck struct {
+	name    string
+	path    string // resolved on PATH; empty if missing
+	version string // first line of `--version`; empty if it could not be read
+}
+
+// checkBinaries resolves each binary on PATH and reads its reported version.
+// A binary that resolves but fails `--version` still counts as present.
+func checkBinaries(ctx context.Context, names []string) []binaryCheck {
+	checks := make([]binaryCheck, 0, len(names))
+	for _, name := range names {
+		check := binaryCheck{name: name}
+		if path, err := exec.LookPath(name); err == nil {
+			check.path = path
+			check.version = binaryVersion(ctx, path)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// binaryVersion returns the first line printed by `path --version`.
+func binaryVersion(ctx context.Context, path string) string {
+	out, err := exec.CommandContext(ctx, path, "--version").Output() //nolint:gosec // path comes from LookPath of a fixed name
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	return strings.TrimSpace(line)
+}
+
+// runPreflight checks that git, bd, and claude are installed before the server
+// starts, so a missing binary is reported at boot instead of as a failed agent
+// run or a beads error mid-planning. In strict mode a missing binary is fatal;
+// in degraded mode it is logged and /health/ready reports "degraded".
+func runPreflight(mode string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), preflightTimeout)
+	defer cancel()
+
+	var missing []string
+	for _, check := range checkBinaries(ctx, requiredBinaries) {
+		if check.path == "" {
+			missing = append(missing, check.name)
+			continue
+		}
+		log.Info().
+			Str("binary", check.name).
+			Str("path", check.path).
+			Str("version", check.version).
+			Msg("found required binary")
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if mode == config.PreflightStrict {
+		return fmt.Errorf("required binaries not found on PATH: %s (set PREFLIGHT_MODE=%s to start without them)",
+			strings.Join(missing, ", "), config.PreflightDegraded)
+	}
+
+	log.Error().
+		Strs("missing", missing).
+		Msg("required binaries not found on PATH; starting in degraded mode, agents and beads operations will fail")
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/intern-village/orchestrator/internal/config"
+)
+
+func TestCheckBinaries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the fake binary")
+	}
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho 'fake 1.2.3'\necho 'second line'\n"
+	if err := os.WriteFile(filepath.Join(dir, "fake-present"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	checks := checkBinaries(context.Background(), []string{"fake-present", "fake-missing"})
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 checks, got %d", len(checks))
+	}
+
+	present := checks[0]
+	if present.path != filepath.Join(dir, "fake-present") {
+		t.Errorf("path = %q, want it resolved in %s", present.path, dir)
+	}
+	if present.version != "fake 1.2.3" {
+		t.Errorf("version = %q, want first line of --version", present.version)
+	}
+
+	if missing := checks[1]; missing.path != "" || missing.version != "" {
+		t.Errorf("missing binary should have no path or version, got %+v", missing)
+	}
+}
+
+func TestRunPreflight_Modes(t *testing.T) {
+	// An empty PATH makes every required binary missing
+	t.Setenv("PATH", t.TempDir())
+
+	if err := runPreflight(config.PreflightStrict); err == nil {
+		t.Error("strict mode should fail when binaries are missing")
+	}
+	if err := runPreflight(config.PreflightDegraded); err != nil {
+		t.Errorf("degraded mode should start anyway, got %v", err)
+	}
+}
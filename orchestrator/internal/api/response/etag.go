@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Version identifies one version of an entity in a response.
+type Version struct {
+	ID        uuid.UUID
+	UpdatedAt time.Time
+}
+
+// ETag returns a weak entity tag for a response built from the given entity
+// versions, in order. It changes whenever an entity is updated, added,
+// removed, or reordered.
+func ETag(versions ...Version) string {
+	h := sha256.New()
+	var ts [8]byte
+	for _, v := range versions {
+		h.Write(v.ID[:])
+		binary.BigEndian.PutUint64(ts[:], uint64(v.UpdatedAt.UnixNano())) //nolint:gosec // only hashed
+		h.Write(ts[:])
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// OKWithETag writes data like OK, tagged with etag. If the request's
+// If-None-Match already lists etag, it writes an empty 304 Not Modified
+// instead. Responses are marked private and must be revalidated before reuse.
+func OKWithETag(w http.ResponseWriter, r *http.Request, etag string, data any) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	OK(w, data)
+}
+
+// ETagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison RFC 9110 requires for If-None-Match.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
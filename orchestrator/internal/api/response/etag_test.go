@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestETag(t *testing.T) {
+	now := time.Now()
+	a := Version{ID: uuid.New(), UpdatedAt: now}
+	b := Version{ID: uuid.New(), UpdatedAt: now}
+
+	if ETag(a, b) != ETag(a, b) {
+		t.Error("ETag should be stable for the same versions")
+	}
+
+	updated := a
+	updated.UpdatedAt = now.Add(time.Microsecond)
+	changes := map[string]string{
+		"updated":   ETag(updated, b),
+		"removed":   ETag(a),
+		"added":     ETag(a, b, Version{ID: uuid.New(), UpdatedAt: now}),
+		"reordered": ETag(b, a),
+	}
+	for name, etag := range changes {
+		if etag == ETag(a, b) {
+			t.Errorf("ETag should change when an entity is %s", name)
+		}
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`"xyz", W/"abc"`, true},
+		{`"xyz"`, false},
+		{"*", true},
+	}
+
+	for _, tt := range tests {
+		if got := ETagMatches(tt.ifNoneMatch, etag); got != tt.want {
+			t.Errorf("ETagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
+
+func TestOKWithETag(t *testing.T) {
+	etag := ETag(Version{ID: uuid.New(), UpdatedAt: time.Now()})
+
+	rec := httptest.NewRecorder()
+	OKWithETag(rec, httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil), etag, map[string]string{"id": "1"})
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Fatalf("first request: status %d with %d body bytes, want 200 with a body", rec.Code, rec.Body.Len())
+	}
+	if got := rec.Header().Get("ETag"); got != etag {
+		t.Errorf("ETag header = %q, want %q", got, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	OKWithETag(rec, req, etag, map[string]string{"id": "1"})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("revalidation: status %d with %d body bytes, want an empty 304", rec.Code, rec.Body.Len())
+	}
+}
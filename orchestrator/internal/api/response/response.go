@@ -12,23 +12,60 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// ErrorCode is a stable, machine-readable identifier included in every error body.
+// Clients should branch on the code rather than the HTTP status alone.
+type ErrorCode string
+
 // ErrorResponse represents a standardized error response.
 type ErrorResponse struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+
+	// Current is the resource as it is now, set on conflicts caused by a
+	// stale update so the client can refresh without a second request.
+	Current any `json:"current,omitempty"`
 }
 
 // Error codes matching the spec.
 const (
-	CodeInvalidRequest = "INVALID_REQUEST"
-	CodeUnauthorized   = "UNAUTHORIZED"
-	CodeForbidden      = "FORBIDDEN"
-	CodeNotFound       = "NOT_FOUND"
-	CodeConflict       = "CONFLICT"
-	CodeUnprocessable  = "UNPROCESSABLE"
-	CodeInternalError  = "INTERNAL_ERROR"
+	CodeInvalidRequest        ErrorCode = "INVALID_REQUEST"
+	CodeUnauthorized          ErrorCode = "UNAUTHORIZED"
+	CodeReauthRequired        ErrorCode = "GITHUB_REAUTH_REQUIRED"
+	CodeForbidden             ErrorCode = "FORBIDDEN"
+	CodeNotFound              ErrorCode = "NOT_FOUND"
+	CodeMethodNotAllowed      ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeConflict              ErrorCode = "CONFLICT"
+	CodeAlreadyExists         ErrorCode = "ALREADY_EXISTS"
+	CodeInvalidTransition     ErrorCode = "INVALID_TRANSITION"
+	CodeUnprocessable         ErrorCode = "UNPROCESSABLE"
+	CodeTooManyConnections    ErrorCode = "TOO_MANY_CONNECTIONS"
+	CodeQuotaExceeded         ErrorCode = "QUOTA_EXCEEDED"
+	CodeInsufficientDiskSpace ErrorCode = "INSUFFICIENT_DISK_SPACE"
+	CodeCloneMissing          ErrorCode = "CLONE_MISSING"
+	CodeInternalError         ErrorCode = "INTERNAL_ERROR"
+	CodeShuttingDown          ErrorCode = "SHUTTING_DOWN"
 )
 
+// AllCodes lists every error code the API can return.
+var AllCodes = []ErrorCode{
+	CodeInvalidRequest,
+	CodeUnauthorized,
+	CodeReauthRequired,
+	CodeForbidden,
+	CodeNotFound,
+	CodeMethodNotAllowed,
+	CodeConflict,
+	CodeAlreadyExists,
+	CodeInvalidTransition,
+	CodeUnprocessable,
+	CodeTooManyConnections,
+	CodeQuotaExceeded,
+	CodeInsufficientDiskSpace,
+	CodeCloneMissing,
+	CodeInternalError,
+	CodeShuttingDown,
+}
+
 // JSON writes a JSON response with the given status code and data.
 func JSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -41,32 +78,62 @@ func JSON(w http.ResponseWriter, status int, data any) {
 }
 
 // Error writes an error response.
-func Error(w http.ResponseWriter, status int, code, message string) {
+func Error(w http.ResponseWriter, status int, code ErrorCode, message string) {
 	JSON(w, status, ErrorResponse{
 		Code:    code,
 		Message: message,
 	})
 }
 
-// ErrorFromDomain converts a domain error to an HTTP error response.
-func ErrorFromDomain(w http.ResponseWriter, err error) {
+// MapDomainError returns the HTTP status and error code for a domain error.
+// Each domain error kind maps to exactly one code; anything else is an internal error.
+func MapDomainError(err error) (int, ErrorCode) {
 	switch {
 	case domain.IsNotFound(err):
-		Error(w, http.StatusNotFound, CodeNotFound, err.Error())
+		return http.StatusNotFound, CodeNotFound
 	case domain.IsConflict(err):
-		Error(w, http.StatusConflict, CodeConflict, err.Error())
+		return http.StatusConflict, CodeConflict
+	case domain.IsAlreadyExists(err):
+		return http.StatusConflict, CodeAlreadyExists
+	case domain.IsInvalidTransition(err):
+		return http.StatusConflict, CodeInvalidTransition
 	case domain.IsForbidden(err):
-		Error(w, http.StatusForbidden, CodeForbidden, err.Error())
+		return http.StatusForbidden, CodeForbidden
 	case domain.IsUnprocessable(err):
-		Error(w, http.StatusUnprocessableEntity, CodeUnprocessable, err.Error())
+		return http.StatusUnprocessableEntity, CodeUnprocessable
 	case domain.IsInvalidInput(err):
-		Error(w, http.StatusBadRequest, CodeInvalidRequest, err.Error())
-	case domain.IsInvalidTransition(err):
-		Error(w, http.StatusConflict, CodeConflict, err.Error())
+		return http.StatusBadRequest, CodeInvalidRequest
+	case domain.IsQuotaExceeded(err):
+		return http.StatusTooManyRequests, CodeQuotaExceeded
+	case domain.IsReauthRequired(err):
+		return http.StatusUnauthorized, CodeReauthRequired
+	case domain.IsInsufficientDiskSpace(err):
+		return http.StatusInsufficientStorage, CodeInsufficientDiskSpace
+	case domain.IsCloneMissing(err):
+		return http.StatusConflict, CodeCloneMissing
 	default:
+		return http.StatusInternalServerError, CodeInternalError
+	}
+}
+
+// ErrorFromDomain converts a domain error to an HTTP error response.
+func ErrorFromDomain(w http.ResponseWriter, err error) {
+	status, code := MapDomainError(err)
+	if code == CodeInternalError {
 		log.Error().Err(err).Msg("internal error")
-		Error(w, http.StatusInternalServerError, CodeInternalError, "internal server error")
+		Error(w, status, code, "internal server error")
+		return
 	}
+	Error(w, status, code, err.Error())
+}
+
+// Stale writes a 409 Conflict error carrying the current state of the resource.
+func Stale(w http.ResponseWriter, message string, current any) {
+	JSON(w, http.StatusConflict, ErrorResponse{
+		Code:    CodeConflict,
+		Message: message,
+		Current: current,
+	})
 }
 
 // BadRequest writes a 400 Bad Request error.
@@ -0,0 +1,122 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+func TestMapDomainError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   ErrorCode
+	}{
+		{"not found", domain.NewNotFoundError("task", "1"), http.StatusNotFound, CodeNotFound},
+		{"conflict", domain.NewConflictError("subtask", "already running"), http.StatusConflict, CodeConflict},
+		{"stale", domain.NewStaleError("subtask", "modified", nil), http.StatusConflict, CodeConflict},
+		{"already exists", fmt.Errorf("project: %w", domain.ErrAlreadyExists), http.StatusConflict, CodeAlreadyExists},
+		{"invalid transition", domain.NewInvalidTransitionError("subtask", "MERGED", "READY", ""), http.StatusConflict, CodeInvalidTransition},
+		{"forbidden", domain.NewForbiddenError("project", "not owner"), http.StatusForbidden, CodeForbidden},
+		{"unprocessable", domain.NewUnprocessableError("subtask", "blocked"), http.StatusUnprocessableEntity, CodeUnprocessable},
+		{"validation", domain.NewValidationError("title", "required"), http.StatusBadRequest, CodeInvalidRequest},
+		{"quota exceeded", domain.NewQuotaExceededError("active tasks", 3), http.StatusTooManyRequests, CodeQuotaExceeded},
+		{"reauth required", fmt.Errorf("push: %w", domain.ErrReauthRequired), http.StatusUnauthorized, CodeReauthRequired},
+		{"insufficient disk space", fmt.Errorf("clone: %w", domain.ErrInsufficientDiskSpace), http.StatusInsufficientStorage, CodeInsufficientDiskSpace},
+		{"clone missing", fmt.Errorf("%w: repair it", domain.ErrCloneMissing), http.StatusConflict, CodeCloneMissing},
+		{"wrapped domain error", fmt.Errorf("start: %w", domain.NewNotFoundError("subtask", "2")), http.StatusNotFound, CodeNotFound},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError, CodeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := MapDomainError(tt.err)
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("MapDomainError() = (%d, %s), want (%d, %s)", status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorFromDomain_Body(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    ErrorCode
+		wantMessage string
+	}{
+		{"domain error message is exposed", domain.NewNotFoundError("task", "1"), CodeNotFound, "task with ID 1 not found"},
+		{"internal error message is hidden", errors.New("pq: connection refused"), CodeInternalError, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ErrorFromDomain(w, tt.err)
+
+			var body ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Code, tt.wantCode)
+			}
+			if body.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", body.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHelpers_IncludeCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		write      func(w http.ResponseWriter)
+		wantStatus int
+		wantCode   ErrorCode
+	}{
+		{"bad request", func(w http.ResponseWriter) { BadRequest(w, "x") }, http.StatusBadRequest, CodeInvalidRequest},
+		{"unauthorized", func(w http.ResponseWriter) { Unauthorized(w, "x") }, http.StatusUnauthorized, CodeUnauthorized},
+		{"forbidden", func(w http.ResponseWriter) { Forbidden(w, "x") }, http.StatusForbidden, CodeForbidden},
+		{"not found", func(w http.ResponseWriter) { NotFound(w, "x") }, http.StatusNotFound, CodeNotFound},
+		{"stale", func(w http.ResponseWriter) { Stale(w, "x", map[string]int{"position": 2}) }, http.StatusConflict, CodeConflict},
+		{"internal", func(w http.ResponseWriter) { InternalError(w, errors.New("x")) }, http.StatusInternalServerError, CodeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.write(w)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAllCodes_Unique(t *testing.T) {
+	seen := make(map[ErrorCode]bool, len(AllCodes))
+	for _, code := range AllCodes {
+		if seen[code] {
+			t.Errorf("duplicate error code %s", code)
+		}
+		seen[code] = true
+	}
+}
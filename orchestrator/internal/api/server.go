@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -21,6 +23,7 @@ import (
 	"github.com/intern-village/orchestrator/internal/api/middleware"
 	"github.com/intern-village/orchestrator/internal/api/response"
 	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/metrics"
 	"github.com/intern-village/orchestrator/internal/repository"
 	"github.com/intern-village/orchestrator/internal/repository/postgres"
 	"github.com/intern-village/orchestrator/internal/service"
@@ -28,19 +31,36 @@ import (
 
 // Server represents the HTTP server.
 type Server struct {
-	router       *chi.Mux
-	httpServer   *http.Server
-	cfg          *config.Config
-	db           *postgres.DB
-	repo         *repository.Repository
-	crypto       *repository.Crypto
-	agentManager *agent.AgentManager
-	syncWorker   *service.SyncWorker
-	eventHub     service.EventHub
+	router        *chi.Mux
+	httpServer    *http.Server
+	metricsServer *http.Server
+	metrics       *metrics.Metrics
+	cfg           *config.Config
+	db            pinger
+	repo          *repository.Repository
+	crypto        *repository.Crypto
+	agentManager  *agent.AgentManager
+	recovery      *agent.Recovery
+	syncWorker    *service.SyncWorker
+	cloneSweeper  *service.CloneSweeper
+	taskArchiver  *service.TaskArchiver
+	mirrorUpdater *service.CloneMirrorUpdater
+	webhooks      *service.WebhookDispatcher
+	eventHub      service.EventHub
+	diskSpace     *service.DiskSpaceGuard
+
+	// recoveryDone is set once startup recovery has finished; readiness waits on it.
+	recoveryDone atomic.Bool
+	// draining is set when shutdown begins; new mutating requests get 503.
+	draining atomic.Bool
 }
 
 // NewServer creates a new HTTP server with all routes configured.
 func NewServer(cfg *config.Config, db *postgres.DB, repo *repository.Repository, crypto *repository.Crypto) (*Server, error) {
+	if err := runPreflight(cfg.PreflightMode); err != nil {
+		return nil, err
+	}
+
 	s := &Server{
 		router: chi.NewRouter(),
 		cfg:    cfg,
@@ -49,6 +69,11 @@ func NewServer(cfg *config.Config, db *postgres.DB, repo *repository.Repository,
 		crypto: crypto,
 	}
 
+	if cfg.MetricsEnabled {
+		s.metrics = metrics.New()
+		s.metrics.RegisterDBPool(db.Pool())
+	}
+
 	s.setupMiddleware()
 	if err := s.setupRoutes(); err != nil {
 		return nil, fmt.Errorf("failed to setup routes: %w", err)
@@ -58,16 +83,55 @@ func NewServer(cfg *config.Config, db *postgres.DB, repo *repository.Repository,
 		Addr:    fmt.Sprintf(":%d", cfg.Port),
 		Handler: s.router,
 		// Extended timeouts to support long-running operations like repo cloning
-		ReadTimeout:  5 * time.Minute,
-		WriteTimeout: 11 * time.Minute, // Slightly longer than longest request timeout (10 min)
+		ReadTimeout: 5 * time.Minute,
+		// Longer than the longest request timeout (checked by config validation)
+		WriteTimeout: time.Duration(cfg.HTTPWriteTimeoutS) * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
 
+	// Serve metrics on a dedicated port if configured
+	if s.metrics != nil && cfg.MetricsPort != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", s.metrics.Handler())
+		s.metricsServer = &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.MetricsPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+
 	// Start sync worker if configured
 	if s.syncWorker != nil {
 		s.syncWorker.Start()
 	}
 
+	// Start idle clone sweeper if configured
+	if s.cloneSweeper != nil {
+		s.cloneSweeper.Start()
+	}
+
+	// Start finished task archiver if configured
+	if s.taskArchiver != nil {
+		s.taskArchiver.Start()
+	}
+
+	// Start clone mirror updater if configured
+	if s.mirrorUpdater != nil {
+		s.mirrorUpdater.Start()
+	}
+
+	// Start webhook delivery
+	if s.webhooks != nil {
+		s.webhooks.Start()
+	}
+
+	// Recover from a previous crash in the background
+	if s.recovery != nil {
+		go s.runRecovery()
+	} else {
+		s.recoveryDone.Store(true)
+	}
+
 	return s, nil
 }
 
@@ -82,20 +146,32 @@ func (s *Server) setupMiddleware() {
 	// Custom request logging with zerolog
 	s.router.Use(middleware.Logger)
 
+	// Request latency and status metrics
+	if s.metrics != nil {
+		s.router.Use(middleware.Metrics(s.metrics))
+	}
+
 	// Panic recovery
 	s.router.Use(chimw.Recoverer)
 
 	// Request timeout (applied selectively, not globally - see setupRoutes)
 
 	// CORS
+	log.Info().
+		Strs("allowed_origins", s.cfg.CORSAllowedOrigins).
+		Msg("configured CORS allowed origins")
 	s.router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:*", "https://localhost:*"},
+		AllowedOrigins:   s.cfg.CORSAllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID", middleware.IdempotencyKeyHeader},
+		ExposedHeaders:   []string{"Link", middleware.IdempotentReplayedHeader},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+
+	// Reject new mutating requests once shutdown has begun (after CORS so
+	// browsers can read the 503)
+	s.router.Use(middleware.Drain(&s.draining))
 }
 
 // setupRoutes configures all API routes.
@@ -110,12 +186,21 @@ func (s *Server) setupRoutes() error {
 
 	// Create event hub for real-time events
 	logger := slog.Default()
-	s.eventHub = service.NewEventHub(s.cfg.EventChannelBuffer, logger)
+	s.eventHub = service.NewEventHubWithMetrics(s.cfg.EventChannelBuffer, logger, s.metrics)
+	s.eventHub.SetReconnectPolicy(service.ReconnectPolicy{
+		Window:      time.Duration(s.cfg.SSEFlapWindowS) * time.Second,
+		MaxConnects: s.cfg.SSEFlapMaxConnects,
+		BaseRetry:   time.Duration(s.cfg.SSERetryMS) * time.Millisecond,
+		MaxRetry:    time.Duration(s.cfg.SSEFlapMaxRetryMS) * time.Millisecond,
+	})
 
 	// Create log tailer for streaming agent logs
 	logTailerConfig := service.LogTailerConfig{
-		PollInterval: time.Duration(s.cfg.LogTailPollMS) * time.Millisecond,
-		MaxLineBytes: s.cfg.LogTailMaxLineBytes,
+		PollInterval:      time.Duration(s.cfg.LogTailPollMS) * time.Millisecond,
+		MaxLineBytes:      s.cfg.LogTailMaxLineBytes,
+		BatchWindow:       time.Duration(s.cfg.LogTailBatchWindowMS) * time.Millisecond,
+		BatchMaxLines:     s.cfg.LogTailBatchMaxLines,
+		MaxLinesPerSecond: s.cfg.LogTailMaxLinesPerSec,
 	}
 	logTailer := service.NewLogTailer(s.eventHub, logTailerConfig, logger)
 
@@ -130,22 +215,61 @@ func (s *Server) setupRoutes() error {
 	if err != nil {
 		return fmt.Errorf("failed to create auth service: %w", err)
 	}
+	authService.SetScopes(s.cfg.GitHubOAuthScopes)
+	proxy := s.cfg.Proxy()
+	authService.SetProxy(proxy)
 
 	githubService := service.NewGitHubService()
 	beadsService := service.NewBeadsService()
-	projectService := service.NewProjectService(s.repo, s.crypto, githubService, beadsService, s.cfg.DataDir)
+	commandTimeout := time.Duration(s.cfg.CommandTimeoutS) * time.Second
+	githubService.SetCommandTimeout(commandTimeout)
+	beadsService.SetCommandTimeout(commandTimeout)
+	githubService.SetProxy(proxy)
+	beadsService.SetProxy(proxy)
+	githubService.SetRepoInfoCacheTTL(time.Duration(s.cfg.GitHubRepoInfoCacheTTLS) * time.Second)
+	dataPaths := s.cfg.DataPaths()
+	s.diskSpace = service.NewDiskSpaceGuard(dataPaths, s.cfg.DataDirMinFreeMB)
+	githubService.SetDiskSpaceGuard(s.diskSpace)
+	beadsService.SetDiskSpaceGuard(s.diskSpace)
+	s.metrics.RegisterDataDirFree(dataPaths.FreeBytes)
+	if s.cfg.CloneMirrors {
+		githubService.SetCloneMirrors(dataPaths.Mirrors(), time.Duration(s.cfg.CloneMirrorMaxAgeM)*time.Minute)
+		if s.cfg.CloneMirrorUpdateIntervalM > 0 {
+			s.mirrorUpdater = service.NewCloneMirrorUpdater(githubService, time.Duration(s.cfg.CloneMirrorUpdateIntervalM)*time.Minute)
+		}
+	}
+	projectService := service.NewProjectService(s.repo, s.crypto, githubService, beadsService, dataPaths)
+	projectService.SetMaxRepoSizeMB(s.cfg.MaxRepoSizeMB)
+	projectService.SetRepoOwnerPolicy(s.cfg.RepoOwnerPolicy(), s.cfg.RepoOwnerRequireMembership)
+	quotaService := service.NewQuotaService(s.repo, service.UserQuotas{
+		MaxProjects:         s.cfg.UserMaxProjects,
+		MaxActiveTasks:      s.cfg.UserMaxActiveTasks,
+		MaxConcurrentAgents: s.cfg.UserMaxConcurrentAgents,
+	})
+	projectService.SetQuotaService(quotaService)
 	dependencyService := service.NewDependencyService(s.repo, s.eventHub)
 	taskService := service.NewTaskService(s.repo, projectService, githubService, beadsService, s.eventHub)
+	taskService.SetQuotaService(quotaService)
+	taskService.SetMaxAttachmentBytes(s.cfg.MaxAttachmentBytes)
 	subtaskService := service.NewSubtaskService(s.repo, taskService, dependencyService, beadsService, projectService, githubService, s.eventHub)
+	auditService := service.NewAuditService(s.repo, projectService)
+	taskService.SetAuditService(auditService)
+	subtaskService.SetAuditService(auditService)
+	subtaskService.SetQuotaService(quotaService)
 	syncService := service.NewSyncService(s.repo, beadsService, subtaskService, dependencyService, taskService)
+	syncService.SetMaxSubtasksPerTask(s.cfg.MaxSubtasksPerTask)
+	idempotencyService := service.NewIdempotencyService(s.repo, time.Duration(s.cfg.IdempotencyKeyTTLH)*time.Hour)
 
 	// Initialize agent components (Phase 7)
-	promptRenderer, err := agent.NewPromptRenderer(s.cfg.DataDir)
+	promptRenderer, err := agent.NewPromptRenderer(dataPaths)
 	if err != nil {
 		return fmt.Errorf("failed to create prompt renderer: %w", err)
 	}
 
-	executor := agent.NewExecutor(s.cfg.DataDir)
+	executor := agent.NewExecutor(dataPaths)
+	executor.SetMaxPromptBytes(int64(s.cfg.MaxPromptBytes))
+	executor.SetMaxAttemptLogs(s.cfg.MaxAttemptLogs)
+	executor.SetProxy(proxy)
 
 	// Create agent loop with service adapters
 	agentLoop := agent.NewAgentLoop(
@@ -163,37 +287,108 @@ func (s *Server) setupRoutes() error {
 		},
 		s.cfg.AgentMaxRetries,
 	)
+	if !s.cfg.AgentBackoffJitter {
+		agentLoop.SetJitter(agent.NoJitter)
+	}
+	agentLoop.SetCompleteEmptyPlans(s.cfg.EmptyPlanAction == config.EmptyPlanDone)
+	agentLoop.SetDiskSpaceGuard(s.diskSpace)
+	agentLoop.SetStallTimeout(time.Duration(s.cfg.AgentStallTimeoutS)*time.Second, s.cfg.AgentStallAction == config.StallRetry)
+	agentLoop.SetTrailerKeys(agent.TrailerKeys{BeadsIssue: s.cfg.PRTrailerBeadsIssueKey, Subtask: s.cfg.PRTrailerSubtaskKey})
 
 	// Create and store agent manager
 	s.agentManager = agent.NewAgentManager(agentLoop, s.repo, projectService, s.crypto, s.eventHub)
+	s.agentManager.SetMetrics(s.metrics)
+	s.agentManager.SetMaxAgentsPerUser(s.cfg.UserMaxConcurrentAgents)
+	quotaService.SetAgentCounter(s.agentManager)
 
 	// Wire agent spawners into services
 	taskService.SetAgentSpawner(s.agentManager)
 	subtaskService.SetWorkerSpawner(s.agentManager)
+	taskService.SetPlanSyncer(syncService)
+
+	// Create recovery for stale agent runs and orphaned worktrees
+	s.recovery = agent.NewRecovery(
+		s.repo,
+		s.agentManager,
+		projectService,
+		newTaskServiceAdapter(taskService),
+		newSubtaskServiceAdapter(subtaskService),
+		beadsService,
+		s.cfg.AgentMaxRetries,
+		agent.RecoveryConfig{
+			PlannerStaleAfter: time.Duration(s.cfg.PlannerStaleCutoffM) * time.Minute,
+			WorkerStaleAfter:  time.Duration(s.cfg.WorkerStaleCutoffM) * time.Minute,
+		},
+	)
 
 	// Create sync worker
 	s.syncWorker = service.NewSyncWorker(
 		syncService,
 		projectService,
 		s.cfg.SyncIntervalSeconds,
+		s.cfg.SyncConcurrency,
+		time.Duration(s.cfg.SyncProjectTimeoutS)*time.Second,
 	)
+	s.syncWorker.SetMetrics(s.metrics)
+
+	// Create idle clone sweeper (disabled when CLONE_SWEEP_IDLE_DAYS is 0)
+	if s.cfg.CloneSweepIdleDays > 0 {
+		s.cloneSweeper = service.NewCloneSweeper(
+			projectService,
+			time.Duration(s.cfg.CloneSweepIdleDays)*24*time.Hour,
+			time.Duration(s.cfg.CloneSweepIntervalM)*time.Minute,
+		)
+	}
+
+	// Create finished task archiver (disabled when TASK_ARCHIVE_AFTER_DAYS is 0)
+	if s.cfg.TaskArchiveAfterDays > 0 {
+		s.taskArchiver = service.NewTaskArchiver(
+			taskService,
+			time.Duration(s.cfg.TaskArchiveAfterDays)*24*time.Hour,
+			s.cfg.TaskArchiveRemoveWorktrees,
+			time.Duration(s.cfg.TaskArchiveIntervalM)*time.Minute,
+		)
+	}
+
+	// Forward project events to configured webhooks
+	webhookService := service.NewWebhookService(s.repo, s.crypto, projectService)
+	s.webhooks = service.NewWebhookDispatcher(webhookService, service.WebhookDispatcherConfig{
+		MaxAttempts: s.cfg.WebhookMaxAttempts,
+		Timeout:     time.Duration(s.cfg.WebhookTimeoutS) * time.Second,
+	})
+	s.eventHub.AddSink(s.webhooks)
 
 	// Create handlers
-	authHandler := handlers.NewAuthHandler(authService, s.cfg)
+	authHandler := handlers.NewAuthHandler(authService, projectService, s.cfg)
 	projectHandler := handlers.NewProjectHandler(projectService, authService)
-	taskHandler := handlers.NewTaskHandler(taskService)
-	subtaskHandler := handlers.NewSubtaskHandler(subtaskService)
-	agentHandler := handlers.NewAgentHandler(s.repo, subtaskService)
+	taskHandler := handlers.NewTaskHandler(taskService, authService)
+	subtaskHandler := handlers.NewSubtaskHandler(subtaskService, authService)
+	agentHandler := handlers.NewAgentHandler(s.repo, subtaskService, taskService, s.eventHub, s.cfg)
 	eventHandler := handlers.NewEventHandler(s.eventHub, s.repo, projectService, s.cfg)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	usageHandler := handlers.NewUsageHandler(quotaService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	adminHandler := handlers.NewAdminHandler(s.repo, s.agentManager)
 
 	// Create auth middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService)
 
-	// Health check (no auth required)
-	s.router.Get("/health", s.handleHealth)
+	// Health checks (no auth required)
+	s.healthRoutes(s.router)
+
+	// Prometheus metrics on the main port (no auth required)
+	if s.metrics != nil && s.cfg.MetricsPort == 0 {
+		s.router.Handle("/metrics", s.metrics.Handler())
+	}
 
 	// API routes
 	s.router.Route("/api", func(r chi.Router) {
+		// JSON error bodies for unknown API routes
+		r.NotFound(apiNotFound)
+		r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+			response.Error(w, http.StatusMethodNotAllowed, response.CodeMethodNotAllowed, "method not allowed")
+		})
+
 		// Auth endpoints (no auth required)
 		r.Route("/auth", func(r chi.Router) {
 			r.Get("/github", authHandler.InitiateOAuth)
@@ -204,87 +399,167 @@ func (s *Server) setupRoutes() error {
 			r.Group(func(r chi.Router) {
 				r.Use(authMiddleware.RequireAuth)
 				r.Get("/me", authHandler.GetCurrentUser)
+				r.Get("/me/usage", usageHandler.GetUsage)
+				r.Post("/reconnect", authHandler.Reconnect)
 			})
 		})
 
-		// Project creation with extended timeout (10 min for cloning large repos)
-		// Defined outside the 60s timeout group to avoid timeout being overridden
-		r.With(authMiddleware.RequireAuth, chimw.Timeout(10*time.Minute)).Post("/projects", projectHandler.Create)
+		requestTimeout := time.Duration(s.cfg.RequestTimeoutS) * time.Second
+		projectCreateTimeout := time.Duration(s.cfg.ProjectCreateTimeoutS) * time.Second
+		taskCreateTimeout := time.Duration(s.cfg.TaskCreateTimeoutS) * time.Second
+
+		// Replay stored results for retried creates carrying an Idempotency-Key;
+		// an in-flight claim lapses after the route's timeout
+		projectCreateIdempotency := middleware.Idempotency(idempotencyService, projectCreateTimeout)
+		taskCreateIdempotency := middleware.Idempotency(idempotencyService, taskCreateTimeout)
+
+		// Project creation and clone repair with extended timeout (cloning large repos)
+		// Defined outside the default timeout group to avoid timeout being overridden
+		r.With(authMiddleware.RequireAuth, chimw.Timeout(projectCreateTimeout), projectCreateIdempotency).Post("/projects", projectHandler.Create)
+		r.With(authMiddleware.RequireAuth, chimw.Timeout(projectCreateTimeout)).Post("/projects/{id}/repair", projectHandler.Repair)
+
+		// Task creation with extended timeout (syncs repo before planning), also
+		// outside the default timeout group
+		r.With(authMiddleware.RequireAuth, chimw.Timeout(taskCreateTimeout), taskCreateIdempotency).Post("/projects/{project_id}/tasks", taskHandler.Create)
 
 		// SSE Events - no timeout middleware (SSE connections are long-lived, managed internally)
-		// Defined outside the 60s timeout group to avoid premature connection termination
+		// Defined outside the default timeout group to avoid premature connection termination
 		r.With(authMiddleware.RequireAuth).Get("/projects/{project_id}/events", eventHandler.StreamEvents)
 
-		// Protected API routes (require auth) with default 60s timeout
+		// Run logs - no timeout middleware (follow=true streams the live tail as SSE,
+		// and a download streams the whole file)
+		r.With(authMiddleware.RequireAuth).Get("/runs/{id}/logs", agentHandler.GetLogs)
+		r.With(authMiddleware.RequireAuth).Get("/runs/{id}/logs/download", agentHandler.DownloadLogs)
+
+		// Protected API routes (require auth) with the default timeout
 		r.Group(func(r chi.Router) {
 			r.Use(authMiddleware.RequireAuth)
 			// Default timeout for most API routes
-			r.Use(chimw.Timeout(60 * time.Second))
+			r.Use(chimw.Timeout(requestTimeout))
 
-			// Projects (Phase 4) - Note: POST /projects is defined above with extended timeout
+			// Projects (Phase 4) - Note: POST /projects and POST /projects/{project_id}/tasks
+			// are defined above with extended timeouts
 			r.Get("/projects", projectHandler.List)
+			r.Post("/projects/cancel", projectHandler.CancelCreate)
 			r.Get("/projects/{id}", projectHandler.Get)
+			r.Patch("/projects/{id}", projectHandler.Update)
 			r.Delete("/projects/{id}", projectHandler.Delete)
 			r.Post("/projects/{id}/cleanup", projectHandler.Cleanup)
+			r.Get("/projects/{id}/usage/disk", projectHandler.DiskUsage)
+			r.Get("/projects/{id}/attention", projectHandler.Attention)
+
+			// Outbound webhooks per project
+			r.Get("/projects/{id}/webhooks", webhookHandler.List)
+			r.Post("/projects/{id}/webhooks", webhookHandler.Create)
+			r.Delete("/projects/{id}/webhooks/{webhook_id}", webhookHandler.Delete)
+
+			// Audit log of user-triggered transitions
+			r.Get("/projects/{id}/audit", auditHandler.List)
 
 			// Tasks under projects (Phase 5)
 			r.Get("/projects/{project_id}/tasks", taskHandler.List)
-			// Extended timeout for task creation (syncs repo before planning)
-			r.With(chimw.Timeout(5 * time.Minute)).Post("/projects/{project_id}/tasks", taskHandler.Create)
 
 			// Active runs endpoint (not SSE, can have normal timeout)
 			r.Get("/projects/{project_id}/active-runs", eventHandler.GetActiveRuns)
 
+			// Operator endpoints across all users (ADMIN_GITHUB_IDS only)
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(middleware.RequireAdmin(s.cfg.AdminGitHubIDs))
+				r.Get("/active-runs", adminHandler.ActiveRuns)
+			})
+
 			// Tasks by ID (Phase 5)
 			r.Route("/tasks", func(r chi.Router) {
 				r.Get("/{id}", taskHandler.Get)
+				r.Get("/{id}/tree", taskHandler.GetTree)
+				r.Get("/{id}/archive", taskHandler.DownloadArchive)
 				r.Delete("/{id}", taskHandler.Delete)
 				r.Post("/{id}/retry-planning", taskHandler.RetryPlanning)
+				r.Post("/{id}/pause", taskHandler.Pause)
+				r.Post("/{id}/resume", taskHandler.Resume)
+				r.Post("/{id}/resync", taskHandler.Resync)
+				r.Post("/{id}/replan", taskHandler.Replan)
+
+				// Dry-run plan review
+				r.Get("/{id}/plan", taskHandler.GetPlan)
+				r.Post("/{id}/plan/confirm", taskHandler.ConfirmPlan)
 
 				// Subtasks under tasks
 				r.Get("/{task_id}/subtasks", subtaskHandler.List)
+
+				// Planner and Worker runs across the task
+				r.Get("/{id}/runs", agentHandler.ListTaskRuns)
+				r.Get("/{id}/usage/timeseries", agentHandler.TaskUsageTimeseries)
 			})
 
 			// Subtasks by ID (Phase 5)
 			r.Route("/subtasks", func(r chi.Router) {
 				r.Get("/{id}", subtaskHandler.Get)
+				r.Get("/{id}/diff", subtaskHandler.Diff)
+				r.Patch("/{id}", subtaskHandler.Update)
 				r.Post("/{id}/start", subtaskHandler.Start)
 				r.Post("/{id}/mark-merged", subtaskHandler.MarkMerged)
 				r.Post("/{id}/retry", subtaskHandler.Retry)
+				r.Post("/{id}/stop", subtaskHandler.Stop)
 				r.Patch("/{id}/position", subtaskHandler.UpdatePosition)
 
 				// Agent runs for subtask (Phase 8)
 				r.Get("/{id}/runs", agentHandler.ListRuns)
+				r.Get("/{id}/stats", agentHandler.SubtaskStats)
 			})
 
-			// Agent runs by ID (Phase 8)
-			r.Route("/runs", func(r chi.Router) {
-				r.Get("/{id}/logs", agentHandler.GetLogs)
-			})
+			// Rendered prompt of an agent run
+			r.Get("/runs/{id}/prompt", agentHandler.GetPrompt)
 		})
 	})
 
 	// Serve frontend SPA for all non-API routes (Phase 9)
 	if frontendHandler != nil {
-		s.router.Handle("/*", frontendHandler)
+		s.router.Handle("/*", spaHandler(frontendHandler))
 	}
 
 	return nil
 }
 
-// handleHealth returns the server health status.
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	// Check database connectivity
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
+// apiNotFound answers an unknown API route with a JSON error body.
+func apiNotFound(w http.ResponseWriter, r *http.Request) {
+	response.NotFound(w, "route not found")
+}
+
+// isAPIPath reports whether path is /api or below it. Paths that merely start
+// with the same letters, like /apiary, belong to the SPA.
+func isAPIPath(path string) bool {
+	return path == "/api" || strings.HasPrefix(path, "/api/")
+}
+
+// spaHandler wraps the SPA catch-all so an API path that reaches it, such as
+// a mistyped route, gets a JSON 404 instead of index.html with a 200. It is
+// the only such guard; FrontendHandler itself does not check for API paths.
+func spaHandler(frontend http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAPIPath(r.URL.Path) {
+			apiNotFound(w, r)
+			return
+		}
+		frontend.ServeHTTP(w, r)
+	})
+}
+
+// runRecovery restarts stale agents and then cleans up orphaned worktrees.
+// Worktree cleanup runs second so it never removes a worktree for a restarted agent.
+func (s *Server) runRecovery() {
+	ctx := context.Background()
 
-	if err := s.db.Ping(ctx); err != nil {
-		log.Error().Err(err).Msg("health check failed: database unreachable")
-		response.Error(w, http.StatusServiceUnavailable, "UNHEALTHY", "database unreachable")
-		return
+	if err := s.recovery.RecoverStaleAgents(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to recover stale agents")
 	}
 
-	response.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	if err := s.recovery.RecoverOrphanedWorktrees(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to recover orphaned worktrees")
+	}
+
+	s.recoveryDone.Store(true)
+	log.Info().Msg("startup recovery complete")
 }
 
 // Start starts the HTTP server.
@@ -293,6 +568,17 @@ func (s *Server) Start() error {
 		Int("port", s.cfg.Port).
 		Msg("starting HTTP server")
 
+	if s.metricsServer != nil {
+		go func() {
+			log.Info().
+				Int("port", s.cfg.MetricsPort).
+				Msg("starting metrics server")
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error().Err(err).Msg("metrics server error")
+			}
+		}()
+	}
+
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("HTTP server error: %w", err)
 	}
@@ -304,11 +590,37 @@ func (s *Server) Start() error {
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Info().Msg("shutting down server components")
 
+	// Stop accepting new work; in-flight requests and agents keep running
+	s.draining.Store(true)
+
+	// Drain SSE connections so they don't hold up the HTTP server shutdown
+	if s.eventHub != nil {
+		s.eventHub.Drain()
+	}
+
 	// Stop sync worker first
 	if s.syncWorker != nil {
 		s.syncWorker.Stop()
 	}
 
+	// Stop idle clone sweeper
+	if s.cloneSweeper != nil {
+		s.cloneSweeper.Stop()
+	}
+	if s.taskArchiver != nil {
+		s.taskArchiver.Stop()
+	}
+
+	// Stop clone mirror updater
+	if s.mirrorUpdater != nil {
+		s.mirrorUpdater.Stop()
+	}
+
+	// Stop webhook delivery
+	if s.webhooks != nil {
+		s.webhooks.Stop()
+	}
+
 	// Stop agent manager (waits for running agents)
 	if s.agentManager != nil {
 		if err := s.agentManager.Shutdown(ctx); err != nil {
@@ -316,6 +628,13 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Stop metrics server
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			log.Error().Err(err).Msg("error shutting down metrics server")
+		}
+	}
+
 	// Finally, shut down HTTP server
 	log.Info().Msg("shutting down HTTP server")
 	return s.httpServer.Shutdown(ctx)
@@ -0,0 +1,182 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/intern-village/orchestrator/internal/api/response"
+	"github.com/intern-village/orchestrator/internal/config"
+)
+
+func TestSPAHandler_APINotFound(t *testing.T) {
+	frontend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<!doctype html><div id=root></div>"))
+	})
+
+	// Mirror setupRoutes: an /api subrouter with its own NotFound, then the
+	// SPA catch-all
+	router := chi.NewRouter()
+	router.Route("/api", func(r chi.Router) {
+		r.NotFound(apiNotFound)
+		r.Get("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+			response.OK(w, map[string]string{"id": chi.URLParam(r, "id")})
+		})
+	})
+	router.Handle("/*", spaHandler(frontend))
+
+	tests := []struct {
+		name     string
+		target   http.Handler
+		path     string
+		wantCode int
+		wantJSON bool
+	}{
+		{name: "known API route", target: router, path: "/api/tasks/123", wantCode: http.StatusOK, wantJSON: true},
+		{name: "mistyped API route", target: router, path: "/api/tasksss/123", wantCode: http.StatusNotFound, wantJSON: true},
+		{name: "API root", target: router, path: "/api", wantCode: http.StatusNotFound, wantJSON: true},
+		{name: "SPA route", target: router, path: "/projects/123", wantCode: http.StatusOK},
+		{name: "SPA route sharing the prefix", target: router, path: "/apiary", wantCode: http.StatusOK},
+		// The guard holds even when an API path reaches the catch-all directly
+		{name: "API path at the catch-all", target: spaHandler(frontend), path: "/api/tasksss/123", wantCode: http.StatusNotFound, wantJSON: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.target.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantCode)
+			}
+			contentType := rec.Header().Get("Content-Type")
+			if isJSON := strings.HasPrefix(contentType, "application/json"); isJSON != tt.wantJSON {
+				t.Fatalf("GET %s Content-Type = %q, want JSON %v", tt.path, contentType, tt.wantJSON)
+			}
+			if !tt.wantJSON || rec.Code == http.StatusOK {
+				return
+			}
+			var body response.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("GET %s body is not JSON: %v", tt.path, err)
+			}
+			if body.Code != response.CodeNotFound {
+				t.Errorf("GET %s code = %q, want %q", tt.path, body.Code, response.CodeNotFound)
+			}
+		})
+	}
+}
+
+// fakePinger is a database that is always reachable.
+type fakePinger struct{}
+
+func (fakePinger) Ping(context.Context) error { return nil }
+
+// pathWithBinaries points PATH at a directory holding stubs of the given
+// required binaries, so the others are missing.
+func pathWithBinaries(t *testing.T, names ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+// getHealth requests path from a router with the health routes and returns
+// the status code and decoded readiness body.
+func getHealth(t *testing.T, s *Server, path string) (int, ReadinessResponse) {
+	t.Helper()
+	router := chi.NewRouter()
+	s.healthRoutes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var body ReadinessResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("GET %s body is not JSON: %v", path, err)
+	}
+	return rec.Code, body
+}
+
+func TestHealth_Recovery(t *testing.T) {
+	pathWithBinaries(t, requiredBinaries...)
+	s := &Server{cfg: &config.Config{}, db: fakePinger{}}
+
+	// Liveness does not wait for recovery
+	if code, body := getHealth(t, s, "/health/live"); code != http.StatusOK || body.Status != healthOK {
+		t.Errorf("live during recovery = %d %q, want 200 ok", code, body.Status)
+	}
+	for _, path := range []string{"/health/ready", "/health"} {
+		code, body := getHealth(t, s, path)
+		if code != http.StatusServiceUnavailable || body.Checks["recovery"] != "recovery in progress" {
+			t.Errorf("%s during recovery = %d %v, want 503 with recovery in progress", path, code, body.Checks)
+		}
+	}
+
+	s.recoveryDone.Store(true)
+	for _, path := range []string{"/health/ready", "/health"} {
+		if code, body := getHealth(t, s, path); code != http.StatusOK || body.Status != healthOK {
+			t.Errorf("%s after recovery = %d %q, want 200 ok", path, code, body.Status)
+		}
+	}
+
+	s.draining.Store(true)
+	for _, path := range []string{"/health/ready", "/health"} {
+		code, body := getHealth(t, s, path)
+		if code != http.StatusServiceUnavailable || body.Checks["shutdown"] != "draining" {
+			t.Errorf("%s while draining = %d %v, want 503 draining", path, code, body.Checks)
+		}
+	}
+	if code, _ := getHealth(t, s, "/health/live"); code != http.StatusOK {
+		t.Errorf("live while draining = %d, want 200", code)
+	}
+}
+
+func TestHealth_MissingBinary(t *testing.T) {
+	pathWithBinaries(t, "git", "bd")
+
+	tests := []struct {
+		name        string
+		checkBinary bool
+		wantCode    int
+		wantStatus  string
+	}{
+		{name: "reported as degraded", wantCode: http.StatusOK, wantStatus: healthDegraded},
+		{name: "required by HEALTH_CHECK_BINARIES", checkBinary: true, wantCode: http.StatusServiceUnavailable, wantStatus: healthUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{cfg: &config.Config{HealthCheckBinaries: tt.checkBinary}, db: fakePinger{}}
+			s.recoveryDone.Store(true)
+
+			readyCode, ready := getHealth(t, s, "/health/ready")
+			if readyCode != tt.wantCode || ready.Status != tt.wantStatus {
+				t.Errorf("ready = %d %q, want %d %q", readyCode, ready.Status, tt.wantCode, tt.wantStatus)
+			}
+			if ready.Checks["binary:claude"] != "not found on PATH" || ready.Checks["binary:git"] != healthOK {
+				t.Errorf("binary checks = %v, want only claude missing", ready.Checks)
+			}
+
+			// /health is an alias of /health/ready
+			code, body := getHealth(t, s, "/health")
+			if code != readyCode || body.Status != ready.Status || len(body.Checks) != len(ready.Checks) {
+				t.Errorf("/health = %d %+v, want it to match /health/ready %d %+v", code, body, readyCode, ready)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package cmdexec
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_ReturnsOutput(t *testing.T) {
+	out, err := New("sh").Run(context.Background(), t.TempDir(), "sh", "-c", "echo out; echo err >&2")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(out, "out") || !strings.Contains(out, "err") {
+		t.Errorf("Run() output = %q, want stdout and stderr", out)
+	}
+}
+
+func TestRun_FailureCarriesExitCodeAndRedactedOutput(t *testing.T) {
+	token := "ghs_" + strings.Repeat("a", 36)
+	url := "https://x-access-token:" + token + "@github.com/o/r.git"
+
+	out, err := New("sh").Run(context.Background(), "", "sh", "-c", "echo fatal: "+url+"; exit 3", url)
+	var cmdErr *Error
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Run() error = %v, want *Error", err)
+	}
+	if cmdErr.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", cmdErr.ExitCode)
+	}
+	if !strings.Contains(out, token) {
+		t.Errorf("returned output should be unredacted, got %q", out)
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("error leaks the token: %v", err)
+	}
+	if !strings.Contains(err.Error(), "https://[REDACTED]@github.com/o/r.git") {
+		t.Errorf("error should quote the redacted URL, got %v", err)
+	}
+}
+
+func TestRun_RejectsCommandsNotAllowed(t *testing.T) {
+	_, err := New("git").Run(context.Background(), "", "sh", "-c", "true")
+	if !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Run() error = %v, want ErrNotAllowed", err)
+	}
+}
+
+func TestRun_MissingBinary(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	_, err := New("git").Run(context.Background(), "", "git", "status")
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("Run() error = %v, want exec.ErrNotFound", err)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	r := New("sleep")
+	r.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := r.Run(context.Background(), "", "sleep", "5")
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Run() error = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run() took %s, want it killed at the timeout", elapsed)
+	}
+}
+
+func TestRun_CapsOutput(t *testing.T) {
+	r := New("sh")
+	r.MaxOutputBytes = 10
+
+	out, err := r.Run(context.Background(), "", "sh", "-c", "printf '%0100d' 0")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(out) != 10 {
+		t.Errorf("len(output) = %d, want 10", len(out))
+	}
+}
+
+func TestRun_Env(t *testing.T) {
+	t.Setenv("CMDEXEC_INHERITED", "kept")
+	t.Setenv("CMDEXEC_OVERRIDDEN", "old")
+	r := New("sh")
+	r.Env = []string{"CMDEXEC_OVERRIDDEN=new"}
+
+	out, err := r.Run(context.Background(), "", "sh", "-c", "echo $CMDEXEC_INHERITED $CMDEXEC_OVERRIDDEN")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := strings.TrimSpace(out); got != "kept new" {
+		t.Errorf("Run() output = %q, want %q", got, "kept new")
+	}
+}
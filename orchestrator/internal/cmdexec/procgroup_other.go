@@ -0,0 +1,12 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+//go:build !unix
+
+package cmdexec
+
+import "os/exec"
+
+// killProcessGroup is not implemented on this platform; only the command
+// itself is killed when its context is done.
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,37 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+//go:build unix
+
+package cmdexec
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun_CancelKillsChildren(t *testing.T) {
+	// The background subshell stands in for git's remote helper: it outlives
+	// its parent unless the whole process group is killed.
+	marker := filepath.Join(t.TempDir(), "alive")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := New("sh").Run(ctx, "", "sh", "-c", "(sleep 0.5; touch "+marker+") & wait")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("Run() took %s, want it to return on cancel", elapsed)
+	}
+
+	time.Sleep(time.Second)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("child process kept running after the context was canceled")
+	}
+}
@@ -6,8 +6,38 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/kelseyhightower/envconfig"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/netproxy"
+)
+
+// Actions (EMPTY_PLAN_ACTION) for a Planner run that creates no subtasks.
+const (
+	// EmptyPlanFail moves the task to PLANNING_FAILED so it can be re-planned.
+	EmptyPlanFail = "fail"
+	// EmptyPlanDone moves the task to DONE.
+	EmptyPlanDone = "done"
+)
+
+// Actions (AGENT_STALL_ACTION) for a Worker whose log has gone quiet for
+// AGENT_STALL_TIMEOUT_S.
+const (
+	// StallNotify publishes agent:stalled and lets the run continue.
+	StallNotify = "notify"
+	// StallRetry publishes agent:stalled, cancels the attempt, and retries it.
+	StallRetry = "retry"
+)
+
+// Startup preflight modes (PREFLIGHT_MODE) for missing git, bd, or claude binaries.
+const (
+	// PreflightStrict refuses to start.
+	PreflightStrict = "strict"
+	// PreflightDegraded logs the missing binaries and starts anyway; the
+	// readiness probe reports the server as degraded.
+	PreflightDegraded = "degraded"
 )
 
 // Config holds all configuration values for the orchestrator.
@@ -19,30 +49,162 @@ type Config struct {
 	// GitHub OAuth
 	GitHubClientID     string `envconfig:"GITHUB_CLIENT_ID" required:"true"`
 	GitHubClientSecret string `envconfig:"GITHUB_CLIENT_SECRET" required:"true"`
+	// Scopes requested at login (comma-separated); users must log in again to grant changed scopes
+	GitHubOAuthScopes []string `envconfig:"GITHUB_OAUTH_SCOPES" default:"read:user,repo"`
 
 	// Security
 	JWTSecret     string `envconfig:"JWT_SECRET" required:"true"`
 	EncryptionKey string `envconfig:"ENCRYPTION_KEY" required:"true"`
+	// Previous encryption keys (comma-separated), kept for decrypting tokens during key rotation
+	EncryptionKeysOld []string `envconfig:"ENCRYPTION_KEYS_OLD"`
 
 	// Directories
 	DataDir    string `envconfig:"DATA_DIR" default:"/data"`
 	PromptsDir string `envconfig:"PROMPTS_DIR" default:"./prompts"`
+	// Root for subtask worktrees, laid out as {WorktreeDir}/{projectID}/{subtaskID}.
+	// Empty means {DataDir}/worktrees.
+	WorktreeDir string `envconfig:"WORKTREE_DIR"`
+	// Free space DATA_DIR must have at startup; 0 disables the check
+	DataDirMinFreeMB int `envconfig:"DATA_DIR_MIN_FREE_MB" default:"1024"`
 
 	// Server
 	Port     int    `envconfig:"PORT" default:"8080"`
 	LogLevel string `envconfig:"LOG_LEVEL" default:"info"`
+	// Seconds an API request may take: most routes, project creation and clone
+	// repair, and task creation. The server's write timeout must exceed the longest.
+	RequestTimeoutS       int `envconfig:"REQUEST_TIMEOUT_S" default:"60"`
+	ProjectCreateTimeoutS int `envconfig:"PROJECT_CREATE_TIMEOUT_S" default:"600"`
+	TaskCreateTimeoutS    int `envconfig:"TASK_CREATE_TIMEOUT_S" default:"300"`
+	HTTPWriteTimeoutS     int `envconfig:"HTTP_WRITE_TIMEOUT_S" default:"660"`
+
+	// CORS (comma-separated origins, e.g. "https://app.example.com,http://localhost:*")
+	CORSAllowedOrigins []string `envconfig:"CORS_ALLOWED_ORIGINS" default:"http://localhost:*,https://localhost:*"`
 
 	// Agent settings
 	AgentMaxRetries     int `envconfig:"AGENT_MAX_RETRIES" default:"10"`
 	SyncIntervalSeconds int `envconfig:"SYNC_INTERVAL_SECONDS" default:"30"`
+	// Projects the periodic sync works on at once, and seconds each may take per cycle
+	SyncConcurrency     int `envconfig:"SYNC_CONCURRENCY" default:"4"`
+	SyncProjectTimeoutS int `envconfig:"SYNC_PROJECT_TIMEOUT_S" default:"60"`
+	// Add random jitter to Worker retry backoffs; disable for deterministic timing
+	AgentBackoffJitter bool `envconfig:"AGENT_BACKOFF_JITTER" default:"true"`
+	// What a Planner run that creates no subtasks does to its task: "fail"
+	// moves it to PLANNING_FAILED to be re-planned, "done" completes it
+	EmptyPlanAction string `envconfig:"EMPTY_PLAN_ACTION" default:"fail"`
+	// Seconds a Worker's log may go unwritten before the run is reported
+	// stalled (0 disables the check), and whether to "notify" only or also
+	// cancel and "retry" the attempt
+	AgentStallTimeoutS int    `envconfig:"AGENT_STALL_TIMEOUT_S" default:"0"`
+	AgentStallAction   string `envconfig:"AGENT_STALL_ACTION" default:"notify"`
+	// Trailer keys ending Worker PR bodies and squashed commits, linking them
+	// to their beads issue and subtask; empty leaves a trailer out
+	PRTrailerBeadsIssueKey string `envconfig:"PR_TRAILER_BEADS_ISSUE_KEY" default:"Beads-Issue"`
+	PRTrailerSubtaskKey    string `envconfig:"PR_TRAILER_SUBTASK_KEY" default:"Intern-Subtask"`
+	// Most subtasks a plan may create; a project can override it, 0 disables the limit
+	MaxSubtasksPerTask int `envconfig:"MAX_SUBTASKS_PER_TASK" default:"50"`
+	// Largest prompt (bytes) piped to the Claude CLI; 0 disables the limit
+	MaxPromptBytes int `envconfig:"MAX_PROMPT_BYTES" default:"1048576"`
+	// Attempt logs (run-NNN.log) kept per subtask or Planner; older ones are pruned, 0 keeps all
+	MaxAttemptLogs int `envconfig:"MAX_ATTEMPT_LOGS" default:"20"`
+	// Largest file (bytes) a task may attach for the Planner
+	MaxAttachmentBytes int `envconfig:"MAX_ATTACHMENT_BYTES" default:"131072"`
+	// Seconds a git or bd command may run before it is killed
+	CommandTimeoutS int `envconfig:"COMMAND_TIMEOUT_S" default:"900"`
+
+	// Outbound proxy for GitHub API calls and the git, bd, and claude
+	// subprocesses; unset leaves the process environment (HTTP_PROXY etc.) in charge.
+	// The HTTPS proxy defaults to the HTTP one.
+	OutboundHTTPProxy  string `envconfig:"OUTBOUND_HTTP_PROXY"`
+	OutboundHTTPSProxy string `envconfig:"OUTBOUND_HTTPS_PROXY"`
+	OutboundNoProxy    string `envconfig:"OUTBOUND_NO_PROXY"`
+
+	// Recovery settings (minutes without log activity before a RUNNING run is considered stale)
+	PlannerStaleCutoffM int `envconfig:"PLANNER_STALE_CUTOFF_M" default:"5"`
+	WorkerStaleCutoffM  int `envconfig:"WORKER_STALE_CUTOFF_M" default:"15"`
 
 	// SSE settings
 	SSEHeartbeatIntervalS     int `envconfig:"SSE_HEARTBEAT_INTERVAL_S" default:"30"`
 	SSEConnectionTimeoutM     int `envconfig:"SSE_CONNECTION_TIMEOUT_M" default:"60"`
 	SSEMaxConnectionsPerUser  int `envconfig:"SSE_MAX_CONNECTIONS_PER_USER" default:"5"`
+	SSERetryMS                int `envconfig:"SSE_RETRY_MS" default:"3000"`
+	// A user opening more than SSEFlapMaxConnects streams within SSEFlapWindowS
+	// is backed off with a doubling retry hint capped at SSEFlapMaxRetryMS,
+	// and refused with 429 past twice the limit. 0 disables flap detection.
+	SSEFlapWindowS     int `envconfig:"SSE_FLAP_WINDOW_S" default:"60"`
+	SSEFlapMaxConnects int `envconfig:"SSE_FLAP_MAX_CONNECTS" default:"20"`
+	SSEFlapMaxRetryMS  int `envconfig:"SSE_FLAP_MAX_RETRY_MS" default:"60000"`
 	EventChannelBuffer        int `envconfig:"EVENT_CHANNEL_BUFFER" default:"100"`
 	LogTailPollMS             int `envconfig:"LOG_TAIL_POLL_MS" default:"100"`
 	LogTailMaxLineBytes       int `envconfig:"LOG_TAIL_MAX_LINE_BYTES" default:"1048576"`
+	// LogTailBatchWindowMS > 0 coalesces agent log lines into agent:log_batch
+	// events of at most LogTailBatchMaxLines lines, each held that long at most.
+	LogTailBatchWindowMS int `envconfig:"LOG_TAIL_BATCH_WINDOW_MS" default:"0"`
+	LogTailBatchMaxLines int `envconfig:"LOG_TAIL_BATCH_MAX_LINES" default:"100"`
+	// LogTailMaxLinesPerSec caps the log lines of one run streamed per second;
+	// lines over it are skipped with an agent:log_throttled notice. 0 disables it.
+	LogTailMaxLinesPerSec int `envconfig:"LOG_TAIL_MAX_LINES_PER_SEC" default:"500"`
+
+	// Clone sweep settings
+	// CloneSweepIdleDays of 0 disables the background sweep of idle project clones.
+	CloneSweepIdleDays  int `envconfig:"CLONE_SWEEP_IDLE_DAYS" default:"0"`
+	CloneSweepIntervalM int `envconfig:"CLONE_SWEEP_INTERVAL_M" default:"60"`
+	// CloneMirrors keeps a shared bare mirror of each cloned upstream under
+	// {DATA_DIR}/mirrors and clones with --reference to it. A mirror older than
+	// CloneMirrorMaxAgeM is refreshed before use; mirrors are also refreshed in
+	// the background every CloneMirrorUpdateIntervalM (0 = only before use).
+	CloneMirrors               bool `envconfig:"CLONE_MIRRORS" default:"false"`
+	CloneMirrorMaxAgeM         int  `envconfig:"CLONE_MIRROR_MAX_AGE_M" default:"60"`
+	CloneMirrorUpdateIntervalM int  `envconfig:"CLONE_MIRROR_UPDATE_INTERVAL_M" default:"30"`
+
+	// Task retention settings
+	// TaskArchiveAfterDays of 0 disables archiving DONE and CANCELLED tasks;
+	// TaskArchiveRemoveWorktrees also removes worktrees their subtasks left behind.
+	TaskArchiveAfterDays       int  `envconfig:"TASK_ARCHIVE_AFTER_DAYS" default:"0"`
+	TaskArchiveIntervalM       int  `envconfig:"TASK_ARCHIVE_INTERVAL_M" default:"60"`
+	TaskArchiveRemoveWorktrees bool `envconfig:"TASK_ARCHIVE_REMOVE_WORKTREES" default:"true"`
+
+	// Largest repository (GitHub-reported size, MB) a project may fork and clone; 0 disables the check
+	MaxRepoSizeMB int `envconfig:"MAX_REPO_SIZE_MB" default:"2048"`
+
+	// Repository owners projects may be created from (comma-separated,
+	// case-insensitive, * and ? wildcards); empty allows every owner. Denied
+	// owners are refused even if allowed. With RepoOwnerRequireMembership the
+	// owner must also be the user or an organization they are a member of.
+	AllowedRepoOwners          []string `envconfig:"ALLOWED_REPO_OWNERS"`
+	DeniedRepoOwners           []string `envconfig:"DENIED_REPO_OWNERS"`
+	RepoOwnerRequireMembership bool     `envconfig:"REPO_OWNER_REQUIRE_MEMBERSHIP" default:"false"`
+
+	// Seconds a GitHub repository lookup is reused for the same user; 0 disables the cache
+	GitHubRepoInfoCacheTTLS int `envconfig:"GITHUB_REPO_INFO_CACHE_TTL_S" default:"0"`
+
+	// Per-user quotas for shared deployments; 0 disables a quota
+	UserMaxProjects         int `envconfig:"USER_MAX_PROJECTS" default:"0"`
+	UserMaxActiveTasks      int `envconfig:"USER_MAX_ACTIVE_TASKS" default:"0"`
+	UserMaxConcurrentAgents int `envconfig:"USER_MAX_CONCURRENT_AGENTS" default:"0"`
+
+	// Idempotency settings (hours an Idempotency-Key result is kept for replay)
+	IdempotencyKeyTTLH int `envconfig:"IDEMPOTENCY_KEY_TTL_H" default:"24"`
+
+	// Webhook settings (attempts include the first delivery; retries back off exponentially)
+	WebhookMaxAttempts int `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"5"`
+	WebhookTimeoutS    int `envconfig:"WEBHOOK_TIMEOUT_S" default:"10"`
+
+	// Admin settings
+	// AdminGitHubIDs are the numeric GitHub user IDs allowed to call /api/admin
+	// endpoints. IDs are used rather than usernames because usernames can be
+	// renamed and reclaimed. Empty disables the admin API.
+	AdminGitHubIDs []int64 `envconfig:"ADMIN_GITHUB_IDS"`
+
+	// Health settings
+	// HealthCheckBinaries makes /health/ready also require git, bd, and claude on PATH.
+	HealthCheckBinaries bool `envconfig:"HEALTH_CHECK_BINARIES" default:"false"`
+	// PreflightMode decides whether a missing binary at startup is fatal.
+	PreflightMode string `envconfig:"PREFLIGHT_MODE" default:"strict"`
+
+	// Metrics settings
+	// MetricsPort of 0 serves /metrics on the main server port.
+	MetricsEnabled bool `envconfig:"METRICS_ENABLED" default:"true"`
+	MetricsPort    int  `envconfig:"METRICS_PORT" default:"0"`
 }
 
 // Load reads configuration from environment variables.
@@ -53,6 +215,12 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to process config: %w", err)
 	}
 
+	cfg.CORSAllowedOrigins = trimList(cfg.CORSAllowedOrigins)
+	cfg.GitHubOAuthScopes = trimList(cfg.GitHubOAuthScopes)
+	cfg.EncryptionKeysOld = trimList(cfg.EncryptionKeysOld)
+	cfg.AllowedRepoOwners = trimList(cfg.AllowedRepoOwners)
+	cfg.DeniedRepoOwners = trimList(cfg.DeniedRepoOwners)
+
 	if err := cfg.validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -66,10 +234,68 @@ func (c *Config) validate() error {
 		return fmt.Errorf("ENCRYPTION_KEY must be exactly 32 bytes for AES-256")
 	}
 
+	for _, key := range c.EncryptionKeysOld {
+		if len(key) != 32 {
+			return fmt.Errorf("ENCRYPTION_KEYS_OLD entries must be exactly 32 bytes for AES-256")
+		}
+	}
+
+	if c.DataDirMinFreeMB < 0 {
+		return fmt.Errorf("DATA_DIR_MIN_FREE_MB must not be negative")
+	}
+
 	if c.Port < 1 || c.Port > 65535 {
 		return fmt.Errorf("PORT must be between 1 and 65535")
 	}
 
+	if err := c.validateTimeouts(); err != nil {
+		return err
+	}
+
+	if c.PlannerStaleCutoffM < 1 {
+		return fmt.Errorf("PLANNER_STALE_CUTOFF_M must be at least 1")
+	}
+
+	if c.WorkerStaleCutoffM < 1 {
+		return fmt.Errorf("WORKER_STALE_CUTOFF_M must be at least 1")
+	}
+
+	if len(c.GitHubOAuthScopes) == 0 {
+		return fmt.Errorf("GITHUB_OAUTH_SCOPES must contain at least one scope")
+	}
+
+	if err := validateCORSOrigins(c.CORSAllowedOrigins); err != nil {
+		return err
+	}
+
+	if c.SSERetryMS < 1 {
+		return fmt.Errorf("SSE_RETRY_MS must be at least 1")
+	}
+
+	if c.SSEFlapMaxConnects < 0 {
+		return fmt.Errorf("SSE_FLAP_MAX_CONNECTS must not be negative")
+	}
+
+	if c.SSEFlapWindowS < 1 {
+		return fmt.Errorf("SSE_FLAP_WINDOW_S must be at least 1")
+	}
+
+	if c.SSEFlapMaxRetryMS < c.SSERetryMS {
+		return fmt.Errorf("SSE_FLAP_MAX_RETRY_MS must be at least SSE_RETRY_MS")
+	}
+
+	if c.LogTailBatchWindowMS < 0 {
+		return fmt.Errorf("LOG_TAIL_BATCH_WINDOW_MS must not be negative")
+	}
+
+	if c.LogTailBatchMaxLines < 1 {
+		return fmt.Errorf("LOG_TAIL_BATCH_MAX_LINES must be at least 1")
+	}
+
+	if c.LogTailMaxLinesPerSec < 0 {
+		return fmt.Errorf("LOG_TAIL_MAX_LINES_PER_SEC must not be negative")
+	}
+
 	if c.AgentMaxRetries < 1 {
 		return fmt.Errorf("AGENT_MAX_RETRIES must be at least 1")
 	}
@@ -78,5 +304,208 @@ func (c *Config) validate() error {
 		return fmt.Errorf("SYNC_INTERVAL_SECONDS must be at least 1")
 	}
 
+	if c.SyncConcurrency < 1 {
+		return fmt.Errorf("SYNC_CONCURRENCY must be at least 1")
+	}
+
+	if c.SyncProjectTimeoutS < 1 {
+		return fmt.Errorf("SYNC_PROJECT_TIMEOUT_S must be at least 1")
+	}
+
+	if c.MaxSubtasksPerTask < 0 {
+		return fmt.Errorf("MAX_SUBTASKS_PER_TASK must not be negative")
+	}
+
+	if c.MaxPromptBytes < 0 {
+		return fmt.Errorf("MAX_PROMPT_BYTES must not be negative")
+	}
+
+	if c.MaxAttemptLogs < 0 {
+		return fmt.Errorf("MAX_ATTEMPT_LOGS must not be negative")
+	}
+
+	if c.CloneSweepIdleDays < 0 {
+		return fmt.Errorf("CLONE_SWEEP_IDLE_DAYS must not be negative")
+	}
+
+	if c.CloneSweepIntervalM < 1 {
+		return fmt.Errorf("CLONE_SWEEP_INTERVAL_M must be at least 1")
+	}
+
+	if c.TaskArchiveAfterDays < 0 {
+		return fmt.Errorf("TASK_ARCHIVE_AFTER_DAYS must not be negative")
+	}
+
+	if c.TaskArchiveIntervalM < 1 {
+		return fmt.Errorf("TASK_ARCHIVE_INTERVAL_M must be at least 1")
+	}
+
+	if c.CloneMirrorMaxAgeM < 1 {
+		return fmt.Errorf("CLONE_MIRROR_MAX_AGE_M must be at least 1")
+	}
+
+	if c.CloneMirrorUpdateIntervalM < 0 {
+		return fmt.Errorf("CLONE_MIRROR_UPDATE_INTERVAL_M must not be negative")
+	}
+
+	if c.MaxRepoSizeMB < 0 {
+		return fmt.Errorf("MAX_REPO_SIZE_MB must not be negative")
+	}
+
+	if err := c.RepoOwnerPolicy().Validate(); err != nil {
+		return fmt.Errorf("ALLOWED_REPO_OWNERS or DENIED_REPO_OWNERS: %w", err)
+	}
+
+	if c.GitHubRepoInfoCacheTTLS < 0 {
+		return fmt.Errorf("GITHUB_REPO_INFO_CACHE_TTL_S must not be negative")
+	}
+
+	if c.UserMaxProjects < 0 || c.UserMaxActiveTasks < 0 || c.UserMaxConcurrentAgents < 0 {
+		return fmt.Errorf("USER_MAX_PROJECTS, USER_MAX_ACTIVE_TASKS, and USER_MAX_CONCURRENT_AGENTS must not be negative")
+	}
+
+	if c.IdempotencyKeyTTLH < 1 {
+		return fmt.Errorf("IDEMPOTENCY_KEY_TTL_H must be at least 1")
+	}
+
+	if c.MaxAttachmentBytes < 1 || c.MaxAttachmentBytes > 1<<20 {
+		return fmt.Errorf("MAX_ATTACHMENT_BYTES must be between 1 and 1048576")
+	}
+
+	if c.CommandTimeoutS < 1 {
+		return fmt.Errorf("COMMAND_TIMEOUT_S must be at least 1")
+	}
+
+	if err := c.Proxy().Validate(); err != nil {
+		return fmt.Errorf("OUTBOUND_HTTP_PROXY/OUTBOUND_HTTPS_PROXY: %w", err)
+	}
+
+	if c.WebhookMaxAttempts < 1 {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+
+	if c.WebhookTimeoutS < 1 {
+		return fmt.Errorf("WEBHOOK_TIMEOUT_S must be at least 1")
+	}
+
+	for _, id := range c.AdminGitHubIDs {
+		if id < 1 {
+			return fmt.Errorf("ADMIN_GITHUB_IDS must contain positive GitHub user IDs")
+		}
+	}
+
+	if c.EmptyPlanAction != EmptyPlanFail && c.EmptyPlanAction != EmptyPlanDone {
+		return fmt.Errorf("EMPTY_PLAN_ACTION must be %s or %s", EmptyPlanFail, EmptyPlanDone)
+	}
+
+	if c.AgentStallTimeoutS < 0 {
+		return fmt.Errorf("AGENT_STALL_TIMEOUT_S must be non-negative")
+	}
+	if c.AgentStallAction != StallNotify && c.AgentStallAction != StallRetry {
+		return fmt.Errorf("AGENT_STALL_ACTION must be %s or %s", StallNotify, StallRetry)
+	}
+
+	if !validTrailerKey(c.PRTrailerBeadsIssueKey) {
+		return fmt.Errorf("PR_TRAILER_BEADS_ISSUE_KEY must be letters, digits, and hyphens, not starting with a hyphen")
+	}
+	if !validTrailerKey(c.PRTrailerSubtaskKey) {
+		return fmt.Errorf("PR_TRAILER_SUBTASK_KEY must be letters, digits, and hyphens, not starting with a hyphen")
+	}
+
+	if c.PreflightMode != PreflightStrict && c.PreflightMode != PreflightDegraded {
+		return fmt.Errorf("PREFLIGHT_MODE must be %s or %s", PreflightStrict, PreflightDegraded)
+	}
+
+	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
+		return fmt.Errorf("METRICS_PORT must be between 0 and 65535")
+	}
+
+	if c.MetricsPort != 0 && c.MetricsPort == c.Port {
+		return fmt.Errorf("METRICS_PORT must differ from PORT")
+	}
+
 	return nil
 }
+
+// validateTimeouts checks the request timeouts, and that the server's write
+// timeout outlasts the longest of them so a timed-out route can still respond.
+func (c *Config) validateTimeouts() error {
+	if c.RequestTimeoutS < 1 || c.ProjectCreateTimeoutS < 1 || c.TaskCreateTimeoutS < 1 {
+		return fmt.Errorf("REQUEST_TIMEOUT_S, PROJECT_CREATE_TIMEOUT_S, and TASK_CREATE_TIMEOUT_S must be at least 1")
+	}
+
+	longest := max(c.RequestTimeoutS, c.ProjectCreateTimeoutS, c.TaskCreateTimeoutS)
+	if c.HTTPWriteTimeoutS <= longest {
+		return fmt.Errorf("HTTP_WRITE_TIMEOUT_S must be greater than the longest request timeout (%d)", longest)
+	}
+
+	return nil
+}
+
+// validateCORSOrigins checks that each configured origin is a scheme://host[:port]
+// value. A bare "*" is rejected because credentials are always allowed.
+func validateCORSOrigins(origins []string) error {
+	if len(origins) == 0 {
+		return fmt.Errorf("CORS_ALLOWED_ORIGINS must contain at least one origin")
+	}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS must not be \"*\" when credentials are allowed")
+		}
+
+		// Parsed by hand because url.Parse rejects wildcard ports like "localhost:*"
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS contains invalid origin %q (expected scheme://host[:port])", origin)
+		}
+		if strings.ContainsAny(host, "/?#") {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS origin %q must not include a path, query, or fragment", origin)
+		}
+	}
+
+	return nil
+}
+
+// validTrailerKey reports whether key can be a git trailer token. An empty
+// key is valid and disables its trailer.
+func validTrailerKey(key string) bool {
+	for i, r := range key {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '-' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Proxy returns the outbound proxy configuration.
+func (c *Config) Proxy() netproxy.Config {
+	return netproxy.Config{
+		HTTPProxy:  c.OutboundHTTPProxy,
+		HTTPSProxy: c.OutboundHTTPSProxy,
+		NoProxy:    c.OutboundNoProxy,
+	}
+}
+
+// RepoOwnerPolicy returns the repository owners projects may be created from.
+func (c *Config) RepoOwnerPolicy() domain.RepoOwnerPolicy {
+	return domain.RepoOwnerPolicy{
+		Allowed: c.AllowedRepoOwners,
+		Denied:  c.DeniedRepoOwners,
+	}
+}
+
+// trimList trims whitespace from each value and drops empty entries,
+// so "a, b," is treated the same as "a,b".
+func trimList(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		wantErr bool
+	}{
+		{"default localhost wildcard ports", []string{"http://localhost:*", "https://localhost:*"}, false},
+		{"explicit domain", []string{"https://app.example.com"}, false},
+		{"domain with port", []string{"https://app.example.com:8443"}, false},
+		{"subdomain wildcard", []string{"https://*.example.com"}, false},
+		{"empty list", []string{}, true},
+		{"bare wildcard", []string{"*"}, true},
+		{"missing scheme", []string{"app.example.com"}, true},
+		{"unsupported scheme", []string{"ftp://app.example.com"}, true},
+		{"missing host", []string{"https://"}, true},
+		{"with path", []string{"https://app.example.com/app"}, true},
+		{"with trailing slash", []string{"https://app.example.com/"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCORSOrigins(tt.origins)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCORSOrigins(%v) error = %v, wantErr %v", tt.origins, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrimList(t *testing.T) {
+	got := trimList([]string{" https://a.example.com", "https://b.example.com ", "", "  "})
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("trimList() = %v, want %v", got, want)
+	}
+}
+
+func TestValidateTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"defaults", Config{RequestTimeoutS: 60, ProjectCreateTimeoutS: 600, TaskCreateTimeoutS: 300, HTTPWriteTimeoutS: 660}, false},
+		{"task create longest", Config{RequestTimeoutS: 60, ProjectCreateTimeoutS: 600, TaskCreateTimeoutS: 900, HTTPWriteTimeoutS: 960}, false},
+		{"write timeout equal to longest", Config{RequestTimeoutS: 60, ProjectCreateTimeoutS: 600, TaskCreateTimeoutS: 300, HTTPWriteTimeoutS: 600}, true},
+		{"write timeout below task create", Config{RequestTimeoutS: 60, ProjectCreateTimeoutS: 600, TaskCreateTimeoutS: 900, HTTPWriteTimeoutS: 660}, true},
+		{"zero request timeout", Config{RequestTimeoutS: 0, ProjectCreateTimeoutS: 600, TaskCreateTimeoutS: 300, HTTPWriteTimeoutS: 660}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validateTimeouts()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidTrailerKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"Beads-Issue", true},
+		{"Intern-Subtask", true},
+		{"X-Ref2", true},
+		{"", true},
+		{"-Leading", false},
+		{"Has Space", false},
+		{"Colon:", false},
+		{"Under_score", false},
+	}
+
+	for _, tt := range tests {
+		if got := validTrailerKey(tt.key); got != tt.want {
+			t.Errorf("validTrailerKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PlannerWorktreePrefix starts the directory name of a Planner worktree, which
+// sits next to the subtask worktrees of its project.
+const PlannerWorktreePrefix = "planner-"
+
+// DataPaths builds every on-disk location the orchestrator writes under DATA_DIR:
+//
+//	{root}/projects/{user_id}/{owner}/{repo}          project clones
+//	{worktrees}/{project_id}/{subtask_id}             subtask worktrees (default {root}/worktrees)
+//	{worktrees}/{project_id}/planner-{task_id}        Planner worktrees
+//	{root}/logs/{project_id}/{task_id}[/{subtask_id}] agent run logs
+//	{root}/prompts/{project_id}/{task_id}             rendered prompts
+//	{root}/attachments/{project_id}/{task_id}         task attachments for the Planner
+//	{root}/mirrors/{owner}/{repo}.git                 shared clone mirrors (CLONE_MIRRORS)
+//	{root}/archives/{project_id}/{task_id}.tar.gz     archived runs and logs of finished tasks
+type DataPaths struct {
+	root      string
+	worktrees string
+}
+
+// NewDataPaths creates a DataPaths rooted at dataDir. An empty worktreeDir
+// places worktrees under {dataDir}/worktrees.
+func NewDataPaths(dataDir, worktreeDir string) DataPaths {
+	if worktreeDir == "" {
+		worktreeDir = filepath.Join(dataDir, "worktrees")
+	}
+	return DataPaths{root: dataDir, worktrees: worktreeDir}
+}
+
+// DataPaths returns the data directory layout for this configuration.
+func (c *Config) DataPaths() DataPaths {
+	return NewDataPaths(c.DataDir, c.WorktreeDir)
+}
+
+// Root returns the data directory itself.
+func (p DataPaths) Root() string {
+	return p.root
+}
+
+// Clone returns the clone directory of a user's copy of owner/repo.
+func (p DataPaths) Clone(userID, owner, repo string) string {
+	return filepath.Join(p.root, "projects", userID, owner, repo)
+}
+
+// Worktrees returns the directory holding a project's subtask worktrees.
+func (p DataPaths) Worktrees(projectID string) string {
+	return filepath.Join(p.worktrees, projectID)
+}
+
+// Worktree returns the worktree directory of a subtask.
+func (p DataPaths) Worktree(projectID, subtaskID string) string {
+	return filepath.Join(p.Worktrees(projectID), subtaskID)
+}
+
+// PlannerWorktree returns the worktree directory a task's Planner runs in.
+func (p DataPaths) PlannerWorktree(projectID, taskID string) string {
+	return filepath.Join(p.Worktrees(projectID), PlannerWorktreePrefix+taskID)
+}
+
+// Logs returns the log directory of a task's Planner (empty subtaskID) or of a subtask's Worker.
+func (p DataPaths) Logs(projectID, taskID, subtaskID string) string {
+	if subtaskID != "" {
+		return filepath.Join(p.root, "logs", projectID, taskID, subtaskID)
+	}
+	return filepath.Join(p.root, "logs", projectID, taskID)
+}
+
+// Prompts returns the directory holding a task's rendered prompts.
+func (p DataPaths) Prompts(projectID, taskID string) string {
+	return filepath.Join(p.root, "prompts", projectID, taskID)
+}
+
+// Attachments returns the directory holding a task's attachments.
+func (p DataPaths) Attachments(projectID, taskID string) string {
+	return filepath.Join(p.root, "attachments", projectID, taskID)
+}
+
+// Mirrors returns the directory holding the shared clone mirrors.
+func (p DataPaths) Mirrors() string {
+	return filepath.Join(p.root, "mirrors")
+}
+
+// Archive returns the archive file holding a finished task's runs, logs, and prompts.
+func (p DataPaths) Archive(projectID, taskID string) string {
+	return filepath.Join(p.root, "archives", projectID, taskID+".tar.gz")
+}
+
+// Validate checks that the data directory exists, is writable, and has at least
+// minFreeMB megabytes free (0 skips the free space check). The worktree root is
+// created if missing and must be writable too. Run at startup so a bad volume
+// fails fast instead of on the first clone.
+func (p DataPaths) Validate(minFreeMB int) error {
+	info, err := os.Stat(p.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("DATA_DIR %s does not exist", p.root)
+		}
+		return fmt.Errorf("DATA_DIR %s is not accessible: %w", p.root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("DATA_DIR %s is not a directory", p.root)
+	}
+	if err := checkWritable(p.root); err != nil {
+		return fmt.Errorf("DATA_DIR %s is not writable: %w", p.root, err)
+	}
+
+	if err := os.MkdirAll(p.worktrees, 0o755); err != nil {
+		return fmt.Errorf("failed to create worktree directory %s: %w", p.worktrees, err)
+	}
+	if err := checkWritable(p.worktrees); err != nil {
+		return fmt.Errorf("worktree directory %s is not writable: %w", p.worktrees, err)
+	}
+
+	if minFreeMB <= 0 {
+		return nil
+	}
+	free, ok, err := p.FreeBytes()
+	if err != nil {
+		return fmt.Errorf("failed to check free space in DATA_DIR %s: %w", p.root, err)
+	}
+	if ok && free < uint64(minFreeMB)*1024*1024 {
+		return fmt.Errorf("DATA_DIR %s has %d MB free, less than DATA_DIR_MIN_FREE_MB (%d MB)", p.root, free/(1024*1024), minFreeMB)
+	}
+	return nil
+}
+
+// FreeBytes returns the bytes available to the orchestrator on the filesystem
+// holding the data directory. ok is false on platforms where this is unknown.
+func (p DataPaths) FreeBytes() (free uint64, ok bool, err error) {
+	return freeBytes(p.root)
+}
+
+// checkWritable creates and removes a temporary file in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
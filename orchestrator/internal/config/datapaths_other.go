@@ -0,0 +1,11 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+//go:build !unix
+
+package config
+
+// freeBytes is not implemented on this platform; the free space check is skipped.
+func freeBytes(path string) (uint64, bool, error) {
+	return 0, false, nil
+}
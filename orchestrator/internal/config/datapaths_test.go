@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDataPaths_Layout(t *testing.T) {
+	p := NewDataPaths("/data", "")
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"clone", p.Clone("u1", "owner", "repo"), "/data/projects/u1/owner/repo"},
+		{"worktrees", p.Worktrees("p1"), "/data/worktrees/p1"},
+		{"worktree", p.Worktree("p1", "s1"), "/data/worktrees/p1/s1"},
+		{"planner worktree", p.PlannerWorktree("p1", "t1"), "/data/worktrees/p1/planner-t1"},
+		{"planner logs", p.Logs("p1", "t1", ""), "/data/logs/p1/t1"},
+		{"worker logs", p.Logs("p1", "t1", "s1"), "/data/logs/p1/t1/s1"},
+		{"prompts", p.Prompts("p1", "t1"), "/data/prompts/p1/t1"},
+		{"attachments", p.Attachments("p1", "t1"), "/data/attachments/p1/t1"},
+		{"mirrors", p.Mirrors(), "/data/mirrors"},
+		{"archive", p.Archive("p1", "t1"), "/data/archives/p1/t1.tar.gz"},
+		{"worktree override", NewDataPaths("/data", "/scratch/wt").Worktree("p1", "s1"), "/scratch/wt/p1/s1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataPaths_Validate(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewDataPaths(dir, "").Validate(0); err != nil {
+		t.Fatalf("Validate() on writable dir error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "worktrees")); err != nil {
+		t.Errorf("Validate() should create the worktree root: %v", err)
+	}
+
+	if err := NewDataPaths(filepath.Join(dir, "missing"), "").Validate(0); err == nil {
+		t.Error("Validate() should fail for a missing DATA_DIR")
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewDataPaths(file, "").Validate(0); err == nil {
+		t.Error("Validate() should fail when DATA_DIR is a file")
+	}
+
+	// No filesystem has this much free space
+	if err := NewDataPaths(dir, "").Validate(1 << 40); err == nil {
+		t.Error("Validate() should fail when free space is below the minimum")
+	}
+}
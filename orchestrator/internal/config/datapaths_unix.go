@@ -0,0 +1,17 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+//go:build unix
+
+package config
+
+import "syscall"
+
+// freeBytes returns the bytes available to unprivileged users on the filesystem holding path.
+func freeBytes(path string) (uint64, bool, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), true, nil //nolint:gosec,unconvert // field types vary by platform
+}
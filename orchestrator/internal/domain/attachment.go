@@ -0,0 +1,66 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTaskAttachments is the most attachments a task may be created with.
+const MaxTaskAttachments = 5
+
+// TaskAttachment is a text file handed to the Planner as extra context, such
+// as a design doc or an error log.
+type TaskAttachment struct {
+	Name    string
+	Content []byte
+}
+
+// unsafeAttachmentChars matches characters not allowed in stored attachment names.
+var unsafeAttachmentChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// AttachmentFileName returns the file name an attachment is stored under:
+// name with path separators and other unsafe characters replaced by "_".
+// It returns "" if nothing usable is left.
+func AttachmentFileName(name string) string {
+	name = unsafeAttachmentChars.ReplaceAllString(name, "_")
+	name = strings.TrimLeft(name, "._")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return name
+}
+
+// ValidateTaskAttachments checks that there are at most MaxTaskAttachments
+// attachments with distinct usable names, each non-empty UTF-8 text of at most
+// maxBytes bytes. Binary files are rejected because they cannot be rendered
+// into a prompt.
+func ValidateTaskAttachments(attachments []TaskAttachment, maxBytes int) error {
+	if len(attachments) > MaxTaskAttachments {
+		return NewValidationError("attachments", fmt.Sprintf("at most %d attachments are allowed", MaxTaskAttachments))
+	}
+
+	seen := make(map[string]bool, len(attachments))
+	for _, a := range attachments {
+		fileName := AttachmentFileName(a.Name)
+		switch {
+		case fileName == "":
+			return NewValidationError("attachments", fmt.Sprintf("invalid attachment name %q", a.Name))
+		case seen[fileName]:
+			return NewValidationError("attachments", fmt.Sprintf("duplicate attachment %q", a.Name))
+		case len(a.Content) == 0:
+			return NewValidationError("attachments", fmt.Sprintf("attachment %q is empty", a.Name))
+		case len(a.Content) > maxBytes:
+			return NewValidationError("attachments", fmt.Sprintf("attachment %q exceeds %d bytes", a.Name, maxBytes))
+		case !utf8.Valid(a.Content) || bytes.IndexByte(a.Content, 0) >= 0:
+			return NewValidationError("attachments", fmt.Sprintf("attachment %q is not a text file", a.Name))
+		}
+		seen[fileName] = true
+	}
+	return nil
+}
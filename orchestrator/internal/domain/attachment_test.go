@@ -0,0 +1,57 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttachmentFileName(t *testing.T) {
+	tests := map[string]string{
+		"design.md":        "design.md",
+		"docs/auth v2.md":  "docs_auth_v2.md",
+		"../../etc/passwd": "etc_passwd",
+		".env":             "env",
+		"..":               "",
+	}
+
+	for name, want := range tests {
+		if got := AttachmentFileName(name); got != want {
+			t.Errorf("AttachmentFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidateTaskAttachments(t *testing.T) {
+	text := func(name string) TaskAttachment {
+		return TaskAttachment{Name: name, Content: []byte("panic: nil map\n")}
+	}
+	tests := []struct {
+		name        string
+		attachments []TaskAttachment
+		wantErr     bool
+	}{
+		{name: "none"},
+		{name: "text files", attachments: []TaskAttachment{text("design.md"), text("error.log")}},
+		{name: "too many", attachments: []TaskAttachment{text("a"), text("b"), text("c"), text("d"), text("e"), text("f")}, wantErr: true},
+		{name: "duplicate names", attachments: []TaskAttachment{text("docs/a.md"), text("docs_a.md")}, wantErr: true},
+		{name: "unusable name", attachments: []TaskAttachment{text("..")}, wantErr: true},
+		{name: "empty", attachments: []TaskAttachment{{Name: "a.md"}}, wantErr: true},
+		{name: "too large", attachments: []TaskAttachment{{Name: "a.md", Content: []byte(strings.Repeat("x", 101))}}, wantErr: true},
+		{name: "binary", attachments: []TaskAttachment{{Name: "a.png", Content: []byte("\x89PNG\x00\x00")}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTaskAttachments(tt.attachments, 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateTaskAttachments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !IsInvalidInput(err) {
+				t.Errorf("error should be a validation error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// MaxBeadsPrefixLength is the longest beads issue-ID prefix a project may choose.
+const MaxBeadsPrefixLength = 16
+
+var beadsPrefixPattern = regexp.MustCompile(`^[a-zA-Z]+$`)
+
+// ValidateBeadsPrefix checks a beads issue-ID prefix chosen at project creation.
+// Only ASCII letters are allowed so that issue IDs of the form prefix-N are
+// recognized wherever beads output is parsed.
+func ValidateBeadsPrefix(prefix string) error {
+	if prefix == "" {
+		return NewValidationError("beads_prefix", "must not be empty")
+	}
+	if len(prefix) > MaxBeadsPrefixLength {
+		return NewValidationError("beads_prefix", fmt.Sprintf("must be at most %d characters", MaxBeadsPrefixLength))
+	}
+	if !beadsPrefixPattern.MatchString(prefix) {
+		return NewValidationError("beads_prefix", "must contain only letters")
+	}
+	return nil
+}
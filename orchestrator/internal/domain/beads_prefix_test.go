@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateBeadsPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		wantErr bool
+	}{
+		{name: "lowercase", prefix: "bd"},
+		{name: "mixed case", prefix: "MyApp"},
+		{name: "max length", prefix: strings.Repeat("a", MaxBeadsPrefixLength)},
+		{name: "empty", prefix: "", wantErr: true},
+		{name: "too long", prefix: strings.Repeat("a", MaxBeadsPrefixLength+1), wantErr: true},
+		{name: "hyphen", prefix: "iv-abc", wantErr: true},
+		{name: "digits", prefix: "app2", wantErr: true},
+		{name: "whitespace", prefix: "my app", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBeadsPrefix(tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateBeadsPrefix(%q) error = %v, wantErr %v", tt.prefix, err, tt.wantErr)
+			}
+			if err != nil && !IsInvalidInput(err) {
+				t.Errorf("error should be a validation error, got %v", err)
+			}
+		})
+	}
+}
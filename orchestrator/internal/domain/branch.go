@@ -0,0 +1,59 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeBranchName trims a branch name and any leading "refs/heads/", then
+// checks that it can be passed to git as an argument and interpolated into refs
+// like origin/{branch}. Slashes are allowed (e.g. release/1.2). field names the
+// offending input in the returned ValidationError.
+//
+// The rules follow git check-ref-format, plus a ban on a leading dash so a name
+// is never read as a command-line flag.
+func NormalizeBranchName(field, name string) (string, error) {
+	branch := strings.TrimPrefix(strings.TrimSpace(name), "refs/heads/")
+
+	invalid := func(reason string) (string, error) {
+		return "", NewValidationError(field, fmt.Sprintf("invalid branch name %q: %s", name, reason))
+	}
+	switch {
+	case branch == "":
+		return invalid("must not be empty")
+	case strings.HasPrefix(branch, "-"):
+		return invalid("must not start with a dash")
+	case branch == "@":
+		return invalid(`must not be "@"`)
+	case strings.HasPrefix(branch, "/") || strings.HasSuffix(branch, "/") || strings.Contains(branch, "//"):
+		return invalid("must not have empty path components")
+	case strings.HasSuffix(branch, "."):
+		return invalid("must not end with a dot")
+	case strings.Contains(branch, ".."):
+		return invalid(`must not contain ".."`)
+	case strings.Contains(branch, "@{"):
+		return invalid(`must not contain "@{"`)
+	}
+
+	for _, r := range branch {
+		if r < 0x20 || r == 0x7f {
+			return invalid("must not contain control characters")
+		}
+		if strings.ContainsRune(" ~^:?*[\\", r) {
+			return invalid(fmt.Sprintf("must not contain %q", r))
+		}
+	}
+	for _, part := range strings.Split(branch, "/") {
+		if strings.HasPrefix(part, ".") {
+			return invalid("path components must not start with a dot")
+		}
+		if strings.HasSuffix(part, ".lock") {
+			return invalid(`path components must not end with ".lock"`)
+		}
+	}
+
+	return branch, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeBranchName(t *testing.T) {
+	tests := []struct {
+		name    string
+		branch  string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", branch: "main", want: "main"},
+		{name: "slashes", branch: "release/1.2", want: "release/1.2"},
+		{name: "nested slashes", branch: "feature/team/login-v2", want: "feature/team/login-v2"},
+		{name: "surrounding whitespace", branch: "  develop\n", want: "develop"},
+		{name: "full ref", branch: "refs/heads/release/1.2", want: "release/1.2"},
+		{name: "empty", branch: "", wantErr: true},
+		{name: "blank", branch: "   ", wantErr: true},
+		{name: "looks like a flag", branch: "--upload-pack=evil", wantErr: true},
+		{name: "dash after refs/heads", branch: "refs/heads/-f", wantErr: true},
+		{name: "control character", branch: "main\x00", wantErr: true},
+		{name: "inner newline", branch: "main\nevil", wantErr: true},
+		{name: "space", branch: "my branch", wantErr: true},
+		{name: "ref syntax", branch: "main~1", wantErr: true},
+		{name: "glob", branch: "release/*", wantErr: true},
+		{name: "double dot", branch: "main..evil", wantErr: true},
+		{name: "reflog syntax", branch: "main@{1}", wantErr: true},
+		{name: "at sign alone", branch: "@", wantErr: true},
+		{name: "leading slash", branch: "/main", wantErr: true},
+		{name: "trailing slash", branch: "release/", wantErr: true},
+		{name: "empty component", branch: "release//1.2", wantErr: true},
+		{name: "trailing dot", branch: "release.", wantErr: true},
+		{name: "hidden component", branch: "release/.hidden", wantErr: true},
+		{name: "lock suffix", branch: "release/1.2.lock", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeBranchName("base_branch", tt.branch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeBranchName(%q) error = %v, wantErr %v", tt.branch, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Errorf("NormalizeBranchName(%q) error = %v, want a validation error", tt.branch, err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeBranchName(%q) = %q, want %q", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
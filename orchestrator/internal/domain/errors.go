@@ -30,6 +30,22 @@ var (
 
 	// ErrInvalidInput indicates the input is invalid.
 	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrQuotaExceeded indicates a per-user quota has been reached.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrReauthRequired indicates GitHub rejected the user's stored token and
+	// the user must reconnect GitHub.
+	ErrReauthRequired = errors.New("GitHub must be reconnected")
+
+	// ErrInsufficientDiskSpace indicates the data directory has less free
+	// space than the configured minimum, so no clone, worktree, or agent run
+	// is started.
+	ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+	// ErrCloneMissing indicates a project's clone directory is gone, so work
+	// on it cannot start until the project is repaired.
+	ErrCloneMissing = errors.New("project clone is missing")
 )
 
 // NotFoundError represents a not found error with details.
@@ -70,6 +86,23 @@ func NewConflictError(resource, reason string) *ConflictError {
 	return &ConflictError{Resource: resource, Reason: reason}
 }
 
+// StaleError is a ConflictError raised when a client mutates a resource based
+// on an outdated copy of it. Current holds the resource as it is now so the
+// client can refresh without a second request.
+type StaleError struct {
+	*ConflictError
+	Current any
+}
+
+func (e *StaleError) Unwrap() error {
+	return e.ConflictError
+}
+
+// NewStaleError creates a new StaleError.
+func NewStaleError(resource, reason string, current any) *StaleError {
+	return &StaleError{ConflictError: NewConflictError(resource, reason), Current: current}
+}
+
 // ForbiddenError represents a forbidden error with details.
 type ForbiddenError struct {
 	Resource string
@@ -156,6 +189,25 @@ func NewValidationError(field, message string) *ValidationError {
 	return &ValidationError{Field: field, Message: message}
 }
 
+// QuotaExceededError represents a per-user quota that has been reached.
+type QuotaExceededError struct {
+	Quota string // e.g. "projects", "active tasks", "concurrent agents"
+	Limit int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: at most %d %s per user", e.Limit, e.Quota)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// NewQuotaExceededError creates a new QuotaExceededError.
+func NewQuotaExceededError(quota string, limit int) *QuotaExceededError {
+	return &QuotaExceededError{Quota: quota, Limit: limit}
+}
+
 // IsNotFound checks if an error is a not found error.
 func IsNotFound(err error) bool {
 	return errors.Is(err, ErrNotFound)
@@ -181,7 +233,32 @@ func IsInvalidTransition(err error) bool {
 	return errors.Is(err, ErrInvalidTransition)
 }
 
+// IsAlreadyExists checks if an error is an already exists error.
+func IsAlreadyExists(err error) bool {
+	return errors.Is(err, ErrAlreadyExists)
+}
+
 // IsInvalidInput checks if an error is an invalid input error.
 func IsInvalidInput(err error) bool {
 	return errors.Is(err, ErrInvalidInput)
 }
+
+// IsQuotaExceeded checks if an error is a quota exceeded error.
+func IsQuotaExceeded(err error) bool {
+	return errors.Is(err, ErrQuotaExceeded)
+}
+
+// IsReauthRequired checks if an error means the user must reconnect GitHub.
+func IsReauthRequired(err error) bool {
+	return errors.Is(err, ErrReauthRequired)
+}
+
+// IsInsufficientDiskSpace checks if an error means the data directory is low on disk space.
+func IsInsufficientDiskSpace(err error) bool {
+	return errors.Is(err, ErrInsufficientDiskSpace)
+}
+
+// IsCloneMissing checks if an error means a project's clone must be repaired.
+func IsCloneMissing(err error) bool {
+	return errors.Is(err, ErrCloneMissing)
+}
@@ -5,6 +5,7 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -40,6 +41,25 @@ func TestConflictError(t *testing.T) {
 	}
 }
 
+func TestStaleError(t *testing.T) {
+	current := &Subtask{Title: "now"}
+	err := fmt.Errorf("wrapped: %w", NewStaleError("subtask", "modified since it was read", current))
+
+	if !IsConflict(err) {
+		t.Error("IsConflict should return true")
+	}
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || conflict.Reason != "modified since it was read" {
+		t.Errorf("expected a ConflictError in the chain, got %v", conflict)
+	}
+
+	var stale *StaleError
+	if !errors.As(err, &stale) || stale.Current != current {
+		t.Error("expected the StaleError to carry the current resource")
+	}
+}
+
 func TestForbiddenError(t *testing.T) {
 	err := NewForbiddenError("project", "user does not own this project")
 
@@ -116,6 +136,25 @@ func TestValidationError(t *testing.T) {
 	}
 }
 
+func TestQuotaExceededError(t *testing.T) {
+	err := NewQuotaExceededError("projects", 5)
+
+	if err.Error() != "quota exceeded: at most 5 projects per user" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Error("expected error to wrap ErrQuotaExceeded")
+	}
+
+	if !IsQuotaExceeded(err) {
+		t.Error("IsQuotaExceeded should return true")
+	}
+	if IsForbidden(err) {
+		t.Error("a quota error should not be a forbidden error")
+	}
+}
+
 func TestErrorCheckersWithNilAndOtherErrors(t *testing.T) {
 	otherErr := errors.New("some other error")
 
@@ -34,6 +34,14 @@ type Project struct {
 	BeadsPrefix   string    `json:"beads_prefix"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	// MaxSubtasksPerTask overrides MAX_SUBTASKS_PER_TASK for this project (nil uses the default, 0 disables the limit)
+	MaxSubtasksPerTask *int `json:"max_subtasks_per_task,omitempty"`
+	// PRTitleTemplate formats Worker PR titles (nil uses DefaultPRTitleTemplate)
+	PRTitleTemplate *string `json:"pr_title_template,omitempty"`
+	// SquashBeforePR squashes a Worker's commits into one before its PR is opened
+	SquashBeforePR bool `json:"squash_before_pr"`
+	// PathPolicy limits the paths Workers may modify (empty allows every path)
+	PathPolicy PathPolicy `json:"path_policy"`
 }
 
 // Task represents a user-submitted work item.
@@ -44,6 +52,8 @@ type Task struct {
 	Description string     `json:"description"`
 	Status      TaskStatus `json:"status"`
 	BeadsEpicID *string    `json:"beads_epic_id,omitempty"`
+	DryRun      bool       `json:"dry_run"`     // Stop for plan review before creating subtasks
+	BaseBranch  string     `json:"base_branch"` // Branch synced for planning, worktrees branch from, and PRs target
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -67,6 +77,9 @@ type Subtask struct {
 	WorktreePath       *string        `json:"worktree_path,omitempty"`
 	CreatedAt          time.Time      `json:"created_at"`
 	UpdatedAt          time.Time      `json:"updated_at"`
+	NextAttemptAt      *time.Time     `json:"next_attempt_at,omitempty"` // set while a Worker backs off between attempts
+	TokenBudget        *int           `json:"token_budget,omitempty"`    // nil means unlimited
+	Priority           int            `json:"priority"`                  // 0 (most urgent) to 4, see ValidateSubtaskPriority
 }
 
 // SubtaskDependency tracks which subtasks block others.
@@ -77,6 +90,13 @@ type SubtaskDependency struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+// BlockingDependency is a subtask that another subtask is still waiting on.
+type BlockingDependency struct {
+	ID     uuid.UUID     `json:"id"`
+	Title  string        `json:"title"`
+	Status SubtaskStatus `json:"status"`
+}
+
 // AgentRun represents history of agent executions.
 // For Planner agents: TaskID is set, SubtaskID is nil
 // For Worker agents: SubtaskID is set, TaskID can be derived from subtask
@@ -96,6 +116,58 @@ type AgentRun struct {
 	CreatedAt     time.Time      `json:"created_at"`
 }
 
+// IdempotentResponse is the stored outcome of a request made with an
+// Idempotency-Key, replayed when the same request is retried.
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// IdempotencyClaim identifies a request's hold on an Idempotency-Key. ClaimedAt
+// tells the claim apart from a later one that took the key over, so only the
+// request holding the claim can complete or release it.
+type IdempotencyClaim struct {
+	UserID    uuid.UUID
+	Key       string
+	Route     string
+	ClaimedAt time.Time
+}
+
+// Webhook is an outbound HTTP subscription to a project's events.
+type Webhook struct {
+	ID         uuid.UUID `json:"id"`
+	ProjectID  uuid.UUID `json:"project_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`           // HMAC signing key (plaintext); never serialized
+	EventTypes []string  `json:"event_types"` // empty means all webhook event types
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TaskArchive summarizes a finished task whose agent runs, logs, and prompts
+// were moved into a compressed archive.
+type TaskArchive struct {
+	TaskID            uuid.UUID `json:"task_id"`
+	ArchivePath       string    `json:"-"` // location under DATA_DIR; never serialized
+	RunCount          int       `json:"run_count"`
+	PlannerTokenUsage int       `json:"planner_token_usage"`
+	SizeBytes         int64     `json:"size_bytes"`
+	ArchivedAt        time.Time `json:"archived_at"`
+}
+
+// AuditEntry records a state transition triggered by a user.
+type AuditEntry struct {
+	ID         uuid.UUID `json:"id"`
+	ProjectID  uuid.UUID `json:"project_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Action     string    `json:"action"`      // e.g. "subtask.retry"
+	EntityType string    `json:"entity_type"` // "task" or "subtask"
+	EntityID   uuid.UUID `json:"entity_id"`
+	OldStatus  *string   `json:"old_status,omitempty"` // nil when the entity was created
+	NewStatus  *string   `json:"new_status,omitempty"` // nil when the entity was deleted
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // NewUser creates a new User with a generated UUID.
 func NewUser(githubID int64, githubUsername, encryptedToken string) *User {
 	now := time.Now()
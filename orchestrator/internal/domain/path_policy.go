@@ -0,0 +1,70 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PathPolicy limits which repository paths a project's Workers may modify.
+// Patterns are slash-separated, relative to the repository root, and may use
+// path.Match wildcards in each segment, e.g. "docs/*.md". A pattern also
+// covers everything under a directory it matches, so ".github" or
+// "deploy/*" reaches files at any depth below. An empty Allowed list allows
+// every path; a path matching Denied is refused even if it is also allowed.
+type PathPolicy struct {
+	Allowed []string `json:"allowed"`
+	Denied  []string `json:"denied"`
+}
+
+// IsEmpty reports whether the policy allows every path.
+func (p PathPolicy) IsEmpty() bool {
+	return len(p.Allowed) == 0 && len(p.Denied) == 0
+}
+
+// Validate checks that every pattern is well formed.
+func (p PathPolicy) Validate() error {
+	for _, pattern := range append(append([]string{}, p.Allowed...), p.Denied...) {
+		if strings.TrimSpace(pattern) == "" {
+			return NewValidationError("path_policy", "patterns must not be empty")
+		}
+		if strings.HasPrefix(pattern, "/") {
+			return NewValidationError("path_policy", fmt.Sprintf("pattern %q must be relative to the repository root", pattern))
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return NewValidationError("path_policy", fmt.Sprintf("invalid pattern %q: %v", pattern, err))
+		}
+	}
+	return nil
+}
+
+// Violations returns the paths the policy does not allow, in order.
+func (p PathPolicy) Violations(paths []string) []string {
+	var violations []string
+	for _, file := range paths {
+		if matchesPath(p.Denied, file) || (len(p.Allowed) > 0 && !matchesPath(p.Allowed, file)) {
+			violations = append(violations, file)
+		}
+	}
+	return violations
+}
+
+// matchesPath reports whether file, or a directory containing it, matches any
+// of patterns.
+func matchesPath(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		depth := strings.Count(pattern, "/") + 1
+		segments := strings.Split(file, "/")
+		if len(segments) < depth {
+			continue
+		}
+		if ok, _ := path.Match(pattern, strings.Join(segments[:depth], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
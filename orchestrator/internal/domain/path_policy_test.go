@@ -0,0 +1,44 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathPolicy_Violations(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PathPolicy
+		paths  []string
+		want   []string
+	}{
+		{name: "empty policy allows everything", paths: []string{".github/workflows/ci.yml", "main.go"}},
+		{name: "denied directory", policy: PathPolicy{Denied: []string{".github"}}, paths: []string{".github/workflows/ci.yml", "main.go"}, want: []string{".github/workflows/ci.yml"}},
+		{name: "denied directory with trailing slash", policy: PathPolicy{Denied: []string{"infra/"}}, paths: []string{"infra/main.tf", "infrastructure.md"}, want: []string{"infra/main.tf"}},
+		{name: "denied wildcard file", policy: PathPolicy{Denied: []string{"*.lock"}}, paths: []string{"go.lock", "pkg/go.lock"}, want: []string{"go.lock"}},
+		{name: "wildcard segment", policy: PathPolicy{Denied: []string{"deploy/*/secrets"}}, paths: []string{"deploy/prod/secrets/key.pem", "deploy/prod/values.yaml"}, want: []string{"deploy/prod/secrets/key.pem"}},
+		{name: "allowed directories only", policy: PathPolicy{Allowed: []string{"src", "docs/*.md"}}, paths: []string{"src/app/main.go", "docs/guide.md", "docs/img/logo.png", "Makefile"}, want: []string{"docs/img/logo.png", "Makefile"}},
+		{name: "deny wins over allow", policy: PathPolicy{Allowed: []string{"src"}, Denied: []string{"src/generated"}}, paths: []string{"src/main.go", "src/generated/db.go"}, want: []string{"src/generated/db.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Violations(tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Violations(%v) = %v, want %v", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathPolicy_Validate(t *testing.T) {
+	if err := (PathPolicy{Allowed: []string{"src", "docs/*.md"}, Denied: []string{".github/"}}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, pattern := range []string{"", "/etc", "[z-a"} {
+		if err := (PathPolicy{Denied: []string{pattern}}).Validate(); !IsInvalidInput(err) {
+			t.Errorf("Validate(%q) error = %v, want invalid input", pattern, err)
+		}
+	}
+}
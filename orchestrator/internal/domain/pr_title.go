@@ -0,0 +1,74 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultPRTitleTemplate is the Worker PR title used when a project has no template.
+const DefaultPRTitleTemplate = "[IV-{subtask_id}] {title}"
+
+// MaxPRTitleLength is the longest PR title GitHub accepts.
+const MaxPRTitleLength = 256
+
+// PR title template placeholders.
+const (
+	PRTitleSubtaskID = "{subtask_id}" // first 8 characters of the subtask UUID
+	PRTitleBeadsID   = "{beads_id}"   // beads issue ID, empty if the subtask has none
+	PRTitleTitle     = "{title}"      // subtask title
+	PRTitleTaskTitle = "{task_title}" // parent task title
+)
+
+var prTitlePlaceholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// PRTitleVars are the values substituted into a PR title template.
+type PRTitleVars struct {
+	SubtaskID string
+	BeadsID   string
+	Title     string
+	TaskTitle string
+}
+
+// ValidatePRTitleTemplate checks that a template is non-blank, fits in a PR
+// title, and only uses known placeholders.
+func ValidatePRTitleTemplate(tmpl string) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return NewValidationError("pr_title_template", "must not be blank")
+	}
+	if len(tmpl) > MaxPRTitleLength {
+		return NewValidationError("pr_title_template", fmt.Sprintf("must be at most %d characters", MaxPRTitleLength))
+	}
+	for _, p := range prTitlePlaceholder.FindAllString(tmpl, -1) {
+		switch p {
+		case PRTitleSubtaskID, PRTitleBeadsID, PRTitleTitle, PRTitleTaskTitle:
+		default:
+			return NewValidationError("pr_title_template", fmt.Sprintf("unknown placeholder %s", p))
+		}
+	}
+	return nil
+}
+
+// RenderPRTitle fills in a PR title template. An empty template renders
+// DefaultPRTitleTemplate. The result is trimmed and cut to MaxPRTitleLength bytes
+// without splitting a UTF-8 character.
+func RenderPRTitle(tmpl string, vars PRTitleVars) string {
+	if tmpl == "" {
+		tmpl = DefaultPRTitleTemplate
+	}
+	title := strings.NewReplacer(
+		PRTitleSubtaskID, vars.SubtaskID,
+		PRTitleBeadsID, vars.BeadsID,
+		PRTitleTitle, vars.Title,
+		PRTitleTaskTitle, vars.TaskTitle,
+	).Replace(tmpl)
+	title = strings.TrimSpace(title)
+
+	if len(title) > MaxPRTitleLength {
+		title = strings.ToValidUTF8(title[:MaxPRTitleLength], "")
+	}
+	return title
+}
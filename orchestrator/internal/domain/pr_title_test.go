@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePRTitleTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr bool
+	}{
+		{name: "default", tmpl: DefaultPRTitleTemplate},
+		{name: "all placeholders", tmpl: "{beads_id} {subtask_id} {task_title}: {title}"},
+		{name: "literal braces", tmpl: "{ JIRA-1 } {title}"},
+		{name: "no placeholders", tmpl: "Automated change"},
+		{name: "blank", tmpl: "   ", wantErr: true},
+		{name: "unknown placeholder", tmpl: "{ticket}: {title}", wantErr: true},
+		{name: "too long", tmpl: strings.Repeat("x", MaxPRTitleLength+1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePRTitleTemplate(tt.tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePRTitleTemplate(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+			}
+			if err != nil && !IsInvalidInput(err) {
+				t.Errorf("error should be a validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRenderPRTitle(t *testing.T) {
+	vars := PRTitleVars{SubtaskID: "1a2b3c4d", Title: "Add login", TaskTitle: "Auth"}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "empty uses default", tmpl: "", want: "[IV-1a2b3c4d] Add login"},
+		{name: "missing beads ID is trimmed", tmpl: "{title} {beads_id}", want: "Add login"},
+		{name: "task title", tmpl: "{task_title}: {title}", want: "Auth: Add login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderPRTitle(tt.tmpl, vars); got != tt.want {
+				t.Errorf("RenderPRTitle(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+
+	long := RenderPRTitle("{title}", PRTitleVars{Title: strings.Repeat("é", MaxPRTitleLength)})
+	if len(long) > MaxPRTitleLength || !strings.HasPrefix(long, "é") || strings.ContainsRune(long, '�') {
+		t.Errorf("long title not cut cleanly: %d bytes", len(long))
+	}
+}
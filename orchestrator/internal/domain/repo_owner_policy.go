@@ -0,0 +1,50 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RepoOwnerPolicy decides which GitHub repository owners (users or
+// organizations) projects may be created from. Patterns are matched without
+// regard to case and may use path.Match wildcards, e.g. "acme-*". An empty
+// Allowed list allows every owner; an owner matching Denied is refused even
+// if it is also allowed.
+type RepoOwnerPolicy struct {
+	Allowed []string
+	Denied  []string
+}
+
+// Validate checks that every pattern is well formed.
+func (p RepoOwnerPolicy) Validate() error {
+	for _, pattern := range append(append([]string{}, p.Allowed...), p.Denied...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid repository owner pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Check returns a ForbiddenError if projects may not be created from owner's
+// repositories.
+func (p RepoOwnerPolicy) Check(owner string) error {
+	if matchesOwner(p.Denied, owner) || (len(p.Allowed) > 0 && !matchesOwner(p.Allowed, owner)) {
+		return NewForbiddenError("repository owner", fmt.Sprintf("projects cannot be created from repositories owned by %s", owner))
+	}
+	return nil
+}
+
+// matchesOwner reports whether owner matches any of patterns.
+func matchesOwner(patterns []string, owner string) bool {
+	owner = strings.ToLower(owner)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), owner); ok {
+			return true
+		}
+	}
+	return false
+}
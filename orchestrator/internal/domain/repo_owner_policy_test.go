@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import "testing"
+
+func TestRepoOwnerPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RepoOwnerPolicy
+		owner   string
+		allowed bool
+	}{
+		{name: "no lists allow everyone", owner: "anyone", allowed: true},
+		{name: "allowed owner", policy: RepoOwnerPolicy{Allowed: []string{"acme"}}, owner: "acme", allowed: true},
+		{name: "allowed ignoring case", policy: RepoOwnerPolicy{Allowed: []string{"Acme"}}, owner: "ACME", allowed: true},
+		{name: "owner not allowed", policy: RepoOwnerPolicy{Allowed: []string{"acme"}}, owner: "octocat"},
+		{name: "wildcard allows", policy: RepoOwnerPolicy{Allowed: []string{"acme-*"}}, owner: "acme-labs", allowed: true},
+		{name: "wildcard does not allow others", policy: RepoOwnerPolicy{Allowed: []string{"acme-*"}}, owner: "acme"},
+		{name: "denied owner", policy: RepoOwnerPolicy{Denied: []string{"octocat"}}, owner: "octocat"},
+		{name: "owner not denied", policy: RepoOwnerPolicy{Denied: []string{"octocat"}}, owner: "acme", allowed: true},
+		{name: "deny wins over allow", policy: RepoOwnerPolicy{Allowed: []string{"acme-*"}, Denied: []string{"acme-archive"}}, owner: "acme-archive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.owner)
+			if tt.allowed && err != nil {
+				t.Errorf("Check(%q) error = %v, want allowed", tt.owner, err)
+			}
+			if !tt.allowed && !IsForbidden(err) {
+				t.Errorf("Check(%q) error = %v, want forbidden", tt.owner, err)
+			}
+		})
+	}
+}
+
+func TestRepoOwnerPolicy_Validate(t *testing.T) {
+	if err := (RepoOwnerPolicy{Allowed: []string{"acme", "acme-*"}, Denied: []string{"?ld"}}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := (RepoOwnerPolicy{Denied: []string{"acme-["}}).Validate(); err == nil {
+		t.Error("Validate() accepted a malformed pattern")
+	}
+}
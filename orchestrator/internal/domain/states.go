@@ -13,21 +13,33 @@ const (
 	TaskStatusPlanning TaskStatus = "PLANNING"
 	// TaskStatusPlanningFailed indicates the Planner exceeded max retries.
 	TaskStatusPlanningFailed TaskStatus = "PLANNING_FAILED"
+	// TaskStatusAwaitingApproval indicates a dry-run plan is ready for user review.
+	TaskStatusAwaitingApproval TaskStatus = "AWAITING_APPROVAL"
 	// TaskStatusActive indicates planning is complete, subtasks are being worked on.
 	TaskStatusActive TaskStatus = "ACTIVE"
+	// TaskStatusPaused indicates the user paused the task; no subtasks can be started.
+	TaskStatusPaused TaskStatus = "PAUSED"
 	// TaskStatusDone indicates all subtasks are merged.
 	TaskStatusDone TaskStatus = "DONE"
+	// TaskStatusCancelled indicates the user stopped the task but kept its history.
+	TaskStatusCancelled TaskStatus = "CANCELLED"
 )
 
 // IsValid checks if the TaskStatus is a known value.
 func (s TaskStatus) IsValid() bool {
 	switch s {
-	case TaskStatusPlanning, TaskStatusPlanningFailed, TaskStatusActive, TaskStatusDone:
+	case TaskStatusPlanning, TaskStatusPlanningFailed, TaskStatusAwaitingApproval,
+		TaskStatusActive, TaskStatusPaused, TaskStatusDone, TaskStatusCancelled:
 		return true
 	}
 	return false
 }
 
+// IsTerminal reports whether no further transitions are possible from this status.
+func (s TaskStatus) IsTerminal() bool {
+	return s == TaskStatusDone || s == TaskStatusCancelled
+}
+
 // String returns the string representation of the TaskStatus.
 func (s TaskStatus) String() string {
 	return string(s)
@@ -49,18 +61,31 @@ const (
 	SubtaskStatusCompleted SubtaskStatus = "COMPLETED"
 	// SubtaskStatusMerged indicates the PR was merged.
 	SubtaskStatusMerged SubtaskStatus = "MERGED"
+	// SubtaskStatusCompletedNoChanges indicates the Worker succeeded without
+	// changing anything (e.g. the change was already present), so there is no PR.
+	SubtaskStatusCompletedNoChanges SubtaskStatus = "COMPLETED_NO_CHANGES"
+	// SubtaskStatusCancelled indicates the user abandoned the subtask.
+	SubtaskStatusCancelled SubtaskStatus = "CANCELLED"
 )
 
 // IsValid checks if the SubtaskStatus is a known value.
 func (s SubtaskStatus) IsValid() bool {
 	switch s {
 	case SubtaskStatusPending, SubtaskStatusReady, SubtaskStatusBlocked,
-		SubtaskStatusInProgress, SubtaskStatusCompleted, SubtaskStatusMerged:
+		SubtaskStatusInProgress, SubtaskStatusCompleted, SubtaskStatusMerged,
+		SubtaskStatusCompletedNoChanges, SubtaskStatusCancelled:
 		return true
 	}
 	return false
 }
 
+// IsResolved reports whether the subtask no longer blocks its dependents or
+// its task's completion. Merged, cancelled, and subtasks completed without
+// changes are resolved.
+func (s SubtaskStatus) IsResolved() bool {
+	return s == SubtaskStatusMerged || s == SubtaskStatusCompletedNoChanges || s == SubtaskStatusCancelled
+}
+
 // String returns the string representation of the SubtaskStatus.
 func (s SubtaskStatus) String() string {
 	return string(s)
@@ -74,17 +99,29 @@ const (
 	BlockedReasonDependency BlockedReason = "DEPENDENCY"
 	// BlockedReasonFailure indicates the agent failed after max retries.
 	BlockedReasonFailure BlockedReason = "FAILURE"
+	// BlockedReasonBudgetExceeded indicates the Workers used up the subtask's token budget.
+	BlockedReasonBudgetExceeded BlockedReason = "BUDGET_EXCEEDED"
+	// BlockedReasonStopped indicates the user stopped the running Worker.
+	BlockedReasonStopped BlockedReason = "STOPPED"
+	// BlockedReasonPolicyViolation indicates the Worker modified paths the project's path policy forbids.
+	BlockedReasonPolicyViolation BlockedReason = "POLICY_VIOLATION"
 )
 
 // IsValid checks if the BlockedReason is a known value.
 func (r BlockedReason) IsValid() bool {
 	switch r {
-	case BlockedReasonDependency, BlockedReasonFailure:
+	case BlockedReasonDependency, BlockedReasonFailure, BlockedReasonBudgetExceeded, BlockedReasonStopped, BlockedReasonPolicyViolation:
 		return true
 	}
 	return false
 }
 
+// IsRetryable reports whether a subtask blocked for this reason can be retried
+// by the user. Dependency blocks clear on their own when the dependencies merge.
+func (r BlockedReason) IsRetryable() bool {
+	return r == BlockedReasonFailure || r == BlockedReasonBudgetExceeded || r == BlockedReasonStopped || r == BlockedReasonPolicyViolation
+}
+
 // String returns the string representation of the BlockedReason.
 func (r BlockedReason) String() string {
 	return string(r)
@@ -124,12 +161,16 @@ const (
 	AgentRunStatusSucceeded AgentRunStatus = "SUCCEEDED"
 	// AgentRunStatusFailed indicates the agent failed.
 	AgentRunStatusFailed AgentRunStatus = "FAILED"
+	// AgentRunStatusInterrupted indicates the orchestrator shut down during
+	// the run. It is not a failure: the attempt is not counted against the
+	// retries, and the Worker is resumed on restart.
+	AgentRunStatusInterrupted AgentRunStatus = "INTERRUPTED"
 )
 
 // IsValid checks if the AgentRunStatus is a known value.
 func (s AgentRunStatus) IsValid() bool {
 	switch s {
-	case AgentRunStatusRunning, AgentRunStatusSucceeded, AgentRunStatusFailed:
+	case AgentRunStatusRunning, AgentRunStatusSucceeded, AgentRunStatusFailed, AgentRunStatusInterrupted:
 		return true
 	}
 	return false
@@ -148,10 +189,20 @@ type TaskTransition struct {
 
 // ValidTaskTransitions defines all valid task state transitions.
 var ValidTaskTransitions = []TaskTransition{
-	{TaskStatusPlanning, TaskStatusActive},         // Planner completes successfully
-	{TaskStatusPlanning, TaskStatusPlanningFailed}, // Planner fails after max retries
-	{TaskStatusPlanningFailed, TaskStatusPlanning}, // User retries planning
-	{TaskStatusActive, TaskStatusDone},             // All subtasks merged
+	{TaskStatusPlanning, TaskStatusActive},            // Planner completes successfully
+	{TaskStatusPlanning, TaskStatusPlanningFailed},    // Planner fails after max retries
+	{TaskStatusPlanningFailed, TaskStatusPlanning},    // User retries planning
+	{TaskStatusPlanning, TaskStatusAwaitingApproval},  // Dry-run Planner completes
+	{TaskStatusAwaitingApproval, TaskStatusActive},    // User confirms the plan
+	{TaskStatusActive, TaskStatusDone},                // All subtasks merged
+	{TaskStatusPlanning, TaskStatusDone},              // Planner finds nothing to do (EMPTY_PLAN_ACTION=done)
+	{TaskStatusActive, TaskStatusPaused},              // User pauses the task
+	{TaskStatusPaused, TaskStatusActive},              // User resumes the task
+	{TaskStatusPlanning, TaskStatusCancelled},         // User cancels during planning
+	{TaskStatusPlanningFailed, TaskStatusCancelled},   // User abandons failed planning
+	{TaskStatusAwaitingApproval, TaskStatusCancelled}, // User rejects the plan
+	{TaskStatusActive, TaskStatusCancelled},           // User cancels active task
+	{TaskStatusPaused, TaskStatusCancelled},           // User cancels paused task
 }
 
 // CanTransitionTask checks if a task can transition from one status to another.
@@ -173,14 +224,24 @@ type SubtaskTransition struct {
 
 // ValidSubtaskTransitions defines all valid subtask state transitions.
 var ValidSubtaskTransitions = []SubtaskTransition{
-	{SubtaskStatusPending, SubtaskStatusReady, nil},                            // No dependencies
-	{SubtaskStatusPending, SubtaskStatusBlocked, ptr(BlockedReasonDependency)}, // Has dependencies
-	{SubtaskStatusBlocked, SubtaskStatusReady, nil},                            // Dependencies merged (was DEPENDENCY blocked)
-	{SubtaskStatusReady, SubtaskStatusInProgress, nil},                         // User starts subtask
-	{SubtaskStatusInProgress, SubtaskStatusCompleted, nil},                     // Worker succeeds
-	{SubtaskStatusInProgress, SubtaskStatusBlocked, ptr(BlockedReasonFailure)}, // Worker fails after max retries
-	{SubtaskStatusCompleted, SubtaskStatusMerged, nil},                         // User marks merged
-	{SubtaskStatusBlocked, SubtaskStatusInProgress, nil},                       // User retries (was FAILURE blocked)
+	{SubtaskStatusPending, SubtaskStatusReady, nil},                                    // No dependencies
+	{SubtaskStatusPending, SubtaskStatusBlocked, ptr(BlockedReasonDependency)},         // Has dependencies
+	{SubtaskStatusBlocked, SubtaskStatusReady, nil},                                    // Dependencies merged (was DEPENDENCY blocked)
+	{SubtaskStatusReady, SubtaskStatusInProgress, nil},                                 // User starts subtask
+	{SubtaskStatusInProgress, SubtaskStatusCompleted, nil},                             // Worker succeeds
+	{SubtaskStatusInProgress, SubtaskStatusCompletedNoChanges, nil},                    // Worker succeeds with an empty branch
+	{SubtaskStatusInProgress, SubtaskStatusBlocked, ptr(BlockedReasonFailure)},         // Worker fails after max retries
+	{SubtaskStatusInProgress, SubtaskStatusBlocked, ptr(BlockedReasonBudgetExceeded)},  // Worker uses up the token budget
+	{SubtaskStatusInProgress, SubtaskStatusBlocked, ptr(BlockedReasonStopped)},         // User stops the Worker
+	{SubtaskStatusInProgress, SubtaskStatusBlocked, ptr(BlockedReasonPolicyViolation)}, // Worker modifies forbidden paths
+	{SubtaskStatusCompleted, SubtaskStatusMerged, nil},                                 // User marks merged
+	{SubtaskStatusBlocked, SubtaskStatusInProgress, nil},                               // User retries (was FAILURE, BUDGET_EXCEEDED, STOPPED or POLICY_VIOLATION blocked)
+	{SubtaskStatusBlocked, SubtaskStatusMerged, nil},                                   // Retry finds the PR already merged
+	{SubtaskStatusPending, SubtaskStatusCancelled, nil},                                // User cancels before start
+	{SubtaskStatusReady, SubtaskStatusCancelled, nil},                                  // User cancels before start
+	{SubtaskStatusBlocked, SubtaskStatusCancelled, nil},                                // User abandons blocked subtask
+	{SubtaskStatusInProgress, SubtaskStatusCancelled, nil},                             // User stops running Worker
+	{SubtaskStatusCompleted, SubtaskStatusCancelled, nil},                              // User closes PR without merging
 }
 
 func ptr(r BlockedReason) *BlockedReason {
@@ -14,8 +14,11 @@ func TestTaskStatusIsValid(t *testing.T) {
 	}{
 		{TaskStatusPlanning, true},
 		{TaskStatusPlanningFailed, true},
+		{TaskStatusAwaitingApproval, true},
 		{TaskStatusActive, true},
+		{TaskStatusPaused, true},
 		{TaskStatusDone, true},
+		{TaskStatusCancelled, true},
 		{TaskStatus("INVALID"), false},
 		{TaskStatus(""), false},
 	}
@@ -40,6 +43,8 @@ func TestSubtaskStatusIsValid(t *testing.T) {
 		{SubtaskStatusInProgress, true},
 		{SubtaskStatusCompleted, true},
 		{SubtaskStatusMerged, true},
+		{SubtaskStatusCompletedNoChanges, true},
+		{SubtaskStatusCancelled, true},
 		{SubtaskStatus("INVALID"), false},
 		{SubtaskStatus(""), false},
 	}
@@ -53,6 +58,53 @@ func TestSubtaskStatusIsValid(t *testing.T) {
 	}
 }
 
+func TestTaskStatusIsTerminal(t *testing.T) {
+	tests := []struct {
+		status TaskStatus
+		want   bool
+	}{
+		{TaskStatusPlanning, false},
+		{TaskStatusPlanningFailed, false},
+		{TaskStatusAwaitingApproval, false},
+		{TaskStatusActive, false},
+		{TaskStatusPaused, false},
+		{TaskStatusDone, true},
+		{TaskStatusCancelled, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsTerminal(); got != tt.want {
+				t.Errorf("TaskStatus(%q).IsTerminal() = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtaskStatusIsResolved(t *testing.T) {
+	tests := []struct {
+		status SubtaskStatus
+		want   bool
+	}{
+		{SubtaskStatusPending, false},
+		{SubtaskStatusReady, false},
+		{SubtaskStatusBlocked, false},
+		{SubtaskStatusInProgress, false},
+		{SubtaskStatusCompleted, false},
+		{SubtaskStatusMerged, true},
+		{SubtaskStatusCompletedNoChanges, true},
+		{SubtaskStatusCancelled, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsResolved(); got != tt.want {
+				t.Errorf("SubtaskStatus(%q).IsResolved() = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBlockedReasonIsValid(t *testing.T) {
 	tests := []struct {
 		reason BlockedReason
@@ -60,6 +112,9 @@ func TestBlockedReasonIsValid(t *testing.T) {
 	}{
 		{BlockedReasonDependency, true},
 		{BlockedReasonFailure, true},
+		{BlockedReasonBudgetExceeded, true},
+		{BlockedReasonStopped, true},
+		{BlockedReasonPolicyViolation, true},
 		{BlockedReason("INVALID"), false},
 		{BlockedReason(""), false},
 	}
@@ -125,13 +180,31 @@ func TestCanTransitionTask(t *testing.T) {
 		{TaskStatusPlanning, TaskStatusPlanningFailed, true},
 		{TaskStatusPlanningFailed, TaskStatusPlanning, true},
 		{TaskStatusActive, TaskStatusDone, true},
+		{TaskStatusPlanning, TaskStatusDone, true},
+		{TaskStatusPlanning, TaskStatusCancelled, true},
+		{TaskStatusPlanningFailed, TaskStatusCancelled, true},
+		{TaskStatusActive, TaskStatusCancelled, true},
+		{TaskStatusPlanning, TaskStatusAwaitingApproval, true},
+		{TaskStatusAwaitingApproval, TaskStatusActive, true},
+		{TaskStatusAwaitingApproval, TaskStatusCancelled, true},
+		{TaskStatusActive, TaskStatusPaused, true},
+		{TaskStatusPaused, TaskStatusActive, true},
+		{TaskStatusPaused, TaskStatusCancelled, true},
+		{TaskStatusPaused, TaskStatusDone, false},
+		{TaskStatusPlanning, TaskStatusPaused, false},
 		// Invalid transitions
-		{TaskStatusPlanning, TaskStatusDone, false},
+		{TaskStatusAwaitingApproval, TaskStatusDone, false},
+		{TaskStatusActive, TaskStatusAwaitingApproval, false},
 		{TaskStatusActive, TaskStatusPlanning, false},
 		{TaskStatusDone, TaskStatusActive, false},
 		{TaskStatusDone, TaskStatusPlanning, false},
 		{TaskStatusPlanningFailed, TaskStatusActive, false},
 		{TaskStatusPlanningFailed, TaskStatusDone, false},
+		// Terminal states have no outgoing transitions
+		{TaskStatusDone, TaskStatusCancelled, false},
+		{TaskStatusCancelled, TaskStatusPlanning, false},
+		{TaskStatusCancelled, TaskStatusActive, false},
+		{TaskStatusCancelled, TaskStatusDone, false},
 	}
 
 	for _, tt := range tests {
@@ -156,9 +229,16 @@ func TestCanTransitionSubtask(t *testing.T) {
 		{SubtaskStatusBlocked, SubtaskStatusReady, true},
 		{SubtaskStatusReady, SubtaskStatusInProgress, true},
 		{SubtaskStatusInProgress, SubtaskStatusCompleted, true},
+		{SubtaskStatusInProgress, SubtaskStatusCompletedNoChanges, true},
 		{SubtaskStatusInProgress, SubtaskStatusBlocked, true},
 		{SubtaskStatusCompleted, SubtaskStatusMerged, true},
 		{SubtaskStatusBlocked, SubtaskStatusInProgress, true},
+		{SubtaskStatusBlocked, SubtaskStatusMerged, true},
+		{SubtaskStatusPending, SubtaskStatusCancelled, true},
+		{SubtaskStatusReady, SubtaskStatusCancelled, true},
+		{SubtaskStatusBlocked, SubtaskStatusCancelled, true},
+		{SubtaskStatusInProgress, SubtaskStatusCancelled, true},
+		{SubtaskStatusCompleted, SubtaskStatusCancelled, true},
 		// Invalid transitions
 		{SubtaskStatusPending, SubtaskStatusInProgress, false},
 		{SubtaskStatusPending, SubtaskStatusCompleted, false},
@@ -172,6 +252,14 @@ func TestCanTransitionSubtask(t *testing.T) {
 		{SubtaskStatusCompleted, SubtaskStatusInProgress, false},
 		{SubtaskStatusMerged, SubtaskStatusReady, false},
 		{SubtaskStatusMerged, SubtaskStatusCompleted, false},
+		{SubtaskStatusCompleted, SubtaskStatusCompletedNoChanges, false},
+		{SubtaskStatusCompletedNoChanges, SubtaskStatusMerged, false},
+		// Merged work cannot be cancelled, and cancelled subtasks cannot be revived
+		{SubtaskStatusMerged, SubtaskStatusCancelled, false},
+		{SubtaskStatusCancelled, SubtaskStatusPending, false},
+		{SubtaskStatusCancelled, SubtaskStatusReady, false},
+		{SubtaskStatusCancelled, SubtaskStatusInProgress, false},
+		{SubtaskStatusCancelled, SubtaskStatusMerged, false},
 	}
 
 	for _, tt := range tests {
@@ -187,6 +275,7 @@ func TestCanTransitionSubtask(t *testing.T) {
 func TestValidateSubtaskTransition(t *testing.T) {
 	dep := BlockedReasonDependency
 	failure := BlockedReasonFailure
+	stopped := BlockedReasonStopped
 
 	tests := []struct {
 		name          string
@@ -199,10 +288,13 @@ func TestValidateSubtaskTransition(t *testing.T) {
 		{"pending to ready", SubtaskStatusPending, SubtaskStatusReady, nil, false},
 		{"pending to blocked with reason", SubtaskStatusPending, SubtaskStatusBlocked, &dep, false},
 		{"in_progress to blocked with failure", SubtaskStatusInProgress, SubtaskStatusBlocked, &failure, false},
+		{"in_progress to blocked when stopped", SubtaskStatusInProgress, SubtaskStatusBlocked, &stopped, false},
+		{"in_progress to cancelled", SubtaskStatusInProgress, SubtaskStatusCancelled, nil, false},
 		// Invalid transitions
 		{"invalid transition", SubtaskStatusPending, SubtaskStatusMerged, nil, true},
 		{"blocked without reason", SubtaskStatusPending, SubtaskStatusBlocked, nil, true},
 		{"reason on non-blocked", SubtaskStatusPending, SubtaskStatusReady, &dep, true},
+		{"reason on cancelled", SubtaskStatusBlocked, SubtaskStatusCancelled, &failure, true},
 	}
 
 	for _, tt := range tests {
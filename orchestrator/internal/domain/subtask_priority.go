@@ -0,0 +1,22 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import "fmt"
+
+// Subtask priorities follow the beads scale: 0 is the most urgent and 4 the
+// least. Lists that surface subtasks to act on order them by priority first.
+const (
+	HighestSubtaskPriority = 0
+	LowestSubtaskPriority  = 4
+	DefaultSubtaskPriority = 2
+)
+
+// ValidateSubtaskPriority checks that priority is on the beads scale.
+func ValidateSubtaskPriority(priority int) error {
+	if priority < HighestSubtaskPriority || priority > LowestSubtaskPriority {
+		return NewValidationError("priority", fmt.Sprintf("must be between %d and %d", HighestSubtaskPriority, LowestSubtaskPriority))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package domain
+
+import "testing"
+
+func TestValidateSubtaskPriority(t *testing.T) {
+	tests := []struct {
+		priority int
+		wantErr  bool
+	}{
+		{priority: HighestSubtaskPriority},
+		{priority: DefaultSubtaskPriority},
+		{priority: LowestSubtaskPriority},
+		{priority: -1, wantErr: true},
+		{priority: LowestSubtaskPriority + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSubtaskPriority(tt.priority)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ValidateSubtaskPriority(%d) error = %v, wantErr %v", tt.priority, err, tt.wantErr)
+		}
+		if err != nil && !IsInvalidInput(err) {
+			t.Errorf("error should be a validation error, got %v", err)
+		}
+	}
+}
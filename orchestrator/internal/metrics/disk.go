@@ -0,0 +1,28 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// diskCollector exposes the free space in the data directory. It is read on
+// every scrape, and omitted when it cannot be read.
+type diskCollector struct {
+	free func() (uint64, bool, error)
+
+	freeBytes *prometheus.Desc
+}
+
+// Describe implements prometheus.Collector.
+func (c *diskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.freeBytes
+}
+
+// Collect implements prometheus.Collector.
+func (c *diskCollector) Collect(ch chan<- prometheus.Metric) {
+	free, ok, err := c.free()
+	if err != nil || !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.freeBytes, prometheus.GaugeValue, float64(free))
+}
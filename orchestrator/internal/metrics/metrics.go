@@ -0,0 +1,284 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+// Package metrics provides Prometheus instrumentation for the orchestrator.
+//
+// All recording methods are safe to call on a nil *Metrics, so components can
+// be wired with metrics optionally (e.g. in tests or when metrics are disabled).
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace is the common prefix for all orchestrator metrics.
+const namespace = "intern_village"
+
+// Metrics holds all Prometheus collectors for the orchestrator.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// HTTP
+	httpRequestDuration *prometheus.HistogramVec
+
+	// Agents
+	agentsRunning      *prometheus.GaugeVec
+	agentSpawns        *prometheus.CounterVec
+	agentSpawnFailures *prometheus.CounterVec
+	agentRuns          *prometheus.CounterVec
+	agentRunDuration   *prometheus.HistogramVec
+	agentTokens        *prometheus.CounterVec
+
+	// Events
+	sseConnections prometheus.Gauge
+	sseFlaps       *prometheus.CounterVec
+	eventsDropped  *prometheus.CounterVec
+
+	// Sync
+	syncProjectDuration *prometheus.HistogramVec
+	syncErrors          prometheus.Counter
+}
+
+// New creates a new Metrics instance with its own registry.
+// Go runtime and process collectors are registered alongside the orchestrator metrics.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern, and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+
+		agentsRunning: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "running",
+			Help:      "Number of agents currently running.",
+		}, []string{"agent_type"}),
+
+		agentSpawns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "spawns_total",
+			Help:      "Total number of agents spawned.",
+		}, []string{"agent_type"}),
+
+		agentSpawnFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "spawn_failures_total",
+			Help:      "Total number of agent spawn attempts that failed before the agent started.",
+		}, []string{"agent_type"}),
+
+		agentRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "runs_total",
+			Help:      "Total number of agent runs (attempts) by outcome.",
+		}, []string{"agent_type", "status"}),
+
+		agentRunDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "run_duration_seconds",
+			Help:      "Duration of individual agent runs (attempts).",
+			// 10s .. ~2.8h
+			Buckets: prometheus.ExponentialBuckets(10, 2, 11),
+		}, []string{"agent_type", "status"}),
+
+		agentTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "agent",
+			Name:      "tokens_total",
+			Help:      "Total number of tokens consumed by agent runs.",
+		}, []string{"agent_type"}),
+
+		sseConnections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "events",
+			Name:      "sse_connections",
+			Help:      "Number of active SSE connections.",
+		}),
+
+		sseFlaps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "events",
+			Name:      "sse_flaps_total",
+			Help:      "Total number of SSE connects from users reconnecting too fast, by action taken (backoff, reject).",
+		}, []string{"action"}),
+
+		eventsDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "events",
+			Name:      "dropped_total",
+			Help:      "Total number of events dropped because a connection's buffer was full.",
+		}, []string{"event_type"}),
+
+		syncProjectDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "sync",
+			Name:      "project_duration_seconds",
+			Help:      "Duration of a project's periodic beads sync by outcome (succeeded, failed, timeout).",
+			// 100ms .. ~100s
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 11),
+		}, []string{"status"}),
+
+		syncErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sync",
+			Name:      "errors_total",
+			Help:      "Total number of subtasks whose periodic beads sync failed.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequestDuration,
+		m.agentsRunning,
+		m.agentSpawns,
+		m.agentSpawnFailures,
+		m.agentRuns,
+		m.agentRunDuration,
+		m.agentTokens,
+		m.sseConnections,
+		m.sseFlaps,
+		m.eventsDropped,
+		m.syncProjectDuration,
+		m.syncErrors,
+	)
+
+	return m
+}
+
+// Handler returns the HTTP handler that serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Registry returns the underlying Prometheus registry.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// RegisterDBPool registers gauges and counters exposing pgx connection pool statistics.
+func (m *Metrics) RegisterDBPool(pool *pgxpool.Pool) {
+	if m == nil || pool == nil {
+		return
+	}
+	m.registry.MustRegister(newPoolCollector(pool.Stat))
+}
+
+// RegisterDataDirFree registers a gauge of the free space in the data
+// directory, read from free on every scrape.
+func (m *Metrics) RegisterDataDirFree(free func() (uint64, bool, error)) {
+	if m == nil || free == nil {
+		return
+	}
+	m.registry.MustRegister(&diskCollector{
+		free:      free,
+		freeBytes: prometheus.NewDesc(prometheus.BuildFQName(namespace, "data_dir", "free_bytes"), "Free space in the data directory.", nil, nil),
+	})
+}
+
+// ObserveHTTPRequest records the latency of a completed HTTP request.
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// AgentStarted records that an agent of the given type was spawned and is now running.
+func (m *Metrics) AgentStarted(agentType string) {
+	if m == nil {
+		return
+	}
+	m.agentSpawns.WithLabelValues(agentType).Inc()
+	m.agentsRunning.WithLabelValues(agentType).Inc()
+}
+
+// AgentStopped records that an agent of the given type is no longer running.
+func (m *Metrics) AgentStopped(agentType string) {
+	if m == nil {
+		return
+	}
+	m.agentsRunning.WithLabelValues(agentType).Dec()
+}
+
+// AgentSpawnFailed records an agent spawn that failed before the agent started.
+func (m *Metrics) AgentSpawnFailed(agentType string) {
+	if m == nil {
+		return
+	}
+	m.agentSpawnFailures.WithLabelValues(agentType).Inc()
+}
+
+// ObserveAgentRun records the outcome, duration, and token usage of a single agent run.
+func (m *Metrics) ObserveAgentRun(agentType, status string, duration time.Duration, tokens int) {
+	if m == nil {
+		return
+	}
+	m.agentRuns.WithLabelValues(agentType, status).Inc()
+	m.agentRunDuration.WithLabelValues(agentType, status).Observe(duration.Seconds())
+	if tokens > 0 {
+		m.agentTokens.WithLabelValues(agentType).Add(float64(tokens))
+	}
+}
+
+// SSEConnectionOpened records a new SSE connection.
+func (m *Metrics) SSEConnectionOpened() {
+	if m == nil {
+		return
+	}
+	m.sseConnections.Inc()
+}
+
+// SSEConnectionClosed records a closed SSE connection.
+func (m *Metrics) SSEConnectionClosed() {
+	if m == nil {
+		return
+	}
+	m.sseConnections.Dec()
+}
+
+// SSEFlap records a connect from a user reconnecting too fast, with the action
+// taken: "backoff" (stream opened with a longer retry hint) or "reject" (429).
+func (m *Metrics) SSEFlap(action string) {
+	if m == nil {
+		return
+	}
+	m.sseFlaps.WithLabelValues(action).Inc()
+}
+
+// EventDropped records an event that was dropped because a connection's buffer was full.
+func (m *Metrics) EventDropped(eventType string) {
+	if m == nil {
+		return
+	}
+	m.eventsDropped.WithLabelValues(eventType).Inc()
+}
+
+// ObserveProjectSync records one project's periodic sync: its outcome
+// ("succeeded", "failed", or "timeout"), duration, and how many of its
+// subtasks failed to sync.
+func (m *Metrics) ObserveProjectSync(status string, duration time.Duration, failures int) {
+	if m == nil {
+		return
+	}
+	m.syncProjectDuration.WithLabelValues(status).Observe(duration.Seconds())
+	if failures > 0 {
+		m.syncErrors.Add(float64(failures))
+	}
+}
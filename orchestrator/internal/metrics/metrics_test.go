@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics_NilReceiverIsNoop(t *testing.T) {
+	var m *Metrics
+
+	// None of these should panic
+	m.ObserveHTTPRequest("GET", "/health", 200, time.Millisecond)
+	m.AgentStarted("PLANNER")
+	m.AgentStopped("PLANNER")
+	m.AgentSpawnFailed("WORKER")
+	m.ObserveAgentRun("WORKER", "succeeded", time.Second, 100)
+	m.SSEConnectionOpened()
+	m.SSEConnectionClosed()
+	m.SSEFlap("backoff")
+	m.EventDropped("agent:log")
+	m.ObserveProjectSync("succeeded", time.Second, 0)
+	m.RegisterDBPool(nil)
+	m.RegisterDataDirFree(nil)
+}
+
+func TestMetrics_AgentLifecycle(t *testing.T) {
+	m := New()
+
+	m.AgentStarted("WORKER")
+	m.AgentStarted("WORKER")
+	m.AgentStopped("WORKER")
+	m.AgentSpawnFailed("WORKER")
+
+	if got := testutil.ToFloat64(m.agentsRunning.WithLabelValues("WORKER")); got != 1 {
+		t.Errorf("expected 1 running worker, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.agentSpawns.WithLabelValues("WORKER")); got != 2 {
+		t.Errorf("expected 2 spawns, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.agentSpawnFailures.WithLabelValues("WORKER")); got != 1 {
+		t.Errorf("expected 1 spawn failure, got %v", got)
+	}
+}
+
+func TestMetrics_ObserveAgentRun(t *testing.T) {
+	m := New()
+
+	m.ObserveAgentRun("PLANNER", "succeeded", 30*time.Second, 1500)
+	m.ObserveAgentRun("PLANNER", "failed", 10*time.Second, 0)
+
+	if got := testutil.ToFloat64(m.agentRuns.WithLabelValues("PLANNER", "succeeded")); got != 1 {
+		t.Errorf("expected 1 succeeded run, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.agentTokens.WithLabelValues("PLANNER")); got != 1500 {
+		t.Errorf("expected 1500 tokens, got %v", got)
+	}
+}
+
+func TestMetrics_EventHubMetrics(t *testing.T) {
+	m := New()
+
+	m.SSEConnectionOpened()
+	m.SSEConnectionOpened()
+	m.SSEConnectionClosed()
+	m.SSEFlap("reject")
+	m.EventDropped("agent:log")
+
+	if got := testutil.ToFloat64(m.sseConnections); got != 1 {
+		t.Errorf("expected 1 connection, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.sseFlaps.WithLabelValues("reject")); got != 1 {
+		t.Errorf("expected 1 rejected flap, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.eventsDropped.WithLabelValues("agent:log")); got != 1 {
+		t.Errorf("expected 1 dropped event, got %v", got)
+	}
+}
+
+func TestMetrics_ObserveProjectSync(t *testing.T) {
+	m := New()
+
+	m.ObserveProjectSync("succeeded", time.Second, 0)
+	m.ObserveProjectSync("timeout", time.Minute, 2)
+
+	if got := testutil.CollectAndCount(m.syncProjectDuration); got != 2 {
+		t.Errorf("expected 2 sync duration series, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.syncErrors); got != 2 {
+		t.Errorf("expected 2 sync errors, got %v", got)
+	}
+}
+
+func TestMetrics_DataDirFree(t *testing.T) {
+	m := New()
+	var free uint64 = 5 << 30
+	ok := true
+	m.RegisterDataDirFree(func() (uint64, bool, error) { return free, ok, nil })
+
+	if got, err := testutil.GatherAndCount(m.Registry(), "intern_village_data_dir_free_bytes"); err != nil || got != 1 {
+		t.Fatalf("GatherAndCount() = %d, %v; want 1", got, err)
+	}
+	want := "# HELP intern_village_data_dir_free_bytes Free space in the data directory.\n" +
+		"# TYPE intern_village_data_dir_free_bytes gauge\n" +
+		"intern_village_data_dir_free_bytes 5.36870912e+09\n"
+	if err := testutil.GatherAndCompare(m.Registry(), strings.NewReader(want), "intern_village_data_dir_free_bytes"); err != nil {
+		t.Error(err)
+	}
+
+	// Omitted where free space cannot be read
+	ok = false
+	if got, err := testutil.GatherAndCount(m.Registry(), "intern_village_data_dir_free_bytes"); err != nil || got != 0 {
+		t.Errorf("GatherAndCount() without free space = %d, %v; want 0", got, err)
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveHTTPRequest("GET", "/api/projects", 200, 5*time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+
+	body := rr.Body.String()
+	for _, want := range []string{
+		"intern_village_http_request_duration_seconds",
+		`route="/api/projects"`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics output to contain %q", want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+// Package netproxy applies the configured outbound HTTP(S) proxy to the
+// GitHub API clients and to the git, bd, and claude subprocesses.
+package netproxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Config is an outbound proxy setup. Its fields follow the HTTP_PROXY,
+// HTTPS_PROXY, and NO_PROXY conventions, except that HTTPSProxy defaults to
+// HTTPProxy: GitHub is only reached over HTTPS, so a single proxy URL is
+// enough. The zero Config leaves the process environment in charge.
+type Config struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	// NoProxy is a comma-separated list of hosts reached directly: host names
+	// (matching subdomains too), IPs, CIDR ranges, an optional :port, or "*".
+	NoProxy string
+}
+
+// Enabled reports whether a proxy is configured.
+func (c Config) Enabled() bool {
+	return c.HTTPProxy != "" || c.HTTPSProxy != ""
+}
+
+// httpsProxy is the proxy used for HTTPS requests.
+func (c Config) httpsProxy() string {
+	if c.HTTPSProxy != "" {
+		return c.HTTPSProxy
+	}
+	return c.HTTPProxy
+}
+
+// Validate checks that the proxy URLs parse and use a supported scheme.
+func (c Config) Validate() error {
+	for _, raw := range []string{c.HTTPProxy, c.HTTPSProxy} {
+		if raw == "" {
+			continue
+		}
+		if _, err := parseProxy(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseProxy parses a proxy URL. As with HTTP_PROXY, a URL without a scheme
+// is taken to be http.
+func parseProxy(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https, socks5, or socks5h", u.Redacted())
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", u.Redacted())
+	}
+	return u, nil
+}
+
+// ProxyFunc returns a function for http.Transport.Proxy that sends requests
+// through the configured proxy unless NoProxy matches their host. Invalid
+// URLs are treated as unset; Validate reports them at startup.
+func (c Config) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	httpProxy, _ := parseProxy(c.HTTPProxy)
+	httpsProxy, _ := parseProxy(c.httpsProxy())
+
+	return func(req *http.Request) (*url.URL, error) {
+		var proxy *url.URL
+		switch req.URL.Scheme {
+		case "https":
+			proxy = httpsProxy
+		case "http":
+			proxy = httpProxy
+		}
+		if proxy == nil || c.bypass(req.URL) {
+			return nil, nil
+		}
+		return proxy, nil
+	}
+}
+
+// bypass reports whether NoProxy matches the URL's host.
+func (c Config) bypass(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(c.NoProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		domain := strings.TrimPrefix(strings.TrimPrefix(entryHost, "*"), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Env returns the proxy settings as environment variables for subprocesses,
+// in both cases since tools differ on which they read (curl, and so git,
+// ignores upper-case HTTP_PROXY). It is empty when no proxy is configured.
+func (c Config) Env() []string {
+	if !c.Enabled() {
+		return nil
+	}
+	var env []string
+	for _, v := range []struct{ name, value string }{
+		{"http_proxy", c.HTTPProxy},
+		{"https_proxy", c.httpsProxy()},
+		{"no_proxy", c.NoProxy},
+	} {
+		if v.value == "" {
+			continue
+		}
+		env = append(env, v.name+"="+v.value, strings.ToUpper(v.name)+"="+v.value)
+	}
+	return env
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package netproxy
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "unset", cfg: Config{}},
+		{name: "http proxy", cfg: Config{HTTPProxy: "http://proxy.corp:3128"}},
+		{name: "scheme defaults to http", cfg: Config{HTTPSProxy: "proxy.corp:3128"}},
+		{name: "socks proxy", cfg: Config{HTTPSProxy: "socks5://127.0.0.1:1080"}},
+		{name: "unsupported scheme", cfg: Config{HTTPProxy: "ftp://proxy.corp"}, wantErr: true},
+		{name: "missing host", cfg: Config{HTTPSProxy: "http://"}, wantErr: true},
+		{name: "unparseable", cfg: Config{HTTPProxy: "http://proxy.corp:port"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_ProxyFunc(t *testing.T) {
+	cfg := Config{
+		HTTPProxy: "http://proxy.corp:3128",
+		NoProxy:   "localhost, .internal.corp, 10.0.0.0/8, ghe.corp:8443",
+	}
+	proxy := cfg.ProxyFunc()
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.github.com/user", "http://proxy.corp:3128"}, // HTTPS falls back to the HTTP proxy
+		{"http://example.com/", "http://proxy.corp:3128"},
+		{"http://localhost:8080/", ""},
+		{"https://git.internal.corp/", ""},
+		{"https://internal.corp/", ""},
+		{"http://10.1.2.3/", ""},
+		{"https://ghe.corp:8443/api/v3", ""},
+		{"https://ghe.corp/api/v3", "http://proxy.corp:3128"}, // port does not match
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		got, err := proxy(req)
+		if err != nil {
+			t.Fatalf("proxy(%s) error = %v", tt.url, err)
+		}
+		gotURL := ""
+		if got != nil {
+			gotURL = got.String()
+		}
+		if gotURL != tt.want {
+			t.Errorf("proxy(%s) = %q, want %q", tt.url, gotURL, tt.want)
+		}
+	}
+}
+
+func TestConfig_ProxyFunc_NoProxyWildcard(t *testing.T) {
+	proxy := Config{HTTPSProxy: "http://proxy.corp:3128", NoProxy: "*"}.ProxyFunc()
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/", nil)
+	if got, _ := proxy(req); got != nil {
+		t.Errorf("proxy() = %v, want direct", got)
+	}
+}
+
+func TestConfig_Env(t *testing.T) {
+	if env := (Config{NoProxy: "localhost"}).Env(); env != nil {
+		t.Errorf("Env() without a proxy = %v, want nil", env)
+	}
+
+	env := Config{HTTPProxy: "http://proxy.corp:3128", NoProxy: "localhost"}.Env()
+	for _, want := range []string{
+		"http_proxy=http://proxy.corp:3128",
+		"HTTP_PROXY=http://proxy.corp:3128",
+		"https_proxy=http://proxy.corp:3128",
+		"HTTPS_PROXY=http://proxy.corp:3128",
+		"no_proxy=localhost",
+		"NO_PROXY=localhost",
+	} {
+		if !slices.Contains(env, want) {
+			t.Errorf("Env() = %v, missing %q", env, want)
+		}
+	}
+}
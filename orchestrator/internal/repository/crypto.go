@@ -5,12 +5,19 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
+// keyIDSeparator separates the key ID from the base64 payload in tagged ciphertexts.
+// It is not part of the standard base64 alphabet, so legacy untagged values are unambiguous.
+const keyIDSeparator = ":"
+
 var (
 	// ErrInvalidKey is returned when the encryption key is invalid.
 	ErrInvalidKey = errors.New("encryption key must be 32 bytes for AES-256")
@@ -23,17 +30,67 @@ var (
 )
 
 // Crypto provides AES-256-GCM encryption and decryption for tokens.
+// Ciphertexts are tagged with the ID of the key that produced them, so the
+// primary key can be rotated while old keys remain available for decryption.
 type Crypto struct {
+	primary cryptoKey
+	oldKeys []cryptoKey
+}
+
+// cryptoKey is an encryption key along with its derived ID.
+type cryptoKey struct {
+	id  string
 	key []byte
 }
 
+// newCryptoKey validates a key and derives its ID.
+func newCryptoKey(key []byte) (cryptoKey, error) {
+	if len(key) != 32 {
+		return cryptoKey{}, ErrInvalidKey
+	}
+	return cryptoKey{id: KeyID(key), key: key}, nil
+}
+
 // NewCrypto creates a new Crypto instance with the given key.
 // The key must be exactly 32 bytes (256 bits) for AES-256.
 func NewCrypto(key []byte) (*Crypto, error) {
-	if len(key) != 32 {
-		return nil, ErrInvalidKey
+	return NewCryptoWithOldKeys(key, nil)
+}
+
+// NewCryptoWithOldKeys creates a Crypto instance that encrypts with the primary key
+// and can still decrypt tokens encrypted with any of the old keys.
+// All keys must be exactly 32 bytes (256 bits) for AES-256.
+func NewCryptoWithOldKeys(primary []byte, oldKeys [][]byte) (*Crypto, error) {
+	primaryKey, err := newCryptoKey(primary)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Crypto{primary: primaryKey}
+	for _, key := range oldKeys {
+		oldKey, err := newCryptoKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid old key: %w", err)
+		}
+		if oldKey.id == primaryKey.id {
+			continue
+		}
+		c.oldKeys = append(c.oldKeys, oldKey)
 	}
-	return &Crypto{key: key}, nil
+
+	return c, nil
+}
+
+// KeyID returns a short, non-secret identifier for a key.
+// It is derived from a SHA-256 hash so the key itself is never exposed.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// PrimaryKeyID returns the ID of the key used for encryption.
+func (c *Crypto) PrimaryKeyID() string {
+	return c.primary.id
 }
 
 // NewCryptoFromString creates a new Crypto instance from a base64-encoded key string.
@@ -45,14 +102,14 @@ func NewCryptoFromString(keyStr string) (*Crypto, error) {
 	return NewCrypto(key)
 }
 
-// EncryptToken encrypts a plaintext token using AES-256-GCM.
-// Returns the base64-encoded ciphertext (nonce prepended to ciphertext).
+// EncryptToken encrypts a plaintext token using AES-256-GCM with the primary key.
+// Returns "{keyID}:{base64 ciphertext}" (nonce prepended to ciphertext).
 func (c *Crypto) EncryptToken(plaintext string) (string, error) {
 	if plaintext == "" {
 		return "", ErrEmptyPlaintext
 	}
 
-	block, err := aes.NewCipher(c.key)
+	block, err := aes.NewCipher(c.primary.key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
@@ -71,23 +128,75 @@ func (c *Crypto) EncryptToken(plaintext string) (string, error) {
 	// Encrypt and prepend nonce to ciphertext
 	ciphertext := aesGCM.Seal(nonce, nonce, []byte(plaintext), nil)
 
-	// Return base64-encoded result
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	// Return key-tagged, base64-encoded result
+	return c.primary.id + keyIDSeparator + base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// DecryptToken decrypts a base64-encoded ciphertext using AES-256-GCM.
-// Expects the nonce to be prepended to the ciphertext.
+// DecryptToken decrypts a ciphertext produced by EncryptToken.
+// Tagged ciphertexts are decrypted with the key they name. Untagged (legacy)
+// ciphertexts are tried against the primary key, then each old key.
 func (c *Crypto) DecryptToken(ciphertext string) (string, error) {
 	if ciphertext == "" {
 		return "", ErrDecryptionFailed
 	}
 
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	keyID, payload, tagged := strings.Cut(ciphertext, keyIDSeparator)
+	if !tagged {
+		payload = ciphertext
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
 	}
 
-	block, err := aes.NewCipher(c.key)
+	for _, key := range c.keys() {
+		if tagged && key.id != keyID {
+			continue
+		}
+		if plaintext, err := decrypt(key.key, data); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return "", ErrDecryptionFailed
+}
+
+// NeedsReEncrypt reports whether a ciphertext was not produced by the primary key.
+func (c *Crypto) NeedsReEncrypt(ciphertext string) bool {
+	keyID, _, tagged := strings.Cut(ciphertext, keyIDSeparator)
+	return !tagged || keyID != c.primary.id
+}
+
+// ReEncrypt decrypts a ciphertext with whichever key produced it and encrypts it
+// again under the primary key. It returns the input unchanged (and false) if the
+// ciphertext already uses the primary key.
+func (c *Crypto) ReEncrypt(ciphertext string) (string, bool, error) {
+	if !c.NeedsReEncrypt(ciphertext) {
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := c.DecryptToken(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+
+	reEncrypted, err := c.EncryptToken(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+
+	return reEncrypted, true, nil
+}
+
+// keys returns the primary key followed by the old keys.
+func (c *Crypto) keys() []cryptoKey {
+	return append([]cryptoKey{c.primary}, c.oldKeys...)
+}
+
+// decrypt decrypts AES-256-GCM data with the nonce prepended.
+func decrypt(key, data []byte) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to create cipher: %w", err)
 	}
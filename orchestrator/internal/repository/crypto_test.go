@@ -1,7 +1,12 @@
 package repository
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
 	"encoding/base64"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -171,10 +176,11 @@ func TestDecryptToken_InvalidInput(t *testing.T) {
 		// Encrypt something valid
 		ct, _ := crypto.EncryptToken("test-token")
 
-		// Decode, tamper, re-encode
-		data, _ := base64.StdEncoding.DecodeString(ct)
+		// Decode, tamper, re-encode (keeping the key ID tag)
+		keyID, payload, _ := strings.Cut(ct, keyIDSeparator)
+		data, _ := base64.StdEncoding.DecodeString(payload)
 		data[len(data)-1] ^= 0xFF // Flip bits in last byte
-		tampered := base64.StdEncoding.EncodeToString(data)
+		tampered := keyID + keyIDSeparator + base64.StdEncoding.EncodeToString(data)
 
 		_, err := crypto.DecryptToken(tampered)
 		if err != ErrDecryptionFailed {
@@ -249,3 +255,139 @@ func TestGenerateKey(t *testing.T) {
 		}
 	})
 }
+
+// testKey returns a deterministic 32-byte key derived from seed.
+func testKey(seed byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i) + seed
+	}
+	return key
+}
+
+// legacyEncrypt produces an untagged ciphertext in the pre-rotation format.
+func legacyEncrypt(t *testing.T, key []byte, plaintext string) string {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("failed to create GCM: %v", err)
+	}
+	nonce := make([]byte, aesGCM.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		t.Fatalf("failed to generate nonce: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(aesGCM.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := testKey(0)
+	newKey := testKey(100)
+
+	oldCrypto, _ := NewCrypto(oldKey)
+	rotated, err := NewCryptoWithOldKeys(newKey, [][]byte{oldKey})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Run("ciphertext is tagged with primary key ID", func(t *testing.T) {
+		ct, _ := rotated.EncryptToken("gho_token")
+		if !strings.HasPrefix(ct, KeyID(newKey)+keyIDSeparator) {
+			t.Errorf("expected ciphertext tagged with %s, got %s", KeyID(newKey), ct)
+		}
+	})
+
+	t.Run("decrypt with old key", func(t *testing.T) {
+		ct, _ := oldCrypto.EncryptToken("gho_old_token")
+
+		plaintext, err := rotated.DecryptToken(ct)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if plaintext != "gho_old_token" {
+			t.Errorf("expected gho_old_token, got %s", plaintext)
+		}
+	})
+
+	t.Run("decrypt legacy untagged ciphertext with old key", func(t *testing.T) {
+		ct := legacyEncrypt(t, oldKey, "gho_legacy_token")
+
+		plaintext, err := rotated.DecryptToken(ct)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if plaintext != "gho_legacy_token" {
+			t.Errorf("expected gho_legacy_token, got %s", plaintext)
+		}
+	})
+
+	t.Run("unknown key fails", func(t *testing.T) {
+		other, _ := NewCrypto(testKey(200))
+		ct, _ := other.EncryptToken("gho_token")
+
+		if _, err := rotated.DecryptToken(ct); err != ErrDecryptionFailed {
+			t.Errorf("expected ErrDecryptionFailed, got %v", err)
+		}
+	})
+
+	t.Run("re-encrypt moves token to primary key", func(t *testing.T) {
+		ct, _ := oldCrypto.EncryptToken("gho_rotate_me")
+		if !rotated.NeedsReEncrypt(ct) {
+			t.Fatal("expected old-key ciphertext to need re-encryption")
+		}
+
+		reEncrypted, changed, err := rotated.ReEncrypt(ct)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !changed {
+			t.Error("expected ciphertext to change")
+		}
+		if rotated.NeedsReEncrypt(reEncrypted) {
+			t.Error("expected re-encrypted ciphertext to use primary key")
+		}
+
+		// Decryptable without the old key
+		newOnly, _ := NewCrypto(newKey)
+		plaintext, err := newOnly.DecryptToken(reEncrypted)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if plaintext != "gho_rotate_me" {
+			t.Errorf("expected gho_rotate_me, got %s", plaintext)
+		}
+	})
+
+	t.Run("re-encrypt is a no-op for primary key ciphertext", func(t *testing.T) {
+		ct, _ := rotated.EncryptToken("gho_current")
+
+		reEncrypted, changed, err := rotated.ReEncrypt(ct)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if changed || reEncrypted != ct {
+			t.Error("expected primary-key ciphertext to be left unchanged")
+		}
+	})
+
+	t.Run("re-encrypt legacy untagged ciphertext", func(t *testing.T) {
+		ct := legacyEncrypt(t, newKey, "gho_untagged")
+
+		reEncrypted, changed, err := rotated.ReEncrypt(ct)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !changed || !strings.HasPrefix(reEncrypted, KeyID(newKey)+keyIDSeparator) {
+			t.Errorf("expected untagged ciphertext to be tagged, got %s", reEncrypted)
+		}
+	})
+
+	t.Run("invalid old key", func(t *testing.T) {
+		if _, err := NewCryptoWithOldKeys(newKey, [][]byte{make([]byte, 16)}); err == nil {
+			t.Error("expected error for invalid old key")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/intern-village/orchestrator/generated/db"
+)
+
+// ReEncryptResult summarizes a ReEncryptAllTokens run.
+type ReEncryptResult struct {
+	Total       int
+	ReEncrypted int
+	Failed      int
+}
+
+// ReEncryptAllTokens re-encrypts every user's GitHub token under the crypto's
+// primary key. Tokens already using the primary key are left untouched, so it
+// is safe to run on every startup. Tokens that cannot be decrypted with any
+// configured key are counted as failed and left as-is.
+func (r *Repository) ReEncryptAllTokens(ctx context.Context, crypto *Crypto) (*ReEncryptResult, error) {
+	users, err := r.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	result := &ReEncryptResult{Total: len(users)}
+	for _, user := range users {
+		token, changed, err := crypto.ReEncrypt(user.GithubToken)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		if _, err := r.UpdateUserToken(ctx, db.UpdateUserTokenParams{
+			ID:          user.ID,
+			GithubToken: token,
+		}); err != nil {
+			return result, fmt.Errorf("failed to update token for user %s: %w", user.ID, err)
+		}
+		result.ReEncrypted++
+	}
+
+	return result, nil
+}
+
+// ReEncryptWebhookSecrets re-encrypts every webhook signing secret under the
+// crypto's primary key, with the same semantics as ReEncryptAllTokens.
+func (r *Repository) ReEncryptWebhookSecrets(ctx context.Context, crypto *Crypto) (*ReEncryptResult, error) {
+	webhooks, err := r.ListWebhooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	result := &ReEncryptResult{Total: len(webhooks)}
+	for _, webhook := range webhooks {
+		secret, changed, err := crypto.ReEncrypt(webhook.Secret)
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		if err := r.UpdateWebhookSecret(ctx, db.UpdateWebhookSecretParams{
+			ID:     webhook.ID,
+			Secret: secret,
+		}); err != nil {
+			return result, fmt.Errorf("failed to update secret for webhook %s: %w", webhook.ID, err)
+		}
+		result.ReEncrypted++
+	}
+
+	return result, nil
+}
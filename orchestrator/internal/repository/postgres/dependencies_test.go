@@ -0,0 +1,123 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/intern-village/orchestrator/generated/db"
+)
+
+// testTx opens a transaction on the migrated database at TEST_DATABASE_URL,
+// rolled back when the test ends. The test is skipped without one.
+func testTx(t *testing.T) pgx.Tx {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	conn, err := Connect(ctx, url, DefaultPoolConfig())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	tx, err := conn.Pool().Begin(ctx)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	t.Cleanup(func() { _ = tx.Rollback(ctx) })
+	return tx
+}
+
+func TestListBlockingDependencies(t *testing.T) {
+	tx := testTx(t)
+	ctx := context.Background()
+
+	var userID, projectID, taskID uuid.UUID
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO users (github_id, github_username, github_token) VALUES ($1, 'dep-test', 'x') RETURNING id`,
+		-int64(uuid.New().ID()),
+	).Scan(&userID); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO projects (user_id, github_owner, github_repo, clone_path, beads_prefix)
+		 VALUES ($1, 'owner', 'repo', '/tmp/repo', 'iv-test') RETURNING id`,
+		userID,
+	).Scan(&projectID); err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO tasks (project_id, title, description, status) VALUES ($1, 'Task', '', 'ACTIVE') RETURNING id`,
+		projectID,
+	).Scan(&taskID); err != nil {
+		t.Fatalf("insert task: %v", err)
+	}
+
+	// Graph: e depends on merged, cancelled, running, and ready; f depends on e.
+	subtask := func(title, status string, position int) uuid.UUID {
+		var id uuid.UUID
+		if err := tx.QueryRow(ctx,
+			`INSERT INTO subtasks (task_id, title, status, position) VALUES ($1, $2, $3, $4) RETURNING id`,
+			taskID, title, status, position,
+		).Scan(&id); err != nil {
+			t.Fatalf("insert subtask %s: %v", title, err)
+		}
+		return id
+	}
+	merged := subtask("merged", "MERGED", 0)
+	cancelled := subtask("cancelled", "CANCELLED", 1)
+	ready := subtask("ready", "READY", 3)
+	running := subtask("running", "IN_PROGRESS", 2)
+	e := subtask("e", "BLOCKED", 4)
+	f := subtask("f", "BLOCKED", 5)
+
+	q := db.New(tx)
+	for _, dep := range [][2]uuid.UUID{{e, merged}, {e, cancelled}, {e, ready}, {e, running}, {f, e}} {
+		if _, err := q.CreateDependency(ctx, db.CreateDependencyParams{SubtaskID: dep[0], DependsOnID: dep[1]}); err != nil {
+			t.Fatalf("create dependency: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		subtask uuid.UUID
+		want    []string
+	}{
+		{name: "only unmerged, uncancelled dependencies in position order", subtask: e, want: []string{"running", "ready"}},
+		{name: "transitive dependencies are not included", subtask: f, want: []string{"e"}},
+		{name: "no dependencies", subtask: merged, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := q.ListBlockingDependencies(ctx, tt.subtask)
+			if err != nil {
+				t.Fatalf("ListBlockingDependencies() error = %v", err)
+			}
+			var got []string
+			for _, row := range rows {
+				got = append(got, row.Title)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("blocking = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("blocking = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
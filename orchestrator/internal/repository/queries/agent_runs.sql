@@ -74,6 +74,36 @@ SELECT * FROM agent_runs
 WHERE task_id = $1
 ORDER BY attempt_number DESC;
 
+-- name: ListAllAgentRunsForTask :many
+-- Returns the task's Planner runs and the Worker runs of all its subtasks, oldest first
+SELECT * FROM agent_runs
+WHERE (task_id = sqlc.arg('task_id')::uuid
+    OR subtask_id IN (SELECT id FROM subtasks WHERE subtasks.task_id = sqlc.arg('task_id')::uuid))
+AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')::text)
+AND (sqlc.narg('agent_type')::text IS NULL OR agent_type = sqlc.narg('agent_type')::text)
+ORDER BY started_at ASC;
+
+-- name: ListInterruptedWorkerRuns :many
+-- Worker runs interrupted by a shutdown that are still their IN_PROGRESS
+-- subtask's latest run, for resuming on startup
+SELECT ar.* FROM agent_runs ar
+JOIN subtasks s ON s.id = ar.subtask_id
+WHERE ar.status = 'INTERRUPTED'
+AND s.status = 'IN_PROGRESS'
+AND NOT EXISTS (
+    SELECT 1 FROM agent_runs later
+    WHERE later.subtask_id = ar.subtask_id
+    AND later.created_at > ar.created_at
+)
+ORDER BY ar.started_at ASC;
+
+-- name: ListRecentAgentRunsBySubtask :many
+-- Latest attempts first, for embedding run history in subtask responses
+SELECT * FROM agent_runs
+WHERE subtask_id = $1
+ORDER BY attempt_number DESC
+LIMIT $2;
+
 -- name: GetLatestAgentRunForTask :one
 -- Get most recent Planner run for a task
 SELECT * FROM agent_runs
@@ -86,6 +116,33 @@ SELECT COUNT(*) AS count
 FROM agent_runs
 WHERE task_id = $1;
 
+-- name: SumPlannerTokenUsageForTask :one
+-- Tokens used by a task's Planner runs; Worker tokens are summed per subtask
+SELECT COALESCE(SUM(token_usage), 0)::int AS token_usage
+FROM agent_runs
+WHERE task_id = $1;
+
+-- name: GetSubtaskRunStats :one
+-- Aggregates of a subtask's Worker runs; durations are in milliseconds and
+-- count finished runs only
+SELECT
+    COUNT(*)::int AS attempt_count,
+    COUNT(*) FILTER (WHERE status = 'SUCCEEDED')::int AS succeeded_count,
+    COUNT(*) FILTER (WHERE status = 'FAILED')::int AS failed_count,
+    COUNT(*) FILTER (WHERE status = 'RUNNING')::int AS running_count,
+    COUNT(*) FILTER (WHERE status = 'INTERRUPTED')::int AS interrupted_count,
+    COALESCE(SUM(EXTRACT(EPOCH FROM ended_at - started_at) * 1000) FILTER (WHERE ended_at IS NOT NULL), 0)::bigint AS total_duration_ms,
+    COALESCE(AVG(EXTRACT(EPOCH FROM ended_at - started_at) * 1000) FILTER (WHERE ended_at IS NOT NULL), 0)::bigint AS average_duration_ms,
+    MAX(started_at)::timestamptz AS last_attempt_at
+FROM agent_runs
+WHERE subtask_id = $1;
+
+-- name: DeleteAgentRunsForTask :exec
+-- Deletes the task's Planner runs and the Worker runs of all its subtasks once they are archived
+DELETE FROM agent_runs
+WHERE task_id = sqlc.arg('task_id')::uuid
+OR subtask_id IN (SELECT id FROM subtasks WHERE subtasks.task_id = sqlc.arg('task_id')::uuid);
+
 -- name: MarkStaleAgentRunsFailed :exec
 UPDATE agent_runs
 SET status = 'FAILED',
@@ -94,6 +151,15 @@ SET status = 'FAILED',
 WHERE status = 'RUNNING'
 AND started_at < $1;
 
+-- name: CountActiveAgentRunsByProject :one
+-- Running Planner and Worker runs for the project summary
+SELECT COUNT(*) AS count
+FROM agent_runs ar
+LEFT JOIN subtasks s ON ar.subtask_id = s.id
+JOIN tasks t ON t.id = COALESCE(ar.task_id, s.task_id)
+WHERE t.project_id = $1
+AND ar.status = 'RUNNING';
+
 -- name: ListActiveAgentRunsByProject :many
 -- Returns both Planner runs (task-level) and Worker runs (subtask-level)
 SELECT
@@ -112,3 +178,60 @@ WHERE (t.project_id = $1 OR (
 ))
 AND ar.status = 'RUNNING'
 ORDER BY ar.started_at ASC;
+
+-- name: ListRunningAgentRunsWithOwners :many
+-- Returns every RUNNING Planner and Worker run across all projects with its
+-- project and owner, oldest first. subtask_token_usage is what the run's
+-- subtask has used in earlier attempts (0 for Planner runs).
+SELECT
+    ar.id,
+    ar.subtask_id,
+    t.id AS task_id,
+    p.id AS project_id,
+    p.github_owner,
+    p.github_repo,
+    u.id AS user_id,
+    u.github_username,
+    ar.agent_type,
+    ar.attempt_number,
+    ar.started_at,
+    COALESCE(s.token_usage, 0)::int AS subtask_token_usage
+FROM agent_runs ar
+LEFT JOIN subtasks s ON ar.subtask_id = s.id
+JOIN tasks t ON t.id = COALESCE(ar.task_id, s.task_id)
+JOIN projects p ON p.id = t.project_id
+JOIN users u ON u.id = p.user_id
+WHERE ar.status = 'RUNNING'
+ORDER BY ar.started_at ASC;
+
+-- name: CreateAgentRunTokenUsage :one
+-- Records one token usage report of a run
+INSERT INTO agent_run_token_usage (
+    agent_run_id,
+    input_tokens,
+    output_tokens,
+    total_tokens,
+    recorded_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListTokenUsageForTask :many
+-- Returns the token usage reports of the task's Planner runs and the Worker
+-- runs of all its subtasks with the run they belong to, oldest first
+SELECT
+    u.id,
+    u.agent_run_id,
+    ar.subtask_id,
+    ar.agent_type,
+    ar.attempt_number,
+    u.input_tokens,
+    u.output_tokens,
+    u.total_tokens,
+    u.recorded_at
+FROM agent_run_token_usage u
+JOIN agent_runs ar ON ar.id = u.agent_run_id
+WHERE ar.task_id = sqlc.arg('task_id')::uuid
+OR ar.subtask_id IN (SELECT id FROM subtasks WHERE subtasks.task_id = sqlc.arg('task_id')::uuid)
+ORDER BY u.recorded_at ASC, u.id ASC;
@@ -0,0 +1,22 @@
+-- Audit log SQL queries
+-- Reference: specs/orchestrator.md §5 (Audit Log)
+
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (
+    project_id,
+    user_id,
+    action,
+    entity_type,
+    entity_id,
+    old_status,
+    new_status
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: ListAuditLogByProject :many
+-- Newest first
+SELECT * FROM audit_log
+WHERE project_id = $1
+ORDER BY created_at DESC, id
+LIMIT $2;
@@ -41,11 +41,27 @@ WHERE subtask_id = $1;
 SELECT COUNT(*) AS count
 FROM subtask_dependencies sd
 JOIN subtasks s ON sd.depends_on_id = s.id
-WHERE sd.subtask_id = $1 AND s.status != 'MERGED';
+WHERE sd.subtask_id = $1 AND s.status NOT IN ('MERGED', 'COMPLETED_NO_CHANGES', 'CANCELLED');
+
+-- name: ListBlockingDependencies :many
+-- Dependencies still blocking a subtask: those not MERGED, COMPLETED_NO_CHANGES or CANCELLED
+SELECT s.id, s.title, s.status
+FROM subtask_dependencies sd
+JOIN subtasks s ON sd.depends_on_id = s.id
+WHERE sd.subtask_id = $1 AND s.status NOT IN ('MERGED', 'COMPLETED_NO_CHANGES', 'CANCELLED')
+ORDER BY s.position ASC, s.created_at ASC;
 
 -- name: HasBlockingDependencies :one
 SELECT EXISTS(
     SELECT 1 FROM subtask_dependencies sd
     JOIN subtasks s ON sd.depends_on_id = s.id
-    WHERE sd.subtask_id = $1 AND s.status != 'MERGED'
+    WHERE sd.subtask_id = $1 AND s.status NOT IN ('MERGED', 'COMPLETED_NO_CHANGES', 'CANCELLED')
 ) AS has_blocking;
+
+-- name: ListDependenciesForTask :many
+-- Every dependency edge between the subtasks of a task
+SELECT sd.*
+FROM subtask_dependencies sd
+JOIN subtasks s ON sd.subtask_id = s.id
+WHERE s.task_id = $1
+ORDER BY sd.created_at ASC;
@@ -0,0 +1,47 @@
+-- Idempotency key SQL queries
+-- Reference: specs/orchestrator.md §5 (Idempotent Requests)
+
+-- name: ClaimIdempotencyKey :one
+-- Records a new in-flight key. Returns no rows if the key is already held,
+-- unless it is an in-flight claim for the same request made before
+-- lease_cutoff (the original request crashed without completing or releasing
+-- it), which is taken over. The returned created_at identifies the claim.
+INSERT INTO idempotency_keys (
+    user_id,
+    key,
+    route,
+    request_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, key, route) DO UPDATE
+SET created_at = NOW(),
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.status_code IS NULL
+    AND idempotency_keys.request_hash = EXCLUDED.request_hash
+    AND idempotency_keys.created_at < sqlc.arg('lease_cutoff')::timestamptz
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND key = $2 AND route = $3 LIMIT 1;
+
+-- name: CompleteIdempotencyKey :exec
+-- Stores the response for a claim. A claim that was taken over no longer
+-- matches claimed_at and is left to its new owner.
+UPDATE idempotency_keys
+SET status_code = $4,
+    response_body = $5
+WHERE user_id = $1 AND key = $2 AND route = $3
+    AND created_at = sqlc.arg('claimed_at')::timestamptz;
+
+-- name: DeleteIdempotencyKey :exec
+-- Releases a claim, unless it was taken over since claimed_at.
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND key = $2 AND route = $3
+    AND created_at = sqlc.arg('claimed_at')::timestamptz;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND expires_at < NOW();
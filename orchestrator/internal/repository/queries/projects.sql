@@ -21,6 +21,10 @@ RETURNING *;
 SELECT * FROM projects
 WHERE id = $1 LIMIT 1;
 
+-- name: GetProjectByClonePath :one
+SELECT * FROM projects
+WHERE clone_path = $1 LIMIT 1;
+
 -- name: GetProjectByOwnerRepo :one
 SELECT * FROM projects
 WHERE user_id = $1 AND github_owner = $2 AND github_repo = $3
@@ -31,6 +35,53 @@ SELECT * FROM projects
 WHERE user_id = $1
 ORDER BY created_at DESC;
 
+-- name: CountProjectsByUser :one
+SELECT COUNT(*) AS count
+FROM projects
+WHERE user_id = $1;
+
+-- name: SumTokenUsageForProject :one
+-- Tokens used by all of a project's Workers and Planners, including archived Planner runs
+SELECT (
+    (SELECT COALESCE(SUM(s.token_usage), 0) FROM subtasks s
+     JOIN tasks t ON s.task_id = t.id
+     WHERE t.project_id = $1)
+  + (SELECT COALESCE(SUM(ar.token_usage), 0) FROM agent_runs ar
+     JOIN tasks t ON ar.task_id = t.id
+     WHERE t.project_id = $1)
+  + (SELECT COALESCE(SUM(ta.planner_token_usage), 0) FROM task_archives ta
+     JOIN tasks t ON ta.task_id = t.id
+     WHERE t.project_id = $1)
+)::bigint AS token_usage;
+
+-- name: ListAllProjects :many
+SELECT * FROM projects
+ORDER BY created_at DESC;
+
+-- name: ListIdleProjects :many
+-- Projects with no task, subtask, or agent run activity since the cutoff and no work in flight
+SELECT p.* FROM projects p
+WHERE p.updated_at < sqlc.arg('idle_since')::timestamptz
+AND NOT EXISTS (
+    SELECT 1 FROM tasks t
+    WHERE t.project_id = p.id
+    AND (t.updated_at >= sqlc.arg('idle_since')::timestamptz OR t.status IN ('PLANNING', 'AWAITING_APPROVAL'))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM subtasks s
+    JOIN tasks t ON s.task_id = t.id
+    WHERE t.project_id = p.id
+    AND (s.updated_at >= sqlc.arg('idle_since')::timestamptz OR s.status = 'IN_PROGRESS')
+)
+AND NOT EXISTS (
+    SELECT 1 FROM agent_runs ar
+    LEFT JOIN subtasks s ON ar.subtask_id = s.id
+    JOIN tasks t ON t.id = COALESCE(ar.task_id, s.task_id)
+    WHERE t.project_id = p.id
+    AND (ar.started_at >= sqlc.arg('idle_since')::timestamptz OR ar.status = 'RUNNING')
+)
+ORDER BY p.updated_at ASC;
+
 -- name: UpdateProject :one
 UPDATE projects
 SET github_owner = $2,
@@ -45,6 +96,39 @@ SET github_owner = $2,
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateProjectMaxSubtasks :one
+-- NULL clears the override so MAX_SUBTASKS_PER_TASK applies
+UPDATE projects
+SET max_subtasks_per_task = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateProjectPRTitleTemplate :one
+-- NULL restores the default PR title format
+UPDATE projects
+SET pr_title_template = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateProjectPathPolicy :one
+-- Limit the repository paths a project's Workers may modify
+UPDATE projects
+SET allowed_paths = $2,
+    denied_paths = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateProjectSquashBeforePR :one
+-- Squash a Worker's commits into one before its PR is opened
+UPDATE projects
+SET squash_before_pr = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteProject :exec
 DELETE FROM projects
 WHERE id = $1;
@@ -9,9 +9,10 @@ INSERT INTO subtasks (
     implementation_plan,
     status,
     position,
-    beads_issue_id
+    beads_issue_id,
+    priority
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
 
@@ -29,18 +30,54 @@ WHERE task_id = $1
 ORDER BY position ASC, created_at ASC;
 
 -- name: UpdateSubtaskStatus :one
+-- With expected_updated_at set, no row is returned if the subtask has changed
+-- since the client read it.
 UPDATE subtasks
-SET status = $2,
-    blocked_reason = $3,
+SET status = sqlc.arg('status'),
+    blocked_reason = sqlc.arg('blocked_reason'),
+    next_attempt_at = NULL,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = sqlc.arg('id')
+    AND (sqlc.narg('expected_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('expected_updated_at')::timestamptz)
+RETURNING *;
+
+-- Atomically moves a subtask to IN_PROGRESS only if it is still in the
+-- expected status, so concurrent start requests cannot both win.
+-- name: ClaimSubtaskForStart :one
+UPDATE subtasks
+SET status = 'IN_PROGRESS',
+    blocked_reason = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND status = sqlc.arg('status')
+    AND (sqlc.narg('expected_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('expected_updated_at')::timestamptz)
+RETURNING *;
+
+-- Atomically blocks a subtask with reason STOPPED only if it is still
+-- IN_PROGRESS, so a stop cannot overwrite a Worker that already finished.
+-- name: StopSubtask :one
+UPDATE subtasks
+SET status = 'BLOCKED',
+    blocked_reason = 'STOPPED',
+    next_attempt_at = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND status = 'IN_PROGRESS'
+    AND (sqlc.narg('expected_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('expected_updated_at')::timestamptz)
 RETURNING *;
 
 -- name: UpdateSubtaskPosition :one
 UPDATE subtasks
-SET position = $2,
+SET position = sqlc.arg('position'),
     updated_at = NOW()
-WHERE id = $1
+WHERE id = sqlc.arg('id')
+    AND (sqlc.narg('expected_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('expected_updated_at')::timestamptz)
+RETURNING *;
+
+-- name: UpdateSubtaskPriority :one
+UPDATE subtasks
+SET priority = sqlc.arg('priority'),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+    AND (sqlc.narg('expected_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('expected_updated_at')::timestamptz)
 RETURNING *;
 
 -- name: UpdateSubtaskPR :one
@@ -59,13 +96,31 @@ SET branch_name = $2,
 WHERE id = $1
 RETURNING *;
 
--- name: UpdateSubtaskRetryCount :one
+-- name: UpdateSubtaskNextAttemptAt :one
+-- Set while a Worker backs off between attempts; NULL clears it
 UPDATE subtasks
-SET retry_count = $2,
+SET next_attempt_at = $2,
     updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateSubtaskRetryCount :one
+UPDATE subtasks
+SET retry_count = sqlc.arg('retry_count'),
+    next_attempt_at = NULL,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+    AND (sqlc.narg('expected_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('expected_updated_at')::timestamptz)
+RETURNING *;
+
+-- name: UpdateSubtaskTokenBudget :one
+UPDATE subtasks
+SET token_budget = sqlc.arg('token_budget'),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+    AND (sqlc.narg('expected_updated_at')::timestamptz IS NULL OR updated_at = sqlc.narg('expected_updated_at')::timestamptz)
+RETURNING *;
+
 -- name: UpdateSubtaskTokenUsage :one
 UPDATE subtasks
 SET token_usage = token_usage + $2,
@@ -91,3 +146,35 @@ ORDER BY created_at DESC;
 SELECT COALESCE(MAX(position), 0) + 1 AS next_position
 FROM subtasks
 WHERE task_id = $1;
+
+-- name: CountSubtasksByStatusForProject :many
+-- Subtask counts per status across all of a project's tasks, for the project summary
+SELECT s.status, COUNT(*) AS count
+FROM subtasks s
+JOIN tasks t ON s.task_id = t.id
+WHERE t.project_id = $1
+GROUP BY s.status;
+
+-- name: ListFailedSubtasksByProject :many
+-- Subtasks BLOCKED by a failure across a project's open tasks, with their
+-- task's title and the error of their latest agent run, highest priority
+-- and then most recently updated first, for the project's "needs attention" list
+SELECT
+    s.id, s.task_id, s.title, s.spec, s.implementation_plan, s.status, s.blocked_reason, s.branch_name, s.pr_url, s.pr_number, s.retry_count, s.token_usage, s.position, s.beads_issue_id, s.worktree_path, s.created_at, s.updated_at, s.next_attempt_at, s.token_budget, s.priority,
+    t.title AS task_title,
+    lr.error_message AS last_error,
+    lr.ended_at AS last_failed_at
+FROM subtasks s
+JOIN tasks t ON s.task_id = t.id
+LEFT JOIN LATERAL (
+    SELECT ar.error_message, ar.ended_at
+    FROM agent_runs ar
+    WHERE ar.subtask_id = s.id
+    ORDER BY ar.attempt_number DESC
+    LIMIT 1
+) lr ON TRUE
+WHERE t.project_id = $1
+AND t.status NOT IN ('DONE', 'CANCELLED')
+AND s.status = 'BLOCKED'
+AND s.blocked_reason = 'FAILURE'
+ORDER BY s.priority ASC, s.updated_at DESC;
@@ -0,0 +1,27 @@
+-- Task archive SQL queries
+-- Reference: specs/orchestrator.md §7.8 (Task Retention)
+
+-- name: CreateTaskArchive :one
+INSERT INTO task_archives (
+    task_id,
+    archive_path,
+    run_count,
+    planner_token_usage,
+    size_bytes
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetTaskArchive :one
+SELECT * FROM task_archives
+WHERE task_id = $1 LIMIT 1;
+
+-- name: ListArchivableTasks :many
+-- DONE and CANCELLED tasks last updated before the cutoff that are not archived yet, oldest first
+SELECT * FROM tasks
+WHERE status IN ('DONE', 'CANCELLED')
+AND updated_at < $1
+AND id NOT IN (SELECT task_id FROM task_archives)
+ORDER BY updated_at
+LIMIT $2;
@@ -6,9 +6,11 @@ INSERT INTO tasks (
     project_id,
     title,
     description,
-    status
+    status,
+    dry_run,
+    base_branch
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6
 )
 RETURNING *;
 
@@ -39,7 +41,32 @@ RETURNING *;
 DELETE FROM tasks
 WHERE id = $1;
 
+-- name: CountActiveTasksByUser :one
+-- Tasks not yet DONE or CANCELLED, across all of a user's projects
+SELECT COUNT(*) AS count
+FROM tasks t
+JOIN projects p ON t.project_id = p.id
+WHERE p.user_id = $1
+AND t.status NOT IN ('DONE', 'CANCELLED');
+
 -- name: GetTasksByStatus :many
 SELECT * FROM tasks
 WHERE status = $1
 ORDER BY created_at DESC;
+
+-- name: CountTasksByStatusForProject :many
+-- Task counts per status for the project summary
+SELECT status, COUNT(*) AS count
+FROM tasks
+WHERE project_id = $1
+GROUP BY status;
+
+-- name: GetTaskCompletionSummary :one
+-- Subtask counts and tokens used by the task's Planner and Workers, for the task:completed event
+SELECT
+    (SELECT COUNT(*) FROM subtasks s WHERE s.task_id = $1) AS subtask_count,
+    (SELECT COUNT(*) FROM subtasks s WHERE s.task_id = $1 AND s.status = 'MERGED') AS merged_count,
+    (
+        (SELECT COALESCE(SUM(s.token_usage), 0) FROM subtasks s WHERE s.task_id = $1)
+      + (SELECT COALESCE(SUM(ar.token_usage), 0) FROM agent_runs ar WHERE ar.task_id = $1)
+    )::bigint AS token_usage;
@@ -19,6 +19,10 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM users
 WHERE github_id = $1 LIMIT 1;
 
+-- name: ListUsers :many
+SELECT * FROM users
+ORDER BY created_at;
+
 -- name: UpdateUserToken :one
 UPDATE users
 SET github_token = $2,
@@ -0,0 +1,37 @@
+-- Webhook SQL queries
+-- Reference: specs/orchestrator.md §5 (Webhooks)
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (
+    project_id,
+    url,
+    secret,
+    event_types
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetWebhookByID :one
+SELECT * FROM webhooks
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhooksByProject :many
+SELECT * FROM webhooks
+WHERE project_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhook :exec
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: ListWebhooks :many
+-- All webhooks, for re-encrypting secrets during key rotation
+SELECT * FROM webhooks
+ORDER BY created_at ASC;
+
+-- name: UpdateWebhookSecret :exec
+UPDATE webhooks
+SET secret = $2,
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,925 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+// Package repotest provides an in-memory implementation of the repository
+// store interfaces for service unit tests.
+package repotest
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+var (
+	_ repository.ProjectStore    = (*Store)(nil)
+	_ repository.TaskStore       = (*Store)(nil)
+	_ repository.SubtaskStore    = (*Store)(nil)
+	_ repository.DependencyStore = (*Store)(nil)
+	_ repository.SyncStore       = (*Store)(nil)
+)
+
+// Store is an in-memory stand-in for the Postgres repository. It mirrors the
+// filtering, ordering, and cascading deletes of the SQL queries it replaces,
+// and reports missing rows as pgx.ErrNoRows. It is safe for concurrent use.
+type Store struct {
+	mu       sync.Mutex
+	now      time.Time
+	projects map[uuid.UUID]db.Project
+	tasks    map[uuid.UUID]db.Task
+	subtasks map[uuid.UUID]db.Subtask
+	deps     map[uuid.UUID]db.SubtaskDependency
+	runs     map[uuid.UUID]db.AgentRun
+	archives map[uuid.UUID]db.TaskArchive
+}
+
+// New creates an empty Store.
+func New() *Store {
+	return &Store{
+		projects: make(map[uuid.UUID]db.Project),
+		tasks:    make(map[uuid.UUID]db.Task),
+		subtasks: make(map[uuid.UUID]db.Subtask),
+		deps:     make(map[uuid.UUID]db.SubtaskDependency),
+		runs:     make(map[uuid.UUID]db.AgentRun),
+		archives: make(map[uuid.UUID]db.TaskArchive),
+	}
+}
+
+// AddAgentRun stores an agent run as-is, filling in its ID and timestamps if
+// they are zero. The services read runs but never create them, so tests seed
+// them here.
+func (s *Store) AddAgentRun(run db.AgentRun) db.AgentRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = s.tick()
+	}
+	if run.CreatedAt.IsZero() {
+		run.CreatedAt = run.StartedAt
+	}
+	s.runs[run.ID] = run
+	return run
+}
+
+// tick returns the current time at Postgres' microsecond precision, always
+// later than the previous call so updated_at comparisons behave as in the
+// database even when calls land in the same microsecond.
+func (s *Store) tick() time.Time {
+	t := time.Now().UTC().Truncate(time.Microsecond)
+	if !t.After(s.now) {
+		t = s.now.Add(time.Microsecond)
+	}
+	s.now = t
+	return t
+}
+
+// sorted returns the map values matching keep, ordered by cmpFn.
+func sorted[T any](m map[uuid.UUID]T, keep func(T) bool, cmpFn func(a, b T) int) []T {
+	var out []T
+	for _, v := range m {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	slices.SortFunc(out, cmpFn)
+	return out
+}
+
+// runTaskID returns the task an agent run belongs to, directly for Planner
+// runs or through its subtask for Worker runs.
+func (s *Store) runTaskID(run db.AgentRun) (uuid.UUID, bool) {
+	if run.TaskID.Valid {
+		return run.TaskID.Bytes, true
+	}
+	if run.SubtaskID.Valid {
+		if st, ok := s.subtasks[run.SubtaskID.Bytes]; ok {
+			return st.TaskID, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+func isBlocking(status string) bool {
+	return status != "MERGED" && status != "COMPLETED_NO_CHANGES" && status != "CANCELLED"
+}
+
+// --- Projects ---
+
+func (s *Store) CountActiveAgentRunsByProject(ctx context.Context, projectID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, r := range s.runs {
+		if taskID, ok := s.runTaskID(r); ok && r.Status == "RUNNING" && s.tasks[taskID].ProjectID == projectID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *Store) CountSubtasksByStatusForProject(ctx context.Context, projectID uuid.UUID) ([]db.CountSubtasksByStatusForProjectRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, st := range s.subtasks {
+		if s.tasks[st.TaskID].ProjectID == projectID {
+			counts[st.Status]++
+		}
+	}
+	rows := make([]db.CountSubtasksByStatusForProjectRow, 0, len(counts))
+	for status, n := range counts {
+		rows = append(rows, db.CountSubtasksByStatusForProjectRow{Status: status, Count: n})
+	}
+	return rows, nil
+}
+
+func (s *Store) CountTasksByStatusForProject(ctx context.Context, projectID uuid.UUID) ([]db.CountTasksByStatusForProjectRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int64)
+	for _, t := range s.tasks {
+		if t.ProjectID == projectID {
+			counts[t.Status]++
+		}
+	}
+	rows := make([]db.CountTasksByStatusForProjectRow, 0, len(counts))
+	for status, n := range counts {
+		rows = append(rows, db.CountTasksByStatusForProjectRow{Status: status, Count: n})
+	}
+	return rows, nil
+}
+
+func (s *Store) CreateProject(ctx context.Context, arg db.CreateProjectParams) (db.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.tick()
+	p := db.Project{
+		ID:            uuid.New(),
+		UserID:        arg.UserID,
+		GithubOwner:   arg.GithubOwner,
+		GithubRepo:    arg.GithubRepo,
+		IsFork:        arg.IsFork,
+		UpstreamOwner: arg.UpstreamOwner,
+		UpstreamRepo:  arg.UpstreamRepo,
+		DefaultBranch: arg.DefaultBranch,
+		ClonePath:     arg.ClonePath,
+		BeadsPrefix:   arg.BeadsPrefix,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.projects[p.ID] = p
+	return p, nil
+}
+
+func (s *Store) DeleteProject(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.projects, id)
+	for _, t := range s.tasks {
+		if t.ProjectID == id {
+			s.deleteTask(t.ID)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetProjectByID(ctx context.Context, id uuid.UUID) (db.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.projects[id]
+	if !ok {
+		return db.Project{}, pgx.ErrNoRows
+	}
+	return p, nil
+}
+
+func (s *Store) GetProjectByClonePath(ctx context.Context, clonePath string) (db.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.projects {
+		if p.ClonePath == clonePath {
+			return p, nil
+		}
+	}
+	return db.Project{}, pgx.ErrNoRows
+}
+
+func (s *Store) GetProjectByOwnerRepo(ctx context.Context, arg db.GetProjectByOwnerRepoParams) (db.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.projects {
+		if p.UserID == arg.UserID && p.GithubOwner == arg.GithubOwner && p.GithubRepo == arg.GithubRepo {
+			return p, nil
+		}
+	}
+	return db.Project{}, pgx.ErrNoRows
+}
+
+func (s *Store) ListActiveAgentRunsByProject(ctx context.Context, projectID uuid.UUID) ([]db.ListActiveAgentRunsByProjectRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := sorted(s.runs, func(r db.AgentRun) bool {
+		if r.Status != "RUNNING" {
+			return false
+		}
+		taskID, ok := s.runTaskID(r)
+		return ok && s.tasks[taskID].ProjectID == projectID
+	}, func(a, b db.AgentRun) int { return a.StartedAt.Compare(b.StartedAt) })
+
+	rows := make([]db.ListActiveAgentRunsByProjectRow, 0, len(runs))
+	for _, r := range runs {
+		taskID, _ := s.runTaskID(r)
+		rows = append(rows, db.ListActiveAgentRunsByProjectRow{
+			ID:        r.ID,
+			SubtaskID: r.SubtaskID,
+			TaskID:    taskID,
+			AgentType: r.AgentType,
+			Status:    r.Status,
+			LogPath:   r.LogPath,
+			StartedAt: r.StartedAt,
+		})
+	}
+	return rows, nil
+}
+
+func (s *Store) ListFailedSubtasksByProject(ctx context.Context, projectID uuid.UUID) ([]db.ListFailedSubtasksByProjectRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subtasks := sorted(s.subtasks, func(st db.Subtask) bool {
+		task := s.tasks[st.TaskID]
+		return task.ProjectID == projectID && task.Status != "DONE" && task.Status != "CANCELLED" &&
+			st.Status == "BLOCKED" && st.BlockedReason != nil && *st.BlockedReason == "FAILURE"
+	}, func(a, b db.Subtask) int {
+		if c := cmp.Compare(a.Priority, b.Priority); c != 0 {
+			return c
+		}
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+
+	rows := make([]db.ListFailedSubtasksByProjectRow, 0, len(subtasks))
+	for _, st := range subtasks {
+		row := db.ListFailedSubtasksByProjectRow{
+			ID:                 st.ID,
+			TaskID:             st.TaskID,
+			Title:              st.Title,
+			Spec:               st.Spec,
+			ImplementationPlan: st.ImplementationPlan,
+			Status:             st.Status,
+			BlockedReason:      st.BlockedReason,
+			BranchName:         st.BranchName,
+			PrUrl:              st.PrUrl,
+			PrNumber:           st.PrNumber,
+			RetryCount:         st.RetryCount,
+			TokenUsage:         st.TokenUsage,
+			Position:           st.Position,
+			BeadsIssueID:       st.BeadsIssueID,
+			WorktreePath:       st.WorktreePath,
+			CreatedAt:          st.CreatedAt,
+			UpdatedAt:          st.UpdatedAt,
+			NextAttemptAt:      st.NextAttemptAt,
+			TokenBudget:        st.TokenBudget,
+			Priority:           st.Priority,
+			TaskTitle:          s.tasks[st.TaskID].Title,
+		}
+		var latest *db.AgentRun
+		for _, r := range s.runs {
+			if r.SubtaskID.Valid && uuid.UUID(r.SubtaskID.Bytes) == st.ID && (latest == nil || r.AttemptNumber > latest.AttemptNumber) {
+				latest = &r
+			}
+		}
+		if latest != nil {
+			row.LastError = latest.ErrorMessage
+			row.LastFailedAt = latest.EndedAt
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *Store) ListIdleProjects(ctx context.Context, idleSince time.Time) ([]db.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	active := make(map[uuid.UUID]bool)
+	for _, t := range s.tasks {
+		if !t.UpdatedAt.Before(idleSince) || t.Status == "PLANNING" || t.Status == "AWAITING_APPROVAL" {
+			active[t.ProjectID] = true
+		}
+	}
+	for _, st := range s.subtasks {
+		if !st.UpdatedAt.Before(idleSince) || st.Status == "IN_PROGRESS" {
+			active[s.tasks[st.TaskID].ProjectID] = true
+		}
+	}
+	for _, r := range s.runs {
+		if !r.StartedAt.Before(idleSince) || r.Status == "RUNNING" {
+			if taskID, ok := s.runTaskID(r); ok {
+				active[s.tasks[taskID].ProjectID] = true
+			}
+		}
+	}
+	return sorted(s.projects, func(p db.Project) bool {
+		return p.UpdatedAt.Before(idleSince) && !active[p.ID]
+	}, func(a, b db.Project) int { return a.UpdatedAt.Compare(b.UpdatedAt) }), nil
+}
+
+func (s *Store) ListProjectsByUser(ctx context.Context, userID uuid.UUID) ([]db.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sorted(s.projects, func(p db.Project) bool { return p.UserID == userID },
+		func(a, b db.Project) int { return b.CreatedAt.Compare(a.CreatedAt) }), nil
+}
+
+func (s *Store) SumTokenUsageForProject(ctx context.Context, projectID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sum int64
+	for _, st := range s.subtasks {
+		if s.tasks[st.TaskID].ProjectID == projectID {
+			sum += int64(st.TokenUsage)
+		}
+	}
+	for _, r := range s.runs {
+		if r.TaskID.Valid && r.TokenUsage != nil && s.tasks[r.TaskID.Bytes].ProjectID == projectID {
+			sum += int64(*r.TokenUsage)
+		}
+	}
+	for _, a := range s.archives {
+		if s.tasks[a.TaskID].ProjectID == projectID {
+			sum += int64(a.PlannerTokenUsage)
+		}
+	}
+	return sum, nil
+}
+
+func (s *Store) UpdateProjectMaxSubtasks(ctx context.Context, arg db.UpdateProjectMaxSubtasksParams) (db.Project, error) {
+	return s.updateProject(arg.ID, func(p *db.Project) { p.MaxSubtasksPerTask = arg.MaxSubtasksPerTask })
+}
+
+func (s *Store) UpdateProjectPRTitleTemplate(ctx context.Context, arg db.UpdateProjectPRTitleTemplateParams) (db.Project, error) {
+	return s.updateProject(arg.ID, func(p *db.Project) { p.PrTitleTemplate = arg.PrTitleTemplate })
+}
+
+func (s *Store) UpdateProjectPathPolicy(ctx context.Context, arg db.UpdateProjectPathPolicyParams) (db.Project, error) {
+	return s.updateProject(arg.ID, func(p *db.Project) {
+		p.AllowedPaths = arg.AllowedPaths
+		p.DeniedPaths = arg.DeniedPaths
+	})
+}
+
+func (s *Store) UpdateProjectSquashBeforePR(ctx context.Context, arg db.UpdateProjectSquashBeforePRParams) (db.Project, error) {
+	return s.updateProject(arg.ID, func(p *db.Project) { p.SquashBeforePr = arg.SquashBeforePr })
+}
+
+func (s *Store) updateProject(id uuid.UUID, update func(*db.Project)) (db.Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.projects[id]
+	if !ok {
+		return db.Project{}, pgx.ErrNoRows
+	}
+	update(&p)
+	p.UpdatedAt = s.tick()
+	s.projects[id] = p
+	return p, nil
+}
+
+// --- Tasks ---
+
+func (s *Store) CreateTask(ctx context.Context, arg db.CreateTaskParams) (db.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.tick()
+	t := db.Task{
+		ID:          uuid.New(),
+		ProjectID:   arg.ProjectID,
+		Title:       arg.Title,
+		Description: arg.Description,
+		Status:      arg.Status,
+		DryRun:      arg.DryRun,
+		BaseBranch:  arg.BaseBranch,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	s.tasks[t.ID] = t
+	return t, nil
+}
+
+func (s *Store) DeleteTask(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteTask(id)
+	return nil
+}
+
+// deleteTask removes a task and everything that cascades from it.
+func (s *Store) deleteTask(id uuid.UUID) {
+	for _, st := range s.subtasks {
+		if st.TaskID == id {
+			s.deleteSubtask(st.ID)
+		}
+	}
+	for _, r := range s.runs {
+		if r.TaskID.Valid && r.TaskID.Bytes == id {
+			delete(s.runs, r.ID)
+		}
+	}
+	delete(s.archives, id)
+	delete(s.tasks, id)
+}
+
+// deleteSubtask removes a subtask with its dependency edges and agent runs.
+func (s *Store) deleteSubtask(id uuid.UUID) {
+	for _, d := range s.deps {
+		if d.SubtaskID == id || d.DependsOnID == id {
+			delete(s.deps, d.ID)
+		}
+	}
+	for _, r := range s.runs {
+		if r.SubtaskID.Valid && r.SubtaskID.Bytes == id {
+			delete(s.runs, r.ID)
+		}
+	}
+	delete(s.subtasks, id)
+}
+
+func (s *Store) GetTaskByID(ctx context.Context, id uuid.UUID) (db.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return db.Task{}, pgx.ErrNoRows
+	}
+	return t, nil
+}
+
+func (s *Store) GetTaskCompletionSummary(ctx context.Context, taskID uuid.UUID) (db.GetTaskCompletionSummaryRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var row db.GetTaskCompletionSummaryRow
+	for _, st := range s.subtasks {
+		if st.TaskID != taskID {
+			continue
+		}
+		row.SubtaskCount++
+		if st.Status == "MERGED" {
+			row.MergedCount++
+		}
+		row.TokenUsage += int64(st.TokenUsage)
+	}
+	for _, r := range s.runs {
+		if r.TaskID.Valid && r.TaskID.Bytes == taskID && r.TokenUsage != nil {
+			row.TokenUsage += int64(*r.TokenUsage)
+		}
+	}
+	return row, nil
+}
+
+func (s *Store) ListTasksByProject(ctx context.Context, projectID uuid.UUID) ([]db.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sorted(s.tasks, func(t db.Task) bool { return t.ProjectID == projectID },
+		func(a, b db.Task) int { return b.CreatedAt.Compare(a.CreatedAt) }), nil
+}
+
+func (s *Store) UpdateTaskBeadsEpicID(ctx context.Context, arg db.UpdateTaskBeadsEpicIDParams) (db.Task, error) {
+	return s.updateTask(arg.ID, func(t *db.Task) { t.BeadsEpicID = arg.BeadsEpicID })
+}
+
+func (s *Store) UpdateTaskStatus(ctx context.Context, arg db.UpdateTaskStatusParams) (db.Task, error) {
+	return s.updateTask(arg.ID, func(t *db.Task) { t.Status = arg.Status })
+}
+
+func (s *Store) updateTask(id uuid.UUID, update func(*db.Task)) (db.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok {
+		return db.Task{}, pgx.ErrNoRows
+	}
+	update(&t)
+	t.UpdatedAt = s.tick()
+	s.tasks[id] = t
+	return t, nil
+}
+
+// --- Task archives ---
+
+func (s *Store) ArchiveTaskRuns(ctx context.Context, arg db.CreateTaskArchiveParams) (db.TaskArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[arg.TaskID]; !ok {
+		return db.TaskArchive{}, pgx.ErrNoRows
+	}
+	for _, r := range s.runs {
+		if taskID, ok := s.runTaskID(r); ok && taskID == arg.TaskID {
+			delete(s.runs, r.ID)
+		}
+	}
+	a := db.TaskArchive{
+		TaskID:            arg.TaskID,
+		ArchivePath:       arg.ArchivePath,
+		RunCount:          arg.RunCount,
+		PlannerTokenUsage: arg.PlannerTokenUsage,
+		SizeBytes:         arg.SizeBytes,
+		ArchivedAt:        s.tick(),
+	}
+	s.archives[a.TaskID] = a
+	return a, nil
+}
+
+func (s *Store) GetTaskArchive(ctx context.Context, taskID uuid.UUID) (db.TaskArchive, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.archives[taskID]
+	if !ok {
+		return db.TaskArchive{}, pgx.ErrNoRows
+	}
+	return a, nil
+}
+
+func (s *Store) ListArchivableTasks(ctx context.Context, arg db.ListArchivableTasksParams) ([]db.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tasks := sorted(s.tasks, func(t db.Task) bool {
+		_, archived := s.archives[t.ID]
+		return (t.Status == "DONE" || t.Status == "CANCELLED") && t.UpdatedAt.Before(arg.UpdatedAt) && !archived
+	}, func(a, b db.Task) int { return a.UpdatedAt.Compare(b.UpdatedAt) })
+	if len(tasks) > int(arg.Limit) {
+		tasks = tasks[:arg.Limit]
+	}
+	return tasks, nil
+}
+
+// --- Agent runs ---
+
+func (s *Store) GetLatestAgentRun(ctx context.Context, subtaskID pgtype.UUID) (db.AgentRun, error) {
+	runs, _ := s.ListRecentAgentRunsBySubtask(ctx, db.ListRecentAgentRunsBySubtaskParams{SubtaskID: subtaskID, Limit: 1})
+	if len(runs) == 0 {
+		return db.AgentRun{}, pgx.ErrNoRows
+	}
+	return runs[0], nil
+}
+
+func (s *Store) ListAllAgentRunsForTask(ctx context.Context, arg db.ListAllAgentRunsForTaskParams) ([]db.AgentRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sorted(s.runs, func(r db.AgentRun) bool {
+		taskID, ok := s.runTaskID(r)
+		return ok && taskID == arg.TaskID &&
+			(arg.Status == nil || r.Status == *arg.Status) &&
+			(arg.AgentType == nil || r.AgentType == *arg.AgentType)
+	}, func(a, b db.AgentRun) int { return a.StartedAt.Compare(b.StartedAt) }), nil
+}
+
+func (s *Store) ListRecentAgentRunsBySubtask(ctx context.Context, arg db.ListRecentAgentRunsBySubtaskParams) ([]db.AgentRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := sorted(s.runs, func(r db.AgentRun) bool { return arg.SubtaskID.Valid && r.SubtaskID == arg.SubtaskID },
+		func(a, b db.AgentRun) int { return cmp.Compare(b.AttemptNumber, a.AttemptNumber) })
+	if len(runs) > int(arg.Limit) {
+		runs = runs[:arg.Limit]
+	}
+	return runs, nil
+}
+
+func (s *Store) SumPlannerTokenUsageForTask(ctx context.Context, taskID pgtype.UUID) (int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var sum int32
+	for _, r := range s.runs {
+		if taskID.Valid && r.TaskID == taskID && r.TokenUsage != nil {
+			sum += *r.TokenUsage
+		}
+	}
+	return sum, nil
+}
+
+func (s *Store) UpdateAgentRunStatus(ctx context.Context, arg db.UpdateAgentRunStatusParams) (db.AgentRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.runs[arg.ID]
+	if !ok {
+		return db.AgentRun{}, pgx.ErrNoRows
+	}
+	r.Status = arg.Status
+	r.EndedAt = arg.EndedAt
+	r.ErrorMessage = arg.ErrorMessage
+	s.runs[r.ID] = r
+	return r, nil
+}
+
+// --- Subtasks ---
+
+func (s *Store) ClaimSubtaskForStart(ctx context.Context, arg db.ClaimSubtaskForStartParams) (db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.subtasks[arg.ID]
+	if !ok || st.Status != arg.Status || !unmodified(st, arg.ExpectedUpdatedAt) {
+		return db.Subtask{}, pgx.ErrNoRows
+	}
+	st.Status = "IN_PROGRESS"
+	st.BlockedReason = nil
+	st.UpdatedAt = s.tick()
+	s.subtasks[st.ID] = st
+	return st, nil
+}
+
+func (s *Store) CreateSubtask(ctx context.Context, arg db.CreateSubtaskParams) (db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[arg.TaskID]; !ok {
+		return db.Subtask{}, pgx.ErrNoRows
+	}
+	now := s.tick()
+	st := db.Subtask{
+		ID:                 uuid.New(),
+		TaskID:             arg.TaskID,
+		Title:              arg.Title,
+		Spec:               arg.Spec,
+		ImplementationPlan: arg.ImplementationPlan,
+		Status:             arg.Status,
+		Position:           arg.Position,
+		BeadsIssueID:       arg.BeadsIssueID,
+		Priority:           arg.Priority,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	s.subtasks[st.ID] = st
+	return st, nil
+}
+
+func (s *Store) GetNextPosition(ctx context.Context, taskID uuid.UUID) (int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var maxPos int32
+	for _, st := range s.subtasks {
+		if st.TaskID == taskID {
+			maxPos = max(maxPos, st.Position)
+		}
+	}
+	return maxPos + 1, nil
+}
+
+func (s *Store) GetSubtaskByBeadsID(ctx context.Context, beadsIssueID *string) (db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if beadsIssueID == nil {
+		return db.Subtask{}, pgx.ErrNoRows
+	}
+	for _, st := range s.subtasks {
+		if st.BeadsIssueID != nil && *st.BeadsIssueID == *beadsIssueID {
+			return st, nil
+		}
+	}
+	return db.Subtask{}, pgx.ErrNoRows
+}
+
+func (s *Store) GetSubtaskByID(ctx context.Context, id uuid.UUID) (db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.subtasks[id]
+	if !ok {
+		return db.Subtask{}, pgx.ErrNoRows
+	}
+	return st, nil
+}
+
+func (s *Store) ListInProgressSubtasks(ctx context.Context) ([]db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sorted(s.subtasks, func(st db.Subtask) bool { return st.Status == "IN_PROGRESS" },
+		func(a, b db.Subtask) int { return b.CreatedAt.Compare(a.CreatedAt) }), nil
+}
+
+func (s *Store) ListSubtasksByTask(ctx context.Context, taskID uuid.UUID) ([]db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sorted(s.subtasks, func(st db.Subtask) bool { return st.TaskID == taskID }, compareSubtasks), nil
+}
+
+// compareSubtasks orders subtasks by position, then creation time.
+func compareSubtasks(a, b db.Subtask) int {
+	if c := cmp.Compare(a.Position, b.Position); c != 0 {
+		return c
+	}
+	return a.CreatedAt.Compare(b.CreatedAt)
+}
+
+func (s *Store) StopSubtask(ctx context.Context, arg db.StopSubtaskParams) (db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.subtasks[arg.ID]
+	if !ok || st.Status != "IN_PROGRESS" || !unmodified(st, arg.ExpectedUpdatedAt) {
+		return db.Subtask{}, pgx.ErrNoRows
+	}
+	reason := "STOPPED"
+	st.Status = "BLOCKED"
+	st.BlockedReason = &reason
+	st.NextAttemptAt = pgtype.Timestamptz{}
+	st.UpdatedAt = s.tick()
+	s.subtasks[st.ID] = st
+	return st, nil
+}
+
+func (s *Store) UpdateSubtaskBranch(ctx context.Context, arg db.UpdateSubtaskBranchParams) (db.Subtask, error) {
+	return s.updateSubtask(arg.ID, func(st *db.Subtask) {
+		st.BranchName = arg.BranchName
+		st.WorktreePath = arg.WorktreePath
+	})
+}
+
+func (s *Store) UpdateSubtaskNextAttemptAt(ctx context.Context, arg db.UpdateSubtaskNextAttemptAtParams) (db.Subtask, error) {
+	return s.updateSubtask(arg.ID, func(st *db.Subtask) { st.NextAttemptAt = arg.NextAttemptAt })
+}
+
+func (s *Store) UpdateSubtaskPR(ctx context.Context, arg db.UpdateSubtaskPRParams) (db.Subtask, error) {
+	return s.updateSubtask(arg.ID, func(st *db.Subtask) {
+		st.PrUrl = arg.PrUrl
+		st.PrNumber = arg.PrNumber
+	})
+}
+
+func (s *Store) UpdateSubtaskPosition(ctx context.Context, arg db.UpdateSubtaskPositionParams) (db.Subtask, error) {
+	return s.updateSubtaskIfUnmodified(arg.ID, arg.ExpectedUpdatedAt, func(st *db.Subtask) { st.Position = arg.Position })
+}
+
+func (s *Store) UpdateSubtaskPriority(ctx context.Context, arg db.UpdateSubtaskPriorityParams) (db.Subtask, error) {
+	return s.updateSubtaskIfUnmodified(arg.ID, arg.ExpectedUpdatedAt, func(st *db.Subtask) { st.Priority = arg.Priority })
+}
+
+func (s *Store) UpdateSubtaskRetryCount(ctx context.Context, arg db.UpdateSubtaskRetryCountParams) (db.Subtask, error) {
+	return s.updateSubtaskIfUnmodified(arg.ID, arg.ExpectedUpdatedAt, func(st *db.Subtask) {
+		st.RetryCount = arg.RetryCount
+		st.NextAttemptAt = pgtype.Timestamptz{}
+	})
+}
+
+func (s *Store) UpdateSubtaskStatus(ctx context.Context, arg db.UpdateSubtaskStatusParams) (db.Subtask, error) {
+	return s.updateSubtaskIfUnmodified(arg.ID, arg.ExpectedUpdatedAt, func(st *db.Subtask) {
+		st.Status = arg.Status
+		st.BlockedReason = arg.BlockedReason
+		st.NextAttemptAt = pgtype.Timestamptz{}
+	})
+}
+
+func (s *Store) UpdateSubtaskTokenBudget(ctx context.Context, arg db.UpdateSubtaskTokenBudgetParams) (db.Subtask, error) {
+	return s.updateSubtaskIfUnmodified(arg.ID, arg.ExpectedUpdatedAt, func(st *db.Subtask) { st.TokenBudget = arg.TokenBudget })
+}
+
+func (s *Store) UpdateSubtaskTokenUsage(ctx context.Context, arg db.UpdateSubtaskTokenUsageParams) (db.Subtask, error) {
+	return s.updateSubtask(arg.ID, func(st *db.Subtask) { st.TokenUsage += arg.TokenUsage })
+}
+
+func (s *Store) updateSubtask(id uuid.UUID, update func(*db.Subtask)) (db.Subtask, error) {
+	return s.updateSubtaskIfUnmodified(id, nil, update)
+}
+
+// updateSubtaskIfUnmodified applies update unless expectedUpdatedAt is set and
+// no longer matches, emulating the expected_updated_at predicate.
+func (s *Store) updateSubtaskIfUnmodified(id uuid.UUID, expectedUpdatedAt *time.Time, update func(*db.Subtask)) (db.Subtask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.subtasks[id]
+	if !ok || !unmodified(st, expectedUpdatedAt) {
+		return db.Subtask{}, pgx.ErrNoRows
+	}
+	update(&st)
+	st.UpdatedAt = s.tick()
+	s.subtasks[id] = st
+	return st, nil
+}
+
+// unmodified reports whether st still has the expected updated_at; a nil
+// expectation always matches.
+func unmodified(st db.Subtask, expectedUpdatedAt *time.Time) bool {
+	return expectedUpdatedAt == nil || st.UpdatedAt.Equal(*expectedUpdatedAt)
+}
+
+// --- Dependencies ---
+
+// CreateDependency returns pgx.ErrNoRows for an existing edge, as the
+// ON CONFLICT DO NOTHING insert does.
+func (s *Store) CreateDependency(ctx context.Context, arg db.CreateDependencyParams) (db.SubtaskDependency, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deps {
+		if d.SubtaskID == arg.SubtaskID && d.DependsOnID == arg.DependsOnID {
+			return db.SubtaskDependency{}, pgx.ErrNoRows
+		}
+	}
+	d := db.SubtaskDependency{
+		ID:          uuid.New(),
+		SubtaskID:   arg.SubtaskID,
+		DependsOnID: arg.DependsOnID,
+		CreatedAt:   s.tick(),
+	}
+	s.deps[d.ID] = d
+	return d, nil
+}
+
+func (s *Store) DeleteDependenciesForSubtask(ctx context.Context, subtaskID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deps {
+		if d.SubtaskID == subtaskID {
+			delete(s.deps, d.ID)
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteDependency(ctx context.Context, arg db.DeleteDependencyParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deps {
+		if d.SubtaskID == arg.SubtaskID && d.DependsOnID == arg.DependsOnID {
+			delete(s.deps, d.ID)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetDependenciesForSubtask(ctx context.Context, subtaskID uuid.UUID) ([]db.GetDependenciesForSubtaskRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []db.GetDependenciesForSubtaskRow
+	for _, d := range s.sortedDeps(func(d db.SubtaskDependency) bool { return d.SubtaskID == subtaskID }) {
+		if dep, ok := s.subtasks[d.DependsOnID]; ok {
+			rows = append(rows, db.GetDependenciesForSubtaskRow{
+				ID:               d.ID,
+				SubtaskID:        d.SubtaskID,
+				DependsOnID:      d.DependsOnID,
+				CreatedAt:        d.CreatedAt,
+				DependencyStatus: dep.Status,
+			})
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) GetDependentsOfSubtask(ctx context.Context, dependsOnID uuid.UUID) ([]db.GetDependentsOfSubtaskRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []db.GetDependentsOfSubtaskRow
+	for _, d := range s.sortedDeps(func(d db.SubtaskDependency) bool { return d.DependsOnID == dependsOnID }) {
+		if dependent, ok := s.subtasks[d.SubtaskID]; ok {
+			rows = append(rows, db.GetDependentsOfSubtaskRow{
+				ID:              d.ID,
+				SubtaskID:       d.SubtaskID,
+				DependsOnID:     d.DependsOnID,
+				CreatedAt:       d.CreatedAt,
+				DependentStatus: dependent.Status,
+			})
+		}
+	}
+	return rows, nil
+}
+
+func (s *Store) HasBlockingDependencies(ctx context.Context, subtaskID uuid.UUID) (bool, error) {
+	rows, _ := s.ListBlockingDependencies(ctx, subtaskID)
+	return len(rows) > 0, nil
+}
+
+func (s *Store) ListBlockingDependencies(ctx context.Context, subtaskID uuid.UUID) ([]db.ListBlockingDependenciesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var blocking []db.Subtask
+	for _, d := range s.deps {
+		if dep, ok := s.subtasks[d.DependsOnID]; ok && d.SubtaskID == subtaskID && isBlocking(dep.Status) {
+			blocking = append(blocking, dep)
+		}
+	}
+	slices.SortFunc(blocking, compareSubtasks)
+
+	rows := make([]db.ListBlockingDependenciesRow, 0, len(blocking))
+	for _, st := range blocking {
+		rows = append(rows, db.ListBlockingDependenciesRow{ID: st.ID, Title: st.Title, Status: st.Status})
+	}
+	return rows, nil
+}
+
+func (s *Store) ListDependenciesForTask(ctx context.Context, taskID uuid.UUID) ([]db.SubtaskDependency, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedDeps(func(d db.SubtaskDependency) bool {
+		st, ok := s.subtasks[d.SubtaskID]
+		return ok && st.TaskID == taskID
+	}), nil
+}
+
+// sortedDeps returns the dependency edges matching keep, oldest first.
+func (s *Store) sortedDeps(keep func(db.SubtaskDependency) bool) []db.SubtaskDependency {
+	return sorted(s.deps, keep, func(a, b db.SubtaskDependency) int { return a.CreatedAt.Compare(b.CreatedAt) })
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+)
+
+func TestStore_MirrorsQueries(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	project, _ := s.CreateProject(ctx, db.CreateProjectParams{UserID: uuid.New()})
+	task, _ := s.CreateTask(ctx, db.CreateTaskParams{ProjectID: project.ID, Status: "ACTIVE"})
+	second, _ := s.CreateSubtask(ctx, db.CreateSubtaskParams{TaskID: task.ID, Status: "READY", Position: 2})
+	first, _ := s.CreateSubtask(ctx, db.CreateSubtaskParams{TaskID: task.ID, Status: "READY", Position: 1})
+
+	subtasks, _ := s.ListSubtasksByTask(ctx, task.ID)
+	if len(subtasks) != 2 || subtasks[0].ID != first.ID || subtasks[1].ID != second.ID {
+		t.Errorf("ListSubtasksByTask() should order by position, got %+v", subtasks)
+	}
+	if next, _ := s.GetNextPosition(ctx, task.ID); next != 3 {
+		t.Errorf("GetNextPosition() = %d, want 3", next)
+	}
+
+	// Conditional updates only match the expected row state
+	if _, err := s.ClaimSubtaskForStart(ctx, db.ClaimSubtaskForStartParams{ID: first.ID, Status: "BLOCKED"}); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("ClaimSubtaskForStart() with the wrong status error = %v, want ErrNoRows", err)
+	}
+	readAt := first.UpdatedAt
+	updated, err := s.UpdateSubtaskPriority(ctx, db.UpdateSubtaskPriorityParams{ID: first.ID, Priority: 1, ExpectedUpdatedAt: &readAt})
+	if err != nil {
+		t.Fatalf("UpdateSubtaskPriority() error = %v", err)
+	}
+	if !updated.UpdatedAt.After(readAt) {
+		t.Error("UpdateSubtaskPriority() should bump updated_at")
+	}
+	if _, err := s.UpdateSubtaskPriority(ctx, db.UpdateSubtaskPriorityParams{ID: first.ID, Priority: 2, ExpectedUpdatedAt: &readAt}); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("UpdateSubtaskPriority() with a stale expected_updated_at error = %v, want ErrNoRows", err)
+	}
+
+	// Duplicate edges are ignored like ON CONFLICT DO NOTHING
+	edge := db.CreateDependencyParams{SubtaskID: second.ID, DependsOnID: first.ID}
+	if _, err := s.CreateDependency(ctx, edge); err != nil {
+		t.Fatalf("CreateDependency() error = %v", err)
+	}
+	if _, err := s.CreateDependency(ctx, edge); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("duplicate CreateDependency() error = %v, want ErrNoRows", err)
+	}
+	if blocked, _ := s.HasBlockingDependencies(ctx, second.ID); !blocked {
+		t.Error("HasBlockingDependencies() = false, want true while the dependency is READY")
+	}
+
+	// Deleting the project cascades to everything under it
+	s.AddAgentRun(db.AgentRun{SubtaskID: pgtype.UUID{Bytes: first.ID, Valid: true}, Status: "RUNNING"})
+	if err := s.DeleteProject(ctx, project.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetSubtaskByID(ctx, first.ID); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetSubtaskByID() after project delete error = %v, want ErrNoRows", err)
+	}
+	if len(s.deps) != 0 || len(s.runs) != 0 {
+		t.Errorf("project delete left %d dependencies and %d runs", len(s.deps), len(s.runs))
+	}
+}
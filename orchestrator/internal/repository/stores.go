@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+)
+
+// The store interfaces below are the queries each service uses. Repository
+// implements all of them against Postgres; repotest.Store implements them in
+// memory so service logic can be unit-tested without a database. Like the
+// generated queries, a missing row is reported as pgx.ErrNoRows.
+
+// ProjectStore is the data access ProjectService needs.
+type ProjectStore interface {
+	CountActiveAgentRunsByProject(ctx context.Context, projectID uuid.UUID) (int64, error)
+	CountSubtasksByStatusForProject(ctx context.Context, projectID uuid.UUID) ([]db.CountSubtasksByStatusForProjectRow, error)
+	CountTasksByStatusForProject(ctx context.Context, projectID uuid.UUID) ([]db.CountTasksByStatusForProjectRow, error)
+	CreateProject(ctx context.Context, arg db.CreateProjectParams) (db.Project, error)
+	DeleteProject(ctx context.Context, id uuid.UUID) error
+	GetProjectByClonePath(ctx context.Context, clonePath string) (db.Project, error)
+	GetProjectByID(ctx context.Context, id uuid.UUID) (db.Project, error)
+	GetProjectByOwnerRepo(ctx context.Context, arg db.GetProjectByOwnerRepoParams) (db.Project, error)
+	ListActiveAgentRunsByProject(ctx context.Context, projectID uuid.UUID) ([]db.ListActiveAgentRunsByProjectRow, error)
+	ListFailedSubtasksByProject(ctx context.Context, projectID uuid.UUID) ([]db.ListFailedSubtasksByProjectRow, error)
+	ListIdleProjects(ctx context.Context, idleSince time.Time) ([]db.Project, error)
+	ListProjectsByUser(ctx context.Context, userID uuid.UUID) ([]db.Project, error)
+	SumTokenUsageForProject(ctx context.Context, projectID uuid.UUID) (int64, error)
+	UpdateProjectMaxSubtasks(ctx context.Context, arg db.UpdateProjectMaxSubtasksParams) (db.Project, error)
+	UpdateProjectPRTitleTemplate(ctx context.Context, arg db.UpdateProjectPRTitleTemplateParams) (db.Project, error)
+	UpdateProjectPathPolicy(ctx context.Context, arg db.UpdateProjectPathPolicyParams) (db.Project, error)
+	UpdateProjectSquashBeforePR(ctx context.Context, arg db.UpdateProjectSquashBeforePRParams) (db.Project, error)
+}
+
+// TaskStore is the data access TaskService needs.
+type TaskStore interface {
+	ArchiveTaskRuns(ctx context.Context, arg db.CreateTaskArchiveParams) (db.TaskArchive, error)
+	CreateTask(ctx context.Context, arg db.CreateTaskParams) (db.Task, error)
+	DeleteTask(ctx context.Context, id uuid.UUID) error
+	GetLatestAgentRun(ctx context.Context, subtaskID pgtype.UUID) (db.AgentRun, error)
+	GetTaskArchive(ctx context.Context, taskID uuid.UUID) (db.TaskArchive, error)
+	GetTaskByID(ctx context.Context, id uuid.UUID) (db.Task, error)
+	GetTaskCompletionSummary(ctx context.Context, taskID uuid.UUID) (db.GetTaskCompletionSummaryRow, error)
+	ListAllAgentRunsForTask(ctx context.Context, arg db.ListAllAgentRunsForTaskParams) ([]db.AgentRun, error)
+	ListArchivableTasks(ctx context.Context, arg db.ListArchivableTasksParams) ([]db.Task, error)
+	ListDependenciesForTask(ctx context.Context, taskID uuid.UUID) ([]db.SubtaskDependency, error)
+	ListSubtasksByTask(ctx context.Context, taskID uuid.UUID) ([]db.Subtask, error)
+	ListTasksByProject(ctx context.Context, projectID uuid.UUID) ([]db.Task, error)
+	SumPlannerTokenUsageForTask(ctx context.Context, taskID pgtype.UUID) (int32, error)
+	UpdateAgentRunStatus(ctx context.Context, arg db.UpdateAgentRunStatusParams) (db.AgentRun, error)
+	UpdateSubtaskStatus(ctx context.Context, arg db.UpdateSubtaskStatusParams) (db.Subtask, error)
+	UpdateTaskBeadsEpicID(ctx context.Context, arg db.UpdateTaskBeadsEpicIDParams) (db.Task, error)
+	UpdateTaskStatus(ctx context.Context, arg db.UpdateTaskStatusParams) (db.Task, error)
+}
+
+// SubtaskStore is the data access SubtaskService needs.
+type SubtaskStore interface {
+	ClaimSubtaskForStart(ctx context.Context, arg db.ClaimSubtaskForStartParams) (db.Subtask, error)
+	CreateSubtask(ctx context.Context, arg db.CreateSubtaskParams) (db.Subtask, error)
+	GetNextPosition(ctx context.Context, taskID uuid.UUID) (int32, error)
+	GetSubtaskByBeadsID(ctx context.Context, beadsIssueID *string) (db.Subtask, error)
+	GetSubtaskByID(ctx context.Context, id uuid.UUID) (db.Subtask, error)
+	ListRecentAgentRunsBySubtask(ctx context.Context, arg db.ListRecentAgentRunsBySubtaskParams) ([]db.AgentRun, error)
+	ListSubtasksByTask(ctx context.Context, taskID uuid.UUID) ([]db.Subtask, error)
+	StopSubtask(ctx context.Context, arg db.StopSubtaskParams) (db.Subtask, error)
+	UpdateSubtaskBranch(ctx context.Context, arg db.UpdateSubtaskBranchParams) (db.Subtask, error)
+	UpdateSubtaskNextAttemptAt(ctx context.Context, arg db.UpdateSubtaskNextAttemptAtParams) (db.Subtask, error)
+	UpdateSubtaskPR(ctx context.Context, arg db.UpdateSubtaskPRParams) (db.Subtask, error)
+	UpdateSubtaskPosition(ctx context.Context, arg db.UpdateSubtaskPositionParams) (db.Subtask, error)
+	UpdateSubtaskPriority(ctx context.Context, arg db.UpdateSubtaskPriorityParams) (db.Subtask, error)
+	UpdateSubtaskRetryCount(ctx context.Context, arg db.UpdateSubtaskRetryCountParams) (db.Subtask, error)
+	UpdateSubtaskStatus(ctx context.Context, arg db.UpdateSubtaskStatusParams) (db.Subtask, error)
+	UpdateSubtaskTokenBudget(ctx context.Context, arg db.UpdateSubtaskTokenBudgetParams) (db.Subtask, error)
+	UpdateSubtaskTokenUsage(ctx context.Context, arg db.UpdateSubtaskTokenUsageParams) (db.Subtask, error)
+}
+
+// DependencyStore is the data access DependencyService needs.
+type DependencyStore interface {
+	CreateDependency(ctx context.Context, arg db.CreateDependencyParams) (db.SubtaskDependency, error)
+	DeleteDependenciesForSubtask(ctx context.Context, subtaskID uuid.UUID) error
+	DeleteDependency(ctx context.Context, arg db.DeleteDependencyParams) error
+	GetDependenciesForSubtask(ctx context.Context, subtaskID uuid.UUID) ([]db.GetDependenciesForSubtaskRow, error)
+	GetDependentsOfSubtask(ctx context.Context, dependsOnID uuid.UUID) ([]db.GetDependentsOfSubtaskRow, error)
+	GetSubtaskByID(ctx context.Context, id uuid.UUID) (db.Subtask, error)
+	GetTaskByID(ctx context.Context, id uuid.UUID) (db.Task, error)
+	HasBlockingDependencies(ctx context.Context, subtaskID uuid.UUID) (bool, error)
+	ListBlockingDependencies(ctx context.Context, subtaskID uuid.UUID) ([]db.ListBlockingDependenciesRow, error)
+	UpdateSubtaskStatus(ctx context.Context, arg db.UpdateSubtaskStatusParams) (db.Subtask, error)
+}
+
+// SyncStore is the data access SyncService needs.
+type SyncStore interface {
+	GetProjectByID(ctx context.Context, id uuid.UUID) (db.Project, error)
+	GetSubtaskByBeadsID(ctx context.Context, beadsIssueID *string) (db.Subtask, error)
+	ListInProgressSubtasks(ctx context.Context) ([]db.Subtask, error)
+	ListSubtasksByTask(ctx context.Context, taskID uuid.UUID) ([]db.Subtask, error)
+}
+
+var (
+	_ ProjectStore    = (*Repository)(nil)
+	_ TaskStore       = (*Repository)(nil)
+	_ SubtaskStore    = (*Repository)(nil)
+	_ DependencyStore = (*Repository)(nil)
+	_ SyncStore       = (*Repository)(nil)
+)
+
+// ArchiveTaskRuns deletes a task's agent runs and records its archive in one
+// transaction, so the runs are never gone without the summary row.
+func (r *Repository) ArchiveTaskRuns(ctx context.Context, arg db.CreateTaskArchiveParams) (db.TaskArchive, error) {
+	var archive db.TaskArchive
+	err := r.Transaction(ctx, func(tx *Repository) error {
+		if err := tx.DeleteAgentRunsForTask(ctx, arg.TaskID); err != nil {
+			return err
+		}
+		var err error
+		archive, err = tx.CreateTaskArchive(ctx, arg)
+		return err
+	})
+	return archive, err
+}
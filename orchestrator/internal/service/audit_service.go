@@ -0,0 +1,148 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// Audited entity types.
+const (
+	AuditEntityTask    = "task"
+	AuditEntitySubtask = "subtask"
+)
+
+// Audited actions, named {entity}.{verb}.
+const (
+	AuditActionTaskCreate        = "task.create"
+	AuditActionTaskDelete        = "task.delete"
+	AuditActionTaskRetryPlanning = "task.retry_planning"
+	AuditActionTaskConfirmPlan   = "task.confirm_plan"
+	AuditActionTaskReplan        = "task.replan"
+	AuditActionTaskPause         = "task.pause"
+	AuditActionTaskResume        = "task.resume"
+	AuditActionSubtaskStart      = "subtask.start"
+	AuditActionSubtaskRetry      = "subtask.retry"
+	AuditActionSubtaskStop       = "subtask.stop"
+	AuditActionSubtaskMarkMerged = "subtask.mark_merged"
+)
+
+// Audit log page sizes for ListByProject.
+const (
+	DefaultAuditLogLimit = 100
+	MaxAuditLogLimit     = 500
+)
+
+// AuditEvent is a user-triggered transition to record. An empty OldStatus or
+// NewStatus is stored as NULL (the entity was created or deleted).
+type AuditEvent struct {
+	ProjectID  uuid.UUID
+	UserID     uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	OldStatus  string
+	NewStatus  string
+}
+
+// AuditService records who triggered state transitions and lists them for
+// the project owner.
+type AuditService struct {
+	repo           *repository.Repository
+	projectService *ProjectService
+}
+
+// NewAuditService creates a new AuditService.
+func NewAuditService(repo *repository.Repository, projectService *ProjectService) *AuditService {
+	return &AuditService{
+		repo:           repo,
+		projectService: projectService,
+	}
+}
+
+// Record writes an audit log entry. It is best-effort: the transition being
+// audited has already happened, so a failed write is logged and dropped
+// rather than returned. Record on a nil AuditService does nothing.
+func (s *AuditService) Record(ctx context.Context, event AuditEvent) {
+	if s == nil {
+		return
+	}
+
+	err := s.repo.CreateAuditLogEntry(ctx, db.CreateAuditLogEntryParams{
+		ProjectID:  event.ProjectID,
+		UserID:     event.UserID,
+		Action:     event.Action,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		OldStatus:  optionalString(event.OldStatus),
+		NewStatus:  optionalString(event.NewStatus),
+	})
+	if err != nil {
+		log.Error().Err(err).
+			Str("action", event.Action).
+			Str("entity_id", event.EntityID.String()).
+			Str("user_id", event.UserID.String()).
+			Msg("failed to write audit log entry")
+	}
+}
+
+// ListByProject returns a project's newest audit entries, newest first.
+// limit is clamped to 1..MaxAuditLogLimit; 0 uses DefaultAuditLogLimit.
+func (s *AuditService) ListByProject(ctx context.Context, projectID, userID uuid.UUID, limit int) ([]*domain.AuditEntry, error) {
+	if _, err := s.projectService.GetProject(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case limit <= 0:
+		limit = DefaultAuditLogLimit
+	case limit > MaxAuditLogLimit:
+		limit = MaxAuditLogLimit
+	}
+
+	rows, err := s.repo.ListAuditLogByProject(ctx, db.ListAuditLogByProjectParams{
+		ProjectID: projectID,
+		Limit:     int32(limit), //nolint:gosec // clamped to MaxAuditLogLimit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	entries := make([]*domain.AuditEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = dbAuditLogToDomain(row)
+	}
+	return entries, nil
+}
+
+// optionalString returns nil for an empty string.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// dbAuditLogToDomain converts a database AuditLog to a domain AuditEntry.
+func dbAuditLogToDomain(a db.AuditLog) *domain.AuditEntry {
+	return &domain.AuditEntry{
+		ID:         a.ID,
+		ProjectID:  a.ProjectID,
+		UserID:     a.UserID,
+		Action:     a.Action,
+		EntityType: a.EntityType,
+		EntityID:   a.EntityID,
+		OldStatus:  a.OldStatus,
+		NewStatus:  a.NewStatus,
+		CreatedAt:  a.CreatedAt,
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// auditDB records audit log inserts and fails them when err is set.
+type auditDB struct {
+	inserts [][]any
+	err     error
+}
+
+func (d *auditDB) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if !strings.Contains(sql, "name: CreateAuditLogEntry ") {
+		return pgconn.CommandTag{}, errors.New("unexpected exec")
+	}
+	d.inserts = append(d.inserts, args)
+	return pgconn.CommandTag{}, d.err
+}
+
+func (d *auditDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (d *auditDB) QueryRow(context.Context, string, ...any) pgx.Row {
+	return syncRow{err: errors.New("unexpected query")}
+}
+
+func (d *auditDB) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	return nil, errors.New("unexpected transaction")
+}
+
+func TestAuditService_Record(t *testing.T) {
+	dbtx := &auditDB{}
+	s := NewAuditService(repository.New(dbtx), nil)
+
+	event := AuditEvent{
+		ProjectID:  uuid.New(),
+		UserID:     uuid.New(),
+		Action:     AuditActionTaskCreate,
+		EntityType: AuditEntityTask,
+		EntityID:   uuid.New(),
+		NewStatus:  "PLANNING",
+	}
+	s.Record(context.Background(), event)
+
+	if len(dbtx.inserts) != 1 {
+		t.Fatalf("got %d inserts, want 1", len(dbtx.inserts))
+	}
+	args := dbtx.inserts[0]
+	if args[1] != event.UserID || args[2] != AuditActionTaskCreate || args[4] != event.EntityID {
+		t.Errorf("insert args = %v, want user, action and entity of %+v", args, event)
+	}
+	if old := args[5].(*string); old != nil {
+		t.Errorf("old_status = %q, want NULL for a created entity", *old)
+	}
+	if got := args[6].(*string); got == nil || *got != "PLANNING" {
+		t.Errorf("new_status = %v, want PLANNING", got)
+	}
+}
+
+func TestAuditService_RecordIsBestEffort(t *testing.T) {
+	dbtx := &auditDB{err: errors.New("connection refused")}
+	s := NewAuditService(repository.New(dbtx), nil)
+
+	// Must not panic or surface the error: the audited action already happened
+	s.Record(context.Background(), AuditEvent{Action: AuditActionSubtaskRetry})
+	if len(dbtx.inserts) != 1 {
+		t.Errorf("got %d inserts, want 1 attempted", len(dbtx.inserts))
+	}
+
+	var unset *AuditService
+	unset.Record(context.Background(), AuditEvent{Action: AuditActionSubtaskRetry})
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,6 +19,7 @@ import (
 
 	"github.com/intern-village/orchestrator/generated/db"
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/netproxy"
 	"github.com/intern-village/orchestrator/internal/repository"
 )
 
@@ -37,12 +39,19 @@ type JWTClaims struct {
 	GitHubUsername string    `json:"github_username"`
 }
 
+// DefaultOAuthScopes are the GitHub OAuth scopes requested unless SetScopes
+// is called: the user's profile, and full access to repositories to fork,
+// push, and open pull requests.
+var DefaultOAuthScopes = []string{"read:user", "repo"}
+
 // AuthService handles authentication-related operations.
 type AuthService struct {
 	oauthConfig *oauth2.Config
 	jwtSecret   []byte
 	repo        *repository.Repository
 	crypto      *repository.Crypto
+	// httpClient makes the OAuth and profile requests; nil uses the default client.
+	httpClient *http.Client
 }
 
 // NewAuthService creates a new AuthService.
@@ -67,7 +76,7 @@ func NewAuthService(
 	oauthConfig := &oauth2.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
-		Scopes:       []string{"read:user", "repo"},
+		Scopes:       DefaultOAuthScopes,
 		Endpoint:     githuboauth.Endpoint,
 	}
 
@@ -79,6 +88,31 @@ func NewAuthService(
 	}, nil
 }
 
+// SetScopes sets the GitHub OAuth scopes requested at login. Tokens already
+// granted keep their scopes until their users log in again.
+func (s *AuthService) SetScopes(scopes []string) {
+	s.oauthConfig.Scopes = scopes
+}
+
+// SetProxy routes the OAuth code exchange and profile requests through the
+// proxy. A disabled proxy leaves the process environment in charge.
+func (s *AuthService) SetProxy(proxy netproxy.Config) {
+	if !proxy.Enabled() {
+		return
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy.ProxyFunc()
+	s.httpClient = &http.Client{Transport: transport}
+}
+
+// withHTTPClient makes oauth2 use s.httpClient for requests made with ctx.
+func (s *AuthService) withHTTPClient(ctx context.Context) context.Context {
+	if s.httpClient == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, s.httpClient)
+}
+
 // GetAuthURL returns the GitHub OAuth authorization URL.
 func (s *AuthService) GetAuthURL(state string) string {
 	return s.oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOnline)
@@ -86,7 +120,7 @@ func (s *AuthService) GetAuthURL(state string) string {
 
 // ExchangeCode exchanges an OAuth authorization code for an access token.
 func (s *AuthService) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	token, err := s.oauthConfig.Exchange(ctx, code)
+	token, err := s.oauthConfig.Exchange(s.withHTTPClient(ctx), code)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrOAuthFailed, err)
 	}
@@ -96,7 +130,7 @@ func (s *AuthService) ExchangeCode(ctx context.Context, code string) (*oauth2.To
 // FetchGitHubUser fetches the GitHub user profile using an access token.
 func (s *AuthService) FetchGitHubUser(ctx context.Context, accessToken string) (*github.User, error) {
 	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
-	tc := oauth2.NewClient(ctx, ts)
+	tc := oauth2.NewClient(s.withHTTPClient(ctx), ts)
 	client := github.NewClient(tc)
 
 	user, _, err := client.Users.Get(ctx, "")
@@ -4,6 +4,7 @@
 package service
 
 import (
+	"net/url"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
 )
 
 func TestGenerateAndValidateJWT(t *testing.T) {
@@ -190,3 +192,31 @@ func validateTestJWT(tokenString, secret string) (*JWTClaims, error) {
 
 	return claims, nil
 }
+
+func TestGetAuthURL_Scopes(t *testing.T) {
+	crypto, err := repository.NewCrypto([]byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatalf("failed to create crypto: %v", err)
+	}
+	svc, err := NewAuthService("client-id", "client-secret", "jwt-secret", &repository.Repository{}, crypto)
+	if err != nil {
+		t.Fatalf("NewAuthService() error = %v", err)
+	}
+
+	scope := func() string {
+		u, err := url.Parse(svc.GetAuthURL("state"))
+		if err != nil {
+			t.Fatalf("invalid auth URL: %v", err)
+		}
+		return u.Query().Get("scope")
+	}
+
+	if got := scope(); got != "read:user repo" {
+		t.Errorf("default scope = %q, want %q", got, "read:user repo")
+	}
+
+	svc.SetScopes([]string{"read:user", "public_repo", "workflow"})
+	if got := scope(); got != "read:user public_repo workflow" {
+		t.Errorf("scope = %q, want %q", got, "read:user public_repo workflow")
+	}
+}
@@ -8,10 +8,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 	"unicode"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/cmdexec"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/netproxy"
 )
 
 // Beads service errors.
@@ -42,6 +51,7 @@ type BeadsIssue struct {
 	Description  string            `json:"description"`
 	Status       string            `json:"status"` // "open", "in_progress", "closed"
 	ParentID     string            `json:"parent,omitempty"`
+	Priority     *int              `json:"priority,omitempty"` // 0 (most urgent) to 4
 	Dependencies []BeadsDependency `json:"dependencies,omitempty"`
 }
 
@@ -67,62 +77,120 @@ func (b *BeadsIssue) GetParentFromDeps() string {
 	return ""
 }
 
+// BeadsClient is the subset of BeadsService the project, task, subtask, and
+// sync services use. Tests substitute an in-memory fake so sync logic can run
+// without the bd binary.
+type BeadsClient interface {
+	Init(ctx context.Context, repoPath, prefix string) (string, error)
+	ListIssues(ctx context.Context, repoPath, parentID string) ([]BeadsIssue, error)
+	ShowIssue(ctx context.Context, repoPath, issueID string) (*BeadsIssue, error)
+	CloseIssue(ctx context.Context, repoPath, issueID, reason string) error
+	DeleteIssue(ctx context.Context, repoPath, issueID string, cascade bool) error
+	GetDependencies(ctx context.Context, repoPath, issueID string) ([]string, error)
+	EnsureWorktree(ctx context.Context, repoPath, worktreePath, branch string) error
+	RemoveWorktree(ctx context.Context, repoPath, worktreePath string) error
+	GenerateBranchName(issueID, title string) string
+}
+
+var _ BeadsClient = (*BeadsService)(nil)
+
 // BeadsService wraps the beads CLI for issue tracking.
 type BeadsService struct {
 	// bdPath is the path to the bd executable. Empty means use PATH.
 	bdPath string
+	// runner runs bd and git commands.
+	runner *cmdexec.Runner
+	// diskSpace refuses new worktrees while the data directory is low on space.
+	diskSpace *DiskSpaceGuard
 }
 
 // NewBeadsService creates a new BeadsService.
 func NewBeadsService() *BeadsService {
-	return &BeadsService{
-		bdPath: "bd",
-	}
+	return NewBeadsServiceWithPath("bd")
 }
 
 // NewBeadsServiceWithPath creates a BeadsService with a custom bd path.
 func NewBeadsServiceWithPath(bdPath string) *BeadsService {
 	return &BeadsService{
 		bdPath: bdPath,
+		runner: cmdexec.New(bdPath, "git"),
 	}
 }
 
+// SetCommandTimeout sets how long a bd or git command may run before it is killed.
+func (s *BeadsService) SetCommandTimeout(timeout time.Duration) {
+	s.runner.Timeout = timeout
+}
+
+// SetProxy passes the proxy to bd and git, which fetch and push the beads
+// sync branch. A disabled proxy leaves the process environment in charge.
+func (s *BeadsService) SetProxy(proxy netproxy.Config) {
+	s.runner.Env = proxy.Env()
+}
+
+// SetDiskSpaceGuard makes CreateWorktree fail with
+// domain.ErrInsufficientDiskSpace while the data directory is below the
+// guard's minimum.
+func (s *BeadsService) SetDiskSpaceGuard(guard *DiskSpaceGuard) {
+	s.diskSpace = guard
+}
+
 // runCommand executes a beads command and returns its output.
 func (s *BeadsService) runCommand(ctx context.Context, workDir string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, s.bdPath, args...) //nolint:gosec // Args are controlled by the service
-	cmd.Dir = workDir
-
-	output, err := cmd.CombinedOutput()
+	output, err := s.runner.Run(ctx, workDir, s.bdPath, args...)
 	if err != nil {
 		// Check if bd is not found
 		if errors.Is(err, exec.ErrNotFound) {
 			return "", ErrBeadsCommandNotFound
 		}
-		return "", fmt.Errorf("command failed: %v (output: %s)", err, string(output))
+		return "", err
 	}
 
-	return strings.TrimSpace(string(output)), nil
+	return strings.TrimSpace(output), nil
 }
 
-// Init initializes beads in a repository with stealth mode.
-// Uses --stealth to avoid committing beads files to the repo.
-func (s *BeadsService) Init(ctx context.Context, repoPath, prefix string) error {
+// Init initializes beads in a repository with stealth mode and returns the
+// issue-ID prefix in use. Uses --stealth to avoid committing beads files to the
+// repo. A repository that already has a beads store keeps it: its prefix is
+// returned instead of prefix, so issue IDs keep matching the existing issues.
+func (s *BeadsService) Init(ctx context.Context, repoPath, prefix string) (string, error) {
+	if _, err := os.Stat(filepath.Join(repoPath, ".beads")); err == nil {
+		existing, err := s.Prefix(ctx, repoPath)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrBeadsInitFailed, err)
+		}
+		return existing, nil
+	}
+
 	_, err := s.runCommand(ctx, repoPath, "init", "--stealth", "--prefix", prefix)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeadsInitFailed, err)
+		return "", fmt.Errorf("%w: %w", ErrBeadsInitFailed, err)
 	}
-	return nil
+	return prefix, nil
+}
+
+// Prefix returns the issue-ID prefix of the beads store in a repository.
+func (s *BeadsService) Prefix(ctx context.Context, repoPath string) (string, error) {
+	output, err := s.runCommand(ctx, repoPath, "config", "get", "issue_prefix")
+	if err != nil {
+		return "", err
+	}
+	if output == "" || strings.ContainsFunc(output, unicode.IsSpace) {
+		return "", fmt.Errorf("%w: unexpected issue prefix: %q", ErrBeadsInvalidOutput, output)
+	}
+	return output, nil
 }
 
-// CreateEpic creates a new epic issue and returns its ID.
-func (s *BeadsService) CreateEpic(ctx context.Context, repoPath, title string) (string, error) {
+// CreateEpic creates a new epic issue and returns its ID. prefix is the
+// repository's issue-ID prefix (see Init).
+func (s *BeadsService) CreateEpic(ctx context.Context, repoPath, prefix, title string) (string, error) {
 	output, err := s.runCommand(ctx, repoPath, "create", "--type", "epic", "--title", title)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", ErrBeadsCreateFailed, err)
+		return "", fmt.Errorf("%w: %w", ErrBeadsCreateFailed, err)
 	}
 
 	// Parse the issue ID from output (e.g., "Created iv-1")
-	id := parseCreatedID(output)
+	id := parseCreatedID(output, prefix)
 	if id == "" {
 		return "", fmt.Errorf("%w: could not parse issue ID from: %s", ErrBeadsInvalidOutput, output)
 	}
@@ -130,14 +198,15 @@ func (s *BeadsService) CreateEpic(ctx context.Context, repoPath, title string) (
 	return id, nil
 }
 
-// CreateIssue creates a new task issue under a parent epic.
-func (s *BeadsService) CreateIssue(ctx context.Context, repoPath, parentID, title, body string) (string, error) {
+// CreateIssue creates a new task issue under a parent epic. prefix is the
+// repository's issue-ID prefix (see Init).
+func (s *BeadsService) CreateIssue(ctx context.Context, repoPath, prefix, parentID, title, body string) (string, error) {
 	output, err := s.runCommand(ctx, repoPath, "create", "--type", "task", "--parent", parentID, "--title", title, "--description", body)
 	if err != nil {
-		return "", fmt.Errorf("%w: %v", ErrBeadsCreateFailed, err)
+		return "", fmt.Errorf("%w: %w", ErrBeadsCreateFailed, err)
 	}
 
-	id := parseCreatedID(output)
+	id := parseCreatedID(output, prefix)
 	if id == "" {
 		return "", fmt.Errorf("%w: could not parse issue ID from: %s", ErrBeadsInvalidOutput, output)
 	}
@@ -150,7 +219,7 @@ func (s *BeadsService) CreateIssue(ctx context.Context, repoPath, parentID, titl
 func (s *BeadsService) AddDependency(ctx context.Context, repoPath, childID, parentID string) error {
 	_, err := s.runCommand(ctx, repoPath, "dep", "add", childID, parentID)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeadsDepFailed, err)
+		return fmt.Errorf("%w: %w", ErrBeadsDepFailed, err)
 	}
 	return nil
 }
@@ -159,7 +228,7 @@ func (s *BeadsService) AddDependency(ctx context.Context, repoPath, childID, par
 func (s *BeadsService) ListIssues(ctx context.Context, repoPath, parentID string) ([]BeadsIssue, error) {
 	output, err := s.runCommand(ctx, repoPath, "list", "--parent", parentID, "--json")
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrBeadsListFailed, err)
+		return nil, fmt.Errorf("%w: %w", ErrBeadsListFailed, err)
 	}
 
 	if output == "" || output == "[]" {
@@ -178,7 +247,7 @@ func (s *BeadsService) ListIssues(ctx context.Context, repoPath, parentID string
 func (s *BeadsService) ShowIssue(ctx context.Context, repoPath, issueID string) (*BeadsIssue, error) {
 	output, err := s.runCommand(ctx, repoPath, "show", issueID, "--json")
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrBeadsShowFailed, err)
+		return nil, fmt.Errorf("%w: %w", ErrBeadsShowFailed, err)
 	}
 
 	if output == "" || output == "[]" {
@@ -202,7 +271,7 @@ func (s *BeadsService) ShowIssue(ctx context.Context, repoPath, issueID string)
 func (s *BeadsService) CloseIssue(ctx context.Context, repoPath, issueID, reason string) error {
 	_, err := s.runCommand(ctx, repoPath, "close", issueID, "--reason", reason)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeadsCloseFailed, err)
+		return fmt.Errorf("%w: %w", ErrBeadsCloseFailed, err)
 	}
 	return nil
 }
@@ -225,7 +294,7 @@ func (s *BeadsService) DeleteIssue(ctx context.Context, repoPath, issueID string
 func (s *BeadsService) UpdateStatus(ctx context.Context, repoPath, issueID, status string) error {
 	_, err := s.runCommand(ctx, repoPath, "update", issueID, "--status", status)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeadsShowFailed, err)
+		return fmt.Errorf("%w: %w", ErrBeadsShowFailed, err)
 	}
 	return nil
 }
@@ -234,7 +303,7 @@ func (s *BeadsService) UpdateStatus(ctx context.Context, repoPath, issueID, stat
 func (s *BeadsService) GetReadyIssues(ctx context.Context, repoPath, parentID string) ([]BeadsIssue, error) {
 	output, err := s.runCommand(ctx, repoPath, "ready", "--parent", parentID, "--json")
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrBeadsListFailed, err)
+		return nil, fmt.Errorf("%w: %w", ErrBeadsListFailed, err)
 	}
 
 	if output == "" || output == "[]" {
@@ -253,7 +322,7 @@ func (s *BeadsService) GetReadyIssues(ctx context.Context, repoPath, parentID st
 func (s *BeadsService) GetBlockedIssues(ctx context.Context, repoPath, parentID string) ([]BeadsIssue, error) {
 	output, err := s.runCommand(ctx, repoPath, "blocked", "--parent", parentID, "--json")
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrBeadsListFailed, err)
+		return nil, fmt.Errorf("%w: %w", ErrBeadsListFailed, err)
 	}
 
 	if output == "" || output == "[]" {
@@ -268,24 +337,187 @@ func (s *BeadsService) GetBlockedIssues(ctx context.Context, repoPath, parentID
 	return issues, nil
 }
 
-// CreateWorktree creates a git worktree for a subtask.
-func (s *BeadsService) CreateWorktree(ctx context.Context, repoPath, name, branch string) error {
-	_, err := s.runCommand(ctx, repoPath, "worktree", "create", name, "--branch", branch)
+// CreateWorktree creates a git worktree for a subtask at worktreePath, which
+// may be absolute or relative to repoPath. An invalid branch name is rejected
+// before running bd.
+func (s *BeadsService) CreateWorktree(ctx context.Context, repoPath, worktreePath, branch string) error {
+	if err := s.diskSpace.Check(); err != nil {
+		return err
+	}
+	branch, err := domain.NormalizeBranchName("branch", branch)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeadsWorktreeFailed, err)
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
+	}
+	if filepath.IsAbs(worktreePath) {
+		if err := os.MkdirAll(filepath.Dir(worktreePath), 0755); err != nil {
+			return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
+		}
+	}
+	// The worktree branches from the clone's HEAD, so no sync may move it meanwhile
+	unlock, err := cloneLocks.lock(ctx, repoPath)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
+	}
+	defer unlock()
+	_, err = s.runCommand(ctx, repoPath, "worktree", "create", worktreePath, "--branch", branch)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
 	}
 	return nil
 }
 
-// RemoveWorktree removes a git worktree.
-func (s *BeadsService) RemoveWorktree(ctx context.Context, repoPath, name string) error {
-	_, err := s.runCommand(ctx, repoPath, "worktree", "remove", name)
+// RemoveWorktree removes a git worktree. worktreePath may be absolute or
+// relative to repoPath.
+func (s *BeadsService) RemoveWorktree(ctx context.Context, repoPath, worktreePath string) error {
+	_, err := s.runCommand(ctx, repoPath, "worktree", "remove", worktreePath)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeadsWorktreeFailed, err)
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
+	}
+	return nil
+}
+
+// PruneWorktrees removes git's administrative data for worktrees whose
+// directories no longer exist.
+func (s *BeadsService) PruneWorktrees(ctx context.Context, repoPath string) error {
+	if _, err := s.runner.Run(ctx, repoPath, "git", "worktree", "prune"); err != nil {
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
+	}
+	return nil
+}
+
+// DeleteBranch force-deletes a local branch of the clone at repoPath, e.g. the
+// throwaway branch of a removed Planner worktree.
+func (s *BeadsService) DeleteBranch(ctx context.Context, repoPath, branch string) error {
+	if _, err := s.runner.Run(ctx, repoPath, "git", "branch", "-D", branch); err != nil {
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
 	}
 	return nil
 }
 
+// staleWorktreeErrors are fragments of the errors git reports when a worktree
+// path is still taken, typically by a worktree whose creation or removal was
+// cut short by a crash and left locked.
+var staleWorktreeErrors = []string{"already exists", "locked"}
+
+// isStaleWorktreeError reports whether err from CreateWorktree means something
+// is left over at the worktree path.
+func isStaleWorktreeError(err error) bool {
+	msg := err.Error()
+	for _, fragment := range staleWorktreeErrors {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// worktreeEntry is one worktree from git worktree list --porcelain.
+type worktreeEntry struct {
+	path     string
+	branch   string // short name, empty if detached
+	locked   bool
+	prunable bool
+}
+
+// EnsureWorktree creates a worktree like CreateWorktree, recovering from one
+// left behind at worktreePath, e.g. locked by a crash or half created. A
+// worktree there that is still usable, unlocked and on branch, is kept along
+// with any work in it. Otherwise only the worktree at worktreePath is unlocked
+// and force-removed before creating it again; its branch and other worktrees
+// are left alone.
+func (s *BeadsService) EnsureWorktree(ctx context.Context, repoPath, worktreePath, branch string) error {
+	err := s.CreateWorktree(ctx, repoPath, worktreePath, branch)
+	if err == nil || !isStaleWorktreeError(err) {
+		return err
+	}
+
+	path := worktreePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoPath, path)
+	}
+	entry, listErr := s.findWorktree(ctx, repoPath, path)
+	if listErr != nil {
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, listErr)
+	}
+	if entry != nil && !entry.locked && !entry.prunable && entry.branch == branch {
+		return nil
+	}
+
+	log.Warn().Err(err).Str("worktree_path", path).Msg("recovering stale worktree")
+	if err := s.forceRemoveWorktree(ctx, repoPath, path, entry != nil); err != nil {
+		return err
+	}
+	return s.CreateWorktree(ctx, repoPath, worktreePath, branch)
+}
+
+// forceRemoveWorktree removes the worktree at the absolute path even if it is
+// locked, has changes, or is only a directory git does not know about.
+func (s *BeadsService) forceRemoveWorktree(ctx context.Context, repoPath, path string, registered bool) error {
+	if registered {
+		// A locked worktree needs --force twice; unlock first anyway so the
+		// prune below can clear it if remove gives up
+		_, _ = s.runner.Run(ctx, repoPath, "git", "worktree", "unlock", path)
+		if _, err := s.runner.Run(ctx, repoPath, "git", "worktree", "remove", "--force", "--force", path); err != nil {
+			log.Debug().Err(err).Str("worktree_path", path).Msg("git worktree remove failed, deleting the directory")
+		}
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("%w: %w", ErrBeadsWorktreeFailed, err)
+	}
+	return s.PruneWorktrees(ctx, repoPath)
+}
+
+// findWorktree returns the worktree of the clone at repoPath registered at the
+// absolute path, or nil if there is none.
+func (s *BeadsService) findWorktree(ctx context.Context, repoPath, path string) (*worktreeEntry, error) {
+	output, err := s.runner.Run(ctx, repoPath, "git", "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	want := resolvePath(path)
+	for _, entry := range parseWorktreeList(output) {
+		if resolvePath(entry.path) == want {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseWorktreeList parses the output of git worktree list --porcelain.
+func parseWorktreeList(output string) []worktreeEntry {
+	var entries []worktreeEntry
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		if key == "worktree" {
+			entries = append(entries, worktreeEntry{path: value})
+			continue
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		entry := &entries[len(entries)-1]
+		switch key {
+		case "branch":
+			entry.branch = strings.TrimPrefix(value, "refs/heads/")
+		case "locked":
+			entry.locked = true
+		case "prunable":
+			entry.prunable = true
+		}
+	}
+	return entries
+}
+
+// resolvePath cleans path and resolves symlinks in its parent directory, as
+// git does for the worktree paths it records. The path itself may be missing.
+func resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return path
+}
+
 // GenerateBranchName creates a branch name from an issue ID and title.
 // Format: iv-{number}-{slug-from-title}
 // Example: iv-5-add-oauth-handler
@@ -299,7 +531,7 @@ func (s *BeadsService) GenerateBranchName(issueID, title string) string {
 func (s *BeadsService) AddComment(ctx context.Context, repoPath, issueID, comment string) error {
 	_, err := s.runCommand(ctx, repoPath, "comments", "add", issueID, comment)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeadsShowFailed, err)
+		return fmt.Errorf("%w: %w", ErrBeadsShowFailed, err)
 	}
 	return nil
 }
@@ -313,7 +545,7 @@ func (s *BeadsService) FindEpicByTaskID(ctx context.Context, repoPath, taskIDPre
 	titlePattern := fmt.Sprintf("[%s]", taskIDPrefix)
 	output, err := s.runCommand(ctx, repoPath, "list", "--type", "epic", "--title", titlePattern, "--status", "closed", "--json", "--limit", "1")
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrBeadsListFailed, err)
+		return nil, fmt.Errorf("%w: %w", ErrBeadsListFailed, err)
 	}
 
 	if output == "" || output == "[]" {
@@ -336,7 +568,7 @@ func (s *BeadsService) FindEpicByTaskID(ctx context.Context, repoPath, taskIDPre
 func (s *BeadsService) GetDependencies(ctx context.Context, repoPath, issueID string) ([]string, error) {
 	output, err := s.runCommand(ctx, repoPath, "dep", "list", issueID, "--json")
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrBeadsDepFailed, err)
+		return nil, fmt.Errorf("%w: %w", ErrBeadsDepFailed, err)
 	}
 
 	if output == "" || output == "[]" {
@@ -352,8 +584,18 @@ func (s *BeadsService) GetDependencies(ctx context.Context, repoPath, issueID st
 }
 
 // parseCreatedID parses the issue ID from beads create output.
-// Expected format: "Created iv-1" or similar.
-func parseCreatedID(output string) string {
+// Expected format: "Created iv-1" or similar. With a prefix, only an ID of
+// the form {prefix}-{number} is accepted, which also covers prefixes the
+// generic pattern cannot match, such as generated ones ("iv-1a2b3c4d-5").
+func parseCreatedID(output, prefix string) string {
+	if prefix != "" {
+		re := regexp.MustCompile(`(?:^|[^A-Za-z0-9-])(` + regexp.QuoteMeta(prefix) + `-\d+)`)
+		if matches := re.FindStringSubmatch(output); matches != nil {
+			return matches[1]
+		}
+		return ""
+	}
+
 	// Look for patterns like "iv-1", "iv-123", etc.
 	re := regexp.MustCompile(`[a-zA-Z]+-\d+`)
 	matches := re.FindStringSubmatch(output)
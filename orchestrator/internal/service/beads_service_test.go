@@ -4,7 +4,15 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/intern-village/orchestrator/internal/domain"
 )
 
 func TestSlugify(t *testing.T) {
@@ -98,3 +106,298 @@ func TestGenerateBranchName(t *testing.T) {
 		})
 	}
 }
+
+func TestPruneWorktrees(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v (output: %s)", args, err, output)
+		}
+		return string(output)
+	}
+
+	run("init", "-q")
+	run("commit", "-q", "--allow-empty", "-m", "initial")
+	run("worktree", "add", "-q", "-b", "feature", "wt")
+
+	// Simulate a crash that left the worktree directory deleted but still registered
+	if err := os.RemoveAll(filepath.Join(repoPath, "wt")); err != nil {
+		t.Fatalf("failed to remove worktree dir: %v", err)
+	}
+
+	svc := NewBeadsService()
+	if err := svc.PruneWorktrees(context.Background(), repoPath); err != nil {
+		t.Fatalf("PruneWorktrees() error = %v", err)
+	}
+
+	if out := run("worktree", "list"); strings.Contains(out, "[feature]") {
+		t.Errorf("expected stale worktree to be pruned, got: %s", out)
+	}
+}
+
+func TestEnsureWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	repoPath := filepath.Join(dir, "repo")
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v (output: %s)", args, err, output)
+		}
+		return string(output)
+	}
+	if err := os.Mkdir(repoPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	run("init", "-q")
+	run("commit", "-q", "--allow-empty", "-m", "initial")
+
+	// Stand-in for bd worktree create <path> --branch <branch>, which checks
+	// out the branch if it already exists
+	bdPath := filepath.Join(dir, "bd")
+	script := `#!/bin/sh
+if git show-ref --verify --quiet "refs/heads/$5"; then exec git worktree add -q "$3" "$5"; fi
+exec git worktree add -q -b "$5" "$3"
+`
+	if err := os.WriteFile(bdPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewBeadsServiceWithPath(bdPath)
+	ctx := context.Background()
+	worktrees := filepath.Join(dir, "worktrees")
+
+	t.Run("missing but locked worktree is recreated", func(t *testing.T) {
+		path := filepath.Join(worktrees, "locked")
+		run("worktree", "add", "-q", "-b", "locked-branch", path)
+		run("worktree", "lock", path)
+		if err := os.RemoveAll(path); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := svc.EnsureWorktree(ctx, repoPath, path, "locked-branch"); err != nil {
+			t.Fatalf("EnsureWorktree() error = %v", err)
+		}
+		entry, err := svc.findWorktree(ctx, repoPath, path)
+		if err != nil || entry == nil {
+			t.Fatalf("findWorktree() = %v, %v; want the recreated worktree", entry, err)
+		}
+		if entry.locked || entry.branch != "locked-branch" {
+			t.Errorf("worktree = %+v, want unlocked on locked-branch", *entry)
+		}
+	})
+
+	t.Run("half-created directory is replaced", func(t *testing.T) {
+		path := filepath.Join(worktrees, "partial")
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "junk"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := svc.EnsureWorktree(ctx, repoPath, path, "partial-branch"); err != nil {
+			t.Fatalf("EnsureWorktree() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(path, "junk")); !os.IsNotExist(err) {
+			t.Error("expected the half-created directory to be replaced")
+		}
+	})
+
+	t.Run("usable worktree is kept", func(t *testing.T) {
+		path := filepath.Join(worktrees, "usable")
+		run("worktree", "add", "-q", "-b", "usable-branch", path)
+		if err := os.WriteFile(filepath.Join(path, "work"), []byte("uncommitted"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := svc.EnsureWorktree(ctx, repoPath, path, "usable-branch"); err != nil {
+			t.Fatalf("EnsureWorktree() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(path, "work")); err != nil {
+			t.Error("expected the work in a usable worktree to be kept")
+		}
+	})
+
+	t.Run("branch with slashes", func(t *testing.T) {
+		path := filepath.Join(worktrees, "slashes")
+		if err := svc.EnsureWorktree(ctx, repoPath, path, "feature/team/login"); err != nil {
+			t.Fatalf("EnsureWorktree() error = %v", err)
+		}
+		entry, err := svc.findWorktree(ctx, repoPath, path)
+		if err != nil || entry == nil || entry.branch != "feature/team/login" {
+			t.Errorf("findWorktree() = %v, %v; want a worktree on feature/team/login", entry, err)
+		}
+	})
+
+	t.Run("malformed branch is rejected before running bd", func(t *testing.T) {
+		path := filepath.Join(worktrees, "malformed")
+		for _, branch := range []string{"--detach", "feature\nlogin", "feature/../main"} {
+			err := svc.EnsureWorktree(ctx, repoPath, path, branch)
+			if !errors.Is(err, ErrBeadsWorktreeFailed) || !domain.IsInvalidInput(err) {
+				t.Errorf("EnsureWorktree(%q) error = %v, want an invalid branch worktree error", branch, err)
+			}
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("expected no worktree for a malformed branch")
+		}
+	})
+
+	t.Run("other worktrees are left alone", func(t *testing.T) {
+		sibling := filepath.Join(worktrees, "sibling")
+		run("worktree", "add", "-q", "-b", "sibling-branch", sibling)
+		run("worktree", "lock", sibling)
+		if err := os.RemoveAll(sibling); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(worktrees, "own")
+		run("worktree", "add", "-q", "-b", "own-branch", path)
+		run("worktree", "lock", path)
+
+		if err := svc.EnsureWorktree(ctx, repoPath, path, "own-branch"); err != nil {
+			t.Fatalf("EnsureWorktree() error = %v", err)
+		}
+		entry, err := svc.findWorktree(ctx, repoPath, sibling)
+		if err != nil || entry == nil || !entry.locked {
+			t.Errorf("findWorktree(sibling) = %v, %v; want it still registered and locked", entry, err)
+		}
+	})
+}
+
+func TestParseWorktreeList(t *testing.T) {
+	output := `worktree /data/repo
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /data/worktrees/a
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/iv-1-add-login
+locked initializing
+
+worktree /data/worktrees/b
+HEAD 3333333333333333333333333333333333333333
+detached
+prunable gitdir file points to non-existent location
+`
+	entries := parseWorktreeList(output)
+	if len(entries) != 3 {
+		t.Fatalf("parseWorktreeList() returned %d entries, want 3", len(entries))
+	}
+	want := []worktreeEntry{
+		{path: "/data/repo", branch: "main"},
+		{path: "/data/worktrees/a", branch: "iv-1-add-login", locked: true},
+		{path: "/data/worktrees/b", prunable: true},
+	}
+	for i, w := range want {
+		if entries[i] != w {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], w)
+		}
+	}
+}
+
+func TestParseCreatedID(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		prefix string
+		want   string
+	}{
+		{name: "iv prefix", output: "✓ Created issue: iv-12", prefix: "iv", want: "iv-12"},
+		{name: "custom prefix", output: "Created issue: bd-7\n  Title: Fix login", prefix: "bd", want: "bd-7"},
+		{name: "mixed case prefix", output: "Created MyApp-3", prefix: "MyApp", want: "MyApp-3"},
+		{name: "generated prefix", output: "Created issue: iv-1a2b3c4d-5", prefix: "iv-1a2b3c4d", want: "iv-1a2b3c4d-5"},
+		{name: "generated prefix starting with a digit", output: "Created issue: iv-3abc0def-12", prefix: "iv-3abc0def", want: "iv-3abc0def-12"},
+		{name: "other prefix ignored", output: "Created issue: bd-7", prefix: "iv", want: ""},
+		{name: "prefix inside a longer ID ignored", output: "Created issue: xbd-7", prefix: "bd", want: ""},
+		{name: "no prefix", output: "Created app-42", want: "app-42"},
+		{name: "no ID", output: "Error: database locked", prefix: "bd", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCreatedID(tt.output, tt.prefix); got != tt.want {
+				t.Errorf("parseCreatedID(%q, %q) = %q, want %q", tt.output, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBeadsInit(t *testing.T) {
+	dir := t.TempDir()
+
+	// Stand-in for bd that records init calls and reports the prefix "bd"
+	bdPath := filepath.Join(dir, "bd")
+	calls := filepath.Join(dir, "calls")
+	script := `#!/bin/sh
+echo "$@" >> ` + calls + `
+case "$1" in
+init) mkdir .beads ;;
+config) echo bd ;;
+esac
+`
+	if err := os.WriteFile(bdPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewBeadsServiceWithPath(bdPath)
+	ctx := context.Background()
+
+	t.Run("new store uses the requested prefix", func(t *testing.T) {
+		repoPath := t.TempDir()
+		got, err := svc.Init(ctx, repoPath, "iv-1a2b3c4d")
+		if err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if got != "iv-1a2b3c4d" {
+			t.Errorf("Init() prefix = %q, want %q", got, "iv-1a2b3c4d")
+		}
+		if _, err := os.Stat(filepath.Join(repoPath, ".beads")); err != nil {
+			t.Errorf("expected bd init to run: %v", err)
+		}
+	})
+
+	t.Run("existing store keeps its prefix", func(t *testing.T) {
+		if err := os.Remove(calls); err != nil {
+			t.Fatal(err)
+		}
+		repoPath := t.TempDir()
+		if err := os.Mkdir(filepath.Join(repoPath, ".beads"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := svc.Init(ctx, repoPath, "iv-1a2b3c4d")
+		if err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		if got != "bd" {
+			t.Errorf("Init() prefix = %q, want the existing %q", got, "bd")
+		}
+		recorded, err := os.ReadFile(calls)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(recorded), "init") {
+			t.Errorf("existing store was re-initialized, bd calls:\n%s", recorded)
+		}
+	})
+}
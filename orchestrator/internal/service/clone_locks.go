@@ -0,0 +1,49 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+)
+
+// cloneLocks serializes git operations that move a clone's HEAD, index or
+// working tree: SyncRepo's checkout and hard reset, and the worktrees branched
+// from the clone's HEAD. Two of them on one clone would otherwise fail on
+// git's index.lock or leave it on the wrong branch, while operations on
+// different clones still run in parallel. It is shared by GitHubService and
+// BeadsService since both work on the same directories.
+var cloneLocks = newPathLocks()
+
+// pathLocks is a set of mutexes keyed by directory path.
+type pathLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{} // path -> semaphore of capacity 1
+}
+
+func newPathLocks() *pathLocks {
+	return &pathLocks{locks: make(map[string]chan struct{})}
+}
+
+// lock waits until it holds the lock for path, or ctx is done. The caller must
+// call unlock once finished.
+func (p *pathLocks) lock(ctx context.Context, path string) (unlock func(), err error) {
+	path = filepath.Clean(path)
+
+	p.mu.Lock()
+	sem, ok := p.locks[path]
+	if !ok {
+		sem = make(chan struct{}, 1)
+		p.locks[path] = sem
+	}
+	p.mu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
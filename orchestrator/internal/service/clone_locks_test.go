@@ -0,0 +1,94 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPathLocks(t *testing.T) {
+	locks := newPathLocks()
+	ctx := context.Background()
+
+	unlock, err := locks.lock(ctx, "/data/clones/a")
+	if err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+
+	// Another clone is not held up
+	unlockB, err := locks.lock(ctx, "/data/clones/b")
+	if err != nil {
+		t.Fatalf("lock() of another path error = %v", err)
+	}
+	unlockB()
+
+	// The same clone, however spelled, waits
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := locks.lock(waitCtx, "/data/clones/a/"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lock() of a held path error = %v, want deadline exceeded", err)
+	}
+
+	unlock()
+	unlock, err = locks.lock(ctx, "/data/clones/a")
+	if err != nil {
+		t.Fatalf("lock() after unlock error = %v", err)
+	}
+	unlock()
+}
+
+// TestSyncRepo_Concurrent syncs one clone to two branches at once, as two
+// tasks with different base branches would. Each sync must succeed and leave
+// the clone on its branch with a clean working tree.
+func TestSyncRepo_Concurrent(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available, skipping test")
+	}
+
+	source := newCommitsTestRepo(t)
+	gitRun(t, source, "checkout", "-b", "develop")
+	gitCommit(t, source, "develop.txt", "develop work")
+	gitRun(t, source, "checkout", "main")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	gitRun(t, filepath.Dir(clone), "clone", "--quiet", source, clone)
+
+	svc := NewGitHubService()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		branch := "main"
+		if i%2 == 1 {
+			branch = "develop"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- svc.SyncRepo(context.Background(), clone, branch, false)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("SyncRepo() error = %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(clone, ".git", "index.lock")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("index.lock left behind: %v", err)
+	}
+	status, err := exec.Command("git", "-C", clone, "status", "--porcelain").CombinedOutput()
+	if err != nil || strings.TrimSpace(string(status)) != "" {
+		t.Errorf("clone is not clean after concurrent syncs: %s (err %v)", status, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CloneMirrorUpdater periodically refreshes the clone mirror cache so clones
+// rarely have to wait for a stale mirror to catch up.
+type CloneMirrorUpdater struct {
+	githubService *GitHubService
+	interval      time.Duration
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+	running       bool
+	mu            sync.Mutex
+}
+
+// NewCloneMirrorUpdater creates a new CloneMirrorUpdater.
+func NewCloneMirrorUpdater(githubService *GitHubService, interval time.Duration) *CloneMirrorUpdater {
+	if interval < time.Minute {
+		interval = 30 * time.Minute // Default to half-hourly
+	}
+
+	return &CloneMirrorUpdater{
+		githubService: githubService,
+		interval:      interval,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Start starts the periodic update.
+func (w *CloneMirrorUpdater) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return
+	}
+
+	w.running = true
+	w.wg.Add(1)
+	go w.run()
+
+	log.Info().
+		Dur("interval", w.interval).
+		Msg("clone mirror updater started")
+}
+
+// Stop stops the periodic update gracefully.
+func (w *CloneMirrorUpdater) Stop() {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = false
+	w.mu.Unlock()
+
+	close(w.stopCh)
+	w.wg.Wait()
+
+	log.Info().Msg("clone mirror updater stopped")
+}
+
+// run is the main loop for the clone mirror updater.
+func (w *CloneMirrorUpdater) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.update()
+		}
+	}
+}
+
+// update refreshes every mirror, stopping early if the updater is stopped.
+func (w *CloneMirrorUpdater) update() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.interval)
+	defer cancel()
+	go func() {
+		select {
+		case <-w.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	result, err := w.githubService.UpdateCloneMirrors(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update clone mirrors")
+		return
+	}
+
+	if result.Updated > 0 || result.Failed > 0 {
+		log.Info().
+			Int("updated", result.Updated).
+			Int("failed", result.Failed).
+			Msg("updated clone mirrors")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// mirrorUpdatedFile is touched inside a mirror after each successful update.
+const mirrorUpdatedFile = "iv-updated"
+
+// cloneMirrors is the state of the shared mirror cache: bare mirrors of
+// upstream repositories that clones of the repository, or of forks of it,
+// borrow objects from with git clone --reference instead of downloading the
+// full history again.
+//
+// Mirrors never store a token in their config, and never prune objects, since
+// clones made from them keep referring to their objects through
+// objects/info/alternates. Deleting a mirror breaks the clones made from it.
+type cloneMirrors struct {
+	dir    string
+	maxAge time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*sync.RWMutex // mirror path -> lock
+}
+
+// MirrorUpdateResult summarizes an UpdateCloneMirrors pass.
+type MirrorUpdateResult struct {
+	Updated int
+	Failed  int
+}
+
+// SetCloneMirrors enables the mirror cache under dir. A mirror last updated
+// more than maxAge ago is refreshed before a clone uses it.
+func (s *GitHubService) SetCloneMirrors(dir string, maxAge time.Duration) {
+	s.mirrors = &cloneMirrors{dir: dir, maxAge: maxAge, locks: make(map[string]*sync.RWMutex)}
+}
+
+// path returns the mirror directory of owner/repo. GitHub names are case
+// insensitive, so they are lowercased to share one mirror.
+func (m *cloneMirrors) path(owner, repo string) string {
+	return filepath.Join(m.dir, strings.ToLower(owner), strings.ToLower(repo)+".git")
+}
+
+// lock returns the lock guarding the mirror at path. Clones hold it for
+// reading; creating and updating the mirror hold it for writing.
+func (m *cloneMirrors) lock(path string) *sync.RWMutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.locks[path]
+	if !ok {
+		l = &sync.RWMutex{}
+		m.locks[path] = l
+	}
+	return l
+}
+
+// stale reports whether the mirror at path has not been updated within maxAge.
+func (m *cloneMirrors) stale(path string) bool {
+	info, err := os.Stat(filepath.Join(path, mirrorUpdatedFile))
+	return err != nil || time.Since(info.ModTime()) > m.maxAge
+}
+
+// acquireMirror returns the path of an up-to-date mirror of owner/repo,
+// creating it or refreshing a stale one with accessToken first. The mirror is
+// read-locked until release is called. On error the caller should clone
+// without a mirror.
+func (s *GitHubService) acquireMirror(ctx context.Context, owner, repo, accessToken string) (path string, release func(), err error) {
+	m := s.mirrors
+	path = m.path(owner, repo)
+	l := m.lock(path)
+
+	l.Lock()
+	if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrNotExist) {
+		err = s.createMirror(ctx, owner, repo, accessToken, path)
+	} else if m.stale(path) {
+		err = s.fetchMirror(ctx, path, "fetch", "--prune", tokenCloneURL(owner, repo, accessToken),
+			"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*")
+	}
+	l.Unlock()
+	if err != nil {
+		return "", nil, err
+	}
+
+	l.RLock()
+	return path, l.RUnlock, nil
+}
+
+// createMirror creates a bare mirror of owner/repo at path. It is cloned to a
+// temporary directory and renamed into place so a failed clone leaves nothing
+// behind.
+func (s *GitHubService) createMirror(ctx context.Context, owner, repo, accessToken, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+	tmpPath := fmt.Sprintf("%s.tmp-%s", path, uuid.New().String()[:8])
+	defer func() { _ = os.RemoveAll(tmpPath) }()
+
+	// A bare clone rather than --mirror, which would also fetch every pull
+	// request ref GitHub advertises
+	if _, err := s.runGit(ctx, "", "clone", "--bare", tokenCloneURL(owner, repo, accessToken), tmpPath); err != nil {
+		return fmt.Errorf("failed to create mirror: %w", err)
+	}
+	// Mirrors are shared between users, so keep the token out of their config
+	publicURL := fmt.Sprintf("https://github.com/%s/%s.git", owner, repo)
+	for _, args := range [][]string{
+		{"remote", "set-url", "origin", publicURL},
+		{"config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"},
+		{"config", "--add", "remote.origin.fetch", "+refs/tags/*:refs/tags/*"},
+		// Clones borrow objects from the mirror, so it must never prune them
+		{"config", "gc.pruneExpire", "never"},
+	} {
+		if _, err := s.runGit(ctx, tmpPath, args...); err != nil {
+			return fmt.Errorf("failed to configure mirror: %w", err)
+		}
+	}
+	if err := touchMirror(tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to move mirror into place: %w", err)
+	}
+
+	log.Info().Str("mirror", path).Msg("created clone mirror")
+	return nil
+}
+
+// fetchMirror runs a git fetch-like command in the mirror at path and marks
+// the mirror updated if it succeeds.
+func (s *GitHubService) fetchMirror(ctx context.Context, path string, args ...string) error {
+	if _, err := s.runGit(ctx, path, args...); err != nil {
+		return fmt.Errorf("failed to update mirror: %w", err)
+	}
+	return touchMirror(path)
+}
+
+// touchMirror marks the mirror at path as updated now.
+func touchMirror(path string) error {
+	if err := os.WriteFile(filepath.Join(path, mirrorUpdatedFile), nil, 0o644); err != nil {
+		return fmt.Errorf("failed to mark mirror updated: %w", err)
+	}
+	return nil
+}
+
+// UpdateCloneMirrors refreshes every mirror with git remote update. Mirrors of
+// private repositories need a token and fail here; they are refreshed with the
+// cloning user's token when a clone finds them stale. It is a no-op unless
+// SetCloneMirrors was called.
+func (s *GitHubService) UpdateCloneMirrors(ctx context.Context) (MirrorUpdateResult, error) {
+	var result MirrorUpdateResult
+	if s.mirrors == nil {
+		return result, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(s.mirrors.dir, "*", "*.git"))
+	if err != nil {
+		return result, fmt.Errorf("failed to list mirrors: %w", err)
+	}
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		l := s.mirrors.lock(path)
+		l.Lock()
+		err := s.fetchMirror(ctx, path, "remote", "update", "--prune")
+		l.Unlock()
+
+		if err != nil {
+			result.Failed++
+			log.Debug().Err(err).Str("mirror", path).Msg("failed to update clone mirror")
+			continue
+		}
+		result.Updated++
+	}
+	return result, nil
+}
+
+// tokenCloneURL returns the HTTPS URL of owner/repo with accessToken embedded
+// for authentication.
+func tokenCloneURL(owner, repo, accessToken string) string {
+	return fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git", accessToken, owner, repo)
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CloneSweeper periodically removes the clones of projects that have been idle
+// longer than a configured age.
+type CloneSweeper struct {
+	projectService *ProjectService
+	idleAfter      time.Duration
+	interval       time.Duration
+	stopCh         chan struct{}
+	wg             sync.WaitGroup
+	running        bool
+	mu             sync.Mutex
+}
+
+// NewCloneSweeper creates a new CloneSweeper.
+func NewCloneSweeper(projectService *ProjectService, idleAfter, interval time.Duration) *CloneSweeper {
+	if interval < time.Minute {
+		interval = time.Hour // Default to hourly
+	}
+
+	return &CloneSweeper{
+		projectService: projectService,
+		idleAfter:      idleAfter,
+		interval:       interval,
+		stopCh:         make(chan struct{}),
+	}
+}
+
+// Start starts the periodic sweep.
+func (w *CloneSweeper) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return
+	}
+
+	w.running = true
+	w.wg.Add(1)
+	go w.run()
+
+	log.Info().
+		Dur("idle_after", w.idleAfter).
+		Dur("interval", w.interval).
+		Msg("clone sweeper started")
+}
+
+// Stop stops the periodic sweep gracefully.
+func (w *CloneSweeper) Stop() {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = false
+	w.mu.Unlock()
+
+	close(w.stopCh)
+	w.wg.Wait()
+
+	log.Info().Msg("clone sweeper stopped")
+}
+
+// run is the main loop for the clone sweeper.
+func (w *CloneSweeper) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.sweep()
+		}
+	}
+}
+
+// sweep removes clones for projects idle longer than idleAfter.
+func (w *CloneSweeper) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	result, err := w.projectService.SweepIdleClones(ctx, time.Now().Add(-w.idleAfter))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to sweep idle clones")
+		return
+	}
+
+	if result.Cleaned > 0 {
+		log.Info().
+			Int("cleaned", result.Cleaned).
+			Int64("freed_bytes", result.FreedBytes).
+			Msg("swept idle project clones")
+	}
+}
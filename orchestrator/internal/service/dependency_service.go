@@ -16,12 +16,12 @@ import (
 
 // DependencyService handles subtask dependency operations.
 type DependencyService struct {
-	repo     *repository.Repository
+	repo     repository.DependencyStore
 	eventHub EventHub
 }
 
 // NewDependencyService creates a new DependencyService.
-func NewDependencyService(repo *repository.Repository, eventHub EventHub) *DependencyService {
+func NewDependencyService(repo repository.DependencyStore, eventHub EventHub) *DependencyService {
 	return &DependencyService{
 		repo:     repo,
 		eventHub: eventHub,
@@ -64,19 +64,21 @@ func (s *DependencyService) AddDependency(ctx context.Context, subtaskID, depend
 	}, nil
 }
 
-// GetBlockingDependencies returns all dependencies that are blocking a subtask.
-// A dependency is blocking if its status is not MERGED.
-func (s *DependencyService) GetBlockingDependencies(ctx context.Context, subtaskID uuid.UUID) ([]db.GetDependenciesForSubtaskRow, error) {
-	deps, err := s.repo.GetDependenciesForSubtask(ctx, subtaskID)
+// GetBlockingDependencies returns the subtasks a subtask is still waiting on,
+// in board order. A dependency is blocking unless it is MERGED or CANCELLED,
+// matching HasBlockingDependencies.
+func (s *DependencyService) GetBlockingDependencies(ctx context.Context, subtaskID uuid.UUID) ([]domain.BlockingDependency, error) {
+	rows, err := s.repo.ListBlockingDependencies(ctx, subtaskID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+		return nil, fmt.Errorf("failed to get blocking dependencies: %w", err)
 	}
 
-	// Filter to only blocking (non-MERGED) dependencies
-	var blocking []db.GetDependenciesForSubtaskRow
-	for _, dep := range deps {
-		if dep.DependencyStatus != string(domain.SubtaskStatusMerged) {
-			blocking = append(blocking, dep)
+	blocking := make([]domain.BlockingDependency, len(rows))
+	for i, row := range rows {
+		blocking[i] = domain.BlockingDependency{
+			ID:     row.ID,
+			Title:  row.Title,
+			Status: domain.SubtaskStatus(row.Status),
 		}
 	}
 
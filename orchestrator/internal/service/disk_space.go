@@ -0,0 +1,49 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// DiskSpaceGuard refuses work that writes under the data directory (clones,
+// worktrees, agent runs) while it has less than a minimum of free space, so a
+// full disk is reported up front instead of as a git error halfway through.
+type DiskSpaceGuard struct {
+	free      func() (uint64, bool, error)
+	minFreeMB int
+}
+
+// NewDiskSpaceGuard creates a guard requiring minFreeMB megabytes free in the
+// data directory. Zero disables the check.
+func NewDiskSpaceGuard(paths config.DataPaths, minFreeMB int) *DiskSpaceGuard {
+	return &DiskSpaceGuard{free: paths.FreeBytes, minFreeMB: minFreeMB}
+}
+
+// Check returns an error wrapping domain.ErrInsufficientDiskSpace if the data
+// directory is below the minimum. It passes on a nil guard, when the check is
+// disabled, and where free space cannot be read.
+func (g *DiskSpaceGuard) Check() error {
+	if g == nil || g.minFreeMB <= 0 {
+		return nil
+	}
+	free, ok, err := g.free()
+	if err != nil {
+		return fmt.Errorf("failed to check free disk space: %w", err)
+	}
+	if ok && free < uint64(g.minFreeMB)*1024*1024 {
+		return fmt.Errorf("%w: %d MB free in the data directory, at least %d MB (DATA_DIR_MIN_FREE_MB) required",
+			domain.ErrInsufficientDiskSpace, free/(1024*1024), g.minFreeMB)
+	}
+	return nil
+}
+
+// FreeBytes returns the free space in the data directory. ok is false where
+// it cannot be read.
+func (g *DiskSpaceGuard) FreeBytes() (free uint64, ok bool, err error) {
+	return g.free()
+}
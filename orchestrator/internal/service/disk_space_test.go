@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+func TestDiskSpaceGuard_Check(t *testing.T) {
+	const mb = 1024 * 1024
+
+	tests := []struct {
+		name    string
+		guard   *DiskSpaceGuard
+		wantErr bool
+		wantLow bool
+	}{
+		{"nil guard", nil, false, false},
+		{"disabled", &DiskSpaceGuard{minFreeMB: 0, free: func() (uint64, bool, error) { return 0, true, nil }}, false, false},
+		{"enough free", &DiskSpaceGuard{minFreeMB: 100, free: func() (uint64, bool, error) { return 100 * mb, true, nil }}, false, false},
+		{"below minimum", &DiskSpaceGuard{minFreeMB: 100, free: func() (uint64, bool, error) { return 99 * mb, true, nil }}, true, true},
+		{"unknown on this platform", &DiskSpaceGuard{minFreeMB: 100, free: func() (uint64, bool, error) { return 0, false, nil }}, false, false},
+		{"statfs fails", &DiskSpaceGuard{minFreeMB: 100, free: func() (uint64, bool, error) { return 0, false, errors.New("boom") }}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.guard.Check()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := domain.IsInsufficientDiskSpace(err); got != tt.wantLow {
+				t.Errorf("IsInsufficientDiskSpace(%v) = %v, want %v", err, got, tt.wantLow)
+			}
+		})
+	}
+}
+
+func TestDiskSpaceGuard_RefusesCloneAndWorktree(t *testing.T) {
+	full := &DiskSpaceGuard{minFreeMB: 1, free: func() (uint64, bool, error) { return 0, true, nil }}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	github := NewGitHubService()
+	github.SetDiskSpaceGuard(full)
+	if err := github.CloneRepo(ctx, "owner", "repo", "token", filepath.Join(dir, "clone")); !domain.IsInsufficientDiskSpace(err) {
+		t.Errorf("CloneRepo() error = %v, want insufficient disk space", err)
+	}
+
+	beads := NewBeadsService()
+	beads.SetDiskSpaceGuard(full)
+	if err := beads.CreateWorktree(ctx, dir, filepath.Join(dir, "worktree"), "iv-1-add-login"); !domain.IsInsufficientDiskSpace(err) {
+		t.Errorf("CreateWorktree() error = %v, want insufficient disk space", err)
+	}
+}
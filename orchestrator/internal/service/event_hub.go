@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/metrics"
 )
 
 // Event represents a real-time event that can be sent to clients.
@@ -43,6 +44,30 @@ type AgentLogData struct {
 	Timestamp  string    `json:"timestamp"`
 }
 
+// AgentLogBatchData is the data for an agent:log_batch event, which carries
+// consecutive log lines of one run when log coalescing is enabled.
+type AgentLogBatchData struct {
+	RunID uuid.UUID      `json:"run_id"`
+	Lines []AgentLogLine `json:"lines"`
+}
+
+// AgentLogThrottledData is the data for an agent:log_throttled event, sent
+// when lines of a run were skipped for exceeding the tailer's line-rate cap.
+// The skipped lines remain in the log file.
+type AgentLogThrottledData struct {
+	RunID        uuid.UUID `json:"run_id"`
+	SkippedLines int       `json:"skipped_lines"`
+	FromLine     int       `json:"from_line"` // first skipped line number
+	ToLine       int       `json:"to_line"`   // last skipped line number
+}
+
+// AgentLogLine is one line of an agent:log_batch event.
+type AgentLogLine struct {
+	Line       string `json:"line"`
+	LineNumber int    `json:"line_number"`
+	Timestamp  string `json:"timestamp"`
+}
+
 // AgentCompletedData is the data for an agent:completed event.
 type AgentCompletedData struct {
 	RunID      uuid.UUID `json:"run_id"`
@@ -66,6 +91,19 @@ type AgentFailedData struct {
 	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
 }
 
+// AgentStalledData is the data for an agent:stalled event, sent when a
+// running agent's log has not been written for the stall timeout.
+type AgentStalledData struct {
+	RunID         uuid.UUID `json:"run_id"`
+	AgentType     string    `json:"agent_type"`
+	TaskID        uuid.UUID `json:"task_id"`
+	SubtaskID     *string   `json:"subtask_id"`
+	AttemptNumber int       `json:"attempt_number"`
+	LastOutputAt  time.Time `json:"last_output_at"`
+	StalledForMs  int64     `json:"stalled_for_ms"`
+	Cancelled     bool      `json:"cancelled"` // the attempt is stopped, and retried if attempts remain
+}
+
 // TaskStatusChangedData is the data for a task:status_changed event.
 type TaskStatusChangedData struct {
 	TaskID    uuid.UUID `json:"task_id"`
@@ -74,6 +112,18 @@ type TaskStatusChangedData struct {
 	ChangedAt time.Time `json:"changed_at"`
 }
 
+// TaskCompletedData is the data for a task:completed event, published
+// alongside the task:status_changed to DONE with a summary of the task.
+type TaskCompletedData struct {
+	TaskID       uuid.UUID `json:"task_id"`
+	Title        string    `json:"title"`
+	SubtaskCount int       `json:"subtask_count"`
+	MergedCount  int       `json:"merged_count"`
+	TokenUsage   int64     `json:"token_usage"`
+	DurationMs   int64     `json:"duration_ms"` // from task creation to DONE
+	CompletedAt  time.Time `json:"completed_at"`
+}
+
 // SubtaskStatusChangedData is the data for a subtask:status_changed event.
 type SubtaskStatusChangedData struct {
 	SubtaskID     uuid.UUID `json:"subtask_id"`
@@ -86,6 +136,18 @@ type SubtaskStatusChangedData struct {
 	ChangedAt     time.Time `json:"changed_at"`
 }
 
+// SubtaskCreatedData is the data for a subtask:created event, published for
+// subtasks added to a task that is already ACTIVE, e.g. by a re-plan.
+type SubtaskCreatedData struct {
+	SubtaskID     uuid.UUID `json:"subtask_id"`
+	TaskID        uuid.UUID `json:"task_id"`
+	Title         string    `json:"title"`
+	Status        string    `json:"status"`
+	BlockedReason *string   `json:"blocked_reason"`
+	BeadsIssueID  *string   `json:"beads_issue_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
 // SubtaskUnblockedData is the data for a subtask:unblocked event.
 type SubtaskUnblockedData struct {
 	SubtaskID   uuid.UUID `json:"subtask_id"`
@@ -94,6 +156,42 @@ type SubtaskUnblockedData struct {
 	ChangedAt   time.Time `json:"changed_at"`
 }
 
+// OperationFailedData is the data for an operation:failed event, published when a
+// best-effort step of a user action (e.g. closing the beads issue on merge) fails
+// without failing the action itself.
+type OperationFailedData struct {
+	Operation string     `json:"operation"`
+	TaskID    uuid.UUID  `json:"task_id"`
+	SubtaskID *uuid.UUID `json:"subtask_id,omitempty"`
+	Error     string     `json:"error"`
+	FailedAt  time.Time  `json:"failed_at"`
+}
+
+// Operations reported by operation:failed events.
+const (
+	OperationCloseBeadsIssue   = "close_beads_issue"
+	OperationUnblockDependents = "unblock_dependents"
+	OperationCheckCompletion   = "check_task_completion"
+	OperationRemoveWorktree    = "remove_worktree"
+)
+
+// ReauthRequiredData is the data for a github:reauth_required event, published
+// when GitHub rejects the project owner's token during a background operation.
+// The user must reconnect GitHub before the operation can succeed.
+type ReauthRequiredData struct {
+	Operation  string     `json:"operation"`
+	TaskID     uuid.UUID  `json:"task_id"`
+	SubtaskID  *uuid.UUID `json:"subtask_id,omitempty"`
+	Error      string     `json:"error"`
+	DetectedAt time.Time  `json:"detected_at"`
+}
+
+// Operations reported by github:reauth_required events.
+const (
+	OperationPushBranch = "push_branch"
+	OperationCreatePR   = "create_pr"
+)
+
 // ConnectedData is the data for a connected event.
 type ConnectedData struct {
 	ProjectID    uuid.UUID   `json:"project_id"`
@@ -114,6 +212,19 @@ type HeartbeatData struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// ShutdownData is the data for a shutdown event.
+type ShutdownData struct {
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ReconnectData is the data for a reconnect event, sent before the server
+// closes a stream that the client should immediately reopen.
+type ReconnectData struct {
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // ErrorData is the data for an error event.
 type ErrorData struct {
 	Code    string `json:"code"`
@@ -125,16 +236,32 @@ type ErrorData struct {
 const (
 	EventTypeAgentStarted         = "agent:started"
 	EventTypeAgentLog             = "agent:log"
+	EventTypeAgentLogBatch        = "agent:log_batch"
+	EventTypeAgentLogThrottled    = "agent:log_throttled"
 	EventTypeAgentCompleted       = "agent:completed"
 	EventTypeAgentFailed          = "agent:failed"
+	EventTypeAgentStalled         = "agent:stalled"
 	EventTypeTaskStatusChanged    = "task:status_changed"
+	EventTypeTaskCompleted        = "task:completed"
 	EventTypeSubtaskStatusChanged = "subtask:status_changed"
+	EventTypeSubtaskCreated       = "subtask:created"
 	EventTypeSubtaskUnblocked     = "subtask:unblocked"
+	EventTypeOperationFailed      = "operation:failed"
+	EventTypeReauthRequired       = "github:reauth_required"
 	EventTypeConnected            = "connected"
 	EventTypeHeartbeat            = "heartbeat"
+	EventTypeShutdown             = "shutdown"
+	EventTypeReconnect            = "reconnect"
 	EventTypeError                = "error"
 )
 
+// EventSink receives project events from the hub in addition to SSE clients,
+// e.g. to forward them to webhooks. Agent log events are not delivered to sinks.
+// Deliver is called on the publisher's goroutine and must not block.
+type EventSink interface {
+	Deliver(projectID uuid.UUID, event Event)
+}
+
 // EventHub is the central event distribution system for real-time events.
 type EventHub interface {
 	// Subscribe creates a new subscription for a project.
@@ -151,14 +278,38 @@ type EventHub interface {
 	// UserConnectionCount returns the number of active connections for a user.
 	UserConnectionCount(userID uuid.UUID) int
 
+	// Drain signals all connections to send a final shutdown event and close.
+	// It is safe to call more than once.
+	Drain()
+
+	// Draining returns a channel that is closed once Drain has been called.
+	Draining() <-chan struct{}
+
+	// AddSink registers a sink that receives every non-log event published.
+	AddSink(sink EventSink)
+
+	// SetReconnectPolicy configures the flap detection used by RecordConnect.
+	SetReconnectPolicy(policy ReconnectPolicy)
+
+	// RecordConnect notes that a user is opening a stream and decides the
+	// retry hint to send and whether to refuse it (see ReconnectPolicy).
+	RecordConnect(userID uuid.UUID) ReconnectDecision
+
 	// Publishing methods
 	PublishAgentStarted(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID)
 	PublishAgentLog(projectID, runID uuid.UUID, line string, lineNumber int, timestamp string)
+	PublishAgentLogBatch(projectID, runID uuid.UUID, lines []AgentLogLine)
+	PublishAgentLogThrottled(projectID, runID uuid.UUID, skipped, fromLine, toLine int)
 	PublishAgentCompleted(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, prURL string)
 	PublishAgentFailed(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, errMsg string, willRetry bool, nextAttemptAt *time.Time)
+	PublishAgentStalled(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, lastOutputAt time.Time, cancelled bool)
 	PublishTaskStatusChanged(projectID, taskID uuid.UUID, oldStatus, newStatus string)
+	PublishTaskCompleted(projectID uuid.UUID, task *domain.Task, summary TaskCompletionSummary)
 	PublishSubtaskStatusChanged(projectID uuid.UUID, subtask *domain.Subtask, oldStatus string)
+	PublishSubtaskCreated(projectID uuid.UUID, subtask *domain.Subtask)
 	PublishSubtaskUnblocked(projectID, taskID, subtaskID, unblockedByID uuid.UUID)
+	PublishOperationFailed(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string)
+	PublishReauthRequired(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string)
 }
 
 // connection represents a single SSE connection.
@@ -174,12 +325,24 @@ type connection struct {
 type eventHub struct {
 	mu          sync.RWMutex
 	connections map[uuid.UUID]map[string]*connection // projectID -> connID -> connection
+	sinks       []EventSink
+	reconnects  reconnectTracker
 	bufferSize  int
 	logger      *slog.Logger
+	metrics     *metrics.Metrics
+
+	drainCh   chan struct{}
+	drainOnce sync.Once
 }
 
 // NewEventHub creates a new EventHub.
 func NewEventHub(bufferSize int, logger *slog.Logger) EventHub {
+	return NewEventHubWithMetrics(bufferSize, logger, nil)
+}
+
+// NewEventHubWithMetrics creates a new EventHub that records connection and
+// dropped-event metrics. A nil metrics value disables instrumentation.
+func NewEventHubWithMetrics(bufferSize int, logger *slog.Logger, m *metrics.Metrics) EventHub {
 	if bufferSize <= 0 {
 		bufferSize = 100 // default buffer size
 	}
@@ -188,8 +351,11 @@ func NewEventHub(bufferSize int, logger *slog.Logger) EventHub {
 	}
 	return &eventHub{
 		connections: make(map[uuid.UUID]map[string]*connection),
+		reconnects:  reconnectTracker{connects: make(map[uuid.UUID][]time.Time)},
 		bufferSize:  bufferSize,
 		logger:      logger,
+		metrics:     m,
+		drainCh:     make(chan struct{}),
 	}
 }
 
@@ -216,6 +382,8 @@ func (h *eventHub) Subscribe(projectID, userID uuid.UUID, logSubscriptions []uui
 	h.connections[projectID][connID] = conn
 	h.mu.Unlock()
 
+	h.metrics.SSEConnectionOpened()
+
 	h.logger.Debug("client subscribed",
 		"project_id", projectID,
 		"user_id", userID,
@@ -231,6 +399,7 @@ func (h *eventHub) Subscribe(projectID, userID uuid.UUID, logSubscriptions []uui
 			if conn, exists := projectConns[connID]; exists {
 				close(conn.eventChan)
 				delete(projectConns, connID)
+				h.metrics.SSEConnectionClosed()
 			}
 			// Remove project entry if no more connections
 			if len(projectConns) == 0 {
@@ -293,8 +462,69 @@ func (h *eventHub) UserConnectionCount(userID uuid.UUID) int {
 	return count
 }
 
-// broadcast sends an event to all connections for a project.
+// Drain signals all connections to send a final shutdown event and close.
+func (h *eventHub) Drain() {
+	h.drainOnce.Do(func() {
+		h.mu.RLock()
+		count := 0
+		for _, projectConns := range h.connections {
+			count += len(projectConns)
+		}
+		h.mu.RUnlock()
+
+		h.logger.Info("draining SSE connections", "connections", count)
+		close(h.drainCh)
+	})
+}
+
+// Draining returns a channel that is closed once Drain has been called.
+func (h *eventHub) Draining() <-chan struct{} {
+	return h.drainCh
+}
+
+// AddSink registers a sink that receives every non-log event published.
+func (h *eventHub) AddSink(sink EventSink) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sinks = append(h.sinks, sink)
+}
+
+// SetReconnectPolicy configures the flap detection used by RecordConnect.
+func (h *eventHub) SetReconnectPolicy(policy ReconnectPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reconnects.policy = policy
+}
+
+// RecordConnect notes that a user is opening a stream and decides the retry
+// hint to send and whether to refuse it.
+func (h *eventHub) RecordConnect(userID uuid.UUID) ReconnectDecision {
+	h.mu.Lock()
+	decision := h.reconnects.record(userID, time.Now())
+	h.mu.Unlock()
+
+	switch {
+	case decision.Reject:
+		h.metrics.SSEFlap("reject")
+	case decision.Flapping:
+		h.metrics.SSEFlap("backoff")
+	}
+	return decision
+}
+
+// broadcast sends an event to all sinks and connections for a project.
 func (h *eventHub) broadcast(projectID uuid.UUID, event Event, runID *uuid.UUID) {
+	h.mu.RLock()
+	sinks := h.sinks
+	h.mu.RUnlock()
+
+	// Sinks get events even when no browser is connected
+	if !isLogEvent(event.Type) {
+		for _, sink := range sinks {
+			sink.Deliver(projectID, event)
+		}
+	}
+
 	h.mu.RLock()
 	projectConns, ok := h.connections[projectID]
 	if !ok {
@@ -311,7 +541,7 @@ func (h *eventHub) broadcast(projectID uuid.UUID, event Event, runID *uuid.UUID)
 
 	for _, conn := range conns {
 		// For log events, check if the connection is subscribed
-		if event.Type == EventTypeAgentLog && runID != nil {
+		if isLogEvent(event.Type) && runID != nil {
 			conn.mu.RLock()
 			subscribed := conn.logSubscriptions[*runID]
 			conn.mu.RUnlock()
@@ -326,6 +556,7 @@ func (h *eventHub) broadcast(projectID uuid.UUID, event Event, runID *uuid.UUID)
 			// Event sent successfully
 		default:
 			// Channel full, drop event and log warning
+			h.metrics.EventDropped(event.Type)
 			h.logger.Warn("event channel full, dropping event",
 				"conn_id", conn.id,
 				"event_type", event.Type,
@@ -378,6 +609,40 @@ func (h *eventHub) PublishAgentLog(projectID, runID uuid.UUID, line string, line
 	h.broadcast(projectID, event, &runID)
 }
 
+// PublishAgentLogBatch publishes an agent:log_batch event.
+func (h *eventHub) PublishAgentLogBatch(projectID, runID uuid.UUID, lines []AgentLogLine) {
+	event := Event{
+		Type: EventTypeAgentLogBatch,
+		Data: AgentLogBatchData{
+			RunID: runID,
+			Lines: lines,
+		},
+	}
+
+	h.broadcast(projectID, event, &runID)
+}
+
+// PublishAgentLogThrottled publishes an agent:log_throttled event.
+func (h *eventHub) PublishAgentLogThrottled(projectID, runID uuid.UUID, skipped, fromLine, toLine int) {
+	event := Event{
+		Type: EventTypeAgentLogThrottled,
+		Data: AgentLogThrottledData{
+			RunID:        runID,
+			SkippedLines: skipped,
+			FromLine:     fromLine,
+			ToLine:       toLine,
+		},
+	}
+
+	h.broadcast(projectID, event, &runID)
+}
+
+// isLogEvent reports whether eventType carries agent log lines, which only go
+// to connections subscribed to the run and never to sinks.
+func isLogEvent(eventType string) bool {
+	return eventType == EventTypeAgentLog || eventType == EventTypeAgentLogBatch || eventType == EventTypeAgentLogThrottled
+}
+
 // PublishAgentCompleted publishes an agent:completed event.
 func (h *eventHub) PublishAgentCompleted(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, prURL string) {
 	var subtaskID *string
@@ -449,6 +714,38 @@ func (h *eventHub) PublishAgentFailed(projectID uuid.UUID, run *domain.AgentRun,
 	)
 }
 
+// PublishAgentStalled publishes an agent:stalled event.
+func (h *eventHub) PublishAgentStalled(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, lastOutputAt time.Time, cancelled bool) {
+	var subtaskID *string
+	if run.AgentType == domain.AgentTypeWorker {
+		s := run.SubtaskID.String()
+		subtaskID = &s
+	}
+
+	stalledFor := time.Since(lastOutputAt)
+	event := Event{
+		Type: EventTypeAgentStalled,
+		Data: AgentStalledData{
+			RunID:         run.ID,
+			AgentType:     string(run.AgentType),
+			TaskID:        taskID,
+			SubtaskID:     subtaskID,
+			AttemptNumber: run.AttemptNumber,
+			LastOutputAt:  lastOutputAt,
+			StalledForMs:  stalledFor.Milliseconds(),
+			Cancelled:     cancelled,
+		},
+	}
+
+	h.broadcast(projectID, event, nil)
+
+	h.logger.Debug("published agent:stalled",
+		"project_id", projectID,
+		"run_id", run.ID,
+		"stalled_for", stalledFor,
+	)
+}
+
 // PublishTaskStatusChanged publishes a task:status_changed event.
 func (h *eventHub) PublishTaskStatusChanged(projectID, taskID uuid.UUID, oldStatus, newStatus string) {
 	event := Event{
@@ -471,6 +768,33 @@ func (h *eventHub) PublishTaskStatusChanged(projectID, taskID uuid.UUID, oldStat
 	)
 }
 
+// PublishTaskCompleted publishes a task:completed event for a task that has
+// just moved to DONE.
+func (h *eventHub) PublishTaskCompleted(projectID uuid.UUID, task *domain.Task, summary TaskCompletionSummary) {
+	durationMs := task.UpdatedAt.Sub(task.CreatedAt).Milliseconds()
+
+	event := Event{
+		Type: EventTypeTaskCompleted,
+		Data: TaskCompletedData{
+			TaskID:       task.ID,
+			Title:        task.Title,
+			SubtaskCount: summary.SubtaskCount,
+			MergedCount:  summary.MergedCount,
+			TokenUsage:   summary.TokenUsage,
+			DurationMs:   durationMs,
+			CompletedAt:  task.UpdatedAt,
+		},
+	}
+
+	h.broadcast(projectID, event, nil)
+
+	h.logger.Debug("published task:completed",
+		"project_id", projectID,
+		"task_id", task.ID,
+		"duration_ms", durationMs,
+	)
+}
+
 // PublishSubtaskStatusChanged publishes a subtask:status_changed event.
 func (h *eventHub) PublishSubtaskStatusChanged(projectID uuid.UUID, subtask *domain.Subtask, oldStatus string) {
 	var blockedReason *string
@@ -503,6 +827,36 @@ func (h *eventHub) PublishSubtaskStatusChanged(projectID uuid.UUID, subtask *dom
 	)
 }
 
+// PublishSubtaskCreated publishes a subtask:created event.
+func (h *eventHub) PublishSubtaskCreated(projectID uuid.UUID, subtask *domain.Subtask) {
+	var blockedReason *string
+	if subtask.BlockedReason != nil {
+		s := string(*subtask.BlockedReason)
+		blockedReason = &s
+	}
+
+	event := Event{
+		Type: EventTypeSubtaskCreated,
+		Data: SubtaskCreatedData{
+			SubtaskID:     subtask.ID,
+			TaskID:        subtask.TaskID,
+			Title:         subtask.Title,
+			Status:        string(subtask.Status),
+			BlockedReason: blockedReason,
+			BeadsIssueID:  subtask.BeadsIssueID,
+			CreatedAt:     subtask.CreatedAt,
+		},
+	}
+
+	h.broadcast(projectID, event, nil)
+
+	h.logger.Debug("published subtask:created",
+		"project_id", projectID,
+		"subtask_id", subtask.ID,
+		"status", subtask.Status,
+	)
+}
+
 // PublishSubtaskUnblocked publishes a subtask:unblocked event.
 func (h *eventHub) PublishSubtaskUnblocked(projectID, taskID, subtaskID, unblockedByID uuid.UUID) {
 	event := Event{
@@ -523,3 +877,47 @@ func (h *eventHub) PublishSubtaskUnblocked(projectID, taskID, subtaskID, unblock
 		"unblocked_by", unblockedByID,
 	)
 }
+
+// PublishOperationFailed publishes an operation:failed event.
+func (h *eventHub) PublishOperationFailed(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string) {
+	event := Event{
+		Type: EventTypeOperationFailed,
+		Data: OperationFailedData{
+			Operation: operation,
+			TaskID:    taskID,
+			SubtaskID: subtaskID,
+			Error:     errMsg,
+			FailedAt:  time.Now(),
+		},
+	}
+
+	h.broadcast(projectID, event, nil)
+
+	h.logger.Debug("published operation:failed",
+		"project_id", projectID,
+		"task_id", taskID,
+		"operation", operation,
+	)
+}
+
+// PublishReauthRequired publishes a github:reauth_required event.
+func (h *eventHub) PublishReauthRequired(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string) {
+	event := Event{
+		Type: EventTypeReauthRequired,
+		Data: ReauthRequiredData{
+			Operation:  operation,
+			TaskID:     taskID,
+			SubtaskID:  subtaskID,
+			Error:      errMsg,
+			DetectedAt: time.Now(),
+		},
+	}
+
+	h.broadcast(projectID, event, nil)
+
+	h.logger.Debug("published github:reauth_required",
+		"project_id", projectID,
+		"task_id", taskID,
+		"operation", operation,
+	)
+}
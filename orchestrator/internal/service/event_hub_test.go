@@ -195,6 +195,42 @@ func TestEventHub_LogSubscriptionInitial(t *testing.T) {
 	}
 }
 
+func TestEventHub_LogBatchSubscriptions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewEventHub(100, logger)
+
+	projectID := uuid.New()
+	runID := uuid.New()
+
+	_, subscribed, cleanup := hub.Subscribe(projectID, uuid.New(), []uuid.UUID{runID})
+	defer cleanup()
+	_, other, cleanupOther := hub.Subscribe(projectID, uuid.New(), nil)
+	defer cleanupOther()
+
+	hub.PublishAgentLogBatch(projectID, runID, []AgentLogLine{
+		{Line: "[14:32:05] one", LineNumber: 1, Timestamp: "14:32:05"},
+		{Line: "[14:32:05] two", LineNumber: 2, Timestamp: "14:32:05"},
+	})
+
+	select {
+	case event := <-subscribed:
+		assert.Equal(t, EventTypeAgentLogBatch, event.Type)
+		data, ok := event.Data.(AgentLogBatchData)
+		require.True(t, ok)
+		assert.Equal(t, runID, data.RunID)
+		require.Len(t, data.Lines, 2)
+		assert.Equal(t, 2, data.Lines[1].LineNumber)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for log batch event")
+	}
+
+	select {
+	case <-other:
+		t.Fatal("should not receive log batches when not subscribed")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 func TestEventHub_ChannelFull(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	// Small buffer to test overflow
@@ -381,6 +417,45 @@ func TestEventHub_PublishAgentFailed(t *testing.T) {
 	}
 }
 
+func TestEventHub_PublishAgentStalled(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewEventHub(100, logger)
+
+	projectID := uuid.New()
+	userID := uuid.New()
+	taskID := uuid.New()
+	subtaskID := uuid.New()
+
+	_, eventChan, cleanup := hub.Subscribe(projectID, userID, nil)
+	defer cleanup()
+
+	run := &domain.AgentRun{
+		ID:            uuid.New(),
+		SubtaskID:     &subtaskID,
+		AgentType:     domain.AgentTypeWorker,
+		AttemptNumber: 2,
+		StartedAt:     time.Now(),
+	}
+
+	lastOutput := time.Now().Add(-10 * time.Minute)
+	hub.PublishAgentStalled(projectID, run, taskID, lastOutput, true)
+
+	select {
+	case event := <-eventChan:
+		assert.Equal(t, EventTypeAgentStalled, event.Type)
+		data, ok := event.Data.(AgentStalledData)
+		require.True(t, ok)
+		assert.Equal(t, run.ID, data.RunID)
+		require.NotNil(t, data.SubtaskID)
+		assert.Equal(t, subtaskID.String(), *data.SubtaskID)
+		assert.Equal(t, 2, data.AttemptNumber)
+		assert.GreaterOrEqual(t, data.StalledForMs, (10 * time.Minute).Milliseconds())
+		assert.True(t, data.Cancelled)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for event")
+	}
+}
+
 func TestEventHub_PublishSubtaskStatusChanged(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	hub := NewEventHub(100, logger)
@@ -419,6 +494,45 @@ func TestEventHub_PublishSubtaskStatusChanged(t *testing.T) {
 	}
 }
 
+func TestEventHub_PublishSubtaskCreated(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewEventHub(100, logger)
+
+	projectID := uuid.New()
+	beadsID := "bd-42"
+	reason := domain.BlockedReasonDependency
+	subtask := &domain.Subtask{
+		ID:            uuid.New(),
+		TaskID:        uuid.New(),
+		Title:         "Add retry metrics",
+		Status:        domain.SubtaskStatusBlocked,
+		BlockedReason: &reason,
+		BeadsIssueID:  &beadsID,
+		CreatedAt:     time.Now(),
+	}
+
+	_, eventChan, cleanup := hub.Subscribe(projectID, uuid.New(), nil)
+	defer cleanup()
+
+	hub.PublishSubtaskCreated(projectID, subtask)
+
+	select {
+	case event := <-eventChan:
+		assert.Equal(t, EventTypeSubtaskCreated, event.Type)
+		data, ok := event.Data.(SubtaskCreatedData)
+		require.True(t, ok)
+		assert.Equal(t, subtask.ID, data.SubtaskID)
+		assert.Equal(t, subtask.TaskID, data.TaskID)
+		assert.Equal(t, "Add retry metrics", data.Title)
+		assert.Equal(t, string(domain.SubtaskStatusBlocked), data.Status)
+		require.NotNil(t, data.BlockedReason)
+		assert.Equal(t, string(domain.BlockedReasonDependency), *data.BlockedReason)
+		assert.Equal(t, &beadsID, data.BeadsIssueID)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for event")
+	}
+}
+
 func TestEventHub_PublishSubtaskUnblocked(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	hub := NewEventHub(100, logger)
@@ -447,9 +561,111 @@ func TestEventHub_PublishSubtaskUnblocked(t *testing.T) {
 	}
 }
 
+func TestEventHub_PublishOperationFailed(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewEventHub(100, logger)
+
+	projectID := uuid.New()
+	taskID := uuid.New()
+	subtaskID := uuid.New()
+
+	_, eventChan, cleanup := hub.Subscribe(projectID, uuid.New(), nil)
+	defer cleanup()
+
+	hub.PublishOperationFailed(projectID, taskID, &subtaskID, OperationCloseBeadsIssue, "bd: issue not found")
+
+	select {
+	case event := <-eventChan:
+		assert.Equal(t, EventTypeOperationFailed, event.Type)
+		data, ok := event.Data.(OperationFailedData)
+		require.True(t, ok)
+		assert.Equal(t, OperationCloseBeadsIssue, data.Operation)
+		assert.Equal(t, taskID, data.TaskID)
+		require.NotNil(t, data.SubtaskID)
+		assert.Equal(t, subtaskID, *data.SubtaskID)
+		assert.Equal(t, "bd: issue not found", data.Error)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for event")
+	}
+}
+
+func TestEventHub_PublishReauthRequired(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewEventHub(100, logger)
+
+	projectID := uuid.New()
+	taskID := uuid.New()
+	subtaskID := uuid.New()
+
+	_, eventChan, cleanup := hub.Subscribe(projectID, uuid.New(), nil)
+	defer cleanup()
+
+	hub.PublishReauthRequired(projectID, taskID, &subtaskID, OperationPushBranch, "bad credentials")
+
+	select {
+	case event := <-eventChan:
+		assert.Equal(t, EventTypeReauthRequired, event.Type)
+		data, ok := event.Data.(ReauthRequiredData)
+		require.True(t, ok)
+		assert.Equal(t, OperationPushBranch, data.Operation)
+		assert.Equal(t, taskID, data.TaskID)
+		require.NotNil(t, data.SubtaskID)
+		assert.Equal(t, subtaskID, *data.SubtaskID)
+		assert.Equal(t, "bad credentials", data.Error)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timeout waiting for event")
+	}
+}
+
 func TestEventHub_DefaultBufferSize(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	// Test with 0 buffer size - should default to 100
 	hub := NewEventHub(0, logger).(*eventHub)
 	assert.Equal(t, 100, hub.bufferSize)
 }
+
+func TestEventHub_Drain(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewEventHub(100, logger)
+
+	select {
+	case <-hub.Draining():
+		t.Fatal("hub should not be draining before Drain is called")
+	default:
+	}
+
+	hub.Drain()
+	hub.Drain() // Safe to call twice
+
+	select {
+	case <-hub.Draining():
+		// Expected
+	default:
+		t.Fatal("hub should be draining after Drain is called")
+	}
+}
+
+// recordingSink collects events delivered to it.
+type recordingSink struct {
+	events []Event
+}
+
+func (s *recordingSink) Deliver(projectID uuid.UUID, event Event) {
+	s.events = append(s.events, event)
+}
+
+func TestEventHub_SinkReceivesEventsWithoutConnections(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := NewEventHub(100, logger)
+
+	sink := &recordingSink{}
+	hub.AddSink(sink)
+
+	projectID := uuid.New()
+	hub.PublishTaskStatusChanged(projectID, uuid.New(), "PLANNING", "ACTIVE")
+	hub.PublishAgentLog(projectID, uuid.New(), "line", 1, "2026-01-01T00:00:00Z")
+	hub.PublishAgentLogBatch(projectID, uuid.New(), []AgentLogLine{{Line: "line", LineNumber: 2}})
+
+	require.Len(t, sink.events, 1, "log events should not reach sinks")
+	assert.Equal(t, EventTypeTaskStatusChanged, sink.events[0].Type)
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// gitRun runs a git command in dir and fails the test on error.
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v (output: %s)", args, err, output)
+	}
+}
+
+// gitCommit writes a file and commits it with the given message.
+func gitCommit(t *testing.T, dir, file, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(message), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", file, err)
+	}
+	gitRun(t, dir, "add", file)
+	gitRun(t, dir, "commit", "-m", message)
+}
+
+// newCommitsTestRepo creates a repo with one commit on main and returns its path.
+func newCommitsTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available, skipping test")
+	}
+
+	repoPath := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(repoPath, 0755); err != nil {
+		t.Fatalf("failed to create repo dir: %v", err)
+	}
+	gitRun(t, repoPath, "init", "-b", "main")
+	gitCommit(t, repoPath, "README.md", "initial commit")
+	return repoPath
+}
+
+func TestGetCommitMessages_BaseExists(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+	gitRun(t, repoPath, "checkout", "-b", "feature")
+	gitCommit(t, repoPath, "a.txt", "add a")
+	gitCommit(t, repoPath, "b.txt", "add b")
+
+	svc := NewGitHubService()
+	messages, err := svc.GetCommitMessages(context.Background(), repoPath, "main")
+	if err != nil {
+		t.Fatalf("GetCommitMessages() error = %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("GetCommitMessages() = %v, want 2 commits", messages)
+	}
+	if !contains(messages[0], "add b") || !contains(messages[1], "add a") {
+		t.Errorf("GetCommitMessages() = %v, want [add b, add a]", messages)
+	}
+}
+
+func TestGetCommitMessages_NoNewCommits(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+	gitRun(t, repoPath, "checkout", "-b", "feature")
+
+	svc := NewGitHubService()
+	messages, err := svc.GetCommitMessages(context.Background(), repoPath, "main")
+	if err != nil {
+		t.Fatalf("GetCommitMessages() error = %v", err)
+	}
+	if messages == nil || len(messages) != 0 {
+		t.Errorf("GetCommitMessages() = %#v, want empty non-nil slice", messages)
+	}
+}
+
+func TestGetCommitMessages_BaseOnlyOnOrigin(t *testing.T) {
+	remotePath := newCommitsTestRepo(t)
+
+	clonePath := filepath.Join(t.TempDir(), "clone")
+	gitRun(t, filepath.Dir(clonePath), "clone", "--quiet", remotePath, clonePath)
+	gitRun(t, clonePath, "checkout", "-b", "feature")
+	gitCommit(t, clonePath, "a.txt", "add a")
+	gitRun(t, clonePath, "branch", "-D", "main")
+
+	svc := NewGitHubService()
+	messages, err := svc.GetCommitMessages(context.Background(), clonePath, "main")
+	if err != nil {
+		t.Fatalf("GetCommitMessages() error = %v", err)
+	}
+	if len(messages) != 1 || !contains(messages[0], "add a") {
+		t.Errorf("GetCommitMessages() = %v, want [add a]", messages)
+	}
+}
+
+func TestGetCommitMessages_BaseMissingFallsBackToHead(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+	for i := 0; i < commitMessagesFallbackLimit+5; i++ {
+		gitCommit(t, repoPath, "counter.txt", "commit "+string(rune('a'+i)))
+	}
+
+	// No origin remote and no "develop" branch anywhere
+	svc := NewGitHubService()
+	messages, err := svc.GetCommitMessages(context.Background(), repoPath, "develop")
+	if err != nil {
+		t.Fatalf("GetCommitMessages() error = %v", err)
+	}
+	if len(messages) != commitMessagesFallbackLimit {
+		t.Errorf("GetCommitMessages() returned %d commits, want %d", len(messages), commitMessagesFallbackLimit)
+	}
+}
+
+func TestGetChangedFiles(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+	gitRun(t, repoPath, "checkout", "-b", "feature")
+	gitCommit(t, repoPath, "a.txt", "line one\nline two\n")
+	if err := os.WriteFile(filepath.Join(repoPath, "README.md"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, repoPath, "commit", "-am", "edit readme")
+
+	svc := NewGitHubService()
+	files, err := svc.GetChangedFiles(context.Background(), repoPath, "main")
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+
+	want := map[string]ChangedFile{
+		"a.txt":     {Path: "a.txt", Status: "A", Additions: 2},
+		"README.md": {Path: "README.md", Status: "M", Additions: 1, Deletions: 1},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("GetChangedFiles() = %+v, want %d files", files, len(want))
+	}
+	for _, f := range files {
+		if f != want[f.Path] {
+			t.Errorf("GetChangedFiles() file = %+v, want %+v", f, want[f.Path])
+		}
+	}
+}
+
+func TestGetChangedFiles_BaseMovedOn(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+	gitRun(t, repoPath, "checkout", "-b", "feature")
+	gitCommit(t, repoPath, "a.txt", "add a")
+
+	// A sibling's change lands on main after the branch was cut
+	gitRun(t, repoPath, "checkout", "main")
+	gitCommit(t, repoPath, "sibling.txt", "add sibling")
+	gitRun(t, repoPath, "checkout", "feature")
+
+	svc := NewGitHubService()
+	files, err := svc.GetChangedFiles(context.Background(), repoPath, "main")
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "a.txt" || files[0].Status != "A" {
+		t.Errorf("GetChangedFiles() = %+v, want only the branch's a.txt", files)
+	}
+}
+
+func TestGetChangedFiles_BaseMissing(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+
+	svc := NewGitHubService()
+	if _, err := svc.GetChangedFiles(context.Background(), repoPath, "develop"); err == nil {
+		t.Error("GetChangedFiles() expected error for missing base, got nil")
+	}
+}
+
+// setGitIdentity lets git commands run by the service commit in tests.
+func setGitIdentity(t *testing.T) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test User")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test User")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+}
+
+func TestSquashCommits(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+	setGitIdentity(t)
+	gitRun(t, repoPath, "checkout", "-b", "feature")
+	gitCommit(t, repoPath, "a.txt", "add a")
+	gitCommit(t, repoPath, "b.txt", "add b")
+	gitCommit(t, repoPath, "a.txt", "change a")
+
+	svc := NewGitHubService()
+	squashed, err := svc.SquashCommits(context.Background(), repoPath, "main", "Add a and b")
+	if err != nil {
+		t.Fatalf("SquashCommits() error = %v", err)
+	}
+	if !squashed {
+		t.Fatal("SquashCommits() squashed = false, want true")
+	}
+
+	messages, err := svc.GetCommitMessages(context.Background(), repoPath, "main")
+	if err != nil {
+		t.Fatalf("GetCommitMessages() error = %v", err)
+	}
+	if len(messages) != 1 || !contains(messages[0], "Add a and b") {
+		t.Errorf("commits after squash = %v, want [Add a and b]", messages)
+	}
+
+	files, err := svc.GetChangedFiles(context.Background(), repoPath, "main")
+	if err != nil {
+		t.Fatalf("GetChangedFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("files after squash = %+v, want a.txt and b.txt", files)
+	}
+	content, err := os.ReadFile(filepath.Join(repoPath, "a.txt"))
+	if err != nil || string(content) != "change a" {
+		t.Errorf("a.txt = %q (err %v), want the last committed content", content, err)
+	}
+}
+
+func TestSquashCommits_NothingToSquash(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits []string
+	}{
+		{name: "no commits"},
+		{name: "one commit", commits: []string{"add a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := newCommitsTestRepo(t)
+			setGitIdentity(t)
+			gitRun(t, repoPath, "checkout", "-b", "feature")
+			for _, c := range tt.commits {
+				gitCommit(t, repoPath, "a.txt", c)
+			}
+
+			svc := NewGitHubService()
+			squashed, err := svc.SquashCommits(context.Background(), repoPath, "main", "squashed")
+			if err != nil {
+				t.Fatalf("SquashCommits() error = %v", err)
+			}
+			if squashed {
+				t.Error("SquashCommits() squashed = true, want false")
+			}
+
+			messages, err := svc.GetCommitMessages(context.Background(), repoPath, "main")
+			if err != nil {
+				t.Fatalf("GetCommitMessages() error = %v", err)
+			}
+			if len(messages) != len(tt.commits) {
+				t.Errorf("commits = %v, want %v", messages, tt.commits)
+			}
+		})
+	}
+}
+
+func TestSquashCommits_BaseMissing(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+
+	svc := NewGitHubService()
+	if _, err := svc.SquashCommits(context.Background(), repoPath, "develop", "squashed"); err == nil {
+		t.Error("SquashCommits() expected error for missing base, got nil")
+	}
+}
+
+func TestParseChangedFiles(t *testing.T) {
+	nameStatus := "M\tmain.go\nA\timg/logo.png\nD\told.txt\n"
+	numstat := "3\t1\tmain.go\n-\t-\timg/logo.png\n0\t12\told.txt\n"
+
+	files := parseChangedFiles(nameStatus, numstat)
+	want := []ChangedFile{
+		{Path: "main.go", Status: "M", Additions: 3, Deletions: 1},
+		{Path: "img/logo.png", Status: "A", Binary: true},
+		{Path: "old.txt", Status: "D", Deletions: 12},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("parseChangedFiles() = %+v, want %+v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("parseChangedFiles()[%d] = %+v, want %+v", i, files[i], want[i])
+		}
+	}
+}
+
+func TestGetDiff_FromMergeBase(t *testing.T) {
+	repoPath := newCommitsTestRepo(t)
+	gitRun(t, repoPath, "checkout", "-b", "feature")
+	gitCommit(t, repoPath, "a.txt", "add a")
+
+	// A commit on main after the branch was cut is not part of the branch's diff
+	gitRun(t, repoPath, "checkout", "main")
+	gitCommit(t, repoPath, "main.txt", "add main")
+	gitRun(t, repoPath, "checkout", "feature")
+
+	svc := NewGitHubService()
+	diff, err := svc.GetDiff(context.Background(), repoPath, "main")
+	if err != nil {
+		t.Fatalf("GetDiff() error = %v", err)
+	}
+	if !contains(diff, "+++ b/a.txt") || !contains(diff, "+add a") {
+		t.Errorf("GetDiff() = %q, want the a.txt addition", diff)
+	}
+	if contains(diff, "main.txt") {
+		t.Errorf("GetDiff() = %q, want no changes from main", diff)
+	}
+
+	if _, err := svc.GetDiff(context.Background(), repoPath, "missing"); err == nil {
+		t.Error("GetDiff() with a missing base succeeded, want error")
+	}
+}
@@ -5,11 +5,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/intern-village/orchestrator/internal/domain"
 )
 
 // TestSyncRepoWithRetry_MaxRetriesReached tests that SyncRepoWithRetry returns error after max retries.
@@ -45,6 +49,73 @@ func TestSyncRepoWithRetry_ContextCancellation(t *testing.T) {
 	}
 }
 
+// TestSyncRepoWithRetry_InvalidBranch tests that a malformed branch name is
+// rejected without running git or retrying.
+func TestSyncRepoWithRetry_InvalidBranch(t *testing.T) {
+	svc := NewGitHubService()
+	ctx := context.Background()
+
+	for _, branch := range []string{"", "--upload-pack=touch /tmp/x", "main\x00", "main..evil", "release/1.2.lock"} {
+		start := time.Now()
+		err := svc.SyncRepoWithRetry(ctx, t.TempDir(), branch, false, 3)
+		if !errors.Is(err, ErrSyncFailed) || !domain.IsInvalidInput(err) {
+			t.Errorf("SyncRepoWithRetry(%q) error = %v, want an invalid branch sync error", branch, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("SyncRepoWithRetry(%q) took %v, want no retries", branch, elapsed)
+		}
+	}
+}
+
+// TestSyncDirectClone_BranchWithSlashes syncs a clone to a branch whose name
+// contains slashes, given as a full ref.
+func TestSyncDirectClone_BranchWithSlashes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available, skipping test")
+	}
+
+	dir := t.TempDir()
+	git := func(repo string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v (output: %s)", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+
+	// A remote with a release/1.2 branch one commit ahead of the default
+	source := filepath.Join(dir, "source")
+	if err := os.Mkdir(source, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git(source, "init", "-q")
+	git(source, "commit", "-q", "--allow-empty", "-m", "initial")
+	git(source, "checkout", "-q", "-b", "release/1.2")
+	git(source, "commit", "-q", "--allow-empty", "-m", "release")
+	want := git(source, "rev-parse", "HEAD")
+
+	clone := filepath.Join(dir, "clone")
+	git(dir, "clone", "-q", source, clone)
+
+	svc := NewGitHubService()
+	if err := svc.SyncRepo(context.Background(), clone, "refs/heads/release/1.2", false); err != nil {
+		t.Fatalf("SyncRepo() error = %v", err)
+	}
+	if got := git(clone, "branch", "--show-current"); got != "release/1.2" {
+		t.Errorf("checked out branch = %q, want release/1.2", got)
+	}
+	if got := git(clone, "rev-parse", "HEAD"); got != want {
+		t.Errorf("HEAD = %s, want %s", got, want)
+	}
+}
+
 // TestSyncDirectClone_InvalidPath tests syncDirectClone with invalid path.
 func TestSyncDirectClone_InvalidPath(t *testing.T) {
 	svc := NewGitHubService()
@@ -4,7 +4,18 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+
+	"github.com/intern-village/orchestrator/internal/cmdexec"
+	"github.com/intern-village/orchestrator/internal/domain"
 )
 
 func TestParseRepoURL(t *testing.T) {
@@ -94,3 +105,153 @@ func TestParseRepoURL(t *testing.T) {
 		})
 	}
 }
+
+func TestNewClient_SharedTransportPerUserToken(t *testing.T) {
+	var mu sync.Mutex
+	var auths []string
+	conns := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		conns[r.RemoteAddr] = true
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	defer server.Close()
+
+	svc := NewGitHubService()
+	baseURL, _ := url.Parse(server.URL + "/")
+	for _, token := range []string{"token-a", "token-b", "token-a"} {
+		client := svc.newClient(token)
+		client.BaseURL = baseURL
+		if _, _, err := client.Users.Get(context.Background(), ""); err != nil {
+			t.Fatalf("Users.Get() error = %v", err)
+		}
+	}
+
+	want := []string{"Bearer token-a", "Bearer token-b", "Bearer token-a"}
+	for i := range want {
+		if auths[i] != want[i] {
+			t.Errorf("request %d Authorization = %q, want %q", i, auths[i], want[i])
+		}
+	}
+	if len(conns) != 1 {
+		t.Errorf("requests used %d connections, want 1 reused connection", len(conns))
+	}
+}
+
+func TestCheckGitAuth(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantInvalid bool
+	}{
+		{"token rejected", &cmdexec.Error{Output: "remote: Invalid username or password.\nfatal: Authentication failed for 'https://github.com/o/r.git/'", Err: errors.New("exit status 128")}, true},
+		{"no credentials", &cmdexec.Error{Output: "fatal: could not read Username for 'https://github.com': terminal prompts disabled", Err: errors.New("exit status 128")}, true},
+		{"network error", &cmdexec.Error{Output: "fatal: unable to access 'https://github.com/o/r.git/': Could not resolve host", Err: errors.New("exit status 128")}, false},
+		{"not a git error", errors.New("authentication failed"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGitAuth(tt.err)
+			if got := errors.Is(err, ErrTokenInvalid); got != tt.wantInvalid {
+				t.Errorf("errors.Is(checkGitAuth(), ErrTokenInvalid) = %v, want %v", got, tt.wantInvalid)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("checkGitAuth() should keep the original error")
+			}
+			if tt.wantInvalid && !domain.IsReauthRequired(err) {
+				t.Error("a rejected token should require reauthorization")
+			}
+		})
+	}
+}
+
+func TestSetOriginToken(t *testing.T) {
+	repoPath := t.TempDir()
+	svc := NewGitHubService()
+	ctx := context.Background()
+	if _, err := svc.runGit(ctx, repoPath, "init", "--quiet"); err != nil {
+		t.Fatalf("git init error = %v", err)
+	}
+	if _, err := svc.runGit(ctx, repoPath, "remote", "add", "origin", tokenCloneURL("o", "r", "old-token")); err != nil {
+		t.Fatalf("git remote add error = %v", err)
+	}
+
+	if err := svc.SetOriginToken(ctx, repoPath, "o", "r", "new-token"); err != nil {
+		t.Fatalf("SetOriginToken() error = %v", err)
+	}
+	out, err := svc.runGit(ctx, repoPath, "remote", "get-url", "origin")
+	if err != nil {
+		t.Fatalf("git remote get-url error = %v", err)
+	}
+	if got, want := strings.TrimSpace(out), tokenCloneURL("o", "r", "new-token"); got != want {
+		t.Errorf("origin = %q, want %q", got, want)
+	}
+}
+
+func TestGetPRStatus_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	svc := NewGitHubService()
+	svc.apiURL, _ = url.Parse(server.URL + "/")
+
+	_, err := svc.GetPRStatus(context.Background(), "owner", "repo", 7, "revoked")
+	if !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("GetPRStatus() error = %v, want ErrTokenInvalid", err)
+	}
+	_, err = svc.FindBranchPR(context.Background(), "owner", "repo", "iv-1", "revoked")
+	if !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("FindBranchPR() error = %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestCreatePR_BranchNames(t *testing.T) {
+	var requests int
+	var got struct {
+		Head string `json:"head"`
+		Base string `json:"base"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number":1,"html_url":"https://github.com/owner/repo/pull/1"}`))
+	}))
+	defer server.Close()
+
+	svc := NewGitHubService()
+	svc.apiURL, _ = url.Parse(server.URL + "/")
+	ctx := context.Background()
+
+	// A base branch with slashes, given as a full ref, is sent as a plain name
+	if _, err := svc.CreatePR(ctx, "owner", "repo", "token", "iv-1-add-login", "refs/heads/release/1.2", "title", "body"); err != nil {
+		t.Fatalf("CreatePR() error = %v", err)
+	}
+	if got.Head != "iv-1-add-login" || got.Base != "release/1.2" {
+		t.Errorf("PR head = %q, base = %q; want iv-1-add-login and release/1.2", got.Head, got.Base)
+	}
+
+	for _, tt := range []struct{ head, base string }{
+		{"iv-1-add-login", "-main"},
+		{"iv-1-add-login", "release\n1.2"},
+		{"iv-1..add-login", "main"},
+		{"", "main"},
+	} {
+		_, err := svc.CreatePR(ctx, "owner", "repo", "token", tt.head, tt.base, "title", "body")
+		if !errors.Is(err, ErrPRCreationFailed) || !domain.IsInvalidInput(err) {
+			t.Errorf("CreatePR(head %q, base %q) error = %v, want an invalid branch error", tt.head, tt.base, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("GitHub received %d requests, want only the valid one", requests)
+	}
+}
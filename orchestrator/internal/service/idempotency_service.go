@@ -0,0 +1,127 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// IdempotencyService records Idempotency-Key outcomes so retried create
+// requests replay the original response instead of repeating side effects.
+type IdempotencyService struct {
+	repo *repository.Repository
+	ttl  time.Duration
+}
+
+// NewIdempotencyService creates a new IdempotencyService. Keys expire after ttl.
+func NewIdempotencyService(repo *repository.Repository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Begin claims key for a request. It returns the claim when the caller should
+// execute the request and then call Complete or Release with it. It returns the
+// stored response when the request is a replay of a completed one, a conflict
+// error while the original request is still in flight, and an unprocessable
+// error if the key was used for a different request body. An in-flight claim
+// for the same request older than lease is assumed abandoned (the server died
+// mid-request) and is taken over by this request.
+func (s *IdempotencyService) Begin(ctx context.Context, userID uuid.UUID, key, route, requestHash string, lease time.Duration) (*domain.IdempotencyClaim, *domain.IdempotentResponse, error) {
+	// Expired keys are purged per user here rather than by a background job
+	if err := s.repo.DeleteExpiredIdempotencyKeys(ctx, userID); err != nil {
+		log.Warn().Err(err).Str("user_id", userID.String()).Msg("failed to delete expired idempotency keys")
+	}
+
+	now := time.Now()
+	claimed, err := s.repo.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+		UserID:      userID,
+		Key:         key,
+		Route:       route,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(s.ttl),
+		LeaseCutoff: now.Add(-lease),
+	})
+	if err == nil {
+		return &domain.IdempotencyClaim{
+			UserID:    userID,
+			Key:       key,
+			Route:     route,
+			ClaimedAt: claimed.CreatedAt,
+		}, nil, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	existing, err := s.repo.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		UserID: userID,
+		Key:    key,
+		Route:  route,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Released between claim and lookup; the client can retry
+			return nil, nil, domain.NewConflictError("idempotency key", "original request is still in progress")
+		}
+		return nil, nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, nil, domain.NewUnprocessableError("idempotency key", "key was already used for a different request")
+	}
+	if existing.StatusCode == nil {
+		return nil, nil, domain.NewConflictError("idempotency key", "original request is still in progress")
+	}
+
+	return nil, &domain.IdempotentResponse{
+		StatusCode: int(*existing.StatusCode),
+		Body:       existing.ResponseBody,
+	}, nil
+}
+
+// Complete stores the response for a claim so later retries replay it. It does
+// nothing if the claim has since been taken over.
+func (s *IdempotencyService) Complete(ctx context.Context, claim *domain.IdempotencyClaim, resp *domain.IdempotentResponse) error {
+	//nolint:gosec // HTTP status codes fit in int32
+	statusCode := int32(resp.StatusCode)
+	if err := s.repo.CompleteIdempotencyKey(ctx, db.CompleteIdempotencyKeyParams{
+		UserID:       claim.UserID,
+		Key:          claim.Key,
+		Route:        claim.Route,
+		StatusCode:   &statusCode,
+		ResponseBody: resp.Body,
+		ClaimedAt:    claim.ClaimedAt,
+	}); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release drops a claim without storing a response, so the request can be
+// retried from scratch (used when the original request failed server-side).
+// It does nothing if the claim has since been taken over.
+func (s *IdempotencyService) Release(ctx context.Context, claim *domain.IdempotencyClaim) error {
+	if err := s.repo.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{
+		UserID:    claim.UserID,
+		Key:       claim.Key,
+		Route:     claim.Route,
+		ClaimedAt: claim.ClaimedAt,
+	}); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
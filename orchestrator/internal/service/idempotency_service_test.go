@@ -0,0 +1,188 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// idempotencyDB is a minimal DBTX holding a single idempotency_keys row. It
+// emulates the ON CONFLICT clause of ClaimIdempotencyKey: a held key only
+// returns a row when it is still in flight for the same request and was
+// claimed before the lease cutoff. Complete and Delete only apply to the claim
+// whose created_at they carry.
+type idempotencyDB struct {
+	row *db.IdempotencyKey
+}
+
+type idempotencyRow struct {
+	key db.IdempotencyKey
+	err error
+}
+
+func (r idempotencyRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*uuid.UUID) = r.key.UserID
+	*dest[1].(*string) = r.key.Key
+	*dest[2].(*string) = r.key.Route
+	*dest[3].(*string) = r.key.RequestHash
+	*dest[4].(**int32) = r.key.StatusCode
+	*dest[5].(*[]byte) = r.key.ResponseBody
+	*dest[6].(*time.Time) = r.key.CreatedAt
+	*dest[7].(*time.Time) = r.key.ExpiresAt
+	return nil
+}
+
+func (d *idempotencyDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	switch {
+	case strings.Contains(sql, "name: ClaimIdempotencyKey"):
+		if d.row != nil && (d.row.StatusCode != nil || d.row.RequestHash != args[3].(string) || !d.row.CreatedAt.Before(args[5].(time.Time))) {
+			return idempotencyRow{err: pgx.ErrNoRows}
+		}
+		d.row = &db.IdempotencyKey{
+			UserID:      args[0].(uuid.UUID),
+			Key:         args[1].(string),
+			Route:       args[2].(string),
+			RequestHash: args[3].(string),
+			CreatedAt:   time.Now(),
+			ExpiresAt:   args[4].(time.Time),
+		}
+		return idempotencyRow{key: *d.row}
+	case strings.Contains(sql, "name: GetIdempotencyKey"):
+		if d.row == nil {
+			return idempotencyRow{err: pgx.ErrNoRows}
+		}
+		return idempotencyRow{key: *d.row}
+	}
+	return idempotencyRow{err: errors.New("unexpected query")}
+}
+
+func (d *idempotencyDB) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	switch {
+	case strings.Contains(sql, "name: DeleteExpiredIdempotencyKeys"):
+	case strings.Contains(sql, "name: CompleteIdempotencyKey"):
+		if d.row != nil && d.row.CreatedAt.Equal(args[5].(time.Time)) {
+			d.row.StatusCode = args[3].(*int32)
+			d.row.ResponseBody = args[4].([]byte)
+		}
+	case strings.Contains(sql, "name: DeleteIdempotencyKey"):
+		if d.row != nil && d.row.CreatedAt.Equal(args[3].(time.Time)) {
+			d.row = nil
+		}
+	default:
+		return pgconn.CommandTag{}, errors.New("unexpected exec")
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+func (d *idempotencyDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (d *idempotencyDB) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	return nil, errors.New("unexpected transaction")
+}
+
+func TestIdempotencyService_Begin_PendingClaim(t *testing.T) {
+	userID := uuid.New()
+	lease := time.Minute
+
+	tests := []struct {
+		name        string
+		claimedAt   time.Time
+		requestHash string
+		wantErr     func(error) bool
+	}{
+		{"in-flight claim within its lease conflicts", time.Now().Add(-lease / 2), "hash", domain.IsConflict},
+		{"stale claim past its lease is taken over", time.Now().Add(-2 * lease), "hash", nil},
+		{"stale claim for a different request is kept", time.Now().Add(-2 * lease), "other", domain.IsUnprocessable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &idempotencyDB{row: &db.IdempotencyKey{
+				UserID:      userID,
+				Key:         "key-1",
+				Route:       "POST /api/projects",
+				RequestHash: "hash",
+				CreatedAt:   tt.claimedAt,
+				ExpiresAt:   time.Now().Add(24 * time.Hour),
+			}}
+			s := NewIdempotencyService(repository.New(fake), 24*time.Hour)
+
+			claim, stored, err := s.Begin(context.Background(), userID, "key-1", "POST /api/projects", tt.requestHash, lease)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("Begin() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Begin() error = %v", err)
+			}
+			if claim == nil || stored != nil {
+				t.Errorf("Begin() = %+v, %+v, want a claim so the request executes", claim, stored)
+			}
+			if !fake.row.CreatedAt.After(tt.claimedAt) {
+				t.Error("expected the stale claim to be renewed")
+			}
+		})
+	}
+}
+
+func TestIdempotencyService_TakenOverClaim(t *testing.T) {
+	userID := uuid.New()
+	route := "POST /api/projects"
+	fake := &idempotencyDB{}
+	s := NewIdempotencyService(repository.New(fake), 24*time.Hour)
+	ctx := context.Background()
+
+	original, _, err := s.Begin(ctx, userID, "key-1", route, "hash", time.Minute)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	// The original request outlives its lease and a retry takes the key over
+	fake.row.CreatedAt = fake.row.CreatedAt.Add(-2 * time.Minute)
+	original.ClaimedAt = fake.row.CreatedAt
+	retry, _, err := s.Begin(ctx, userID, "key-1", route, "hash", time.Minute)
+	if err != nil || retry == nil {
+		t.Fatalf("Begin() on retry = %+v, %v, want a claim", retry, err)
+	}
+
+	// The original request can neither release nor complete the retry's claim
+	if err := s.Release(ctx, original); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if fake.row == nil {
+		t.Fatal("the original request released the retry's claim")
+	}
+	if err := s.Complete(ctx, original, &domain.IdempotentResponse{StatusCode: 500}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if fake.row.StatusCode != nil {
+		t.Fatalf("the original request completed the retry's claim with %d", *fake.row.StatusCode)
+	}
+
+	if err := s.Complete(ctx, retry, &domain.IdempotentResponse{StatusCode: 201, Body: []byte("{}")}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	_, stored, err := s.Begin(ctx, userID, "key-1", route, "hash", time.Minute)
+	if err != nil || stored == nil || stored.StatusCode != 201 {
+		t.Errorf("Begin() after completion = %+v, %v, want the retry's response", stored, err)
+	}
+}
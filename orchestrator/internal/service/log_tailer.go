@@ -6,6 +6,8 @@ package service
 import (
 	"bufio"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"io"
 	"log/slog"
 	"os"
@@ -21,7 +23,9 @@ import (
 type LogTailer interface {
 	// StartTailing begins tailing a log file and publishing lines to the EventHub.
 	// It blocks until the context is cancelled or the log file indicates completion.
-	StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath string) error
+	// token is the run's completion token (see RunCompleteLine); when empty,
+	// only the legacy RunCompleteSentinel ends the tail.
+	StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath, token string) error
 
 	// StopTailing stops tailing a specific run's log file.
 	StopTailing(runID uuid.UUID)
@@ -37,6 +41,9 @@ type logTailer struct {
 	activeTails  map[uuid.UUID]context.CancelFunc
 	pollInterval time.Duration
 	maxLineBytes int
+	batchWindow  time.Duration
+	batchLines   int
+	maxLineRate  int
 	logger       *slog.Logger
 }
 
@@ -44,13 +51,24 @@ type logTailer struct {
 type LogTailerConfig struct {
 	PollInterval time.Duration
 	MaxLineBytes int
+	// BatchWindow enables log coalescing: lines are published as
+	// agent:log_batch events, each held for at most BatchWindow and carrying
+	// at most BatchMaxLines lines. 0 publishes every line as its own agent:log.
+	BatchWindow   time.Duration
+	BatchMaxLines int
+	// MaxLinesPerSecond caps the lines of one run published per second, so a
+	// flood of output cannot fill the connections' buffers and crowd out
+	// other events. Lines over the cap are skipped and reported by an
+	// agent:log_throttled event; they stay in the log file. 0 disables it.
+	MaxLinesPerSecond int
 }
 
 // DefaultLogTailerConfig returns the default configuration for the log tailer.
 func DefaultLogTailerConfig() LogTailerConfig {
 	return LogTailerConfig{
-		PollInterval: 100 * time.Millisecond,
-		MaxLineBytes: 1048576, // 1MB
+		PollInterval:  100 * time.Millisecond,
+		MaxLineBytes:  1048576, // 1MB
+		BatchMaxLines: 100,
 	}
 }
 
@@ -62,6 +80,9 @@ func NewLogTailer(eventHub EventHub, cfg LogTailerConfig, logger *slog.Logger) L
 	if cfg.MaxLineBytes <= 0 {
 		cfg.MaxLineBytes = 1048576
 	}
+	if cfg.BatchMaxLines <= 0 {
+		cfg.BatchMaxLines = 100
+	}
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -71,6 +92,9 @@ func NewLogTailer(eventHub EventHub, cfg LogTailerConfig, logger *slog.Logger) L
 		activeTails:  make(map[uuid.UUID]context.CancelFunc),
 		pollInterval: cfg.PollInterval,
 		maxLineBytes: cfg.MaxLineBytes,
+		batchWindow:  cfg.BatchWindow,
+		batchLines:   cfg.BatchMaxLines,
+		maxLineRate:  max(cfg.MaxLinesPerSecond, 0),
 		logger:       logger,
 	}
 }
@@ -78,8 +102,73 @@ func NewLogTailer(eventHub EventHub, cfg LogTailerConfig, logger *slog.Logger) L
 // timestampRegex matches log line timestamps like [14:32:05]
 var timestampRegex = regexp.MustCompile(`^\[(\d{2}:\d{2}:\d{2})\]`)
 
+// RunCompleteSentinel is the completion line executors wrote before runs had a
+// completion token. It is still accepted for logs without a token.
+const RunCompleteSentinel = "=== Run Complete ==="
+
+// CompletionTokenHeader prefixes the log header line carrying the run's
+// completion token, so readers that only have the log can find it.
+const CompletionTokenHeader = "Completion Token: "
+
+// NewCompletionToken returns a random token for one run's completion line.
+func NewCompletionToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// RunCompleteLine returns the line the executor appends when a run with the
+// given completion token finishes.
+func RunCompleteLine(token string) string {
+	return "=== Run Complete [" + token + "] ==="
+}
+
+// IsRunComplete reports whether line marks the end of a run. With a token only
+// the exact completion line matches, so agent output that echoes a marker does
+// not; without one the legacy sentinel is matched anywhere in the line.
+func IsRunComplete(line, token string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	if token == "" {
+		return strings.Contains(line, RunCompleteSentinel)
+	}
+	return line == RunCompleteLine(token)
+}
+
+// ReadCompletionToken returns the completion token from the header of the log
+// at logPath, or "" if the log has none (it predates tokens or is unreadable).
+// Only the header, which ends at the first blank line, is searched.
+func ReadCompletionToken(logPath string) string {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if token, ok := strings.CutPrefix(line, CompletionTokenHeader); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// ParseLogTimestamp returns the HH:MM:SS timestamp prefix of a log line, or "" if none.
+func ParseLogTimestamp(line string) string {
+	if matches := timestampRegex.FindStringSubmatch(line); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
 // StartTailing begins tailing a log file and publishing lines to the EventHub.
-func (t *logTailer) StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath string) error {
+func (t *logTailer) StartTailing(ctx context.Context, projectID, runID uuid.UUID, logPath, token string) error {
 	// Check if already tailing this run
 	t.mu.Lock()
 	if _, exists := t.activeTails[runID]; exists {
@@ -131,6 +220,17 @@ func (t *logTailer) StartTailing(ctx context.Context, projectID, runID uuid.UUID
 	reader := bufio.NewReader(file)
 	lineNumber := 0
 
+	var batch *logBatch
+	if t.batchWindow > 0 {
+		batch = &logBatch{}
+		defer t.flushBatch(projectID, runID, batch)
+	}
+	var rate *lineRate
+	if t.maxLineRate > 0 {
+		rate = &lineRate{limit: t.maxLineRate}
+		defer t.flushThrottled(projectID, runID, batch, rate)
+	}
+
 	for {
 		select {
 		case <-tailCtx.Done():
@@ -143,17 +243,32 @@ func (t *logTailer) StartTailing(ctx context.Context, projectID, runID uuid.UUID
 		if err != nil {
 			if err == io.EOF {
 				// Check if the run is complete
-				if strings.Contains(line, "=== Run Complete ===") {
-					t.publishLine(projectID, runID, line, lineNumber+1)
+				if IsRunComplete(line, token) {
+					t.publishLine(projectID, runID, batch, rate, line, lineNumber+1, true)
 					t.logger.Debug("log tail complete (sentinel found)", "run_id", runID)
 					return nil
 				}
 
-				// No new data, wait and try again
+				// The output has paused, so report any lines skipped so far
+				if rate != nil {
+					t.flushThrottled(projectID, runID, batch, rate)
+				}
+
+				// No new data: send what is batched once its window is up,
+				// then wait and try again
+				wait := t.pollInterval
+				if batch != nil && len(batch.lines) > 0 {
+					remaining := t.batchWindow - time.Since(batch.started)
+					if remaining <= 0 {
+						t.flushBatch(projectID, runID, batch)
+					} else {
+						wait = min(wait, remaining)
+					}
+				}
 				select {
 				case <-tailCtx.Done():
 					return tailCtx.Err()
-				case <-time.After(t.pollInterval):
+				case <-time.After(wait):
 				}
 				continue
 			}
@@ -170,24 +285,106 @@ func (t *logTailer) StartTailing(ctx context.Context, projectID, runID uuid.UUID
 			line = line[:t.maxLineBytes] + "... (truncated)"
 		}
 
-		t.publishLine(projectID, runID, line, lineNumber)
-
 		// Check for completion sentinel
-		if strings.Contains(line, "=== Run Complete ===") {
+		complete := IsRunComplete(line, token)
+		t.publishLine(projectID, runID, batch, rate, line, lineNumber, complete)
+		if complete {
 			t.logger.Debug("log tail complete (sentinel found)", "run_id", runID)
 			return nil
 		}
 	}
 }
 
-// publishLine publishes a single log line to the EventHub.
-func (t *logTailer) publishLine(projectID, runID uuid.UUID, line string, lineNumber int) {
-	timestamp := ""
-	if matches := timestampRegex.FindStringSubmatch(line); len(matches) > 1 {
-		timestamp = matches[1]
+// logBatch holds the lines of a run waiting to be published together.
+type logBatch struct {
+	lines   []AgentLogLine
+	started time.Time // when the first line was added
+}
+
+// lineRate counts the lines of a run published in the current one-second
+// window, and the lines skipped since the last agent:log_throttled event.
+type lineRate struct {
+	limit    int
+	started  time.Time // when the current window began
+	sent     int       // lines published in the current window
+	skipped  int       // lines skipped since the last notice
+	fromLine int       // first skipped line number
+	toLine   int       // last skipped line number
+}
+
+// take reports whether the line may be published at now, or records it as
+// skipped if the current window has reached the limit.
+func (r *lineRate) take(now time.Time, lineNumber int) bool {
+	if now.Sub(r.started) >= time.Second {
+		r.started = now
+		r.sent = 0
+	}
+	if r.sent < r.limit {
+		r.sent++
+		return true
+	}
+	if r.skipped == 0 {
+		r.fromLine = lineNumber
+	}
+	r.skipped++
+	r.toLine = lineNumber
+	return false
+}
+
+// publishLine publishes a single log line to the EventHub, or adds it to batch
+// when coalescing. The batch is published once it is full or its window is up.
+// Lines over the rate limit are skipped, except the run's final line.
+func (t *logTailer) publishLine(projectID, runID uuid.UUID, batch *logBatch, rate *lineRate, line string, lineNumber int, final bool) {
+	if rate != nil {
+		if !final && !rate.take(time.Now(), lineNumber) {
+			return
+		}
+		// Report skipped lines before the next line that is sent
+		t.flushThrottled(projectID, runID, batch, rate)
 	}
 
-	t.eventHub.PublishAgentLog(projectID, runID, line, lineNumber, timestamp)
+	if batch == nil {
+		t.eventHub.PublishAgentLog(projectID, runID, line, lineNumber, ParseLogTimestamp(line))
+		return
+	}
+
+	if len(batch.lines) == 0 {
+		batch.started = time.Now()
+	}
+	batch.lines = append(batch.lines, AgentLogLine{
+		Line:       line,
+		LineNumber: lineNumber,
+		Timestamp:  ParseLogTimestamp(line),
+	})
+	if len(batch.lines) >= t.batchLines || time.Since(batch.started) >= t.batchWindow {
+		t.flushBatch(projectID, runID, batch)
+	}
+}
+
+// flushBatch publishes the lines in batch, if any, as one agent:log_batch event.
+func (t *logTailer) flushBatch(projectID, runID uuid.UUID, batch *logBatch) {
+	if len(batch.lines) == 0 {
+		return
+	}
+	t.eventHub.PublishAgentLogBatch(projectID, runID, batch.lines)
+	batch.lines = nil
+}
+
+// flushThrottled publishes an agent:log_throttled event for the lines rate
+// has skipped, if any, after the batched lines that came before them.
+func (t *logTailer) flushThrottled(projectID, runID uuid.UUID, batch *logBatch, rate *lineRate) {
+	if rate.skipped == 0 {
+		return
+	}
+	if batch != nil {
+		t.flushBatch(projectID, runID, batch)
+	}
+	t.eventHub.PublishAgentLogThrottled(projectID, runID, rate.skipped, rate.fromLine, rate.toLine)
+	t.logger.Debug("throttled agent log",
+		"run_id", runID,
+		"skipped_lines", rate.skipped,
+	)
+	rate.skipped = 0
 }
 
 // StopTailing stops tailing a specific run's log file.
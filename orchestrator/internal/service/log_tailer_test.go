@@ -5,9 +5,11 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,9 +20,16 @@ import (
 	"github.com/intern-village/orchestrator/internal/domain"
 )
 
-// mockEventHub is a test implementation of EventHub for log tailer tests.
+// mockEventHub is a test implementation of EventHub that records published logs,
+// log batches, throttle notices, agent failures, completed tasks, and new
+// subtask statuses.
 type mockEventHub struct {
-	logs []AgentLogData
+	logs            []AgentLogData
+	batches         []AgentLogBatchData
+	throttles       []AgentLogThrottledData
+	failures        []AgentFailedData
+	completions     []TaskCompletionSummary
+	subtaskStatuses []string
 }
 
 func (m *mockEventHub) Subscribe(projectID, userID uuid.UUID, logSubscriptions []uuid.UUID) (string, <-chan Event, func()) {
@@ -30,6 +39,8 @@ func (m *mockEventHub) Subscribe(projectID, userID uuid.UUID, logSubscriptions [
 func (m *mockEventHub) UpdateLogSubscriptions(connID string, runIDs []uuid.UUID) {}
 func (m *mockEventHub) ConnectionCount(projectID uuid.UUID) int                  { return 0 }
 func (m *mockEventHub) UserConnectionCount(userID uuid.UUID) int                 { return 0 }
+func (m *mockEventHub) Drain()                                                   {}
+func (m *mockEventHub) Draining() <-chan struct{}                                { return nil }
 
 func (m *mockEventHub) PublishAgentStarted(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID) {
 }
@@ -41,17 +52,57 @@ func (m *mockEventHub) PublishAgentLog(projectID, runID uuid.UUID, line string,
 		Timestamp:  timestamp,
 	})
 }
+func (m *mockEventHub) PublishAgentLogBatch(projectID, runID uuid.UUID, lines []AgentLogLine) {
+	m.batches = append(m.batches, AgentLogBatchData{RunID: runID, Lines: lines})
+}
+func (m *mockEventHub) PublishAgentLogThrottled(projectID, runID uuid.UUID, skipped, fromLine, toLine int) {
+	m.throttles = append(m.throttles, AgentLogThrottledData{RunID: runID, SkippedLines: skipped, FromLine: fromLine, ToLine: toLine})
+}
 func (m *mockEventHub) PublishAgentCompleted(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, prURL string) {
 }
 func (m *mockEventHub) PublishAgentFailed(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, errMsg string, willRetry bool, nextAttemptAt *time.Time) {
+	var subtaskID *string
+	if run.SubtaskID != nil {
+		s := run.SubtaskID.String()
+		subtaskID = &s
+	}
+	m.failures = append(m.failures, AgentFailedData{
+		RunID:     run.ID,
+		AgentType: string(run.AgentType),
+		TaskID:    taskID,
+		SubtaskID: subtaskID,
+		Error:     errMsg,
+		WillRetry: willRetry,
+	})
+}
+func (m *mockEventHub) PublishAgentStalled(projectID uuid.UUID, run *domain.AgentRun, taskID uuid.UUID, lastOutputAt time.Time, cancelled bool) {
 }
 func (m *mockEventHub) PublishTaskStatusChanged(projectID, taskID uuid.UUID, oldStatus, newStatus string) {
 }
+func (m *mockEventHub) PublishTaskCompleted(projectID uuid.UUID, task *domain.Task, summary TaskCompletionSummary) {
+	m.completions = append(m.completions, summary)
+}
 func (m *mockEventHub) PublishSubtaskStatusChanged(projectID uuid.UUID, subtask *domain.Subtask, oldStatus string) {
+	m.subtaskStatuses = append(m.subtaskStatuses, string(subtask.Status))
+}
+func (m *mockEventHub) PublishSubtaskCreated(projectID uuid.UUID, subtask *domain.Subtask) {
 }
+
 func (m *mockEventHub) PublishSubtaskUnblocked(projectID, taskID, subtaskID, unblockedByID uuid.UUID) {
 }
 
+func (m *mockEventHub) PublishOperationFailed(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string) {
+}
+
+func (m *mockEventHub) PublishReauthRequired(projectID, taskID uuid.UUID, subtaskID *uuid.UUID, operation, errMsg string) {
+}
+
+func (m *mockEventHub) AddSink(sink EventSink)                    {}
+func (m *mockEventHub) SetReconnectPolicy(policy ReconnectPolicy) {}
+func (m *mockEventHub) RecordConnect(userID uuid.UUID) ReconnectDecision {
+	return ReconnectDecision{}
+}
+
 func TestLogTailer_TailsNewLines(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	mockHub := &mockEventHub{logs: make([]AgentLogData, 0)}
@@ -85,7 +136,7 @@ func TestLogTailer_TailsNewLines(t *testing.T) {
 	done := make(chan bool)
 
 	go func() {
-		_ = tailer.StartTailing(ctx, projectID, runID, logPath)
+		_ = tailer.StartTailing(ctx, projectID, runID, logPath, "")
 		done <- true
 	}()
 
@@ -117,10 +168,11 @@ func TestLogTailer_WaitsForFile(t *testing.T) {
 	logPath := filepath.Join(tmpDir, "delayed.log")
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	done := make(chan bool)
 
 	go func() {
-		_ = tailer.StartTailing(ctx, projectID, runID, logPath)
+		_ = tailer.StartTailing(ctx, projectID, runID, logPath, "")
 		done <- true
 	}()
 
@@ -169,7 +221,7 @@ func TestLogTailer_StopsOnContextCancel(t *testing.T) {
 	done := make(chan error)
 
 	go func() {
-		err := tailer.StartTailing(ctx, projectID, runID, logPath)
+		err := tailer.StartTailing(ctx, projectID, runID, logPath, "")
 		done <- err
 	}()
 
@@ -202,13 +254,76 @@ func TestLogTailer_StopsOnSentinel(t *testing.T) {
 	f.Close()
 
 	ctx := context.Background()
-	err = tailer.StartTailing(ctx, projectID, runID, logPath)
+	err = tailer.StartTailing(ctx, projectID, runID, logPath, "")
 
 	// Should return nil (completed successfully, not cancelled)
 	assert.NoError(t, err)
 	assert.GreaterOrEqual(t, len(mockHub.logs), 2)
 }
 
+func TestLogTailer_IgnoresEchoedSentinel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockHub := &mockEventHub{logs: make([]AgentLogData, 0)}
+
+	tailer := NewLogTailer(mockHub, LogTailerConfig{
+		PollInterval: 10 * time.Millisecond,
+	}, logger)
+
+	token := NewCompletionToken()
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	content := "=== Agent Run 1 ===\n" + CompletionTokenHeader + token + "\n\n" +
+		"[14:32:05] grep -n \"=== Run Complete ===\" executor.go\n" +
+		RunCompleteSentinel + "\n" +
+		RunCompleteLine("not-the-token") + "\n" +
+		"[14:32:06] Done\n" +
+		RunCompleteLine(token) + "\n" +
+		"Exit Code: 0\n"
+	require.NoError(t, os.WriteFile(logPath, []byte(content), 0644))
+	assert.Equal(t, token, ReadCompletionToken(logPath))
+
+	err := tailer.StartTailing(context.Background(), uuid.New(), uuid.New(), logPath, token)
+	require.NoError(t, err)
+
+	// Echoed markers are published as ordinary lines; only the run's own
+	// completion line ends the tail
+	require.Len(t, mockHub.logs, 8)
+	assert.Equal(t, RunCompleteSentinel, mockHub.logs[4].Line)
+	assert.Equal(t, RunCompleteLine(token), mockHub.logs[7].Line)
+}
+
+func TestIsRunComplete(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		token string
+		want  bool
+	}{
+		{name: "legacy sentinel without token", line: RunCompleteSentinel, want: true},
+		{name: "legacy sentinel with CRLF", line: RunCompleteSentinel + "\r\n", want: true},
+		{name: "completion line", line: RunCompleteLine("abc"), token: "abc", want: true},
+		{name: "legacy sentinel with token", line: RunCompleteSentinel, token: "abc", want: false},
+		{name: "other token", line: RunCompleteLine("xyz"), token: "abc", want: false},
+		{name: "echoed completion line", line: "[14:32:05] " + RunCompleteLine("abc"), token: "abc", want: false},
+		{name: "ordinary line", line: "[14:32:05] Working...", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRunComplete(tt.line, tt.token))
+		})
+	}
+}
+
+func TestReadCompletionToken_NoHeader(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	// A token line after the header is agent output, not the run's token
+	content := "=== Agent Run 1 ===\nPrompt: p.md\n\n" + CompletionTokenHeader + "abc\n"
+	require.NoError(t, os.WriteFile(logPath, []byte(content), 0644))
+
+	assert.Empty(t, ReadCompletionToken(logPath))
+	assert.Empty(t, ReadCompletionToken(filepath.Join(t.TempDir(), "missing.log")))
+}
+
 func TestLogTailer_TruncatesLongLines(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	mockHub := &mockEventHub{logs: make([]AgentLogData, 0)}
@@ -231,7 +346,7 @@ func TestLogTailer_TruncatesLongLines(t *testing.T) {
 	f.WriteString("=== Run Complete ===\n")
 	f.Close()
 
-	err = tailer.StartTailing(context.Background(), projectID, runID, logPath)
+	err = tailer.StartTailing(context.Background(), projectID, runID, logPath, "")
 	assert.NoError(t, err)
 
 	assert.GreaterOrEqual(t, len(mockHub.logs), 1)
@@ -262,7 +377,7 @@ func TestLogTailer_DuplicateStartTailing(t *testing.T) {
 
 	done1 := make(chan bool)
 	go func() {
-		_ = tailer.StartTailing(ctx, projectID, runID, logPath)
+		_ = tailer.StartTailing(ctx, projectID, runID, logPath, "")
 		done1 <- true
 	}()
 
@@ -271,7 +386,7 @@ func TestLogTailer_DuplicateStartTailing(t *testing.T) {
 	// Second call should return immediately (already tailing)
 	done2 := make(chan bool)
 	go func() {
-		_ = tailer.StartTailing(ctx, projectID, runID, logPath)
+		_ = tailer.StartTailing(ctx, projectID, runID, logPath, "")
 		done2 <- true
 	}()
 
@@ -308,7 +423,7 @@ func TestLogTailer_StopTailing(t *testing.T) {
 	done := make(chan bool)
 
 	go func() {
-		_ = tailer.StartTailing(ctx, projectID, runID, logPath)
+		_ = tailer.StartTailing(ctx, projectID, runID, logPath, "")
 		done <- true
 	}()
 
@@ -327,6 +442,138 @@ func TestLogTailer_StopTailing(t *testing.T) {
 	assert.False(t, tailer.IsActive(runID))
 }
 
+func TestLogTailer_BatchesLines(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockHub := &mockEventHub{}
+
+	tailer := NewLogTailer(mockHub, LogTailerConfig{
+		PollInterval:  10 * time.Millisecond,
+		MaxLineBytes:  1024,
+		BatchWindow:   time.Hour,
+		BatchMaxLines: 3,
+	}, logger)
+
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	content := "[14:32:05] 1\n2\n3\n4\n5\n6\n=== Run Complete ===\n"
+	require.NoError(t, os.WriteFile(logPath, []byte(content), 0644))
+
+	err := tailer.StartTailing(context.Background(), uuid.New(), uuid.New(), logPath, "")
+	require.NoError(t, err)
+
+	// Full batches go out at once; the rest when the run completes
+	assert.Empty(t, mockHub.logs, "no agent:log events when coalescing")
+	require.Len(t, mockHub.batches, 3)
+	assert.Len(t, mockHub.batches[0].Lines, 3)
+	assert.Len(t, mockHub.batches[1].Lines, 3)
+	assert.Equal(t, "14:32:05", mockHub.batches[0].Lines[0].Timestamp)
+	last := mockHub.batches[2].Lines
+	require.Len(t, last, 1)
+	assert.Equal(t, RunCompleteSentinel, last[0].Line)
+	assert.Equal(t, 7, last[0].LineNumber)
+}
+
+func TestLogTailer_FlushesBatchAfterWindow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockHub := &mockEventHub{}
+
+	tailer := NewLogTailer(mockHub, LogTailerConfig{
+		PollInterval:  10 * time.Millisecond,
+		MaxLineBytes:  1024,
+		BatchWindow:   20 * time.Millisecond,
+		BatchMaxLines: 100,
+	}, logger)
+
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(logPath, []byte("one\ntwo\n"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = tailer.StartTailing(ctx, uuid.New(), uuid.New(), logPath, "")
+		close(done)
+	}()
+
+	// Lines written after the first window has passed form a second batch
+	time.Sleep(100 * time.Millisecond)
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("three\n")
+	require.NoError(t, err)
+	f.Close()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	require.Len(t, mockHub.batches, 2)
+	assert.Len(t, mockHub.batches[0].Lines, 2)
+	assert.Equal(t, "three", mockHub.batches[1].Lines[0].Line)
+	assert.Equal(t, 3, mockHub.batches[1].Lines[0].LineNumber)
+}
+
+func TestLogTailer_ThrottlesFlood(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockHub := &mockEventHub{}
+
+	const limit, flood = 100, 5000
+	tailer := NewLogTailer(mockHub, LogTailerConfig{
+		PollInterval:      10 * time.Millisecond,
+		MaxLineBytes:      1024,
+		MaxLinesPerSecond: limit,
+	}, logger)
+
+	var content strings.Builder
+	for i := 1; i <= flood; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	content.WriteString(RunCompleteSentinel + "\n")
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, os.WriteFile(logPath, []byte(content.String()), 0644))
+
+	start := time.Now()
+	err := tailer.StartTailing(context.Background(), uuid.New(), uuid.New(), logPath, "")
+	require.NoError(t, err)
+	elapsed := time.Since(start)
+
+	// At most limit lines per second started, plus the completion line
+	windows := int(elapsed/time.Second) + 1
+	assert.LessOrEqual(t, len(mockHub.logs), windows*limit+1)
+	assert.LessOrEqual(t, len(mockHub.throttles), windows)
+
+	// Every line is either published or reported as skipped
+	skipped := 0
+	for _, n := range mockHub.throttles {
+		skipped += n.SkippedLines
+		assert.LessOrEqual(t, n.FromLine, n.ToLine)
+	}
+	assert.Equal(t, flood+1, len(mockHub.logs)+skipped)
+
+	assert.Equal(t, "line 1", mockHub.logs[0].Line)
+	final := mockHub.logs[len(mockHub.logs)-1]
+	assert.Equal(t, RunCompleteSentinel, final.Line, "the completion line is never skipped")
+	assert.Equal(t, flood+1, final.LineNumber)
+}
+
+func TestLineRate_Take(t *testing.T) {
+	start := time.Now()
+	rate := &lineRate{limit: 2}
+
+	assert.True(t, rate.take(start, 1))
+	assert.True(t, rate.take(start, 2))
+	assert.False(t, rate.take(start.Add(500*time.Millisecond), 3))
+	assert.False(t, rate.take(start.Add(900*time.Millisecond), 4))
+	assert.Equal(t, 2, rate.skipped)
+	assert.Equal(t, 3, rate.fromLine)
+	assert.Equal(t, 4, rate.toLine)
+
+	// A new window allows lines again
+	assert.True(t, rate.take(start.Add(time.Second), 5))
+	assert.True(t, rate.take(start.Add(time.Second), 6))
+	assert.False(t, rate.take(start.Add(time.Second), 7))
+	assert.Equal(t, 3, rate.skipped, "skipped lines count until a notice is sent")
+	assert.Equal(t, 7, rate.toLine)
+}
+
 func TestLogTailer_DefaultConfig(t *testing.T) {
 	cfg := DefaultLogTailerConfig()
 	assert.Equal(t, 100*time.Millisecond, cfg.PollInterval)
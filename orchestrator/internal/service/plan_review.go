@@ -0,0 +1,128 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// PlanSyncer materializes a task's beads plan as subtasks.
+// It is implemented by SyncService.
+type PlanSyncer interface {
+	SyncTaskFromBeads(ctx context.Context, taskID uuid.UUID, repoPath string) error
+}
+
+// SetPlanSyncer sets the syncer used to confirm dry-run plans.
+// This is set after construction to break circular dependencies.
+func (s *TaskService) SetPlanSyncer(syncer PlanSyncer) {
+	s.planSyncer = syncer
+}
+
+// PlanPreview is the Planner's proposed breakdown for a dry-run task.
+type PlanPreview struct {
+	TaskID   uuid.UUID
+	EpicID   string
+	Subtasks []PlannedSubtask
+}
+
+// PlannedSubtask is a beads issue that will become a subtask once the plan is confirmed.
+type PlannedSubtask struct {
+	BeadsIssueID       string
+	Title              string
+	Spec               string
+	ImplementationPlan string
+	DependsOn          []string // Beads issue IDs
+}
+
+// GetPlanPreview returns the plan a dry-run Planner wrote to beads, without
+// creating any subtasks. The task must be in AWAITING_APPROVAL status.
+func (s *TaskService) GetPlanPreview(ctx context.Context, taskID, userID uuid.UUID) (*PlanPreview, error) {
+	task, project, err := s.getTaskAwaitingApproval(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &PlanPreview{
+		TaskID:   task.ID,
+		Subtasks: []PlannedSubtask{},
+	}
+	if task.BeadsEpicID == nil || *task.BeadsEpicID == "" {
+		// The Planner finished without creating an epic; there is nothing to confirm
+		return preview, nil
+	}
+	preview.EpicID = *task.BeadsEpicID
+
+	issues, err := s.beadsService.ListIssues(ctx, project.ClonePath, *task.BeadsEpicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list planned issues: %w", err)
+	}
+
+	for _, issue := range issues {
+		spec, plan := parseIssueBody(issue.Description)
+		dependsOn := issue.GetDependencyIDs()
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+		preview.Subtasks = append(preview.Subtasks, PlannedSubtask{
+			BeadsIssueID:       issue.ID,
+			Title:              issue.Title,
+			Spec:               spec,
+			ImplementationPlan: plan,
+			DependsOn:          dependsOn,
+		})
+	}
+
+	return preview, nil
+}
+
+// ConfirmPlan approves a dry-run plan: subtasks are created from beads and the
+// task transitions to ACTIVE.
+func (s *TaskService) ConfirmPlan(ctx context.Context, taskID, userID uuid.UUID) (*domain.Task, error) {
+	task, project, err := s.getTaskAwaitingApproval(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.BeadsEpicID != nil && *task.BeadsEpicID != "" {
+		if s.planSyncer == nil {
+			return nil, errors.New("plan syncer not configured")
+		}
+		if err := s.planSyncer.SyncTaskFromBeads(ctx, taskID, project.ClonePath); err != nil {
+			return nil, fmt.Errorf("failed to create subtasks from plan: %w", err)
+		}
+	}
+
+	if err := s.TransitionToActive(ctx, taskID); err != nil {
+		return nil, err
+	}
+	s.auditTask(ctx, userID, AuditActionTaskConfirmPlan, task, task.Status, domain.TaskStatusActive)
+
+	return s.GetTaskByIDInternal(ctx, taskID)
+}
+
+// getTaskAwaitingApproval loads a task with ownership check and its project,
+// and verifies the task has a plan awaiting review.
+func (s *TaskService) getTaskAwaitingApproval(ctx context.Context, taskID, userID uuid.UUID) (*domain.Task, *domain.Project, error) {
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if task.Status != domain.TaskStatusAwaitingApproval {
+		return nil, nil, domain.NewUnprocessableError("task", "plan review is only available for tasks in AWAITING_APPROVAL status")
+	}
+
+	project, err := s.projectService.GetProject(ctx, task.ProjectID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return task, project, nil
+}
@@ -7,14 +7,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
 
 	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/config"
 	"github.com/intern-village/orchestrator/internal/domain"
 	"github.com/intern-village/orchestrator/internal/repository"
 )
@@ -25,52 +31,171 @@ var (
 	ErrProjectNotFound      = errors.New("project not found")
 	ErrProjectAccessDenied  = errors.New("access denied to project")
 	ErrClonePathExists      = errors.New("clone path already exists")
+	ErrCreationCanceled     = errors.New("project creation canceled")
 )
 
 // ProjectService handles project management operations.
 type ProjectService struct {
-	repo          *repository.Repository
+	repo          repository.ProjectStore
 	crypto        *repository.Crypto
 	githubService *GitHubService
-	beadsService  *BeadsService
-	dataDir       string
+	beadsService  BeadsClient
+	paths         config.DataPaths
+	maxRepoSizeMB int // 0 disables the size check
+	quotas        *QuotaService
+	ownerPolicy   domain.RepoOwnerPolicy
+	ownerMember   bool     // owners must also be the user or one of their orgs
+	repairing     sync.Map // project IDs with a RepairClone in progress
+	creating      sync.Map // clone paths with a CreateProject in progress
+	inFlight      sync.Map // creationKey -> context.CancelCauseFunc of a CreateProject in progress
+}
+
+// creationKey identifies a user's in-progress creation of a project for a
+// repository, as named in the request.
+type creationKey struct {
+	userID uuid.UUID
+	repo   string // owner/repo, lowercased
+}
+
+func newCreationKey(userID uuid.UUID, owner, repo string) creationKey {
+	return creationKey{userID: userID, repo: strings.ToLower(owner + "/" + repo)}
 }
 
 // NewProjectService creates a new ProjectService.
 func NewProjectService(
-	repo *repository.Repository,
+	repo repository.ProjectStore,
 	crypto *repository.Crypto,
 	githubService *GitHubService,
-	beadsService *BeadsService,
-	dataDir string,
+	beadsService BeadsClient,
+	paths config.DataPaths,
 ) *ProjectService {
 	return &ProjectService{
 		repo:          repo,
 		crypto:        crypto,
 		githubService: githubService,
 		beadsService:  beadsService,
-		dataDir:       dataDir,
+		paths:         paths,
 	}
 }
 
+// SetMaxRepoSizeMB sets the largest repository, by GitHub's reported size,
+// that CreateProject will fork and clone. Zero disables the check.
+func (s *ProjectService) SetMaxRepoSizeMB(mb int) {
+	s.maxRepoSizeMB = mb
+}
+
+// SetQuotaService sets the per-user quotas CreateProject enforces.
+func (s *ProjectService) SetQuotaService(quotas *QuotaService) {
+	s.quotas = quotas
+}
+
+// SetRepoOwnerPolicy restricts the repository owners CreateProject accepts.
+// With requireMembership the owner must also be the user or an organization
+// they are an active member of, which is checked with the user's token.
+func (s *ProjectService) SetRepoOwnerPolicy(policy domain.RepoOwnerPolicy, requireMembership bool) {
+	s.ownerPolicy = policy
+	s.ownerMember = requireMembership
+}
+
+// WorktreeRoot returns the directory holding a project's subtask worktrees.
+// Worktrees created before this layout live inside the clone at
+// {clonePath}/{subtaskID}; their stored WorktreePath is used as-is.
+func (s *ProjectService) WorktreeRoot(projectID uuid.UUID) string {
+	return s.paths.Worktrees(projectID.String())
+}
+
+// WorktreePath returns where the worktree for a new subtask run is created.
+func (s *ProjectService) WorktreePath(projectID, subtaskID uuid.UUID) string {
+	return s.paths.Worktree(projectID.String(), subtaskID.String())
+}
+
+// AttachmentsDir returns the directory holding a task's attachments.
+func (s *ProjectService) AttachmentsDir(projectID, taskID uuid.UUID) string {
+	return s.paths.Attachments(projectID.String(), taskID.String())
+}
+
 // CreateProjectInput contains the input for creating a project.
 type CreateProjectInput struct {
 	UserID      uuid.UUID
 	RepoURL     string
 	GitHubToken string // Decrypted token
+	BeadsPrefix string // Optional; generated when empty
 }
 
 // CreateProject creates a new project by cloning a GitHub repository.
-// If the user doesn't have push access, the repo is forked first.
+// If the user doesn't have push access, the repo is forked first. It stops,
+// removing any partial clone, when ctx is done or CancelCreateProject is
+// called for the same repository.
 func (s *ProjectService) CreateProject(ctx context.Context, input CreateProjectInput) (*domain.Project, error) {
 	// Parse the repository URL
 	owner, repo, err := s.githubService.ParseRepoURL(input.RepoURL)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkRepoOwner(ctx, owner, input.GitHubToken); err != nil {
+		return nil, err
+	}
+
+	key := newCreationKey(input.UserID, owner, repo)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	if _, busy := s.inFlight.LoadOrStore(key, cancel); busy {
+		return nil, domain.NewConflictError("project", "creation already in progress for this repository")
+	}
+	defer s.inFlight.Delete(key)
+
+	project, err := s.createProject(ctx, input, owner, repo)
+	if err != nil && errors.Is(context.Cause(ctx), ErrCreationCanceled) {
+		return nil, domain.NewConflictError("project", "creation was canceled")
+	}
+	return project, err
+}
+
+// checkRepoOwner refuses, with a ForbiddenError, a repository owner the
+// deployment does not allow projects from (see SetRepoOwnerPolicy).
+func (s *ProjectService) checkRepoOwner(ctx context.Context, owner, accessToken string) error {
+	if err := s.ownerPolicy.Check(owner); err != nil {
+		return err
+	}
+	if !s.ownerMember {
+		return nil
+	}
+	member, err := s.githubService.IsOwnerOrMember(ctx, owner, accessToken)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return domain.NewForbiddenError("repository owner", fmt.Sprintf("you are not a member of %s", owner))
+	}
+	return nil
+}
+
+// CancelCreateProject aborts the user's in-progress CreateProject for the
+// repository at repoURL. The canceled creation kills its git process and
+// removes its partial clone; a fork it already created is kept.
+func (s *ProjectService) CancelCreateProject(userID uuid.UUID, repoURL string) error {
+	owner, repo, err := s.githubService.ParseRepoURL(repoURL)
+	if err != nil {
+		return err
+	}
+	cancel, ok := s.inFlight.Load(newCreationKey(userID, owner, repo))
+	if !ok {
+		return domain.NewNotFoundError("project creation", owner+"/"+repo)
+	}
+	cancel.(context.CancelCauseFunc)(ErrCreationCanceled)
+	return nil
+}
+
+// createProject does the work of CreateProject for the repository owner/repo.
+func (s *ProjectService) createProject(ctx context.Context, input CreateProjectInput, owner, repo string) (*domain.Project, error) {
+	if input.BeadsPrefix != "" {
+		if err := domain.ValidateBeadsPrefix(input.BeadsPrefix); err != nil {
+			return nil, err
+		}
+	}
 
 	// Check if project already exists for this user
-	_, err = s.repo.GetProjectByOwnerRepo(ctx, db.GetProjectByOwnerRepoParams{
+	_, err := s.repo.GetProjectByOwnerRepo(ctx, db.GetProjectByOwnerRepoParams{
 		UserID:      input.UserID,
 		GithubOwner: owner,
 		GithubRepo:  repo,
@@ -82,12 +207,23 @@ func (s *ProjectService) CreateProject(ctx context.Context, input CreateProjectI
 		return nil, fmt.Errorf("failed to check existing project: %w", err)
 	}
 
+	if s.quotas != nil {
+		if err := s.quotas.CheckProjects(ctx, input.UserID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get repository info and check access
 	repoInfo, err := s.githubService.GetRepoInfo(ctx, owner, repo, input.GitHubToken)
 	if err != nil {
 		return nil, err
 	}
 
+	// Reject oversized repositories before spending minutes forking and cloning
+	if err := checkRepoSize(repoInfo, s.maxRepoSizeMB); err != nil {
+		return nil, err
+	}
+
 	// Determine if we need to fork
 	isFork := false
 	actualOwner := owner
@@ -110,15 +246,29 @@ func (s *ProjectService) CreateProject(ctx context.Context, input CreateProjectI
 
 	// Generate paths
 	clonePath := s.generateClonePath(input.UserID, actualOwner, actualRepo)
-	beadsPrefix := s.generateBeadsPrefix()
+	beadsPrefix := input.BeadsPrefix
+	if beadsPrefix == "" {
+		beadsPrefix = s.generateBeadsPrefix()
+	}
+
+	// Only one creation may work on a clone path at a time, so a directory
+	// found there below is never another creation's clone in progress
+	if _, busy := s.creating.LoadOrStore(clonePath, struct{}{}); busy {
+		return nil, domain.NewConflictError("project", "creation already in progress for this repository")
+	}
+	defer s.creating.Delete(clonePath)
 
-	// Check if clone path already exists
-	if _, err := os.Stat(clonePath); err == nil {
-		return nil, domain.NewConflictError("project", "clone directory already exists")
+	if err := s.clearDanglingClone(ctx, clonePath); err != nil {
+		return nil, err
 	}
 
 	// Clone the repository
-	if err := s.githubService.CloneRepo(ctx, actualOwner, actualRepo, input.GitHubToken, clonePath); err != nil {
+	if isFork {
+		err = s.githubService.CloneFork(ctx, actualOwner, actualRepo, owner, repo, input.GitHubToken, clonePath)
+	} else {
+		err = s.githubService.CloneRepo(ctx, actualOwner, actualRepo, input.GitHubToken, clonePath)
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -131,12 +281,21 @@ func (s *ProjectService) CreateProject(ctx context.Context, input CreateProjectI
 		}
 	}
 
-	// Initialize beads in the cloned repo
-	if err := s.beadsService.Init(ctx, clonePath, beadsPrefix); err != nil {
+	// Initialize beads in the cloned repo, adopting the prefix of a store the repo already has
+	initPrefix, err := s.beadsService.Init(ctx, clonePath, beadsPrefix)
+	if err != nil {
 		// Cleanup the clone on failure
 		_ = os.RemoveAll(clonePath)
 		return nil, err
 	}
+	if initPrefix != beadsPrefix {
+		log.Info().
+			Str("repo", actualOwner+"/"+actualRepo).
+			Str("requested_prefix", beadsPrefix).
+			Str("prefix", initPrefix).
+			Msg("Repository already has a beads store, using its prefix")
+		beadsPrefix = initPrefix
+	}
 
 	// Create the project record
 	dbProject, err := s.repo.CreateProject(ctx, db.CreateProjectParams{
@@ -159,6 +318,31 @@ func (s *ProjectService) CreateProject(ctx context.Context, input CreateProjectI
 	return dbProjectToDomain(dbProject), nil
 }
 
+// clearDanglingClone makes way for a new clone at clonePath. A directory left
+// there by a creation that crashed before recording its project is removed;
+// one that belongs to a project record is never touched and is a conflict.
+func (s *ProjectService) clearDanglingClone(ctx context.Context, clonePath string) error {
+	if _, err := os.Stat(clonePath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to check clone directory: %w", err)
+	}
+
+	_, err := s.repo.GetProjectByClonePath(ctx, clonePath)
+	if err == nil {
+		return domain.NewConflictError("project", "clone directory belongs to an existing project")
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to check clone directory owner: %w", err)
+	}
+
+	log.Warn().Str("clone_path", clonePath).Msg("removing clone directory left by an interrupted project creation")
+	if err := os.RemoveAll(clonePath); err != nil {
+		return fmt.Errorf("failed to remove dangling clone directory: %w", err)
+	}
+	return nil
+}
+
 // GetProject retrieves a project by ID with ownership verification.
 func (s *ProjectService) GetProject(ctx context.Context, projectID, userID uuid.UUID) (*domain.Project, error) {
 	project, err := s.repo.GetProjectByID(ctx, projectID)
@@ -191,6 +375,146 @@ func (s *ProjectService) ListProjects(ctx context.Context, userID uuid.UUID) ([]
 	return result, nil
 }
 
+// ProjectSummary is a project's status at a glance: its tasks and subtasks
+// counted by status, its running agents, and the tokens it has used.
+type ProjectSummary struct {
+	TasksByStatus    map[domain.TaskStatus]int
+	SubtasksByStatus map[domain.SubtaskStatus]int
+	ActiveAgents     int
+	TokenUsage       int64
+}
+
+// GetProjectSummary computes a ProjectSummary with aggregate queries rather
+// than loading the project's rows. The project must already have been fetched
+// with GetProject, which checks ownership.
+func (s *ProjectService) GetProjectSummary(ctx context.Context, project *domain.Project) (*ProjectSummary, error) {
+	taskCounts, err := s.repo.CountTasksByStatusForProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	subtaskCounts, err := s.repo.CountSubtasksByStatusForProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count subtasks: %w", err)
+	}
+	activeAgents, err := s.repo.CountActiveAgentRunsByProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active agent runs: %w", err)
+	}
+	tokenUsage, err := s.repo.SumTokenUsageForProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum token usage: %w", err)
+	}
+
+	summary := &ProjectSummary{
+		TasksByStatus:    make(map[domain.TaskStatus]int, len(taskCounts)),
+		SubtasksByStatus: make(map[domain.SubtaskStatus]int, len(subtaskCounts)),
+		ActiveAgents:     int(activeAgents),
+		TokenUsage:       tokenUsage,
+	}
+	for _, row := range taskCounts {
+		summary.TasksByStatus[domain.TaskStatus(row.Status)] = int(row.Count)
+	}
+	for _, row := range subtaskCounts {
+		summary.SubtasksByStatus[domain.SubtaskStatus(row.Status)] = int(row.Count)
+	}
+	return summary, nil
+}
+
+// SetMaxSubtasksPerTask sets or, with nil, clears the project's override of
+// MAX_SUBTASKS_PER_TASK. Zero disables the limit for the project.
+func (s *ProjectService) SetMaxSubtasksPerTask(ctx context.Context, projectID, userID uuid.UUID, limit *int) (*domain.Project, error) {
+	if _, err := s.GetProject(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+
+	var value *int32
+	if limit != nil {
+		if *limit < 0 || *limit > math.MaxInt32 {
+			return nil, domain.NewValidationError("max_subtasks_per_task", "must be between 0 and 2147483647")
+		}
+		v := int32(*limit)
+		value = &v
+	}
+
+	project, err := s.repo.UpdateProjectMaxSubtasks(ctx, db.UpdateProjectMaxSubtasksParams{
+		ID:                 projectID,
+		MaxSubtasksPerTask: value,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return dbProjectToDomain(project), nil
+}
+
+// SetPRTitleTemplate sets or, with nil, clears the project's Worker PR title
+// template (see domain.RenderPRTitle).
+func (s *ProjectService) SetPRTitleTemplate(ctx context.Context, projectID, userID uuid.UUID, tmpl *string) (*domain.Project, error) {
+	if _, err := s.GetProject(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+
+	if tmpl != nil {
+		if err := domain.ValidatePRTitleTemplate(*tmpl); err != nil {
+			return nil, err
+		}
+	}
+
+	project, err := s.repo.UpdateProjectPRTitleTemplate(ctx, db.UpdateProjectPRTitleTemplateParams{
+		ID:              projectID,
+		PrTitleTemplate: tmpl,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return dbProjectToDomain(project), nil
+}
+
+// SetPathPolicy sets the paths the project's Workers may modify. An empty
+// policy allows every path.
+func (s *ProjectService) SetPathPolicy(ctx context.Context, projectID, userID uuid.UUID, policy domain.PathPolicy) (*domain.Project, error) {
+	if _, err := s.GetProject(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The columns are NOT NULL
+	allowed := append([]string{}, policy.Allowed...)
+	denied := append([]string{}, policy.Denied...)
+	project, err := s.repo.UpdateProjectPathPolicy(ctx, db.UpdateProjectPathPolicyParams{
+		ID:           projectID,
+		AllowedPaths: allowed,
+		DeniedPaths:  denied,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return dbProjectToDomain(project), nil
+}
+
+// SetSquashBeforePR sets whether a Worker's commits are squashed into one
+// before its PR is opened.
+func (s *ProjectService) SetSquashBeforePR(ctx context.Context, projectID, userID uuid.UUID, squash bool) (*domain.Project, error) {
+	if _, err := s.GetProject(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+
+	project, err := s.repo.UpdateProjectSquashBeforePR(ctx, db.UpdateProjectSquashBeforePRParams{
+		ID:             projectID,
+		SquashBeforePr: squash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return dbProjectToDomain(project), nil
+}
+
 // DeleteProject deletes a project and its clone.
 func (s *ProjectService) DeleteProject(ctx context.Context, projectID, userID uuid.UUID) error {
 	// Get project with ownership check
@@ -199,10 +523,11 @@ func (s *ProjectService) DeleteProject(ctx context.Context, projectID, userID uu
 		return err
 	}
 
-	// Delete the clone directory (ignore errors - directory might not exist)
+	// Delete the clone and worktree directories (ignore errors - directories might not exist)
 	if project.ClonePath != "" {
 		_ = os.RemoveAll(project.ClonePath)
 	}
+	_ = os.RemoveAll(s.WorktreeRoot(project.ID))
 
 	// Delete the project record
 	if err := s.repo.DeleteProject(ctx, projectID); err != nil {
@@ -212,7 +537,7 @@ func (s *ProjectService) DeleteProject(ctx context.Context, projectID, userID uu
 	return nil
 }
 
-// CleanupProject removes the clone directory without deleting the project record.
+// CleanupProject removes the clone and worktree directories without deleting the project record.
 // This is useful for manual cleanup of disk space.
 func (s *ProjectService) CleanupProject(ctx context.Context, projectID, userID uuid.UUID) error {
 	// Get project with ownership check
@@ -227,14 +552,338 @@ func (s *ProjectService) CleanupProject(ctx context.Context, projectID, userID u
 			return fmt.Errorf("failed to cleanup clone: %w", err)
 		}
 	}
+	if err := os.RemoveAll(s.WorktreeRoot(project.ID)); err != nil {
+		return fmt.Errorf("failed to cleanup worktrees: %w", err)
+	}
 
 	return nil
 }
 
+// CheckClone fails with domain.ErrCloneMissing if the project's clone
+// directory does not exist, as after CleanupProject, so callers can refuse
+// work up front instead of failing on a git error after sync retries.
+func (s *ProjectService) CheckClone(project *domain.Project) error {
+	info, err := os.Stat(project.ClonePath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && !info.IsDir()) {
+		return fmt.Errorf("%w: %s/%s must be re-cloned with POST /api/projects/%s/repair",
+			domain.ErrCloneMissing, project.GitHubOwner, project.GitHubRepo, project.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check project clone: %w", err)
+	}
+	return nil
+}
+
+// RepairClone re-clones a project whose clone directory was deleted or corrupted,
+// keeping the project, task, and subtask records. The fresh clone is made beside
+// the old one and swapped in only once it is complete, so a failed repair leaves
+// things as they were. The project's worktrees are removed since they belong to
+// the old clone; subtasks recreate them on their next run. Refused while agents
+// are running.
+func (s *ProjectService) RepairClone(ctx context.Context, projectID, userID uuid.UUID, githubToken string) (*domain.Project, error) {
+	// Get project with ownership check
+	project, err := s.GetProject(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if project.ClonePath == "" {
+		return nil, domain.NewUnprocessableError("project", "project has no clone path")
+	}
+
+	if _, busy := s.repairing.LoadOrStore(project.ID, struct{}{}); busy {
+		return nil, domain.NewConflictError("project", "repair already in progress")
+	}
+	defer s.repairing.Delete(project.ID)
+
+	activeRuns, err := s.repo.ListActiveAgentRunsByProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active runs: %w", err)
+	}
+	if len(activeRuns) > 0 {
+		return nil, domain.NewConflictError("project", "cannot repair clone while agents are running")
+	}
+
+	tmpPath := fmt.Sprintf("%s.repair-%s", project.ClonePath, uuid.New().String()[:8])
+	if project.IsFork && project.UpstreamOwner != nil && project.UpstreamRepo != nil {
+		err = s.githubService.CloneFork(ctx, project.GitHubOwner, project.GitHubRepo, *project.UpstreamOwner, *project.UpstreamRepo, githubToken, tmpPath)
+	} else {
+		err = s.githubService.CloneRepo(ctx, project.GitHubOwner, project.GitHubRepo, githubToken, tmpPath)
+	}
+	if err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return nil, err
+	}
+
+	if project.IsFork && project.UpstreamOwner != nil && project.UpstreamRepo != nil {
+		if err := s.githubService.AddUpstreamRemote(ctx, tmpPath, *project.UpstreamOwner, *project.UpstreamRepo); err != nil {
+			_ = os.RemoveAll(tmpPath)
+			return nil, err
+		}
+	}
+
+	// Stealth beads data is never committed, so a fresh clone normally needs it re-initialized
+	prefix, err := s.beadsService.Init(ctx, tmpPath, project.BeadsPrefix)
+	if err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return nil, err
+	}
+	if prefix != project.BeadsPrefix {
+		log.Warn().
+			Str("project_id", project.ID.String()).
+			Str("prefix", prefix).
+			Str("project_prefix", project.BeadsPrefix).
+			Msg("Repaired clone has a beads store with a different prefix")
+	}
+
+	// Swap the clones between syncs, not under one
+	unlock, err := cloneLocks.lock(ctx, project.ClonePath)
+	if err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return nil, err
+	}
+	defer unlock()
+	if err := os.RemoveAll(project.ClonePath); err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return nil, fmt.Errorf("failed to remove old clone: %w", err)
+	}
+	if err := os.Rename(tmpPath, project.ClonePath); err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return nil, fmt.Errorf("failed to move repaired clone into place: %w", err)
+	}
+	if err := os.RemoveAll(s.WorktreeRoot(project.ID)); err != nil {
+		log.Warn().Err(err).Str("project_id", project.ID.String()).Msg("failed to remove stale worktrees after repair")
+	}
+
+	return project, nil
+}
+
+// RefreshCloneTokens stores accessToken in the origin remote of each of the
+// user's clones, replacing the token they were cloned with, so pushes keep
+// working after the user reconnects GitHub. Clones that are missing or fail to
+// update are logged and skipped; it returns how many were updated.
+func (s *ProjectService) RefreshCloneTokens(ctx context.Context, userID uuid.UUID, accessToken string) (int, error) {
+	projects, err := s.repo.ListProjectsByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	updated := 0
+	for _, p := range projects {
+		if _, err := os.Stat(p.ClonePath); err != nil {
+			continue
+		}
+		if err := s.githubService.SetOriginToken(ctx, p.ClonePath, p.GithubOwner, p.GithubRepo, accessToken); err != nil {
+			log.Warn().Err(err).Str("project_id", p.ID.String()).Msg("failed to refresh clone token")
+			continue
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// DiskUsage reports the disk space used by a project's clone and worktrees.
+type DiskUsage struct {
+	ProjectID     uuid.UUID
+	ClonePath     string
+	WorktreeRoot  string
+	Exists        bool
+	Bytes         int64 // Total, including WorktreeBytes
+	WorktreeBytes int64
+}
+
+// DiskUsage walks the project's clone and worktree directories and returns
+// the bytes used. Legacy worktrees inside the clone are counted with the clone.
+func (s *ProjectService) DiskUsage(ctx context.Context, projectID, userID uuid.UUID) (*DiskUsage, error) {
+	// Get project with ownership check
+	project, err := s.GetProject(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &DiskUsage{
+		ProjectID:    project.ID,
+		ClonePath:    project.ClonePath,
+		WorktreeRoot: s.WorktreeRoot(project.ID),
+	}
+
+	worktreeBytes, err := dirSize(ctx, usage.WorktreeRoot)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to measure worktrees: %w", err)
+	}
+	usage.WorktreeBytes = worktreeBytes
+	usage.Bytes = worktreeBytes
+
+	if project.ClonePath == "" {
+		return usage, nil
+	}
+
+	cloneBytes, err := dirSize(ctx, project.ClonePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return usage, nil
+		}
+		return nil, fmt.Errorf("failed to measure clone: %w", err)
+	}
+
+	usage.Exists = true
+	usage.Bytes += cloneBytes
+	return usage, nil
+}
+
+// AttentionSubtask is a subtask BLOCKED by a failure, with the context a
+// user needs to decide whether to retry it.
+type AttentionSubtask struct {
+	Subtask      *domain.Subtask
+	TaskTitle    string
+	LastError    *string    // error of the subtask's latest agent run, if any
+	LastFailedAt *time.Time // when that run ended
+}
+
+// ListAttention returns the subtasks BLOCKED by a failure across the
+// project's open tasks, highest priority first and then most recently
+// updated. It is one query, however many tasks the project has.
+func (s *ProjectService) ListAttention(ctx context.Context, projectID, userID uuid.UUID) ([]*AttentionSubtask, error) {
+	// Get project with ownership check
+	project, err := s.GetProject(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListFailedSubtasksByProject(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed subtasks: %w", err)
+	}
+
+	subtasks := make([]*AttentionSubtask, 0, len(rows))
+	for _, row := range rows {
+		subtasks = append(subtasks, &AttentionSubtask{
+			Subtask: dbSubtaskToDomain(db.Subtask{
+				ID:                 row.ID,
+				TaskID:             row.TaskID,
+				Title:              row.Title,
+				Spec:               row.Spec,
+				ImplementationPlan: row.ImplementationPlan,
+				Status:             row.Status,
+				BlockedReason:      row.BlockedReason,
+				BranchName:         row.BranchName,
+				PrUrl:              row.PrUrl,
+				PrNumber:           row.PrNumber,
+				RetryCount:         row.RetryCount,
+				TokenUsage:         row.TokenUsage,
+				Position:           row.Position,
+				BeadsIssueID:       row.BeadsIssueID,
+				WorktreePath:       row.WorktreePath,
+				CreatedAt:          row.CreatedAt,
+				UpdatedAt:          row.UpdatedAt,
+				NextAttemptAt:      row.NextAttemptAt,
+				TokenBudget:        row.TokenBudget,
+				Priority:           row.Priority,
+			}),
+			TaskTitle:    row.TaskTitle,
+			LastError:    row.LastError,
+			LastFailedAt: repository.TimestamptzToPointer(row.LastFailedAt),
+		})
+	}
+	return subtasks, nil
+}
+
+// SweepResult summarizes a SweepIdleClones pass.
+type SweepResult struct {
+	Cleaned    int
+	FreedBytes int64
+}
+
+// SweepIdleClones removes the clone and worktree directories of projects with no activity
+// since idleSince. Projects with a planning task, an in-progress subtask, or a
+// running agent are never swept. Project records are kept, like CleanupProject.
+func (s *ProjectService) SweepIdleClones(ctx context.Context, idleSince time.Time) (*SweepResult, error) {
+	projects, err := s.repo.ListIdleProjects(ctx, idleSince)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list idle projects: %w", err)
+	}
+
+	result := &SweepResult{}
+	for _, project := range projects {
+		if project.ClonePath == "" {
+			continue
+		}
+		if _, err := os.Stat(project.ClonePath); err != nil {
+			// Already cleaned (or never cloned)
+			continue
+		}
+
+		// Re-check right before removing: an agent may have started since the query ran
+		activeRuns, err := s.repo.ListActiveAgentRunsByProject(ctx, project.ID)
+		if err != nil {
+			log.Error().Err(err).Str("project_id", project.ID.String()).Msg("failed to check active runs before sweep")
+			continue
+		}
+		if len(activeRuns) > 0 {
+			continue
+		}
+
+		bytes, err := dirSize(ctx, project.ClonePath)
+		if err != nil {
+			log.Warn().Err(err).Str("clone_path", project.ClonePath).Msg("failed to measure clone before sweep")
+		}
+		worktreeRoot := s.WorktreeRoot(project.ID)
+		if worktreeBytes, err := dirSize(ctx, worktreeRoot); err == nil {
+			bytes += worktreeBytes
+		}
+
+		if err := os.RemoveAll(project.ClonePath); err != nil {
+			log.Error().Err(err).Str("clone_path", project.ClonePath).Msg("failed to remove idle clone")
+			continue
+		}
+		if err := os.RemoveAll(worktreeRoot); err != nil {
+			log.Error().Err(err).Str("worktree_root", worktreeRoot).Msg("failed to remove idle project worktrees")
+		}
+
+		result.Cleaned++
+		result.FreedBytes += bytes
+		log.Info().
+			Str("project_id", project.ID.String()).
+			Str("clone_path", project.ClonePath).
+			Int64("bytes", bytes).
+			Time("last_activity_before", idleSince).
+			Msg("removed idle project clone")
+	}
+
+	return result, nil
+}
+
+// dirSize returns the total size of the regular files under root.
+// Symlinks are not followed.
+func dirSize(ctx context.Context, root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The root itself must exist; entries removed mid-walk are skipped
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
 // generateClonePath generates a path for cloning a repository.
-// Format: {dataDir}/projects/{userID}/{owner}/{repo}
+// Format: {DATA_DIR}/projects/{userID}/{owner}/{repo}
 func (s *ProjectService) generateClonePath(userID uuid.UUID, owner, repo string) string {
-	return filepath.Join(s.dataDir, "projects", userID.String(), owner, repo)
+	return s.paths.Clone(userID.String(), owner, repo)
 }
 
 // generateBeadsPrefix generates a unique beads prefix for a project.
@@ -272,20 +921,38 @@ func (s *ProjectService) CheckProjectOwnership(projectID, userID uuid.UUID) erro
 	return err
 }
 
+// checkRepoSize returns ErrRepoTooLarge (also matching domain.ErrUnprocessable)
+// if the repository exceeds maxMB. A maxMB of 0 disables the check.
+func checkRepoSize(info *RepoInfo, maxMB int) error {
+	if maxMB <= 0 || info.SizeKB <= maxMB*1024 {
+		return nil
+	}
+	reason := fmt.Sprintf("repository %s/%s is %d MB, over the %d MB limit", info.Owner, info.Repo, info.SizeKB/1024, maxMB)
+	return fmt.Errorf("%w: %w", ErrRepoTooLarge, domain.NewUnprocessableError("project", reason))
+}
+
 // dbProjectToDomain converts a database Project to a domain Project.
 func dbProjectToDomain(p db.Project) *domain.Project {
-	return &domain.Project{
-		ID:            p.ID,
-		UserID:        p.UserID,
-		GitHubOwner:   p.GithubOwner,
-		GitHubRepo:    p.GithubRepo,
-		IsFork:        p.IsFork,
-		UpstreamOwner: p.UpstreamOwner,
-		UpstreamRepo:  p.UpstreamRepo,
-		DefaultBranch: p.DefaultBranch,
-		ClonePath:     p.ClonePath,
-		BeadsPrefix:   p.BeadsPrefix,
-		CreatedAt:     p.CreatedAt,
-		UpdatedAt:     p.UpdatedAt,
+	project := &domain.Project{
+		ID:              p.ID,
+		UserID:          p.UserID,
+		GitHubOwner:     p.GithubOwner,
+		GitHubRepo:      p.GithubRepo,
+		IsFork:          p.IsFork,
+		UpstreamOwner:   p.UpstreamOwner,
+		UpstreamRepo:    p.UpstreamRepo,
+		DefaultBranch:   p.DefaultBranch,
+		ClonePath:       p.ClonePath,
+		BeadsPrefix:     p.BeadsPrefix,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+		PRTitleTemplate: p.PrTitleTemplate,
+		SquashBeforePR:  p.SquashBeforePr,
+		PathPolicy:      domain.PathPolicy{Allowed: p.AllowedPaths, Denied: p.DeniedPaths},
+	}
+	if p.MaxSubtasksPerTask != nil {
+		limit := int(*p.MaxSubtasksPerTask)
+		project.MaxSubtasksPerTask = &limit
 	}
+	return project
 }
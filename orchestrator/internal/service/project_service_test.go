@@ -0,0 +1,476 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/config"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository/repotest"
+)
+
+func TestDirSize(t *testing.T) {
+	root := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(root, "worktrees", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]int{
+		"README.md":                            10,
+		filepath.Join("worktrees", "a"):        100,
+		filepath.Join("worktrees", "sub", "b"): 1000,
+	}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(root, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Symlinks are not followed or counted
+	if err := os.Symlink(filepath.Join(root, "README.md"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := dirSize(context.Background(), root)
+	if err != nil {
+		t.Fatalf("dirSize() error = %v", err)
+	}
+	if got != 1110 {
+		t.Errorf("dirSize() = %d, want 1110", got)
+	}
+}
+
+func TestDirSize_Missing(t *testing.T) {
+	_, err := dirSize(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("dirSize() error = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestDirSize_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := dirSize(ctx, t.TempDir())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("dirSize() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestProjectService_WorktreePath(t *testing.T) {
+	projectID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	subtaskID := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+
+	svc := NewProjectService(nil, nil, nil, nil, config.NewDataPaths("/data", ""))
+	if got, want := svc.WorktreePath(projectID, subtaskID), "/data/worktrees/"+projectID.String()+"/"+subtaskID.String(); got != want {
+		t.Errorf("WorktreePath() = %q, want %q", got, want)
+	}
+	if got, want := svc.WorktreeRoot(projectID), "/data/worktrees/"+projectID.String(); got != want {
+		t.Errorf("WorktreeRoot() = %q, want %q", got, want)
+	}
+
+	svc = NewProjectService(nil, nil, nil, nil, config.NewDataPaths("/data", "/scratch/wt"))
+	if got, want := svc.WorktreePath(projectID, subtaskID), "/scratch/wt/"+projectID.String()+"/"+subtaskID.String(); got != want {
+		t.Errorf("WorktreePath() with override = %q, want %q", got, want)
+	}
+}
+
+func TestCheckRepoSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		sizeKB  int
+		maxMB   int
+		wantErr bool
+	}{
+		{"under limit", 500 * 1024, 1024, false},
+		{"exactly at limit", 1024 * 1024, 1024, false},
+		{"over limit", 1024*1024 + 1, 1024, true},
+		{"limit disabled", 50 * 1024 * 1024, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRepoSize(&RepoInfo{Owner: "o", Repo: "r", SizeKB: tt.sizeKB}, tt.maxMB)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkRepoSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			if !errors.Is(err, ErrRepoTooLarge) {
+				t.Errorf("error should wrap ErrRepoTooLarge, got %v", err)
+			}
+			if !domain.IsUnprocessable(err) {
+				t.Errorf("error should be unprocessable, got %v", err)
+			}
+		})
+	}
+}
+
+func TestProjectService_GetProjectSummary(t *testing.T) {
+	store := repotest.New()
+	svc := &ProjectService{repo: store}
+	ctx := context.Background()
+	owner := uuid.New()
+
+	task, subtasks := seedTask(t, store, owner, domain.TaskStatusActive,
+		domain.SubtaskStatusInProgress, domain.SubtaskStatusInProgress, domain.SubtaskStatusMerged)
+	if _, err := store.UpdateSubtaskTokenUsage(ctx, db.UpdateSubtaskTokenUsageParams{ID: subtasks[0].ID, TokenUsage: 1000}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpdateSubtaskTokenUsage(ctx, db.UpdateSubtaskTokenUsageParams{ID: subtasks[2].ID, TokenUsage: 500}); err != nil {
+		t.Fatal(err)
+	}
+	plannerTokens := int32(250)
+	store.AddAgentRun(db.AgentRun{TaskID: pgtype.UUID{Bytes: task.ID, Valid: true}, Status: "COMPLETED", TokenUsage: &plannerTokens})
+	store.AddAgentRun(db.AgentRun{SubtaskID: pgtype.UUID{Bytes: subtasks[0].ID, Valid: true}, Status: "RUNNING"})
+	store.AddAgentRun(db.AgentRun{SubtaskID: pgtype.UUID{Bytes: subtasks[1].ID, Valid: true}, Status: "FAILED"})
+
+	// Another project's work is not counted
+	_, other := seedTask(t, store, owner, domain.TaskStatusActive, domain.SubtaskStatusInProgress)
+	store.AddAgentRun(db.AgentRun{SubtaskID: pgtype.UUID{Bytes: other[0].ID, Valid: true}, Status: "RUNNING"})
+
+	summary, err := svc.GetProjectSummary(ctx, &domain.Project{ID: task.ProjectID})
+	if err != nil {
+		t.Fatalf("GetProjectSummary() error = %v", err)
+	}
+	if got := summary.TasksByStatus; len(got) != 1 || got[domain.TaskStatusActive] != 1 {
+		t.Errorf("TasksByStatus = %v, want 1 ACTIVE", got)
+	}
+	if got := summary.SubtasksByStatus; len(got) != 2 || got[domain.SubtaskStatusInProgress] != 2 || got[domain.SubtaskStatusMerged] != 1 {
+		t.Errorf("SubtasksByStatus = %v, want 2 IN_PROGRESS and 1 MERGED", got)
+	}
+	if summary.ActiveAgents != 1 {
+		t.Errorf("ActiveAgents = %d, want 1", summary.ActiveAgents)
+	}
+	if summary.TokenUsage != 1750 {
+		t.Errorf("TokenUsage = %d, want 1750", summary.TokenUsage)
+	}
+}
+
+func TestProjectService_ListAttention(t *testing.T) {
+	store := repotest.New()
+	svc := &ProjectService{repo: store}
+	ctx := context.Background()
+	owner := uuid.New()
+
+	task, subtasks := seedTask(t, store, owner, domain.TaskStatusActive,
+		domain.SubtaskStatusBlocked, domain.SubtaskStatusBlocked, domain.SubtaskStatusBlocked, domain.SubtaskStatusReady)
+	block := func(st db.Subtask, reason domain.BlockedReason) {
+		t.Helper()
+		r := string(reason)
+		if _, err := store.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{ID: st.ID, Status: st.Status, BlockedReason: &r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block(subtasks[0], domain.BlockedReasonFailure)
+	block(subtasks[1], domain.BlockedReasonDependency)
+	block(subtasks[2], domain.BlockedReasonFailure)
+
+	oldErr, lastErr := "exit code: 1", "stalled: no log output for 10m0s"
+	ended := time.Now().Truncate(time.Second)
+	store.AddAgentRun(db.AgentRun{SubtaskID: pgtype.UUID{Bytes: subtasks[0].ID, Valid: true}, AttemptNumber: 1, Status: "FAILED", ErrorMessage: &oldErr})
+	store.AddAgentRun(db.AgentRun{
+		SubtaskID: pgtype.UUID{Bytes: subtasks[0].ID, Valid: true}, AttemptNumber: 2, Status: "FAILED",
+		ErrorMessage: &lastErr, EndedAt: pgtype.Timestamptz{Time: ended, Valid: true},
+	})
+
+	// Failures under a finished task need no attention
+	cancelled, err := store.CreateTask(ctx, db.CreateTaskParams{ProjectID: task.ProjectID, Title: "cancelled", Status: string(domain.TaskStatusCancelled)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := string(domain.BlockedReasonFailure)
+	abandoned, err := store.CreateSubtask(ctx, db.CreateSubtaskParams{TaskID: cancelled.ID, Title: "abandoned", Status: string(domain.SubtaskStatusBlocked)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{ID: abandoned.ID, Status: abandoned.Status, BlockedReason: &failure}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := svc.ListAttention(ctx, task.ProjectID, owner)
+	if err != nil {
+		t.Fatalf("ListAttention() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ListAttention() returned %d subtasks, want the 2 blocked by failure", len(got))
+	}
+	byID := map[uuid.UUID]*AttentionSubtask{got[0].Subtask.ID: got[0], got[1].Subtask.ID: got[1]}
+	first, ok := byID[subtasks[0].ID]
+	if !ok {
+		t.Fatalf("ListAttention() = %v, want subtask %s", got, subtasks[0].ID)
+	}
+	if first.TaskTitle != task.Title {
+		t.Errorf("TaskTitle = %q, want %q", first.TaskTitle, task.Title)
+	}
+	if first.LastError == nil || *first.LastError != lastErr {
+		t.Errorf("LastError = %v, want the latest run's %q", first.LastError, lastErr)
+	}
+	if first.LastFailedAt == nil || !first.LastFailedAt.Equal(ended) {
+		t.Errorf("LastFailedAt = %v, want %v", first.LastFailedAt, ended)
+	}
+	// A subtask without runs has no error to show
+	if third, ok := byID[subtasks[2].ID]; !ok || third.LastError != nil {
+		t.Errorf("subtask without runs = %+v, want listed without an error", third)
+	}
+
+	if _, err := svc.ListAttention(ctx, task.ProjectID, uuid.New()); !domain.IsForbidden(err) {
+		t.Errorf("ListAttention() as another user error = %v, want forbidden", err)
+	}
+}
+
+func TestProjectService_ListAttention_PriorityFirst(t *testing.T) {
+	store := repotest.New()
+	svc := &ProjectService{repo: store}
+	ctx := context.Background()
+	owner := uuid.New()
+
+	task, subtasks := seedTask(t, store, owner, domain.TaskStatusActive,
+		domain.SubtaskStatusBlocked, domain.SubtaskStatusBlocked, domain.SubtaskStatusBlocked)
+	failure := string(domain.BlockedReasonFailure)
+	for i, priority := range []int32{2, 1, 2} {
+		if _, err := store.UpdateSubtaskPriority(ctx, db.UpdateSubtaskPriorityParams{ID: subtasks[i].ID, Priority: priority}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{ID: subtasks[i].ID, Status: subtasks[i].Status, BlockedReason: &failure}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := svc.ListAttention(ctx, task.ProjectID, owner)
+	if err != nil {
+		t.Fatalf("ListAttention() error = %v", err)
+	}
+	var ids []uuid.UUID
+	for _, s := range got {
+		ids = append(ids, s.Subtask.ID)
+	}
+	// The urgent subtask leads; equal priorities are most recently updated first
+	want := []uuid.UUID{subtasks[1].ID, subtasks[2].ID, subtasks[0].ID}
+	if !slices.Equal(ids, want) {
+		t.Errorf("ListAttention() order = %v, want %v", ids, want)
+	}
+}
+
+func TestProjectService_ClearDanglingClone(t *testing.T) {
+	store := repotest.New()
+	s := &ProjectService{repo: store}
+	ctx := context.Background()
+	root := t.TempDir()
+
+	// A missing directory needs nothing done
+	if err := s.clearDanglingClone(ctx, filepath.Join(root, "missing")); err != nil {
+		t.Errorf("clearDanglingClone() for a missing directory error = %v", err)
+	}
+
+	// A partial clone with no project record is removed
+	dangling := filepath.Join(root, "dangling")
+	if err := os.MkdirAll(filepath.Join(dangling, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.clearDanglingClone(ctx, dangling); err != nil {
+		t.Fatalf("clearDanglingClone() for a dangling clone error = %v", err)
+	}
+	if _, err := os.Stat(dangling); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("dangling clone still exists: %v", err)
+	}
+
+	// A clone that belongs to a project is kept
+	owned := filepath.Join(root, "owned")
+	if err := os.MkdirAll(owned, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateProject(ctx, db.CreateProjectParams{UserID: uuid.New(), GithubOwner: "owner", GithubRepo: "repo", ClonePath: owned}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.clearDanglingClone(ctx, owned); !domain.IsConflict(err) {
+		t.Errorf("clearDanglingClone() for a project's clone error = %v, want conflict", err)
+	}
+	if _, err := os.Stat(owned); err != nil {
+		t.Errorf("project's clone was removed: %v", err)
+	}
+}
+
+func TestProjectService_CheckClone(t *testing.T) {
+	s := &ProjectService{}
+	root := t.TempDir()
+	file := filepath.Join(root, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CheckClone(&domain.Project{ClonePath: root}); err != nil {
+		t.Errorf("CheckClone() for an existing clone error = %v", err)
+	}
+	for _, path := range []string{filepath.Join(root, "deleted"), file, ""} {
+		if err := s.CheckClone(&domain.Project{ClonePath: path}); !domain.IsCloneMissing(err) {
+			t.Errorf("CheckClone(%q) error = %v, want clone missing", path, err)
+		}
+	}
+}
+
+func TestProjectService_CreateProject_RepoOwnerPolicy(t *testing.T) {
+	// The user "me" is an active member of acme only; the repository lookup
+	// after the owner checks always fails, which is enough to show they passed
+	var repoLookups int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/user":
+			_, _ = w.Write([]byte(`{"login":"me"}`))
+		case "/user/memberships/orgs/acme":
+			_, _ = w.Write([]byte(`{"state":"active"}`))
+		case "/user/memberships/orgs/pending":
+			_, _ = w.Write([]byte(`{"state":"pending"}`))
+		default:
+			if strings.HasPrefix(r.URL.Path, "/repos/") {
+				repoLookups++
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	gh := NewGitHubService()
+	gh.apiURL, _ = url.Parse(server.URL + "/")
+
+	tests := []struct {
+		name       string
+		policy     domain.RepoOwnerPolicy
+		membership bool
+		owner      string
+		allowed    bool
+	}{
+		{name: "no policy", owner: "octocat", allowed: true},
+		{name: "allowed owner", policy: domain.RepoOwnerPolicy{Allowed: []string{"acme"}}, owner: "ACME", allowed: true},
+		{name: "owner not allowed", policy: domain.RepoOwnerPolicy{Allowed: []string{"acme"}}, owner: "octocat"},
+		{name: "denied owner", policy: domain.RepoOwnerPolicy{Allowed: []string{"acme*"}, Denied: []string{"acme-old"}}, owner: "acme-old"},
+		{name: "member of the org", membership: true, owner: "acme", allowed: true},
+		{name: "the user's own repos", membership: true, owner: "Me", allowed: true},
+		{name: "not a member", membership: true, owner: "octocat"},
+		{name: "membership pending", membership: true, owner: "pending"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ProjectService{repo: repotest.New(), githubService: gh}
+			s.SetRepoOwnerPolicy(tt.policy, tt.membership)
+			before := repoLookups
+
+			_, err := s.CreateProject(context.Background(), CreateProjectInput{UserID: uuid.New(), RepoURL: "https://github.com/" + tt.owner + "/repo", GitHubToken: "token"})
+
+			if tt.allowed {
+				if domain.IsForbidden(err) || repoLookups == before {
+					t.Errorf("CreateProject() error = %v after %d repository lookups, want the owner allowed", err, repoLookups-before)
+				}
+				return
+			}
+			if !domain.IsForbidden(err) {
+				t.Errorf("CreateProject() error = %v, want forbidden", err)
+			}
+			if repoLookups != before {
+				t.Error("a disallowed owner's repository was looked up")
+			}
+		})
+	}
+}
+
+func TestProjectService_SetPathPolicy(t *testing.T) {
+	store := repotest.New()
+	svc := &ProjectService{repo: store}
+	ctx := context.Background()
+	userID := uuid.New()
+	created, err := store.CreateProject(ctx, db.CreateProjectParams{UserID: userID, GithubOwner: "owner", GithubRepo: "repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := domain.PathPolicy{Allowed: []string{"src"}, Denied: []string{".github", "infra"}}
+	project, err := svc.SetPathPolicy(ctx, created.ID, userID, policy)
+	if err != nil {
+		t.Fatalf("SetPathPolicy() error = %v", err)
+	}
+	if !reflect.DeepEqual(project.PathPolicy, policy) {
+		t.Errorf("PathPolicy = %+v, want %+v", project.PathPolicy, policy)
+	}
+
+	if _, err := svc.SetPathPolicy(ctx, created.ID, userID, domain.PathPolicy{Denied: []string{"/etc"}}); !domain.IsInvalidInput(err) {
+		t.Errorf("SetPathPolicy(absolute pattern) error = %v, want invalid input", err)
+	}
+	if _, err := svc.SetPathPolicy(ctx, created.ID, uuid.New(), domain.PathPolicy{}); err == nil {
+		t.Error("SetPathPolicy() by another user succeeded, want an error")
+	}
+
+	project, err = svc.SetPathPolicy(ctx, created.ID, userID, domain.PathPolicy{})
+	if err != nil {
+		t.Fatalf("SetPathPolicy(empty) error = %v", err)
+	}
+	if !project.PathPolicy.IsEmpty() {
+		t.Errorf("PathPolicy = %+v, want every path allowed", project.PathPolicy)
+	}
+}
+
+func TestProjectService_CancelCreateProject(t *testing.T) {
+	// GitHub answers only once the request is abandoned, so CreateProject is
+	// still in progress when it is canceled
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	gh := NewGitHubService()
+	gh.apiURL, _ = url.Parse(server.URL + "/")
+	s := &ProjectService{repo: repotest.New(), githubService: gh}
+	userID := uuid.New()
+
+	if err := s.CancelCreateProject(userID, "https://github.com/owner/repo"); !domain.IsNotFound(err) {
+		t.Errorf("CancelCreateProject() with nothing in progress error = %v, want not found", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.CreateProject(context.Background(), CreateProjectInput{UserID: userID, RepoURL: "https://github.com/owner/repo", GitHubToken: "token"})
+		done <- err
+	}()
+	<-started
+
+	if _, err := s.CreateProject(context.Background(), CreateProjectInput{UserID: userID, RepoURL: "https://github.com/Owner/Repo"}); !domain.IsConflict(err) {
+		t.Errorf("concurrent CreateProject() error = %v, want conflict", err)
+	}
+	if err := s.CancelCreateProject(uuid.New(), "https://github.com/owner/repo"); !domain.IsNotFound(err) {
+		t.Errorf("CancelCreateProject() by another user error = %v, want not found", err)
+	}
+	if err := s.CancelCreateProject(userID, "https://github.com/owner/repo"); err != nil {
+		t.Fatalf("CancelCreateProject() error = %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if !domain.IsConflict(err) {
+			t.Errorf("canceled CreateProject() error = %v, want conflict", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CreateProject() did not return after being canceled")
+	}
+	if err := s.CancelCreateProject(userID, "https://github.com/owner/repo"); !domain.IsNotFound(err) {
+		t.Errorf("CancelCreateProject() after the creation ended error = %v, want not found", err)
+	}
+}
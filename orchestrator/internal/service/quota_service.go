@@ -0,0 +1,134 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// Quota names used in QuotaExceededError and usage responses.
+const (
+	QuotaProjects         = "projects"
+	QuotaActiveTasks      = "active tasks"
+	QuotaConcurrentAgents = "concurrent agents"
+)
+
+// UserQuotas are the per-user limits of a shared deployment. Zero disables a limit.
+type UserQuotas struct {
+	MaxProjects         int
+	MaxActiveTasks      int
+	MaxConcurrentAgents int
+}
+
+// AgentCounter counts the agents currently running on behalf of a user.
+// It is implemented by agent.AgentManager.
+type AgentCounter interface {
+	CountRunningAgentsForUser(userID uuid.UUID) int
+}
+
+// QuotaUsage is one quota's current usage. Limit 0 means unlimited.
+type QuotaUsage struct {
+	Used  int
+	Limit int
+}
+
+// UserUsage is a user's usage of every quota.
+type UserUsage struct {
+	Projects         QuotaUsage
+	ActiveTasks      QuotaUsage
+	ConcurrentAgents QuotaUsage
+}
+
+// QuotaService enforces per-user quotas on projects and active tasks and
+// reports usage. Concurrent agents are checked here so a request can be refused
+// up front, and again by the agent manager, which claims the slot atomically.
+type QuotaService struct {
+	repo   *repository.Repository
+	quotas UserQuotas
+	agents AgentCounter
+}
+
+// NewQuotaService creates a new QuotaService.
+func NewQuotaService(repo *repository.Repository, quotas UserQuotas) *QuotaService {
+	return &QuotaService{
+		repo:   repo,
+		quotas: quotas,
+	}
+}
+
+// SetAgentCounter sets the source of running agent counts for Usage.
+// This is set after construction to break circular dependencies.
+func (s *QuotaService) SetAgentCounter(agents AgentCounter) {
+	s.agents = agents
+}
+
+// CheckProjects fails with a quota error if the user cannot add another project.
+func (s *QuotaService) CheckProjects(ctx context.Context, userID uuid.UUID) error {
+	if s.quotas.MaxProjects == 0 {
+		return nil
+	}
+	count, err := s.repo.CountProjectsByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count projects: %w", err)
+	}
+	return checkQuota(QuotaProjects, int(count), s.quotas.MaxProjects)
+}
+
+// CheckActiveTasks fails with a quota error if the user cannot create another task.
+func (s *QuotaService) CheckActiveTasks(ctx context.Context, userID uuid.UUID) error {
+	if s.quotas.MaxActiveTasks == 0 {
+		return nil
+	}
+	count, err := s.repo.CountActiveTasksByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to count active tasks: %w", err)
+	}
+	return checkQuota(QuotaActiveTasks, int(count), s.quotas.MaxActiveTasks)
+}
+
+// CheckConcurrentAgents fails with a quota error if the user already has the
+// maximum number of agents running. Services call it before changing anything
+// for a request that spawns an agent.
+func (s *QuotaService) CheckConcurrentAgents(userID uuid.UUID) error {
+	if s.quotas.MaxConcurrentAgents == 0 || s.agents == nil {
+		return nil
+	}
+	return checkQuota(QuotaConcurrentAgents, s.agents.CountRunningAgentsForUser(userID), s.quotas.MaxConcurrentAgents)
+}
+
+// Usage returns the user's current usage of each quota.
+func (s *QuotaService) Usage(ctx context.Context, userID uuid.UUID) (*UserUsage, error) {
+	projects, err := s.repo.CountProjectsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count projects: %w", err)
+	}
+	tasks, err := s.repo.CountActiveTasksByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active tasks: %w", err)
+	}
+	agents := 0
+	if s.agents != nil {
+		agents = s.agents.CountRunningAgentsForUser(userID)
+	}
+
+	return &UserUsage{
+		Projects:         QuotaUsage{Used: int(projects), Limit: s.quotas.MaxProjects},
+		ActiveTasks:      QuotaUsage{Used: int(tasks), Limit: s.quotas.MaxActiveTasks},
+		ConcurrentAgents: QuotaUsage{Used: agents, Limit: s.quotas.MaxConcurrentAgents},
+	}, nil
+}
+
+// checkQuota fails if used has already reached limit (0 means no limit).
+func checkQuota(quota string, used, limit int) error {
+	if limit == 0 || used < limit {
+		return nil
+	}
+	return domain.NewQuotaExceededError(quota, limit)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+func TestCheckQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		used    int
+		limit   int
+		wantErr bool
+	}{
+		{name: "under limit", used: 2, limit: 3},
+		{name: "at limit", used: 3, limit: 3, wantErr: true},
+		{name: "over limit", used: 4, limit: 3, wantErr: true},
+		{name: "unlimited", used: 100, limit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQuota(QuotaProjects, tt.used, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkQuota() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !domain.IsQuotaExceeded(err) {
+				t.Errorf("error should be a quota error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestQuotaService_UnlimitedSkipsCounting(t *testing.T) {
+	// With no limits configured the checks must not touch the database
+	s := NewQuotaService(nil, UserQuotas{})
+
+	if err := s.CheckProjects(context.Background(), uuid.New()); err != nil {
+		t.Errorf("CheckProjects() = %v, want nil", err)
+	}
+	if err := s.CheckActiveTasks(context.Background(), uuid.New()); err != nil {
+		t.Errorf("CheckActiveTasks() = %v, want nil", err)
+	}
+}
+
+// agentCount is an AgentCounter reporting a fixed number of running agents.
+type agentCount int
+
+func (n agentCount) CountRunningAgentsForUser(uuid.UUID) int {
+	return int(n)
+}
+
+func TestQuotaService_CheckConcurrentAgents(t *testing.T) {
+	s := NewQuotaService(nil, UserQuotas{MaxConcurrentAgents: 2})
+	if err := s.CheckConcurrentAgents(uuid.New()); err != nil {
+		t.Errorf("without an agent counter CheckConcurrentAgents() = %v, want nil", err)
+	}
+
+	s.SetAgentCounter(agentCount(1))
+	if err := s.CheckConcurrentAgents(uuid.New()); err != nil {
+		t.Errorf("under the limit CheckConcurrentAgents() = %v, want nil", err)
+	}
+
+	s.SetAgentCounter(agentCount(2))
+	if err := s.CheckConcurrentAgents(uuid.New()); !domain.IsQuotaExceeded(err) {
+		t.Errorf("at the limit CheckConcurrentAgents() = %v, want quota exceeded", err)
+	}
+}
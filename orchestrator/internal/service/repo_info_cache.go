@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"time"
+)
+
+// repoInfoCache keeps GetRepoInfo results for a short time so bursts of
+// lookups of one repository, such as repeated project creation, cost one
+// GitHub API call. Repository metadata is shared by owner/repo, but push
+// access is per user: a token is only answered from the cache if that token
+// fetched the entry's repository itself, so the cache never reveals a private
+// repository to a user who cannot see it. Errors are not cached.
+type repoInfoCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*repoInfoEntry // lowercased owner/repo -> entry
+}
+
+// repoInfoEntry is one cached repository.
+type repoInfoEntry struct {
+	info      RepoInfo
+	fetchedAt time.Time
+	access    map[[sha256.Size]byte]bool // token hash -> push access
+}
+
+func newRepoInfoCache(ttl time.Duration) *repoInfoCache {
+	return &repoInfoCache{ttl: ttl, now: time.Now, entries: make(map[string]*repoInfoEntry)}
+}
+
+// repoInfoKey returns the cache key of owner/repo. GitHub names are case
+// insensitive.
+func repoInfoKey(owner, repo string) string {
+	return strings.ToLower(owner + "/" + repo)
+}
+
+// get returns a copy of the cached info of owner/repo as seen by accessToken,
+// if it is fresh and that token fetched it. A nil cache always misses.
+func (c *repoInfoCache) get(owner, repo, accessToken string) (*RepoInfo, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[repoInfoKey(owner, repo)]
+	if !ok || c.now().Sub(entry.fetchedAt) >= c.ttl {
+		return nil, false
+	}
+	push, ok := entry.access[sha256.Sum256([]byte(accessToken))]
+	if !ok {
+		return nil, false
+	}
+	info := entry.info
+	info.HasPushAccess = push
+	return &info, true
+}
+
+// put records info as fetched with accessToken. A fresh entry only gains the
+// token's access, so no entry outlives the TTL of its first fetch; expired
+// entries are dropped.
+func (c *repoInfoCache) put(owner, repo, accessToken string, info *RepoInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+
+	key := repoInfoKey(owner, repo)
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &repoInfoEntry{info: *info, fetchedAt: now, access: make(map[[sha256.Size]byte]bool)}
+		c.entries[key] = entry
+	}
+	entry.access[sha256.Sum256([]byte(accessToken))] = info.HasPushAccess
+}
+
+// invalidate drops the cached info of owner/repo.
+func (c *repoInfoCache) invalidate(owner, repo string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, repoInfoKey(owner, repo))
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRepoInfoCache(t *testing.T) {
+	now := time.Now()
+	c := newRepoInfoCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.put("Owner", "Repo", "token-a", &RepoInfo{Owner: "Owner", Repo: "Repo", DefaultBranch: "main", HasPushAccess: true})
+
+	info, ok := c.get("owner", "repo", "token-a")
+	if !ok || info.DefaultBranch != "main" || !info.HasPushAccess {
+		t.Fatalf("get() = %+v, %v; want the cached info with push access", info, ok)
+	}
+	if _, ok := c.get("owner", "repo", "token-b"); ok {
+		t.Error("get() with a token that never fetched the repo hit the cache")
+	}
+
+	// Another user's access is cached alongside, without extending the entry
+	now = now.Add(30 * time.Second)
+	c.put("owner", "repo", "token-b", &RepoInfo{Owner: "Owner", Repo: "Repo", DefaultBranch: "main"})
+	if info, ok := c.get("owner", "repo", "token-b"); !ok || info.HasPushAccess {
+		t.Errorf("get() for token-b = %+v, %v; want cached info without push access", info, ok)
+	}
+	if info, _ := c.get("owner", "repo", "token-a"); info == nil || !info.HasPushAccess {
+		t.Errorf("get() for token-a = %+v; want push access kept", info)
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, ok := c.get("owner", "repo", "token-b"); ok {
+		t.Error("get() after the TTL of the first fetch hit the cache")
+	}
+
+	c.put("owner", "repo", "token-a", &RepoInfo{Owner: "Owner", Repo: "Repo"})
+	c.invalidate("OWNER", "REPO")
+	if _, ok := c.get("owner", "repo", "token-a"); ok {
+		t.Error("get() after invalidate hit the cache")
+	}
+}
+
+func TestGitHubService_GetRepoInfo_Cached(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/repos/owner/repo" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"name":"repo","owner":{"login":"owner"},"default_branch":"main","permissions":{"push":true}}`))
+	}))
+	defer server.Close()
+
+	svc := NewGitHubService()
+	svc.apiURL, _ = url.Parse(server.URL + "/")
+	ctx := context.Background()
+
+	lookup := func(owner, repo, token string) {
+		t.Helper()
+		_, _ = svc.GetRepoInfo(ctx, owner, repo, token)
+	}
+
+	// Without a TTL every lookup calls GitHub
+	lookup("owner", "repo", "token")
+	lookup("owner", "repo", "token")
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("uncached lookups made %d requests, want 2", got)
+	}
+
+	svc.SetRepoInfoCacheTTL(time.Minute)
+	requests.Store(0)
+	for range 3 {
+		info, err := svc.GetRepoInfo(ctx, "owner", "repo", "token")
+		if err != nil || !info.HasPushAccess || info.DefaultBranch != "main" {
+			t.Fatalf("GetRepoInfo() = %+v, %v", info, err)
+		}
+	}
+	lookup("owner", "repo", "other-token")
+	lookup("owner", "missing", "token")
+	lookup("owner", "missing", "token")
+	if got := requests.Load(); got != 4 {
+		t.Errorf("cached lookups made %d requests, want 4 (one per token, errors not cached)", got)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReconnectPolicy decides when a user's SSE connects count as flapping, e.g. a
+// crashing tab reopening its stream in a tight loop, and how far to back it off.
+type ReconnectPolicy struct {
+	// Window is how far back connects are counted.
+	Window time.Duration
+	// MaxConnects is how many connects a user may open within Window before
+	// being backed off (0 = no flap detection).
+	MaxConnects int
+	// BaseRetry is the retry hint sent to well-behaved clients.
+	BaseRetry time.Duration
+	// MaxRetry caps the backed-off retry hint.
+	MaxRetry time.Duration
+}
+
+// ReconnectDecision is the hub's verdict on a new SSE connect.
+type ReconnectDecision struct {
+	// Retry is the reconnect delay to send in the stream's retry: field.
+	Retry time.Duration
+	// Flapping is true once the user exceeds MaxConnects within Window.
+	Flapping bool
+	// Reject is true once the user exceeds twice MaxConnects within Window;
+	// the connect should be refused and retried after Retry.
+	Reject bool
+}
+
+// reconnectTracker counts connects per user over a sliding window.
+type reconnectTracker struct {
+	policy   ReconnectPolicy
+	connects map[uuid.UUID][]time.Time
+}
+
+// record notes a connect by userID at now and decides how to answer it.
+// Callers must hold the hub's lock.
+func (t *reconnectTracker) record(userID uuid.UUID, now time.Time) ReconnectDecision {
+	p := t.policy
+	decision := ReconnectDecision{Retry: p.BaseRetry}
+	if p.MaxConnects <= 0 {
+		return decision
+	}
+
+	cutoff := now.Add(-p.Window)
+	for id, times := range t.connects {
+		if id != userID && !times[len(times)-1].After(cutoff) {
+			delete(t.connects, id)
+		}
+	}
+
+	times := t.connects[userID]
+	for len(times) > 0 && !times[0].After(cutoff) {
+		times = times[1:]
+	}
+	times = append(times, now)
+	// Anything past the rejection point decides the same way, so keep the
+	// history bounded for a client that never lets up
+	if limit := 2*p.MaxConnects + 1; len(times) > limit {
+		times = times[len(times)-limit:]
+	}
+	t.connects[userID] = times
+
+	excess := len(times) - p.MaxConnects
+	if excess <= 0 {
+		return decision
+	}
+	decision.Flapping = true
+	decision.Reject = excess > p.MaxConnects
+	decision.Retry = flapRetry(p.BaseRetry, p.MaxRetry, excess)
+	return decision
+}
+
+// flapRetry doubles base for each connect over the limit, capped at maxRetry,
+// less up to 20% jitter so clients backed off together do not return together.
+func flapRetry(base, maxRetry time.Duration, excess int) time.Duration {
+	delay := maxRetry
+	if excess < 32 && base<<excess < maxRetry && base<<excess > 0 {
+		delay = base << excess
+	}
+	jitter := time.Duration(float64(delay) * 0.2 * rand.Float64()) //nolint:gosec // Non-cryptographic use for backoff jitter
+	return max(delay-jitter, base)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReconnectTracker() *reconnectTracker {
+	return &reconnectTracker{
+		policy: ReconnectPolicy{
+			Window:      time.Minute,
+			MaxConnects: 3,
+			BaseRetry:   time.Second,
+			MaxRetry:    10 * time.Second,
+		},
+		connects: make(map[uuid.UUID][]time.Time),
+	}
+}
+
+func TestReconnectTracker_BacksOffFlappingUser(t *testing.T) {
+	tracker := newTestReconnectTracker()
+	userID := uuid.New()
+	now := time.Now()
+
+	var decisions []ReconnectDecision
+	for i := range 8 {
+		decisions = append(decisions, tracker.record(userID, now.Add(time.Duration(i)*time.Second)))
+	}
+
+	for i, d := range decisions[:3] {
+		assert.False(t, d.Flapping, "connect %d", i+1)
+		assert.Equal(t, time.Second, d.Retry, "connect %d", i+1)
+	}
+	for i, d := range decisions[3:6] {
+		assert.True(t, d.Flapping, "connect %d", i+4)
+		assert.False(t, d.Reject, "connect %d", i+4)
+	}
+	// Doubling per excess connect, less up to 20% jitter
+	assert.InDelta(t, 2*time.Second, decisions[3].Retry, float64(400*time.Millisecond))
+	assert.InDelta(t, 8*time.Second, decisions[5].Retry, float64(1600*time.Millisecond))
+	// Past twice the limit connects are refused, with the hint capped
+	assert.True(t, decisions[6].Reject)
+	assert.LessOrEqual(t, decisions[7].Retry, 10*time.Second)
+}
+
+func TestReconnectTracker_ForgetsOldConnects(t *testing.T) {
+	tracker := newTestReconnectTracker()
+	userID, otherID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	tracker.record(otherID, now)
+	for i := range 3 {
+		tracker.record(userID, now.Add(time.Duration(i)*time.Second))
+	}
+
+	later := now.Add(2 * time.Minute)
+	assert.False(t, tracker.record(userID, later).Flapping)
+	assert.NotContains(t, tracker.connects, otherID)
+}
+
+func TestReconnectTracker_OtherUsersUnaffected(t *testing.T) {
+	tracker := newTestReconnectTracker()
+	now := time.Now()
+
+	flapper := uuid.New()
+	for range 5 {
+		tracker.record(flapper, now)
+	}
+
+	assert.False(t, tracker.record(uuid.New(), now).Flapping)
+}
+
+func TestReconnectTracker_Disabled(t *testing.T) {
+	tracker := newTestReconnectTracker()
+	tracker.policy.MaxConnects = 0
+	userID := uuid.New()
+
+	for range 10 {
+		d := tracker.record(userID, time.Now())
+		assert.False(t, d.Flapping)
+		assert.Equal(t, time.Second, d.Retry)
+	}
+	assert.Empty(t, tracker.connects)
+}
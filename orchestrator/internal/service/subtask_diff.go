@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// MaxSubtaskDiffBytes caps the diff returned by GetDiff.
+const MaxSubtaskDiffBytes = 1 << 20 // 1MB
+
+// Diff sources reported in SubtaskDiff.Source.
+const (
+	DiffSourceWorktree = "worktree"
+	DiffSourceGitHub   = "github"
+)
+
+// SubtaskDiff is the diff of a subtask's branch against its task's base branch.
+type SubtaskDiff struct {
+	Base   string
+	Branch string
+	// Source is DiffSourceWorktree when the diff came from the subtask's
+	// worktree and DiffSourceGitHub when its branch was compared on GitHub.
+	Source string
+	Diff   string
+	// Size is the length in bytes of the full diff, which is larger than Diff
+	// when Truncated.
+	Size      int
+	Truncated bool
+}
+
+// GetDiff returns the diff of a subtask's branch against its task's base
+// branch so it can be reviewed before merging. The subtask's worktree is used
+// while it exists; once removed, the pushed branch is compared on GitHub with
+// githubToken, the user's decrypted token. Diffs over MaxSubtaskDiffBytes are
+// cut at the last whole line within the cap.
+func (s *SubtaskService) GetDiff(ctx context.Context, subtaskID, userID uuid.UUID, githubToken string) (*SubtaskDiff, error) {
+	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if subtask.BranchName == nil || *subtask.BranchName == "" {
+		return nil, domain.NewUnprocessableError("subtask", "subtask has no branch")
+	}
+
+	task, err := s.taskService.GetTaskByIDInternal(ctx, subtask.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SubtaskDiff{Base: task.BaseBranch, Branch: *subtask.BranchName}
+	var diff string
+	if subtask.WorktreePath != nil && worktreeExists(*subtask.WorktreePath) {
+		result.Source = DiffSourceWorktree
+		diff, err = s.githubService.GetDiff(ctx, *subtask.WorktreePath, task.BaseBranch)
+	} else {
+		project, projectErr := s.projectService.GetProject(ctx, task.ProjectID, userID)
+		if projectErr != nil {
+			return nil, projectErr
+		}
+		result.Source = DiffSourceGitHub
+		diff, err = s.githubService.GetBranchDiff(ctx, project.GitHubOwner, project.GitHubRepo, task.BaseBranch, *subtask.BranchName, githubToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Size = len(diff)
+	result.Diff, result.Truncated = truncateDiff(diff, MaxSubtaskDiffBytes)
+	return result, nil
+}
+
+// worktreeExists reports whether path is a directory still on disk.
+func worktreeExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// truncateDiff returns diff cut to at most limit bytes, ending at the last
+// newline within the limit so no line is split, and whether it was cut.
+func truncateDiff(diff string, limit int) (string, bool) {
+	if len(diff) <= limit {
+		return diff, false
+	}
+	cut := diff[:limit]
+	if i := strings.LastIndexByte(cut, '\n'); i >= 0 {
+		cut = cut[:i+1]
+	}
+	return cut, true
+}
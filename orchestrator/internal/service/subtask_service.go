@@ -7,9 +7,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
 
 	"github.com/intern-village/orchestrator/generated/db"
 	"github.com/intern-village/orchestrator/internal/domain"
@@ -24,22 +28,24 @@ type WorkerSpawner interface {
 
 // SubtaskService handles subtask management operations.
 type SubtaskService struct {
-	repo              *repository.Repository
+	repo              repository.SubtaskStore
 	taskService       *TaskService
 	dependencyService *DependencyService
-	beadsService      *BeadsService
+	beadsService      BeadsClient
 	projectService    *ProjectService
 	githubService     *GitHubService
 	workerSpawner     WorkerSpawner
+	quotas            *QuotaService
+	audit             *AuditService
 	eventHub          EventHub
 }
 
 // NewSubtaskService creates a new SubtaskService.
 func NewSubtaskService(
-	repo *repository.Repository,
+	repo repository.SubtaskStore,
 	taskService *TaskService,
 	dependencyService *DependencyService,
-	beadsService *BeadsService,
+	beadsService BeadsClient,
 	projectService *ProjectService,
 	githubService *GitHubService,
 	eventHub EventHub,
@@ -60,6 +66,29 @@ func (s *SubtaskService) SetWorkerSpawner(spawner WorkerSpawner) {
 	s.workerSpawner = spawner
 }
 
+// SetQuotaService sets the per-user quotas StartSubtask and RetrySubtask enforce.
+func (s *SubtaskService) SetQuotaService(quotas *QuotaService) {
+	s.quotas = quotas
+}
+
+// SetAuditService sets the audit log that user-triggered transitions are recorded in.
+func (s *SubtaskService) SetAuditService(audit *AuditService) {
+	s.audit = audit
+}
+
+// auditSubtask records a user-triggered subtask transition.
+func (s *SubtaskService) auditSubtask(ctx context.Context, projectID, userID uuid.UUID, action, oldStatus string, subtask *domain.Subtask) {
+	s.audit.Record(ctx, AuditEvent{
+		ProjectID:  projectID,
+		UserID:     userID,
+		Action:     action,
+		EntityType: AuditEntitySubtask,
+		EntityID:   subtask.ID,
+		OldStatus:  oldStatus,
+		NewStatus:  string(subtask.Status),
+	})
+}
+
 // CreateSubtaskInput contains the input for creating a subtask.
 type CreateSubtaskInput struct {
 	TaskID             uuid.UUID
@@ -67,6 +96,7 @@ type CreateSubtaskInput struct {
 	Spec               *string
 	ImplementationPlan *string
 	BeadsIssueID       *string
+	Priority           *int // nil means domain.DefaultSubtaskPriority
 }
 
 // CreateSubtask creates a new subtask (called by sync service).
@@ -77,6 +107,14 @@ func (s *SubtaskService) CreateSubtask(ctx context.Context, input CreateSubtaskI
 		return nil, fmt.Errorf("failed to get next position: %w", err)
 	}
 
+	priority := domain.DefaultSubtaskPriority
+	if input.Priority != nil {
+		if err := domain.ValidateSubtaskPriority(*input.Priority); err != nil {
+			return nil, err
+		}
+		priority = *input.Priority
+	}
+
 	// Create the subtask record
 	dbSubtask, err := s.repo.CreateSubtask(ctx, db.CreateSubtaskParams{
 		TaskID:             input.TaskID,
@@ -86,6 +124,7 @@ func (s *SubtaskService) CreateSubtask(ctx context.Context, input CreateSubtaskI
 		Status:             string(domain.SubtaskStatusPending),
 		Position:           position,
 		BeadsIssueID:       input.BeadsIssueID,
+		Priority:           int32(priority), //nolint:gosec // validated to be 0-4 above
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create subtask: %w", err)
@@ -120,6 +159,33 @@ func (s *SubtaskService) CheckSubtaskOwnership(ctx context.Context, subtaskID, u
 	return err
 }
 
+// checkUnmodified rejects a client mutation with a StaleError carrying the
+// subtask if the copy read for the request is no longer at expectedUpdatedAt.
+// It is an early exit before side effects such as a repository sync; the
+// mutating UPDATE itself carries the expected_updated_at predicate (see
+// staleOr), so of two requests made from the same copy only the first gets
+// through. A nil expectedUpdatedAt skips the check.
+func checkUnmodified(subtask *domain.Subtask, expectedUpdatedAt *time.Time) error {
+	if expectedUpdatedAt != nil && !subtask.UpdatedAt.Equal(*expectedUpdatedAt) {
+		return domain.NewStaleError("subtask", "subtask was modified since expected_updated_at", subtask)
+	}
+	return nil
+}
+
+// staleOr returns err, unless it is the no-rows result of an UPDATE guarded by
+// expectedUpdatedAt: the subtask changed after it was read, so a StaleError
+// carrying the current subtask is returned instead.
+func (s *SubtaskService) staleOr(ctx context.Context, subtaskID uuid.UUID, expectedUpdatedAt *time.Time, err error) error {
+	if expectedUpdatedAt == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	current, getErr := s.repo.GetSubtaskByID(ctx, subtaskID)
+	if getErr != nil {
+		return fmt.Errorf("failed to reload subtask: %w", getErr)
+	}
+	return domain.NewStaleError("subtask", "subtask was modified since expected_updated_at", dbSubtaskToDomain(current))
+}
+
 // ListSubtasks lists all subtasks for a task.
 func (s *SubtaskService) ListSubtasks(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Subtask, error) {
 	// Verify task access
@@ -141,8 +207,31 @@ func (s *SubtaskService) ListSubtasks(ctx context.Context, taskID, userID uuid.U
 	return result, nil
 }
 
+// BlockedBy returns the dependencies a subtask blocked with reason DEPENDENCY
+// is waiting on, and nil for any other subtask.
+func (s *SubtaskService) BlockedBy(ctx context.Context, subtask *domain.Subtask) ([]domain.BlockingDependency, error) {
+	if subtask.Status != domain.SubtaskStatusBlocked ||
+		subtask.BlockedReason == nil || *subtask.BlockedReason != domain.BlockedReasonDependency {
+		return nil, nil
+	}
+	return s.dependencyService.GetBlockingDependencies(ctx, subtask.ID)
+}
+
+// ListRecentRuns returns up to limit of the subtask's most recent agent runs,
+// latest attempt first. Callers are expected to have checked ownership.
+func (s *SubtaskService) ListRecentRuns(ctx context.Context, subtaskID uuid.UUID, limit int) ([]db.AgentRun, error) {
+	runs, err := s.repo.ListRecentAgentRunsBySubtask(ctx, db.ListRecentAgentRunsBySubtaskParams{
+		SubtaskID: pgtype.UUID{Bytes: subtaskID, Valid: true},
+		Limit:     int32(limit), //nolint:gosec // limit is a small handler constant
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent runs: %w", err)
+	}
+	return runs, nil
+}
+
 // StartSubtask starts a subtask by creating a worktree and spawning the Worker agent.
-func (s *SubtaskService) StartSubtask(ctx context.Context, subtaskID, userID uuid.UUID) (*domain.Subtask, error) {
+func (s *SubtaskService) StartSubtask(ctx context.Context, subtaskID, userID uuid.UUID, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
 	// Get subtask with ownership check
 	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
 	if err != nil {
@@ -181,15 +270,37 @@ func (s *SubtaskService) StartSubtask(ctx context.Context, subtaskID, userID uui
 	if err != nil {
 		return nil, err
 	}
+	if task.Status == domain.TaskStatusPaused {
+		return nil, domain.NewConflictError("subtask", "task is paused")
+	}
 
 	project, err := s.projectService.GetProject(ctx, task.ProjectID, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkUnmodified(subtask, expectedUpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAgentQuota(project); err != nil {
+		return nil, err
+	}
+
+	if err := s.projectService.CheckClone(project); err != nil {
+		return nil, err
+	}
+
+	// Claim the subtask before touching the worktree so only one concurrent
+	// start request proceeds; the status is restored if anything below fails
+	if err := s.claimSubtaskStart(ctx, subtask, expectedUpdatedAt); err != nil {
+		return nil, err
+	}
+
 	// Sync repository to latest before creating worktree (see §9.5 Repository Sync Strategy)
 	if s.githubService != nil {
-		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, project.DefaultBranch, project.IsFork, 3); err != nil {
+		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, task.BaseBranch, project.IsFork, 3); err != nil {
+			s.releaseSubtaskStart(ctx, subtask)
 			return nil, fmt.Errorf("failed to sync repository before starting subtask: %w", err)
 		}
 	}
@@ -204,38 +315,32 @@ func (s *SubtaskService) StartSubtask(ctx context.Context, subtaskID, userID uui
 	}
 	branchName := s.beadsService.GenerateBranchName(issueID, subtask.Title)
 
-	// Create worktree
-	err = s.beadsService.CreateWorktree(ctx, project.ClonePath, subtaskID.String(), branchName)
+	// Create worktree outside the clone so agents cannot touch sibling worktrees.
+	// The branch starts from the clone's HEAD, which the sync left on the task's base branch.
+	// A retry keeps the worktree of the previous attempt, or replaces one a crash left locked
+	worktreePath := s.projectService.WorktreePath(project.ID, subtaskID)
+	err = s.beadsService.EnsureWorktree(ctx, project.ClonePath, worktreePath, branchName)
 	if err != nil {
+		s.releaseSubtaskStart(ctx, subtask)
 		return nil, fmt.Errorf("failed to create worktree: %w", err)
 	}
-	worktreePath := fmt.Sprintf("%s/%s", project.ClonePath, subtaskID.String())
 
-	// Update subtask with branch info and status
-	_, err = s.repo.UpdateSubtaskBranch(ctx, db.UpdateSubtaskBranchParams{
+	// Update subtask with branch info
+	dbSubtask, err := s.repo.UpdateSubtaskBranch(ctx, db.UpdateSubtaskBranchParams{
 		ID:           subtaskID,
 		BranchName:   &branchName,
 		WorktreePath: &worktreePath,
 	})
 	if err != nil {
 		// Try to cleanup worktree on failure
-		_ = s.beadsService.RemoveWorktree(ctx, project.ClonePath, subtaskID.String())
+		_ = s.beadsService.RemoveWorktree(ctx, project.ClonePath, worktreePath)
+		s.releaseSubtaskStart(ctx, subtask)
 		return nil, fmt.Errorf("failed to update subtask branch: %w", err)
 	}
 
 	oldStatus := string(subtask.Status)
-
-	// Update status to IN_PROGRESS
-	dbSubtask, err := s.repo.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
-		ID:            subtaskID,
-		Status:        string(domain.SubtaskStatusInProgress),
-		BlockedReason: nil,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to update subtask status: %w", err)
-	}
-
 	updatedSubtask := dbSubtaskToDomain(dbSubtask)
+	s.auditSubtask(ctx, project.ID, userID, AuditActionSubtaskStart, oldStatus, updatedSubtask)
 
 	// Publish subtask:status_changed event
 	if s.eventHub != nil {
@@ -244,19 +349,114 @@ func (s *SubtaskService) StartSubtask(ctx context.Context, subtaskID, userID uui
 
 	// Spawn Worker agent asynchronously
 	if s.workerSpawner != nil {
-		go func() {
-			bgCtx := context.Background()
-			if err := s.workerSpawner.SpawnWorker(bgCtx, updatedSubtask, project); err != nil {
-				fmt.Printf("failed to spawn worker for subtask %s: %v\n", subtaskID, err)
-			}
-		}()
+		go s.spawnWorker(updatedSubtask, project, subtask)
 	}
 
 	return updatedSubtask, nil
 }
 
+// spawnWorker spawns the Worker for an IN_PROGRESS subtask. Run it in a
+// goroutine: there is no caller to return an error to, so a refused or failed
+// spawn (e.g. ErrAgentAlreadyRunning while a previous Worker is still winding
+// down) is published as agent:failed and the subtask is blocked with reason
+// FAILURE, where it can be retried, rather than left IN_PROGRESS with no Worker.
+// A refusal for the concurrent agent quota, which a request lost to a
+// concurrent one after passing the check up front, instead restores prev, the
+// subtask as it was before the request.
+func (s *SubtaskService) spawnWorker(subtask *domain.Subtask, project *domain.Project, prev *domain.Subtask) {
+	ctx := context.Background()
+	err := s.workerSpawner.SpawnWorker(ctx, subtask, project)
+	if err == nil {
+		return
+	}
+
+	log.Error().Err(err).
+		Str("subtask_id", subtask.ID.String()).
+		Bool("already_running", errors.Is(err, ErrAgentAlreadyRunning)).
+		Msg("failed to spawn worker")
+
+	if s.eventHub != nil {
+		run := &domain.AgentRun{SubtaskID: &subtask.ID, AgentType: domain.AgentTypeWorker}
+		s.eventHub.PublishAgentFailed(project.ID, run, subtask.TaskID, fmt.Sprintf("failed to start worker: %v", err), false, nil)
+	}
+
+	status := domain.SubtaskStatusBlocked
+	reason := string(domain.BlockedReasonFailure)
+	blockedReason := &reason
+	if domain.IsQuotaExceeded(err) {
+		status = prev.Status
+		blockedReason = nil
+		if prev.BlockedReason != nil {
+			prevReason := string(*prev.BlockedReason)
+			blockedReason = &prevReason
+		}
+	}
+
+	dbSubtask, err := s.repo.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
+		ID:            subtask.ID,
+		Status:        string(status),
+		BlockedReason: blockedReason,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to update subtask after spawn failure")
+		return
+	}
+	if s.eventHub != nil {
+		s.eventHub.PublishSubtaskStatusChanged(project.ID, dbSubtaskToDomain(dbSubtask), string(subtask.Status))
+	}
+}
+
+// checkAgentQuota fails with a quota error if the project owner cannot run
+// another agent, so a start or retry is refused before the subtask changes.
+func (s *SubtaskService) checkAgentQuota(project *domain.Project) error {
+	if s.quotas == nil {
+		return nil
+	}
+	return s.quotas.CheckConcurrentAgents(project.UserID)
+}
+
+// claimSubtaskStart atomically moves a subtask from the status it was read in
+// to IN_PROGRESS. If another request changed the status in the meantime (e.g.
+// a concurrent start), a conflict error is returned, or a StaleError when the
+// client sent expectedUpdatedAt.
+func (s *SubtaskService) claimSubtaskStart(ctx context.Context, subtask *domain.Subtask, expectedUpdatedAt *time.Time) error {
+	_, err := s.repo.ClaimSubtaskForStart(ctx, db.ClaimSubtaskForStartParams{
+		ID:                subtask.ID,
+		Status:            string(subtask.Status),
+		ExpectedUpdatedAt: expectedUpdatedAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) && expectedUpdatedAt == nil {
+		return domain.NewConflictError("subtask", "subtask is already being started")
+	}
+	if err != nil {
+		return s.staleOr(ctx, subtask.ID, expectedUpdatedAt, fmt.Errorf("failed to claim subtask: %w", err))
+	}
+	return nil
+}
+
+// releaseSubtaskStart restores the status a subtask had before
+// claimSubtaskStart, after a start attempt failed partway through.
+func (s *SubtaskService) releaseSubtaskStart(ctx context.Context, subtask *domain.Subtask) {
+	var blockedReason *string
+	if subtask.BlockedReason != nil {
+		reason := string(*subtask.BlockedReason)
+		blockedReason = &reason
+	}
+
+	_, err := s.repo.UpdateSubtaskStatus(context.WithoutCancel(ctx), db.UpdateSubtaskStatusParams{
+		ID:            subtask.ID,
+		Status:        string(subtask.Status),
+		BlockedReason: blockedReason,
+	})
+	if err != nil {
+		log.Error().Err(err).
+			Str("subtask_id", subtask.ID.String()).
+			Msg("failed to restore subtask status after failed start")
+	}
+}
+
 // MarkMerged marks a subtask as merged after the user confirms the PR was merged.
-func (s *SubtaskService) MarkMerged(ctx context.Context, subtaskID, userID uuid.UUID) (*domain.Subtask, error) {
+func (s *SubtaskService) MarkMerged(ctx context.Context, subtaskID, userID uuid.UUID, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
 	// Get subtask with ownership check
 	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
 	if err != nil {
@@ -288,62 +488,97 @@ func (s *SubtaskService) MarkMerged(ctx context.Context, subtaskID, userID uuid.
 
 	// Update status to MERGED
 	dbSubtask, err := s.repo.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
-		ID:            subtaskID,
-		Status:        string(domain.SubtaskStatusMerged),
-		BlockedReason: nil,
+		ID:                subtaskID,
+		Status:            string(domain.SubtaskStatusMerged),
+		BlockedReason:     nil,
+		ExpectedUpdatedAt: expectedUpdatedAt,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update subtask status: %w", err)
+		return nil, s.staleOr(ctx, subtaskID, expectedUpdatedAt, fmt.Errorf("failed to update subtask status: %w", err))
 	}
 
 	mergedSubtask := dbSubtaskToDomain(dbSubtask)
+	s.auditSubtask(ctx, project.ID, userID, AuditActionSubtaskMarkMerged, oldStatus, mergedSubtask)
 
 	// Publish subtask:status_changed event
 	if s.eventHub != nil {
 		s.eventHub.PublishSubtaskStatusChanged(project.ID, mergedSubtask, oldStatus)
 	}
 
+	s.finishMerge(ctx, project, task, subtask, mergedSubtask)
+	return mergedSubtask, nil
+}
+
+// finishMerge runs the steps that follow a subtask's move to MERGED: closing
+// its beads issue, then finishResolved. They are best-effort: the PR is merged
+// whatever happens here, so failures are logged and published as
+// operation:failed instead of returned.
+func (s *SubtaskService) finishMerge(ctx context.Context, project *domain.Project, task *domain.Task, subtask, mergedSubtask *domain.Subtask) {
 	// Close beads issue
 	if subtask.BeadsIssueID != nil {
 		if err := s.beadsService.CloseIssue(ctx, project.ClonePath, *subtask.BeadsIssueID, "Merged"); err != nil {
-			// Log but don't fail
-			fmt.Printf("failed to close beads issue %s: %v\n", *subtask.BeadsIssueID, err)
+			log.Error().Err(err).
+				Str("subtask_id", subtask.ID.String()).
+				Str("beads_issue_id", *subtask.BeadsIssueID).
+				Msg("failed to close beads issue after merge")
+			s.publishOperationFailed(project.ID, mergedSubtask, OperationCloseBeadsIssue, err)
 		}
 	}
 
+	s.finishResolved(ctx, project, task, subtask, mergedSubtask)
+}
+
+// finishResolved runs the steps that follow a subtask's move to a resolved
+// status (see IsResolved) with its work done: unblocking its dependents,
+// checking whether the task is complete, and removing its worktree. Like
+// finishMerge, failures are logged and published as operation:failed.
+func (s *SubtaskService) finishResolved(ctx context.Context, project *domain.Project, task *domain.Task, subtask, resolvedSubtask *domain.Subtask) {
 	// Unblock dependents
-	unblocked, err := s.dependencyService.UnblockDependents(ctx, subtaskID)
+	unblocked, err := s.dependencyService.UnblockDependents(ctx, subtask.ID)
 	if err != nil {
-		// Log but don't fail
-		fmt.Printf("failed to unblock dependents for subtask %s: %v\n", subtaskID, err)
+		log.Error().Err(err).Str("subtask_id", subtask.ID.String()).Msg("failed to unblock dependents")
+		s.publishOperationFailed(project.ID, resolvedSubtask, OperationUnblockDependents, err)
 	}
 	if len(unblocked) > 0 {
-		fmt.Printf("unblocked %d dependents for subtask %s\n", len(unblocked), subtaskID)
+		log.Info().
+			Str("subtask_id", subtask.ID.String()).
+			Int("unblocked", len(unblocked)).
+			Msg("unblocked dependent subtasks")
 	}
 
 	// Check if task is complete
 	completed, err := s.taskService.CheckTaskCompletion(ctx, task.ID)
 	if err != nil {
-		// Log but don't fail
-		fmt.Printf("failed to check task completion for %s: %v\n", task.ID, err)
+		log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to check task completion")
+		s.publishOperationFailed(project.ID, resolvedSubtask, OperationCheckCompletion, err)
 	}
 	if completed {
-		fmt.Printf("task %s is now complete\n", task.ID)
+		log.Info().Str("task_id", task.ID.String()).Msg("task is now complete")
 	}
 
-	// Cleanup worktree
+	// Cleanup worktree (the stored path also covers legacy worktrees inside the clone)
 	if subtask.WorktreePath != nil {
-		if err := s.beadsService.RemoveWorktree(ctx, project.ClonePath, subtaskID.String()); err != nil {
-			// Log but don't fail
-			fmt.Printf("failed to remove worktree for subtask %s: %v\n", subtaskID, err)
+		if err := s.beadsService.RemoveWorktree(ctx, project.ClonePath, *subtask.WorktreePath); err != nil {
+			log.Warn().Err(err).
+				Str("subtask_id", subtask.ID.String()).
+				Str("worktree_path", *subtask.WorktreePath).
+				Msg("failed to remove worktree")
+			s.publishOperationFailed(project.ID, resolvedSubtask, OperationRemoveWorktree, err)
 		}
 	}
+}
 
-	return mergedSubtask, nil
+// publishOperationFailed reports a failed best-effort step for a subtask.
+func (s *SubtaskService) publishOperationFailed(projectID uuid.UUID, subtask *domain.Subtask, operation string, err error) {
+	if s.eventHub != nil {
+		s.eventHub.PublishOperationFailed(projectID, subtask.TaskID, &subtask.ID, operation, err.Error())
+	}
 }
 
 // RetrySubtask retries a failed subtask by resetting it and spawning the Worker agent.
-func (s *SubtaskService) RetrySubtask(ctx context.Context, subtaskID, userID uuid.UUID) (*domain.Subtask, error) {
+// If GitHub reports the subtask's PR already merged, the subtask is moved to
+// MERGED instead. githubToken is the user's decrypted token; empty skips the check.
+func (s *SubtaskService) RetrySubtask(ctx context.Context, subtaskID, userID uuid.UUID, githubToken string, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
 	// Get subtask with ownership check
 	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
 	if err != nil {
@@ -355,9 +590,12 @@ func (s *SubtaskService) RetrySubtask(ctx context.Context, subtaskID, userID uui
 		return nil, domain.NewUnprocessableError("subtask", "can only retry BLOCKED subtasks")
 	}
 
-	// Validate blocked reason is FAILURE
-	if subtask.BlockedReason == nil || *subtask.BlockedReason != domain.BlockedReasonFailure {
-		return nil, domain.NewUnprocessableError("subtask", "can only retry subtasks blocked due to failure")
+	// Validate blocked reason is FAILURE, BUDGET_EXCEEDED, STOPPED or POLICY_VIOLATION
+	if subtask.BlockedReason == nil || !subtask.BlockedReason.IsRetryable() {
+		return nil, domain.NewUnprocessableError("subtask", "can only retry subtasks blocked due to failure, an exceeded token budget, a stopped Worker or a path policy violation")
+	}
+	if *subtask.BlockedReason == domain.BlockedReasonBudgetExceeded && subtask.TokenBudget != nil && subtask.TokenUsage >= *subtask.TokenBudget {
+		return nil, domain.NewUnprocessableError("subtask", "raise the token budget before retrying")
 	}
 
 	// Get task and project for spawning worker
@@ -365,26 +603,52 @@ func (s *SubtaskService) RetrySubtask(ctx context.Context, subtaskID, userID uui
 	if err != nil {
 		return nil, err
 	}
+	if task.Status == domain.TaskStatusPaused {
+		return nil, domain.NewConflictError("subtask", "task is paused")
+	}
 
 	project, err := s.projectService.GetProject(ctx, task.ProjectID, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkUnmodified(subtask, expectedUpdatedAt); err != nil {
+		return nil, err
+	}
+
+	// A PR merged outside the orchestrator means the work is done: record the
+	// merge instead of running a Worker over it again
+	pr, err := s.mergedPR(ctx, subtask, project, githubToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether the subtask's PR is merged: %w", err)
+	}
+	if pr != nil {
+		return s.markMergedOnRetry(ctx, subtask, task, project, userID, pr, expectedUpdatedAt)
+	}
+
+	if err := s.checkAgentQuota(project); err != nil {
+		return nil, err
+	}
+
+	if err := s.projectService.CheckClone(project); err != nil {
+		return nil, err
+	}
+
 	// Sync repository to latest before retrying (see §9.5 Repository Sync Strategy)
 	if s.githubService != nil {
-		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, project.DefaultBranch, project.IsFork, 3); err != nil {
+		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, task.BaseBranch, project.IsFork, 3); err != nil {
 			return nil, fmt.Errorf("failed to sync repository before retrying subtask: %w", err)
 		}
 	}
 
 	// Reset retry count
 	_, err = s.repo.UpdateSubtaskRetryCount(ctx, db.UpdateSubtaskRetryCountParams{
-		ID:         subtaskID,
-		RetryCount: 0,
+		ID:                subtaskID,
+		RetryCount:        0,
+		ExpectedUpdatedAt: expectedUpdatedAt,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to reset retry count: %w", err)
+		return nil, s.staleOr(ctx, subtaskID, expectedUpdatedAt, fmt.Errorf("failed to reset retry count: %w", err))
 	}
 
 	oldStatus := string(subtask.Status)
@@ -400,6 +664,7 @@ func (s *SubtaskService) RetrySubtask(ctx context.Context, subtaskID, userID uui
 	}
 
 	updatedSubtask := dbSubtaskToDomain(dbSubtask)
+	s.auditSubtask(ctx, project.ID, userID, AuditActionSubtaskRetry, oldStatus, updatedSubtask)
 
 	// Publish subtask:status_changed event
 	if s.eventHub != nil {
@@ -408,21 +673,87 @@ func (s *SubtaskService) RetrySubtask(ctx context.Context, subtaskID, userID uui
 
 	// Spawn Worker agent asynchronously
 	if s.workerSpawner != nil {
-		go func() {
-			bgCtx := context.Background()
-			if err := s.workerSpawner.SpawnWorker(bgCtx, updatedSubtask, project); err != nil {
-				fmt.Printf("failed to spawn worker for subtask %s: %v\n", subtaskID, err)
-			}
-		}()
+		go s.spawnWorker(updatedSubtask, project, subtask)
 	}
 
 	return updatedSubtask, nil
 }
 
+// mergedPR returns the subtask's pull request if GitHub reports it merged, and
+// nil if it is not merged or the subtask never had one. A subtask without a
+// recorded PR is looked up by its branch, since its PR may have been opened by hand.
+func (s *SubtaskService) mergedPR(ctx context.Context, subtask *domain.Subtask, project *domain.Project, githubToken string) (*PRStatus, error) {
+	if s.githubService == nil || githubToken == "" {
+		return nil, nil
+	}
+
+	var pr *PRStatus
+	var err error
+	switch {
+	case subtask.PRNumber != nil && *subtask.PRNumber > 0:
+		pr, err = s.githubService.GetPRStatus(ctx, project.GitHubOwner, project.GitHubRepo, *subtask.PRNumber, githubToken)
+	case subtask.BranchName != nil && *subtask.BranchName != "":
+		pr, err = s.githubService.FindBranchPR(ctx, project.GitHubOwner, project.GitHubRepo, *subtask.BranchName, githubToken)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil || !pr.Merged {
+		return nil, nil
+	}
+	return pr, nil
+}
+
+// markMergedOnRetry moves a subtask whose retry found its PR already merged
+// to MERGED, recording the PR if it was found by branch, and finishes the
+// merge as MarkMerged does.
+func (s *SubtaskService) markMergedOnRetry(ctx context.Context, subtask *domain.Subtask, task *domain.Task, project *domain.Project, userID uuid.UUID, pr *PRStatus, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
+	oldStatus := string(subtask.Status)
+	dbSubtask, err := s.repo.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
+		ID:                subtask.ID,
+		Status:            string(domain.SubtaskStatusMerged),
+		BlockedReason:     nil,
+		ExpectedUpdatedAt: expectedUpdatedAt,
+	})
+	if err != nil {
+		return nil, s.staleOr(ctx, subtask.ID, expectedUpdatedAt, fmt.Errorf("failed to update subtask status: %w", err))
+	}
+
+	if subtask.PRNumber == nil {
+		//nolint:gosec // PR numbers from GitHub are always within int32 range
+		prNum := int32(pr.Number)
+		dbSubtask, err = s.repo.UpdateSubtaskPR(ctx, db.UpdateSubtaskPRParams{
+			ID:       subtask.ID,
+			PrUrl:    &pr.HTMLURL,
+			PrNumber: &prNum,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update PR info: %w", err)
+		}
+	}
+
+	mergedSubtask := dbSubtaskToDomain(dbSubtask)
+	s.auditSubtask(ctx, project.ID, userID, AuditActionSubtaskRetry, oldStatus, mergedSubtask)
+	log.Info().
+		Str("subtask_id", subtask.ID.String()).
+		Int("pr_number", pr.Number).
+		Msg("subtask PR already merged; marked merged instead of retrying")
+
+	// Publish subtask:status_changed event
+	if s.eventHub != nil {
+		s.eventHub.PublishSubtaskStatusChanged(project.ID, mergedSubtask, oldStatus)
+	}
+
+	s.finishMerge(ctx, project, task, subtask, mergedSubtask)
+	return mergedSubtask, nil
+}
+
 // UpdatePosition updates the position of a subtask (for drag-and-drop reordering).
-func (s *SubtaskService) UpdatePosition(ctx context.Context, subtaskID, userID uuid.UUID, position int) (*domain.Subtask, error) {
+func (s *SubtaskService) UpdatePosition(ctx context.Context, subtaskID, userID uuid.UUID, position int, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
 	// Get subtask with ownership check
-	_, err := s.GetSubtask(ctx, subtaskID, userID)
+	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -430,11 +761,65 @@ func (s *SubtaskService) UpdatePosition(ctx context.Context, subtaskID, userID u
 	// Update position
 	//nolint:gosec // position is validated to be non-negative and bounded by UI
 	dbSubtask, err := s.repo.UpdateSubtaskPosition(ctx, db.UpdateSubtaskPositionParams{
-		ID:       subtaskID,
-		Position: int32(position),
+		ID:                subtask.ID,
+		Position:          int32(position),
+		ExpectedUpdatedAt: expectedUpdatedAt,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update position: %w", err)
+		return nil, s.staleOr(ctx, subtask.ID, expectedUpdatedAt, fmt.Errorf("failed to update position: %w", err))
+	}
+
+	return dbSubtaskToDomain(dbSubtask), nil
+}
+
+// SetPriority sets the subtask's priority, from 0 (most urgent) to 4.
+func (s *SubtaskService) SetPriority(ctx context.Context, subtaskID, userID uuid.UUID, priority int, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
+	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := domain.ValidateSubtaskPriority(priority); err != nil {
+		return nil, err
+	}
+
+	dbSubtask, err := s.repo.UpdateSubtaskPriority(ctx, db.UpdateSubtaskPriorityParams{
+		ID:                subtask.ID,
+		Priority:          int32(priority), //nolint:gosec // validated to be 0-4 above
+		ExpectedUpdatedAt: expectedUpdatedAt,
+	})
+	if err != nil {
+		return nil, s.staleOr(ctx, subtask.ID, expectedUpdatedAt, fmt.Errorf("failed to update priority: %w", err))
+	}
+
+	return dbSubtaskToDomain(dbSubtask), nil
+}
+
+// SetTokenBudget sets the most tokens the subtask's Workers may use across
+// all attempts. nil removes the budget. A running Worker picks up the new
+// budget after its current attempt.
+func (s *SubtaskService) SetTokenBudget(ctx context.Context, subtaskID, userID uuid.UUID, budget *int, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
+	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if budget != nil && (*budget <= 0 || *budget > math.MaxInt32) {
+		return nil, domain.NewUnprocessableError("subtask", fmt.Sprintf("token budget must be between 1 and %d", math.MaxInt32))
+	}
+
+	var tokenBudget *int32
+	if budget != nil {
+		b := int32(*budget) //nolint:gosec // validated to fit above
+		tokenBudget = &b
+	}
+	dbSubtask, err := s.repo.UpdateSubtaskTokenBudget(ctx, db.UpdateSubtaskTokenBudgetParams{
+		ID:                subtask.ID,
+		TokenBudget:       tokenBudget,
+		ExpectedUpdatedAt: expectedUpdatedAt,
+	})
+	if err != nil {
+		return nil, s.staleOr(ctx, subtask.ID, expectedUpdatedAt, fmt.Errorf("failed to update token budget: %w", err))
 	}
 
 	return dbSubtaskToDomain(dbSubtask), nil
@@ -483,8 +868,65 @@ func (s *SubtaskService) MarkCompleted(ctx context.Context, subtaskID uuid.UUID,
 	return nil
 }
 
+// MarkCompletedNoChanges marks a subtask whose Worker succeeded without
+// committing anything, e.g. because the change was already present (called by
+// agent loop). There is no PR to wait for, so the subtask is resolved at once:
+// its dependents are unblocked and the task's completion is checked as after
+// a merge.
+func (s *SubtaskService) MarkCompletedNoChanges(ctx context.Context, subtaskID uuid.UUID) error {
+	oldSubtask, err := s.repo.GetSubtaskByID(ctx, subtaskID)
+	if err != nil {
+		return fmt.Errorf("failed to get subtask: %w", err)
+	}
+
+	dbSubtask, err := s.repo.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
+		ID:            subtaskID,
+		Status:        string(domain.SubtaskStatusCompletedNoChanges),
+		BlockedReason: nil,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update subtask status: %w", err)
+	}
+	resolvedSubtask := dbSubtaskToDomain(dbSubtask)
+
+	task, err := s.taskService.GetTaskByIDInternal(ctx, dbSubtask.TaskID)
+	if err != nil {
+		return err
+	}
+	project, err := s.projectService.GetProjectByIDInternal(ctx, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	// Publish subtask:status_changed event
+	if s.eventHub != nil {
+		s.eventHub.PublishSubtaskStatusChanged(project.ID, resolvedSubtask, oldSubtask.Status)
+	}
+
+	s.finishResolved(ctx, project, task, dbSubtaskToDomain(oldSubtask), resolvedSubtask)
+	return nil
+}
+
 // MarkFailed marks a subtask as blocked due to failure (called by agent loop after max retries).
 func (s *SubtaskService) MarkFailed(ctx context.Context, subtaskID uuid.UUID) error {
+	return s.markBlocked(ctx, subtaskID, domain.BlockedReasonFailure)
+}
+
+// MarkBudgetExceeded blocks a subtask whose Workers have used up its token
+// budget (called by agent loop instead of retrying).
+func (s *SubtaskService) MarkBudgetExceeded(ctx context.Context, subtaskID uuid.UUID) error {
+	return s.markBlocked(ctx, subtaskID, domain.BlockedReasonBudgetExceeded)
+}
+
+// MarkPolicyViolation blocks a subtask whose Worker modified paths the
+// project's path policy forbids (called by agent loop instead of opening a PR).
+func (s *SubtaskService) MarkPolicyViolation(ctx context.Context, subtaskID uuid.UUID) error {
+	return s.markBlocked(ctx, subtaskID, domain.BlockedReasonPolicyViolation)
+}
+
+// markBlocked moves a subtask to BLOCKED for reason and publishes
+// subtask:status_changed.
+func (s *SubtaskService) markBlocked(ctx context.Context, subtaskID uuid.UUID, blockedReason domain.BlockedReason) error {
 	// Get the subtask first to capture old status and find project ID
 	oldSubtask, err := s.repo.GetSubtaskByID(ctx, subtaskID)
 	if err != nil {
@@ -492,7 +934,7 @@ func (s *SubtaskService) MarkFailed(ctx context.Context, subtaskID uuid.UUID) er
 	}
 	oldStatus := oldSubtask.Status
 
-	reason := string(domain.BlockedReasonFailure)
+	reason := string(blockedReason)
 	dbSubtask, err := s.repo.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
 		ID:            subtaskID,
 		Status:        string(domain.SubtaskStatusBlocked),
@@ -534,17 +976,52 @@ func (s *SubtaskService) IncrementRetryCount(ctx context.Context, subtaskID uuid
 	return int(newCount), nil
 }
 
-// UpdateTokenUsage adds to the token usage for a subtask.
-func (s *SubtaskService) UpdateTokenUsage(ctx context.Context, subtaskID uuid.UUID, tokens int) error {
+// DecrementRetryCount gives back the retry counted for an attempt that did
+// not run to an outcome, such as one interrupted by a shutdown. The count
+// does not go below zero.
+func (s *SubtaskService) DecrementRetryCount(ctx context.Context, subtaskID uuid.UUID) (int, error) {
+	subtask, err := s.repo.GetSubtaskByID(ctx, subtaskID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get subtask: %w", err)
+	}
+
+	newCount := max(subtask.RetryCount-1, 0)
+	_, err = s.repo.UpdateSubtaskRetryCount(ctx, db.UpdateSubtaskRetryCountParams{
+		ID:         subtaskID,
+		RetryCount: newCount,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update retry count: %w", err)
+	}
+
+	return int(newCount), nil
+}
+
+// SetNextAttemptAt records when a backing-off Worker will next try the
+// subtask. nil clears it; starting an attempt or changing status also does.
+func (s *SubtaskService) SetNextAttemptAt(ctx context.Context, subtaskID uuid.UUID, at *time.Time) error {
+	_, err := s.repo.UpdateSubtaskNextAttemptAt(ctx, db.UpdateSubtaskNextAttemptAtParams{
+		ID:            subtaskID,
+		NextAttemptAt: repository.PointerToTimestamptz(at),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update next attempt time: %w", err)
+	}
+	return nil
+}
+
+// UpdateTokenUsage adds to the token usage for a subtask and returns the
+// updated subtask, whose TokenUsage is the total across all attempts.
+func (s *SubtaskService) UpdateTokenUsage(ctx context.Context, subtaskID uuid.UUID, tokens int) (*domain.Subtask, error) {
 	//nolint:gosec // token counts are always positive and bounded
-	_, err := s.repo.UpdateSubtaskTokenUsage(ctx, db.UpdateSubtaskTokenUsageParams{
+	dbSubtask, err := s.repo.UpdateSubtaskTokenUsage(ctx, db.UpdateSubtaskTokenUsageParams{
 		ID:         subtaskID,
 		TokenUsage: int32(tokens),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update token usage: %w", err)
+		return nil, fmt.Errorf("failed to update token usage: %w", err)
 	}
-	return nil
+	return dbSubtaskToDomain(dbSubtask), nil
 }
 
 // GetSubtaskByIDInternal retrieves a subtask by ID without ownership check.
@@ -623,6 +1100,12 @@ func dbSubtaskToDomain(s db.Subtask) *domain.Subtask {
 		prNumber = &n
 	}
 
+	var tokenBudget *int
+	if s.TokenBudget != nil {
+		b := int(*s.TokenBudget)
+		tokenBudget = &b
+	}
+
 	return &domain.Subtask{
 		ID:                 s.ID,
 		TaskID:             s.TaskID,
@@ -641,5 +1124,8 @@ func dbSubtaskToDomain(s db.Subtask) *domain.Subtask {
 		WorktreePath:       s.WorktreePath,
 		CreatedAt:          s.CreatedAt,
 		UpdatedAt:          s.UpdatedAt,
+		NextAttemptAt:      repository.TimestamptzToPointer(s.NextAttemptAt),
+		TokenBudget:        tokenBudget,
+		Priority:           int(s.Priority),
 	}
 }
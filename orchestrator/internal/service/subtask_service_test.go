@@ -0,0 +1,840 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+	"github.com/intern-village/orchestrator/internal/repository/repotest"
+)
+
+// claimDB is a minimal DBTX that tracks subtask statuses. It emulates the
+// conditional UPDATE behind ClaimSubtaskForStart (the row is only returned if
+// the status and any expected updated_at still match), UpdateSubtaskStatus,
+// and GetSubtaskByID. Updates bump updated_at.
+type claimDB struct {
+	mu        sync.Mutex
+	status    map[uuid.UUID]string
+	reason    map[uuid.UUID]*string
+	updatedAt map[uuid.UUID]time.Time
+}
+
+type claimRow struct {
+	id        uuid.UUID
+	status    string
+	reason    *string
+	updatedAt time.Time
+	err       error
+}
+
+func (r claimRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*uuid.UUID) = r.id
+	*dest[5].(*string) = r.status
+	*dest[6].(**string) = r.reason
+	*dest[16].(*time.Time) = r.updatedAt
+	return nil
+}
+
+func (d *claimDB) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	id := args[0].(uuid.UUID)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch {
+	case strings.Contains(sql, "name: ClaimSubtaskForStart"):
+		if d.status[id] != args[1].(string) || !d.unmodified(id, args[2].(*time.Time)) {
+			return claimRow{err: pgx.ErrNoRows}
+		}
+		d.status[id] = string(domain.SubtaskStatusInProgress)
+		d.touch(id)
+	case strings.Contains(sql, "name: UpdateSubtaskStatus"):
+		if !d.unmodified(id, args[3].(*time.Time)) {
+			return claimRow{err: pgx.ErrNoRows}
+		}
+		if d.reason == nil {
+			d.reason = map[uuid.UUID]*string{}
+		}
+		d.status[id] = args[1].(string)
+		d.reason[id] = args[2].(*string)
+		d.touch(id)
+	case strings.Contains(sql, "name: GetSubtaskByID"):
+	default:
+		return claimRow{err: errors.New("unexpected query")}
+	}
+	return claimRow{id: id, status: d.status[id], reason: d.reason[id], updatedAt: d.updatedAt[id]}
+}
+
+func (d *claimDB) unmodified(id uuid.UUID, expectedUpdatedAt *time.Time) bool {
+	return expectedUpdatedAt == nil || d.updatedAt[id].Equal(*expectedUpdatedAt)
+}
+
+func (d *claimDB) touch(id uuid.UUID) {
+	if d.updatedAt == nil {
+		d.updatedAt = map[uuid.UUID]time.Time{}
+	}
+	d.updatedAt[id] = d.updatedAt[id].Add(time.Millisecond)
+}
+
+func (d *claimDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("unexpected exec")
+}
+
+func (d *claimDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (d *claimDB) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	return nil, errors.New("unexpected transaction")
+}
+
+func TestSubtaskService_ClaimSubtaskStart_Concurrent(t *testing.T) {
+	subtask := &domain.Subtask{ID: uuid.New(), Status: domain.SubtaskStatusReady}
+	fake := &claimDB{status: map[uuid.UUID]string{subtask.ID: string(domain.SubtaskStatusReady)}}
+	s := &SubtaskService{repo: repository.New(fake)}
+
+	const callers = 20
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.claimSubtaskStart(context.Background(), subtask, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	winners := 0
+	for _, err := range errs {
+		if err == nil {
+			winners++
+			continue
+		}
+		if !domain.IsConflict(err) {
+			t.Errorf("loser should get a conflict error, got %v", err)
+		}
+	}
+	if winners != 1 {
+		t.Errorf("expected exactly one winner, got %d", winners)
+	}
+	if got := fake.status[subtask.ID]; got != string(domain.SubtaskStatusInProgress) {
+		t.Errorf("status = %s, want IN_PROGRESS", got)
+	}
+}
+
+func TestSubtaskService_ClaimSubtaskStart_StatusChanged(t *testing.T) {
+	// The subtask was read as READY but has since moved on
+	subtask := &domain.Subtask{ID: uuid.New(), Status: domain.SubtaskStatusReady}
+	fake := &claimDB{status: map[uuid.UUID]string{subtask.ID: string(domain.SubtaskStatusCompleted)}}
+	s := &SubtaskService{repo: repository.New(fake)}
+
+	if err := s.claimSubtaskStart(context.Background(), subtask, nil); !domain.IsConflict(err) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if got := fake.status[subtask.ID]; got != string(domain.SubtaskStatusCompleted) {
+		t.Errorf("status should be unchanged, got %s", got)
+	}
+}
+
+// refusingSpawner is a WorkerSpawner that always refuses, as AgentManager does
+// while a Worker is still registered for the subtask.
+type refusingSpawner struct{}
+
+func (refusingSpawner) SpawnWorker(ctx context.Context, subtask *domain.Subtask, project *domain.Project) error {
+	return fmt.Errorf("%w: worker for subtask %s", ErrAgentAlreadyRunning, subtask.ID)
+}
+
+func (refusingSpawner) KillAgentsForSubtask(ctx context.Context, subtaskID uuid.UUID) error {
+	return nil
+}
+
+func TestSubtaskService_SpawnWorker_AlreadyRunning(t *testing.T) {
+	subtask := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Status: domain.SubtaskStatusInProgress}
+	project := &domain.Project{ID: uuid.New()}
+	fake := &claimDB{status: map[uuid.UUID]string{subtask.ID: string(domain.SubtaskStatusInProgress)}}
+	hub := &mockEventHub{}
+	s := &SubtaskService{repo: repository.New(fake), workerSpawner: refusingSpawner{}, eventHub: hub}
+
+	s.spawnWorker(subtask, project, subtask)
+
+	if got := fake.status[subtask.ID]; got != string(domain.SubtaskStatusBlocked) {
+		t.Errorf("status = %s, want BLOCKED", got)
+	}
+	if r := fake.reason[subtask.ID]; r == nil || *r != string(domain.BlockedReasonFailure) {
+		t.Errorf("blocked reason = %v, want FAILURE", r)
+	}
+
+	if len(hub.failures) != 1 {
+		t.Fatalf("expected one agent:failed event, got %d", len(hub.failures))
+	}
+	failure := hub.failures[0]
+	if failure.SubtaskID == nil || *failure.SubtaskID != subtask.ID.String() || failure.WillRetry {
+		t.Errorf("unexpected agent:failed data: %+v", failure)
+	}
+	if !strings.Contains(failure.Error, "already running") {
+		t.Errorf("error = %q, want it to mention the running agent", failure.Error)
+	}
+
+	if len(hub.subtaskStatuses) != 1 || hub.subtaskStatuses[0] != string(domain.SubtaskStatusBlocked) {
+		t.Errorf("subtask:status_changed events = %v, want [BLOCKED]", hub.subtaskStatuses)
+	}
+}
+
+// quotaSpawner is a WorkerSpawner that refuses for the concurrent agent quota,
+// as AgentManager does when a concurrent request took the last slot.
+type quotaSpawner struct{}
+
+func (quotaSpawner) SpawnWorker(ctx context.Context, subtask *domain.Subtask, project *domain.Project) error {
+	return domain.NewQuotaExceededError(QuotaConcurrentAgents, 1)
+}
+
+func (quotaSpawner) KillAgentsForSubtask(ctx context.Context, subtaskID uuid.UUID) error {
+	return nil
+}
+
+func TestSubtaskService_SpawnWorker_QuotaExceeded(t *testing.T) {
+	failure := domain.BlockedReasonFailure
+	prev := &domain.Subtask{ID: uuid.New(), TaskID: uuid.New(), Status: domain.SubtaskStatusBlocked, BlockedReason: &failure}
+	subtask := &domain.Subtask{ID: prev.ID, TaskID: prev.TaskID, Status: domain.SubtaskStatusInProgress}
+	project := &domain.Project{ID: uuid.New()}
+	fake := &claimDB{status: map[uuid.UUID]string{subtask.ID: string(domain.SubtaskStatusInProgress)}}
+	hub := &mockEventHub{}
+	s := &SubtaskService{repo: repository.New(fake), workerSpawner: quotaSpawner{}, eventHub: hub}
+
+	s.spawnWorker(subtask, project, prev)
+
+	// The retry is undone rather than counted as a failure of the subtask
+	if got := fake.status[subtask.ID]; got != string(domain.SubtaskStatusBlocked) {
+		t.Errorf("status = %s, want BLOCKED", got)
+	}
+	if r := fake.reason[subtask.ID]; r == nil || *r != string(failure) {
+		t.Errorf("blocked reason = %v, want the previous FAILURE", r)
+	}
+	if len(hub.failures) != 1 || !strings.Contains(hub.failures[0].Error, "quota exceeded") {
+		t.Errorf("agent:failed events = %+v, want one naming the quota", hub.failures)
+	}
+}
+
+func TestSubtaskService_StartAndRetry_QuotaExceeded(t *testing.T) {
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	quotas := NewQuotaService(nil, UserQuotas{MaxConcurrentAgents: 1})
+	quotas.SetAgentCounter(agentCount(1))
+	s := &SubtaskService{
+		repo:           store,
+		taskService:    &TaskService{repo: store, projectService: projects},
+		projectService: projects,
+		workerSpawner:  refusingSpawner{},
+		quotas:         quotas,
+	}
+	ctx := context.Background()
+	userID := uuid.New()
+	_, subtasks := seedTask(t, store, userID, domain.TaskStatusActive,
+		domain.SubtaskStatusReady, domain.SubtaskStatusBlocked)
+	ready, blocked := subtasks[0], subtasks[1]
+	reason := string(domain.BlockedReasonFailure)
+	if _, err := store.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
+		ID:            blocked.ID,
+		Status:        blocked.Status,
+		BlockedReason: &reason,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.StartSubtask(ctx, ready.ID, userID, nil); !domain.IsQuotaExceeded(err) {
+		t.Fatalf("StartSubtask() error = %v, want quota exceeded", err)
+	}
+	if _, err := s.RetrySubtask(ctx, blocked.ID, userID, "", nil); !domain.IsQuotaExceeded(err) {
+		t.Fatalf("RetrySubtask() error = %v, want quota exceeded", err)
+	}
+
+	// Both requests are refused before the subtasks change
+	if got, _ := store.GetSubtaskByID(ctx, ready.ID); got.Status != string(domain.SubtaskStatusReady) {
+		t.Errorf("started subtask status = %s, want READY", got.Status)
+	}
+	if got, _ := store.GetSubtaskByID(ctx, blocked.ID); got.Status != string(domain.SubtaskStatusBlocked) {
+		t.Errorf("retried subtask status = %s, want BLOCKED", got.Status)
+	}
+}
+
+func TestSubtaskService_ClaimSubtaskStart_Unmodified(t *testing.T) {
+	readAt := time.Date(2026, 2, 4, 10, 0, 0, 123456000, time.UTC)
+	subtask := &domain.Subtask{ID: uuid.New(), Status: domain.SubtaskStatusReady, UpdatedAt: readAt}
+	fake := &claimDB{
+		status:    map[uuid.UUID]string{subtask.ID: string(domain.SubtaskStatusReady)},
+		updatedAt: map[uuid.UUID]time.Time{subtask.ID: readAt},
+	}
+	s := &SubtaskService{repo: repository.New(fake)}
+	ctx := context.Background()
+
+	if err := checkUnmodified(subtask, nil); err != nil {
+		t.Fatalf("no expected_updated_at should skip the check, got %v", err)
+	}
+	if err := checkUnmodified(subtask, &readAt); err != nil {
+		t.Fatalf("the copy just read should pass the early check, got %v", err)
+	}
+
+	// The guarded claim is the only write, and it lets the first request through
+	if err := s.claimSubtaskStart(ctx, subtask, &readAt); err != nil {
+		t.Fatalf("first request from the current copy should pass, got %v", err)
+	}
+	if !fake.updatedAt[subtask.ID].After(readAt) {
+		t.Error("the claim should bump updated_at")
+	}
+
+	// A second request made from the same copy loses the race, even though
+	// the status it expects has since been restored
+	fake.status[subtask.ID] = string(domain.SubtaskStatusReady)
+	err := s.claimSubtaskStart(ctx, subtask, &readAt)
+	var stale *domain.StaleError
+	if !errors.As(err, &stale) {
+		t.Fatalf("expected StaleError, got %v", err)
+	}
+	current, ok := stale.Current.(*domain.Subtask)
+	if !ok || !current.UpdatedAt.Equal(fake.updatedAt[subtask.ID]) {
+		t.Errorf("stale error should carry the current subtask, got %+v", stale.Current)
+	}
+
+	// A client holding an older copy than the one just read is rejected up front
+	older := readAt.Add(-time.Second)
+	if err := checkUnmodified(subtask, &older); !domain.IsConflict(err) {
+		t.Errorf("expected conflict for an older copy, got %v", err)
+	}
+}
+
+// newPRServer serves the GitHub pull request endpoints for owner/repo: PR 7
+// merged, PR 8 open, and a merged PR for branch "iv-1-done". Any other
+// branch has no PR.
+func newPRServer(t *testing.T) *GitHubService {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/repos/owner/repo/pulls/7":
+			_, _ = w.Write([]byte(`{"number":7,"state":"closed","merged":true,"html_url":"https://github.com/owner/repo/pull/7"}`))
+		case r.URL.Path == "/repos/owner/repo/pulls/8":
+			_, _ = w.Write([]byte(`{"number":8,"state":"open","merged":false}`))
+		case r.URL.Path == "/repos/owner/repo/pulls" && r.URL.Query().Get("head") == "owner:iv-1-done":
+			_, _ = w.Write([]byte(`[{"number":9,"state":"closed","merged_at":"2026-02-01T10:00:00Z","html_url":"https://github.com/owner/repo/pull/9"}]`))
+		case r.URL.Path == "/repos/owner/repo/pulls":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	gh := NewGitHubService()
+	gh.apiURL, _ = url.Parse(server.URL + "/")
+	return gh
+}
+
+func TestSubtaskService_MergedPR(t *testing.T) {
+	s := &SubtaskService{githubService: newPRServer(t)}
+	project := &domain.Project{GitHubOwner: "owner", GitHubRepo: "repo"}
+	ptrInt := func(n int) *int { return &n }
+	ptrStr := func(v string) *string { return &v }
+
+	tests := []struct {
+		name       string
+		subtask    *domain.Subtask
+		token      string
+		wantNumber int // 0 means not merged: the retry goes ahead
+	}{
+		{"recorded PR merged", &domain.Subtask{PRNumber: ptrInt(7)}, "token", 7},
+		{"recorded PR open", &domain.Subtask{PRNumber: ptrInt(8)}, "token", 0},
+		{"branch PR merged", &domain.Subtask{BranchName: ptrStr("iv-1-done")}, "token", 9},
+		{"branch without PR", &domain.Subtask{BranchName: ptrStr("iv-2-new")}, "token", 0},
+		{"no PR or branch", &domain.Subtask{}, "token", 0},
+		{"no token", &domain.Subtask{PRNumber: ptrInt(7)}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr, err := s.mergedPR(context.Background(), tt.subtask, project, tt.token)
+			if err != nil {
+				t.Fatalf("mergedPR() error = %v", err)
+			}
+			if tt.wantNumber == 0 {
+				if pr != nil {
+					t.Errorf("mergedPR() = %+v, want nil", pr)
+				}
+				return
+			}
+			if pr == nil || pr.Number != tt.wantNumber {
+				t.Errorf("mergedPR() = %+v, want PR %d", pr, tt.wantNumber)
+			}
+		})
+	}
+}
+
+func TestSubtaskService_MarkMerged(t *testing.T) {
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	tasks := &TaskService{repo: store, projectService: projects}
+	s := &SubtaskService{
+		repo:              store,
+		taskService:       tasks,
+		dependencyService: &DependencyService{repo: store},
+		projectService:    projects,
+	}
+	ctx := context.Background()
+	userID := uuid.New()
+	task, subtasks := seedTask(t, store, userID, domain.TaskStatusActive,
+		domain.SubtaskStatusCompleted, domain.SubtaskStatusBlocked, domain.SubtaskStatusMerged)
+	first, dependent := subtasks[0], subtasks[1]
+
+	prURL := "https://github.com/owner/repo/pull/1"
+	if _, err := store.UpdateSubtaskPR(ctx, db.UpdateSubtaskPRParams{ID: first.ID, PrUrl: &prURL}); err != nil {
+		t.Fatal(err)
+	}
+	dependency := string(domain.BlockedReasonDependency)
+	if _, err := store.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
+		ID:            dependent.ID,
+		Status:        string(domain.SubtaskStatusBlocked),
+		BlockedReason: &dependency,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateDependency(ctx, db.CreateDependencyParams{SubtaskID: dependent.ID, DependsOnID: first.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.MarkMerged(ctx, first.ID, uuid.New(), nil); !domain.IsForbidden(err) {
+		t.Fatalf("MarkMerged() by another user error = %v, want forbidden", err)
+	}
+	merged, err := s.MarkMerged(ctx, first.ID, userID, nil)
+	if err != nil {
+		t.Fatalf("MarkMerged() error = %v", err)
+	}
+	if merged.Status != domain.SubtaskStatusMerged {
+		t.Errorf("MarkMerged() status = %s, want MERGED", merged.Status)
+	}
+
+	// The dependent is unblocked but not yet merged, so the task stays active
+	unblocked, _ := store.GetSubtaskByID(ctx, dependent.ID)
+	if unblocked.Status != string(domain.SubtaskStatusReady) || unblocked.BlockedReason != nil {
+		t.Errorf("dependent = %s (%v), want READY", unblocked.Status, unblocked.BlockedReason)
+	}
+	stored, _ := store.GetTaskByID(ctx, task.ID)
+	if stored.Status != string(domain.TaskStatusActive) {
+		t.Errorf("task status = %s, want ACTIVE", stored.Status)
+	}
+
+	if _, err := s.MarkMerged(ctx, first.ID, userID, nil); !domain.IsUnprocessable(err) {
+		t.Errorf("MarkMerged() on a MERGED subtask error = %v, want unprocessable", err)
+	}
+}
+
+func TestSubtaskService_MarkCompletedNoChanges(t *testing.T) {
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	tasks := &TaskService{repo: store, projectService: projects}
+	s := &SubtaskService{
+		repo:              store,
+		taskService:       tasks,
+		dependencyService: &DependencyService{repo: store},
+		projectService:    projects,
+	}
+	ctx := context.Background()
+	task, subtasks := seedTask(t, store, uuid.New(), domain.TaskStatusActive,
+		domain.SubtaskStatusInProgress, domain.SubtaskStatusBlocked)
+	first, dependent := subtasks[0], subtasks[1]
+
+	dependency := string(domain.BlockedReasonDependency)
+	if _, err := store.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
+		ID:            dependent.ID,
+		Status:        string(domain.SubtaskStatusBlocked),
+		BlockedReason: &dependency,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateDependency(ctx, db.CreateDependencyParams{SubtaskID: dependent.ID, DependsOnID: first.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.MarkCompletedNoChanges(ctx, first.ID); err != nil {
+		t.Fatalf("MarkCompletedNoChanges() error = %v", err)
+	}
+	stored, _ := store.GetSubtaskByID(ctx, first.ID)
+	if stored.Status != string(domain.SubtaskStatusCompletedNoChanges) || stored.PrUrl != nil {
+		t.Errorf("subtask = %s (PR %v), want COMPLETED_NO_CHANGES without a PR", stored.Status, stored.PrUrl)
+	}
+
+	// With nothing to merge, the dependent is unblocked right away
+	unblocked, _ := store.GetSubtaskByID(ctx, dependent.ID)
+	if unblocked.Status != string(domain.SubtaskStatusReady) {
+		t.Errorf("dependent = %s, want READY", unblocked.Status)
+	}
+
+	// The task is done once the rest is resolved
+	if _, err := store.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{ID: dependent.ID, Status: string(domain.SubtaskStatusInProgress)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.MarkCompletedNoChanges(ctx, dependent.ID); err != nil {
+		t.Fatalf("MarkCompletedNoChanges() error = %v", err)
+	}
+	if stored, _ := store.GetTaskByID(ctx, task.ID); stored.Status != string(domain.TaskStatusDone) {
+		t.Errorf("task status = %s, want DONE", stored.Status)
+	}
+}
+
+// killingSpawner is a WorkerSpawner that records kills and runs onKill, which
+// can stand in for a Worker finishing just before it is killed.
+type killingSpawner struct {
+	killed []uuid.UUID
+	onKill func(subtaskID uuid.UUID)
+}
+
+func (k *killingSpawner) SpawnWorker(ctx context.Context, subtask *domain.Subtask, project *domain.Project) error {
+	return nil
+}
+
+func (k *killingSpawner) KillAgentsForSubtask(ctx context.Context, subtaskID uuid.UUID) error {
+	k.killed = append(k.killed, subtaskID)
+	if k.onKill != nil {
+		k.onKill(subtaskID)
+	}
+	return nil
+}
+
+func TestSubtaskService_StopSubtask(t *testing.T) {
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	tasks := &TaskService{repo: store, projectService: projects}
+	spawner := &killingSpawner{}
+	hub := &mockEventHub{}
+	s := &SubtaskService{
+		repo:           store,
+		taskService:    tasks,
+		projectService: projects,
+		workerSpawner:  spawner,
+		eventHub:       hub,
+	}
+	ctx := context.Background()
+	userID := uuid.New()
+	_, subtasks := seedTask(t, store, userID, domain.TaskStatusActive,
+		domain.SubtaskStatusInProgress, domain.SubtaskStatusInProgress, domain.SubtaskStatusReady)
+	running, sibling, ready := subtasks[0], subtasks[1], subtasks[2]
+	run := store.AddAgentRun(db.AgentRun{
+		SubtaskID: pgtype.UUID{Bytes: running.ID, Valid: true},
+		AgentType: string(domain.AgentTypeWorker),
+		Status:    string(domain.AgentRunStatusRunning),
+	})
+
+	if _, err := s.StopSubtask(ctx, running.ID, uuid.New(), nil); !domain.IsForbidden(err) {
+		t.Fatalf("StopSubtask() by another user error = %v, want forbidden", err)
+	}
+	if _, err := s.StopSubtask(ctx, ready.ID, userID, nil); !domain.IsUnprocessable(err) {
+		t.Fatalf("StopSubtask() on a READY subtask error = %v, want unprocessable", err)
+	}
+	if len(spawner.killed) != 0 {
+		t.Fatalf("rejected stops killed workers: %v", spawner.killed)
+	}
+
+	stopped, err := s.StopSubtask(ctx, running.ID, userID, nil)
+	if err != nil {
+		t.Fatalf("StopSubtask() error = %v", err)
+	}
+	if stopped.Status != domain.SubtaskStatusBlocked || stopped.BlockedReason == nil || *stopped.BlockedReason != domain.BlockedReasonStopped {
+		t.Errorf("StopSubtask() = %s (%v), want BLOCKED (STOPPED)", stopped.Status, stopped.BlockedReason)
+	}
+	if len(spawner.killed) != 1 || spawner.killed[0] != running.ID {
+		t.Errorf("killed = %v, want only the stopped subtask", spawner.killed)
+	}
+	storedRun, _ := store.GetLatestAgentRun(ctx, run.SubtaskID)
+	if storedRun.Status != string(domain.AgentRunStatusFailed) || storedRun.ErrorMessage == nil || *storedRun.ErrorMessage != stoppedRunError {
+		t.Errorf("run = %s (%v), want FAILED (%q)", storedRun.Status, storedRun.ErrorMessage, stoppedRunError)
+	}
+	if len(hub.subtaskStatuses) != 1 || hub.subtaskStatuses[0] != string(domain.SubtaskStatusBlocked) {
+		t.Errorf("subtask:status_changed events = %v, want [BLOCKED]", hub.subtaskStatuses)
+	}
+	if other, _ := store.GetSubtaskByID(ctx, sibling.ID); other.Status != string(domain.SubtaskStatusInProgress) {
+		t.Errorf("sibling status = %s, want IN_PROGRESS", other.Status)
+	}
+
+	// The Worker is only killed once the subtask is blocked, so it cannot
+	// record a result over the stop
+	spawner.onKill = func(subtaskID uuid.UUID) {
+		if st, _ := store.GetSubtaskByID(ctx, subtaskID); st.Status != string(domain.SubtaskStatusBlocked) {
+			t.Errorf("status at kill = %s, want BLOCKED", st.Status)
+		}
+	}
+	current, err := s.GetSubtask(ctx, sibling.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readAt := current.UpdatedAt
+	if _, err := store.UpdateSubtaskPriority(ctx, db.UpdateSubtaskPriorityParams{ID: sibling.ID, Priority: 1}); err != nil {
+		t.Fatal(err)
+	}
+	var stale *domain.StaleError
+	if _, err := s.StopSubtask(ctx, sibling.ID, userID, &readAt); !errors.As(err, &stale) {
+		t.Fatalf("StopSubtask() from a stale copy error = %v, want StaleError", err)
+	}
+	if len(spawner.killed) != 1 {
+		t.Errorf("a refused stop killed a worker: %v", spawner.killed)
+	}
+	if _, err := s.StopSubtask(ctx, sibling.ID, userID, nil); err != nil {
+		t.Fatalf("StopSubtask() error = %v", err)
+	}
+	if len(spawner.killed) != 2 || spawner.killed[1] != sibling.ID {
+		t.Errorf("killed = %v, want the sibling killed after its stop", spawner.killed)
+	}
+}
+
+func TestSubtaskService_TokenBudget(t *testing.T) {
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	s := &SubtaskService{
+		repo:           store,
+		taskService:    &TaskService{repo: store, projectService: projects},
+		projectService: projects,
+	}
+	ctx := context.Background()
+	userID := uuid.New()
+	_, subtasks := seedTask(t, store, userID, domain.TaskStatusActive, domain.SubtaskStatusInProgress)
+	id := subtasks[0].ID
+
+	for _, budget := range []int{0, -1} {
+		if _, err := s.SetTokenBudget(ctx, id, userID, &budget, nil); !domain.IsUnprocessable(err) {
+			t.Errorf("SetTokenBudget(%d) error = %v, want unprocessable", budget, err)
+		}
+	}
+	budget := 1000
+	subtask, err := s.SetTokenBudget(ctx, id, userID, &budget, nil)
+	if err != nil {
+		t.Fatalf("SetTokenBudget() error = %v", err)
+	}
+	if subtask.TokenBudget == nil || *subtask.TokenBudget != 1000 {
+		t.Errorf("TokenBudget = %v, want 1000", subtask.TokenBudget)
+	}
+
+	// Usage accumulates across attempts
+	if _, err := s.UpdateTokenUsage(ctx, id, 600); err != nil {
+		t.Fatal(err)
+	}
+	subtask, err = s.UpdateTokenUsage(ctx, id, 600)
+	if err != nil {
+		t.Fatalf("UpdateTokenUsage() error = %v", err)
+	}
+	if subtask.TokenUsage != 1200 {
+		t.Errorf("TokenUsage = %d, want 1200", subtask.TokenUsage)
+	}
+
+	if err := s.MarkBudgetExceeded(ctx, id); err != nil {
+		t.Fatalf("MarkBudgetExceeded() error = %v", err)
+	}
+	stored, _ := store.GetSubtaskByID(ctx, id)
+	if stored.Status != string(domain.SubtaskStatusBlocked) || stored.BlockedReason == nil || *stored.BlockedReason != string(domain.BlockedReasonBudgetExceeded) {
+		t.Errorf("subtask = %s (%v), want BLOCKED (BUDGET_EXCEEDED)", stored.Status, stored.BlockedReason)
+	}
+
+	// A retry would only overrun the budget again until it is raised
+	if _, err := s.RetrySubtask(ctx, id, userID, "", nil); !domain.IsUnprocessable(err) {
+		t.Errorf("RetrySubtask() within the used-up budget error = %v, want unprocessable", err)
+	}
+
+	subtask, err = s.SetTokenBudget(ctx, id, userID, nil, nil)
+	if err != nil {
+		t.Fatalf("SetTokenBudget(nil) error = %v", err)
+	}
+	if subtask.TokenBudget != nil {
+		t.Errorf("TokenBudget = %d, want unlimited", *subtask.TokenBudget)
+	}
+}
+
+func TestSubtaskService_MarkPolicyViolation(t *testing.T) {
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	s := &SubtaskService{
+		repo:           store,
+		taskService:    &TaskService{repo: store, projectService: projects},
+		projectService: projects,
+	}
+	ctx := context.Background()
+	_, subtasks := seedTask(t, store, uuid.New(), domain.TaskStatusActive, domain.SubtaskStatusInProgress)
+	id := subtasks[0].ID
+
+	if err := s.MarkPolicyViolation(ctx, id); err != nil {
+		t.Fatalf("MarkPolicyViolation() error = %v", err)
+	}
+	stored, _ := store.GetSubtaskByID(ctx, id)
+	if stored.Status != string(domain.SubtaskStatusBlocked) || stored.BlockedReason == nil || *stored.BlockedReason != string(domain.BlockedReasonPolicyViolation) {
+		t.Errorf("subtask = %s (%v), want BLOCKED (POLICY_VIOLATION)", stored.Status, stored.BlockedReason)
+	}
+}
+
+func TestSubtaskService_Priority(t *testing.T) {
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	taskService := &TaskService{repo: store, projectService: projects}
+	s := &SubtaskService{repo: store, taskService: taskService, projectService: projects}
+	ctx := context.Background()
+	userID := uuid.New()
+	task, _ := seedTask(t, store, userID, domain.TaskStatusActive)
+
+	created, err := s.CreateSubtask(ctx, CreateSubtaskInput{TaskID: task.ID, Title: "unprioritized"})
+	if err != nil {
+		t.Fatalf("CreateSubtask() error = %v", err)
+	}
+	if created.Priority != domain.DefaultSubtaskPriority {
+		t.Errorf("Priority = %d, want the default %d", created.Priority, domain.DefaultSubtaskPriority)
+	}
+	invalid := 7
+	if _, err := s.CreateSubtask(ctx, CreateSubtaskInput{TaskID: task.ID, Title: "bad", Priority: &invalid}); !domain.IsInvalidInput(err) {
+		t.Errorf("CreateSubtask() with priority %d error = %v, want invalid input", invalid, err)
+	}
+
+	for _, priority := range []int{-1, 5} {
+		if _, err := s.SetPriority(ctx, created.ID, userID, priority, nil); !domain.IsInvalidInput(err) {
+			t.Errorf("SetPriority(%d) error = %v, want invalid input", priority, err)
+		}
+	}
+	subtask, err := s.SetPriority(ctx, created.ID, userID, domain.HighestSubtaskPriority, nil)
+	if err != nil {
+		t.Fatalf("SetPriority() error = %v", err)
+	}
+	if subtask.Priority != domain.HighestSubtaskPriority {
+		t.Errorf("Priority = %d, want %d", subtask.Priority, domain.HighestSubtaskPriority)
+	}
+
+	if _, err := s.SetPriority(ctx, created.ID, uuid.New(), 1, nil); !domain.IsForbidden(err) {
+		t.Errorf("SetPriority() as another user error = %v, want forbidden", err)
+	}
+}
+
+func TestSubtaskService_GetDiff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/compare/main...iv-1-done" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Accept") != "application/vnd.github.v3.diff" {
+			t.Errorf("compare Accept = %q, want the diff media type", r.Header.Get("Accept"))
+		}
+		_, _ = w.Write([]byte("diff --git a/remote.txt b/remote.txt\n"))
+	}))
+	t.Cleanup(server.Close)
+	gh := NewGitHubService()
+	gh.apiURL, _ = url.Parse(server.URL + "/")
+
+	store := repotest.New()
+	projects := &ProjectService{repo: store}
+	s := &SubtaskService{
+		repo:           store,
+		taskService:    &TaskService{repo: store, projectService: projects},
+		projectService: projects,
+		githubService:  gh,
+	}
+	ctx := context.Background()
+	userID := uuid.New()
+	project, err := store.CreateProject(ctx, db.CreateProjectParams{UserID: userID, GithubOwner: "owner", GithubRepo: "repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := store.CreateTask(ctx, db.CreateTaskParams{ProjectID: project.ID, Title: "task", Status: string(domain.TaskStatusActive), BaseBranch: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSubtask := func(branch, worktree string) uuid.UUID {
+		st, err := store.CreateSubtask(ctx, db.CreateSubtaskParams{TaskID: task.ID, Title: "subtask", Status: string(domain.SubtaskStatusCompleted)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if branch != "" {
+			if _, err := store.UpdateSubtaskBranch(ctx, db.UpdateSubtaskBranchParams{ID: st.ID, BranchName: &branch, WorktreePath: &worktree}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return st.ID
+	}
+
+	// The worktree is diffed while it exists
+	worktree := newCommitsTestRepo(t)
+	gitRun(t, worktree, "checkout", "-b", "iv-1-done")
+	gitCommit(t, worktree, "local.txt", "add local")
+	diff, err := s.GetDiff(ctx, newSubtask("iv-1-done", worktree), userID, "token")
+	if err != nil {
+		t.Fatalf("GetDiff() with worktree error = %v", err)
+	}
+	if diff.Source != DiffSourceWorktree || !strings.Contains(diff.Diff, "local.txt") || diff.Truncated {
+		t.Errorf("GetDiff() with worktree = %+v, want the local diff", diff)
+	}
+
+	// A removed worktree falls back to the branch on GitHub
+	removed := newSubtask("iv-1-done", filepath.Join(t.TempDir(), "removed"))
+	diff, err = s.GetDiff(ctx, removed, userID, "token")
+	if err != nil {
+		t.Fatalf("GetDiff() without worktree error = %v", err)
+	}
+	if diff.Source != DiffSourceGitHub || !strings.Contains(diff.Diff, "remote.txt") || diff.Base != "main" {
+		t.Errorf("GetDiff() without worktree = %+v, want the GitHub diff", diff)
+	}
+
+	if _, err := s.GetDiff(ctx, newSubtask("iv-2-gone", ""), userID, "token"); !domain.IsNotFound(err) {
+		t.Errorf("GetDiff() for a deleted branch error = %v, want not found", err)
+	}
+	if _, err := s.GetDiff(ctx, newSubtask("", ""), userID, "token"); !domain.IsUnprocessable(err) {
+		t.Errorf("GetDiff() without a branch error = %v, want unprocessable", err)
+	}
+	if _, err := s.GetDiff(ctx, removed, uuid.New(), "token"); !domain.IsForbidden(err) {
+		t.Errorf("GetDiff() by another user error = %v, want forbidden", err)
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	diff := "line 1\nline 2\nline 3\n"
+
+	if got, truncated := truncateDiff(diff, len(diff)); got != diff || truncated {
+		t.Errorf("truncateDiff() at the limit = %q, %v; want unchanged", got, truncated)
+	}
+	// Cut at the last whole line within the limit
+	if got, truncated := truncateDiff(diff, 10); got != "line 1\n" || !truncated {
+		t.Errorf("truncateDiff(10) = %q, %v; want first line", got, truncated)
+	}
+	if got, truncated := truncateDiff("no newline at all", 5); got != "no ne" || !truncated {
+		t.Errorf("truncateDiff() without newline = %q, %v; want a hard cut", got, truncated)
+	}
+}
+
+func TestSubtaskService_DecrementRetryCount(t *testing.T) {
+	store := repotest.New()
+	s := &SubtaskService{repo: store}
+	ctx := context.Background()
+	_, subtasks := seedTask(t, store, uuid.New(), domain.TaskStatusActive, domain.SubtaskStatusInProgress)
+	id := subtasks[0].ID
+
+	if n, err := s.IncrementRetryCount(ctx, id); err != nil || n != 1 {
+		t.Fatalf("IncrementRetryCount() = %d, %v; want 1", n, err)
+	}
+	// The second decrement stops at zero
+	for _, want := range []int{0, 0} {
+		if n, err := s.DecrementRetryCount(ctx, id); err != nil || n != want {
+			t.Errorf("DecrementRetryCount() = %d, %v; want %d", n, err, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// stoppedRunError is recorded on Worker runs stopped by StopSubtask.
+const stoppedRunError = "stopped by user"
+
+// StopSubtask kills the Worker of an IN_PROGRESS subtask, leaving the rest of
+// the task running. The subtask is first blocked with reason STOPPED, from
+// where it can be retried, by an update that only applies while it is still
+// IN_PROGRESS and unmodified; only then is the Worker killed and its run marked
+// FAILED. The killed agent loop cannot record this itself because its context
+// is already cancelled.
+func (s *SubtaskService) StopSubtask(ctx context.Context, subtaskID, userID uuid.UUID, expectedUpdatedAt *time.Time) (*domain.Subtask, error) {
+	subtask, err := s.GetSubtask(ctx, subtaskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtask.Status != domain.SubtaskStatusInProgress {
+		return nil, domain.NewUnprocessableError("subtask", fmt.Sprintf("cannot stop subtask in %s status", subtask.Status))
+	}
+
+	task, err := s.taskService.GetTaskByIDInternal(ctx, subtask.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := s.projectService.GetProject(ctx, task.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkUnmodified(subtask, expectedUpdatedAt); err != nil {
+		return nil, err
+	}
+
+	// The Worker may have finished since the status check, in which case its
+	// result is kept and nothing is killed
+	dbSubtask, err := s.repo.StopSubtask(ctx, db.StopSubtaskParams{
+		ID:                subtaskID,
+		ExpectedUpdatedAt: expectedUpdatedAt,
+	})
+	if errors.Is(err, pgx.ErrNoRows) && expectedUpdatedAt == nil {
+		return nil, domain.NewConflictError("subtask", "subtask is no longer in progress")
+	}
+	if err != nil {
+		return nil, s.staleOr(ctx, subtaskID, expectedUpdatedAt, fmt.Errorf("failed to stop subtask: %w", err))
+	}
+
+	if s.workerSpawner != nil {
+		if err := s.workerSpawner.KillAgentsForSubtask(ctx, subtaskID); err != nil {
+			log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to kill worker of stopped subtask")
+		}
+	}
+	s.taskService.failRunningRun(ctx, subtaskID, stoppedRunError)
+
+	oldStatus := string(subtask.Status)
+	stoppedSubtask := dbSubtaskToDomain(dbSubtask)
+	s.auditSubtask(ctx, project.ID, userID, AuditActionSubtaskStop, oldStatus, stoppedSubtask)
+
+	log.Info().
+		Str("subtask_id", subtaskID.String()).
+		Str("user_id", userID.String()).
+		Msg("worker stopped by user")
+
+	// Publish subtask:status_changed event
+	if s.eventHub != nil {
+		s.eventHub.PublishSubtaskStatusChanged(project.ID, stoppedSubtask, oldStatus)
+	}
+
+	return stoppedSubtask, nil
+}
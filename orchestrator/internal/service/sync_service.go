@@ -5,30 +5,44 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 
 	"github.com/intern-village/orchestrator/generated/db"
 	"github.com/intern-village/orchestrator/internal/domain"
 	"github.com/intern-village/orchestrator/internal/repository"
 )
 
+// Sync errors for plans that cannot be synced as they are. Nothing is synced
+// in either case.
+var (
+	// ErrTooManySubtasks is returned by SyncTaskFromBeads when the Planner
+	// created more issues than the task's project allows.
+	ErrTooManySubtasks = errors.New("too many subtasks")
+	// ErrEmptyPlan is returned by SyncTaskFromBeads when the Planner's epic has
+	// no issues, which would leave the task ACTIVE with nothing to do.
+	ErrEmptyPlan = errors.New("plan has no subtasks")
+)
+
 // SyncService synchronizes Beads state to Postgres.
 // Beads is the source of truth for dependencies and agent state.
 type SyncService struct {
-	repo              *repository.Repository
-	beadsService      *BeadsService
+	repo              repository.SyncStore
+	beadsService      BeadsClient
 	subtaskService    *SubtaskService
 	dependencyService *DependencyService
 	taskService       *TaskService
+	maxSubtasks       int // default limit per task; 0 disables it
 }
 
 // NewSyncService creates a new SyncService.
 func NewSyncService(
-	repo *repository.Repository,
-	beadsService *BeadsService,
+	repo repository.SyncStore,
+	beadsService BeadsClient,
 	subtaskService *SubtaskService,
 	dependencyService *DependencyService,
 	taskService *TaskService,
@@ -42,8 +56,21 @@ func NewSyncService(
 	}
 }
 
+// SetMaxSubtasksPerTask sets how many subtasks a plan may create when the
+// task's project has no override. Zero disables the limit.
+func (s *SyncService) SetMaxSubtasksPerTask(n int) {
+	s.maxSubtasks = n
+}
+
 // SyncTaskFromBeads syncs all subtasks for a task from Beads.
-// This is called after the Planner agent completes.
+// This is called after the Planner agent completes, and again when a user
+// re-syncs a task (see TaskService.ResyncTask). A plan with more issues than
+// the subtask limit fails with ErrTooManySubtasks before any subtask is
+// created, so the task can be re-planned from a clean state; an epic without
+// issues fails with ErrEmptyPlan.
+//
+// Only subtasks that have not started (see awaitingStart) get their status
+// recomputed, so re-syncing never disturbs running or finished Workers.
 func (s *SyncService) SyncTaskFromBeads(ctx context.Context, taskID uuid.UUID, repoPath string) error {
 	// Get the task
 	task, err := s.taskService.GetTaskByIDInternal(ctx, taskID)
@@ -60,9 +87,20 @@ func (s *SyncService) SyncTaskFromBeads(ctx context.Context, taskID uuid.UUID, r
 	if err != nil {
 		return fmt.Errorf("failed to list issues from beads: %w", err)
 	}
+	if len(issues) == 0 {
+		return EmptyPlanError()
+	}
+
+	limit, err := s.subtaskLimit(ctx, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	if err := checkSubtaskLimit(len(issues), limit); err != nil {
+		return err
+	}
 
-	// Track created subtask IDs for dependency sync
-	beadsIDToSubtaskID := make(map[string]uuid.UUID)
+	// Track synced subtasks for dependency sync
+	beadsIDToSubtask := make(map[string]*domain.Subtask)
 
 	// Create or update subtasks
 	for _, issue := range issues {
@@ -70,7 +108,7 @@ func (s *SyncService) SyncTaskFromBeads(ctx context.Context, taskID uuid.UUID, r
 		if err != nil {
 			return fmt.Errorf("failed to sync issue %s: %w", issue.ID, err)
 		}
-		beadsIDToSubtaskID[issue.ID] = subtask.ID
+		beadsIDToSubtask[issue.ID] = subtask
 	}
 
 	// Sync dependencies
@@ -78,33 +116,212 @@ func (s *SyncService) SyncTaskFromBeads(ctx context.Context, taskID uuid.UUID, r
 		// Get blocking dependencies (not parent-child)
 		depIDs := issue.GetDependencyIDs()
 		if len(depIDs) > 0 {
-			subtaskID := beadsIDToSubtaskID[issue.ID]
+			subtaskID := beadsIDToSubtask[issue.ID].ID
 			for _, depID := range depIDs {
-				if depSubtaskID, ok := beadsIDToSubtaskID[depID]; ok {
-					_, err := s.dependencyService.AddDependency(ctx, subtaskID, depSubtaskID)
+				if depSubtask, ok := beadsIDToSubtask[depID]; ok {
+					_, err := s.dependencyService.AddDependency(ctx, subtaskID, depSubtask.ID)
 					if err != nil {
 						// Log but don't fail - might be duplicate
-						fmt.Printf("warning: failed to add dependency %s -> %s: %v\n", issue.ID, depID, err)
+						log.Warn().Err(err).
+							Str("beads_issue_id", issue.ID).
+							Str("depends_on", depID).
+							Msg("failed to add dependency")
 					}
 				}
 			}
 		}
 	}
 
-	// Determine initial status for each subtask
-	for _, subtaskID := range beadsIDToSubtaskID {
-		status, reason, err := s.dependencyService.DetermineInitialStatus(ctx, subtaskID)
+	// Determine status for each subtask that has not started
+	for _, subtask := range beadsIDToSubtask {
+		if !awaitingStart(subtask) {
+			continue
+		}
+		status, reason, err := s.dependencyService.DetermineInitialStatus(ctx, subtask.ID)
 		if err != nil {
-			return fmt.Errorf("failed to determine status for subtask %s: %w", subtaskID, err)
+			return fmt.Errorf("failed to determine status for subtask %s: %w", subtask.ID, err)
+		}
+		if status == subtask.Status {
+			continue
 		}
-		if err := s.subtaskService.UpdateSubtaskStatus(ctx, subtaskID, status, reason); err != nil {
-			return fmt.Errorf("failed to update subtask status %s: %w", subtaskID, err)
+		if err := s.subtaskService.UpdateSubtaskStatus(ctx, subtask.ID, status, reason); err != nil {
+			return fmt.Errorf("failed to update subtask status %s: %w", subtask.ID, err)
 		}
 	}
 
 	return nil
 }
 
+// SyncNewIssuesFromBeads syncs the issues an incremental re-plan added under
+// a task's epic (see TaskService.ReplanTask) and returns the subtasks it
+// created. Existing subtasks, and their dependencies, are never touched:
+//   - An issue whose title matches an existing subtask is skipped, as the
+//     Planner recreating work that is already tracked.
+//   - Only dependencies of new issues are added, on new or existing subtasks.
+//   - The subtask limit applies to the task's existing and new subtasks together.
+func (s *SyncService) SyncNewIssuesFromBeads(ctx context.Context, taskID uuid.UUID, repoPath string) ([]*domain.Subtask, error) {
+	task, err := s.taskService.GetTaskByIDInternal(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.BeadsEpicID == nil || *task.BeadsEpicID == "" {
+		return nil, fmt.Errorf("task %s has no beads epic ID", taskID)
+	}
+
+	issues, err := s.beadsService.ListIssues(ctx, repoPath, *task.BeadsEpicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues from beads: %w", err)
+	}
+
+	existing, err := s.repo.ListSubtasksByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subtasks: %w", err)
+	}
+	newIssues, skipped := selectNewIssues(issues, existing)
+	for _, issue := range skipped {
+		log.Warn().
+			Str("task_id", taskID.String()).
+			Str("beads_issue_id", issue.ID).
+			Str("title", issue.Title).
+			Msg("skipping re-planned issue that duplicates an existing subtask")
+	}
+	if len(newIssues) == 0 {
+		return nil, nil
+	}
+
+	limit, err := s.subtaskLimit(ctx, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSubtaskLimit(len(existing)+len(newIssues), limit); err != nil {
+		return nil, err
+	}
+
+	beadsIDToSubtaskID := make(map[string]uuid.UUID, len(existing)+len(newIssues))
+	for _, subtask := range existing {
+		if subtask.BeadsIssueID != nil {
+			beadsIDToSubtaskID[*subtask.BeadsIssueID] = subtask.ID
+		}
+	}
+
+	created := make([]*domain.Subtask, len(newIssues))
+	for i, issue := range newIssues {
+		subtask, err := s.syncIssueToSubtask(ctx, taskID, issue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sync issue %s: %w", issue.ID, err)
+		}
+		created[i] = subtask
+		beadsIDToSubtaskID[issue.ID] = subtask.ID
+	}
+
+	for i, issue := range newIssues {
+		for _, depID := range issue.GetDependencyIDs() {
+			depSubtaskID, ok := beadsIDToSubtaskID[depID]
+			if !ok {
+				continue
+			}
+			if _, err := s.dependencyService.AddDependency(ctx, created[i].ID, depSubtaskID); err != nil {
+				log.Warn().Err(err).
+					Str("beads_issue_id", issue.ID).
+					Str("depends_on", depID).
+					Msg("failed to add dependency")
+			}
+		}
+	}
+
+	for _, subtask := range created {
+		status, reason, err := s.dependencyService.DetermineInitialStatus(ctx, subtask.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine status for subtask %s: %w", subtask.ID, err)
+		}
+		if status == subtask.Status {
+			continue
+		}
+		if err := s.subtaskService.UpdateSubtaskStatus(ctx, subtask.ID, status, reason); err != nil {
+			return nil, fmt.Errorf("failed to update subtask status %s: %w", subtask.ID, err)
+		}
+		subtask.Status = status
+		subtask.BlockedReason = reason
+	}
+
+	return created, nil
+}
+
+// selectNewIssues returns the issues that have no subtask yet, and separately
+// those skipped because their title duplicates an existing subtask or an
+// earlier new issue.
+func selectNewIssues(issues []BeadsIssue, existing []db.Subtask) (newIssues, skipped []BeadsIssue) {
+	synced := make(map[string]bool, len(existing))
+	titles := make(map[string]bool, len(existing))
+	for _, subtask := range existing {
+		if subtask.BeadsIssueID != nil {
+			synced[*subtask.BeadsIssueID] = true
+		}
+		titles[normalizeTitle(subtask.Title)] = true
+	}
+
+	for _, issue := range issues {
+		if synced[issue.ID] {
+			continue
+		}
+		title := normalizeTitle(issue.Title)
+		if titles[title] {
+			skipped = append(skipped, issue)
+			continue
+		}
+		titles[title] = true
+		newIssues = append(newIssues, issue)
+	}
+	return newIssues, skipped
+}
+
+// normalizeTitle folds case and whitespace so near-identical titles compare equal.
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// awaitingStart reports whether a subtask's status is still derived from its
+// dependencies: PENDING, READY, or BLOCKED on a dependency. Subtasks that are
+// in progress, done, or blocked by a failure are left alone by syncs.
+func awaitingStart(subtask *domain.Subtask) bool {
+	switch subtask.Status {
+	case domain.SubtaskStatusPending, domain.SubtaskStatusReady:
+		return true
+	case domain.SubtaskStatusBlocked:
+		return subtask.BlockedReason != nil && *subtask.BlockedReason == domain.BlockedReasonDependency
+	default:
+		return false
+	}
+}
+
+// subtaskLimit returns the project's subtask limit override, or the default.
+func (s *SyncService) subtaskLimit(ctx context.Context, projectID uuid.UUID) (int, error) {
+	project, err := s.repo.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get project: %w", err)
+	}
+	if project.MaxSubtasksPerTask != nil {
+		return int(*project.MaxSubtasksPerTask), nil
+	}
+	return s.maxSubtasks, nil
+}
+
+// checkSubtaskLimit fails if a plan has more issues than limit (0 means no limit).
+func checkSubtaskLimit(issues, limit int) error {
+	if limit == 0 || issues <= limit {
+		return nil
+	}
+	reason := fmt.Sprintf("plan has %d subtasks, more than the limit of %d; re-plan the task with fewer, larger subtasks", issues, limit)
+	return fmt.Errorf("%w: %w", ErrTooManySubtasks, domain.NewUnprocessableError("task", reason))
+}
+
+// EmptyPlanError returns the error for a plan without subtasks: ErrEmptyPlan,
+// wrapping an unprocessable error that tells the user to re-plan.
+func EmptyPlanError() error {
+	reason := "the planner created no subtasks; re-plan the task with a more specific description"
+	return fmt.Errorf("%w: %w", ErrEmptyPlan, domain.NewUnprocessableError("task", reason))
+}
+
 // syncIssueToSubtask creates or updates a subtask from a Beads issue.
 func (s *SyncService) syncIssueToSubtask(ctx context.Context, taskID uuid.UUID, issue BeadsIssue) (*domain.Subtask, error) {
 	// Check if subtask already exists
@@ -122,6 +339,16 @@ func (s *SyncService) syncIssueToSubtask(ctx context.Context, taskID uuid.UUID,
 	// Parse spec and implementation plan from description
 	spec, plan := parseIssueBody(issue.Description)
 
+	// Keep the issue's priority when it is on the scale subtasks use
+	priority := issue.Priority
+	if priority != nil && domain.ValidateSubtaskPriority(*priority) != nil {
+		log.Warn().
+			Str("beads_issue_id", issue.ID).
+			Int("priority", *priority).
+			Msg("ignoring out-of-range beads priority")
+		priority = nil
+	}
+
 	// Create new subtask
 	return s.subtaskService.CreateSubtask(ctx, CreateSubtaskInput{
 		TaskID:             taskID,
@@ -129,6 +356,7 @@ func (s *SyncService) syncIssueToSubtask(ctx context.Context, taskID uuid.UUID,
 		Spec:               &spec,
 		ImplementationPlan: &plan,
 		BeadsIssueID:       &issue.ID,
+		Priority:           priority,
 	})
 }
 
@@ -4,7 +4,23 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+	"github.com/intern-village/orchestrator/internal/repository/repotest"
 )
 
 func TestParseIssueBody(t *testing.T) {
@@ -127,7 +143,7 @@ func TestSyncWorker_NewSyncWorker(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			worker := NewSyncWorker(nil, nil, tt.intervalSeconds)
+			worker := NewSyncWorker(nil, nil, tt.intervalSeconds, 0, 0)
 			if worker == nil {
 				t.Fatal("NewSyncWorker returned nil")
 			}
@@ -136,3 +152,537 @@ func TestSyncWorker_NewSyncWorker(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncWorker_SyncProjects_BoundedConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, peak, synced := 0, 0, 0
+	w := NewSyncWorker(nil, nil, 30, 2, time.Minute)
+	w.syncSubtask = func(ctx context.Context, subtaskID uuid.UUID, repoPath string) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		synced++
+		mu.Unlock()
+		return nil
+	}
+
+	var projects []*projectSync
+	for range 5 {
+		projects = append(projects, &projectSync{
+			project:  &domain.Project{ID: uuid.New()},
+			subtasks: []db.Subtask{{ID: uuid.New()}, {ID: uuid.New()}},
+		})
+	}
+	w.syncProjects(context.Background(), projects)
+
+	if synced != 10 {
+		t.Errorf("synced %d subtasks, want 10", synced)
+	}
+	if peak > 2 {
+		t.Errorf("%d subtasks synced at once, want at most 2", peak)
+	}
+}
+
+func TestSyncWorker_SyncProjects_SlowProjectTimesOut(t *testing.T) {
+	slow := &projectSync{
+		project:  &domain.Project{ID: uuid.New(), ClonePath: "slow"},
+		subtasks: []db.Subtask{{ID: uuid.New()}, {ID: uuid.New()}},
+	}
+	fast := &projectSync{
+		project:  &domain.Project{ID: uuid.New(), ClonePath: "fast"},
+		subtasks: []db.Subtask{{ID: uuid.New()}},
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+	w := NewSyncWorker(nil, nil, 30, 2, time.Second)
+	w.projectTimeout = 20 * time.Millisecond
+	w.syncSubtask = func(ctx context.Context, subtaskID uuid.UUID, repoPath string) error {
+		mu.Lock()
+		calls[repoPath]++
+		mu.Unlock()
+		if repoPath == "slow" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.syncProjects(context.Background(), []*projectSync{slow, fast})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("syncProjects did not return after the slow project timed out")
+	}
+
+	if calls["fast"] != 1 {
+		t.Errorf("fast project synced %d times, want 1", calls["fast"])
+	}
+	if calls["slow"] != 1 {
+		t.Errorf("slow project synced %d subtasks, want 1 (the rest skipped after the timeout)", calls["slow"])
+	}
+}
+
+func TestCheckSubtaskLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		issues  int
+		limit   int
+		wantErr bool
+	}{
+		{name: "under limit", issues: 3, limit: 5},
+		{name: "at limit", issues: 5, limit: 5},
+		{name: "over limit", issues: 6, limit: 5, wantErr: true},
+		{name: "no limit", issues: 500, limit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSubtaskLimit(tt.issues, tt.limit)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrTooManySubtasks) {
+				t.Errorf("error should wrap ErrTooManySubtasks, got %v", err)
+			}
+			if !domain.IsUnprocessable(err) {
+				t.Errorf("error should be unprocessable, got %v", err)
+			}
+		})
+	}
+}
+
+// syncDB is a DBTX that serves one task and its project and records every
+// other query, so a test can assert that a sync wrote nothing. If
+// subtaskStatus is set, every beads issue already has a subtask in that status.
+type syncDB struct {
+	taskID             uuid.UUID
+	projectID          uuid.UUID
+	epicID             string
+	maxSubtasksPerTask *int32
+	subtaskStatus      domain.SubtaskStatus
+	subtaskReason      *string
+	unexpected         []string
+}
+
+type syncRow struct {
+	scan func(dest ...any)
+	err  error
+}
+
+func (r syncRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.scan(dest...)
+	return nil
+}
+
+func (d *syncDB) QueryRow(_ context.Context, sql string, _ ...any) pgx.Row {
+	switch {
+	case strings.Contains(sql, "name: GetTaskByID "):
+		return syncRow{scan: func(dest ...any) {
+			*dest[0].(*uuid.UUID) = d.taskID
+			*dest[1].(*uuid.UUID) = d.projectID
+			*dest[4].(*string) = string(domain.TaskStatusPlanning)
+			*dest[5].(**string) = &d.epicID
+		}}
+	case strings.Contains(sql, "name: GetProjectByID "):
+		return syncRow{scan: func(dest ...any) {
+			*dest[0].(*uuid.UUID) = d.projectID
+			*dest[12].(**int32) = d.maxSubtasksPerTask
+		}}
+	case strings.Contains(sql, "name: GetSubtaskByBeadsID ") && d.subtaskStatus != "":
+		return syncRow{scan: func(dest ...any) {
+			*dest[0].(*uuid.UUID) = uuid.New()
+			*dest[1].(*uuid.UUID) = d.taskID
+			*dest[5].(*string) = string(d.subtaskStatus)
+			*dest[6].(**string) = d.subtaskReason
+		}}
+	}
+	d.unexpected = append(d.unexpected, sql)
+	return syncRow{err: errors.New("unexpected query")}
+}
+
+func (d *syncDB) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	d.unexpected = append(d.unexpected, sql)
+	return pgconn.CommandTag{}, errors.New("unexpected exec")
+}
+
+func (d *syncDB) Query(_ context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	d.unexpected = append(d.unexpected, sql)
+	return nil, errors.New("unexpected query")
+}
+
+func (d *syncDB) BeginTx(context.Context, pgx.TxOptions) (pgx.Tx, error) {
+	return nil, errors.New("unexpected transaction")
+}
+
+// fakeBeads is an in-memory BeadsClient serving canned issues and
+// dependencies. The worktree and init methods succeed without doing anything.
+type fakeBeads struct {
+	issues map[string][]BeadsIssue // issues by parent ID
+	deps   map[string][]string     // blocking dependency IDs by issue ID
+	closed []string
+}
+
+// newFakeBeads returns a fakeBeads with n open issues under the epic.
+func newFakeBeads(epicID string, n int) *fakeBeads {
+	issues := make([]BeadsIssue, n)
+	for i := range issues {
+		issues[i] = BeadsIssue{
+			ID:       fmt.Sprintf("%s.%d", epicID, i+1),
+			Type:     "task",
+			Title:    fmt.Sprintf("Issue %d", i+1),
+			Status:   "open",
+			ParentID: epicID,
+		}
+	}
+	return &fakeBeads{issues: map[string][]BeadsIssue{epicID: issues}, deps: map[string][]string{}}
+}
+
+func (f *fakeBeads) Init(_ context.Context, _, prefix string) (string, error) {
+	return prefix, nil
+}
+
+func (f *fakeBeads) ListIssues(_ context.Context, _, parentID string) ([]BeadsIssue, error) {
+	return slices.Clone(f.issues[parentID]), nil
+}
+
+func (f *fakeBeads) ShowIssue(_ context.Context, _, issueID string) (*BeadsIssue, error) {
+	for _, issues := range f.issues {
+		for _, issue := range issues {
+			if issue.ID == issueID {
+				return &issue, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: no issue %s", ErrBeadsShowFailed, issueID)
+}
+
+func (f *fakeBeads) CloseIssue(_ context.Context, _, issueID, _ string) error {
+	f.closed = append(f.closed, issueID)
+	return nil
+}
+
+func (f *fakeBeads) DeleteIssue(context.Context, string, string, bool) error {
+	return nil
+}
+
+func (f *fakeBeads) GetDependencies(_ context.Context, _, issueID string) ([]string, error) {
+	return f.deps[issueID], nil
+}
+
+func (f *fakeBeads) EnsureWorktree(context.Context, string, string, string) error {
+	return nil
+}
+
+func (f *fakeBeads) RemoveWorktree(context.Context, string, string) error {
+	return nil
+}
+
+func (f *fakeBeads) GenerateBranchName(issueID, title string) string {
+	return issueID + "-" + slugify(title)
+}
+
+func TestSyncTaskFromBeads_TooManySubtasks(t *testing.T) {
+	projectLimit := int32(3)
+	tests := []struct {
+		name         string
+		defaultLimit int
+		projectLimit *int32
+		issues       int
+	}{
+		{name: "over default limit", defaultLimit: 5, issues: 6},
+		{name: "project override is stricter", defaultLimit: 50, projectLimit: &projectLimit, issues: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &syncDB{
+				taskID:             uuid.New(),
+				projectID:          uuid.New(),
+				epicID:             "bd-epic",
+				maxSubtasksPerTask: tt.projectLimit,
+			}
+			repo := repository.New(fake)
+			beads := newFakeBeads(fake.epicID, tt.issues)
+			taskService := NewTaskService(repo, nil, nil, beads, nil)
+			subtaskService := NewSubtaskService(repo, taskService, nil, beads, nil, nil, nil)
+			syncService := NewSyncService(repo, beads, subtaskService, nil, taskService)
+			syncService.SetMaxSubtasksPerTask(tt.defaultLimit)
+
+			err := syncService.SyncTaskFromBeads(context.Background(), fake.taskID, t.TempDir())
+			if !errors.Is(err, ErrTooManySubtasks) {
+				t.Fatalf("expected ErrTooManySubtasks, got %v", err)
+			}
+			if len(fake.unexpected) != 0 {
+				t.Errorf("sync should abort before writing anything, ran:\n%s", strings.Join(fake.unexpected, "\n"))
+			}
+		})
+	}
+}
+
+func TestSyncTaskFromBeads_EmptyPlan(t *testing.T) {
+	fake := &syncDB{taskID: uuid.New(), projectID: uuid.New(), epicID: "bd-epic"}
+	repo := repository.New(fake)
+	beads := newFakeBeads(fake.epicID, 0)
+	taskService := NewTaskService(repo, nil, nil, beads, nil)
+	subtaskService := NewSubtaskService(repo, taskService, nil, beads, nil, nil, nil)
+	syncService := NewSyncService(repo, beads, subtaskService, nil, taskService)
+
+	err := syncService.SyncTaskFromBeads(context.Background(), fake.taskID, t.TempDir())
+	if !errors.Is(err, ErrEmptyPlan) || !domain.IsUnprocessable(err) {
+		t.Fatalf("expected an unprocessable ErrEmptyPlan, got %v", err)
+	}
+	if len(fake.unexpected) != 0 {
+		t.Errorf("sync should abort before writing anything, ran:\n%s", strings.Join(fake.unexpected, "\n"))
+	}
+}
+
+func TestAwaitingStart(t *testing.T) {
+	dependency := domain.BlockedReasonDependency
+	failure := domain.BlockedReasonFailure
+
+	tests := []struct {
+		status domain.SubtaskStatus
+		reason *domain.BlockedReason
+		want   bool
+	}{
+		{domain.SubtaskStatusPending, nil, true},
+		{domain.SubtaskStatusReady, nil, true},
+		{domain.SubtaskStatusBlocked, &dependency, true},
+		{domain.SubtaskStatusBlocked, &failure, false},
+		{domain.SubtaskStatusInProgress, nil, false},
+		{domain.SubtaskStatusCompleted, nil, false},
+		{domain.SubtaskStatusMerged, nil, false},
+		{domain.SubtaskStatusCancelled, nil, false},
+	}
+
+	for _, tt := range tests {
+		subtask := &domain.Subtask{Status: tt.status, BlockedReason: tt.reason}
+		if got := awaitingStart(subtask); got != tt.want {
+			t.Errorf("awaitingStart(%s, %v) = %v, want %v", tt.status, tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestSelectNewIssues(t *testing.T) {
+	synced := "bd-epic.1"
+	existing := []db.Subtask{
+		{ID: uuid.New(), Title: "Add OAuth callback", BeadsIssueID: &synced},
+		{ID: uuid.New(), Title: "Store  Sessions"},
+	}
+	issues := []BeadsIssue{
+		{ID: "bd-epic.1", Title: "Add OAuth callback"},
+		{ID: "bd-epic.2", Title: "store sessions"},
+		{ID: "bd-epic.3", Title: "Add logout"},
+		{ID: "bd-epic.4", Title: "Add Logout "},
+		{ID: "bd-epic.5", Title: "Expire sessions"},
+	}
+
+	newIssues, skipped := selectNewIssues(issues, existing)
+
+	ids := func(issues []BeadsIssue) []string {
+		var out []string
+		for _, issue := range issues {
+			out = append(out, issue.ID)
+		}
+		return out
+	}
+	if got, want := ids(newIssues), []string{"bd-epic.3", "bd-epic.5"}; !slices.Equal(got, want) {
+		t.Errorf("new issues = %v, want %v", got, want)
+	}
+	if got, want := ids(skipped), []string{"bd-epic.2", "bd-epic.4"}; !slices.Equal(got, want) {
+		t.Errorf("skipped issues = %v, want %v", got, want)
+	}
+}
+
+func TestSyncTaskFromBeads_LeavesStartedSubtasks(t *testing.T) {
+	failure := string(domain.BlockedReasonFailure)
+	tests := []struct {
+		status domain.SubtaskStatus
+		reason *string
+	}{
+		{status: domain.SubtaskStatusInProgress},
+		{status: domain.SubtaskStatusCompleted},
+		{status: domain.SubtaskStatusMerged},
+		{status: domain.SubtaskStatusBlocked, reason: &failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			fake := &syncDB{
+				taskID:        uuid.New(),
+				projectID:     uuid.New(),
+				epicID:        "bd-epic",
+				subtaskStatus: tt.status,
+				subtaskReason: tt.reason,
+			}
+			repo := repository.New(fake)
+			beads := newFakeBeads(fake.epicID, 2)
+			taskService := NewTaskService(repo, nil, nil, beads, nil)
+			subtaskService := NewSubtaskService(repo, taskService, nil, beads, nil, nil, nil)
+			syncService := NewSyncService(repo, beads, subtaskService, NewDependencyService(repo, nil), taskService)
+
+			if err := syncService.SyncTaskFromBeads(context.Background(), fake.taskID, t.TempDir()); err != nil {
+				t.Fatalf("SyncTaskFromBeads() error = %v", err)
+			}
+			if len(fake.unexpected) != 0 {
+				t.Errorf("re-sync should not touch %s subtasks, ran:\n%s", tt.status, strings.Join(fake.unexpected, "\n"))
+			}
+		})
+	}
+}
+
+// newSyncFixture returns a SyncService over an in-memory store and beads, and
+// a PLANNING task whose epic is epicID.
+func newSyncFixture(t *testing.T, beads *fakeBeads, epicID string) (*SyncService, *repotest.Store, db.Task) {
+	t.Helper()
+	store := repotest.New()
+	task, _ := seedTask(t, store, uuid.New(), domain.TaskStatusPlanning)
+	task, err := store.UpdateTaskBeadsEpicID(context.Background(), db.UpdateTaskBeadsEpicIDParams{ID: task.ID, BeadsEpicID: &epicID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taskService := NewTaskService(store, nil, nil, beads, nil)
+	subtaskService := NewSubtaskService(store, taskService, nil, beads, nil, nil, nil)
+	return NewSyncService(store, beads, subtaskService, NewDependencyService(store, nil), taskService), store, task
+}
+
+func TestSyncTaskFromBeads_CreatesSubtasksWithDependencies(t *testing.T) {
+	beads := newFakeBeads("bd-epic", 3)
+	issues := beads.issues["bd-epic"]
+	issues[0].Description = "## Spec\nAdd the callback\n\n## Implementation Plan\nWire the handler"
+	urgent := domain.HighestSubtaskPriority
+	issues[2].Priority = &urgent
+	issues[1].Dependencies = []BeadsDependency{
+		{IssueID: issues[1].ID, DependsOnID: "bd-epic", Type: "parent-child"},
+		{IssueID: issues[1].ID, DependsOnID: issues[0].ID, Type: "blocks"},
+	}
+	syncService, store, task := newSyncFixture(t, beads, "bd-epic")
+	ctx := context.Background()
+
+	if err := syncService.SyncTaskFromBeads(ctx, task.ID, t.TempDir()); err != nil {
+		t.Fatalf("SyncTaskFromBeads() error = %v", err)
+	}
+
+	subtasks, err := store.ListSubtasksByTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subtasks) != 3 {
+		t.Fatalf("got %d subtasks, want 3", len(subtasks))
+	}
+	byIssue := make(map[string]db.Subtask)
+	for _, st := range subtasks {
+		byIssue[*st.BeadsIssueID] = st
+	}
+
+	first, second, third := byIssue["bd-epic.1"], byIssue["bd-epic.2"], byIssue["bd-epic.3"]
+	if first.Spec == nil || *first.Spec != "Add the callback" || first.ImplementationPlan == nil || *first.ImplementationPlan != "Wire the handler" {
+		t.Errorf("spec and plan were not parsed from the issue body: %v, %v", first.Spec, first.ImplementationPlan)
+	}
+	if first.Priority != domain.DefaultSubtaskPriority || third.Priority != int32(urgent) {
+		t.Errorf("priorities = %d and %d, want the default and the issue's %d", first.Priority, third.Priority, urgent)
+	}
+	if first.Status != string(domain.SubtaskStatusReady) || third.Status != string(domain.SubtaskStatusReady) {
+		t.Errorf("independent subtasks should be READY, got %s and %s", first.Status, third.Status)
+	}
+	if second.Status != string(domain.SubtaskStatusBlocked) || second.BlockedReason == nil || *second.BlockedReason != string(domain.BlockedReasonDependency) {
+		t.Errorf("dependent subtask should be BLOCKED on its dependency, got %s (%v)", second.Status, second.BlockedReason)
+	}
+
+	deps, err := store.GetDependenciesForSubtask(ctx, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 1 || deps[0].DependsOnID != first.ID {
+		t.Errorf("dependencies of %s = %+v, want only %s", second.ID, deps, first.ID)
+	}
+}
+
+func TestSyncTaskFromBeads_Resync(t *testing.T) {
+	beads := newFakeBeads("bd-epic", 2)
+	syncService, store, task := newSyncFixture(t, beads, "bd-epic")
+	ctx := context.Background()
+
+	if err := syncService.SyncTaskFromBeads(ctx, task.ID, t.TempDir()); err != nil {
+		t.Fatalf("first sync error = %v", err)
+	}
+	if err := syncService.SyncTaskFromBeads(ctx, task.ID, t.TempDir()); err != nil {
+		t.Fatalf("second sync error = %v", err)
+	}
+
+	subtasks, err := store.ListSubtasksByTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subtasks) != 2 {
+		t.Errorf("re-sync should not duplicate subtasks, got %d", len(subtasks))
+	}
+}
+
+func TestSyncDependencies(t *testing.T) {
+	beads := newFakeBeads("bd-epic", 2)
+	syncService, store, task := newSyncFixture(t, beads, "bd-epic")
+	ctx := context.Background()
+	if err := syncService.SyncTaskFromBeads(ctx, task.ID, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The Planner adds a dependency after the first sync
+	beads.deps["bd-epic.2"] = []string{"bd-epic.1", "bd-unknown"}
+	if err := syncService.SyncDependencies(ctx, task.ID, t.TempDir()); err != nil {
+		t.Fatalf("SyncDependencies() error = %v", err)
+	}
+
+	firstID, secondID := "bd-epic.1", "bd-epic.2"
+	first, err := store.GetSubtaskByBeadsID(ctx, &firstID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.GetSubtaskByBeadsID(ctx, &secondID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps, err := store.GetDependenciesForSubtask(ctx, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 1 || deps[0].DependsOnID != first.ID {
+		t.Errorf("dependencies of bd-epic.2 = %+v, want only bd-epic.1", deps)
+	}
+}
+
+func TestIsBeadsIssueClosed(t *testing.T) {
+	beads := newFakeBeads("bd-epic", 2)
+	beads.issues["bd-epic"][1].Status = "closed"
+	syncService := NewSyncService(nil, beads, nil, nil, nil)
+	ctx := context.Background()
+
+	for id, want := range map[string]bool{"bd-epic.1": false, "bd-epic.2": true} {
+		closed, err := syncService.IsBeadsIssueClosed(ctx, t.TempDir(), id)
+		if err != nil {
+			t.Fatalf("IsBeadsIssueClosed(%s) error = %v", id, err)
+		}
+		if closed != want {
+			t.Errorf("IsBeadsIssueClosed(%s) = %v, want %v", id, closed, want)
+		}
+	}
+	if _, err := syncService.IsBeadsIssueClosed(ctx, t.TempDir(), "bd-missing"); err == nil {
+		t.Error("expected an error for an unknown issue")
+	}
+}
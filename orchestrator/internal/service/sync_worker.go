@@ -5,40 +5,76 @@ package service
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/metrics"
 )
 
-// SyncWorker runs periodic sync operations in the background.
+// SyncWorker runs periodic sync operations in the background. Each cycle
+// groups in-progress subtasks by project and syncs up to concurrency projects
+// at once, each within projectTimeout, so a slow project cannot hold up the
+// others. Subtasks of a project are synced one at a time.
 type SyncWorker struct {
 	syncService    *SyncService
 	projectService *ProjectService
 	interval       time.Duration
+	concurrency    int
+	projectTimeout time.Duration
+	metrics        *metrics.Metrics
 	stopCh         chan struct{}
 	wg             sync.WaitGroup
 	running        bool
 	mu             sync.Mutex
+
+	// syncSubtask syncs one subtask from its project's clone
+	// (SyncService.SyncSubtaskFromBeads outside tests).
+	syncSubtask func(ctx context.Context, subtaskID uuid.UUID, repoPath string) error
 }
 
-// NewSyncWorker creates a new SyncWorker.
+// NewSyncWorker creates a new SyncWorker. A concurrency below 1 syncs one
+// project at a time; a projectTimeout below one second defaults to a minute.
 func NewSyncWorker(
 	syncService *SyncService,
 	projectService *ProjectService,
 	intervalSeconds int,
+	concurrency int,
+	projectTimeout time.Duration,
 ) *SyncWorker {
 	interval := time.Duration(intervalSeconds) * time.Second
 	if interval < time.Second*5 {
 		interval = time.Second * 30 // Default to 30 seconds
 	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if projectTimeout < time.Second {
+		projectTimeout = time.Minute
+	}
 
-	return &SyncWorker{
+	w := &SyncWorker{
 		syncService:    syncService,
 		projectService: projectService,
 		interval:       interval,
+		concurrency:    concurrency,
+		projectTimeout: projectTimeout,
 		stopCh:         make(chan struct{}),
 	}
+	if syncService != nil {
+		w.syncSubtask = syncService.SyncSubtaskFromBeads
+	}
+	return w
+}
+
+// SetMetrics sets the metrics recorder for project sync durations and errors.
+func (w *SyncWorker) SetMetrics(m *metrics.Metrics) {
+	w.metrics = m
 }
 
 // Start starts the periodic sync worker.
@@ -56,6 +92,8 @@ func (w *SyncWorker) Start() {
 
 	log.Info().
 		Dur("interval", w.interval).
+		Int("concurrency", w.concurrency).
+		Dur("project_timeout", w.projectTimeout).
 		Msg("sync worker started")
 }
 
@@ -92,55 +130,156 @@ func (w *SyncWorker) run() {
 	}
 }
 
+// projectSync is the in-progress subtasks of one project to sync in a cycle.
+type projectSync struct {
+	project  *domain.Project
+	subtasks []db.Subtask
+}
+
 // syncInProgressSubtasks syncs all subtasks that are currently in progress.
+// The cycle is cancelled if the worker is stopped.
 func (w *SyncWorker) syncInProgressSubtasks() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	go func() {
+		select {
+		case <-w.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
-	subtasks, err := w.syncService.GetInProgressSubtasks(ctx)
+	projects, err := w.collectProjects(ctx)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to get in-progress subtasks")
 		return
 	}
 
-	if len(subtasks) == 0 {
+	if len(projects) == 0 {
 		return
 	}
 
 	log.Debug().
-		Int("count", len(subtasks)).
+		Int("projects", len(projects)).
 		Msg("syncing in-progress subtasks")
 
+	w.syncProjects(ctx, projects)
+}
+
+// collectProjects groups the in-progress subtasks that have a beads issue by
+// project. Subtasks of tasks that are not ACTIVE are skipped, so projects
+// with no active task are not synced at all.
+func (w *SyncWorker) collectProjects(ctx context.Context) ([]*projectSync, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	subtasks, err := w.syncService.GetInProgressSubtasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make(map[uuid.UUID]*domain.Task)
+	byProject := make(map[uuid.UUID]*projectSync)
+	var projects []*projectSync
 	for _, subtask := range subtasks {
 		if subtask.BeadsIssueID == nil {
 			continue
 		}
 
-		// Get the task to find the repo path
-		task, err := w.syncService.taskService.GetTaskByIDInternal(ctx, subtask.TaskID)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("task_id", subtask.TaskID.String()).
-				Msg("failed to get task for sync")
+		task, ok := tasks[subtask.TaskID]
+		if !ok {
+			task, err = w.syncService.taskService.GetTaskByIDInternal(ctx, subtask.TaskID)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("task_id", subtask.TaskID.String()).
+					Msg("failed to get task for sync")
+				continue
+			}
+			tasks[subtask.TaskID] = task
+		}
+		if task.Status != domain.TaskStatusActive {
 			continue
 		}
 
-		project, err := w.projectService.GetProjectInternal(ctx, task.ProjectID)
-		if err != nil {
-			log.Error().
-				Err(err).
-				Str("project_id", task.ProjectID.String()).
-				Msg("failed to get project for sync")
-			continue
+		ps, ok := byProject[task.ProjectID]
+		if !ok {
+			project, err := w.projectService.GetProjectInternal(ctx, task.ProjectID)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("project_id", task.ProjectID.String()).
+					Msg("failed to get project for sync")
+				continue
+			}
+			ps = &projectSync{project: project}
+			byProject[task.ProjectID] = ps
+			projects = append(projects, ps)
 		}
+		ps.subtasks = append(ps.subtasks, subtask)
+	}
+
+	return projects, nil
+}
 
-		// Sync the subtask
-		if err := w.syncService.SyncSubtaskFromBeads(ctx, subtask.ID, project.ClonePath); err != nil {
+// syncProjects syncs the projects with at most w.concurrency running at once,
+// and returns when all of them are done.
+func (w *SyncWorker) syncProjects(ctx context.Context, projects []*projectSync) {
+	slots := make(chan struct{}, w.concurrency)
+	var wg sync.WaitGroup
+	for _, ps := range projects {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			w.syncProject(ctx, ps)
+		}()
+	}
+	wg.Wait()
+}
+
+// syncProject syncs a project's subtasks in turn, giving up on the rest once
+// w.projectTimeout has passed. Failures are logged and counted; the next
+// cycle tries again.
+func (w *SyncWorker) syncProject(ctx context.Context, ps *projectSync) {
+	ctx, cancel := context.WithTimeout(ctx, w.projectTimeout)
+	defer cancel()
+
+	start := time.Now()
+	failures := 0
+	for _, subtask := range ps.subtasks {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := w.syncSubtask(ctx, subtask.ID, ps.project.ClonePath); err != nil {
+			failures++
 			log.Error().
 				Err(err).
 				Str("subtask_id", subtask.ID.String()).
 				Msg("failed to sync subtask from beads")
 		}
 	}
+
+	status := "succeeded"
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status = "timeout"
+		log.Warn().
+			Str("project_id", ps.project.ID.String()).
+			Dur("timeout", w.projectTimeout).
+			Int("subtasks", len(ps.subtasks)).
+			Msg("project sync timed out")
+	case ctx.Err() != nil:
+		// The worker is stopping; this cycle does not count
+		return
+	case failures > 0:
+		status = "failed"
+	}
+	w.metrics.ObserveProjectSync(status, time.Since(start), failures)
 }
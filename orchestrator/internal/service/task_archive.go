@@ -0,0 +1,283 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// archiveBatchSize bounds how many tasks one ArchiveTasks pass archives.
+const archiveBatchSize = 100
+
+// ArchiveResult summarizes an ArchiveTasks pass.
+type ArchiveResult struct {
+	Archived      int
+	ArchivedBytes int64
+}
+
+// ArchiveTasks archives DONE and CANCELLED tasks last updated before
+// finishedBefore. Each task's agent runs, logs, and prompts are written to a
+// tar.gz under DATA_DIR/archives, then removed; a task_archives row keeps the
+// summary. With removeWorktrees, worktrees its subtasks left behind are
+// removed too. A task that fails is logged and retried on the next pass.
+func (s *TaskService) ArchiveTasks(ctx context.Context, finishedBefore time.Time, removeWorktrees bool) (*ArchiveResult, error) {
+	tasks, err := s.repo.ListArchivableTasks(ctx, db.ListArchivableTasksParams{
+		UpdatedAt: finishedBefore,
+		Limit:     archiveBatchSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archivable tasks: %w", err)
+	}
+
+	result := &ArchiveResult{}
+	for _, t := range tasks {
+		task := dbTaskToDomain(t)
+		archive, err := s.archiveTask(ctx, task)
+		if err != nil {
+			log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to archive task")
+			continue
+		}
+		if removeWorktrees {
+			s.removeTaskWorktrees(ctx, task)
+		}
+
+		result.Archived++
+		result.ArchivedBytes += archive.SizeBytes
+		log.Info().
+			Str("task_id", task.ID.String()).
+			Int("runs", archive.RunCount).
+			Int64("bytes", archive.SizeBytes).
+			Msg("archived finished task")
+	}
+
+	return result, nil
+}
+
+// archiveTask writes a task's archive, then swaps its agent runs for the
+// summary row and removes the archived directories.
+func (s *TaskService) archiveTask(ctx context.Context, task *domain.Task) (*domain.TaskArchive, error) {
+	runs, err := s.repo.ListAllAgentRunsForTask(ctx, db.ListAllAgentRunsForTaskParams{TaskID: task.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent runs: %w", err)
+	}
+	plannerTokens, err := s.repo.SumPlannerTokenUsageForTask(ctx, pgtype.UUID{Bytes: task.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum planner token usage: %w", err)
+	}
+
+	paths := s.projectService.paths
+	projectID, taskID := task.ProjectID.String(), task.ID.String()
+	dirs := map[string]string{
+		"logs":    paths.Logs(projectID, taskID, ""),
+		"prompts": paths.Prompts(projectID, taskID),
+	}
+	archivePath := paths.Archive(projectID, taskID)
+	size, err := writeTaskArchive(archivePath, runs, dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := s.repo.ArchiveTaskRuns(ctx, db.CreateTaskArchiveParams{
+		TaskID:            task.ID,
+		ArchivePath:       archivePath,
+		RunCount:          int32(len(runs)),
+		PlannerTokenUsage: plannerTokens,
+		SizeBytes:         size,
+	})
+	if err != nil {
+		_ = os.Remove(archivePath)
+		return nil, fmt.Errorf("failed to record task archive: %w", err)
+	}
+
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warn().Err(err).Str("dir", dir).Msg("failed to remove archived task directory")
+		}
+	}
+	return dbTaskArchiveToDomain(archive), nil
+}
+
+// removeTaskWorktrees removes the worktrees a finished task's subtasks still
+// have on disk. Failures are logged; the archive is already written.
+func (s *TaskService) removeTaskWorktrees(ctx context.Context, task *domain.Task) {
+	if s.beadsService == nil {
+		return
+	}
+	project, err := s.projectService.GetProjectByIDInternal(ctx, task.ProjectID)
+	if err != nil || project.ClonePath == "" {
+		return
+	}
+	subtasks, err := s.repo.ListSubtasksByTask(ctx, task.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("task_id", task.ID.String()).Msg("failed to list subtasks for worktree removal")
+		return
+	}
+	for _, st := range subtasks {
+		if st.WorktreePath == nil {
+			continue
+		}
+		if _, err := os.Stat(*st.WorktreePath); err != nil {
+			continue
+		}
+		if err := s.beadsService.RemoveWorktree(ctx, project.ClonePath, *st.WorktreePath); err != nil {
+			log.Warn().Err(err).
+				Str("subtask_id", st.ID.String()).
+				Str("worktree_path", *st.WorktreePath).
+				Msg("failed to remove worktree of archived task")
+		}
+	}
+}
+
+// GetTaskArchive returns the archive summary of a task the user owns.
+// Returns a NotFoundError if the task has not been archived.
+func (s *TaskService) GetTaskArchive(ctx context.Context, taskID, userID uuid.UUID) (*domain.TaskArchive, error) {
+	if err := s.CheckTaskOwnership(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	archive, err := s.repo.GetTaskArchive(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.NewNotFoundError("task archive", taskID.String())
+		}
+		return nil, fmt.Errorf("failed to get task archive: %w", err)
+	}
+	return dbTaskArchiveToDomain(archive), nil
+}
+
+// writeTaskArchive writes runs.json and the files under each of dirs (keyed
+// by their directory name in the archive) to a tar.gz at path, and returns its
+// size. Missing directories are skipped. The archive is written to a temporary
+// file first so a failed write never leaves a truncated archive behind.
+func writeTaskArchive(path string, runs []db.AgentRun, dirs map[string]string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".archive-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = writeTaskArchiveEntries(tw, runs, dirs)
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat archive: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// writeTaskArchiveEntries adds runs.json and the regular files under dirs to tw.
+func writeTaskArchiveEntries(tw *tar.Writer, runs []db.AgentRun, dirs map[string]string) error {
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    "runs.json",
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	for name, root := range dirs {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == root && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			return addArchiveFile(tw, p, filepath.ToSlash(filepath.Join(name, rel)))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addArchiveFile copies the regular file at path into tw as name.
+func addArchiveFile(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// dbTaskArchiveToDomain converts a database TaskArchive to a domain TaskArchive.
+func dbTaskArchiveToDomain(a db.TaskArchive) *domain.TaskArchive {
+	return &domain.TaskArchive{
+		TaskID:            a.TaskID,
+		ArchivePath:       a.ArchivePath,
+		RunCount:          int(a.RunCount),
+		PlannerTokenUsage: int(a.PlannerTokenUsage),
+		SizeBytes:         a.SizeBytes,
+		ArchivedAt:        a.ArchivedAt,
+	}
+}
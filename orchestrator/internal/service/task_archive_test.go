@@ -0,0 +1,108 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/generated/db"
+)
+
+// readArchive returns the contents of each file in the tar.gz at path.
+func readArchive(t *testing.T, path string) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(data)
+	}
+}
+
+func TestWriteTaskArchive(t *testing.T) {
+	root := t.TempDir()
+	logs := filepath.Join(root, "logs")
+	if err := os.MkdirAll(filepath.Join(logs, "sub-1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(logs, "planner.log"), []byte("planning"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(logs, "sub-1", "worker_1.log"), []byte("working"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := []db.AgentRun{{ID: uuid.New(), AgentType: "PLANNER", Status: "SUCCEEDED"}}
+	path := filepath.Join(root, "archives", "p1", "t1.tar.gz")
+	size, err := writeTaskArchive(path, runs, map[string]string{
+		"logs":    logs,
+		"prompts": filepath.Join(root, "missing"),
+	})
+	if err != nil {
+		t.Fatalf("writeTaskArchive() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != info.Size() {
+		t.Errorf("writeTaskArchive() size = %d, want %d", size, info.Size())
+	}
+
+	files := readArchive(t, path)
+	if len(files) != 3 {
+		t.Errorf("archive has %d files, want 3: %v", len(files), files)
+	}
+	if got := files["logs/planner.log"]; got != "planning" {
+		t.Errorf("logs/planner.log = %q, want %q", got, "planning")
+	}
+	if got := files["logs/sub-1/worker_1.log"]; got != "working" {
+		t.Errorf("logs/sub-1/worker_1.log = %q, want %q", got, "working")
+	}
+	var archived []db.AgentRun
+	if err := json.Unmarshal([]byte(files["runs.json"]), &archived); err != nil {
+		t.Fatalf("runs.json: %v", err)
+	}
+	if len(archived) != 1 || archived[0].ID != runs[0].ID {
+		t.Errorf("runs.json = %+v, want %+v", archived, runs)
+	}
+
+	// Only the archive itself is left in its directory
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("archive directory has %d entries, want 1", len(entries))
+	}
+}
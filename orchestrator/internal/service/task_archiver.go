@@ -0,0 +1,112 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// TaskArchiver periodically archives tasks that have been DONE or CANCELLED
+// longer than a configured age.
+type TaskArchiver struct {
+	taskService     *TaskService
+	archiveAfter    time.Duration
+	removeWorktrees bool
+	interval        time.Duration
+	stopCh          chan struct{}
+	wg              sync.WaitGroup
+	running         bool
+	mu              sync.Mutex
+}
+
+// NewTaskArchiver creates a new TaskArchiver.
+func NewTaskArchiver(taskService *TaskService, archiveAfter time.Duration, removeWorktrees bool, interval time.Duration) *TaskArchiver {
+	if interval < time.Minute {
+		interval = time.Hour // Default to hourly
+	}
+
+	return &TaskArchiver{
+		taskService:     taskService,
+		archiveAfter:    archiveAfter,
+		removeWorktrees: removeWorktrees,
+		interval:        interval,
+		stopCh:          make(chan struct{}),
+	}
+}
+
+// Start starts the periodic archival.
+func (w *TaskArchiver) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return
+	}
+
+	w.running = true
+	w.wg.Add(1)
+	go w.run()
+
+	log.Info().
+		Dur("archive_after", w.archiveAfter).
+		Bool("remove_worktrees", w.removeWorktrees).
+		Dur("interval", w.interval).
+		Msg("task archiver started")
+}
+
+// Stop stops the periodic archival gracefully.
+func (w *TaskArchiver) Stop() {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = false
+	w.mu.Unlock()
+
+	close(w.stopCh)
+	w.wg.Wait()
+
+	log.Info().Msg("task archiver stopped")
+}
+
+// run is the main loop for the task archiver.
+func (w *TaskArchiver) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.sweep()
+		}
+	}
+}
+
+// sweep archives tasks finished longer than archiveAfter ago.
+func (w *TaskArchiver) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	result, err := w.taskService.ArchiveTasks(ctx, time.Now().Add(-w.archiveAfter), w.removeWorktrees)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to archive finished tasks")
+		return
+	}
+
+	if result.Archived > 0 {
+		log.Info().
+			Int("archived", result.Archived).
+			Int64("archived_bytes", result.ArchivedBytes).
+			Msg("archived finished tasks")
+	}
+}
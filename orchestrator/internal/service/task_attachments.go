@@ -0,0 +1,92 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// DefaultMaxAttachmentBytes is the largest task attachment accepted unless
+// SetMaxAttachmentBytes changes it.
+const DefaultMaxAttachmentBytes = 128 << 10
+
+// SetMaxAttachmentBytes sets the largest attachment CreateTask accepts.
+func (s *TaskService) SetMaxAttachmentBytes(n int) {
+	s.maxAttachmentBytes = n
+}
+
+// readRepoAttachments reads attachments referenced by paths relative to the
+// root of a project clone. Paths may not leave the clone, including through
+// symlinks, and must name regular files of at most maxBytes bytes.
+func readRepoAttachments(clonePath string, paths []string, maxBytes int) ([]domain.TaskAttachment, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	root, err := filepath.EvalSymlinks(clonePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve clone path: %w", err)
+	}
+
+	attachments := make([]domain.TaskAttachment, 0, len(paths))
+	for _, p := range paths {
+		rel := filepath.FromSlash(strings.TrimSpace(p))
+		if !filepath.IsLocal(rel) {
+			return nil, domain.NewValidationError("attachment_paths", fmt.Sprintf("%q must be a path inside the repository", p))
+		}
+
+		resolved, err := filepath.EvalSymlinks(filepath.Join(root, rel))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, domain.NewValidationError("attachment_paths", fmt.Sprintf("%q does not exist in the repository", p))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve attachment %s: %w", p, err)
+		}
+		if within, err := filepath.Rel(root, resolved); err != nil || !filepath.IsLocal(within) {
+			return nil, domain.NewValidationError("attachment_paths", fmt.Sprintf("%q must be a path inside the repository", p))
+		}
+
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat attachment %s: %w", p, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil, domain.NewValidationError("attachment_paths", fmt.Sprintf("%q is not a file", p))
+		}
+		if info.Size() > int64(maxBytes) {
+			return nil, domain.NewValidationError("attachment_paths", fmt.Sprintf("%q exceeds %d bytes", p, maxBytes))
+		}
+
+		content, err := os.ReadFile(resolved) //nolint:gosec // resolved is checked to be inside the clone
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment %s: %w", p, err)
+		}
+		attachments = append(attachments, domain.TaskAttachment{Name: filepath.ToSlash(rel), Content: content})
+	}
+	return attachments, nil
+}
+
+// saveAttachments writes validated attachments to dir, which the Planner
+// prompt renderer reads them back from.
+func saveAttachments(dir string, attachments []domain.TaskAttachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create attachment directory: %w", err)
+	}
+	for _, a := range attachments {
+		path := filepath.Join(dir, domain.AttachmentFileName(a.Name))
+		if err := os.WriteFile(path, a.Content, 0o600); err != nil {
+			return fmt.Errorf("failed to write attachment %s: %w", a.Name, err)
+		}
+	}
+	return nil
+}
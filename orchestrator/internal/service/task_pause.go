@@ -0,0 +1,172 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// pausedRunError is recorded on Worker runs stopped by PauseTask.
+const pausedRunError = "stopped: task paused"
+
+// PauseTask moves an ACTIVE task to PAUSED. While paused, subtasks cannot be
+// started or retried; merging is still allowed. Workers already running keep
+// going (including their retries) and finish as usual, unless stopWorkers is
+// set: then they are killed, their runs are marked FAILED, and their subtasks
+// are blocked with reason FAILURE so they can be retried after ResumeTask.
+func (s *TaskService) PauseTask(ctx context.Context, taskID, userID uuid.UUID, stopWorkers bool) (*domain.Task, error) {
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status == domain.TaskStatusPaused {
+		return nil, domain.NewConflictError("task", "task is already paused")
+	}
+	if !domain.CanTransitionTask(task.Status, domain.TaskStatusPaused) {
+		return nil, domain.NewInvalidTransitionError(
+			"task",
+			string(task.Status),
+			string(domain.TaskStatusPaused),
+			"only ACTIVE tasks can be paused",
+		)
+	}
+
+	updatedTask, err := s.setTaskStatus(ctx, task, domain.TaskStatusPaused)
+	if err != nil {
+		return nil, err
+	}
+	s.auditTask(ctx, userID, AuditActionTaskPause, task, task.Status, updatedTask.Status)
+
+	if stopWorkers {
+		s.stopWorkers(ctx, task)
+	}
+
+	return updatedTask, nil
+}
+
+// ResumeTask moves a PAUSED task back to ACTIVE so subtasks can be started
+// again. Nothing is restarted automatically. If every subtask was resolved
+// while the task was paused, the task moves on to DONE.
+func (s *TaskService) ResumeTask(ctx context.Context, taskID, userID uuid.UUID) (*domain.Task, error) {
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status != domain.TaskStatusPaused {
+		return nil, domain.NewConflictError("task", "task is not paused")
+	}
+
+	updatedTask, err := s.setTaskStatus(ctx, task, domain.TaskStatusActive)
+	if err != nil {
+		return nil, err
+	}
+	s.auditTask(ctx, userID, AuditActionTaskResume, task, task.Status, updatedTask.Status)
+
+	done, err := s.CheckTaskCompletion(ctx, taskID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", taskID.String()).Msg("failed to check task completion after resume")
+	}
+	if done {
+		return s.GetTaskByIDInternal(ctx, taskID)
+	}
+
+	return updatedTask, nil
+}
+
+// setTaskStatus persists a user-initiated task status change and publishes it.
+func (s *TaskService) setTaskStatus(ctx context.Context, task *domain.Task, status domain.TaskStatus) (*domain.Task, error) {
+	dbTask, err := s.repo.UpdateTaskStatus(ctx, db.UpdateTaskStatusParams{
+		ID:     task.ID,
+		Status: string(status),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task status: %w", err)
+	}
+
+	if s.eventHub != nil {
+		s.eventHub.PublishTaskStatusChanged(task.ProjectID, task.ID, string(task.Status), string(status))
+	}
+
+	return dbTaskToDomain(dbTask), nil
+}
+
+// stopWorkers kills the task's running Workers and blocks their subtasks with
+// reason FAILURE. The killed agent loop cannot record this itself because its
+// context is already cancelled. Errors are logged: the task is paused either way.
+func (s *TaskService) stopWorkers(ctx context.Context, task *domain.Task) {
+	if s.agentSpawner != nil {
+		if err := s.agentSpawner.KillAgentsForTask(ctx, task.ID); err != nil {
+			log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to kill agents for paused task")
+		}
+	}
+
+	subtasks, err := s.repo.ListSubtasksByTask(ctx, task.ID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to list subtasks of paused task")
+		return
+	}
+
+	reason := string(domain.BlockedReasonFailure)
+	for _, st := range subtasks {
+		if st.Status != string(domain.SubtaskStatusInProgress) {
+			continue
+		}
+
+		s.failRunningRun(ctx, st.ID, pausedRunError)
+
+		dbSubtask, err := s.repo.UpdateSubtaskStatus(ctx, db.UpdateSubtaskStatusParams{
+			ID:            st.ID,
+			Status:        string(domain.SubtaskStatusBlocked),
+			BlockedReason: &reason,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("subtask_id", st.ID.String()).Msg("failed to block subtask of paused task")
+			continue
+		}
+
+		if s.eventHub != nil {
+			s.eventHub.PublishSubtaskStatusChanged(task.ProjectID, dbSubtaskToDomain(dbSubtask), st.Status)
+		}
+	}
+}
+
+// failRunningRun marks the subtask's latest agent run FAILED with errorMsg if
+// it is still RUNNING.
+func (s *TaskService) failRunningRun(ctx context.Context, subtaskID uuid.UUID, errorMsg string) {
+	run, err := s.repo.GetLatestAgentRun(ctx, pgtype.UUID{Bytes: subtaskID, Valid: true})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error().Err(err).Str("subtask_id", subtaskID.String()).Msg("failed to get latest agent run")
+		}
+		return
+	}
+	if run.Status != string(domain.AgentRunStatusRunning) {
+		return
+	}
+
+	now := time.Now()
+	_, err = s.repo.UpdateAgentRunStatus(ctx, db.UpdateAgentRunStatusParams{
+		ID:           run.ID,
+		Status:       string(domain.AgentRunStatusFailed),
+		EndedAt:      repository.PointerToTimestamptz(&now),
+		ErrorMessage: &errorMsg,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("run_id", run.ID.String()).Msg("failed to mark agent run failed")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// ReplanTask re-runs the Planner for an ACTIVE task to add the subtasks still
+// needed to finish it, e.g. after the task's scope grew. Unlike RetryPlanning
+// the task stays ACTIVE and its existing subtasks, including running and
+// merged ones, are kept: the Planner is shown them and told to only add new
+// issues under the task's epic, and only those are synced (see
+// SyncService.SyncNewIssuesFromBeads). Each new subtask is published as
+// subtask:created.
+//
+// The Planner is spawned before returning, so a refused spawn is reported to
+// the caller rather than failing the task.
+func (s *TaskService) ReplanTask(ctx context.Context, taskID, userID uuid.UUID) (*domain.Task, error) {
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status != domain.TaskStatusActive {
+		return nil, domain.NewUnprocessableError("task", fmt.Sprintf("can only re-plan ACTIVE tasks, task is %s", task.Status))
+	}
+	if task.BeadsEpicID == nil || *task.BeadsEpicID == "" {
+		return nil, domain.NewUnprocessableError("task", "task has no beads epic to add subtasks to")
+	}
+
+	project, err := s.projectService.GetProject(ctx, task.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.agentSpawner == nil {
+		return nil, errors.New("agent spawner not configured")
+	}
+
+	if err := s.projectService.CheckClone(project); err != nil {
+		return nil, err
+	}
+
+	// Plan against the latest base branch, as RetryPlanning does
+	if s.githubService != nil {
+		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, task.BaseBranch, project.IsFork, 3); err != nil {
+			return nil, fmt.Errorf("failed to sync repository before re-planning: %w", err)
+		}
+	}
+
+	if err := s.agentSpawner.SpawnPlanner(ctx, task, project); err != nil {
+		if errors.Is(err, ErrAgentAlreadyRunning) {
+			return nil, domain.NewConflictError("task", "planner already running")
+		}
+		return nil, fmt.Errorf("failed to start planner: %w", err)
+	}
+
+	s.auditTask(ctx, userID, AuditActionTaskReplan, task, task.Status, task.Status)
+	return task, nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// ResyncTask re-runs the beads sync for a task whose board has drifted from
+// beads, e.g. after manual bd edits or a partial sync failure, and returns the
+// task's subtasks afterwards. Missing subtasks are created and dependencies
+// added; only subtasks that have not started have their status recomputed, so
+// it is safe to run while Workers are active.
+func (s *TaskService) ResyncTask(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Subtask, error) {
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch task.Status {
+	case domain.TaskStatusActive, domain.TaskStatusPaused, domain.TaskStatusDone:
+	default:
+		return nil, domain.NewUnprocessableError("task", fmt.Sprintf("cannot re-sync a task in %s status", task.Status))
+	}
+	if task.BeadsEpicID == nil || *task.BeadsEpicID == "" {
+		return nil, domain.NewUnprocessableError("task", "task has no beads epic to sync from")
+	}
+
+	project, err := s.projectService.GetProject(ctx, task.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.planSyncer == nil {
+		return nil, errors.New("plan syncer not configured")
+	}
+
+	// Two re-syncs at once could both create a subtask for the same new issue
+	if _, busy := s.resyncing.LoadOrStore(taskID, struct{}{}); busy {
+		return nil, domain.NewConflictError("task", "re-sync already in progress")
+	}
+	defer s.resyncing.Delete(taskID)
+
+	if err := s.planSyncer.SyncTaskFromBeads(ctx, taskID, project.ClonePath); err != nil {
+		return nil, fmt.Errorf("failed to re-sync task from beads: %w", err)
+	}
+
+	subtasks, err := s.repo.ListSubtasksByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subtasks: %w", err)
+	}
+
+	result := make([]*domain.Subtask, len(subtasks))
+	for i, subtask := range subtasks {
+		result[i] = dbSubtaskToDomain(subtask)
+	}
+	return result, nil
+}
@@ -7,15 +7,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
 
 	"github.com/intern-village/orchestrator/generated/db"
 	"github.com/intern-village/orchestrator/internal/domain"
 	"github.com/intern-village/orchestrator/internal/repository"
 )
 
+// ErrAgentAlreadyRunning is returned by spawners when an agent is already
+// running for the task or subtask.
+var ErrAgentAlreadyRunning = errors.New("agent already running")
+
 // AgentSpawner is an interface for spawning agents.
 // This allows us to mock agent spawning in tests.
 type AgentSpawner interface {
@@ -25,20 +33,26 @@ type AgentSpawner interface {
 
 // TaskService handles task management operations.
 type TaskService struct {
-	repo           *repository.Repository
+	repo           repository.TaskStore
 	projectService *ProjectService
 	githubService  *GitHubService
-	beadsService   *BeadsService
+	beadsService   BeadsClient
 	agentSpawner   AgentSpawner
+	planSyncer     PlanSyncer
+	quotas         *QuotaService
+	audit          *AuditService
 	eventHub       EventHub
+	resyncing      sync.Map // task IDs with a ResyncTask in progress
+
+	maxAttachmentBytes int
 }
 
 // NewTaskService creates a new TaskService.
 func NewTaskService(
-	repo *repository.Repository,
+	repo repository.TaskStore,
 	projectService *ProjectService,
 	githubService *GitHubService,
-	beadsService *BeadsService,
+	beadsService BeadsClient,
 	eventHub EventHub,
 ) *TaskService {
 	return &TaskService{
@@ -47,6 +61,8 @@ func NewTaskService(
 		githubService:  githubService,
 		beadsService:   beadsService,
 		eventHub:       eventHub,
+
+		maxAttachmentBytes: DefaultMaxAttachmentBytes,
 	}
 }
 
@@ -56,12 +72,45 @@ func (s *TaskService) SetAgentSpawner(spawner AgentSpawner) {
 	s.agentSpawner = spawner
 }
 
+// SetQuotaService sets the per-user quotas CreateTask enforces.
+func (s *TaskService) SetQuotaService(quotas *QuotaService) {
+	s.quotas = quotas
+}
+
+// SetAuditService sets the audit log that user-triggered transitions are recorded in.
+func (s *TaskService) SetAuditService(audit *AuditService) {
+	s.audit = audit
+}
+
+// auditTask records a user-triggered task transition. An empty status means
+// the task did not exist before (or no longer exists after) the action.
+func (s *TaskService) auditTask(ctx context.Context, userID uuid.UUID, action string, task *domain.Task, oldStatus, newStatus domain.TaskStatus) {
+	s.audit.Record(ctx, AuditEvent{
+		ProjectID:  task.ProjectID,
+		UserID:     userID,
+		Action:     action,
+		EntityType: AuditEntityTask,
+		EntityID:   task.ID,
+		OldStatus:  string(oldStatus),
+		NewStatus:  string(newStatus),
+	})
+}
+
 // CreateTaskInput contains the input for creating a task.
 type CreateTaskInput struct {
 	ProjectID   uuid.UUID
 	UserID      uuid.UUID
 	Title       string
 	Description string
+	DryRun      bool   // Hold the plan for review instead of creating subtasks
+	BaseBranch  string // Branch to plan against and open PRs into; empty uses the project default
+	GitHubToken string // Decrypted token, used to check that BaseBranch exists
+
+	// Attachments are uploaded files given to the Planner as extra context.
+	Attachments []domain.TaskAttachment
+	// AttachmentPaths are files in the repository, relative to its root, that
+	// are read after the sync and given to the Planner like Attachments.
+	AttachmentPaths []string
 }
 
 // CreateTask creates a new task and spawns the Planner agent.
@@ -71,26 +120,69 @@ func (s *TaskService) CreateTask(ctx context.Context, input CreateTaskInput) (*d
 	if err != nil {
 		return nil, err
 	}
+	if err := s.projectService.CheckClone(project); err != nil {
+		return nil, err
+	}
+
+	if s.quotas != nil {
+		if err := s.quotas.CheckActiveTasks(ctx, input.UserID); err != nil {
+			return nil, err
+		}
+		if err := s.quotas.CheckConcurrentAgents(project.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(input.Attachments)+len(input.AttachmentPaths) > domain.MaxTaskAttachments {
+		return nil, domain.NewValidationError("attachments", fmt.Sprintf("at most %d attachments are allowed", domain.MaxTaskAttachments))
+	}
+	if err := domain.ValidateTaskAttachments(input.Attachments, s.maxAttachmentBytes); err != nil {
+		return nil, err
+	}
+
+	baseBranch, err := s.resolveBaseBranch(ctx, project, input.BaseBranch, input.GitHubToken)
+	if err != nil {
+		return nil, err
+	}
 
 	// Sync repository to latest before planning (see §9.5 Repository Sync Strategy)
 	if s.githubService != nil {
-		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, project.DefaultBranch, project.IsFork, 3); err != nil {
+		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, baseBranch, project.IsFork, 3); err != nil {
 			return nil, fmt.Errorf("failed to sync repository before planning: %w", err)
 		}
 	}
 
+	// Repository attachments are read at the base branch the Planner sees
+	repoAttachments, err := readRepoAttachments(project.ClonePath, input.AttachmentPaths, s.maxAttachmentBytes)
+	if err != nil {
+		return nil, err
+	}
+	attachments := slices.Concat(input.Attachments, repoAttachments)
+	if err := domain.ValidateTaskAttachments(attachments, s.maxAttachmentBytes); err != nil {
+		return nil, err
+	}
+
 	// Create the task record
 	dbTask, err := s.repo.CreateTask(ctx, db.CreateTaskParams{
 		ProjectID:   input.ProjectID,
 		Title:       input.Title,
 		Description: input.Description,
 		Status:      string(domain.TaskStatusPlanning),
+		DryRun:      input.DryRun,
+		BaseBranch:  baseBranch,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
 	task := dbTaskToDomain(dbTask)
+	if err := saveAttachments(s.projectService.AttachmentsDir(task.ProjectID, task.ID), attachments); err != nil {
+		if delErr := s.repo.DeleteTask(ctx, task.ID); delErr != nil {
+			log.Error().Err(delErr).Str("task_id", task.ID.String()).Msg("failed to delete task after attachment write failure")
+		}
+		return nil, err
+	}
+	s.auditTask(ctx, input.UserID, AuditActionTaskCreate, task, "", task.Status)
 
 	// Publish task:status_changed event (nil -> PLANNING)
 	if s.eventHub != nil {
@@ -99,20 +191,44 @@ func (s *TaskService) CreateTask(ctx context.Context, input CreateTaskInput) (*d
 
 	// Spawn Planner agent asynchronously if spawner is set
 	if s.agentSpawner != nil {
-		go func() {
-			// Use background context since this is async
-			bgCtx := context.Background()
-			if err := s.agentSpawner.SpawnPlanner(bgCtx, task, project); err != nil {
-				// Log error but don't fail the task creation
-				// The task will be in PLANNING state and can be retried
-				fmt.Printf("failed to spawn planner for task %s: %v\n", task.ID, err)
-			}
-		}()
+		go s.spawnPlanner(task, project, "")
 	}
 
 	return task, nil
 }
 
+// resolveBaseBranch returns the branch a new task is based on: the project
+// default branch, or the requested branch once GitHub confirms it exists in
+// the repository the clone syncs from.
+func (s *TaskService) resolveBaseBranch(ctx context.Context, project *domain.Project, branch, token string) (string, error) {
+	if branch == "" {
+		return project.DefaultBranch, nil
+	}
+	branch, err := domain.NormalizeBranchName("base_branch", branch)
+	if err != nil {
+		return "", err
+	}
+	if branch == project.DefaultBranch {
+		return project.DefaultBranch, nil
+	}
+	if s.githubService == nil {
+		return branch, nil
+	}
+
+	owner, repo := project.GitHubOwner, project.GitHubRepo
+	if project.IsFork && project.UpstreamOwner != nil && project.UpstreamRepo != nil {
+		owner, repo = *project.UpstreamOwner, *project.UpstreamRepo
+	}
+	exists, err := s.githubService.BranchExists(ctx, owner, repo, branch, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to check base branch: %w", err)
+	}
+	if !exists {
+		return "", domain.NewValidationError("base_branch", fmt.Sprintf("branch %q does not exist in %s/%s", branch, owner, repo))
+	}
+	return branch, nil
+}
+
 // GetTask retrieves a task by ID with ownership verification.
 func (s *TaskService) GetTask(ctx context.Context, taskID, userID uuid.UUID) (*domain.Task, error) {
 	task, err := s.repo.GetTaskByID(ctx, taskID)
@@ -132,6 +248,13 @@ func (s *TaskService) GetTask(ctx context.Context, taskID, userID uuid.UUID) (*d
 	return dbTaskToDomain(task), nil
 }
 
+// CheckTaskOwnership verifies that the user owns the task (via task -> project chain).
+// Returns nil if ownership is valid, or an error if not.
+func (s *TaskService) CheckTaskOwnership(ctx context.Context, taskID, userID uuid.UUID) error {
+	_, err := s.GetTask(ctx, taskID, userID)
+	return err
+}
+
 // ListTasks lists all tasks for a project.
 func (s *TaskService) ListTasks(ctx context.Context, projectID, userID uuid.UUID) ([]*domain.Task, error) {
 	// Verify project access
@@ -165,7 +288,7 @@ func (s *TaskService) DeleteTask(ctx context.Context, taskID, userID uuid.UUID)
 	if s.agentSpawner != nil {
 		if err := s.agentSpawner.KillAgentsForTask(ctx, task.ID); err != nil {
 			// Log but continue - we still want to delete the task
-			fmt.Printf("failed to kill agents for task %s: %v\n", taskID, err)
+			log.Error().Err(err).Str("task_id", taskID.String()).Msg("failed to kill agents before deleting task")
 		}
 	}
 
@@ -175,7 +298,10 @@ func (s *TaskService) DeleteTask(ctx context.Context, taskID, userID uuid.UUID)
 		if err == nil && project.ClonePath != "" {
 			if err := s.beadsService.DeleteIssue(ctx, project.ClonePath, *task.BeadsEpicID, true); err != nil {
 				// Log but continue - we still want to delete the task from DB
-				fmt.Printf("failed to delete beads epic %s: %v\n", *task.BeadsEpicID, err)
+				log.Error().Err(err).
+					Str("task_id", taskID.String()).
+					Str("beads_epic_id", *task.BeadsEpicID).
+					Msg("failed to delete beads epic before deleting task")
 			}
 		}
 	}
@@ -184,6 +310,8 @@ func (s *TaskService) DeleteTask(ctx context.Context, taskID, userID uuid.UUID)
 	if err := s.repo.DeleteTask(ctx, taskID); err != nil {
 		return fmt.Errorf("failed to delete task: %w", err)
 	}
+	_ = os.RemoveAll(s.projectService.AttachmentsDir(task.ProjectID, task.ID))
+	s.auditTask(ctx, userID, AuditActionTaskDelete, task, task.Status, "")
 
 	return nil
 }
@@ -217,9 +345,19 @@ func (s *TaskService) RetryPlanning(ctx context.Context, taskID, userID uuid.UUI
 		return nil, err
 	}
 
+	if s.quotas != nil {
+		if err := s.quotas.CheckConcurrentAgents(project.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.projectService.CheckClone(project); err != nil {
+		return nil, err
+	}
+
 	// Sync repository to latest before retrying planning (see §9.5 Repository Sync Strategy)
 	if s.githubService != nil {
-		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, project.DefaultBranch, project.IsFork, 3); err != nil {
+		if err := s.githubService.SyncRepoWithRetry(ctx, project.ClonePath, task.BaseBranch, project.IsFork, 3); err != nil {
 			return nil, fmt.Errorf("failed to sync repository before planning: %w", err)
 		}
 	}
@@ -236,6 +374,7 @@ func (s *TaskService) RetryPlanning(ctx context.Context, taskID, userID uuid.UUI
 	}
 
 	updatedTask := dbTaskToDomain(dbTask)
+	s.auditTask(ctx, userID, AuditActionTaskRetryPlanning, task, task.Status, updatedTask.Status)
 
 	// Publish task:status_changed event (PLANNING_FAILED -> PLANNING)
 	if s.eventHub != nil {
@@ -244,17 +383,72 @@ func (s *TaskService) RetryPlanning(ctx context.Context, taskID, userID uuid.UUI
 
 	// Spawn Planner agent asynchronously
 	if s.agentSpawner != nil {
-		go func() {
-			bgCtx := context.Background()
-			if err := s.agentSpawner.SpawnPlanner(bgCtx, updatedTask, project); err != nil {
-				fmt.Printf("failed to spawn planner for task %s: %v\n", taskID, err)
-			}
-		}()
+		go s.spawnPlanner(updatedTask, project, task.Status)
 	}
 
 	return updatedTask, nil
 }
 
+// spawnPlanner spawns the Planner for a task. Run it in a goroutine: there is
+// no caller to return an error to, so a refused or failed spawn is published as
+// agent:failed and the task is moved to PLANNING_FAILED, where it can be retried,
+// rather than left in PLANNING with no Planner. A refusal for the concurrent
+// agent quota, which a request lost to a concurrent one after passing the check
+// up front, instead undoes the request: the task returns to prevStatus, or is
+// deleted again if it was just created (prevStatus is empty).
+func (s *TaskService) spawnPlanner(task *domain.Task, project *domain.Project, prevStatus domain.TaskStatus) {
+	ctx := context.Background()
+	err := s.agentSpawner.SpawnPlanner(ctx, task, project)
+	if err == nil {
+		return
+	}
+
+	log.Error().Err(err).
+		Str("task_id", task.ID.String()).
+		Bool("already_running", errors.Is(err, ErrAgentAlreadyRunning)).
+		Msg("failed to spawn planner")
+
+	if s.eventHub != nil {
+		run := &domain.AgentRun{TaskID: &task.ID, AgentType: domain.AgentTypePlanner}
+		s.eventHub.PublishAgentFailed(project.ID, run, task.ID, fmt.Sprintf("failed to start planner: %v", err), false, nil)
+	}
+
+	if domain.IsQuotaExceeded(err) {
+		s.releasePlanning(ctx, task, prevStatus)
+		return
+	}
+
+	if err := s.MarkPlanningFailed(ctx, task.ID); err != nil {
+		log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to mark planning failed after spawn failure")
+	}
+}
+
+// releasePlanning undoes the request that moved a task to PLANNING after its
+// Planner was refused: the task returns to prevStatus, or is deleted if the
+// request created it.
+func (s *TaskService) releasePlanning(ctx context.Context, task *domain.Task, prevStatus domain.TaskStatus) {
+	if prevStatus == "" {
+		if err := s.repo.DeleteTask(ctx, task.ID); err != nil {
+			log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to delete task after planner was refused")
+			return
+		}
+		_ = os.RemoveAll(s.projectService.AttachmentsDir(task.ProjectID, task.ID))
+	} else {
+		_, err := s.repo.UpdateTaskStatus(ctx, db.UpdateTaskStatusParams{
+			ID:     task.ID,
+			Status: string(prevStatus),
+		})
+		if err != nil {
+			log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to restore task status after planner was refused")
+			return
+		}
+	}
+
+	if s.eventHub != nil {
+		s.eventHub.PublishTaskStatusChanged(task.ProjectID, task.ID, string(domain.TaskStatusPlanning), string(prevStatus))
+	}
+}
+
 // MarkPlanningFailed transitions a task to PLANNING_FAILED status.
 // Called when the Planner agent exceeds max retries.
 func (s *TaskService) MarkPlanningFailed(ctx context.Context, taskID uuid.UUID) error {
@@ -333,7 +527,88 @@ func (s *TaskService) TransitionToActive(ctx context.Context, taskID uuid.UUID)
 	return nil
 }
 
-// CheckTaskCompletion checks if all subtasks are merged and transitions task to DONE.
+// CompleteEmptyPlan transitions a task whose Planner created no subtasks
+// straight from PLANNING to DONE, publishing task:status_changed and
+// task:completed. Called instead of failing planning when EMPTY_PLAN_ACTION
+// is "done".
+func (s *TaskService) CompleteEmptyPlan(ctx context.Context, taskID uuid.UUID) error {
+	task, err := s.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.NewNotFoundError("task", taskID.String())
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	oldStatus := task.Status
+
+	// Validate transition; an ACTIVE task is only done once its subtasks are
+	if task.Status != string(domain.TaskStatusPlanning) || !domain.CanTransitionTask(domain.TaskStatus(task.Status), domain.TaskStatusDone) {
+		return domain.NewInvalidTransitionError(
+			"task",
+			task.Status,
+			string(domain.TaskStatusDone),
+			"task is not in PLANNING status",
+		)
+	}
+
+	doneTask, err := s.repo.UpdateTaskStatus(ctx, db.UpdateTaskStatusParams{
+		ID:     taskID,
+		Status: string(domain.TaskStatusDone),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update task status: %w", err)
+	}
+
+	// Publish task:status_changed event (PLANNING -> DONE)
+	if s.eventHub != nil {
+		s.eventHub.PublishTaskStatusChanged(task.ProjectID, taskID, oldStatus, string(domain.TaskStatusDone))
+		s.publishTaskCompleted(ctx, dbTaskToDomain(doneTask))
+	}
+
+	return nil
+}
+
+// TransitionToAwaitingApproval transitions a dry-run task to AWAITING_APPROVAL.
+// Called when the Planner agent completes for a task created with DryRun.
+func (s *TaskService) TransitionToAwaitingApproval(ctx context.Context, taskID uuid.UUID) error {
+	task, err := s.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.NewNotFoundError("task", taskID.String())
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	oldStatus := task.Status
+
+	// Validate transition
+	if !domain.CanTransitionTask(domain.TaskStatus(task.Status), domain.TaskStatusAwaitingApproval) {
+		return domain.NewInvalidTransitionError(
+			"task",
+			task.Status,
+			string(domain.TaskStatusAwaitingApproval),
+			"task is not in PLANNING status",
+		)
+	}
+
+	_, err = s.repo.UpdateTaskStatus(ctx, db.UpdateTaskStatusParams{
+		ID:     taskID,
+		Status: string(domain.TaskStatusAwaitingApproval),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update task status: %w", err)
+	}
+
+	// Publish task:status_changed event (PLANNING -> AWAITING_APPROVAL)
+	if s.eventHub != nil {
+		s.eventHub.PublishTaskStatusChanged(task.ProjectID, taskID, oldStatus, string(domain.TaskStatusAwaitingApproval))
+	}
+
+	return nil
+}
+
+// CheckTaskCompletion checks if all subtasks are merged or cancelled and transitions task to DONE.
 func (s *TaskService) CheckTaskCompletion(ctx context.Context, taskID uuid.UUID) (bool, error) {
 	task, err := s.repo.GetTaskByID(ctx, taskID)
 	if err != nil {
@@ -354,22 +629,29 @@ func (s *TaskService) CheckTaskCompletion(ctx context.Context, taskID uuid.UUID)
 		return false, fmt.Errorf("failed to list subtasks: %w", err)
 	}
 
-	// Check if all subtasks are merged
+	// Check if all subtasks are resolved (see IsResolved) and at least one
+	// was done, merged or completed without changes; a task whose subtasks
+	// were all cancelled is not done.
 	if len(subtasks) == 0 {
 		return false, nil
 	}
 
-	allMerged := true
+	allResolved := true
+	anyDone := false
 	for _, st := range subtasks {
-		if st.Status != string(domain.SubtaskStatusMerged) {
-			allMerged = false
+		status := domain.SubtaskStatus(st.Status)
+		if !status.IsResolved() {
+			allResolved = false
 			break
 		}
+		if status == domain.SubtaskStatusMerged || status == domain.SubtaskStatusCompletedNoChanges {
+			anyDone = true
+		}
 	}
 
-	if allMerged {
+	if allResolved && anyDone {
 		// Transition to DONE
-		_, err = s.repo.UpdateTaskStatus(ctx, db.UpdateTaskStatusParams{
+		doneTask, err := s.repo.UpdateTaskStatus(ctx, db.UpdateTaskStatusParams{
 			ID:     taskID,
 			Status: string(domain.TaskStatusDone),
 		})
@@ -377,9 +659,10 @@ func (s *TaskService) CheckTaskCompletion(ctx context.Context, taskID uuid.UUID)
 			return false, fmt.Errorf("failed to update task status: %w", err)
 		}
 
-		// Publish task:status_changed event (ACTIVE -> DONE)
+		// Publish task:status_changed event (ACTIVE -> DONE), then task:completed
 		if s.eventHub != nil {
 			s.eventHub.PublishTaskStatusChanged(task.ProjectID, taskID, task.Status, string(domain.TaskStatusDone))
+			s.publishTaskCompleted(ctx, dbTaskToDomain(doneTask))
 		}
 
 		return true, nil
@@ -388,6 +671,28 @@ func (s *TaskService) CheckTaskCompletion(ctx context.Context, taskID uuid.UUID)
 	return false, nil
 }
 
+// TaskCompletionSummary totals a finished task for the task:completed event.
+type TaskCompletionSummary struct {
+	SubtaskCount int
+	MergedCount  int
+	TokenUsage   int64 // Planner and Worker tokens
+}
+
+// publishTaskCompleted publishes task:completed for a task that has just moved
+// to DONE. The task is already done, so a failure to total it is only logged.
+func (s *TaskService) publishTaskCompleted(ctx context.Context, task *domain.Task) {
+	row, err := s.repo.GetTaskCompletionSummary(ctx, task.ID)
+	if err != nil {
+		log.Error().Err(err).Str("task_id", task.ID.String()).Msg("failed to summarize completed task")
+		return
+	}
+	s.eventHub.PublishTaskCompleted(task.ProjectID, task, TaskCompletionSummary{
+		SubtaskCount: int(row.SubtaskCount),
+		MergedCount:  int(row.MergedCount),
+		TokenUsage:   row.TokenUsage,
+	})
+}
+
 // UpdateBeadsEpicID sets the beads epic ID for a task.
 func (s *TaskService) UpdateBeadsEpicID(ctx context.Context, taskID uuid.UUID, epicID string) error {
 	_, err := s.repo.UpdateTaskBeadsEpicID(ctx, db.UpdateTaskBeadsEpicIDParams{
@@ -422,6 +727,8 @@ func dbTaskToDomain(t db.Task) *domain.Task {
 		Description: t.Description,
 		Status:      domain.TaskStatus(t.Status),
 		BeadsEpicID: t.BeadsEpicID,
+		DryRun:      t.DryRun,
+		BaseBranch:  t.BaseBranch,
 		CreatedAt:   t.CreatedAt,
 		UpdatedAt:   t.UpdatedAt,
 	}
@@ -4,9 +4,21 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/config"
 	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+	"github.com/intern-village/orchestrator/internal/repository/repotest"
 )
 
 func TestDbTaskToDomain(t *testing.T) {
@@ -46,10 +58,10 @@ func TestTaskStateTransitions(t *testing.T) {
 			expected: true,
 		},
 		{
-			name:     "planning to done is invalid",
+			name:     "planning to done is valid (empty plan)",
 			from:     domain.TaskStatusPlanning,
 			to:       domain.TaskStatusDone,
-			expected: false,
+			expected: true,
 		},
 		{
 			name:     "done to active is invalid",
@@ -218,3 +230,326 @@ func TestValidateSubtaskTransition(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveBaseBranch(t *testing.T) {
+	svc := &TaskService{} // no GitHub service: existence is not checked
+	project := &domain.Project{DefaultBranch: "main"}
+
+	tests := []struct {
+		name    string
+		branch  string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty uses project default", branch: "", want: "main"},
+		{name: "project default", branch: "main", want: "main"},
+		{name: "release branch", branch: "release/1.2", want: "release/1.2"},
+		{name: "full ref", branch: "refs/heads/release/1.2", want: "release/1.2"},
+		{name: "default as full ref", branch: " refs/heads/main ", want: "main"},
+		{name: "leading dash", branch: "-f", wantErr: true},
+		{name: "control character", branch: "release\x1b[0m", wantErr: true},
+		{name: "double dot", branch: "release/../main", wantErr: true},
+		{name: "space", branch: "my branch", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.resolveBaseBranch(context.Background(), project, tt.branch, "")
+			if tt.wantErr {
+				if !domain.IsInvalidInput(err) {
+					t.Errorf("resolveBaseBranch(%q) error = %v, want validation error", tt.branch, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveBaseBranch(%q) error = %v", tt.branch, err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveBaseBranch(%q) = %q, want %q", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResyncTask_RejectsTaskStillPlanning(t *testing.T) {
+	// syncDB serves a PLANNING task owned by the nil user
+	fake := &syncDB{taskID: uuid.New(), projectID: uuid.New(), epicID: "bd-epic"}
+	repo := repository.New(fake)
+	projectService := NewProjectService(repo, nil, nil, nil, config.NewDataPaths(t.TempDir(), ""))
+	taskService := NewTaskService(repo, projectService, nil, nil, nil)
+
+	_, err := taskService.ResyncTask(context.Background(), fake.taskID, uuid.Nil)
+	if !domain.IsUnprocessable(err) {
+		t.Fatalf("ResyncTask() error = %v, want unprocessable while the Planner runs", err)
+	}
+}
+
+func TestReadRepoAttachments(t *testing.T) {
+	clone := t.TempDir()
+	outside := t.TempDir()
+	mustWrite := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite(filepath.Join(clone, "docs", "design.md"), "# Design\n")
+	mustWrite(filepath.Join(clone, "big.log"), strings.Repeat("x", 101))
+	mustWrite(filepath.Join(outside, "secret.txt"), "secret")
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(clone, "escape.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	attachments, err := readRepoAttachments(clone, []string{"docs/design.md"}, 100)
+	if err != nil {
+		t.Fatalf("readRepoAttachments() error = %v", err)
+	}
+	if len(attachments) != 1 || attachments[0].Name != "docs/design.md" || string(attachments[0].Content) != "# Design\n" {
+		t.Errorf("readRepoAttachments() = %+v, want docs/design.md", attachments)
+	}
+
+	for _, path := range []string{"../secret.txt", "/etc/passwd", "escape.txt", "missing.md", "docs", "big.log"} {
+		if _, err := readRepoAttachments(clone, []string{path}, 100); !domain.IsInvalidInput(err) {
+			t.Errorf("readRepoAttachments(%q) error = %v, want a validation error", path, err)
+		}
+	}
+}
+
+// seedTask stores a project owned by userID with one task in taskStatus and a
+// subtask in each of subtaskStatuses, in order.
+func seedTask(t *testing.T, store *repotest.Store, userID uuid.UUID, taskStatus domain.TaskStatus, subtaskStatuses ...domain.SubtaskStatus) (db.Task, []db.Subtask) {
+	t.Helper()
+	ctx := context.Background()
+	project, err := store.CreateProject(ctx, db.CreateProjectParams{UserID: userID, GithubOwner: "owner", GithubRepo: "repo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := store.CreateTask(ctx, db.CreateTaskParams{ProjectID: project.ID, Title: "task", Status: string(taskStatus)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subtasks []db.Subtask
+	for i, status := range subtaskStatuses {
+		st, err := store.CreateSubtask(ctx, db.CreateSubtaskParams{
+			TaskID:   task.ID,
+			Title:    fmt.Sprintf("subtask %d", i+1),
+			Status:   string(status),
+			Position: int32(i + 1),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		subtasks = append(subtasks, st)
+	}
+	return task, subtasks
+}
+
+func TestTaskService_GetTask_Ownership(t *testing.T) {
+	store := repotest.New()
+	s := &TaskService{repo: store, projectService: &ProjectService{repo: store}}
+	owner := uuid.New()
+	task, _ := seedTask(t, store, owner, domain.TaskStatusActive)
+	ctx := context.Background()
+
+	got, err := s.GetTask(ctx, task.ID, owner)
+	if err != nil {
+		t.Fatalf("GetTask() as owner error = %v", err)
+	}
+	if got.ID != task.ID {
+		t.Errorf("GetTask() ID = %s, want %s", got.ID, task.ID)
+	}
+	if _, err := s.GetTask(ctx, task.ID, uuid.New()); !domain.IsForbidden(err) {
+		t.Errorf("GetTask() as another user error = %v, want forbidden", err)
+	}
+	if _, err := s.GetTask(ctx, uuid.New(), owner); !domain.IsNotFound(err) {
+		t.Errorf("GetTask() for a missing task error = %v, want not found", err)
+	}
+}
+
+func TestTaskService_CheckTaskCompletion(t *testing.T) {
+	tests := []struct {
+		name       string
+		taskStatus domain.TaskStatus
+		subtasks   []domain.SubtaskStatus
+		want       bool
+	}{
+		{"all merged", domain.TaskStatusActive, []domain.SubtaskStatus{domain.SubtaskStatusMerged, domain.SubtaskStatusMerged}, true},
+		{"merged and cancelled", domain.TaskStatusActive, []domain.SubtaskStatus{domain.SubtaskStatusMerged, domain.SubtaskStatusCancelled}, true},
+		{"all cancelled", domain.TaskStatusActive, []domain.SubtaskStatus{domain.SubtaskStatusCancelled}, false},
+		{"one unresolved", domain.TaskStatusActive, []domain.SubtaskStatus{domain.SubtaskStatusMerged, domain.SubtaskStatusCompleted}, false},
+		{"no subtasks", domain.TaskStatusActive, nil, false},
+		{"task not active", domain.TaskStatusPaused, []domain.SubtaskStatus{domain.SubtaskStatusMerged}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repotest.New()
+			s := &TaskService{repo: store}
+			task, _ := seedTask(t, store, uuid.New(), tt.taskStatus, tt.subtasks...)
+			ctx := context.Background()
+
+			got, err := s.CheckTaskCompletion(ctx, task.ID)
+			if err != nil {
+				t.Fatalf("CheckTaskCompletion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CheckTaskCompletion() = %v, want %v", got, tt.want)
+			}
+
+			wantStatus := tt.taskStatus
+			if tt.want {
+				wantStatus = domain.TaskStatusDone
+			}
+			stored, _ := store.GetTaskByID(ctx, task.ID)
+			if stored.Status != string(wantStatus) {
+				t.Errorf("task status = %s, want %s", stored.Status, wantStatus)
+			}
+		})
+	}
+}
+
+func TestTaskService_CheckTaskCompletion_PublishesSummary(t *testing.T) {
+	store := repotest.New()
+	hub := &mockEventHub{}
+	s := &TaskService{repo: store, eventHub: hub}
+	ctx := context.Background()
+	task, subtasks := seedTask(t, store, uuid.New(), domain.TaskStatusActive,
+		domain.SubtaskStatusMerged, domain.SubtaskStatusMerged, domain.SubtaskStatusCancelled)
+	for i, tokens := range []int32{1000, 2000, 500} {
+		if _, err := store.UpdateSubtaskTokenUsage(ctx, db.UpdateSubtaskTokenUsageParams{ID: subtasks[i].ID, TokenUsage: tokens}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plannerTokens := int32(300)
+	store.AddAgentRun(db.AgentRun{TaskID: pgtype.UUID{Bytes: task.ID, Valid: true}, TokenUsage: &plannerTokens})
+
+	done, err := s.CheckTaskCompletion(ctx, task.ID)
+	if err != nil || !done {
+		t.Fatalf("CheckTaskCompletion() = %v, %v; want done", done, err)
+	}
+
+	want := TaskCompletionSummary{SubtaskCount: 3, MergedCount: 2, TokenUsage: 3800}
+	if len(hub.completions) != 1 || hub.completions[0] != want {
+		t.Errorf("task:completed summaries = %+v, want [%+v]", hub.completions, want)
+	}
+
+	// A task already DONE is not completed again
+	if _, err := s.CheckTaskCompletion(ctx, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(hub.completions) != 1 {
+		t.Errorf("task:completed published %d times, want once", len(hub.completions))
+	}
+}
+
+func TestTaskService_CompleteEmptyPlan(t *testing.T) {
+	store := repotest.New()
+	hub := &mockEventHub{}
+	s := &TaskService{repo: store, eventHub: hub}
+	ctx := context.Background()
+
+	// An ACTIVE task is only done once its subtasks are
+	active, _ := seedTask(t, store, uuid.New(), domain.TaskStatusActive)
+	if err := s.CompleteEmptyPlan(ctx, active.ID); !domain.IsInvalidTransition(err) {
+		t.Errorf("CompleteEmptyPlan() for an ACTIVE task error = %v, want invalid transition", err)
+	}
+
+	task, _ := seedTask(t, store, uuid.New(), domain.TaskStatusPlanning)
+	if err := s.CompleteEmptyPlan(ctx, task.ID); err != nil {
+		t.Fatalf("CompleteEmptyPlan() error = %v", err)
+	}
+	got, err := store.GetTaskByID(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != string(domain.TaskStatusDone) {
+		t.Errorf("status = %s, want DONE", got.Status)
+	}
+	if want := (TaskCompletionSummary{}); len(hub.completions) != 1 || hub.completions[0] != want {
+		t.Errorf("task:completed summaries = %+v, want one empty summary", hub.completions)
+	}
+}
+
+func TestTaskService_RetryPlanning_CloneMissing(t *testing.T) {
+	store := repotest.New()
+	s := &TaskService{repo: store, projectService: &ProjectService{repo: store}}
+	userID := uuid.New()
+	// seedTask's project has no clone on disk
+	task, _ := seedTask(t, store, userID, domain.TaskStatusPlanningFailed)
+
+	if _, err := s.RetryPlanning(context.Background(), task.ID, userID); !domain.IsCloneMissing(err) {
+		t.Fatalf("RetryPlanning() error = %v, want clone missing", err)
+	}
+	got, err := store.GetTaskByID(context.Background(), task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != string(domain.TaskStatusPlanningFailed) {
+		t.Errorf("task status = %s, want it left PLANNING_FAILED", got.Status)
+	}
+}
+
+// quotaPlannerSpawner is an AgentSpawner that refuses for the concurrent agent
+// quota, as AgentManager does when a concurrent request took the last slot.
+type quotaPlannerSpawner struct{}
+
+func (quotaPlannerSpawner) SpawnPlanner(ctx context.Context, task *domain.Task, project *domain.Project) error {
+	return domain.NewQuotaExceededError(QuotaConcurrentAgents, 1)
+}
+
+func (quotaPlannerSpawner) KillAgentsForTask(ctx context.Context, taskID uuid.UUID) error {
+	return nil
+}
+
+func TestTaskService_RetryPlanning_QuotaExceeded(t *testing.T) {
+	store := repotest.New()
+	quotas := NewQuotaService(nil, UserQuotas{MaxConcurrentAgents: 1})
+	quotas.SetAgentCounter(agentCount(1))
+	s := &TaskService{repo: store, projectService: &ProjectService{repo: store}, quotas: quotas}
+	userID := uuid.New()
+	task, _ := seedTask(t, store, userID, domain.TaskStatusPlanningFailed)
+
+	if _, err := s.RetryPlanning(context.Background(), task.ID, userID); !domain.IsQuotaExceeded(err) {
+		t.Fatalf("RetryPlanning() error = %v, want quota exceeded", err)
+	}
+	got, err := store.GetTaskByID(context.Background(), task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != string(domain.TaskStatusPlanningFailed) {
+		t.Errorf("task status = %s, want it left PLANNING_FAILED", got.Status)
+	}
+}
+
+func TestTaskService_SpawnPlanner_QuotaExceeded(t *testing.T) {
+	store := repotest.New()
+	hub := &mockEventHub{}
+	s := &TaskService{repo: store, projectService: &ProjectService{repo: store}, agentSpawner: quotaPlannerSpawner{}, eventHub: hub}
+	ctx := context.Background()
+	userID := uuid.New()
+	project := &domain.Project{ID: uuid.New(), UserID: userID}
+
+	// A retried planning returns to the status it was retried from
+	retried, _ := seedTask(t, store, userID, domain.TaskStatusPlanning)
+	s.spawnPlanner(dbTaskToDomain(retried), project, domain.TaskStatusPlanningFailed)
+	got, err := store.GetTaskByID(ctx, retried.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != string(domain.TaskStatusPlanningFailed) {
+		t.Errorf("retried task status = %s, want PLANNING_FAILED", got.Status)
+	}
+
+	// A task the request created is removed again, not failed
+	created, _ := seedTask(t, store, userID, domain.TaskStatusPlanning)
+	s.spawnPlanner(dbTaskToDomain(created), project, "")
+	if _, err := store.GetTaskByID(ctx, created.ID); err == nil {
+		t.Error("created task should be deleted")
+	}
+	if len(hub.failures) != 2 {
+		t.Errorf("expected an agent:failed event per refusal, got %d", len(hub.failures))
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// TaskTree is a task with everything needed to render its board: its
+// subtasks, the dependency edges between them, and the tokens it has used.
+type TaskTree struct {
+	Task         *domain.Task
+	Subtasks     []*domain.Subtask
+	Dependencies []TaskTreeDependency
+
+	// PlannerTokenUsage is the tokens used by the task's Planner runs, and
+	// TokenUsage that plus every subtask's Worker tokens.
+	PlannerTokenUsage int
+	TokenUsage        int
+
+	// Archive is set once the task's runs and logs have been archived; its
+	// Planner tokens are counted in PlannerTokenUsage.
+	Archive *domain.TaskArchive
+}
+
+// TaskTreeDependency is an edge of a task tree: SubtaskID depends on DependsOnID.
+type TaskTreeDependency struct {
+	SubtaskID   uuid.UUID
+	DependsOnID uuid.UUID
+}
+
+// GetTaskTree returns a task with its subtasks, their dependencies and the
+// task's token usage. Ownership is checked once for the task, and each part
+// is loaded with a single query rather than per subtask.
+func (s *TaskService) GetTaskTree(ctx context.Context, taskID, userID uuid.UUID) (*TaskTree, error) {
+	task, err := s.GetTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	subtasks, err := s.repo.ListSubtasksByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subtasks: %w", err)
+	}
+	deps, err := s.repo.ListDependenciesForTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependencies: %w", err)
+	}
+	plannerTokens, err := s.repo.SumPlannerTokenUsageForTask(ctx, pgtype.UUID{Bytes: taskID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum planner token usage: %w", err)
+	}
+	var archive *domain.TaskArchive
+	if a, err := s.repo.GetTaskArchive(ctx, taskID); err == nil {
+		archive = dbTaskArchiveToDomain(a)
+		plannerTokens += a.PlannerTokenUsage
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get task archive: %w", err)
+	}
+
+	tree := &TaskTree{
+		Task:              task,
+		Subtasks:          make([]*domain.Subtask, len(subtasks)),
+		Dependencies:      make([]TaskTreeDependency, len(deps)),
+		PlannerTokenUsage: int(plannerTokens),
+		TokenUsage:        int(plannerTokens),
+		Archive:           archive,
+	}
+	for i, st := range subtasks {
+		tree.Subtasks[i] = dbSubtaskToDomain(st)
+		tree.TokenUsage += tree.Subtasks[i].TokenUsage
+	}
+	for i, dep := range deps {
+		tree.Dependencies[i] = TaskTreeDependency{SubtaskID: dep.SubtaskID, DependsOnID: dep.DependsOnID}
+	}
+	return tree, nil
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// Webhook delivery headers.
+const (
+	WebhookEventHeader     = "X-Intern-Village-Event"
+	WebhookDeliveryHeader  = "X-Intern-Village-Delivery"
+	WebhookSignatureHeader = "X-Intern-Village-Signature" // "sha256=" + hex HMAC of the body
+)
+
+const (
+	webhookQueueSize   = 1000
+	webhookWorkers     = 4
+	webhookMaxBackoff  = time.Minute
+	webhookListTimeout = 10 * time.Second
+)
+
+// WebhookPayload is the JSON body POSTed to webhooks.
+type WebhookPayload struct {
+	ID        uuid.UUID   `json:"id"` // delivery ID, stable across retries
+	Event     string      `json:"event"`
+	ProjectID uuid.UUID   `json:"project_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookSource provides the webhooks of a project, with decrypted secrets.
+// It is implemented by WebhookService.
+type WebhookSource interface {
+	ListWebhooksForDelivery(ctx context.Context, projectID uuid.UUID) ([]*domain.Webhook, error)
+}
+
+// WebhookDispatcherConfig holds the delivery settings.
+type WebhookDispatcherConfig struct {
+	MaxAttempts int           // total attempts per webhook, including the first
+	Timeout     time.Duration // per-request timeout
+	BaseBackoff time.Duration // delay before the first retry, doubled per attempt
+}
+
+// webhookJob is a queued event awaiting delivery.
+type webhookJob struct {
+	projectID uuid.UUID
+	event     Event
+	queuedAt  time.Time
+}
+
+// WebhookDispatcher is an EventSink that POSTs events to project webhooks.
+// Events are queued without blocking the publisher, so a slow or failing
+// endpoint never delays SSE delivery; if the queue is full, events are dropped.
+type WebhookDispatcher struct {
+	source      WebhookSource
+	client      *http.Client
+	maxAttempts int
+	baseBackoff time.Duration
+	queue       chan webhookJob
+	stopCh      chan struct{}
+	wg          sync.WaitGroup
+	running     bool
+	mu          sync.Mutex
+}
+
+// NewWebhookDispatcher creates a new WebhookDispatcher.
+func NewWebhookDispatcher(source WebhookSource, cfg WebhookDispatcherConfig) *WebhookDispatcher {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+
+	return &WebhookDispatcher{
+		source:      source,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		maxAttempts: cfg.MaxAttempts,
+		baseBackoff: cfg.BaseBackoff,
+		queue:       make(chan webhookJob, webhookQueueSize),
+		stopCh:      make(chan struct{}),
+	}
+}
+
+// Deliver queues an event for delivery. It never blocks.
+func (d *WebhookDispatcher) Deliver(projectID uuid.UUID, event Event) {
+	if !isWebhookEventType(event.Type) {
+		return
+	}
+
+	select {
+	case d.queue <- webhookJob{projectID: projectID, event: event, queuedAt: time.Now()}:
+	default:
+		log.Warn().
+			Str("project_id", projectID.String()).
+			Str("event_type", event.Type).
+			Msg("webhook queue full, dropping event")
+	}
+}
+
+// Start starts the delivery workers.
+func (d *WebhookDispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.running {
+		return
+	}
+
+	d.running = true
+	for i := 0; i < webhookWorkers; i++ {
+		d.wg.Add(1)
+		go d.run()
+	}
+
+	log.Info().
+		Int("workers", webhookWorkers).
+		Int("max_attempts", d.maxAttempts).
+		Msg("webhook dispatcher started")
+}
+
+// Stop stops the delivery workers. Queued events not yet delivered are dropped.
+func (d *WebhookDispatcher) Stop() {
+	d.mu.Lock()
+	if !d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = false
+	d.mu.Unlock()
+
+	close(d.stopCh)
+	d.wg.Wait()
+
+	log.Info().Msg("webhook dispatcher stopped")
+}
+
+// run is the main loop of a delivery worker.
+func (d *WebhookDispatcher) run() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case job := <-d.queue:
+			d.process(job)
+		}
+	}
+}
+
+// process delivers one event to every matching webhook of its project.
+func (d *WebhookDispatcher) process(job webhookJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookListTimeout)
+	webhooks, err := d.source.ListWebhooksForDelivery(ctx, job.projectID)
+	cancel()
+	if err != nil {
+		log.Error().Err(err).
+			Str("project_id", job.projectID.String()).
+			Msg("failed to list webhooks")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhookWants(webhook, job.event.Type) {
+			continue
+		}
+
+		payload := WebhookPayload{
+			ID:        uuid.New(),
+			Event:     job.event.Type,
+			ProjectID: job.projectID,
+			Timestamp: job.queuedAt,
+			Data:      job.event.Data,
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Error().Err(err).
+				Str("event_type", job.event.Type).
+				Msg("failed to marshal webhook payload")
+			return
+		}
+
+		d.deliverWithRetry(webhook, payload, body)
+	}
+}
+
+// deliverWithRetry POSTs body to the webhook, retrying with exponential
+// backoff until it succeeds, attempts run out, or the dispatcher stops.
+func (d *WebhookDispatcher) deliverWithRetry(webhook *domain.Webhook, payload WebhookPayload, body []byte) {
+	backoff := d.baseBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := d.post(webhook, payload, body)
+		if err == nil {
+			return
+		}
+
+		if attempt >= d.maxAttempts {
+			log.Error().Err(err).
+				Str("webhook_id", webhook.ID.String()).
+				Str("delivery_id", payload.ID.String()).
+				Str("event_type", payload.Event).
+				Int("attempts", attempt).
+				Msg("webhook delivery failed, giving up")
+			return
+		}
+
+		log.Warn().Err(err).
+			Str("webhook_id", webhook.ID.String()).
+			Str("delivery_id", payload.ID.String()).
+			Int("attempt", attempt).
+			Dur("retry_in", backoff).
+			Msg("webhook delivery failed, retrying")
+
+		select {
+		case <-d.stopCh:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+}
+
+// post makes a single signed delivery attempt. Any non-2xx status is an error.
+func (d *WebhookDispatcher) post(webhook *domain.Webhook, payload WebhookPayload, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Intern-Village-Webhook")
+	req.Header.Set(WebhookEventHeader, payload.Event)
+	req.Header.Set(WebhookDeliveryHeader, payload.ID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookBody(webhook.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhookBody returns the signature header value for body: "sha256="
+// followed by the hex HMAC-SHA256 of body keyed with secret.
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookWants reports whether the webhook subscribes to eventType.
+func webhookWants(webhook *domain.Webhook, eventType string) bool {
+	if len(webhook.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range webhook.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
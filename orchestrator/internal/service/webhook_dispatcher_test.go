@@ -0,0 +1,233 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/intern-village/orchestrator/internal/domain"
+)
+
+// staticWebhookSource returns the same webhooks for every project.
+type staticWebhookSource struct {
+	webhooks []*domain.Webhook
+}
+
+func (s *staticWebhookSource) ListWebhooksForDelivery(ctx context.Context, projectID uuid.UUID) ([]*domain.Webhook, error) {
+	return s.webhooks, nil
+}
+
+func newTestDispatcher(source WebhookSource, maxAttempts int) *WebhookDispatcher {
+	return NewWebhookDispatcher(source, WebhookDispatcherConfig{
+		MaxAttempts: maxAttempts,
+		Timeout:     5 * time.Second,
+		BaseBackoff: time.Millisecond,
+	})
+}
+
+func TestWebhookDispatcher_SignedDelivery(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: body}
+	}))
+	defer server.Close()
+
+	webhook := &domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "s3cret"}
+	d := newTestDispatcher(&staticWebhookSource{webhooks: []*domain.Webhook{webhook}}, 1)
+	d.Start()
+	defer d.Stop()
+
+	projectID := uuid.New()
+	taskID := uuid.New()
+	d.Deliver(projectID, Event{
+		Type: EventTypeTaskStatusChanged,
+		Data: TaskStatusChangedData{TaskID: taskID, OldStatus: "PLANNING", NewStatus: "ACTIVE"},
+	})
+
+	var r received
+	select {
+	case r = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	if sig := r.header.Get(WebhookSignatureHeader); sig != SignWebhookBody("s3cret", r.body) {
+		t.Errorf("signature = %q, want HMAC of body", sig)
+	}
+	if ev := r.header.Get(WebhookEventHeader); ev != EventTypeTaskStatusChanged {
+		t.Errorf("event header = %q", ev)
+	}
+
+	var payload struct {
+		ID        uuid.UUID             `json:"id"`
+		Event     string                `json:"event"`
+		ProjectID uuid.UUID             `json:"project_id"`
+		Data      TaskStatusChangedData `json:"data"`
+	}
+	if err := json.Unmarshal(r.body, &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload.ProjectID != projectID || payload.Data.TaskID != taskID || payload.Data.NewStatus != "ACTIVE" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	if r.header.Get(WebhookDeliveryHeader) != payload.ID.String() {
+		t.Errorf("delivery header %q does not match payload id %s", r.header.Get(WebhookDeliveryHeader), payload.ID)
+	}
+}
+
+func TestWebhookDispatcher_RetriesNon2xx(t *testing.T) {
+	var calls atomic.Int32
+	var mu sync.Mutex
+	deliveryIDs := map[string]bool{}
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		deliveryIDs[r.Header.Get(WebhookDeliveryHeader)] = true
+		mu.Unlock()
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		close(done)
+	}))
+	defer server.Close()
+
+	webhook := &domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "x"}
+	d := newTestDispatcher(&staticWebhookSource{webhooks: []*domain.Webhook{webhook}}, 5)
+	d.Start()
+	defer d.Stop()
+
+	d.Deliver(uuid.New(), Event{Type: EventTypeAgentFailed, Data: AgentFailedData{Error: "boom"}})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("delivery did not succeed after retries (calls=%d)", calls.Load())
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deliveryIDs) != 1 {
+		t.Errorf("retries should reuse the delivery ID, got %d distinct", len(deliveryIDs))
+	}
+}
+
+func TestWebhookDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := &domain.Webhook{ID: uuid.New(), URL: server.URL, Secret: "x"}
+	d := newTestDispatcher(&staticWebhookSource{webhooks: []*domain.Webhook{webhook}}, 2)
+
+	body := []byte(`{}`)
+	d.deliverWithRetry(webhook, WebhookPayload{ID: uuid.New(), Event: EventTypeAgentStarted}, body)
+
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestWebhookDispatcher_EventTypeFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventTypes []string
+		eventType  string
+		want       bool
+	}{
+		{"empty allowlist gets everything", nil, EventTypeAgentStarted, true},
+		{"listed type", []string{EventTypeAgentFailed}, EventTypeAgentFailed, true},
+		{"unlisted type", []string{EventTypeAgentFailed}, EventTypeAgentStarted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookWants(&domain.Webhook{EventTypes: tt.eventTypes}, tt.eventType); got != tt.want {
+				t.Errorf("webhookWants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcher_DeliverNeverBlocks(t *testing.T) {
+	// Not started, so nothing drains the queue
+	d := newTestDispatcher(&staticWebhookSource{}, 1)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < webhookQueueSize+10; i++ {
+			d.Deliver(uuid.New(), Event{Type: EventTypeAgentStarted})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Deliver blocked on a full queue")
+	}
+	if len(d.queue) != webhookQueueSize {
+		t.Errorf("queue length = %d, want %d", len(d.queue), webhookQueueSize)
+	}
+}
+
+func TestWebhookDispatcher_IgnoresNonWebhookEvents(t *testing.T) {
+	d := newTestDispatcher(&staticWebhookSource{}, 1)
+
+	d.Deliver(uuid.New(), Event{Type: EventTypeAgentLog})
+	d.Deliver(uuid.New(), Event{Type: EventTypeHeartbeat})
+
+	if len(d.queue) != 0 {
+		t.Errorf("queue length = %d, want 0", len(d.queue))
+	}
+}
+
+func TestValidateWebhookInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		wantErr    bool
+	}{
+		{"https url", "https://hooks.example.com/iv", nil, false},
+		{"http url with types", "http://ci.internal:8080/hook", []string{EventTypeAgentCompleted}, false},
+		{"missing url", "", nil, true},
+		{"relative url", "/hook", nil, true},
+		{"unsupported scheme", "ftp://example.com/hook", nil, true},
+		{"log events not allowed", "https://example.com", []string{EventTypeAgentLog}, true},
+		{"unknown event", "https://example.com", []string{"task:exploded"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookURL(tt.url)
+			if err == nil {
+				err = validateWebhookEventTypes(tt.eventTypes)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
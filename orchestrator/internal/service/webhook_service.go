@@ -0,0 +1,225 @@
+// Copyright (c) 2026 Intern Village. All rights reserved.
+// SPDX-License-Identifier: Proprietary
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/intern-village/orchestrator/generated/db"
+	"github.com/intern-village/orchestrator/internal/domain"
+	"github.com/intern-village/orchestrator/internal/repository"
+)
+
+// WebhookEventTypes are the event types that can be delivered to webhooks.
+// Agent logs and SSE connection events are deliberately excluded.
+var WebhookEventTypes = []string{
+	EventTypeAgentStarted,
+	EventTypeAgentCompleted,
+	EventTypeAgentFailed,
+	EventTypeAgentStalled,
+	EventTypeTaskStatusChanged,
+	EventTypeTaskCompleted,
+	EventTypeSubtaskStatusChanged,
+	EventTypeSubtaskCreated,
+	EventTypeSubtaskUnblocked,
+	EventTypeOperationFailed,
+	EventTypeReauthRequired,
+}
+
+// webhookSecretBytes is the length of generated signing secrets.
+const webhookSecretBytes = 32
+
+// WebhookService manages per-project webhook configurations.
+type WebhookService struct {
+	repo           *repository.Repository
+	crypto         *repository.Crypto
+	projectService *ProjectService
+}
+
+// NewWebhookService creates a new WebhookService.
+func NewWebhookService(repo *repository.Repository, crypto *repository.Crypto, projectService *ProjectService) *WebhookService {
+	return &WebhookService{
+		repo:           repo,
+		crypto:         crypto,
+		projectService: projectService,
+	}
+}
+
+// CreateWebhookInput contains the input for creating a webhook.
+type CreateWebhookInput struct {
+	ProjectID  uuid.UUID
+	UserID     uuid.UUID
+	URL        string
+	EventTypes []string // empty means all webhook event types
+	Secret     string   // generated if empty
+}
+
+// CreateWebhook registers a webhook for a project. The returned webhook carries
+// the plaintext secret; it is not returned again by ListWebhooks.
+func (s *WebhookService) CreateWebhook(ctx context.Context, input CreateWebhookInput) (*domain.Webhook, error) {
+	if _, err := s.projectService.GetProject(ctx, input.ProjectID, input.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := validateWebhookURL(input.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEventTypes(input.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		buf := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	encrypted, err := s.crypto.EncryptToken(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	eventTypes := input.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	webhook, err := s.repo.CreateWebhook(ctx, db.CreateWebhookParams{
+		ProjectID:  input.ProjectID,
+		Url:        input.URL,
+		Secret:     encrypted,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	result := dbWebhookToDomain(webhook)
+	result.Secret = secret
+	return result, nil
+}
+
+// ListWebhooks lists a project's webhooks. Secrets are not included.
+func (s *WebhookService) ListWebhooks(ctx context.Context, projectID, userID uuid.UUID) ([]*domain.Webhook, error) {
+	if _, err := s.projectService.GetProject(ctx, projectID, userID); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.repo.ListWebhooksByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	result := make([]*domain.Webhook, len(webhooks))
+	for i, w := range webhooks {
+		result[i] = dbWebhookToDomain(w)
+	}
+	return result, nil
+}
+
+// DeleteWebhook removes a webhook from a project.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, projectID, webhookID, userID uuid.UUID) error {
+	if _, err := s.projectService.GetProject(ctx, projectID, userID); err != nil {
+		return err
+	}
+
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.NewNotFoundError("webhook", webhookID.String())
+		}
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if webhook.ProjectID != projectID {
+		return domain.NewNotFoundError("webhook", webhookID.String())
+	}
+
+	if err := s.repo.DeleteWebhook(ctx, webhookID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListWebhooksForDelivery returns a project's webhooks with decrypted secrets.
+// Used internally by the WebhookDispatcher; no ownership check is performed.
+func (s *WebhookService) ListWebhooksForDelivery(ctx context.Context, projectID uuid.UUID) ([]*domain.Webhook, error) {
+	webhooks, err := s.repo.ListWebhooksByProject(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	result := make([]*domain.Webhook, 0, len(webhooks))
+	for _, w := range webhooks {
+		secret, err := s.crypto.DecryptToken(w.Secret)
+		if err != nil {
+			log.Error().Err(err).
+				Str("webhook_id", w.ID.String()).
+				Msg("failed to decrypt webhook secret, skipping")
+			continue
+		}
+		webhook := dbWebhookToDomain(w)
+		webhook.Secret = secret
+		result = append(result, webhook)
+	}
+	return result, nil
+}
+
+// validateWebhookURL checks that rawURL is an absolute http(s) URL.
+func validateWebhookURL(rawURL string) error {
+	if rawURL == "" {
+		return domain.NewValidationError("url", "is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return domain.NewValidationError("url", "must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// validateWebhookEventTypes checks that every entry is a deliverable event type.
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, t := range eventTypes {
+		if !isWebhookEventType(t) {
+			return domain.NewValidationError("event_types",
+				fmt.Sprintf("unsupported event type %q (allowed: %s)", t, strings.Join(WebhookEventTypes, ", ")))
+		}
+	}
+	return nil
+}
+
+// isWebhookEventType reports whether t can be delivered to webhooks.
+func isWebhookEventType(t string) bool {
+	for _, allowed := range WebhookEventTypes {
+		if t == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// dbWebhookToDomain converts a database Webhook to a domain Webhook.
+// The secret is left empty; callers decrypt it when needed.
+func dbWebhookToDomain(w db.Webhook) *domain.Webhook {
+	return &domain.Webhook{
+		ID:         w.ID,
+		ProjectID:  w.ProjectID,
+		URL:        w.Url,
+		EventTypes: w.EventTypes,
+		CreatedAt:  w.CreatedAt,
+		UpdatedAt:  w.UpdatedAt,
+	}
+}
@@ -0,0 +1,24 @@
+-- Migration: 003_idempotency_keys
+-- Description: Store Idempotency-Key results for retried create requests
+-- Reference: specs/orchestrator.md §5 (Idempotent Requests)
+
+-- +goose Up
+
+-- One row per (user, key, route). status_code is NULL while the original
+-- request is still in flight.
+CREATE TABLE idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    route TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER,
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key, route)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
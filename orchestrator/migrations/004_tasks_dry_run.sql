@@ -0,0 +1,11 @@
+-- Migration: 004_tasks_dry_run
+-- Description: Add dry_run flag so a task's plan can be reviewed before subtasks are created
+-- Reference: specs/orchestrator.md §7.1 (Plan Review)
+
+-- +goose Up
+
+-- Dry-run tasks stop in AWAITING_APPROVAL after planning instead of syncing subtasks
+ALTER TABLE tasks ADD COLUMN dry_run BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN IF EXISTS dry_run;
@@ -0,0 +1,22 @@
+-- Migration: 005_webhooks
+-- Description: Per-project outbound webhooks for agent and status events
+-- Reference: specs/orchestrator.md §5 (Webhooks)
+
+-- +goose Up
+
+-- secret is encrypted with ENCRYPTION_KEY and used to HMAC-sign deliveries.
+-- An empty event_types array subscribes to all webhook event types.
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhooks_project_id ON webhooks(project_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhooks;
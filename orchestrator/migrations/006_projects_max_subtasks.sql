@@ -0,0 +1,11 @@
+-- Migration: 006_projects_max_subtasks
+-- Description: Per-project override of the maximum number of subtasks a plan may create
+-- Reference: specs/orchestrator.md §7.5 (Beads Sync Strategy)
+
+-- +goose Up
+
+-- NULL uses the MAX_SUBTASKS_PER_TASK default; 0 disables the limit for the project
+ALTER TABLE projects ADD COLUMN max_subtasks_per_task INTEGER CHECK (max_subtasks_per_task >= 0);
+
+-- +goose Down
+ALTER TABLE projects DROP COLUMN IF EXISTS max_subtasks_per_task;
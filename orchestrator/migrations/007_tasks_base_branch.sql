@@ -0,0 +1,13 @@
+-- Migration: 007_tasks_base_branch
+-- Description: Per-task base branch so a task can target a release or feature line
+-- Reference: specs/orchestrator.md §4.3 (Task)
+
+-- +goose Up
+
+-- Existing tasks were all based on their project's default branch
+ALTER TABLE tasks ADD COLUMN base_branch TEXT;
+UPDATE tasks t SET base_branch = p.default_branch FROM projects p WHERE t.project_id = p.id;
+ALTER TABLE tasks ALTER COLUMN base_branch SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN IF EXISTS base_branch;
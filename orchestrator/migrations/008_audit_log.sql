@@ -0,0 +1,24 @@
+-- Migration: 008_audit_log
+-- Description: Record who triggered each user-initiated state transition
+-- Reference: specs/orchestrator.md §5 (Audit Log)
+
+-- +goose Up
+
+-- One row per user action. old_status/new_status are NULL when the action
+-- creates or deletes the entity rather than changing its status.
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id UUID NOT NULL,
+    old_status TEXT,
+    new_status TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_project_id_created_at ON audit_log(project_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,11 @@
+-- Migration: 009_projects_pr_title_template
+-- Description: Per-project template for Worker PR titles
+-- Reference: specs/orchestrator.md §9.4 (PR Creation)
+
+-- +goose Up
+
+-- NULL uses the default "[IV-{subtask_id}] {title}"
+ALTER TABLE projects ADD COLUMN pr_title_template TEXT;
+
+-- +goose Down
+ALTER TABLE projects DROP COLUMN IF EXISTS pr_title_template;
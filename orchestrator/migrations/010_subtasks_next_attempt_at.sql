@@ -0,0 +1,11 @@
+-- Migration: 010_subtasks_next_attempt_at
+-- Description: When a backing-off Worker will start its next attempt
+-- Reference: specs/orchestrator.md §7.3 (Agent Execution Loop)
+
+-- +goose Up
+
+-- Set only while a Worker backs off between attempts
+ALTER TABLE subtasks ADD COLUMN next_attempt_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE subtasks DROP COLUMN IF EXISTS next_attempt_at;
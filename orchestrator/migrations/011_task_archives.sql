@@ -0,0 +1,18 @@
+-- Migration: 011_task_archives
+-- Description: Summary rows for finished tasks whose runs and logs were archived
+-- Reference: specs/orchestrator.md §7.8 (Task Retention)
+
+-- +goose Up
+
+-- One row per archived task; the runs and logs live in archive_path
+CREATE TABLE task_archives (
+    task_id             UUID PRIMARY KEY REFERENCES tasks(id) ON DELETE CASCADE,
+    archive_path        TEXT NOT NULL,
+    run_count           INTEGER NOT NULL,
+    planner_token_usage INTEGER NOT NULL,
+    size_bytes          BIGINT NOT NULL,
+    archived_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS task_archives;
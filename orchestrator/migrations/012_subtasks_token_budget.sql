@@ -0,0 +1,11 @@
+-- Migration: 012_subtasks_token_budget
+-- Description: Optional cap on the tokens a subtask's Workers may use
+-- Reference: specs/orchestrator.md §7.3 (Agent Execution Loop)
+
+-- +goose Up
+
+-- NULL means unlimited
+ALTER TABLE subtasks ADD COLUMN token_budget INTEGER;
+
+-- +goose Down
+ALTER TABLE subtasks DROP COLUMN IF EXISTS token_budget;
@@ -0,0 +1,10 @@
+-- Migration: 013_projects_squash_before_pr
+-- Description: Per-project option to squash Worker commits before the PR is opened
+-- Reference: specs/orchestrator.md §9.4 (PR Creation)
+
+-- +goose Up
+
+ALTER TABLE projects ADD COLUMN squash_before_pr BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE projects DROP COLUMN IF EXISTS squash_before_pr;
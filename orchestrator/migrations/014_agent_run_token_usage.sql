@@ -0,0 +1,20 @@
+-- Migration: 014_agent_run_token_usage
+-- Description: Timestamped token usage samples per agent run, for usage over time
+-- Reference: specs/orchestrator.md §7.3 (Agent Execution Loop)
+
+-- +goose Up
+
+-- One row per usage report of a run; agent_runs.token_usage stays the run's total
+CREATE TABLE agent_run_token_usage (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    agent_run_id UUID NOT NULL REFERENCES agent_runs(id) ON DELETE CASCADE,
+    input_tokens INTEGER NOT NULL,
+    output_tokens INTEGER NOT NULL,
+    total_tokens INTEGER NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_agent_run_token_usage_agent_run_id ON agent_run_token_usage(agent_run_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS agent_run_token_usage;
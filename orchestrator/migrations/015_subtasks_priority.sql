@@ -0,0 +1,11 @@
+-- Migration: 015_subtasks_priority
+-- Description: Execution priority of subtasks, on the beads scale
+-- Reference: specs/orchestrator.md §4.4 (Subtask)
+
+-- +goose Up
+
+-- 0 is the most urgent and 4 the least, as in beads; 2 is beads' default
+ALTER TABLE subtasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 2;
+
+-- +goose Down
+ALTER TABLE subtasks DROP COLUMN IF EXISTS priority;
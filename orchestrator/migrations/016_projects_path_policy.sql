@@ -0,0 +1,13 @@
+-- Migration: 016_projects_path_policy
+-- Description: Per-project globs limiting which paths Workers may modify
+-- Reference: specs/orchestrator.md §9.4 (PR Creation)
+
+-- +goose Up
+
+-- Empty lists allow every path
+ALTER TABLE projects ADD COLUMN allowed_paths TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE projects ADD COLUMN denied_paths TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE projects DROP COLUMN IF EXISTS denied_paths;
+ALTER TABLE projects DROP COLUMN IF EXISTS allowed_paths;